@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/naabu/v2/pkg/report"
+)
+
+// runAnnotate attaches a triage note to an open port: naabu annotate annotations.json -host 1.2.3.4 -port 3389 -note "approved exposure"
+func runAnnotate(args []string) error {
+	var host, protocol, note string
+	var port int
+	var list bool
+	flagSet := flag.NewFlagSet("annotate", flag.ExitOnError)
+	flagSet.StringVar(&host, "host", "", "host or ip of the finding")
+	flagSet.IntVar(&port, "port", 0, "port of the finding")
+	flagSet.StringVar(&protocol, "protocol", "tcp", "protocol of the finding (tcp/udp)")
+	flagSet.StringVar(&note, "note", "", "note of the finding, empty to remove its annotation")
+	flagSet.BoolVar(&list, "list", false, "list the annotations")
+
+	// flags may follow the annotations file
+	var files []string
+	for {
+		if err := flagSet.Parse(args); err != nil {
+			return err
+		}
+		if flagSet.NArg() == 0 {
+			break
+		}
+		files = append(files, flagSet.Arg(0))
+		args = flagSet.Args()[1:]
+	}
+	if len(files) != 1 {
+		return errors.New("one annotations file expected (naabu annotate annotations.json -host 1.2.3.4 -port 3389 -note \"approved exposure\")")
+	}
+
+	annotations, err := report.LoadAnnotations(files[0])
+	if err != nil {
+		return err
+	}
+	if list {
+		for _, annotation := range annotations.List() {
+			fmt.Printf("%s  %s %d/%s  %s\n", annotation.Time.Format("2006-01-02 15:04"), annotation.Host, annotation.Port, annotation.Protocol, annotation.Note)
+		}
+		return nil
+	}
+	if host == "" {
+		return errors.New("no host given (-host 1.2.3.4)")
+	}
+	if port <= 0 || port > 65535 {
+		return errors.New("no valid port given (-port 3389)")
+	}
+	if protocol != "tcp" && protocol != "udp" {
+		return fmt.Errorf("invalid protocol %s (allowed: tcp, udp)", protocol)
+	}
+	annotations.Add(host, port, protocol, note, time.Now())
+	return annotations.Save(files[0])
+}
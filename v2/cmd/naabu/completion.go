@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/naabu/v2/pkg/runner"
+)
+
+// subcommands are the commands handled before the scan flags, offered by the shell completions
+var subcommands = []string{"annotate", "completion", "convert", "diff", "history", "init", "listen", "report", "selftest", "track", "trend", "update"}
+
+// runCompletion writes the completion script of the shell: naabu completion bash|zsh|fish
+func runCompletion(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: naabu completion bash|zsh|fish")
+	}
+	script, err := runner.Completion(args[0], subcommands)
+	if err != nil {
+		return err
+	}
+	fmt.Print(script)
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/naabu/v2/pkg/schema"
+)
+
+// runConvert upgrades json results of older naabu versions to the current schema: naabu convert -i old.json [-o results.json]
+func runConvert(args []string) error {
+	var inputs stringSlice
+	var output string
+
+	flagSet := flag.NewFlagSet("convert", flag.ExitOnError)
+	flagSet.Var(&inputs, "i", "naabu json results to convert (can be repeated)")
+	flagSet.StringVar(&output, "o", "", "file to write the converted results to (default stdout)")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	inputs = append(inputs, flagSet.Args()...)
+	if len(inputs) == 0 {
+		return errors.New("no results given (-i results.json)")
+	}
+
+	var writer io.Writer = os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		writer = f
+	}
+
+	for _, input := range inputs {
+		f, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		err = schema.Convert(f, writer)
+		f.Close()
+		if err != nil {
+			return errors.Wrapf(err, "could not convert %s", input)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/naabu/v2/pkg/report"
+)
+
+// runDiff compares the open ports of two result files: naabu diff old.json new.json [-format md] [-o diff.md]
+// [-annotations annotations.json [-suppress-annotated]]
+func runDiff(args []string) error {
+	var format, output, annotationsFile string
+	var suppressAnnotated bool
+	flagSet := flag.NewFlagSet("diff", flag.ExitOnError)
+	flagSet.StringVar(&format, "format", report.DiffText, "format of the comparison (text/md/json)")
+	flagSet.StringVar(&output, "o", "", "file to write the comparison to (default stdout)")
+	flagSet.StringVar(&annotationsFile, "annotations", "", "annotations file labeling the triaged ports (naabu annotate)")
+	flagSet.BoolVar(&suppressAnnotated, "suppress-annotated", false, "omit the annotated ports instead of labeling them")
+
+	// flags may follow the result files
+	var files []string
+	for {
+		if err := flagSet.Parse(args); err != nil {
+			return err
+		}
+		if flagSet.NArg() == 0 {
+			break
+		}
+		files = append(files, flagSet.Arg(0))
+		args = flagSet.Args()[1:]
+	}
+	if len(files) != 2 {
+		return errors.New("two json results expected (naabu diff old.json new.json)")
+	}
+	if suppressAnnotated && annotationsFile == "" {
+		return errors.New("suppress-annotated requires an annotations file (-annotations annotations.json)")
+	}
+
+	scans, err := loadScans(files)
+	if err != nil {
+		return err
+	}
+	// loadScans orders the runs chronologically, keep the given order
+	if scans[0].Source != files[0] {
+		scans[0], scans[1] = scans[1], scans[0]
+	}
+
+	diff := report.Compare(scans[0], scans[1])
+	if annotationsFile != "" {
+		annotations, err := report.LoadAnnotations(annotationsFile)
+		if err != nil {
+			return err
+		}
+		diff.Annotate(annotations, suppressAnnotated)
+	}
+
+	var writer io.Writer = os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		writer = f
+	}
+	return diff.Write(format, writer)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/naabu/v2/pkg/report"
+)
+
+// runHistory prints how the open ports of a host changed across runs: naabu history -host 1.2.3.4 results/*.json
+func runHistory(args []string) error {
+	var host string
+	flagSet := flag.NewFlagSet("history", flag.ExitOnError)
+	flagSet.StringVar(&host, "host", "", "host or ip to show the history of")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if host == "" {
+		return errors.New("no host given (-host 1.2.3.4)")
+	}
+	scans, err := loadScans(flagSet.Args())
+	if err != nil {
+		return err
+	}
+	for _, snapshot := range report.HostHistory(scans, host) {
+		fmt.Println(snapshot)
+	}
+	return nil
+}
+
+// runTrend prints how many hosts exposed a port across runs: naabu trend -port 3389 -since 30d results/*.json
+func runTrend(args []string) error {
+	var port int
+	var since string
+	flagSet := flag.NewFlagSet("trend", flag.ExitOnError)
+	flagSet.IntVar(&port, "port", 0, "port to show the trend of")
+	flagSet.StringVar(&since, "since", "30d", "only include runs more recent than this duration (30d, 12h)")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if port <= 0 || port > 65535 {
+		return errors.New("no valid port given (-port 3389)")
+	}
+	sinceTime, err := report.ParseSince(since, time.Now())
+	if err != nil {
+		return err
+	}
+	scans, err := loadScans(flagSet.Args())
+	if err != nil {
+		return err
+	}
+	for _, snapshot := range report.PortTrend(scans, port, sinceTime) {
+		fmt.Println(snapshot)
+	}
+	return nil
+}
+
+// loadScans reads the json results of each run in chronological order
+func loadScans(files []string) ([]*report.Scan, error) {
+	if len(files) == 0 {
+		return nil, errors.New("no json results given")
+	}
+	var scans []*report.Scan
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		scan, err := report.LoadScan(file, f)
+		f.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not load %s", file)
+		}
+		scans = append(scans, scan)
+	}
+	report.SortScans(scans)
+	return scans, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/runner"
+	fileutil "github.com/projectdiscovery/utils/file"
+)
+
+// runInit writes the config file answered to the wizard: naabu init [-o config.yaml] [-force]
+func runInit(args []string) error {
+	var output string
+	var force bool
+	flagSet := flag.NewFlagSet("init", flag.ExitOnError)
+	flagSet.StringVar(&output, "o", "", "config file to write (default $HOME/.config/naabu/config.yaml)")
+	flagSet.BoolVar(&force, "force", false, "overwrite an existing config file")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	if output == "" {
+		path, err := runner.DefaultConfigPath()
+		if err != nil {
+			return errors.Wrap(err, "could not get config file path")
+		}
+		output = path
+	}
+	if fileutil.FileExists(output) && !force {
+		return errors.Errorf("%s already exists, use -force to overwrite it", output)
+	}
+
+	config, err := runner.RunWizard(os.Stdin, os.Stderr)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(output), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(output, []byte(config), 0600); err != nil {
+		return err
+	}
+	gologger.Info().Msgf("Config file written to %s\n", output)
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/listen"
+	"github.com/projectdiscovery/naabu/v2/pkg/runner"
+)
+
+// runListen binds ports and reports which probes of a paired scan arrived:
+// naabu listen -p 1-1024 [-source 198.51.100.7] [-duration 10m] [-o report.txt]
+func runListen(args []string) error {
+	var sources stringSlice
+	var ports, address, output string
+	var duration time.Duration
+	var asJSON bool
+
+	flagSet := flag.NewFlagSet("listen", flag.ExitOnError)
+	flagSet.StringVar(&ports, "p", "", "ports to bind (80,443,u:53,1000-2000)")
+	flagSet.StringVar(&address, "a", "", "address to bind the ports on (default all)")
+	flagSet.Var(&sources, "source", "ip of the scanning host, probes of other sources are ignored (can be repeated)")
+	flagSet.DurationVar(&duration, "duration", 0, "time to listen for (default until interrupted)")
+	flagSet.BoolVar(&asJSON, "json", false, "write the report as json")
+	flagSet.StringVar(&output, "o", "", "file to write the report to (default stdout)")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if ports == "" {
+		return errors.New("no ports given (-p 1-1024)")
+	}
+
+	portsList, err := runner.ParsePorts(&runner.Options{Ports: ports, ScanType: runner.ConnectScan})
+	if err != nil {
+		return err
+	}
+	listener, err := listen.New(address, portsList, sources)
+	if err != nil {
+		return err
+	}
+	gologger.Info().Msgf("Listening on %d ports, start the paired scan with -scan-type c (u for udp ports)\n", listener.Bound())
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	var timeout <-chan time.Time
+	if duration > 0 {
+		timeout = time.After(duration)
+	}
+	select {
+	case <-interrupt:
+	case <-timeout:
+	}
+	signal.Stop(interrupt)
+	listener.Close()
+
+	var writer io.Writer = os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		writer = f
+	}
+	return listener.Report().Write(asJSON, writer)
+}
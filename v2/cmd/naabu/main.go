@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	_ "github.com/projectdiscovery/fdmax/autofdmax"
 	"github.com/projectdiscovery/gologger"
 	"github.com/projectdiscovery/naabu/v2/pkg/runner"
@@ -9,10 +10,79 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "report":
+			if err := runReport(os.Args[2:]); err != nil {
+				gologger.Fatal().Msgf("Could not generate report: %s\n", err)
+			}
+			return
+		case "history":
+			if err := runHistory(os.Args[2:]); err != nil {
+				gologger.Fatal().Msgf("Could not show history: %s\n", err)
+			}
+			return
+		case "trend":
+			if err := runTrend(os.Args[2:]); err != nil {
+				gologger.Fatal().Msgf("Could not show trend: %s\n", err)
+			}
+			return
+		case "track":
+			if err := runTrack(os.Args[2:]); err != nil {
+				gologger.Fatal().Msgf("Could not track exposure: %s\n", err)
+			}
+			return
+		case "diff":
+			if err := runDiff(os.Args[2:]); err != nil {
+				gologger.Fatal().Msgf("Could not compare results: %s\n", err)
+			}
+			return
+		case "annotate":
+			if err := runAnnotate(os.Args[2:]); err != nil {
+				gologger.Fatal().Msgf("Could not annotate result: %s\n", err)
+			}
+			return
+		case "update":
+			if err := runUpdate(os.Args[2:]); err != nil {
+				gologger.Fatal().Msgf("Could not update naabu: %s\n", err)
+			}
+			return
+		case "listen":
+			if err := runListen(os.Args[2:]); err != nil {
+				gologger.Fatal().Msgf("Could not listen: %s\n", err)
+			}
+			return
+		case "convert":
+			if err := runConvert(os.Args[2:]); err != nil {
+				gologger.Fatal().Msgf("Could not convert results: %s\n", err)
+			}
+			return
+		case "completion":
+			if err := runCompletion(os.Args[2:]); err != nil {
+				gologger.Fatal().Msgf("Could not generate completion: %s\n", err)
+			}
+			return
+		case "selftest":
+			if err := runSelftest(os.Args[2:]); err != nil {
+				gologger.Fatal().Msgf("Could not pass self test: %s\n", err)
+			}
+			return
+		case "init":
+			if err := runInit(os.Args[2:]); err != nil {
+				gologger.Fatal().Msgf("Could not write config file: %s\n", err)
+			}
+			return
+		}
+	}
+
 	// Parse the command line flags and read config files
 	options := runner.ParseOptions()
 
 	naabuRunner, err := runner.NewRunner(options)
+	if errors.Is(err, runner.ErrScanLocked) {
+		gologger.Info().Msgf("Skipping scan: %s\n", err)
+		return
+	}
 	if err != nil {
 		gologger.Fatal().Msgf("Could not create runner: %s\n", err)
 	}
@@ -25,7 +95,7 @@ func main() {
 			gologger.Info().Msgf("CTRL+C pressed: Exiting\n")
 			if options.ResumeCfg.ShouldSaveResume() {
 				gologger.Info().Msgf("Creating resume file: %s\n", runner.DefaultResumeFilePath())
-				err := options.ResumeCfg.SaveResumeConfig()
+				err := naabuRunner.SaveResume()
 				if err != nil {
 					gologger.Error().Msgf("Couldn't create resume file: %s\n", err)
 				}
@@ -35,10 +105,11 @@ func main() {
 		}
 	}()
 
-	err = naabuRunner.RunEnumeration()
-	if err != nil {
+	if err := naabuRunner.RunEnumeration(); err != nil {
 		gologger.Fatal().Msgf("Could not run enumeration: %s\n", err)
 	}
-	// on successful execution remove the resume file in case it exists
-	options.ResumeCfg.CleanupResumeConfig()
+	if enumerationErrors := naabuRunner.Errors(); enumerationErrors != nil {
+		// the errors were already logged as they occurred
+		gologger.Warning().Msgf("Enumeration completed with %s\n", enumerationErrors)
+	}
 }
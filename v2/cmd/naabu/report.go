@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/naabu/v2/pkg/report"
+)
+
+// runReport renders json results into a report: naabu report -i results.json [-t template.html] [-o report.html]
+func runReport(args []string) error {
+	var inputs stringSlice
+	var templateFile, output, title string
+
+	flagSet := flag.NewFlagSet("report", flag.ExitOnError)
+	flagSet.Var(&inputs, "i", "naabu json results to include in the report (can be repeated)")
+	flagSet.StringVar(&templateFile, "t", "", "go html/template to render (default built-in html report)")
+	flagSet.StringVar(&output, "o", "", "file to write the report to (default stdout)")
+	flagSet.StringVar(&title, "title", "Naabu Report", "title of the report")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	inputs = append(inputs, flagSet.Args()...)
+	if len(inputs) == 0 {
+		return errors.New("no results given (-i results.json)")
+	}
+
+	var records []*report.Record
+	for _, input := range inputs {
+		f, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		fileRecords, err := report.Load(f)
+		f.Close()
+		if err != nil {
+			return errors.Wrapf(err, "could not load %s", input)
+		}
+		records = append(records, fileRecords...)
+	}
+
+	var templateData string
+	if templateFile != "" {
+		data, err := os.ReadFile(templateFile)
+		if err != nil {
+			return err
+		}
+		templateData = string(data)
+	}
+
+	var writer io.Writer = os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		writer = f
+	}
+	return report.New(title, records).Render(templateData, writer)
+}
+
+type stringSlice []string
+
+func (s *stringSlice) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringSlice) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
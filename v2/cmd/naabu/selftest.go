@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/gologger/levels"
+	"github.com/projectdiscovery/naabu/v2/pkg/runner"
+)
+
+// runSelftest scans an always open endpoint with the syn and connect pipelines before a real
+// engagement: naabu selftest [-target scanme.sh:80] [-timeout 5s] [-allow-skip] [-v]
+func runSelftest(args []string) error {
+	var target string
+	var timeout time.Duration
+	var allowSkip, verbose bool
+	flagSet := flag.NewFlagSet("selftest", flag.ExitOnError)
+	flagSet.StringVar(&target, "target", runner.DefaultSelfTestTarget, "always open endpoint to scan (host:port)")
+	flagSet.DurationVar(&timeout, "timeout", 5*time.Second, "time to wait for the endpoint to answer")
+	flagSet.BoolVar(&allowSkip, "allow-skip", false, "don't fail when a pipeline is skipped (eg. the syn scan without root privileges)")
+	flagSet.BoolVar(&verbose, "v", false, "show the logs and results of the scans")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if !verbose {
+		gologger.DefaultLogger.SetMaxLevel(levels.LevelFatal)
+	}
+
+	checks, err := runner.SelfTest(target, timeout)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, check := range checks {
+		fmt.Println(check)
+		// a skipped syn scan is what the self test is meant to catch, unless explicitly allowed
+		if !check.Passed() && (check.Skipped == "" || !allowSkip) {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return errors.Errorf("%d of %d scan pipelines failed against %s", failed, len(checks), target)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/report"
+)
+
+// runTrack records the json results of each monitoring cycle in the exposure state and writes the
+// opened and closed findings as json lines: naabu track -state exposure.json [-ttl 3] [-o events.jsonl] results.json
+func runTrack(args []string) error {
+	var state, output string
+	var ttl int
+	flagSet := flag.NewFlagSet("track", flag.ExitOnError)
+	flagSet.StringVar(&state, "state", "", "json file of the tracked exposure, created if missing")
+	flagSet.IntVar(&ttl, "ttl", 3, "number of consecutive cycles a finding must be missing from to expire")
+	flagSet.StringVar(&output, "o", "", "file to append the opened and closed events to (default stdout)")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if state == "" {
+		return errors.New("no state file given (-state exposure.json)")
+	}
+	if ttl < 1 {
+		return errors.New("ttl must be at least 1 cycle")
+	}
+	scans, err := loadScans(flagSet.Args())
+	if err != nil {
+		return err
+	}
+	exposure, err := report.LoadExposure(state)
+	if err != nil {
+		return err
+	}
+
+	var writer io.Writer = os.Stdout
+	if output != "" {
+		f, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		writer = f
+	}
+	encoder := json.NewEncoder(writer)
+	for _, scan := range scans {
+		if len(scan.Records) == 0 {
+			gologger.Warning().Msgf("Skipping %s, a scan without results isn't recorded as a cycle\n", scan.Source)
+			continue
+		}
+		for _, event := range exposure.Observe(scan, ttl, time.Now()) {
+			if err := encoder.Encode(event); err != nil {
+				return err
+			}
+		}
+	}
+	return exposure.Save(state)
+}
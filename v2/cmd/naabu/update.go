@@ -0,0 +1,52 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"flag"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/runner"
+	"github.com/projectdiscovery/naabu/v2/pkg/selfupdate"
+)
+
+// runUpdate replaces the binary with a verified release: naabu update [-version 2.2.0] [-public-key naabu.pub] [-base-url url]
+func runUpdate(args []string) error {
+	var version, publicKey, baseURL string
+	var skipSignature bool
+	flagSet := flag.NewFlagSet("update", flag.ExitOnError)
+	flagSet.StringVar(&version, "version", "", "version to install, pinning the fleet to it (default latest)")
+	flagSet.StringVar(&publicKey, "public-key", "", "ed25519 public key (base64 or file) verifying the signature of the release checksums (default $NAABU_UPDATE_PUBLIC_KEY, then the key of the release)")
+	flagSet.StringVar(&baseURL, "base-url", selfupdate.DefaultBaseURL, "url of the release assets, a mirror can serve them")
+	flagSet.BoolVar(&skipSignature, "skip-signature", false, "only verify the release checksums, for releases published without signature")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	var key ed25519.PublicKey
+	var err error
+	if publicKey != "" {
+		key, err = selfupdate.ParsePublicKey(publicKey)
+	} else {
+		key, err = selfupdate.TrustedKey()
+	}
+	switch {
+	case err != nil:
+		return err
+	case key == nil && !skipSignature:
+		return errors.New("no public key given (-public-key naabu.pub), use -skip-signature to only verify the checksums")
+	case key == nil:
+		gologger.Warning().Msgf("Skipping the signature verification, only the checksums are verified\n")
+	}
+
+	installed, err := selfupdate.New(baseURL, key).Update(version, runner.Version())
+	if err != nil {
+		return err
+	}
+	if installed == "" {
+		gologger.Info().Msgf("naabu %s is already installed\n", runner.Version())
+		return nil
+	}
+	gologger.Info().Msgf("Updated naabu from %s to %s\n", runner.Version(), installed)
+	return nil
+}
@@ -0,0 +1,222 @@
+// Package listen binds ports on a host controlled by the user and records the probes of a paired
+// scan reaching them, measuring the filtering between the scanning host and the listener
+package listen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+)
+
+// Arrival is the first probe received on a bound port
+type Arrival struct {
+	Port     int       `json:"port"`
+	Protocol string    `json:"protocol"`
+	Source   string    `json:"source"`
+	Count    int       `json:"count"`
+	Time     time.Time `json:"timestamp"`
+}
+
+// Listener accepts the connections and datagrams of the bound ports
+type Listener struct {
+	sources map[string]struct{}
+	closers []io.Closer
+	bound   []*port.Port
+	failed  map[string]error
+
+	sync.Mutex
+	arrivals map[string]*Arrival
+	wg       sync.WaitGroup
+}
+
+// New binds the ports on the address, ports failing to bind (already in use, privileged) are
+// reported and skipped. Only the probes of the sources are recorded if any are given
+func New(address string, ports []*port.Port, sources []string) (*Listener, error) {
+	l := &Listener{
+		sources:  make(map[string]struct{}),
+		failed:   make(map[string]error),
+		arrivals: make(map[string]*Arrival),
+	}
+	for _, source := range sources {
+		ip := net.ParseIP(source)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid source ip %s", source)
+		}
+		l.sources[ip.String()] = struct{}{}
+	}
+
+	for _, p := range ports {
+		hostPort := net.JoinHostPort(address, strconv.Itoa(p.Port))
+		switch p.Protocol {
+		case protocol.UDP:
+			conn, err := net.ListenPacket("udp", hostPort)
+			if err != nil {
+				l.failed[key(p.Port, p.Protocol.String())] = err
+				continue
+			}
+			l.closers = append(l.closers, conn)
+			l.wg.Add(1)
+			go l.readUDP(conn, p.Port)
+		default:
+			listener, err := net.Listen("tcp", hostPort)
+			if err != nil {
+				l.failed[key(p.Port, p.Protocol.String())] = err
+				continue
+			}
+			l.closers = append(l.closers, listener)
+			l.wg.Add(1)
+			go l.acceptTCP(listener, p.Port)
+		}
+		l.bound = append(l.bound, p)
+	}
+	if len(l.bound) == 0 {
+		return nil, errors.New("no port could be bound")
+	}
+	return l, nil
+}
+
+// Bound returns the number of bound ports
+func (l *Listener) Bound() int {
+	return len(l.bound)
+}
+
+// Close stops listening and waits for the readers to return
+func (l *Listener) Close() {
+	for _, closer := range l.closers {
+		closer.Close()
+	}
+	l.wg.Wait()
+}
+
+func (l *Listener) acceptTCP(listener net.Listener, portNumber int) {
+	defer l.wg.Done()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+			l.record(portNumber, protocol.TCP.String(), addr.IP)
+		}
+		conn.Close()
+	}
+}
+
+func (l *Listener) readUDP(conn net.PacketConn, portNumber int) {
+	defer l.wg.Done()
+	buffer := make([]byte, 65535)
+	for {
+		_, addr, err := conn.ReadFrom(buffer)
+		if err != nil {
+			return
+		}
+		if addr, ok := addr.(*net.UDPAddr); ok {
+			l.record(portNumber, protocol.UDP.String(), addr.IP)
+		}
+	}
+}
+
+// record keeps the first probe received on the port from an expected source
+func (l *Listener) record(portNumber int, proto string, ip net.IP) {
+	source := ip.String()
+	if ip4 := ip.To4(); ip4 != nil {
+		source = ip4.String()
+	}
+	if _, ok := l.sources[source]; len(l.sources) > 0 && !ok {
+		return
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	k := key(portNumber, proto)
+	if arrival, ok := l.arrivals[k]; ok {
+		arrival.Count++
+		return
+	}
+	l.arrivals[k] = &Arrival{Port: portNumber, Protocol: proto, Source: source, Count: 1, Time: time.Now().UTC()}
+	gologger.Info().Msgf("Probe from %s reached %d/%s\n", source, portNumber, proto)
+}
+
+// Report is the outcome of the bound ports once the paired scan completed
+type Report struct {
+	Arrived []*Arrival `json:"arrived"`
+	// Blocked are the bound ports no probe reached, filtered on the path or not scanned
+	Blocked []string `json:"blocked"`
+	// Failed are the ports that could not be bound with the reason
+	Failed map[string]string `json:"failed,omitempty"`
+}
+
+// Report returns the ports reached by the probes and the ones left silent
+func (l *Listener) Report() *Report {
+	l.Lock()
+	defer l.Unlock()
+
+	report := &Report{Arrived: []*Arrival{}, Blocked: []string{}}
+	for _, p := range l.bound {
+		if arrival, ok := l.arrivals[key(p.Port, p.Protocol.String())]; ok {
+			copied := *arrival
+			report.Arrived = append(report.Arrived, &copied)
+		} else {
+			report.Blocked = append(report.Blocked, key(p.Port, p.Protocol.String()))
+		}
+	}
+	sort.Slice(report.Arrived, func(i, j int) bool {
+		if report.Arrived[i].Port == report.Arrived[j].Port {
+			return report.Arrived[i].Protocol < report.Arrived[j].Protocol
+		}
+		return report.Arrived[i].Port < report.Arrived[j].Port
+	})
+	if len(l.failed) > 0 {
+		report.Failed = make(map[string]string, len(l.failed))
+		for k, err := range l.failed {
+			report.Failed[k] = err.Error()
+		}
+	}
+	return report
+}
+
+// Write writes the report as text lines or indented json
+func (report *Report) Write(asJSON bool, writer io.Writer) error {
+	if asJSON {
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	for _, arrival := range report.Arrived {
+		if _, err := fmt.Fprintf(writer, "arrived %d/%s from %s (%d probes)\n", arrival.Port, arrival.Protocol, arrival.Source, arrival.Count); err != nil {
+			return err
+		}
+	}
+	for _, blocked := range report.Blocked {
+		if _, err := fmt.Fprintf(writer, "blocked %s\n", blocked); err != nil {
+			return err
+		}
+	}
+	failed := make([]string, 0, len(report.Failed))
+	for k := range report.Failed {
+		failed = append(failed, k)
+	}
+	sort.Strings(failed)
+	for _, k := range failed {
+		if _, err := fmt.Fprintf(writer, "unbound %s: %s\n", k, report.Failed[k]); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(writer, "%d of %d bound ports reached\n", len(report.Arrived), len(report.Arrived)+len(report.Blocked))
+	return err
+}
+
+func key(portNumber int, proto string) string {
+	return strconv.Itoa(portNumber) + "/" + proto
+}
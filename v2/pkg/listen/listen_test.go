@@ -0,0 +1,77 @@
+package listen
+
+import (
+	"bytes"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+// freePort returns a port number available on the loopback for the protocol
+func freePort(t *testing.T, proto protocol.Protocol) int {
+	if proto == protocol.UDP {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.Nil(t, err)
+		defer conn.Close()
+		return conn.LocalAddr().(*net.UDPAddr).Port
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestListener(t *testing.T) {
+	reached := &port.Port{Port: freePort(t, protocol.TCP), Protocol: protocol.TCP}
+	silent := &port.Port{Port: freePort(t, protocol.TCP), Protocol: protocol.TCP}
+	datagram := &port.Port{Port: freePort(t, protocol.UDP), Protocol: protocol.UDP}
+
+	l, err := New("127.0.0.1", []*port.Port{reached, silent, datagram}, []string{"127.0.0.1"})
+	require.Nil(t, err)
+	require.Equal(t, 3, l.Bound())
+
+	conn, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(reached.Port)))
+	require.Nil(t, err)
+	conn.Close()
+	conn, err = net.Dial("udp", net.JoinHostPort("127.0.0.1", strconv.Itoa(datagram.Port)))
+	require.Nil(t, err)
+	_, err = conn.Write([]byte("probe"))
+	require.Nil(t, err)
+	conn.Close()
+
+	require.Eventually(t, func() bool {
+		return len(l.Report().Arrived) == 2
+	}, time.Second, 10*time.Millisecond)
+	l.Close()
+
+	report := l.Report()
+	require.Equal(t, reached.Port, report.Arrived[0].Port)
+	require.Equal(t, "127.0.0.1", report.Arrived[0].Source)
+	require.Equal(t, []string{strconv.Itoa(silent.Port) + "/tcp"}, report.Blocked)
+
+	var buf bytes.Buffer
+	require.Nil(t, report.Write(false, &buf))
+	require.Contains(t, buf.String(), "2 of 3 bound ports reached")
+}
+
+func TestListenerSources(t *testing.T) {
+	reached := &port.Port{Port: freePort(t, protocol.TCP), Protocol: protocol.TCP}
+	l, err := New("127.0.0.1", []*port.Port{reached}, []string{"192.0.2.1"})
+	require.Nil(t, err)
+	defer l.Close()
+
+	// probes of other sources are ignored
+	conn, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(reached.Port)))
+	require.Nil(t, err)
+	conn.Close()
+	time.Sleep(50 * time.Millisecond)
+	require.Empty(t, l.Report().Arrived)
+
+	_, err = New("127.0.0.1", []*port.Port{reached}, []string{"not an ip"})
+	require.NotNil(t, err)
+}
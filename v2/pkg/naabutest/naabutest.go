@@ -0,0 +1,258 @@
+// Package naabutest provides simulated targets on the loopback interface to run the full naabu
+// runner against, for end-to-end tests of the scan logic and to validate a deployment:
+//
+//	env := naabutest.New()
+//	defer env.Close()
+//	env.TCP("SSH-2.0-OpenSSH_8.9\r\n")
+//	env.UDP(nil, []byte("pong"))
+//	env.Closed(protocol.TCP)
+//	env.Blackhole()
+//	results, err := env.Run()
+//	err = env.Verify(results)
+package naabutest
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+	"github.com/projectdiscovery/naabu/v2/pkg/runner"
+)
+
+// Host is the address the simulated targets listen on
+const Host = "127.0.0.1"
+
+// Kinds of simulated targets
+const (
+	// KindOpen is a tcp listener, or an udp service answering its protocol payload
+	KindOpen = "open"
+	// KindClosed is a port without listener, answered by a tcp reset or an icmp port unreachable
+	KindClosed = "closed"
+	// KindBlackhole is an udp socket silently swallowing the datagrams, as a filtering firewall would
+	KindBlackhole = "blackhole"
+)
+
+// Target is a simulated port of the loopback interface
+type Target struct {
+	Kind     string
+	Protocol protocol.Protocol
+	Port     int
+}
+
+// Open returns true if a scan is expected to report the port as open
+func (target *Target) Open() bool {
+	return target.Kind == KindOpen
+}
+
+func (target *Target) String() string {
+	return fmt.Sprintf("%s %d/%s", target.Kind, target.Port, target.Protocol.String())
+}
+
+// Environment is a set of simulated targets, closed together
+type Environment struct {
+	sync.Mutex
+	targets []*Target
+	closers []func() error
+}
+
+// New returns an environment without targets
+func New() *Environment {
+	return &Environment{}
+}
+
+// TCP starts a tcp listener sending the banner, if any, to each connection
+func (env *Environment) TCP(banner string) (*Target, error) {
+	listener, err := net.Listen("tcp4", net.JoinHostPort(Host, "0"))
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			if banner != "" {
+				_, _ = conn.Write([]byte(banner))
+			}
+			conn.Close()
+		}
+	}()
+	return env.add(KindOpen, protocol.TCP, listener.Addr().(*net.TCPAddr).Port, listener.Close), nil
+}
+
+// UDP starts an udp service answering the response to the datagrams equal to the request, as
+// services only answer a well formed request. A nil request answers any datagram, including the
+// empty one sent to the ports without protocol payload
+func (env *Environment) UDP(request, response []byte) (*Target, error) {
+	conn, err := net.ListenPacket("udp4", net.JoinHostPort(Host, "0"))
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		buffer := make([]byte, 65535)
+		for {
+			n, addr, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			if request == nil || string(buffer[:n]) == string(request) {
+				_, _ = conn.WriteTo(response, addr)
+			}
+		}
+	}()
+	return env.add(KindOpen, protocol.UDP, conn.LocalAddr().(*net.UDPAddr).Port, conn.Close), nil
+}
+
+// Closed reserves a port and releases it, so that the kernel answers the probes with a tcp reset
+// or an icmp port unreachable
+func (env *Environment) Closed(proto protocol.Protocol) (*Target, error) {
+	var portNumber int
+	switch proto {
+	case protocol.TCP:
+		listener, err := net.Listen("tcp4", net.JoinHostPort(Host, "0"))
+		if err != nil {
+			return nil, err
+		}
+		portNumber = listener.Addr().(*net.TCPAddr).Port
+		listener.Close()
+	case protocol.UDP:
+		conn, err := net.ListenPacket("udp4", net.JoinHostPort(Host, "0"))
+		if err != nil {
+			return nil, err
+		}
+		portNumber = conn.LocalAddr().(*net.UDPAddr).Port
+		conn.Close()
+	default:
+		return nil, fmt.Errorf("invalid protocol %s (allowed: tcp, udp)", proto.String())
+	}
+	return env.add(KindClosed, proto, portNumber, nil), nil
+}
+
+// Blackhole starts an udp socket reading the datagrams without ever answering. Tcp ports can't be
+// blackholed on the loopback interface without a firewall rule, the kernel always answers them
+func (env *Environment) Blackhole() (*Target, error) {
+	conn, err := net.ListenPacket("udp4", net.JoinHostPort(Host, "0"))
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		buffer := make([]byte, 65535)
+		for {
+			if _, _, err := conn.ReadFrom(buffer); err != nil {
+				return
+			}
+		}
+	}()
+	return env.add(KindBlackhole, protocol.UDP, conn.LocalAddr().(*net.UDPAddr).Port, conn.Close), nil
+}
+
+func (env *Environment) add(kind string, proto protocol.Protocol, portNumber int, closer func() error) *Target {
+	env.Lock()
+	defer env.Unlock()
+
+	target := &Target{Kind: kind, Protocol: proto, Port: portNumber}
+	env.targets = append(env.targets, target)
+	if closer != nil {
+		env.closers = append(env.closers, closer)
+	}
+	return target
+}
+
+// Targets returns the simulated targets
+func (env *Environment) Targets() []*Target {
+	env.Lock()
+	defer env.Unlock()
+	return append([]*Target(nil), env.targets...)
+}
+
+// Ports returns the ports of the targets in the -p syntax, the udp ones prefixed with u:
+func (env *Environment) Ports() string {
+	var ports []string
+	for _, target := range env.Targets() {
+		if target.Protocol == protocol.UDP {
+			ports = append(ports, "u:"+strconv.Itoa(target.Port))
+		} else {
+			ports = append(ports, strconv.Itoa(target.Port))
+		}
+	}
+	return strings.Join(ports, ",")
+}
+
+// Run scans the targets with a connect scan, which needs no privileges, and returns the results.
+// The options are applied after the defaults of the environment and can override them
+func (env *Environment) Run(opts ...runner.Option) ([]*result.HostResult, error) {
+	var (
+		mu      sync.Mutex
+		results []*result.HostResult
+	)
+	defaults := []runner.Option{
+		runner.WithHosts(Host),
+		runner.WithPorts(env.Ports()),
+		runner.WithScanType(runner.ConnectScan),
+		runner.WithSkipHostDiscovery(),
+		runner.WithSilent(),
+		runner.WithOnResult(func(hostResult *result.HostResult) {
+			mu.Lock()
+			defer mu.Unlock()
+			results = append(results, hostResult)
+		}),
+	}
+	naabuRunner, err := runner.New(append(defaults, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer naabuRunner.Close()
+
+	if err := naabuRunner.RunEnumeration(); err != nil {
+		return results, err
+	}
+	return results, nil
+}
+
+// Verify returns an error listing the open targets missing from the results and the ports
+// reported open although they are closed or blackholed
+func (env *Environment) Verify(results []*result.HostResult) error {
+	reported := make(map[string]*port.Port)
+	for _, hostResult := range results {
+		if hostResult.IP != Host {
+			continue
+		}
+		for _, p := range hostResult.Ports {
+			reported[fmt.Sprintf("%d/%s", p.Port, p.Protocol.String())] = p
+		}
+	}
+
+	var problems []string
+	for _, target := range env.Targets() {
+		_, found := reported[fmt.Sprintf("%d/%s", target.Port, target.Protocol.String())]
+		switch {
+		case target.Open() && !found:
+			problems = append(problems, fmt.Sprintf("%s not reported", target))
+		case !target.Open() && found:
+			problems = append(problems, fmt.Sprintf("%s reported open", target))
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("unexpected scan results: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// Close stops the simulated targets
+func (env *Environment) Close() {
+	env.Lock()
+	defer env.Unlock()
+
+	for _, closer := range env.closers {
+		_ = closer()
+	}
+	env.closers = nil
+}
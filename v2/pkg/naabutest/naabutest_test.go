@@ -0,0 +1,59 @@
+package naabutest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+	"github.com/projectdiscovery/naabu/v2/pkg/runner"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvironment(t *testing.T) {
+	env := New()
+	defer env.Close()
+
+	open, err := env.TCP("SSH-2.0-OpenSSH_8.9\r\n")
+	require.Nil(t, err)
+	echo, err := env.UDP(nil, []byte("pong"))
+	require.Nil(t, err)
+	closed, err := env.Closed(protocol.TCP)
+	require.Nil(t, err)
+	blackhole, err := env.Blackhole()
+	require.Nil(t, err)
+	_, err = env.Closed(protocol.ARP)
+	require.NotNil(t, err)
+
+	require.Len(t, env.Targets(), 4)
+	require.True(t, open.Open())
+	require.False(t, blackhole.Open())
+	require.Contains(t, env.Ports(), "u:")
+
+	results := []*result.HostResult{{IP: Host, Ports: []*port.Port{
+		{Port: open.Port, Protocol: protocol.TCP},
+		{Port: echo.Port, Protocol: protocol.UDP},
+	}}}
+	require.Nil(t, env.Verify(results))
+
+	results[0].Ports = append(results[0].Ports, &port.Port{Port: closed.Port, Protocol: protocol.TCP})
+	require.ErrorContains(t, env.Verify(results), "reported open")
+	require.ErrorContains(t, env.Verify(nil), "not reported")
+}
+
+func TestRunConnectScan(t *testing.T) {
+	env := New()
+	defer env.Close()
+
+	_, err := env.TCP("")
+	require.Nil(t, err)
+	_, err = env.TCP("220 ready\r\n")
+	require.Nil(t, err)
+	_, err = env.Closed(protocol.TCP)
+	require.Nil(t, err)
+
+	results, err := env.Run(runner.WithTimeout(500 * time.Millisecond))
+	require.Nil(t, err)
+	require.Nil(t, env.Verify(results))
+}
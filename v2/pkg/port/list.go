@@ -0,0 +1,189 @@
+package port
+
+import (
+	"sort"
+	"sync/atomic"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+)
+
+const (
+	// maxNumber is the highest port number
+	maxNumber = 65535
+	// blockSize is the number of ports of the list allocated together once indexed
+	blockSize = 1024
+)
+
+// block holds the allocated ports of consecutive indexes of the list
+type block [blockSize]Port
+
+// span is a run of consecutive port numbers of a protocol, offset being the index of its first port
+type span struct {
+	first    int
+	last     int
+	protocol protocol.Protocol
+	offset   int
+}
+
+// List is an ordered list of unique ports stored as runs of consecutive numbers, so that large
+// ranges (eg. 1-65535 over tcp and udp) are indexed without allocating a Port for each number
+type List struct {
+	spans []span
+	size  int
+	seen  map[protocol.Protocol][]uint64 // bitmap of the numbers of each protocol
+	// blocks are allocated as their indexes are picked, and shared by the picks of every host
+	blocks []*atomic.Pointer[block]
+}
+
+// NewList returns the list of the ports, skipping the duplicates
+func NewList(ports ...*Port) *List {
+	list := &List{}
+	for _, p := range ports {
+		list.Add(p.Port, p.Protocol)
+	}
+	return list
+}
+
+// Add appends the port if it's not in the list and returns true if it was added
+func (l *List) Add(number int, portProtocol protocol.Protocol) bool {
+	if number < 0 || number > maxNumber || l.Contains(number, portProtocol) {
+		return false
+	}
+	if l.seen == nil {
+		l.seen = make(map[protocol.Protocol][]uint64)
+	}
+	bitmap, ok := l.seen[portProtocol]
+	if !ok {
+		bitmap = make([]uint64, maxNumber/64+1)
+		l.seen[portProtocol] = bitmap
+	}
+	bitmap[number/64] |= 1 << (number % 64)
+
+	if last := len(l.spans) - 1; last >= 0 && l.spans[last].protocol == portProtocol && l.spans[last].last+1 == number {
+		l.spans[last].last = number
+	} else {
+		l.spans = append(l.spans, span{first: number, last: number, protocol: portProtocol, offset: l.size})
+	}
+	l.size++
+	if len(l.blocks)*blockSize < l.size {
+		l.blocks = append(l.blocks, &atomic.Pointer[block]{})
+	}
+	return true
+}
+
+// AddRange appends the ports of the range not in the list
+func (l *List) AddRange(first, last int, portProtocol protocol.Protocol) {
+	for number := first; number <= last; number++ {
+		l.Add(number, portProtocol)
+	}
+}
+
+// AddList appends the ports of the other list not in the list
+func (l *List) AddList(other *List) {
+	if other == nil {
+		return
+	}
+	for _, s := range other.spans {
+		l.AddRange(s.first, s.last, s.protocol)
+	}
+}
+
+// Without returns the ports of the list not in the excluded list
+func (l *List) Without(excluded *List) *List {
+	filtered := &List{}
+	if l == nil {
+		return filtered
+	}
+	for _, s := range l.spans {
+		for number := s.first; number <= s.last; number++ {
+			if !excluded.Contains(number, s.protocol) {
+				filtered.Add(number, s.protocol)
+			}
+		}
+	}
+	return filtered
+}
+
+// Contains returns true if the port is in the list
+func (l *List) Contains(number int, portProtocol protocol.Protocol) bool {
+	if l == nil || number < 0 || number > maxNumber {
+		return false
+	}
+	bitmap, ok := l.seen[portProtocol]
+	return ok && bitmap[number/64]&(1<<(number%64)) != 0
+}
+
+// HasProtocol returns true if the list contains a port of the protocol
+func (l *List) HasProtocol(portProtocol protocol.Protocol) bool {
+	if l == nil {
+		return false
+	}
+	_, ok := l.seen[portProtocol]
+	return ok
+}
+
+// Len returns the number of ports of the list
+func (l *List) Len() int {
+	if l == nil {
+		return 0
+	}
+	return l.size
+}
+
+// At returns the port at the index of the list, nil if the index is out of range. The port is
+// shared by every call with the index and must not be modified
+func (l *List) At(index int) *Port {
+	if l == nil || index < 0 || index >= l.size {
+		return nil
+	}
+	pointer := l.blocks[index/blockSize]
+	ports := pointer.Load()
+	if ports == nil {
+		ports = l.fill(index / blockSize)
+		if !pointer.CompareAndSwap(nil, ports) {
+			ports = pointer.Load()
+		}
+	}
+	return &ports[index%blockSize]
+}
+
+// fill allocates the ports of the block of indexes
+func (l *List) fill(n int) *block {
+	ports := &block{}
+	first := n * blockSize
+	i := sort.Search(len(l.spans), func(i int) bool {
+		return l.spans[i].offset > first
+	}) - 1
+	for index := first; index < first+blockSize && index < l.size; index++ {
+		for i+1 < len(l.spans) && l.spans[i+1].offset <= index {
+			i++
+		}
+		s := l.spans[i]
+		ports[index-first] = Port{Port: s.first + index - s.offset, Protocol: s.protocol}
+	}
+	return ports
+}
+
+// Each calls fn with the ports of the list in order
+func (l *List) Each(fn func(p *Port)) {
+	if l == nil {
+		return
+	}
+	for _, s := range l.spans {
+		for number := s.first; number <= s.last; number++ {
+			fn(&Port{Port: number, Protocol: s.protocol})
+		}
+	}
+}
+
+// Ports returns the ports of the list, allocating each of them
+func (l *List) Ports() []*Port {
+	if l.Len() == 0 {
+		return nil
+	}
+	ports := make([]*Port, 0, l.size)
+	l.Each(func(p *Port) {
+		ports = append(ports, p)
+	})
+	return ports
+}
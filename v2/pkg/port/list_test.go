@@ -0,0 +1,80 @@
+package port
+
+import (
+	"testing"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestList(t *testing.T) {
+	list := &List{}
+	list.AddRange(1, 65535, protocol.TCP)
+	list.AddRange(1, 65535, protocol.UDP)
+	// full ranges over both protocols are two runs
+	require.Equal(t, 2*65535, list.Len())
+	require.Len(t, list.spans, 2)
+	require.Equal(t, &Port{Port: 1, Protocol: protocol.TCP}, list.At(0))
+	require.Equal(t, &Port{Port: 65535, Protocol: protocol.TCP}, list.At(65534))
+	require.Equal(t, &Port{Port: 1, Protocol: protocol.UDP}, list.At(65535))
+	require.Equal(t, &Port{Port: 65535, Protocol: protocol.UDP}, list.At(2*65535-1))
+
+	// duplicates are skipped
+	require.False(t, list.Add(80, protocol.TCP))
+	require.Equal(t, 2*65535, list.Len())
+}
+
+func TestListOrder(t *testing.T) {
+	list := NewList(
+		&Port{Port: 443, Protocol: protocol.TCP},
+		&Port{Port: 80, Protocol: protocol.TCP},
+		&Port{Port: 81, Protocol: protocol.TCP},
+		&Port{Port: 53, Protocol: protocol.UDP},
+		&Port{Port: 80, Protocol: protocol.TCP},
+	)
+	require.Equal(t, 4, list.Len())
+	require.Len(t, list.spans, 3)
+	require.Equal(t, []*Port{
+		{Port: 443, Protocol: protocol.TCP},
+		{Port: 80, Protocol: protocol.TCP},
+		{Port: 81, Protocol: protocol.TCP},
+		{Port: 53, Protocol: protocol.UDP},
+	}, list.Ports())
+	for index, p := range list.Ports() {
+		require.Equal(t, p, list.At(index))
+	}
+	require.True(t, list.Contains(53, protocol.UDP))
+	require.False(t, list.Contains(53, protocol.TCP))
+	require.True(t, list.HasProtocol(protocol.UDP))
+
+	// out of range indexes return no port
+	require.Nil(t, list.At(-1))
+	require.Nil(t, list.At(list.Len()))
+	// the ports of an index are allocated once
+	require.Same(t, list.At(2), list.At(2))
+
+	var empty *List
+	require.Nil(t, empty.At(0))
+	require.Nil(t, (&List{}).At(0))
+	require.Equal(t, 0, empty.Len())
+	require.Nil(t, empty.Ports())
+	require.False(t, empty.Contains(80, protocol.TCP))
+}
+
+func TestListMerge(t *testing.T) {
+	list := &List{}
+	list.AddRange(1, 100, protocol.TCP)
+	other := &List{}
+	other.AddRange(50, 150, protocol.TCP)
+	other.Add(53, protocol.UDP)
+	list.AddList(other)
+	require.Equal(t, 151, list.Len())
+	require.Len(t, list.spans, 2)
+
+	excluded := NewList(&Port{Port: 53, Protocol: protocol.UDP}, &Port{Port: 80, Protocol: protocol.TCP})
+	filtered := list.Without(excluded)
+	require.Equal(t, 149, filtered.Len())
+	require.False(t, filtered.Contains(80, protocol.TCP))
+	require.True(t, filtered.Contains(81, protocol.TCP))
+	require.False(t, filtered.HasProtocol(protocol.UDP))
+}
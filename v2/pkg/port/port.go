@@ -2,14 +2,45 @@ package port
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
 )
 
 type Port struct {
-	Port     int               `json:"port"`
-	Protocol protocol.Protocol `json:"protocol"`
-	TLS      bool              `json:"tls"`
+	Port      int               `json:"port"`
+	Protocol  protocol.Protocol `json:"protocol"`
+	TLS       bool              `json:"tls"`
+	Banner    string            `json:"banner,omitempty"`
+	Service   string            `json:"service,omitempty"`
+	Version   string            `json:"version,omitempty"`
+	Responder string            `json:"responder,omitempty"`
+	RTT       time.Duration     `json:"rtt,omitempty"`
+	Severity  string            `json:"severity,omitempty"`
+	// Risk are the notes of the heuristics flagging an outdated high risk service
+	Risk string `json:"risk,omitempty"`
+	// Enrichment are the fields of the port looked up in an external inventory
+	Enrichment map[string]interface{} `json:"enrichment,omitempty"`
+	// Path is the network path of the probe that found the port
+	Path *Path `json:"path,omitempty"`
+}
+
+// Path identifies where the probe finding a port was emitted from
+type Path struct {
+	Interface string `json:"interface,omitempty"`
+	SourceIP  string `json:"source_ip,omitempty"`
+	Gateway   string `json:"gateway,omitempty"`
+	// Vantage is the label of the scanning node
+	Vantage string `json:"vantage,omitempty"`
+}
+
+// reservedPorts are the port numbers reserved by IANA, never assigned to a service
+var reservedPorts = map[int]struct{}{0: {}, 1023: {}, 1024: {}, 49151: {}, 65535: {}}
+
+// IsReserved returns true if the port number is reserved by IANA (0, 1023, 1024, 49151, 65535)
+func IsReserved(number int) bool {
+	_, ok := reservedPorts[number]
+	return ok
 }
 
 func (p *Port) String() string {
@@ -0,0 +1,73 @@
+package port
+
+import (
+	_ "embed"
+	"strconv"
+	"strings"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+)
+
+//go:embed services.txt
+var servicesData string
+
+// serviceKey is a port number over a protocol
+type serviceKey struct {
+	number   int
+	protocol protocol.Protocol
+}
+
+// services maps the service names to their port number for each protocol, serviceNames the ports
+// to their registered name
+var services, serviceNames = parseServices(servicesData)
+
+// parseServices parses the "name port/protocol" lines of the services database, the first name of
+// a port being its registered name and the following ones its aliases
+func parseServices(data string) (map[string]map[protocol.Protocol]int, map[serviceKey]string) {
+	parsed := make(map[string]map[protocol.Protocol]int)
+	names := make(map[serviceKey]string)
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		number, protocolName, _ := strings.Cut(fields[1], "/")
+		portNumber, err := strconv.Atoi(number)
+		if err != nil {
+			continue
+		}
+		portProtocol := protocol.TCP
+		if protocolName == "udp" {
+			portProtocol = protocol.UDP
+		}
+		name := strings.ToLower(fields[0])
+		if _, ok := parsed[name]; !ok {
+			parsed[name] = make(map[protocol.Protocol]int)
+		}
+		parsed[name][portProtocol] = portNumber
+		if key := (serviceKey{portNumber, portProtocol}); names[key] == "" {
+			names[key] = name
+		}
+	}
+	return parsed, names
+}
+
+// ServicePort returns the port number of the service name over the protocol from the embedded
+// services database (ssh, https, rdp), found being false for unknown names
+func ServicePort(name string, portProtocol protocol.Protocol) (int, bool) {
+	number, ok := services[strings.ToLower(name)][portProtocol]
+	return number, ok
+}
+
+// ServiceName returns the registered service name of the port number over the protocol from the
+// embedded services database, found being false for unknown ports
+func ServiceName(number int, portProtocol protocol.Protocol) (string, bool) {
+	name, ok := serviceNames[serviceKey{number, portProtocol}]
+	return name, ok
+}
+
+// IsService returns true if the name is in the services database, over any protocol
+func IsService(name string) bool {
+	_, ok := services[strings.ToLower(name)]
+	return ok
+}
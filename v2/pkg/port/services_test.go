@@ -0,0 +1,39 @@
+package port
+
+import (
+	"testing"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServicePort(t *testing.T) {
+	number, ok := ServicePort("SSH", protocol.TCP)
+	require.True(t, ok)
+	require.Equal(t, 22, number)
+
+	number, ok = ServicePort("domain", protocol.UDP)
+	require.True(t, ok)
+	require.Equal(t, 53, number)
+
+	_, ok = ServicePort("ntp", protocol.TCP)
+	require.False(t, ok)
+	require.True(t, IsService("ntp"))
+	require.False(t, IsService("unknown"))
+
+	// the ports are named after their registered service, not its aliases
+	name, ok := ServiceName(53, protocol.UDP)
+	require.True(t, ok)
+	require.Equal(t, "domain", name)
+	name, _ = ServiceName(3389, protocol.TCP)
+	require.Equal(t, "ms-wbt-server", name)
+	_, ok = ServiceName(123, protocol.TCP)
+	require.False(t, ok)
+
+	// every service of the database has a valid port
+	for name, numbers := range services {
+		for _, number := range numbers {
+			require.True(t, number > 0 && number <= maxNumber, name)
+		}
+	}
+}
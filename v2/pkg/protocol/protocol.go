@@ -1,5 +1,10 @@
 package protocol
 
+import (
+	"fmt"
+	"strings"
+)
+
 type Protocol int
 
 const (
@@ -24,3 +29,17 @@ func (p Protocol) String() string {
 func (p Protocol) MarshalJSON() ([]byte, error) {
 	return []byte(`"` + p.String() + `"`), nil
 }
+
+func (p *Protocol) UnmarshalJSON(data []byte) error {
+	switch strings.Trim(string(data), `"`) {
+	case "tcp", "0":
+		*p = TCP
+	case "udp", "1":
+		*p = UDP
+	case "arp", "2":
+		*p = ARP
+	default:
+		return fmt.Errorf("unknown protocol %s", data)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// Annotation is a triage note attached to an open port of a host
+type Annotation struct {
+	Host     string    `json:"host"`
+	Port     int       `json:"port"`
+	Protocol string    `json:"protocol"`
+	Note     string    `json:"note"`
+	Time     time.Time `json:"timestamp"`
+}
+
+// key returns the host and port/protocol the annotation applies to
+func (annotation *Annotation) key() string {
+	return fmt.Sprintf("%s %d/%s", annotation.Host, annotation.Port, annotation.Protocol)
+}
+
+// Annotations are the triage notes of the findings, at most one per host and port
+type Annotations struct {
+	notes map[string]*Annotation
+}
+
+// LoadAnnotations reads the annotations of the json file, a missing file has none
+func LoadAnnotations(path string) (*Annotations, error) {
+	annotations := &Annotations{notes: make(map[string]*Annotation)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return annotations, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []*Annotation
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid annotations file %s: %w", path, err)
+	}
+	for _, annotation := range list {
+		annotations.notes[annotation.key()] = annotation
+	}
+	return annotations, nil
+}
+
+// Add annotates the port of the host, replacing its previous note. An empty note removes the annotation
+func (annotations *Annotations) Add(host string, port int, protocol, note string, now time.Time) {
+	annotation := &Annotation{Host: host, Port: port, Protocol: protocol, Note: note, Time: now}
+	if note == "" {
+		delete(annotations.notes, annotation.key())
+		return
+	}
+	annotations.notes[annotation.key()] = annotation
+}
+
+// Note returns the note of the port/protocol (443/tcp) of the host, empty if it isn't annotated
+func (annotations *Annotations) Note(host, port string) string {
+	if annotations == nil {
+		return ""
+	}
+	if annotation, ok := annotations.notes[host+" "+port]; ok {
+		return annotation.Note
+	}
+	return ""
+}
+
+// List returns the annotations sorted by host and port
+func (annotations *Annotations) List() []*Annotation {
+	list := make([]*Annotation, 0, len(annotations.notes))
+	for _, annotation := range annotations.notes {
+		list = append(list, annotation)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Host != list[j].Host {
+			return list[i].Host < list[j].Host
+		}
+		if list[i].Port != list[j].Port {
+			return list[i].Port < list[j].Port
+		}
+		return list[i].Protocol < list[j].Protocol
+	})
+	return list
+}
+
+// Save writes the annotations to the json file
+func (annotations *Annotations) Save(path string) error {
+	data, err := json.MarshalIndent(annotations.List(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,58 @@
+package report
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnotations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "annotations.json")
+	annotations, err := LoadAnnotations(path)
+	require.Nil(t, err)
+	require.Empty(t, annotations.List())
+
+	now := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+	annotations.Add("10.0.0.2", 3389, "tcp", "approved exposure", now)
+	annotations.Add("10.0.0.1", 443, "tcp", "public site", now)
+	annotations.Add("10.0.0.1", 8080, "tcp", "temporary", now)
+	annotations.Add("10.0.0.1", 8080, "tcp", "", now)
+	require.Nil(t, annotations.Save(path))
+
+	annotations, err = LoadAnnotations(path)
+	require.Nil(t, err)
+	require.Len(t, annotations.List(), 2)
+	require.Equal(t, "10.0.0.1", annotations.List()[0].Host)
+	require.Equal(t, "approved exposure", annotations.Note("10.0.0.2", "3389/tcp"))
+	require.Empty(t, annotations.Note("10.0.0.2", "3389/udp"))
+	require.Empty(t, (*Annotations)(nil).Note("10.0.0.2", "3389/tcp"))
+}
+
+func TestDiffAnnotate(t *testing.T) {
+	previous, err := LoadScan("old.json", strings.NewReader(`{"ip":"10.0.0.1","port":22,"protocol":"tcp","timestamp":"2023-01-01T00:00:00Z"}
+`))
+	require.Nil(t, err)
+	current, err := LoadScan("new.json", strings.NewReader(`{"ip":"10.0.0.1","port":22,"protocol":"tcp","timestamp":"2023-02-01T00:00:00Z"}
+{"ip":"10.0.0.1","port":443,"protocol":"tcp","timestamp":"2023-02-01T00:00:00Z"}
+{"ip":"10.0.0.2","port":3389,"protocol":"tcp","timestamp":"2023-02-01T00:00:00Z"}
+`))
+	require.Nil(t, err)
+	annotations, err := LoadAnnotations(filepath.Join(t.TempDir(), "missing.json"))
+	require.Nil(t, err)
+	annotations.Add("10.0.0.2", 3389, "tcp", "approved exposure", time.Now())
+
+	diff := Compare(previous, current)
+	diff.Annotate(annotations, false)
+	var buffer bytes.Buffer
+	require.Nil(t, diff.Write(DiffText, &buffer))
+	require.Equal(t, "--- old.json\n+++ new.json\n+ 10.0.0.2 3389/tcp (approved exposure)\n~ 10.0.0.1 +443/tcp\n", buffer.String())
+
+	diff = Compare(previous, current)
+	diff.Annotate(annotations, true)
+	require.Empty(t, diff.AddedHosts)
+	require.Len(t, diff.ChangedHosts, 1)
+}
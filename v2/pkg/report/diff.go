@@ -0,0 +1,217 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Diff output formats
+const (
+	DiffText     = "text"
+	DiffMarkdown = "md"
+	DiffJSON     = "json"
+)
+
+// HostChange is a host whose exposure differs between two scans
+type HostChange struct {
+	Host   string   `json:"host"`
+	Ports  []string `json:"ports,omitempty"`
+	Opened []string `json:"opened,omitempty"`
+	Closed []string `json:"closed,omitempty"`
+	// Notes are the triage notes of the annotated ports
+	Notes map[string]string `json:"notes,omitempty"`
+}
+
+// Diff is the comparison of the open ports of two scans
+type Diff struct {
+	Old          string        `json:"old"`
+	New          string        `json:"new"`
+	AddedHosts   []*HostChange `json:"added_hosts"`
+	RemovedHosts []*HostChange `json:"removed_hosts"`
+	ChangedHosts []*HostChange `json:"changed_hosts"`
+}
+
+// Compare returns the hosts added, removed and whose ports changed from the old to the new scan
+func Compare(previous, current *Scan) *Diff {
+	diff := &Diff{
+		Old:          previous.Source,
+		New:          current.Source,
+		AddedHosts:   []*HostChange{},
+		RemovedHosts: []*HostChange{},
+		ChangedHosts: []*HostChange{},
+	}
+	oldPorts, newPorts := hostPorts(previous), hostPorts(current)
+	for _, host := range sortedKeys(union(oldPorts, newPorts)) {
+		before, inOld := oldPorts[host]
+		after, inNew := newPorts[host]
+		switch {
+		case !inOld:
+			diff.AddedHosts = append(diff.AddedHosts, &HostChange{Host: host, Ports: sortedKeys(after)})
+		case !inNew:
+			diff.RemovedHosts = append(diff.RemovedHosts, &HostChange{Host: host, Ports: sortedKeys(before)})
+		default:
+			change := &HostChange{Host: host, Opened: difference(after, before), Closed: difference(before, after)}
+			if len(change.Opened) > 0 || len(change.Closed) > 0 {
+				diff.ChangedHosts = append(diff.ChangedHosts, change)
+			}
+		}
+	}
+	return diff
+}
+
+// Annotate labels the annotated ports of the changes with their note, or drops them if suppress is
+// set along with the hosts left without changes
+func (diff *Diff) Annotate(annotations *Annotations, suppress bool) {
+	diff.AddedHosts = annotateChanges(diff.AddedHosts, annotations, suppress)
+	diff.RemovedHosts = annotateChanges(diff.RemovedHosts, annotations, suppress)
+	diff.ChangedHosts = annotateChanges(diff.ChangedHosts, annotations, suppress)
+}
+
+func annotateChanges(changes []*HostChange, annotations *Annotations, suppress bool) []*HostChange {
+	kept := changes[:0]
+	for _, change := range changes {
+		annotate := func(ports []string) []string {
+			var unannotated []string
+			for _, port := range ports {
+				note := annotations.Note(change.Host, port)
+				switch {
+				case note == "":
+					unannotated = append(unannotated, port)
+				case !suppress:
+					unannotated = append(unannotated, port)
+					if change.Notes == nil {
+						change.Notes = make(map[string]string)
+					}
+					change.Notes[port] = note
+				}
+			}
+			return unannotated
+		}
+		change.Ports, change.Opened, change.Closed = annotate(change.Ports), annotate(change.Opened), annotate(change.Closed)
+		if len(change.Ports) > 0 || len(change.Opened) > 0 || len(change.Closed) > 0 {
+			kept = append(kept, change)
+		}
+	}
+	return kept
+}
+
+// Empty returns true if both scans expose the same ports
+func (diff *Diff) Empty() bool {
+	return len(diff.AddedHosts) == 0 && len(diff.RemovedHosts) == 0 && len(diff.ChangedHosts) == 0
+}
+
+// Write writes the comparison in the format (text/md/json)
+func (diff *Diff) Write(format string, writer io.Writer) error {
+	switch format {
+	case DiffText:
+		return diff.writeText(writer)
+	case DiffMarkdown:
+		return diff.writeMarkdown(writer)
+	case DiffJSON:
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(diff)
+	default:
+		return fmt.Errorf("invalid format %s (allowed: %s, %s, %s)", format, DiffText, DiffMarkdown, DiffJSON)
+	}
+}
+
+// writeText writes a "+host", "-host" or "~host" line per host with its ports
+func (diff *Diff) writeText(writer io.Writer) error {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "--- %s\n+++ %s\n", diff.Old, diff.New)
+	for _, change := range diff.AddedHosts {
+		fmt.Fprintf(&builder, "+ %s %s\n", change.Host, strings.Join(change.labels(change.Ports), ","))
+	}
+	for _, change := range diff.RemovedHosts {
+		fmt.Fprintf(&builder, "- %s %s\n", change.Host, strings.Join(change.labels(change.Ports), ","))
+	}
+	for _, change := range diff.ChangedHosts {
+		fmt.Fprintf(&builder, "~ %s %s\n", change.Host, change.ports())
+	}
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
+
+// writeMarkdown writes a section with a table per kind of change, omitting the empty ones
+func (diff *Diff) writeMarkdown(writer io.Writer) error {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "# Scan comparison\n\n`%s` → `%s`: %d added, %d removed, %d changed hosts\n",
+		diff.Old, diff.New, len(diff.AddedHosts), len(diff.RemovedHosts), len(diff.ChangedHosts))
+	for _, section := range []struct {
+		title   string
+		changes []*HostChange
+	}{
+		{"Added hosts", diff.AddedHosts},
+		{"Removed hosts", diff.RemovedHosts},
+	} {
+		if len(section.changes) == 0 {
+			continue
+		}
+		fmt.Fprintf(&builder, "\n## %s\n\n| Host | Ports |\n| --- | --- |\n", section.title)
+		for _, change := range section.changes {
+			fmt.Fprintf(&builder, "| %s | %s |\n", change.Host, strings.Join(change.labels(change.Ports), ", "))
+		}
+	}
+	if len(diff.ChangedHosts) > 0 {
+		builder.WriteString("\n## Changed hosts\n\n| Host | Opened | Closed |\n| --- | --- | --- |\n")
+		for _, change := range diff.ChangedHosts {
+			fmt.Fprintf(&builder, "| %s | %s | %s |\n", change.Host, strings.Join(change.labels(change.Opened), ", "), strings.Join(change.labels(change.Closed), ", "))
+		}
+	}
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
+
+// ports formats the opened and closed ports as "+443/tcp,-22/tcp"
+func (change *HostChange) ports() string {
+	ports := make([]string, 0, len(change.Opened)+len(change.Closed))
+	for _, port := range change.labels(change.Opened) {
+		ports = append(ports, "+"+port)
+	}
+	for _, port := range change.labels(change.Closed) {
+		ports = append(ports, "-"+port)
+	}
+	return strings.Join(ports, ",")
+}
+
+// labels returns the ports followed by their note if annotated, as "3389/tcp (approved exposure)"
+func (change *HostChange) labels(ports []string) []string {
+	if len(change.Notes) == 0 {
+		return ports
+	}
+	labels := make([]string, len(ports))
+	for i, port := range ports {
+		labels[i] = port
+		if note, ok := change.Notes[port]; ok {
+			labels[i] = fmt.Sprintf("%s (%s)", port, note)
+		}
+	}
+	return labels
+}
+
+// hostPorts returns the open ports of each host (hostname or ip) of the scan
+func hostPorts(scan *Scan) map[string]map[string]struct{} {
+	hosts := make(map[string]map[string]struct{})
+	for _, record := range scan.Records {
+		name := record.Name()
+		if hosts[name] == nil {
+			hosts[name] = make(map[string]struct{})
+		}
+		hosts[name][fmt.Sprintf("%d/%s", record.Port, record.Protocol)] = struct{}{}
+	}
+	return hosts
+}
+
+func union(a, b map[string]map[string]struct{}) map[string]struct{} {
+	keys := make(map[string]struct{}, len(a)+len(b))
+	for key := range a {
+		keys[key] = struct{}{}
+	}
+	for key := range b {
+		keys[key] = struct{}{}
+	}
+	return keys
+}
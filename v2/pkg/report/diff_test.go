@@ -0,0 +1,63 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompare(t *testing.T) {
+	previous, err := LoadScan("old.json", strings.NewReader(`{"ip":"10.0.0.1","port":22,"protocol":"tcp","timestamp":"2023-01-01T00:00:00Z"}
+{"ip":"10.0.0.1","port":80,"protocol":"tcp","timestamp":"2023-01-01T00:00:00Z"}
+{"ip":"10.0.0.2","port":3389,"protocol":"tcp","timestamp":"2023-01-01T00:00:00Z"}
+{"ip":"10.0.0.3","port":443,"protocol":"tcp","timestamp":"2023-01-01T00:00:00Z"}
+`))
+	require.Nil(t, err)
+	current, err := LoadScan("new.json", strings.NewReader(`{"ip":"10.0.0.1","port":22,"protocol":"tcp","timestamp":"2023-02-01T00:00:00Z"}
+{"ip":"10.0.0.1","port":443,"protocol":"tcp","timestamp":"2023-02-01T00:00:00Z"}
+{"ip":"10.0.0.3","port":443,"protocol":"tcp","timestamp":"2023-02-01T00:00:00Z"}
+{"host":"new.example.com","ip":"10.0.0.4","port":53,"protocol":"udp","timestamp":"2023-02-01T00:00:00Z"}
+`))
+	require.Nil(t, err)
+
+	diff := Compare(previous, current)
+	require.False(t, diff.Empty())
+	require.Equal(t, []*HostChange{{Host: "new.example.com", Ports: []string{"53/udp"}}}, diff.AddedHosts)
+	require.Equal(t, []*HostChange{{Host: "10.0.0.2", Ports: []string{"3389/tcp"}}}, diff.RemovedHosts)
+	require.Equal(t, []*HostChange{{Host: "10.0.0.1", Opened: []string{"443/tcp"}, Closed: []string{"80/tcp"}}}, diff.ChangedHosts)
+	require.True(t, Compare(previous, previous).Empty())
+
+	var buffer bytes.Buffer
+	require.Nil(t, diff.Write(DiffText, &buffer))
+	require.Equal(t, "--- old.json\n+++ new.json\n+ new.example.com 53/udp\n- 10.0.0.2 3389/tcp\n~ 10.0.0.1 +443/tcp,-80/tcp\n", buffer.String())
+
+	buffer.Reset()
+	require.Nil(t, diff.Write(DiffMarkdown, &buffer))
+	require.Contains(t, buffer.String(), "1 added, 1 removed, 1 changed hosts")
+	require.Contains(t, buffer.String(), "| 10.0.0.1 | 443/tcp | 80/tcp |\n")
+
+	buffer.Reset()
+	require.Nil(t, diff.Write(DiffJSON, &buffer))
+	decoded := &Diff{}
+	require.Nil(t, json.Unmarshal(buffer.Bytes(), decoded))
+	require.Equal(t, diff, decoded)
+
+	require.NotNil(t, diff.Write("xml", &buffer))
+}
+
+func TestCompareNormalizedAddresses(t *testing.T) {
+	previous, err := LoadScan("v6.json", strings.NewReader(`{"ip":"::ffff:10.0.0.1","port":22,"protocol":"tcp","timestamp":"2023-01-01T00:00:00Z"}
+{"ip":"2001:DB8:0:0::1","port":443,"protocol":"tcp","timestamp":"2023-01-01T00:00:00Z"}
+`))
+	require.Nil(t, err)
+	require.Equal(t, "ipv4", previous.Records[0].Family)
+	require.Equal(t, "ipv6", previous.Records[1].Family)
+	current, err := LoadScan("v4.json", strings.NewReader(`{"ip":"10.0.0.1","family":"ipv4","port":22,"protocol":"tcp","timestamp":"2023-02-01T00:00:00Z"}
+{"ip":"2001:db8::1","family":"ipv6","port":443,"protocol":"tcp","timestamp":"2023-02-01T00:00:00Z"}
+`))
+	require.Nil(t, err)
+	require.True(t, Compare(previous, current).Empty())
+}
@@ -0,0 +1,193 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Finding states and events of the tracked exposure
+const (
+	FindingOpen    = "open"
+	FindingExpired = "expired"
+	EventOpened    = "opened"
+	EventClosed    = "closed"
+)
+
+// Finding is an open port of a host tracked across the monitoring cycles
+type Finding struct {
+	Host      string     `json:"host"`
+	IP        string     `json:"ip,omitempty"`
+	Port      int        `json:"port"`
+	Protocol  string     `json:"protocol"`
+	State     string     `json:"state"`
+	FirstSeen time.Time  `json:"first_seen"`
+	LastSeen  time.Time  `json:"last_seen"`
+	Missed    int        `json:"missed_cycles"`
+	ExpiredAt *time.Time `json:"expired_at,omitempty"`
+}
+
+// key returns the host and port/protocol of the finding
+func (finding *Finding) key() string {
+	return fmt.Sprintf("%s %d/%s", finding.Host, finding.Port, finding.Protocol)
+}
+
+// ExposureEvent is a finding opened or closed by a monitoring cycle
+type ExposureEvent struct {
+	Event string    `json:"event"`
+	Time  time.Time `json:"timestamp"`
+	*Finding
+}
+
+// Exposure is the state of the findings of successive scans, the findings not observed for a number
+// of cycles expiring so that the state converges with the actual exposure
+type Exposure struct {
+	Cycles   int        `json:"cycles"`
+	Findings []*Finding `json:"findings"`
+}
+
+// LoadExposure reads the exposure state of the json file, a missing file has no findings
+func LoadExposure(path string) (*Exposure, error) {
+	exposure := &Exposure{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return exposure, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, exposure); err != nil {
+		return nil, fmt.Errorf("invalid exposure state %s: %w", path, err)
+	}
+	return exposure, nil
+}
+
+// Observe records the scan as a new cycle: the findings of the scan are opened or refreshed, and
+// the open findings missing from ttl consecutive cycles expire. It returns the opened and closed
+// findings, at the scan time or now if unknown. A scan without results, usually a failed run, isn't
+// a cycle and would otherwise expire every finding
+func (exposure *Exposure) Observe(scan *Scan, ttl int, now time.Time) []*ExposureEvent {
+	if len(scan.Records) == 0 {
+		return nil
+	}
+	at := scan.Time
+	if at.IsZero() {
+		at = now
+	}
+	exposure.Cycles++
+
+	findings := make(map[string]*Finding, len(exposure.Findings))
+	for _, finding := range exposure.Findings {
+		findings[finding.key()] = finding
+	}
+	var events []*ExposureEvent
+	observed := make(map[string]struct{})
+	for _, record := range scan.Records {
+		seen := &Finding{Host: record.Name(), IP: record.IP, Port: record.Port, Protocol: record.Protocol}
+		key := seen.key()
+		if _, ok := observed[key]; ok {
+			continue
+		}
+		observed[key] = struct{}{}
+
+		finding, ok := findings[key]
+		switch {
+		case !ok:
+			finding = seen
+			finding.FirstSeen = at
+			findings[key] = finding
+			exposure.Findings = append(exposure.Findings, finding)
+		case finding.State == FindingExpired:
+			// a reopened port starts a new exposure
+			finding.FirstSeen = at
+			finding.ExpiredAt = nil
+		}
+		opened := finding.State != FindingOpen
+		finding.State = FindingOpen
+		finding.LastSeen = at
+		finding.Missed = 0
+		if record.IP != "" {
+			finding.IP = record.IP
+		}
+		if opened {
+			events = append(events, &ExposureEvent{Event: EventOpened, Time: at, Finding: finding})
+		}
+	}
+	for key, finding := range findings {
+		if _, ok := observed[key]; ok || finding.State != FindingOpen {
+			continue
+		}
+		finding.Missed++
+		if finding.Missed >= ttl {
+			expiredAt := at
+			finding.State = FindingExpired
+			finding.ExpiredAt = &expiredAt
+			events = append(events, &ExposureEvent{Event: EventClosed, Time: at, Finding: finding})
+		}
+	}
+
+	exposure.sort()
+	sort.SliceStable(events, func(i, j int) bool {
+		return lessFinding(events[i].Finding, events[j].Finding)
+	})
+	return events
+}
+
+// Open returns the findings currently open
+func (exposure *Exposure) Open() []*Finding {
+	var open []*Finding
+	for _, finding := range exposure.Findings {
+		if finding.State == FindingOpen {
+			open = append(open, finding)
+		}
+	}
+	return open
+}
+
+func (exposure *Exposure) sort() {
+	sort.SliceStable(exposure.Findings, func(i, j int) bool {
+		return lessFinding(exposure.Findings[i], exposure.Findings[j])
+	})
+}
+
+func lessFinding(a, b *Finding) bool {
+	if a.Host != b.Host {
+		return a.Host < b.Host
+	}
+	if a.Port != b.Port {
+		return a.Port < b.Port
+	}
+	return a.Protocol < b.Protocol
+}
+
+// Save writes the exposure state to the json file, through a temporary file renamed over it so
+// that an interrupted write never leaves a truncated state
+func (exposure *Exposure) Save(path string) error {
+	data, err := json.MarshalIndent(exposure, "", "  ")
+	if err != nil {
+		return err
+	}
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(append(data, '\n')); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(temp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
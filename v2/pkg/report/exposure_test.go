@@ -0,0 +1,74 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExposure(t *testing.T) {
+	scan := func(results string) *Scan {
+		s, err := LoadScan("scan.json", strings.NewReader(results))
+		require.Nil(t, err)
+		return s
+	}
+	now := time.Date(2023, 1, 10, 0, 0, 0, 0, time.UTC)
+	exposure := &Exposure{}
+
+	events := exposure.Observe(scan(`{"ip":"10.0.0.1","port":22,"protocol":"tcp","timestamp":"2023-01-01T00:00:00Z"}
+{"ip":"10.0.0.1","port":3389,"protocol":"tcp","timestamp":"2023-01-01T00:00:00Z"}
+`), 2, now)
+	require.Len(t, events, 2)
+	require.Equal(t, EventOpened, events[0].Event)
+	require.Equal(t, 22, events[0].Port)
+
+	// a single missed cycle keeps the finding open
+	events = exposure.Observe(scan(`{"ip":"10.0.0.1","port":22,"protocol":"tcp","timestamp":"2023-01-02T00:00:00Z"}
+`), 2, now)
+	require.Empty(t, events)
+	require.Len(t, exposure.Open(), 2)
+
+	// a scan without results isn't a cycle
+	require.Empty(t, exposure.Observe(scan(""), 2, now))
+	require.Equal(t, 2, exposure.Cycles)
+	require.Len(t, exposure.Open(), 2)
+
+	// the second missed cycle expires it
+	events = exposure.Observe(scan(`{"ip":"10.0.0.1","port":22,"protocol":"tcp","timestamp":"2023-01-03T00:00:00Z"}
+`), 2, now)
+	require.Len(t, events, 1)
+	require.Equal(t, EventClosed, events[0].Event)
+	require.Equal(t, 3389, events[0].Port)
+	require.Equal(t, time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC), *events[0].ExpiredAt)
+	require.Len(t, exposure.Open(), 1)
+
+	// a reopened port is a new exposure
+	events = exposure.Observe(scan(`{"ip":"10.0.0.1","port":22,"protocol":"tcp","timestamp":"2023-01-11T00:00:00Z"}
+{"ip":"10.0.0.1","port":3389,"protocol":"tcp","timestamp":"2023-01-11T00:00:00Z"}
+`), 2, now)
+	require.Len(t, events, 1)
+	require.Equal(t, EventOpened, events[0].Event)
+	require.Equal(t, time.Date(2023, 1, 11, 0, 0, 0, 0, time.UTC), events[0].FirstSeen)
+	require.Nil(t, events[0].ExpiredAt)
+	require.Equal(t, 4, exposure.Cycles)
+
+	path := filepath.Join(t.TempDir(), "exposure.json")
+	require.Nil(t, exposure.Save(path))
+	require.Nil(t, exposure.Save(path))
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.Nil(t, err)
+	require.Len(t, entries, 1)
+	loaded, err := LoadExposure(path)
+	require.Nil(t, err)
+	require.Equal(t, exposure.Cycles, loaded.Cycles)
+	require.Len(t, loaded.Findings, 2)
+	require.Equal(t, FindingOpen, loaded.Findings[1].State)
+
+	missing, err := LoadExposure(filepath.Join(t.TempDir(), "missing.json"))
+	require.Nil(t, err)
+	require.Empty(t, missing.Findings)
+}
@@ -0,0 +1,145 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Scan is the set of results of a single naabu run
+type Scan struct {
+	Source  string
+	Time    time.Time
+	Records []*Record
+}
+
+// LoadScan reads the json results of a run, the scan time is the earliest result timestamp
+func LoadScan(source string, reader io.Reader) (*Scan, error) {
+	records, err := Load(reader)
+	if err != nil {
+		return nil, err
+	}
+	scan := &Scan{Source: source, Records: records}
+	for _, record := range records {
+		if scan.Time.IsZero() || record.TimeStamp.Before(scan.Time) {
+			scan.Time = record.TimeStamp
+		}
+	}
+	return scan, nil
+}
+
+// SortScans orders the scans chronologically
+func SortScans(scans []*Scan) {
+	sort.SliceStable(scans, func(i, j int) bool {
+		return scans[i].Time.Before(scans[j].Time)
+	})
+}
+
+// Snapshot is the exposure observed in a scan along with the changes since the previous one
+type Snapshot struct {
+	Scan    *Scan
+	Items   []string
+	Opened  []string
+	Closed  []string
+	Changed bool
+}
+
+// HostHistory returns the open ports of the host (hostname or ip) in each scan
+func HostHistory(scans []*Scan, host string) []*Snapshot {
+	return history(scans, func(scan *Scan) []string {
+		var ports []string
+		for _, record := range scan.Records {
+			if record.Host == host || record.IP == host {
+				ports = append(ports, fmt.Sprintf("%d/%s", record.Port, record.Protocol))
+			}
+		}
+		return ports
+	})
+}
+
+// PortTrend returns the hosts exposing the port in each scan performed after since
+func PortTrend(scans []*Scan, port int, since time.Time) []*Snapshot {
+	var recent []*Scan
+	for _, scan := range scans {
+		if !scan.Time.Before(since) {
+			recent = append(recent, scan)
+		}
+	}
+	return history(recent, func(scan *Scan) []string {
+		var hosts []string
+		for _, record := range scan.Records {
+			if record.Port == port {
+				hosts = append(hosts, record.Name())
+			}
+		}
+		return hosts
+	})
+}
+
+func history(scans []*Scan, items func(scan *Scan) []string) []*Snapshot {
+	var snapshots []*Snapshot
+	var previous map[string]struct{}
+	for _, scan := range scans {
+		current := make(map[string]struct{})
+		for _, item := range items(scan) {
+			current[item] = struct{}{}
+		}
+		snapshot := &Snapshot{Scan: scan, Items: sortedKeys(current)}
+		if previous != nil {
+			snapshot.Opened = difference(current, previous)
+			snapshot.Closed = difference(previous, current)
+			snapshot.Changed = len(snapshot.Opened) > 0 || len(snapshot.Closed) > 0
+		}
+		snapshots = append(snapshots, snapshot)
+		previous = current
+	}
+	return snapshots
+}
+
+func difference(a, b map[string]struct{}) []string {
+	diff := make(map[string]struct{})
+	for item := range a {
+		if _, ok := b[item]; !ok {
+			diff[item] = struct{}{}
+		}
+	}
+	return sortedKeys(diff)
+}
+
+func sortedKeys(items map[string]struct{}) []string {
+	keys := make([]string, 0, len(items))
+	for item := range items {
+		keys = append(keys, item)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// String formats the snapshot as "time source items (+opened -closed)"
+func (snapshot *Snapshot) String() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%s  %s  %d [%s]", snapshot.Scan.Time.Format("2006-01-02 15:04"), snapshot.Scan.Source, len(snapshot.Items), strings.Join(snapshot.Items, ","))
+	if snapshot.Changed {
+		fmt.Fprintf(&builder, " (+%s -%s)", strings.Join(snapshot.Opened, ","), strings.Join(snapshot.Closed, ","))
+	}
+	return builder.String()
+}
+
+// ParseSince parses a duration also accepting days (30d) and returns the time elapsed since now
+func ParseSince(value string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		count, err := strconv.Atoi(days)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid duration %s", value)
+		}
+		return now.AddDate(0, 0, -count), nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return now.Add(-duration), nil
+}
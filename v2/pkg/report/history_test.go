@@ -0,0 +1,48 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	first, err := LoadScan("first.json", strings.NewReader(`{"ip":"10.0.0.1","port":22,"protocol":"tcp","timestamp":"2023-01-01T00:00:00Z"}
+{"ip":"10.0.0.1","port":3389,"protocol":"tcp","timestamp":"2023-01-01T00:01:00Z"}
+`))
+	require.Nil(t, err)
+	second, err := LoadScan("second.json", strings.NewReader(`{"ip":"10.0.0.1","port":22,"protocol":"tcp","timestamp":"2023-02-01T00:00:00Z"}
+{"host":"rdp.example.com","ip":"10.0.0.2","port":3389,"protocol":"tcp","timestamp":"2023-02-01T00:00:00Z"}
+`))
+	require.Nil(t, err)
+	scans := []*Scan{second, first}
+	SortScans(scans)
+	require.Equal(t, "first.json", scans[0].Source)
+	require.Equal(t, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), scans[0].Time)
+
+	hostHistory := HostHistory(scans, "10.0.0.1")
+	require.Len(t, hostHistory, 2)
+	require.Equal(t, []string{"22/tcp", "3389/tcp"}, hostHistory[0].Items)
+	require.Equal(t, []string{"3389/tcp"}, hostHistory[1].Closed)
+	require.Equal(t, "2023-02-01 00:00  second.json  1 [22/tcp] (+ -3389/tcp)", hostHistory[1].String())
+
+	portTrend := PortTrend(scans, 3389, time.Date(2023, 1, 15, 0, 0, 0, 0, time.UTC))
+	require.Len(t, portTrend, 1)
+	require.Equal(t, []string{"rdp.example.com"}, portTrend[0].Items)
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2023, 3, 31, 0, 0, 0, 0, time.UTC)
+	since, err := ParseSince("30d", now)
+	require.Nil(t, err)
+	require.Equal(t, time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC), since)
+
+	since, err = ParseSince("12h", now)
+	require.Nil(t, err)
+	require.Equal(t, now.Add(-12*time.Hour), since)
+
+	_, err = ParseSince("xd", now)
+	require.NotNil(t, err)
+}
@@ -0,0 +1,209 @@
+package report
+
+import (
+	"bufio"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+	"github.com/projectdiscovery/naabu/v2/pkg/schema"
+)
+
+//go:embed default.html
+var defaultTemplate string
+
+// Record is an open port as written by naabu json output
+type Record struct {
+	Host      string    `json:"host,omitempty"`
+	IP        string    `json:"ip"`
+	Family    string    `json:"family,omitempty"`
+	Port      int       `json:"port"`
+	Protocol  string    `json:"protocol"`
+	TLS       bool      `json:"tls"`
+	IsCDNIP   bool      `json:"cdn,omitempty"`
+	CDNName   string    `json:"cdn-name,omitempty"`
+	Banner    string    `json:"banner,omitempty"`
+	Detected  string    `json:"service,omitempty"`
+	Version   string    `json:"version,omitempty"`
+	Tags      []string  `json:"tags,omitempty"`
+	TimeStamp time.Time `json:"timestamp"`
+}
+
+// Name returns the hostname of the record or its ip
+func (record *Record) Name() string {
+	if record.Host != "" {
+		return record.Host
+	}
+	return record.IP
+}
+
+// Service returns the service detected on the port, its well known service name or port/protocol
+func (record *Record) Service() string {
+	if record.Detected != "" {
+		return record.Detected
+	}
+	portProtocol := protocol.TCP
+	if record.Protocol == protocol.UDP.String() {
+		portProtocol = protocol.UDP
+	}
+	if name, ok := port.ServiceName(record.Port, portProtocol); ok {
+		return name
+	}
+	return fmt.Sprintf("%d/%s", record.Port, record.Protocol)
+}
+
+// HostSummary aggregates the open ports of a host
+type HostSummary struct {
+	Name    string
+	IP      string
+	Records []*Record
+	Percent float64
+}
+
+// ServiceSummary is the number of open ports exposing a service or having a tag
+type ServiceSummary struct {
+	Name    string
+	Count   int
+	Percent float64
+}
+
+// Report is the data available to report templates
+type Report struct {
+	Title       string
+	GeneratedAt time.Time
+	Records     []*Record
+	Hosts       []*HostSummary
+	Services    []*ServiceSummary
+	Tags        []*ServiceSummary
+}
+
+// Load reads json lines results of any schema version, lines without a port (host discovery) are ignored
+// and the addresses are normalized
+func Load(reader io.Reader) ([]*Record, error) {
+	var records []*Record
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		data := strings.TrimSpace(scanner.Text())
+		if data == "" {
+			continue
+		}
+		upgraded, err := schema.Upgrade([]byte(data))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid result at line %d", line)
+		}
+		record := &Record{}
+		if err := json.Unmarshal(upgraded, record); err != nil {
+			return nil, errors.Wrapf(err, "invalid result at line %d", line)
+		}
+		// host discovery records, the results of port 0 having a protocol
+		if record.Protocol == "" {
+			continue
+		}
+		// results of older versions may hold ipv4-mapped or uncompressed ipv6 addresses
+		record.Host, record.IP = result.NormalizeIP(record.Host), result.NormalizeIP(record.IP)
+		if record.Family == "" {
+			record.Family = result.Family(record.IP)
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// New summarizes the records by host, by service and by tag
+func New(title string, records []*Record) *Report {
+	report := &Report{Title: title, GeneratedAt: time.Now().UTC(), Records: records}
+
+	hosts := make(map[string]*HostSummary)
+	services := make(map[string]int)
+	tags := make(map[string]int)
+	for _, record := range records {
+		key := record.Name() + "|" + record.IP
+		if _, ok := hosts[key]; !ok {
+			hosts[key] = &HostSummary{Name: record.Name(), IP: record.IP}
+		}
+		hosts[key].Records = append(hosts[key].Records, record)
+
+		services[record.Service()]++
+		for _, tag := range record.Tags {
+			tags[tag]++
+		}
+	}
+
+	var maxPorts int
+	for _, host := range hosts {
+		sort.Slice(host.Records, func(i, j int) bool {
+			return host.Records[i].Port < host.Records[j].Port
+		})
+		if len(host.Records) > maxPorts {
+			maxPorts = len(host.Records)
+		}
+		report.Hosts = append(report.Hosts, host)
+	}
+	for _, host := range report.Hosts {
+		host.Percent = percent(len(host.Records), maxPorts)
+	}
+	sort.Slice(report.Hosts, func(i, j int) bool {
+		if len(report.Hosts[i].Records) == len(report.Hosts[j].Records) {
+			return report.Hosts[i].Name < report.Hosts[j].Name
+		}
+		return len(report.Hosts[i].Records) > len(report.Hosts[j].Records)
+	})
+
+	report.Services = summarize(services)
+	report.Tags = summarize(tags)
+
+	return report
+}
+
+// summarize returns the summaries of the counts, the largest first
+func summarize(counts map[string]int) []*ServiceSummary {
+	var (
+		summaries []*ServiceSummary
+		maxCount  int
+	)
+	for name, count := range counts {
+		if count > maxCount {
+			maxCount = count
+		}
+		summaries = append(summaries, &ServiceSummary{Name: name, Count: count})
+	}
+	for _, summary := range summaries {
+		summary.Percent = percent(summary.Count, maxCount)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Count == summaries[j].Count {
+			return summaries[i].Name < summaries[j].Name
+		}
+		return summaries[i].Count > summaries[j].Count
+	})
+	return summaries
+}
+
+func percent(value, max int) float64 {
+	if max == 0 {
+		return 0
+	}
+	return float64(value) * 100 / float64(max)
+}
+
+// Render executes the template (the default html report if empty) with the report data
+func (report *Report) Render(templateData string, writer io.Writer) error {
+	if templateData == "" {
+		templateData = defaultTemplate
+	}
+	tpl, err := template.New("report").Parse(templateData)
+	if err != nil {
+		return errors.Wrap(err, "could not parse report template")
+	}
+	return tpl.Execute(writer, report)
+}
@@ -0,0 +1,50 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const results = `{"host":"scanme.sh","ip":"45.33.32.156","port":22,"protocol":"tcp","tls":false,"timestamp":"2023-01-01T00:00:00Z"}
+{"host":"scanme.sh","ip":"45.33.32.156","port":80,"protocol":"tcp","tls":false,"tags":["cloud:linode"],"timestamp":"2023-01-01T00:00:00Z"}
+{"ip":"10.0.0.1","port":22,"protocol":"tcp","tls":false,"banner":"SSH-2.0-OpenSSH_8.9","timestamp":"2023-01-01T00:00:00Z"}
+{"ip":"10.0.0.2","timestamp":"2023-01-01T00:00:00Z"}
+`
+
+func TestLoad(t *testing.T) {
+	records, err := Load(strings.NewReader(results))
+	require.Nil(t, err)
+	require.Len(t, records, 3)
+	require.Equal(t, "scanme.sh", records[0].Name())
+	require.Equal(t, "10.0.0.1", records[2].Name())
+
+	_, err = Load(strings.NewReader("not json"))
+	require.NotNil(t, err)
+}
+
+func TestReport(t *testing.T) {
+	records, err := Load(strings.NewReader(results))
+	require.Nil(t, err)
+
+	report := New("Engagement", records)
+	require.Len(t, report.Hosts, 2)
+	require.Equal(t, "scanme.sh", report.Hosts[0].Name)
+	require.Equal(t, float64(100), report.Hosts[0].Percent)
+	require.Equal(t, float64(50), report.Hosts[1].Percent)
+	require.Equal(t, "ssh", report.Services[0].Name)
+	require.Equal(t, 2, report.Services[0].Count)
+	require.Equal(t, []*ServiceSummary{{Name: "cloud:linode", Count: 1, Percent: 100}}, report.Tags)
+
+	buf := bytes.Buffer{}
+	require.Nil(t, report.Render("", &buf))
+	require.Contains(t, buf.String(), "<title>Engagement</title>")
+	require.Contains(t, buf.String(), "SSH-2.0-OpenSSH_8.9")
+	require.Contains(t, buf.String(), "Open ports by tag")
+
+	buf.Reset()
+	require.Nil(t, report.Render(`{{ range .Services }}{{ .Name }}={{ .Count }} {{ end }}`, &buf))
+	require.Equal(t, "ssh=2 http=1 ", buf.String())
+}
@@ -0,0 +1,35 @@
+package result
+
+import "net/netip"
+
+// Address families of the results
+const (
+	FamilyIPv4 = "ipv4"
+	FamilyIPv6 = "ipv6"
+)
+
+// NormalizeIP returns the canonical notation of the ip, ipv4-mapped ipv6 addresses (::ffff:10.0.0.1)
+// being written as ipv4 and ipv6 ones compressed in lowercase, so that the same address is deduplicated
+// and compared consistently whatever the ip version it was scanned with. 6to4 and teredo addresses
+// are distinct ipv6 hosts (relays, tunnel endpoints) and are only compressed. Other values, and
+// scoped addresses, are unchanged
+func NormalizeIP(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil || addr.Zone() != "" {
+		return ip
+	}
+	return addr.Unmap().String()
+}
+
+// Family returns the address family of the ip (ipv4/ipv6), empty if it isn't an ip
+func Family(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	switch {
+	case err != nil:
+		return ""
+	case addr.Unmap().Is4():
+		return FamilyIPv4
+	default:
+		return FamilyIPv6
+	}
+}
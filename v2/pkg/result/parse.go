@@ -0,0 +1,389 @@
+package result
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/projectdiscovery/naabu/v2/pkg/schema"
+)
+
+var (
+	// portGroupLine is a line of the text output grouped by port (443/tcp: a.example.com,b.example.com)
+	portGroupLine = regexp.MustCompile(`^(\d+)/([a-z]+): (.*)$`)
+	// hostGroupLine is a line of the text output grouped by host, followed by the ports of each
+	// address of hostnames having several (a.example.com: 80,443 (10.0.0.1: 80,443; 2001:db8::1: 443))
+	hostGroupLine = regexp.MustCompile(`^(\S+): (\d+(?:,\d+)*)(?: \((.*)\))?$`)
+	// cdnSuffix is the cdn name appended to the text output lines (a.example.com:443 [cloudflare])
+	cdnSuffix = regexp.MustCompile(` \[[^\]]*\]$`)
+)
+
+// record is a json line of the results, the grouped outputs holding ports or hosts
+type record struct {
+	Host         string     `json:"host"`
+	IP           string     `json:"ip"`
+	Port         int        `json:"port"`
+	Protocol     string     `json:"protocol"`
+	TLS          bool       `json:"tls"`
+	Banner       string     `json:"banner"`
+	Service      string     `json:"service"`
+	Version      string     `json:"version"`
+	Responder    string     `json:"responder"`
+	ResponseTime float64    `json:"response_time_ms"`
+	Severity     string     `json:"severity"`
+	Risk         string     `json:"risk"`
+	Path         *port.Path `json:"path"`
+	Ports        []int      `json:"ports"`
+	Hosts        []string   `json:"hosts"`
+	Addresses    []struct {
+		IP    string `json:"ip"`
+		Ports []int  `json:"ports"`
+	} `json:"ips"`
+}
+
+// parsedResults groups the parsed ports by host and ip in the order they were first seen
+type parsedResults struct {
+	results []*HostResult
+	index   map[string]*HostResult
+	ports   map[string]struct{}
+}
+
+// add records the port of the host, a nil port only recording the host
+func (parsed *parsedResults) add(host, ip string, p *port.Port) error {
+	if host == "" && ip == "" {
+		return errors.New("no host or ip")
+	}
+	if ip != "" {
+		if _, err := netip.ParseAddr(ip); err != nil {
+			return fmt.Errorf("invalid ip %s", ip)
+		}
+		ip = NormalizeIP(ip)
+	} else if _, err := netip.ParseAddr(host); err == nil {
+		ip = NormalizeIP(host)
+	}
+	if host == "" {
+		host = ip
+	}
+	if p != nil && (p.Port < 0 || p.Port > 65535) {
+		return fmt.Errorf("invalid port %d", p.Port)
+	}
+
+	key := host + " " + ip
+	hostResult, ok := parsed.index[key]
+	if !ok {
+		hostResult = &HostResult{Host: host, IP: ip}
+		parsed.index[key] = hostResult
+		parsed.results = append(parsed.results, hostResult)
+	}
+	if p == nil {
+		return nil
+	}
+	portKey := fmt.Sprintf("%s %d/%s", key, p.Port, p.Protocol)
+	if _, ok := parsed.ports[portKey]; !ok {
+		parsed.ports[portKey] = struct{}{}
+		hostResult.Ports = append(hostResult.Ports, p)
+	}
+	return nil
+}
+
+// ParseResults reads the results written by naabu in any of its output formats: json lines of any
+// schema version, csv, host:port lines, lists of hosts and the outputs grouped by host or port.
+// The ports of each host are returned once, with the hosts in the order they first appear
+func ParseResults(r io.Reader) ([]HostResult, error) {
+	reader := bufio.NewReader(r)
+	parsed := &parsedResults{index: make(map[string]*HostResult), ports: make(map[string]struct{})}
+
+	first, err := peekLine(reader)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case strings.HasPrefix(first, "{"):
+		err = parsed.parseJSON(reader)
+	case isCSVHeader(first):
+		err = parsed.parseCSV(reader)
+	default:
+		err = parsed.parseText(reader)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]HostResult, 0, len(parsed.results))
+	for _, hostResult := range parsed.results {
+		results = append(results, *hostResult)
+	}
+	return results, nil
+}
+
+// peekLine returns the first non empty line without consuming the reader
+func peekLine(reader *bufio.Reader) (string, error) {
+	for size := 512; ; size *= 2 {
+		data, err := reader.Peek(size)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return "", err
+		}
+		text := strings.TrimLeft(string(data), " \t\r\n")
+		if line, _, found := strings.Cut(text, "\n"); found || err != nil || size >= 64*1024 {
+			return strings.TrimSpace(line), nil
+		}
+	}
+}
+
+// isCSVHeader returns true if the line is the header of the csv output
+func isCSVHeader(line string) bool {
+	columns := strings.Split(line, ",")
+	for _, column := range columns {
+		if column == "ip" || column == "port" {
+			return len(columns) > 1
+		}
+	}
+	return false
+}
+
+func newLineScanner(reader io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	return scanner
+}
+
+// parseJSON reads json lines of results, host discovery and grouped results
+func (parsed *parsedResults) parseJSON(reader io.Reader) error {
+	scanner := newLineScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		data := strings.TrimSpace(scanner.Text())
+		if data == "" {
+			continue
+		}
+		if err := parsed.addJSON([]byte(data)); err != nil {
+			return errors.Wrapf(err, "invalid result at line %d", line)
+		}
+	}
+	return scanner.Err()
+}
+
+func (parsed *parsedResults) addJSON(data []byte) error {
+	upgraded, err := schema.Upgrade(data)
+	if err != nil {
+		return err
+	}
+	rec := &record{}
+	if err := json.Unmarshal(upgraded, rec); err != nil {
+		return err
+	}
+	// grouped by port
+	if len(rec.Hosts) > 0 {
+		proto, err := parseProtocol(rec.Protocol)
+		if err != nil {
+			return err
+		}
+		for _, host := range rec.Hosts {
+			if err := parsed.add(host, "", &port.Port{Port: rec.Port, Protocol: proto}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	// grouped by host, the tcp ports only being written with their number
+	for _, address := range rec.Addresses {
+		for _, number := range address.Ports {
+			if err := parsed.add(rec.Host, address.IP, &port.Port{Port: number, Protocol: protocol.TCP}); err != nil {
+				return err
+			}
+		}
+	}
+	if len(rec.Addresses) > 0 {
+		return nil
+	}
+	if rec.Ports != nil {
+		for _, number := range rec.Ports {
+			if err := parsed.add(rec.Host, rec.IP, &port.Port{Port: number, Protocol: protocol.TCP}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	// host discovery, the results of port 0 having a protocol
+	if rec.Protocol == "" {
+		return parsed.add(rec.Host, rec.IP, nil)
+	}
+	proto, err := parseProtocol(rec.Protocol)
+	if err != nil {
+		return err
+	}
+	return parsed.add(rec.Host, rec.IP, &port.Port{
+		Port:      rec.Port,
+		Protocol:  proto,
+		TLS:       rec.TLS,
+		Banner:    rec.Banner,
+		Service:   rec.Service,
+		Version:   rec.Version,
+		Responder: rec.Responder,
+		RTT:       time.Duration(rec.ResponseTime * float64(time.Millisecond)),
+		Severity:  rec.Severity,
+		Risk:      rec.Risk,
+		Path:      rec.Path,
+	})
+}
+
+// parseCSV reads the csv output, whose port column holds the port, protocol number and tls (443-0-true)
+func (parsed *parsedResults) parseCSV(reader io.Reader) error {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	columns := make(map[string]int)
+	for line := 1; ; line++ {
+		row, err := csvReader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// the header is written again by each host
+		if isCSVHeader(strings.Join(row, ",")) {
+			for i, column := range row {
+				columns[column] = i
+			}
+			continue
+		}
+		if len(columns) == 0 {
+			return fmt.Errorf("invalid result at line %d: no csv header", line)
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(row) {
+				return row[i]
+			}
+			return ""
+		}
+		var p *port.Port
+		if value := field("port"); value != "" {
+			if p, err = parseCSVPort(value); err != nil {
+				return errors.Wrapf(err, "invalid result at line %d", line)
+			}
+		}
+		if err := parsed.add(field("host"), field("ip"), p); err != nil {
+			return errors.Wrapf(err, "invalid result at line %d", line)
+		}
+	}
+}
+
+func parseCSVPort(value string) (*port.Port, error) {
+	parts := strings.Split(value, "-")
+	number, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %s", value)
+	}
+	p := &port.Port{Port: number, Protocol: protocol.TCP}
+	if len(parts) == 3 {
+		proto, err := strconv.Atoi(parts[1])
+		if err != nil || proto < int(protocol.TCP) || proto > int(protocol.ARP) {
+			return nil, fmt.Errorf("invalid port %s", value)
+		}
+		p.Protocol = protocol.Protocol(proto)
+		p.TLS = parts[2] == "true"
+	}
+	return p, nil
+}
+
+// parseText reads host:port lines, optionally followed by the cdn name, lists of hosts and the
+// outputs grouped by host or port
+func (parsed *parsedResults) parseText(reader io.Reader) error {
+	scanner := newLineScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if err := parsed.addText(text); err != nil {
+			return errors.Wrapf(err, "invalid result at line %d", line)
+		}
+	}
+	return scanner.Err()
+}
+
+func (parsed *parsedResults) addText(text string) error {
+	if match := portGroupLine.FindStringSubmatch(text); match != nil {
+		number, _ := strconv.Atoi(match[1])
+		proto, err := parseProtocol(match[2])
+		if err != nil {
+			return err
+		}
+		for _, host := range strings.Split(match[3], ",") {
+			if err := parsed.add(host, "", &port.Port{Port: number, Protocol: proto}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if match := hostGroupLine.FindStringSubmatch(text); match != nil {
+		if match[3] != "" {
+			for _, address := range strings.Split(match[3], "; ") {
+				ip, ports, found := strings.Cut(address, ": ")
+				if !found {
+					return fmt.Errorf("invalid address %s", address)
+				}
+				for _, value := range strings.Split(ports, ",") {
+					number, err := strconv.Atoi(value)
+					if err != nil {
+						return fmt.Errorf("invalid port %s", value)
+					}
+					if err := parsed.add(match[1], ip, &port.Port{Port: number, Protocol: protocol.TCP}); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}
+		for _, value := range strings.Split(match[2], ",") {
+			number, _ := strconv.Atoi(value)
+			if err := parsed.add(match[1], "", &port.Port{Port: number, Protocol: protocol.TCP}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	text = cdnSuffix.ReplaceAllString(text, "")
+	// bare ipv6 addresses are hosts, their last group not being a port
+	if _, err := netip.ParseAddr(text); err == nil {
+		return parsed.add(text, "", nil)
+	}
+	host, value, err := net.SplitHostPort(text)
+	if err != nil {
+		// ipv6 hosts are written without brackets
+		separator := strings.LastIndex(text, ":")
+		if separator < 0 {
+			return parsed.add(text, "", nil)
+		}
+		host, value = text[:separator], text[separator+1:]
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("invalid port %s", value)
+	}
+	return parsed.add(host, "", &port.Port{Port: number, Protocol: protocol.TCP})
+}
+
+func parseProtocol(value string) (protocol.Protocol, error) {
+	switch value {
+	case "", "tcp":
+		return protocol.TCP, nil
+	case "udp":
+		return protocol.UDP, nil
+	case "arp":
+		return protocol.ARP, nil
+	default:
+		return 0, fmt.Errorf("invalid protocol %s (allowed: tcp, udp, arp)", value)
+	}
+}
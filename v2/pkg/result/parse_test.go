@@ -0,0 +1,93 @@
+package result
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseResults(t *testing.T) {
+	parse := func(data string) []HostResult {
+		results, err := ParseResults(strings.NewReader(data))
+		require.Nil(t, err)
+		return results
+	}
+
+	t.Run("json", func(t *testing.T) {
+		results := parse(`{"host":"a.example.com","ip":"10.0.0.1","port":443,"protocol":"tcp","tls":true,"response_time_ms":1.5,"path":{"interface":"eth0","source_ip":"10.0.0.254"},"schema_version":1}
+{"ip":"::ffff:10.0.0.2","port":{"Port":53,"Protocol":1,"TLS":false}}
+{"host":"a.example.com","ip":"10.0.0.1","port":443,"protocol":"tcp","tls":true,"schema_version":1}
+{"ip":"10.0.0.3","timestamp":"2023-01-01T00:00:00Z"}
+{"ip":"10.0.0.4","port":0,"protocol":"tcp","reserved":true,"schema_version":1}
+{"ip":"10.0.0.5","probe":"tcp-syn","port":80,"rtt_ms":1.2,"schema_version":1}
+`)
+		require.Len(t, results, 5)
+		require.Equal(t, 0, results[3].Ports[0].Port)
+		require.Empty(t, results[4].Ports)
+		require.Equal(t, "a.example.com", results[0].Host)
+		require.Len(t, results[0].Ports, 1)
+		require.True(t, results[0].Ports[0].TLS)
+		require.Equal(t, 1500*time.Microsecond, results[0].Ports[0].RTT)
+		require.Equal(t, "eth0", results[0].Ports[0].Path.Interface)
+		require.Equal(t, "10.0.0.2", results[1].IP)
+		require.Equal(t, "10.0.0.2", results[1].Host)
+		require.Equal(t, protocol.UDP, results[1].Ports[0].Protocol)
+		require.Empty(t, results[2].Ports)
+	})
+
+	t.Run("grouped json", func(t *testing.T) {
+		results := parse(`{"port":80,"protocol":"tcp","hosts":["a.example.com","b.example.com"]}
+{"host":"a.example.com","ip":"10.0.0.1","ports":[22]}
+{"host":"c.example.com","ip":"10.0.0.3","ports":[22,80],"ips":[{"ip":"10.0.0.3","family":"ipv4","ports":[22,80]},{"ip":"2001:db8::3","family":"ipv6","ports":[22]}]}
+`)
+		require.Len(t, results, 5)
+		require.Equal(t, "2001:db8::3", results[4].IP)
+		require.Len(t, results[4].Ports, 1)
+		require.Equal(t, "b.example.com", results[1].Host)
+		require.Equal(t, 22, results[2].Ports[0].Port)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		results := parse(`host,ip,port,timestamp,family
+a.example.com,10.0.0.1,443-0-true,2023-01-01 00:00:00 +0000 UTC,ipv4
+host,ip,port,timestamp,family
+b.example.com,10.0.0.2,53-1-false,2023-01-01 00:00:00 +0000 UTC,ipv4
+`)
+		require.Len(t, results, 2)
+		require.True(t, results[0].Ports[0].TLS)
+		require.Equal(t, protocol.UDP, results[1].Ports[0].Protocol)
+	})
+
+	t.Run("text", func(t *testing.T) {
+		results := parse(`a.example.com:443
+a.example.com:8443 [cloudflare]
+[2001:db8::1]:22
+10.0.0.1
+b.example.com: 80,443
+25/tcp: c.example.com
+d.example.com: 22,80 (10.0.0.4: 22,80; 2001:db8::4: 22)
+`)
+		require.Len(t, results, 7)
+		require.Equal(t, "2001:db8::4", results[6].IP)
+		require.Len(t, results[0].Ports, 2)
+		require.Equal(t, "2001:db8::1", results[1].IP)
+		require.Equal(t, "10.0.0.1", results[2].IP)
+		require.Empty(t, results[2].Ports)
+		require.Len(t, results[3].Ports, 2)
+		require.Equal(t, 25, results[4].Ports[0].Port)
+	})
+
+	for _, invalid := range []string{
+		`{"ip":"10.0.0.1","port":443,"protocol":"sctp"}`,
+		`{"ip":"not an ip","port":443}`,
+		`{"ip":"10.0.0.1","port":70000}`,
+		`{"ip":"10.0.0.1","port":443,"schema_version":99}`,
+		"a.example.com:https",
+	} {
+		_, err := ParseResults(strings.NewReader(invalid))
+		require.NotNil(t, err, invalid)
+	}
+}
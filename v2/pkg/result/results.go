@@ -19,6 +19,8 @@ type Result struct {
 	ipPorts map[string]map[string]*port.Port
 	ips     map[string]struct{}
 	skipped map[string]struct{}
+	// stopped holds the ips whose remaining probes are skipped, their results being kept
+	stopped map[string]struct{}
 }
 
 // NewResult structure
@@ -26,7 +28,8 @@ func NewResult() *Result {
 	ipPorts := make(map[string]map[string]*port.Port)
 	ips := make(map[string]struct{})
 	skipped := make(map[string]struct{})
-	return &Result{ipPorts: ipPorts, ips: ips, skipped: skipped}
+	stopped := make(map[string]struct{})
+	return &Result{ipPorts: ipPorts, ips: ips, skipped: skipped, stopped: stopped}
 }
 
 // AddPort to a specific ip
@@ -84,6 +87,7 @@ func (r *Result) HasIPsPorts() bool {
 
 // AddPort to a specific ip
 func (r *Result) AddPort(ip string, p *port.Port) {
+	ip = NormalizeIP(ip)
 	r.Lock()
 	defer r.Unlock()
 
@@ -95,8 +99,30 @@ func (r *Result) AddPort(ip string, p *port.Port) {
 	r.ips[ip] = struct{}{}
 }
 
+// ReplacePort records the port of the ip, replacing the one with the same number and protocol
+// whatever its tls flag
+func (r *Result) ReplacePort(ip string, p *port.Port) {
+	ip = NormalizeIP(ip)
+	r.Lock()
+	defer r.Unlock()
+
+	ports, ok := r.ipPorts[ip]
+	if !ok {
+		ports = make(map[string]*port.Port)
+		r.ipPorts[ip] = ports
+	}
+	for key, stored := range ports {
+		if stored.Port == p.Port && stored.Protocol == p.Protocol {
+			delete(ports, key)
+		}
+	}
+	ports[p.String()] = p
+	r.ips[ip] = struct{}{}
+}
+
 // SetPorts for a specific ip
 func (r *Result) SetPorts(ip string, ports []*port.Port) {
+	ip = NormalizeIP(ip)
 	r.Lock()
 	defer r.Unlock()
 
@@ -112,6 +138,7 @@ func (r *Result) SetPorts(ip string, ports []*port.Port) {
 
 // IPHasPort checks if an ip has a specific port
 func (r *Result) IPHasPort(ip string, p *port.Port) bool {
+	ip = NormalizeIP(ip)
 	r.RLock()
 	defer r.RUnlock()
 
@@ -126,6 +153,7 @@ func (r *Result) IPHasPort(ip string, p *port.Port) bool {
 
 // AddIp adds an ip to the results
 func (r *Result) AddIp(ip string) {
+	ip = NormalizeIP(ip)
 	r.Lock()
 	defer r.Unlock()
 
@@ -134,6 +162,7 @@ func (r *Result) AddIp(ip string) {
 
 // HasIP checks if an ip has been seen
 func (r *Result) HasIP(ip string) bool {
+	ip = NormalizeIP(ip)
 	r.RLock()
 	defer r.RUnlock()
 
@@ -154,14 +183,38 @@ func (r *Result) Len() int {
 
 // GetPortCount returns the number of ports discovered for an ip
 func (r *Result) GetPortCount(host string) int {
+	host = NormalizeIP(host)
 	r.RLock()
 	defer r.RUnlock()
 
 	return len(r.ipPorts[host])
 }
 
+// PortCount returns the number of ports discovered for all the ips
+func (r *Result) PortCount() int {
+	r.RLock()
+	defer r.RUnlock()
+
+	var count int
+	for _, ports := range r.ipPorts {
+		count += len(ports)
+	}
+	return count
+}
+
+// RemoveIP removes the ip and its ports from the results
+func (r *Result) RemoveIP(ip string) {
+	ip = NormalizeIP(ip)
+	r.Lock()
+	defer r.Unlock()
+
+	delete(r.ips, ip)
+	delete(r.ipPorts, ip)
+}
+
 // AddSkipped adds an ip to the skipped list
 func (r *Result) AddSkipped(ip string) {
+	ip = NormalizeIP(ip)
 	r.Lock()
 	defer r.Unlock()
 
@@ -170,9 +223,32 @@ func (r *Result) AddSkipped(ip string) {
 
 // HasSkipped checks if an ip has been skipped
 func (r *Result) HasSkipped(ip string) bool {
+	ip = NormalizeIP(ip)
 	r.RLock()
 	defer r.RUnlock()
 
 	_, ok := r.skipped[ip]
 	return ok
 }
+
+// AddStopped stops probing an ip, keeping its results unlike AddSkipped
+func (r *Result) AddStopped(ip string) {
+	ip = NormalizeIP(ip)
+	r.Lock()
+	defer r.Unlock()
+
+	r.stopped[ip] = struct{}{}
+}
+
+// HasStopped checks if no more probes are sent to an ip, skipped or stopped
+func (r *Result) HasStopped(ip string) bool {
+	ip = NormalizeIP(ip)
+	r.RLock()
+	defer r.RUnlock()
+
+	if _, ok := r.skipped[ip]; ok {
+		return true
+	}
+	_, ok := r.stopped[ip]
+	return ok
+}
@@ -42,6 +42,14 @@ func TestSetPorts(t *testing.T) {
 	assert.Equal(t, res.ipPorts, expectedIPSPorts)
 }
 
+func TestPortCount(t *testing.T) {
+	res := NewResult()
+	res.SetPorts("127.0.0.1", []*port.Port{{Port: 80, Protocol: protocol.TCP}, {Port: 443, Protocol: protocol.TCP}})
+	res.AddPort("127.0.0.2", &port.Port{Port: 53, Protocol: protocol.UDP})
+
+	assert.Equal(t, 3, res.PortCount())
+}
+
 func TestIPHasPort(t *testing.T) {
 	targetIP := "127.0.0.1"
 	expectedPort := &port.Port{Port: 8080, Protocol: protocol.TCP}
@@ -69,4 +77,46 @@ func TestHasIP(t *testing.T) {
 	res.AddIp(targetIP)
 	assert.True(t, res.HasIP(targetIP))
 	assert.False(t, res.HasIP("1.2.3.4"))
+
+	res.RemoveIP(targetIP)
+	assert.False(t, res.HasIP(targetIP))
+	assert.True(t, res.IsEmpty())
+}
+
+func TestNormalizeIP(t *testing.T) {
+	for input, expected := range map[string]string{
+		"::ffff:10.0.0.1":                      "10.0.0.1",
+		"10.0.0.1":                             "10.0.0.1",
+		"2001:DB8:0::1":                        "2001:db8::1",
+		"::ffff:102:304":                       "1.2.3.4",
+		"2002:102:304::1":                      "2002:102:304::1",
+		"2001:0:4136:e378:8000:63bf:fefd:fcfb": "2001:0:4136:e378:8000:63bf:fefd:fcfb",
+		"fe80::1%eth0":                         "fe80::1%eth0",
+		"scanme.sh":                            "scanme.sh",
+		"":                                     "",
+	} {
+		assert.Equal(t, expected, NormalizeIP(input), input)
+	}
+	assert.Equal(t, FamilyIPv4, Family("::ffff:10.0.0.1"))
+	assert.Equal(t, FamilyIPv6, Family("2001:db8::1"))
+	assert.Equal(t, "", Family("scanme.sh"))
+
+	res := NewResult()
+	res.AddPort("::ffff:10.0.0.1", &port.Port{Port: 80, Protocol: protocol.TCP})
+	res.AddPort("10.0.0.1", &port.Port{Port: 443, Protocol: protocol.TCP})
+	assert.True(t, res.HasIP("10.0.0.1"))
+	assert.Equal(t, 2, res.GetPortCount("::ffff:10.0.0.1"))
+	assert.Equal(t, 1, res.Len())
+}
+
+func TestReplacePort(t *testing.T) {
+	targetIP := "127.0.0.1"
+	res := NewResult()
+	res.AddPort(targetIP, &port.Port{Port: 443, Protocol: protocol.TCP})
+	identified := &port.Port{Port: 443, Protocol: protocol.TCP, TLS: true, Service: "https"}
+	res.ReplacePort(targetIP, identified)
+	res.ReplacePort(targetIP, &port.Port{Port: 443, Protocol: protocol.UDP})
+
+	assert.Len(t, res.ipPorts[targetIP], 2)
+	assert.Equal(t, identified, res.ipPorts[targetIP][identified.String()])
 }
@@ -0,0 +1,143 @@
+package runner
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/scan"
+)
+
+const (
+	// adaptiveRateInterval is the interval at which the send rate is adjusted
+	adaptiveRateInterval = time.Second
+	// adaptiveRateMin is the lowest rate the adaptive mode slows down to
+	adaptiveRateMin = 10
+	// adaptiveRateMinProbes is the number of probes an interval needs to be representative
+	adaptiveRateMinProbes = 50
+	// adaptiveRateLoss is the fraction of the usual response ratio below which probes are considered lost
+	adaptiveRateLoss = 0.5
+)
+
+// adaptiveRate paces the probes at a rate halved when the response ratio of an interval drops, as lost
+// probes and rate limited answers lower the ratio, and raised back up to -rate while it holds
+type adaptiveRate struct {
+	sync.Mutex
+	rate     float64
+	min      float64
+	max      float64
+	baseline float64 // moving average of the response ratio
+	next     time.Time
+}
+
+func newAdaptiveRate(initial int) *adaptiveRate {
+	rate := float64(initial)
+	floor := float64(adaptiveRateMin)
+	if rate < floor {
+		floor = rate
+	}
+	return &adaptiveRate{rate: rate, min: floor, max: rate}
+}
+
+// wait blocks until the next probe can be sent at the current rate
+func (a *adaptiveRate) wait() {
+	if a == nil {
+		return
+	}
+	a.Lock()
+	now := time.Now()
+	if a.next.Before(now) {
+		a.next = now
+	}
+	delay := a.next.Sub(now)
+	a.next = a.next.Add(time.Duration(float64(time.Second) / a.rate))
+	a.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// adjust updates the rate from the probes sent and answered during the last interval, returning
+// the new rate and true if probes were lost
+func (a *adaptiveRate) adjust(sent, answered uint64) (float64, bool) {
+	a.Lock()
+	defer a.Unlock()
+
+	if sent < adaptiveRateMinProbes {
+		return a.rate, false
+	}
+	ratio := float64(answered) / float64(sent)
+	if a.baseline > 0 && ratio < a.baseline*adaptiveRateLoss {
+		a.rate /= 2
+		if a.rate < a.min {
+			a.rate = a.min
+		}
+		// the baseline decays slowly so that a sparser part of the targets isn't mistaken for loss forever
+		a.baseline = a.baseline*0.9 + ratio*0.1
+		return a.rate, true
+	}
+	if a.baseline == 0 {
+		a.baseline = ratio
+	} else {
+		a.baseline = a.baseline*0.8 + ratio*0.2
+	}
+	a.rate *= 1.25
+	if a.rate > a.max {
+		a.rate = a.max
+	}
+	return a.rate, false
+}
+
+// current returns the current rate
+func (a *adaptiveRate) current() float64 {
+	a.Lock()
+	defer a.Unlock()
+	return a.rate
+}
+
+// maxRate returns the highest rate probes can be sent at, -rate being the ceiling of the adaptive rate
+func (r *Runner) maxRate() int {
+	if r.adaptiveRate != nil {
+		return int(r.adaptiveRate.max)
+	}
+	return r.options.Rate
+}
+
+// startAdaptiveRate adjusts the send rate at each interval of the port scan from the ratio of
+// answered probes
+func (r *Runner) startAdaptiveRate(ctx context.Context) {
+	if r.adaptiveRate == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(adaptiveRateInterval)
+		defer ticker.Stop()
+
+		lastSent, lastAnswered := r.probesSent.Load(), r.probesAnswered.Load()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			sent, answered := r.probesSent.Load(), r.probesAnswered.Load()
+			if !r.scanner.Phase.Is(scan.Scan) {
+				lastSent, lastAnswered = sent, answered
+				continue
+			}
+
+			previous := r.adaptiveRate.current()
+			rate, lost := r.adaptiveRate.adjust(sent-lastSent, answered-lastAnswered)
+			lastSent, lastAnswered = sent, answered
+			if lost {
+				r.probesDropped.Store(true)
+				gologger.Verbose().Msgf("Response ratio dropped, lowering rate from %.0f to %.0f pps\n", previous, rate)
+			} else if rate != previous {
+				gologger.Debug().Msgf("Raising rate from %.0f to %.0f pps\n", previous, rate)
+			}
+		}
+	}()
+}
@@ -0,0 +1,49 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdaptiveRate(t *testing.T) {
+	rate := newAdaptiveRate(100)
+
+	// too few probes to judge
+	current, lost := rate.adjust(10, 0)
+	require.Equal(t, 100.0, current)
+	require.False(t, lost)
+
+	// steady response ratio keeps the rate at -rate
+	for i := 0; i < 20; i++ {
+		current, lost = rate.adjust(1000, 100)
+		require.False(t, lost)
+	}
+	require.Equal(t, 100.0, current)
+
+	// a drop of the response ratio halves the rate
+	current, lost = rate.adjust(1000, 10)
+	require.True(t, lost)
+	require.Equal(t, 50.0, current)
+
+	for i := 0; i < 20; i++ {
+		current, _ = rate.adjust(1000, 1)
+	}
+	require.Equal(t, float64(adaptiveRateMin), current)
+
+	// the rate recovers up to -rate once the ratio holds again
+	for i := 0; i < 40; i++ {
+		current, _ = rate.adjust(1000, 1)
+	}
+	require.Equal(t, 100.0, current)
+}
+
+func TestAdaptiveRateNil(t *testing.T) {
+	var rate *adaptiveRate
+	rate.wait()
+
+	r := &Runner{options: &Options{Rate: 1000}}
+	require.Equal(t, 1000, r.maxRate())
+	r.adaptiveRate = newAdaptiveRate(1000)
+	require.Equal(t, 1000, r.maxRate())
+}
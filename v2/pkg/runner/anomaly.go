@@ -0,0 +1,13 @@
+package runner
+
+import (
+	"github.com/projectdiscovery/gologger"
+)
+
+// reportAnomalies warns about the probes answered by another address than the probed one, whose
+// ports are reported open with the address that answered
+func (r *Runner) reportAnomalies() {
+	for _, anomaly := range r.scanner.Anomalies() {
+		gologger.Warning().Msgf("%s:%d answered by %s (nat hairpin, anycast or middlebox)\n", anomaly.IP, anomaly.Port, anomaly.Responder())
+	}
+}
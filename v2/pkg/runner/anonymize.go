@@ -0,0 +1,179 @@
+package runner
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+)
+
+// Anonymization modes of the output files
+const (
+	AnonymizeHash     = "hash"
+	AnonymizeTruncate = "truncate"
+)
+
+// anonymizer replaces the ips and hostnames written to output files so that result datasets can be shared.
+// Hashing is keyed (hmac-sha256), the same input always maps to the same value for a given key.
+type anonymizer struct {
+	mode string
+	key  []byte
+}
+
+// newAnonymizer creates an anonymizer, with a random key valid for the run if none is given
+func newAnonymizer(mode, key string) (*anonymizer, error) {
+	switch mode {
+	case AnonymizeHash, AnonymizeTruncate:
+	default:
+		return nil, fmt.Errorf("invalid anonymize mode %s (allowed: %s, %s)", mode, AnonymizeHash, AnonymizeTruncate)
+	}
+	anon := &anonymizer{mode: mode, key: []byte(key)}
+	if key == "" {
+		anon.key = make([]byte, 32)
+		if _, err := rand.Read(anon.key); err != nil {
+			return nil, err
+		}
+	}
+	return anon, nil
+}
+
+// Value anonymizes an ip or hostname, ips are hashed into an address of the same family or truncated
+// to their /24 (ipv4) or /48 (ipv6) network, hostnames are always hashed into a .invalid name
+func (a *anonymizer) Value(value string) string {
+	if a == nil || value == "" {
+		return value
+	}
+	ip := net.ParseIP(value)
+	switch {
+	case ip == nil:
+		return hex.EncodeToString(a.hash(value)[:8]) + ".invalid"
+	case a.mode == AnonymizeTruncate && ip.To4() != nil:
+		return ip.Mask(net.CIDRMask(24, 32)).String()
+	case a.mode == AnonymizeTruncate:
+		return ip.Mask(net.CIDRMask(48, 128)).String()
+	case ip.To4() != nil:
+		return net.IP(a.hash(value)[:net.IPv4len]).String()
+	default:
+		return net.IP(a.hash(value)[:net.IPv6len]).String()
+	}
+}
+
+// Values anonymizes a list of ips or hostnames
+func (a *anonymizer) Values(values []string) []string {
+	if a == nil {
+		return values
+	}
+	anonymized := make([]string, 0, len(values))
+	for _, value := range values {
+		anonymized = append(anonymized, a.Value(value))
+	}
+	return anonymized
+}
+
+// Labels hashes free-form labels (tags) which may be derived from the names of the host
+func (a *anonymizer) Labels(labels []string) []string {
+	if a == nil || len(labels) == 0 {
+		return labels
+	}
+	anonymized := make([]string, 0, len(labels))
+	for _, label := range labels {
+		anonymized = append(anonymized, hex.EncodeToString(a.hash(label)[:8]))
+	}
+	return anonymized
+}
+
+// addressTokens matches the candidate ipv4 and ipv6 addresses of a text
+var addressTokens = regexp.MustCompile(`[0-9A-Fa-f:.]*[.:][0-9A-Fa-f:.]*`)
+
+// Text anonymizes the ip addresses and the given names appearing in a text (banner)
+func (a *anonymizer) Text(text string, names ...string) string {
+	if a == nil || text == "" {
+		return text
+	}
+	for _, name := range names {
+		if name != "" && net.ParseIP(name) == nil {
+			text = strings.ReplaceAll(text, name, a.Value(name))
+		}
+	}
+	return addressTokens.ReplaceAllStringFunc(text, func(token string) string {
+		if !strings.ContainsAny(token, "0123456789") || net.ParseIP(token) == nil {
+			return token
+		}
+		return a.Value(token)
+	})
+}
+
+// Ports returns anonymized copies of the ports, the names of the host being removed from the banners
+func (a *anonymizer) Ports(ports []*port.Port, names ...string) []*port.Port {
+	if a == nil {
+		return ports
+	}
+	anonymized := make([]*port.Port, 0, len(ports))
+	for _, p := range ports {
+		anonymized = append(anonymized, a.Port(p, names...))
+	}
+	return anonymized
+}
+
+// Port returns an anonymized copy of the port banner and responder
+func (a *anonymizer) Port(p *port.Port, names ...string) *port.Port {
+	if a == nil || p == nil {
+		return p
+	}
+	anonymized := *p
+	anonymized.Banner = a.Text(p.Banner, names...)
+	if host, portNumber, err := net.SplitHostPort(p.Responder); err == nil {
+		anonymized.Responder = net.JoinHostPort(a.Value(host), portNumber)
+	} else {
+		anonymized.Responder = a.Value(p.Responder)
+	}
+	return &anonymized
+}
+
+// Result returns an anonymized copy of the result, covering its names, aliases, tags and port
+func (a *anonymizer) Result(data *Result) *Result {
+	if a == nil {
+		return data
+	}
+	anonymized := *data
+	anonymized.Host, anonymized.IP = a.Value(data.Host), a.Value(data.IP)
+	anonymized.CNAME = a.Values(data.CNAME)
+	anonymized.Tags = a.Labels(data.Tags)
+	anonymized.Port = a.Port(data.Port, data.names()...)
+	return &anonymized
+}
+
+// Groups returns anonymized copies of host and port groups
+func (a *anonymizer) Groups(groups []interface{}) []interface{} {
+	if a == nil {
+		return groups
+	}
+	anonymized := make([]interface{}, 0, len(groups))
+	for _, group := range groups {
+		switch g := group.(type) {
+		case *hostGroup:
+			hg := &hostGroup{Host: a.Value(g.Host), IP: a.Value(g.IP), Ports: g.Ports}
+			for _, address := range g.Addresses {
+				hg.Addresses = append(hg.Addresses, &addressGroup{IP: a.Value(address.IP), Family: address.Family, Ports: address.Ports})
+			}
+			anonymized = append(anonymized, hg)
+		case *portGroup:
+			anonymized = append(anonymized, &portGroup{Port: g.Port, Protocol: g.Protocol, Hosts: a.Values(g.Hosts)})
+		default:
+			anonymized = append(anonymized, group)
+		}
+	}
+	return anonymized
+}
+
+func (a *anonymizer) hash(value string) []byte {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnonymizer(t *testing.T) {
+	_, err := newAnonymizer("scramble", "")
+	require.NotNil(t, err)
+
+	var disabled *anonymizer
+	require.Equal(t, "192.168.1.10", disabled.Value("192.168.1.10"))
+
+	hash, err := newAnonymizer(AnonymizeHash, "secret")
+	require.Nil(t, err)
+	ip := hash.Value("192.168.1.10")
+	require.NotEqual(t, "192.168.1.10", ip)
+	require.NotNil(t, net.ParseIP(ip).To4())
+	require.NotNil(t, net.ParseIP(hash.Value("2001:db8::1")))
+	host := hash.Value("www.example.com")
+	require.True(t, strings.HasSuffix(host, ".invalid"))
+	require.NotContains(t, host, "example")
+
+	// the same key yields the same values across runs
+	again, err := newAnonymizer(AnonymizeHash, "secret")
+	require.Nil(t, err)
+	require.Equal(t, ip, again.Value("192.168.1.10"))
+	require.Equal(t, host, again.Value("www.example.com"))
+	other, err := newAnonymizer(AnonymizeHash, "other")
+	require.Nil(t, err)
+	require.NotEqual(t, ip, other.Value("192.168.1.10"))
+
+	truncate, err := newAnonymizer(AnonymizeTruncate, "")
+	require.Nil(t, err)
+	require.Equal(t, "192.168.1.0", truncate.Value("192.168.1.10"))
+	require.Equal(t, "2001:db8:1::", truncate.Value("2001:db8:1:2::1"))
+	require.True(t, strings.HasSuffix(truncate.Value("www.example.com"), ".invalid"))
+
+	groups := truncate.Groups([]interface{}{&hostGroup{IP: "10.0.0.1", Ports: []int{80}}, &portGroup{Port: 80, Protocol: "tcp", Hosts: []string{"10.0.0.1"}}})
+	require.Equal(t, "10.0.0.0", groups[0].(*hostGroup).IP)
+	require.Equal(t, []string{"10.0.0.0"}, groups[1].(*portGroup).Hosts)
+}
+
+func TestAnonymizerResult(t *testing.T) {
+	hash, err := newAnonymizer(AnonymizeHash, "secret")
+	require.Nil(t, err)
+
+	data := &Result{
+		Host:  "www.example.com",
+		IP:    "192.0.2.10",
+		CNAME: CNAMEChain{"lb.example.net"},
+		Tags:  Tags{"owner:www.example.com"},
+		Port:  &port.Port{Port: 22, Banner: "SSH-2.0-OpenSSH_8.9 www.example.com 192.0.2.10 ready", Responder: "192.0.2.11:22"},
+	}
+	anonymized := hash.Result(data)
+	require.Equal(t, hash.Value("www.example.com"), anonymized.Host)
+	require.Equal(t, []string{hash.Value("lb.example.net")}, []string(anonymized.CNAME))
+	require.NotContains(t, anonymized.Tags[0], "example")
+	require.Equal(t, "SSH-2.0-OpenSSH_8.9 "+hash.Value("www.example.com")+" "+hash.Value("192.0.2.10")+" ready", anonymized.Port.Banner)
+	require.Equal(t, net.JoinHostPort(hash.Value("192.0.2.11"), "22"), anonymized.Port.Responder)
+	require.Equal(t, "192.0.2.11:22", data.Port.Responder, "the result must not be modified")
+}
@@ -0,0 +1,186 @@
+package runner
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+	"github.com/remeh/sizedwaitgroup"
+)
+
+const (
+	// anycastProbes is the number of connections timed to each host, each from another source port
+	// hashed by ecmp routers to a possibly different site
+	anycastProbes = 8
+	// anycastTTLSpread is the minimum hop count difference between the responses of a host
+	anycastTTLSpread = 3
+	// anycastRTTGap is the minimum round trip time difference between two groups of connections
+	anycastRTTGap = 20 * time.Millisecond
+)
+
+// anycastDetector records the ttls of the responses and the connection round trip times of each
+// host, hosts answering from sites at different distances are probably anycast
+type anycastDetector struct {
+	sync.Mutex
+	hops    map[hopsKey][2]uint8 // min and max hop count
+	rtts    map[string][]time.Duration
+	anycast map[string]struct{}
+}
+
+// hopsKey groups the hop counts of a host by initial ttl, as the responses of distinct stacks
+// (firewalls, load balancers) start from different initial ttls
+type hopsKey struct {
+	ip         string
+	initialTTL uint8
+}
+
+// initialTTLs are the initial ttls used by the common network stacks
+var initialTTLs = []uint8{32, 64, 128, 255}
+
+func newAnycastDetector() *anycastDetector {
+	return &anycastDetector{
+		hops:    make(map[hopsKey][2]uint8),
+		rtts:    make(map[string][]time.Duration),
+		anycast: make(map[string]struct{}),
+	}
+}
+
+// hopCount returns the initial ttl the response most likely started from and the hops it crossed
+func hopCount(ttl uint8) (uint8, uint8) {
+	for _, initialTTL := range initialTTLs {
+		if ttl <= initialTTL {
+			return initialTTL, initialTTL - ttl
+		}
+	}
+	return 255, 0
+}
+
+// addTTL records the hop count of a syn-ack of the host, other responses (rsts sent by firewalls on
+// behalf of the host) being ignored
+func (d *anycastDetector) addTTL(ip string, ttl uint8, flags string) {
+	if flags != "SA" {
+		return
+	}
+	initialTTL, hops := hopCount(ttl)
+	key := hopsKey{ip: ip, initialTTL: initialTTL}
+
+	d.Lock()
+	defer d.Unlock()
+
+	bounds, ok := d.hops[key]
+	if !ok || hops < bounds[0] {
+		bounds[0] = hops
+	}
+	if hops > bounds[1] {
+		bounds[1] = hops
+	}
+	d.hops[key] = bounds
+}
+
+// addRTT records the round trip time of a connection to the host
+func (d *anycastDetector) addRTT(ip string, rtt time.Duration) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.rtts[ip] = append(d.rtts[ip], rtt)
+}
+
+// evaluate marks the host as anycast if the hop counts of its syn-acks from the same initial ttl or
+// its round trip times vary, returning the verdict
+func (d *anycastDetector) evaluate(ip string) bool {
+	d.Lock()
+	defer d.Unlock()
+
+	spread := false
+	for _, initialTTL := range initialTTLs {
+		if bounds, ok := d.hops[hopsKey{ip: ip, initialTTL: initialTTL}]; ok && bounds[1]-bounds[0] >= anycastTTLSpread {
+			spread = true
+		}
+	}
+	if spread || isBimodal(d.rtts[ip]) {
+		d.anycast[ip] = struct{}{}
+		return true
+	}
+	return false
+}
+
+// isAnycast returns true if the host was marked as probable anycast
+func (d *anycastDetector) isAnycast(ip string) bool {
+	if d == nil {
+		return false
+	}
+	d.Lock()
+	defer d.Unlock()
+
+	_, ok := d.anycast[ip]
+	return ok
+}
+
+// isBimodal returns true if the round trip times split into two groups of at least two samples,
+// far enough apart not to be explained by jitter
+func isBimodal(rtts []time.Duration) bool {
+	if len(rtts) < 4 {
+		return false
+	}
+	sorted := append([]time.Duration(nil), rtts...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	fastest, slowest := sorted[0], sorted[len(sorted)-1]
+	if slowest-fastest < anycastRTTGap || slowest < fastest*3/2 {
+		return false
+	}
+	middle := fastest + (slowest-fastest)/2
+	var slow int
+	for _, rtt := range sorted {
+		if rtt > middle {
+			slow++
+		}
+	}
+	return slow >= 2 && len(sorted)-slow >= 2
+}
+
+// checkAnycast times repeated connections to an open tcp port of each host and marks the hosts
+// whose ttls or round trip times vary as probable anycast
+func (r *Runner) checkAnycast(scanResults *result.Result) {
+	if r.anycast == nil || !scanResults.HasIPsPorts() || r.scanKilled() {
+		return
+	}
+	timeout := time.Duration(r.options.Timeout) * time.Millisecond
+	swg := sizedwaitgroup.New(r.options.Threads)
+	for hostResult := range scanResults.GetIPsPorts() {
+		var target *port.Port
+		for _, p := range hostResult.Ports {
+			if p.Protocol == protocol.TCP {
+				target = p
+				break
+			}
+		}
+		if target == nil || !r.waitTrafficWindow() {
+			continue
+		}
+
+		swg.Add()
+		go func(ip string, p *port.Port) {
+			defer swg.Done()
+			for i := 0; i < anycastProbes; i++ {
+				r.sockets.acquire()
+				start := time.Now()
+				conn, err := r.scanner.DialPort(ip, p, timeout)
+				if err == nil {
+					r.anycast.addRTT(ip, time.Since(start))
+					conn.Close()
+				}
+				r.sockets.release()
+			}
+			if r.anycast.evaluate(ip) {
+				gologger.Info().Msgf("%s is probably anycast, results may differ from other vantage points\n", ip)
+			}
+		}(hostResult.IP, target)
+	}
+	swg.Wait()
+}
@@ -0,0 +1,68 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsBimodal(t *testing.T) {
+	ms := func(values ...int) []time.Duration {
+		var rtts []time.Duration
+		for _, value := range values {
+			rtts = append(rtts, time.Duration(value)*time.Millisecond)
+		}
+		return rtts
+	}
+
+	// two sites 10ms and 80ms away
+	require.True(t, isBimodal(ms(10, 81, 11, 80, 10, 79, 12, 10)))
+	// a single slow outlier is jitter
+	require.False(t, isBimodal(ms(10, 11, 10, 80, 12, 10, 11, 10)))
+	// close groups
+	require.False(t, isBimodal(ms(10, 15, 10, 15, 11, 16)))
+	require.False(t, isBimodal(ms(10, 80)))
+}
+
+func TestHopCount(t *testing.T) {
+	for ttl, expected := range map[uint8][2]uint8{
+		57:  {64, 7},
+		64:  {64, 0},
+		119: {128, 9},
+		30:  {32, 2},
+		240: {255, 15},
+	} {
+		initialTTL, hops := hopCount(ttl)
+		require.Equal(t, expected, [2]uint8{initialTTL, hops}, ttl)
+	}
+}
+
+func TestAnycastDetector(t *testing.T) {
+	detector := newAnycastDetector()
+
+	detector.addTTL("192.0.2.1", 57, "SA")
+	detector.addTTL("192.0.2.1", 52, "SA")
+	detector.addTTL("192.0.2.2", 57, "SA")
+	detector.addTTL("192.0.2.2", 56, "SA")
+	require.True(t, detector.evaluate("192.0.2.1"))
+	require.False(t, detector.evaluate("192.0.2.2"))
+
+	// a linux host behind a windows load balancer, and rsts of a firewall, are not anycast
+	detector.addTTL("192.0.2.4", 57, "SA")
+	detector.addTTL("192.0.2.4", 119, "SA")
+	detector.addTTL("192.0.2.4", 50, "RA")
+	require.False(t, detector.evaluate("192.0.2.4"))
+
+	for _, rtt := range []int{10, 60, 10, 60} {
+		detector.addRTT("192.0.2.3", time.Duration(rtt)*time.Millisecond)
+	}
+	require.True(t, detector.evaluate("192.0.2.3"))
+
+	require.True(t, detector.isAnycast("192.0.2.1"))
+	require.False(t, detector.isAnycast("192.0.2.2"))
+	require.True(t, detector.isAnycast("192.0.2.3"))
+
+	var disabled *anycastDetector
+	require.False(t, disabled.isAnycast("192.0.2.1"))
+}
@@ -0,0 +1,120 @@
+package runner
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	zipMagic  = []byte("PK\x03\x04")
+	// tarMagic is found at tarMagicOffset of the header of a tar archive
+	tarMagic = []byte("ustar")
+)
+
+const tarMagicOffset = 257
+
+// copyTargetsFile copies the target list of the file, decompressing gzip and zstd files and reading
+// the files of zip and tar archives (.gz, .zst, .zip, .tar, .tar.gz), detected from their content
+func copyTargetsFile(dst io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	magic, _ := reader.Peek(len(zstdMagic))
+	var content io.Reader = reader
+	switch {
+	case bytes.HasPrefix(magic, zipMagic):
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		return copyZipTargets(dst, f, info.Size())
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return fmt.Errorf("could not read gzip file %s: %w", path, err)
+		}
+		defer gz.Close()
+		content = gz
+	case bytes.HasPrefix(magic, zstdMagic):
+		decoder, err := zstd.NewReader(reader)
+		if err != nil {
+			return fmt.Errorf("could not read zstd file %s: %w", path, err)
+		}
+		defer decoder.Close()
+		content = decoder
+	}
+
+	// tar archives, compressed or not
+	buffered := bufio.NewReader(content)
+	if header, _ := buffered.Peek(tarMagicOffset + len(tarMagic)); bytes.HasSuffix(header, tarMagic) {
+		return copyTarTargets(dst, buffered)
+	}
+	_, err = io.Copy(dst, buffered)
+	return err
+}
+
+// copyTarTargets copies the regular files of the tar archive, each ending with a new line
+func copyTarTargets(dst io.Writer, reader io.Reader) error {
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("could not read tar archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := copyTargetsEntry(dst, archive); err != nil {
+			return err
+		}
+	}
+}
+
+// copyZipTargets copies the files of the zip archive, each ending with a new line
+func copyZipTargets(dst io.Writer, reader io.ReaderAt, size int64) error {
+	archive, err := zip.NewReader(reader, size)
+	if err != nil {
+		return fmt.Errorf("could not read zip archive: %w", err)
+	}
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		entry, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("could not read %s of zip archive: %w", file.Name, err)
+		}
+		err = copyTargetsEntry(dst, entry)
+		entry.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyTargetsEntry copies a file of an archive, separating its last target from the next file
+func copyTargetsEntry(dst io.Writer, entry io.Reader) error {
+	if _, err := io.Copy(dst, entry); err != nil {
+		return err
+	}
+	_, err := io.WriteString(dst, "\n")
+	return err
+}
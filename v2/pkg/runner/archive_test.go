@@ -0,0 +1,66 @@
+package runner
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyTargetsFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		require.Nil(t, os.WriteFile(path, data, 0600))
+		return path
+	}
+	copyTargets := func(path string) string {
+		var buffer bytes.Buffer
+		require.Nil(t, copyTargetsFile(&buffer, path))
+		return buffer.String()
+	}
+	targets := "10.0.0.1\nscanme.sh\n"
+
+	require.Equal(t, targets, copyTargets(write("hosts.txt", []byte(targets))))
+
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	_, _ = gz.Write([]byte(targets))
+	require.Nil(t, gz.Close())
+	require.Equal(t, targets, copyTargets(write("hosts.txt.gz", gzipped.Bytes())))
+
+	encoder, err := zstd.NewWriter(nil)
+	require.Nil(t, err)
+	require.Equal(t, targets, copyTargets(write("hosts.zst", encoder.EncodeAll([]byte(targets), nil))))
+
+	var zipped bytes.Buffer
+	archive := zip.NewWriter(&zipped)
+	for name, content := range map[string]string{"a.txt": "10.0.0.1", "b.txt": "scanme.sh\n"} {
+		entry, err := archive.Create(name)
+		require.Nil(t, err)
+		_, _ = entry.Write([]byte(content))
+	}
+	require.Nil(t, archive.Close())
+	require.ElementsMatch(t, []string{"10.0.0.1", "scanme.sh"}, strings.Fields(copyTargets(write("hosts.zip", zipped.Bytes()))))
+
+	var tarred bytes.Buffer
+	gz = gzip.NewWriter(&tarred)
+	tarArchive := tar.NewWriter(gz)
+	require.Nil(t, tarArchive.WriteHeader(&tar.Header{Name: "exports", Typeflag: tar.TypeDir, Mode: 0700}))
+	require.Nil(t, tarArchive.WriteHeader(&tar.Header{Name: "exports/hosts.txt", Typeflag: tar.TypeReg, Mode: 0600, Size: int64(len(targets))}))
+	_, _ = tarArchive.Write([]byte(targets))
+	require.Nil(t, tarArchive.Close())
+	require.Nil(t, gz.Close())
+	require.Equal(t, targets+"\n", copyTargets(write("hosts.tar.gz", tarred.Bytes())))
+
+	var buffer bytes.Buffer
+	require.NotNil(t, copyTargetsFile(&buffer, filepath.Join(dir, "missing.txt")))
+	require.NotNil(t, copyTargetsFile(&buffer, write("broken.gz", []byte{0x1f, 0x8b, 0x00})))
+}
@@ -0,0 +1,170 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/ratelimit"
+)
+
+// asnQueueSize is the maximum number of probes waiting for the rate limit of an asn
+const asnQueueSize = 1024
+
+// asnCacheSize is the maximum number of ips whose group is cached, the cache is reset once full
+const asnCacheSize = 65536
+
+// asnRateGroup sends the probes to the prefixes of an asn through a dedicated rate limited queue,
+// so that throttled providers don't slow down the rest of the scan
+type asnRateGroup struct {
+	asn      string
+	networks []*net.IPNet
+	limiter  *ratelimit.Limiter
+	queue    chan func()
+	wg       sync.WaitGroup
+	// dropped counts the probes discarded because the queue was full
+	dropped atomic.Uint64
+}
+
+// asnRateLimiter maps target ips to the rate limited group of their asn
+type asnRateLimiter struct {
+	groups []*asnRateGroup
+	// cache stores the group (or nil) of the ips recently looked up
+	mu    sync.RWMutex
+	cache map[string]*asnRateGroup
+}
+
+// parseASNRates parses AS15169=100 values into the rate of each asn
+func parseASNRates(values []string) (map[string]int, error) {
+	rates := make(map[string]int)
+	for _, value := range values {
+		asn, rateValue, ok := strings.Cut(value, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid asn rate %s (expected AS15169=100)", value)
+		}
+		asn = strings.ToUpper(strings.TrimSpace(asn))
+		if !strings.HasPrefix(asn, "AS") {
+			asn = "AS" + asn
+		}
+		if _, err := strconv.Atoi(strings.TrimPrefix(asn, "AS")); err != nil {
+			return nil, fmt.Errorf("invalid asn %s", asn)
+		}
+		rate, err := strconv.Atoi(strings.TrimSpace(rateValue))
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("invalid rate for %s: %s", asn, rateValue)
+		}
+		rates[asn] = rate
+	}
+	return rates, nil
+}
+
+// newASNRateLimiter resolves the prefixes announced by each asn and starts their queues
+func newASNRateLimiter(rates map[string]int, prefixes func(asn string) ([]*net.IPNet, error)) (*asnRateLimiter, error) {
+	limiter := &asnRateLimiter{cache: make(map[string]*asnRateGroup)}
+	for asn, rate := range rates {
+		networks, err := prefixes(asn)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not get prefixes of %s", asn)
+		}
+		if len(networks) == 0 {
+			gologger.Warning().Msgf("No prefixes found for %s, rate limit ignored\n", asn)
+			continue
+		}
+		gologger.Verbose().Msgf("Limiting %d prefixes of %s to %d packets/second\n", len(networks), asn, rate)
+		group := &asnRateGroup{
+			asn:      asn,
+			networks: networks,
+			limiter:  ratelimit.New(context.Background(), uint(rate), time.Second),
+			queue:    make(chan func(), asnQueueSize),
+		}
+		go group.worker()
+		limiter.groups = append(limiter.groups, group)
+	}
+	return limiter, nil
+}
+
+// Lookup returns the rate limited group of the ip or nil if it isn't throttled
+func (limiter *asnRateLimiter) Lookup(ip string) *asnRateGroup {
+	limiter.mu.RLock()
+	group, ok := limiter.cache[ip]
+	limiter.mu.RUnlock()
+	if ok {
+		return group
+	}
+	var found *asnRateGroup
+	if parsedIP := net.ParseIP(ip); parsedIP != nil {
+	groups:
+		for _, group := range limiter.groups {
+			for _, network := range group.networks {
+				if network.Contains(parsedIP) {
+					found = group
+					break groups
+				}
+			}
+		}
+	}
+	limiter.mu.Lock()
+	if len(limiter.cache) >= asnCacheSize {
+		limiter.cache = make(map[string]*asnRateGroup)
+	}
+	limiter.cache[ip] = found
+	limiter.mu.Unlock()
+	return found
+}
+
+// Wait waits for the queued probes of all the groups to be sent
+func (limiter *asnRateLimiter) Wait() {
+	for _, group := range limiter.groups {
+		group.wg.Wait()
+		if dropped := group.dropped.Swap(0); dropped > 0 {
+			gologger.Warning().Msgf("Dropped %d probes to %s exceeding its rate limit queue\n", dropped, group.asn)
+		}
+	}
+}
+
+// Enqueue schedules the probe to be sent within the rate of the asn, the probe is dropped
+// rather than blocking the sender when the queue is full
+func (group *asnRateGroup) Enqueue(probe func()) bool {
+	group.wg.Add(1)
+	select {
+	case group.queue <- probe:
+		return true
+	default:
+		group.wg.Done()
+		group.dropped.Add(1)
+		return false
+	}
+}
+
+func (group *asnRateGroup) worker() {
+	for probe := range group.queue {
+		group.limiter.Take()
+		probe()
+		group.wg.Done()
+	}
+}
+
+// scheduleProbe runs the probe right away or through the rate limited queue of the ip asn
+func (r *Runner) scheduleProbe(ip string, probe func()) {
+	if r.asnRates != nil {
+		if group := r.asnRates.Lookup(ip); group != nil {
+			group.Enqueue(probe)
+			return
+		}
+	}
+	probe()
+}
+
+// waitASNQueues waits for the probes delayed by asn rate limits to be sent
+func (r *Runner) waitASNQueues() {
+	if r.asnRates != nil {
+		r.asnRates.Wait()
+	}
+}
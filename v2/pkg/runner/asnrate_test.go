@@ -0,0 +1,63 @@
+package runner
+
+import (
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseASNRates(t *testing.T) {
+	rates, err := parseASNRates([]string{"AS15169=100", "as13335=50", "16509=10"})
+	require.Nil(t, err)
+	require.Equal(t, map[string]int{"AS15169": 100, "AS13335": 50, "AS16509": 10}, rates)
+
+	for _, value := range []string{"AS15169", "ASX=10", "AS15169=0", "AS15169=fast"} {
+		_, err := parseASNRates([]string{value})
+		require.NotNil(t, err, value)
+	}
+}
+
+func TestASNRateLimiter(t *testing.T) {
+	_, network, _ := net.ParseCIDR("192.0.2.0/24")
+	limiter, err := newASNRateLimiter(map[string]int{"AS64500": 1000}, func(asn string) ([]*net.IPNet, error) {
+		return []*net.IPNet{network}, nil
+	})
+	require.Nil(t, err)
+
+	require.NotNil(t, limiter.Lookup("192.0.2.1"))
+	require.Nil(t, limiter.Lookup("198.51.100.1"))
+	require.Nil(t, limiter.Lookup("198.51.100.1"), "cached lookups must be consistent")
+
+	r := &Runner{asnRates: limiter}
+	var sent atomic.Int32
+	for i := 0; i < 10; i++ {
+		r.scheduleProbe("192.0.2.1", func() { sent.Add(1) })
+	}
+	r.scheduleProbe("198.51.100.1", func() { sent.Add(1) })
+	r.waitASNQueues()
+	require.Equal(t, int32(11), sent.Load())
+}
+
+func TestASNRateGroupFullQueue(t *testing.T) {
+	group := &asnRateGroup{asn: "AS64500", queue: make(chan func(), 1)}
+	require.True(t, group.Enqueue(func() {}))
+	require.False(t, group.Enqueue(func() {}), "a full queue must not block the sender")
+	require.EqualValues(t, 1, group.dropped.Load())
+
+	<-group.queue
+	group.wg.Done()
+	limiter := &asnRateLimiter{groups: []*asnRateGroup{group}}
+	limiter.Wait()
+	require.Zero(t, group.dropped.Load())
+}
+
+func TestASNRateLimiterCacheBound(t *testing.T) {
+	limiter := &asnRateLimiter{cache: make(map[string]*asnRateGroup)}
+	for i := 0; i <= asnCacheSize; i++ {
+		limiter.Lookup(fmt.Sprintf("10.%d.%d.%d", i>>16&0xff, i>>8&0xff, i&0xff))
+	}
+	require.LessOrEqual(t, len(limiter.cache), asnCacheSize)
+}
@@ -0,0 +1,175 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/routing"
+	sliceutil "github.com/projectdiscovery/utils/slice"
+)
+
+const (
+	// autoRateShare is the share of the outbound bandwidth used by the probes, leaving room for
+	// the retransmissions, the other traffic of the host and the links slower than the interface
+	autoRateShare = 0.1
+	// minAutoRate is the lowest rate set by auto rate
+	minAutoRate = 10
+	// ethernetFraming is the ethernet header and frame check sequence of each frame, ethernetGap
+	// the preamble and inter frame gap sent between frames
+	ethernetFraming  = 14 + 4
+	ethernetGap      = 8 + 12
+	ethernetMinFrame = 64
+	// udpProbePayload is the average size of the udp protocol payloads
+	udpProbePayload = 32
+)
+
+// parseBandwidth returns the bits per second of a bandwidth with an optional k, m or g decimal
+// suffix, optionally followed by bit or bps (20M, 1g, 512kbit)
+func parseBandwidth(value string) (uint64, error) {
+	text := strings.ToLower(strings.TrimSpace(value))
+	text = strings.TrimSuffix(strings.TrimSuffix(text, "bps"), "bit")
+	multiplier := uint64(1)
+	switch {
+	case strings.HasSuffix(text, "k"):
+		multiplier = 1e3
+	case strings.HasSuffix(text, "m"):
+		multiplier = 1e6
+	case strings.HasSuffix(text, "g"):
+		multiplier = 1e9
+	}
+	if multiplier > 1 {
+		text = text[:len(text)-1]
+	}
+	number, err := strconv.ParseFloat(text, 64)
+	if err != nil || number <= 0 {
+		return 0, fmt.Errorf("invalid bandwidth %s (allowed: bits per second with k, m or g suffix like 20M)", value)
+	}
+	return uint64(number * float64(multiplier)), nil
+}
+
+// formatBandwidth returns the bandwidth in the largest unit (1 Gbit/s)
+func formatBandwidth(bandwidth uint64) string {
+	switch {
+	case bandwidth >= 1e9:
+		return strconv.FormatFloat(float64(bandwidth)/1e9, 'f', -1, 64) + " Gbit/s"
+	case bandwidth >= 1e6:
+		return strconv.FormatFloat(float64(bandwidth)/1e6, 'f', -1, 64) + " Mbit/s"
+	case bandwidth >= 1e3:
+		return strconv.FormatFloat(float64(bandwidth)/1e3, 'f', -1, 64) + " kbit/s"
+	default:
+		return strconv.FormatUint(bandwidth, 10) + " bit/s"
+	}
+}
+
+// wireSize returns the bytes on the wire of an ethernet frame carrying the ip packet
+func wireSize(packet int) int {
+	frame := packet + ethernetFraming
+	if frame < ethernetMinFrame {
+		frame = ethernetMinFrame
+	}
+	return frame + ethernetGap
+}
+
+// probeWireSize returns the bytes sent on the wire for each probe of the scan type, a connect
+// probe sending the syn, the ack of the handshake and the closing segment
+func probeWireSize(scanType string, ipv6, timestamps bool) int {
+	ipHeader := 20
+	if ipv6 {
+		ipHeader = 40
+	}
+	switch scanType {
+	case UDPScan:
+		return wireSize(ipHeader + 8 + udpProbePayload)
+	case ConnectScan:
+		// the syn of the kernel carries mss, sack, timestamps and window scale options
+		return wireSize(ipHeader+40) + 2*wireSize(ipHeader+32)
+	default:
+		tcpHeader := 24
+		if timestamps {
+			tcpHeader += 12
+		}
+		return wireSize(ipHeader + tcpHeader)
+	}
+}
+
+// autoRate returns the probes per second using the share of the bandwidth
+func autoRate(bandwidth uint64, probeSize int) int {
+	rate := int(float64(bandwidth) * autoRateShare / float64(8*probeSize))
+	if rate < minAutoRate {
+		return minAutoRate
+	}
+	return rate
+}
+
+// outboundInterface returns the scanning interface, or the one of the default route
+func (options *Options) outboundInterface() (string, error) {
+	if options.Interface != "" {
+		return options.Interface, nil
+	}
+	router, err := routing.New()
+	if err != nil {
+		return "", err
+	}
+	// the route lookup doesn't send any packet
+	iface, _, _, err := router.Route(net.IPv4(192, 0, 2, 1))
+	if err != nil {
+		return "", err
+	}
+	if iface == nil {
+		return "", errors.New("no default route")
+	}
+	return iface.Name, nil
+}
+
+// estimateBandwidth returns the outbound bandwidth, from -bandwidth or the interface speed, and
+// where it comes from
+func (options *Options) estimateBandwidth() (uint64, string, error) {
+	if options.Bandwidth != "" {
+		bandwidth, err := parseBandwidth(options.Bandwidth)
+		return bandwidth, "bandwidth option", err
+	}
+	name, err := options.outboundInterface()
+	if err != nil {
+		return 0, "", fmt.Errorf("could not find outbound interface: %w", err)
+	}
+	speed, err := interfaceSpeed(name)
+	if err != nil {
+		return 0, "", fmt.Errorf("could not get speed of interface %s: %w", name, err)
+	}
+	return speed, "interface " + name, nil
+}
+
+// configureAutoRate sets the rate left at its default using a share of the outbound bandwidth,
+// accounting for the size of the probes on the wire. Without auto rate the suggested rate is only
+// looked up and shown with -debug
+func (options *Options) configureAutoRate() {
+	rateIsDefault := options.isDefault("rate", options.Rate == DefaultRateSynScan || options.Rate == DefaultRateConnectScan)
+	if !options.AutoRate && !options.Debug {
+		return
+	}
+	bandwidth, source, err := options.estimateBandwidth()
+	if err != nil {
+		if options.AutoRate {
+			gologger.Warning().Msgf("Could not estimate bandwidth, keeping rate %d: %s\n", options.Rate, err)
+		} else {
+			gologger.Debug().Msgf("Could not estimate bandwidth: %s\n", err)
+		}
+		return
+	}
+	ipv6 := sliceutil.Contains(options.IPVersion, "6")
+	rate := autoRate(bandwidth, probeWireSize(options.usedScanType(), ipv6, options.TCPTimestamps))
+
+	switch {
+	case !options.AutoRate:
+		gologger.Debug().Msgf("Outbound bandwidth of %s (%s) allows %d packets per second, use -auto-rate to set it\n", formatBandwidth(bandwidth), source, rate)
+	case !rateIsDefault:
+		gologger.Info().Msgf("Keeping rate %d, auto rate for %s (%s) would be %d\n", options.Rate, formatBandwidth(bandwidth), source, rate)
+	default:
+		gologger.Info().Msgf("Rate set to %d packets per second for %s (%s)\n", rate, formatBandwidth(bandwidth), source)
+		options.Rate = rate
+	}
+}
@@ -0,0 +1,25 @@
+//go:build linux
+
+package runner
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// interfaceSpeed returns the link speed of the interface in bits per second
+func interfaceSpeed(name string) (uint64, error) {
+	data, err := os.ReadFile(filepath.Join("/sys/class/net", name, "speed"))
+	if err != nil {
+		return 0, err
+	}
+	// virtual and wireless interfaces report -1 or no speed
+	speed, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil || speed <= 0 {
+		return 0, errors.New("unknown link speed")
+	}
+	return uint64(speed) * 1e6, nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package runner
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// interfaceSpeed returns the link speed of the interface in bits per second
+func interfaceSpeed(name string) (uint64, error) {
+	return 0, fmt.Errorf("link speed of %s not available on %s, set -bandwidth", name, runtime.GOOS)
+}
@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBandwidth(t *testing.T) {
+	for value, expected := range map[string]uint64{
+		"20M":     20e6,
+		"1g":      1e9,
+		"1.5Gbit": 15e8,
+		"512kbps": 512e3,
+		"9600":    9600,
+	} {
+		bandwidth, err := parseBandwidth(value)
+		require.Nil(t, err, value)
+		require.Equal(t, expected, bandwidth, value)
+	}
+	for _, invalid := range []string{"", "fast", "0", "-1M", "10T"} {
+		_, err := parseBandwidth(invalid)
+		require.NotNil(t, err, invalid)
+	}
+	require.Equal(t, "1.5 Gbit/s", formatBandwidth(15e8))
+	require.Equal(t, "20 Mbit/s", formatBandwidth(20e6))
+}
+
+func TestAutoRate(t *testing.T) {
+	// minimum ethernet frame with preamble and inter frame gap
+	require.Equal(t, 84, probeWireSize(SynScan, false, false))
+	require.Equal(t, 102, probeWireSize(SynScan, true, false))
+	require.Greater(t, probeWireSize(ConnectScan, false, false), 2*probeWireSize(SynScan, false, false))
+
+	require.Equal(t, 148809, autoRate(1e9, 84))
+	require.Equal(t, 148, autoRate(1e6, 84))
+	require.Equal(t, minAutoRate, autoRate(9600, 84))
+
+	options := &Options{ScanType: SynScan, Rate: DefaultRateSynScan, AutoRate: true, Bandwidth: "10M"}
+	options.configureAutoRate()
+	require.Equal(t, autoRate(10e6, probeWireSize(options.usedScanType(), false, false)), options.Rate)
+
+	options = &Options{ScanType: SynScan, Rate: 5000, AutoRate: true, Bandwidth: "10M"}
+	options.configureAutoRate()
+	require.Equal(t, 5000, options.Rate)
+
+	options = &Options{ScanType: SynScan, Rate: DefaultRateSynScan, Bandwidth: "10M"}
+	options.configureAutoRate()
+	require.Equal(t, DefaultRateSynScan, options.Rate)
+
+	// -rate given with the default value is kept
+	options = &Options{ScanType: SynScan, Rate: DefaultRateSynScan, AutoRate: true, Bandwidth: "10M", givenFlags: map[string]struct{}{"rate": {}}}
+	options.configureAutoRate()
+	require.Equal(t, DefaultRateSynScan, options.Rate)
+}
@@ -0,0 +1,191 @@
+package runner
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"github.com/projectdiscovery/clistats"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/projectdiscovery/ratelimit"
+)
+
+const (
+	// bannerQueueSize is the maximum number of open ports waiting for a banner grab
+	bannerQueueSize = 65536
+	// maxBannerSize is the maximum number of bytes read from a service
+	maxBannerSize = 512
+)
+
+type bannerJob struct {
+	ip   string
+	port *port.Port
+}
+
+// bannerGrabber reads service banners of open ports in a worker pool isolated
+// from port discovery, so that slow services don't stall the scan
+type bannerGrabber struct {
+	runner           *Runner
+	jobs             chan bannerJob
+	wg               sync.WaitGroup
+	limiter          *ratelimit.Limiter
+	timeout          time.Duration
+	payload          []byte
+	evidenceOnly     bool // only record the tls certificates, without reading banners
+	identifyServices bool // probe the tcp ports for their service and version
+	certificates     bool // record the tls certificates of the tcp ports for the evidence bundle
+	riskHeuristics   bool // tag the outdated high risk services
+	queued           atomic.Int64
+	dropped          atomic.Int64
+	stop             sync.Once
+}
+
+// newBannerGrabber starts the banner grab workers with their own rate and timeout
+func newBannerGrabber(r *Runner) *bannerGrabber {
+	grabber := &bannerGrabber{
+		runner:           r,
+		jobs:             make(chan bannerJob, bannerQueueSize),
+		limiter:          ratelimit.New(context.Background(), uint(r.options.BannerRate), time.Second),
+		timeout:          time.Duration(r.options.BannerTimeout) * time.Millisecond,
+		payload:          r.rawProbe,
+		evidenceOnly:     r.options.EvidenceOutput != "" && !r.options.Banner && !r.options.ServiceVersion && r.rawProbe == nil,
+		identifyServices: r.options.ServiceVersion && r.rawProbe == nil,
+		certificates:     r.options.EvidenceOutput != "",
+		riskHeuristics:   r.options.RiskHeuristics,
+	}
+	for i := 0; i < r.options.BannerThreads; i++ {
+		grabber.wg.Add(1)
+		go grabber.worker()
+	}
+	return grabber
+}
+
+// Enqueue schedules a banner grab without blocking port discovery, udp ports are only probed
+// with a raw probe payload
+func (grabber *bannerGrabber) Enqueue(ip string, p *port.Port) {
+	if p.Protocol != protocol.TCP && grabber.payload == nil {
+		return
+	}
+	select {
+	case grabber.jobs <- bannerJob{ip: ip, port: p}:
+		grabber.queued.Add(1)
+		grabber.runner.stages.enqueue(StageBanner)
+	default:
+		grabber.dropped.Add(1)
+		gologger.Debug().Msgf("Banner queue full, skipping %s:%d\n", ip, p.Port)
+	}
+}
+
+// Wait stops accepting new jobs and waits for the pending ones to complete
+func (grabber *bannerGrabber) Wait() {
+	grabber.stop.Do(func() {
+		close(grabber.jobs)
+		grabber.wg.Wait()
+		if dropped := grabber.dropped.Load(); dropped > 0 {
+			gologger.Warning().Msgf("Skipped banner grab on %d ports (queue full)\n", dropped)
+		}
+	})
+}
+
+// addStats exposes the banner queue depth
+func (grabber *bannerGrabber) addStats(stats *clistats.Statistics) {
+	stats.AddDynamic("banner_queue", func(_ clistats.StatisticsClient) interface{} {
+		return grabber.queued.Load()
+	})
+}
+
+func (grabber *bannerGrabber) worker() {
+	defer grabber.wg.Done()
+
+	for job := range grabber.jobs {
+		if !grabber.runner.waitTrafficWindow() {
+			grabber.queued.Add(-1)
+			continue
+		}
+		grabber.limiter.Take()
+		start := grabber.runner.stages.start(StageBanner, true)
+		grabber.runner.sockets.acquire()
+		var banner string
+		if !grabber.evidenceOnly {
+			banner = grabber.grab(job.ip, job.port)
+		}
+		// ports are shared among hosts, so the banner is attached to a copy
+		var identified *port.Port
+		if grabber.identifyServices && job.port.Protocol == protocol.TCP {
+			identified = grabber.identify(job.ip, job.port, banner)
+		} else if banner != "" {
+			withBanner := *job.port
+			withBanner.Banner = banner
+			identified = &withBanner
+		}
+		if identified != nil {
+			if grabber.riskHeuristics {
+				identified.Risk = assessRisk(identified)
+			}
+			grabber.runner.scanner.StorePort(job.ip, identified)
+		}
+		// the handshake of a tls service identified above already recorded its certificates
+		if grabber.certificates && job.port.Protocol == protocol.TCP && (identified == nil || !identified.TLS) {
+			grabber.grabCertificates(job.ip, job.port)
+		}
+		grabber.runner.sockets.release()
+		grabber.queued.Add(-1)
+		grabber.runner.stages.done(StageBanner, start)
+	}
+}
+
+// grab reads the initial data sent by the service, or sends the raw probe payload and returns
+// the hex encoding of the response
+func (grabber *bannerGrabber) grab(ip string, p *port.Port) string {
+	conn, err := grabber.runner.scanner.DialPort(ip, p, grabber.timeout)
+	if err != nil {
+		gologger.Debug().Msgf("Couldn't connect to %s:%d for banner grab: %s\n", ip, p.Port, err)
+		return ""
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(grabber.timeout)); err != nil {
+		return ""
+	}
+	if grabber.payload != nil {
+		if _, err := conn.Write(grabber.payload); err != nil {
+			gologger.Debug().Msgf("Couldn't send raw probe to %s:%d: %s\n", ip, p.Port, err)
+			return ""
+		}
+		data := make([]byte, maxBannerSize)
+		n, _ := io.ReadAtLeast(conn, data, 1)
+		return hex.EncodeToString(data[:n])
+	}
+	data, _ := io.ReadAll(io.LimitReader(conn, maxBannerSize))
+	return sanitizeBanner(data)
+}
+
+// waitBanners waits for the pending banner grabs to complete and their results to be recorded
+func (r *Runner) waitBanners() {
+	r.scanner.FlushResults()
+	if r.banners != nil {
+		r.banners.Wait()
+		r.scanner.FlushResults()
+	}
+}
+
+// sanitizeBanner replaces non printable characters and trims the banner
+func sanitizeBanner(data []byte) string {
+	banner := strings.Map(func(r rune) rune {
+		if r == '\r' || r == '\n' || r == '\t' {
+			return ' '
+		}
+		if !unicode.IsPrint(r) {
+			return '.'
+		}
+		return r
+	}, string(data))
+	return strings.TrimSpace(banner)
+}
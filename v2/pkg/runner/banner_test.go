@@ -0,0 +1,88 @@
+package runner
+
+import (
+	"net"
+	"testing"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+	"github.com/projectdiscovery/naabu/v2/pkg/scan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizeBanner(t *testing.T) {
+	require.Equal(t, "SSH-2.0-OpenSSH_8.9", sanitizeBanner([]byte("SSH-2.0-OpenSSH_8.9\r\n")))
+	require.Equal(t, "220 ready .", sanitizeBanner([]byte("220 ready \x00")))
+	require.Equal(t, "", sanitizeBanner(nil))
+}
+
+func TestBannerGrabber(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = conn.Write([]byte("SSH-2.0-OpenSSH_8.9\r\n"))
+			conn.Close()
+		}
+	}()
+
+	r := &Runner{
+		options: &Options{BannerThreads: 1, BannerRate: 10, BannerTimeout: 1000},
+		scanner: &scan.Scanner{ScanResults: result.NewResult()},
+	}
+	r.banners = newBannerGrabber(r)
+	r.scanner.OnPortFound = r.banners.Enqueue
+
+	p := &port.Port{Port: l.Addr().(*net.TCPAddr).Port, Protocol: protocol.TCP}
+	r.scanner.AddPort("127.0.0.1", p)
+	r.waitBanners()
+
+	for hostResult := range r.scanner.ScanResults.GetIPsPorts() {
+		require.Len(t, hostResult.Ports, 1)
+		require.Equal(t, "SSH-2.0-OpenSSH_8.9", hostResult.Ports[0].Banner)
+	}
+	require.Empty(t, p.Banner, "shared port must not be modified")
+}
+
+func TestBannerGrabberRawProbe(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer conn.Close()
+	go func() {
+		buffer := make([]byte, 64)
+		for {
+			n, addr, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			// echoes the payload reversed
+			response := make([]byte, n)
+			for i := range response {
+				response[i] = buffer[n-1-i]
+			}
+			_, _ = conn.WriteTo(response, addr)
+		}
+	}()
+
+	r := &Runner{
+		options:  &Options{BannerThreads: 1, BannerRate: 10, BannerTimeout: 1000},
+		scanner:  &scan.Scanner{ScanResults: result.NewResult()},
+		rawProbe: []byte{0xca, 0xfe, 0x01},
+	}
+	r.banners = newBannerGrabber(r)
+	r.scanner.OnPortFound = r.banners.Enqueue
+
+	r.scanner.AddPort("127.0.0.1", &port.Port{Port: conn.LocalAddr().(*net.UDPAddr).Port, Protocol: protocol.UDP})
+	r.waitBanners()
+
+	for hostResult := range r.scanner.ScanResults.GetIPsPorts() {
+		require.Len(t, hostResult.Ports, 1)
+		require.Equal(t, "01feca", hostResult.Ports[0].Banner)
+	}
+}
@@ -2,13 +2,14 @@ package runner
 
 import (
 	"net"
+	"os"
 	"strings"
 
 	"github.com/projectdiscovery/gologger"
 	"github.com/projectdiscovery/naabu/v2/pkg/privileges"
 	"github.com/projectdiscovery/naabu/v2/pkg/scan"
+	"github.com/projectdiscovery/naabu/v2/pkg/selfupdate"
 	osutil "github.com/projectdiscovery/utils/os"
-	updateutils "github.com/projectdiscovery/utils/update"
 )
 
 const banner = `
@@ -21,6 +22,11 @@ const banner = `
 // Version is the current version of naabu
 const version = `2.2.0`
 
+// Version returns the current version of naabu
+func Version() string {
+	return version
+}
+
 // showBanner is used to show the banner to the user
 func showBanner() {
 	gologger.Print().Msgf("%s\n", banner)
@@ -32,7 +38,7 @@ func showNetworkCapabilities(options *Options) {
 	var accessLevel, scanType string
 
 	switch {
-	case privileges.IsPrivileged && options.ScanType == SynScan:
+	case privileges.IsPrivileged && options.isRawScanType():
 		accessLevel = "root"
 		if osutil.IsLinux() {
 			accessLevel = "CAP_NET_RAW"
@@ -45,6 +51,9 @@ func showNetworkCapabilities(options *Options) {
 		accessLevel = "non root"
 		scanType = "CONNECT"
 	}
+	if options.ScanType == UDPScan && !options.Passive {
+		scanType = "UDP"
+	}
 
 	switch {
 	case options.OnlyHostDiscovery:
@@ -80,20 +89,59 @@ func showNetworkInterfaces() error {
 		}
 		gologger.Info().Msgf("Interface %s:\nMAC: %s\nAddresses: %s\nMTU: %d\nFlags: %s\n", itf.Name, itf.HardwareAddr, strings.Join(addrstr, " "), itf.MTU, itf.Flags.String())
 	}
-	// External ip
+
+	return nil
+}
+
+// reportScanFallback repeats in the final summary that the requested syn scan fell back to a connect scan
+func (r *Runner) reportScanFallback() {
+	if r.options.scanFallback == "" {
+		return
+	}
+	gologger.Warning().Str("reason", r.options.scanFallback).Msgf("Scanned with CONNECT instead of the requested SYN scan: %s\n", r.options.scanFallback)
+}
+
+// showExternalIP shows the public ip obtained from an external api
+func showExternalIP() {
 	externalIP, err := scan.WhatsMyIP()
 	if err != nil {
 		gologger.Warning().Msgf("Could not obtain public ip: %s\n", err)
 	}
 	gologger.Info().Msgf("External Ip: %s\n", externalIP)
+}
 
-	return nil
+// checkPinnedVersion warns if the running version differs from the version the fleet is pinned
+// to, without looking up the latest release
+func (options *Options) checkPinnedVersion() {
+	pinned := strings.TrimPrefix(options.PinVersion, "v")
+	if pinned == version {
+		gologger.Info().Msgf("Current naabu version %s (pinned)\n", version)
+		return
+	}
+	gologger.Warning().Str("pinned", pinned).Msgf("Current naabu version %s doesn't match the pinned version %s, run naabu update -version %s\n", version, pinned, pinned)
 }
 
-// GetUpdateCallback returns a callback function that updates naabu
+// GetUpdateCallback returns a callback function that updates naabu to the latest release, verified
+// like naabu update with the trusted public key
 func GetUpdateCallback() func() {
 	return func() {
 		showBanner()
-		updateutils.GetUpdateToolCallback("naabu", version)()
+		key, err := selfupdate.TrustedKey()
+		if err != nil {
+			gologger.Fatal().Msgf("Could not update naabu: %s\n", err)
+		}
+		if key == nil {
+			gologger.Fatal().Msgf("Could not update naabu: no trusted public key, run naabu update -public-key naabu.pub\n")
+		}
+		installed, err := selfupdate.New(selfupdate.DefaultBaseURL, key).Update("", version)
+		switch {
+		case err != nil:
+			gologger.Fatal().Msgf("Could not update naabu: %s\n", err)
+		case installed == "":
+			gologger.Info().Msgf("naabu %s is already installed\n", version)
+		default:
+			gologger.Info().Msgf("Updated naabu from %s to %s\n", version, installed)
+		}
+		os.Exit(0)
 	}
 }
\ No newline at end of file
@@ -0,0 +1,181 @@
+package runner
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+	iputil "github.com/projectdiscovery/utils/ip"
+)
+
+// baselineEntry declares the ports expected open on a hostname or on each address of a range
+type baselineEntry struct {
+	host    string
+	network *net.IPNet
+	ports   map[string]struct{} // port/protocol
+	found   bool                // results were found for the entry
+}
+
+// single returns true if the entry is a hostname or a single address
+func (entry *baselineEntry) single() bool {
+	if entry.network == nil {
+		return true
+	}
+	ones, bits := entry.network.Mask.Size()
+	return ones == bits
+}
+
+func (entry *baselineEntry) String() string {
+	if entry.network == nil {
+		return entry.host
+	}
+	return entry.network.String()
+}
+
+// baseline is the allowlist of the expected open ports, the output only keeps the unexpected
+// ones. Each line holds a hostname, ip or cidr followed by its expected ports, none if omitted:
+//
+//	10.0.0.0/24 22,443
+//	db.example.com 5432
+//	10.0.0.10 53/udp,53
+//	# comments and empty lines are ignored
+type baseline struct {
+	entries []*baselineEntry
+}
+
+// loadBaseline reads the baseline file
+func loadBaseline(path string) (*baseline, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseBaseline(f)
+}
+
+// parseBaseline parses "target [ports]" lines, the protocol of the ports defaults to tcp
+func parseBaseline(reader io.Reader) (*baseline, error) {
+	expected := &baseline{}
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("invalid baseline at line %d: expected \"target ports\"", line)
+		}
+		entry := &baselineEntry{ports: make(map[string]struct{})}
+		if iputil.IsIP(fields[0]) || iputil.IsCIDR(fields[0]) {
+			entry.network = iputil.ToCidr(fields[0])
+		}
+		if entry.network == nil {
+			entry.host = canonicalHost(fields[0])
+		}
+		if len(fields) == 2 {
+			for _, value := range strings.Split(fields[1], ",") {
+				number, protocolName, _ := strings.Cut(strings.ToLower(value), "/")
+				if protocolName == "" {
+					protocolName = "tcp"
+				}
+				if portNumber, err := strconv.Atoi(number); err != nil || portNumber <= 0 || portNumber > 65535 {
+					return nil, fmt.Errorf("invalid port %s at line %d", value, line)
+				}
+				if protocolName != "tcp" && protocolName != "udp" {
+					return nil, fmt.Errorf("invalid protocol %s at line %d (allowed: tcp, udp)", protocolName, line)
+				}
+				entry.ports[number+"/"+protocolName] = struct{}{}
+			}
+		}
+		expected.entries = append(expected.entries, entry)
+	}
+	return expected, scanner.Err()
+}
+
+// match returns the entries declaring the hostname or containing the ip
+func (b *baseline) match(host, ip string) []*baselineEntry {
+	var matched []*baselineEntry
+	name, parsedIP := canonicalHost(host), net.ParseIP(ip)
+	for _, entry := range b.entries {
+		if (entry.network == nil && entry.host == name) || (entry.network != nil && parsedIP != nil && entry.network.Contains(parsedIP)) {
+			matched = append(matched, entry)
+		}
+	}
+	return matched
+}
+
+// expected returns true if the port is declared on any entry matching the host or its ip
+func (b *baseline) expected(host, ip string, p *port.Port) bool {
+	key := fmt.Sprintf("%d/%s", p.Port, p.Protocol.String())
+	for _, entry := range b.match(host, ip) {
+		if _, ok := entry.ports[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// missing returns the sorted ports expected on the host or its ip but not found open, and marks
+// the matching entries as found
+func (b *baseline) missing(host, ip string, ports []*port.Port) []string {
+	open := make(map[string]struct{}, len(ports))
+	for _, p := range ports {
+		open[fmt.Sprintf("%d/%s", p.Port, p.Protocol.String())] = struct{}{}
+	}
+	absent := make(map[string]struct{})
+	for _, entry := range b.match(host, ip) {
+		entry.found = true
+		for key := range entry.ports {
+			if _, ok := open[key]; !ok {
+				absent[key] = struct{}{}
+			}
+		}
+	}
+	missing := make([]string, 0, len(absent))
+	for key := range absent {
+		missing = append(missing, key)
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// reportBaseline warns about the expected ports not found open. The ranges are only checked on
+// the addresses having open ports, as most addresses of a range are usually unused
+func (r *Runner) reportBaseline(scanResults *result.Result) {
+	if r.baseline == nil || r.options.OnlyHostDiscovery {
+		return
+	}
+	for _, entry := range r.baseline.entries {
+		entry.found = false
+	}
+	for hostResult := range scanResults.GetIPsPorts() {
+		hosts, err := r.getResultHosts(hostResult)
+		if err != nil {
+			continue
+		}
+		for _, host := range hosts {
+			if missing := r.baseline.missing(host, hostResult.IP, hostResult.Ports); len(missing) > 0 {
+				gologger.Warning().Msgf("Expected ports not found open on %s (%s): %s\n", host, hostResult.IP, strings.Join(missing, ","))
+			}
+		}
+	}
+	for _, entry := range r.baseline.entries {
+		if !entry.found && entry.single() && len(entry.ports) > 0 {
+			missing := make([]string, 0, len(entry.ports))
+			for key := range entry.ports {
+				missing = append(missing, key)
+			}
+			sort.Strings(missing)
+			gologger.Warning().Msgf("Expected ports not found open on %s: %s\n", entry, strings.Join(missing, ","))
+		}
+	}
+}
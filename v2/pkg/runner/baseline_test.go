@@ -0,0 +1,52 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaseline(t *testing.T) {
+	expected, err := parseBaseline(strings.NewReader(`# allowlist
+10.0.0.0/24 22,443
+DB.example.com. 5432
+10.0.0.10 53/udp,53
+10.0.1.1
+`))
+	require.Nil(t, err)
+	require.Len(t, expected.entries, 4)
+
+	ssh := &port.Port{Port: 22, Protocol: protocol.TCP}
+	dns := &port.Port{Port: 53, Protocol: protocol.UDP}
+	postgres := &port.Port{Port: 5432, Protocol: protocol.TCP}
+	require.True(t, expected.expected("10.0.0.5", "10.0.0.5", ssh))
+	require.True(t, expected.expected("10.0.0.10", "10.0.0.10", dns))
+	require.False(t, expected.expected("10.0.0.5", "10.0.0.5", dns))
+	require.True(t, expected.expected("db.example.com", "192.0.2.1", postgres))
+	require.False(t, expected.expected("192.0.2.1", "192.0.2.1", postgres))
+	require.False(t, expected.expected("10.0.1.1", "10.0.1.1", ssh))
+
+	require.Equal(t, []string{"443/tcp", "53/tcp"}, expected.missing("10.0.0.10", "10.0.0.10", []*port.Port{ssh, dns}))
+	require.Empty(t, expected.missing("192.0.2.2", "192.0.2.2", []*port.Port{ssh}))
+	require.True(t, expected.entries[0].found)
+	require.False(t, expected.entries[1].found)
+	require.True(t, expected.entries[1].single())
+	require.False(t, expected.entries[0].single())
+
+	for _, invalid := range []string{"10.0.0.1 22 443", "10.0.0.1 ssh", "10.0.0.1 22/sctp"} {
+		_, err := parseBaseline(strings.NewReader(invalid))
+		require.NotNil(t, err, invalid)
+	}
+}
+
+func TestFilterPortsBaseline(t *testing.T) {
+	expected, err := parseBaseline(strings.NewReader("10.0.0.1 22\n"))
+	require.Nil(t, err)
+	r := &Runner{options: &Options{}, baseline: expected}
+	ports := []*port.Port{{Port: 22, Protocol: protocol.TCP}, {Port: 3389, Protocol: protocol.TCP}}
+	require.Equal(t, []int{3389}, portNumbers(r.filterPorts("10.0.0.1", "10.0.0.1", ports, false, "")))
+	require.Equal(t, []int{22, 3389}, portNumbers(r.filterPorts("10.0.0.2", "10.0.0.2", ports, false, "")))
+}
@@ -0,0 +1,93 @@
+package runner
+
+import (
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/projectdiscovery/naabu/v2/pkg/scan"
+)
+
+// portBurst returns the number of ports of a host probed back-to-back, bursts needing raw packets
+func (options *Options) portBurst(useRawPackets bool) int {
+	if options.PortBurst > 1 && useRawPackets {
+		return options.PortBurst
+	}
+	return 1
+}
+
+// pickPorts returns the count ports starting at the index
+func (r *Runner) pickPorts(index, count int) []*port.Port {
+	ports := make([]*port.Port, 0, count)
+	for i := index; i < index+count; i++ {
+		ports = append(ports, r.PickPort(i))
+	}
+	return ports
+}
+
+// ambiguousPorts returns the ports of the ip adaptive and backoff retries probe again. The regular
+// retries resend every unanswered probe, silent hosts included, the extra retries of the adaptive
+// strategy only the unanswered ports of the hosts that answered other probes
+func (r *Runner) ambiguousPorts(ip string, ports []*port.Port, retry int) []*port.Port {
+	extra := r.backoff == nil && retry >= r.options.Retries
+	var ambiguous []*port.Port
+	for _, p := range ports {
+		if extra {
+			if r.responses.isAmbiguous(ip, p) {
+				ambiguous = append(ambiguous, p)
+			}
+		} else if !r.responses.isAnswered(ip, p) {
+			ambiguous = append(ambiguous, p)
+		}
+	}
+	return ambiguous
+}
+
+// scheduleBurst probes the ports of the ip, the raw probes being queued back-to-back so that the
+// sender reuses the route and the responses of the host arrive together
+func (r *Runner) scheduleBurst(ip string, ports []*port.Port, useRawPackets bool) {
+	// the probes to a rate limited asn are queued one by one to stay within its rate
+	if len(ports) == 1 || (r.asnRates != nil && r.asnRates.Lookup(ip) != nil) {
+		for _, p := range ports {
+			p := p
+			r.scheduleProbe(ip, func() {
+				r.durations.add(ip, time.Now())
+				r.enumerate(ip, p, useRawPackets)
+			})
+		}
+		return
+	}
+	r.durations.add(ip, time.Now())
+	r.burstEnumeration(ip, ports)
+}
+
+// burstEnumeration sends the raw probes of the ports in a single burst
+func (r *Runner) burstEnumeration(ip string, ports []*port.Port) {
+	var tcpPorts, udpPorts []*port.Port
+	for _, p := range ports {
+		switch {
+		case r.isConnectPort(p):
+			r.enumerate(ip, p, true)
+		case !r.canIScanIfCDN(ip, p):
+			gologger.Debug().Msgf("Skipping cdn target: %s:%d\n", ip, p.Port)
+		case p.Protocol == protocol.TCP:
+			tcpPorts = append(tcpPorts, p)
+		case p.Protocol == protocol.UDP:
+			udpPorts = append(udpPorts, p)
+		}
+	}
+	probes := len(tcpPorts) + len(udpPorts)
+	for i := 0; i < probes; i++ {
+		r.limiter.Take()
+	}
+	r.probesSent.Add(uint64(probes))
+	start := r.stages.start(StageScan, false)
+	defer r.stages.done(StageScan, start)
+	if len(tcpPorts) > 0 {
+		r.scanner.EnqueueTCP(ip, scan.Syn, tcpPorts...)
+	}
+	if len(udpPorts) > 0 {
+		r.scanner.EnqueueUDP(ip, udpPorts...)
+	}
+}
@@ -0,0 +1,123 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/projectdiscovery/naabu/v2/pkg/scan"
+)
+
+const (
+	// canaryProbes is the number of probes sent to the canary at each interval
+	canaryProbes = 10
+	// canaryLossThreshold is the loss ratio above which a warning is shown
+	canaryLossThreshold = 0.05
+)
+
+// canarySample is the outcome of a canary measurement
+type canarySample struct {
+	sent    int
+	lost    int
+	latency time.Duration
+	pps     float64
+}
+
+// Loss returns the ratio of canary probes without answer
+func (sample *canarySample) Loss() float64 {
+	if sample.sent == 0 {
+		return 0
+	}
+	return float64(sample.lost) / float64(sample.sent)
+}
+
+// startCanary periodically probes the operator controlled canary while the scan is running
+// to measure the achieved rate and the end-to-end loss
+func (r *Runner) startCanary(ctx context.Context) {
+	if r.options.Canary == "" {
+		return
+	}
+	interval := time.Duration(r.options.CanaryInterval) * time.Second
+	timeout := time.Duration(r.options.Timeout) * time.Millisecond
+	host, p, err := canaryAddress(r.options.Canary)
+	if err != nil {
+		return
+	}
+	// the canary is dialed like the probes, through the proxy and recorded in the activity log
+	dial := func() (net.Conn, error) {
+		return r.scanner.DialPort(host, p, timeout)
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		lastSent, lastTime := r.probesSent.Load(), time.Now()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if !r.scanner.Phase.Is(scan.Scan) {
+				lastSent, lastTime = r.probesSent.Load(), time.Now()
+				continue
+			}
+
+			sample := probeCanary(ctx, dial)
+			sent, now := r.probesSent.Load(), time.Now()
+			sample.pps = float64(sent-lastSent) / now.Sub(lastTime).Seconds()
+			lastSent, lastTime = sent, now
+			r.reportCanary(sample)
+		}
+	}()
+}
+
+// canaryAddress returns the host and tcp port of the canary (host:port)
+func canaryAddress(address string) (string, *port.Port, error) {
+	host, value, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", nil, err
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number <= 0 || number > 65535 {
+		return "", nil, fmt.Errorf("invalid port %s", value)
+	}
+	return host, &port.Port{Port: number, Protocol: protocol.TCP}, nil
+}
+
+// probeCanary connects to the canary and measures the loss and average latency
+func probeCanary(ctx context.Context, dial func() (net.Conn, error)) *canarySample {
+	sample := &canarySample{}
+	var total time.Duration
+	for i := 0; i < canaryProbes && ctx.Err() == nil; i++ {
+		sample.sent++
+		start := time.Now()
+		conn, err := dial()
+		if err != nil {
+			sample.lost++
+			continue
+		}
+		total += time.Since(start)
+		conn.Close()
+	}
+	if answered := sample.sent - sample.lost; answered > 0 {
+		sample.latency = total / time.Duration(answered)
+	}
+	return sample
+}
+
+func (r *Runner) reportCanary(sample *canarySample) {
+	loss := sample.Loss() * 100
+	if sample.Loss() > canaryLossThreshold {
+		r.probesDropped.Store(true)
+		gologger.Warning().Msgf("Canary %s: %.0f%% of probes lost at %.0f pps, your uplink may be dropping probes (consider lowering -rate)\n", r.options.Canary, loss, sample.pps)
+		return
+	}
+	gologger.Verbose().Msgf("Canary %s: %.0f%% loss, %s latency at %.0f pps\n", r.options.Canary, loss, sample.latency, sample.pps)
+}
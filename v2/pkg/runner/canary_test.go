@@ -0,0 +1,43 @@
+package runner
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbeCanary(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	address := l.Addr().String()
+	dial := func() (net.Conn, error) {
+		return net.DialTimeout("tcp", address, time.Second)
+	}
+	sample := probeCanary(context.Background(), dial)
+	require.Equal(t, canaryProbes, sample.sent)
+	require.Zero(t, sample.Loss())
+
+	l.Close()
+	sample = probeCanary(context.Background(), dial)
+	require.Equal(t, float64(1), sample.Loss())
+
+	host, p, err := canaryAddress("192.0.2.1:8443")
+	require.Nil(t, err)
+	require.Equal(t, "192.0.2.1", host)
+	require.Equal(t, 8443, p.Port)
+	_, _, err = canaryAddress("192.0.2.1:https")
+	require.NotNil(t, err)
+}
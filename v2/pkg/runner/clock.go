@@ -0,0 +1,91 @@
+package runner
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// timestampClocks are the common tcp timestamp clock frequencies (Hz) of network stacks
+var timestampClocks = []float64{10, 100, 128, 200, 250, 500, 1000}
+
+const (
+	// minClockElapsed is the minimum time between two timestamps of a host to estimate its clock
+	minClockElapsed = 500 * time.Millisecond
+	// clockTolerance is the maximum relative difference between the measured and a common frequency
+	clockTolerance = 0.1
+)
+
+// clockSample is a tcp timestamp value received from a host
+type clockSample struct {
+	at    time.Time
+	tsval uint32
+}
+
+// clockHint is the uptime and clock skew of a host estimated from its tcp timestamps
+type clockHint struct {
+	hz     float64
+	uptime float64 // seconds
+	skew   float64 // ppm
+}
+
+// clockTracker records the first and last tcp timestamp values received from each host, which
+// distinguish freshly rebooted hosts from long lived ones. Stacks randomizing the timestamp offset
+// per destination still yield the clock skew, their uptime is meaningless
+type clockTracker struct {
+	sync.Mutex
+	hosts map[string][2]clockSample
+}
+
+func newClockTracker() *clockTracker {
+	return &clockTracker{hosts: make(map[string][2]clockSample)}
+}
+
+// add records the timestamp value received from the host
+func (t *clockTracker) add(ip string, at time.Time, tsval uint32) {
+	t.Lock()
+	defer t.Unlock()
+
+	samples, ok := t.hosts[ip]
+	if !ok {
+		samples[0] = clockSample{at: at, tsval: tsval}
+	}
+	samples[1] = clockSample{at: at, tsval: tsval}
+	t.hosts[ip] = samples
+}
+
+// hint returns the clock estimate of the host, nil if its timestamps were not conclusive
+func (t *clockTracker) hint(ip string) *clockHint {
+	if t == nil {
+		return nil
+	}
+	t.Lock()
+	samples, ok := t.hosts[ip]
+	t.Unlock()
+	if !ok {
+		return nil
+	}
+	return estimateClock(samples[0], samples[1])
+}
+
+// estimateClock returns the clock estimate from two timestamps of a host, matching the measured
+// frequency to a common one
+func estimateClock(first, last clockSample) *clockHint {
+	elapsed := last.at.Sub(first.at)
+	ticks := last.tsval - first.tsval // wraps around
+	if elapsed < minClockElapsed || ticks == 0 {
+		return nil
+	}
+	measured := float64(ticks) / elapsed.Seconds()
+	for _, hz := range timestampClocks {
+		if math.Abs(measured-hz)/hz > clockTolerance {
+			continue
+		}
+		return &clockHint{
+			hz:     hz,
+			uptime: math.Round(float64(last.tsval) / hz),
+			skew:   math.Round((measured-hz)/hz*1e7) / 10,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClockTracker(t *testing.T) {
+	clocks := newClockTracker()
+	start := time.Now()
+
+	// a 1000Hz clock up for a day running 100ppm fast
+	clocks.add("10.0.0.1", start, 86400000)
+	clocks.add("10.0.0.1", start.Add(time.Second), 86401000)
+	clocks.add("10.0.0.1", start.Add(10*time.Second), 86410001)
+	hint := clocks.hint("10.0.0.1")
+	require.NotNil(t, hint)
+	require.Equal(t, float64(1000), hint.hz)
+	require.Equal(t, float64(86410), hint.uptime)
+	require.Equal(t, 100.0, hint.skew)
+
+	// too close samples are not conclusive
+	clocks.add("10.0.0.2", start, 500)
+	clocks.add("10.0.0.2", start.Add(100*time.Millisecond), 510)
+	require.Nil(t, clocks.hint("10.0.0.2"))
+	require.Nil(t, clocks.hint("10.0.0.3"))
+
+	// wrapped around timestamps of a 100Hz clock
+	hint = estimateClock(clockSample{at: start, tsval: 0xffffffff - 49}, clockSample{at: start.Add(time.Second), tsval: 50})
+	require.NotNil(t, hint)
+	require.Equal(t, float64(100), hint.hz)
+
+	var disabled *clockTracker
+	require.Nil(t, disabled.hint("10.0.0.1"))
+}
@@ -0,0 +1,93 @@
+package runner
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// completionFlag is a flag offered by the shell completions
+type completionFlag struct {
+	name  string
+	usage string
+}
+
+// completionFlags returns the short and long names of all the flags, sorted
+func completionFlags() []completionFlag {
+	var flags []completionFlag
+	newFlagSet(&Options{}).CommandLine.VisitAll(func(f *flag.Flag) {
+		flags = append(flags, completionFlag{name: f.Name, usage: f.Usage})
+	})
+	sort.Slice(flags, func(i, j int) bool {
+		return flags[i].name < flags[j].name
+	})
+	return flags
+}
+
+// Completion returns the completion script of the shell for the flags and the subcommands
+func Completion(shell string, subcommands []string) (string, error) {
+	flags := completionFlags()
+	switch strings.ToLower(shell) {
+	case "bash":
+		return bashCompletion(flags, subcommands), nil
+	case "zsh":
+		return zshCompletion(flags, subcommands), nil
+	case "fish":
+		return fishCompletion(flags, subcommands), nil
+	default:
+		return "", fmt.Errorf("invalid shell %s (allowed: bash, zsh, fish)", shell)
+	}
+}
+
+func bashCompletion(flags []completionFlag, subcommands []string) string {
+	names := make([]string, 0, len(flags))
+	for _, f := range flags {
+		names = append(names, "-"+f.name)
+	}
+	var builder strings.Builder
+	builder.WriteString("# bash completion for naabu, source it from ~/.bashrc\n")
+	builder.WriteString("_naabu() {\n")
+	builder.WriteString("\tlocal cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	builder.WriteString("\tif [[ ${cur} == -* ]]; then\n")
+	fmt.Fprintf(&builder, "\t\tCOMPREPLY=($(compgen -W %q -- \"${cur}\"))\n", strings.Join(names, " "))
+	builder.WriteString("\telif [[ ${COMP_CWORD} -eq 1 ]]; then\n")
+	fmt.Fprintf(&builder, "\t\tCOMPREPLY=($(compgen -W %q -f -- \"${cur}\"))\n", strings.Join(subcommands, " "))
+	builder.WriteString("\telse\n")
+	builder.WriteString("\t\tCOMPREPLY=($(compgen -f -- \"${cur}\"))\n")
+	builder.WriteString("\tfi\n")
+	builder.WriteString("}\n")
+	builder.WriteString("complete -o filenames -F _naabu naabu\n")
+	return builder.String()
+}
+
+func zshCompletion(flags []completionFlag, subcommands []string) string {
+	escape := strings.NewReplacer("'", `'\''`, "[", `\[`, "]", `\]`, ":", `\:`)
+	var builder strings.Builder
+	builder.WriteString("#compdef naabu\n")
+	builder.WriteString("# zsh completion for naabu, write it as _naabu in a directory of $fpath\n")
+	builder.WriteString("_naabu() {\n")
+	builder.WriteString("\t_arguments \\\n")
+	for _, f := range flags {
+		fmt.Fprintf(&builder, "\t\t'-%s[%s]' \\\n", f.name, escape.Replace(f.usage))
+	}
+	fmt.Fprintf(&builder, "\t\t'1::subcommand:(%s)' \\\n", strings.Join(subcommands, " "))
+	builder.WriteString("\t\t'*:file:_files'\n")
+	builder.WriteString("}\n")
+	// autoloaded from $fpath the file is the body of _naabu, sourced it registers the function
+	builder.WriteString("if [ \"$funcstack[1]\" = \"_naabu\" ]; then\n\t_naabu \"$@\"\nelse\n\tcompdef _naabu naabu\nfi\n")
+	return builder.String()
+}
+
+func fishCompletion(flags []completionFlag, subcommands []string) string {
+	escape := strings.NewReplacer(`\`, `\\`, "'", `\'`)
+	var builder strings.Builder
+	builder.WriteString("# fish completion for naabu, write it to ~/.config/fish/completions/naabu.fish\n")
+	for _, subcommand := range subcommands {
+		fmt.Fprintf(&builder, "complete -c naabu -n '__fish_use_subcommand' -a %s\n", subcommand)
+	}
+	for _, f := range flags {
+		fmt.Fprintf(&builder, "complete -c naabu -o %s -d '%s'\n", f.name, escape.Replace(f.usage))
+	}
+	return builder.String()
+}
@@ -0,0 +1,26 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompletion(t *testing.T) {
+	subcommands := []string{"diff", "init"}
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		script, err := Completion(shell, subcommands)
+		require.Nil(t, err, shell)
+		require.Contains(t, script, "top-ports", shell)
+		require.Contains(t, script, "tp", shell)
+		require.Contains(t, script, "init", shell)
+	}
+
+	script, err := Completion("zsh", subcommands)
+	require.Nil(t, err)
+	require.Contains(t, script, `'-host[hosts to scan ports for (comma-separated)]'`)
+	require.Contains(t, script, `(example\: -nmap-cli '\''nmap -sV'\'')`)
+
+	_, err = Completion("powershell", subcommands)
+	require.NotNil(t, err)
+}
@@ -0,0 +1,72 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+)
+
+// countResult is the number of open ports and affected hosts
+type countResult struct {
+	Ports int `json:"ports"`
+	Hosts int `json:"hosts"`
+}
+
+// handleCountOutput writes only the number of open ports and affected hosts
+func (r *Runner) handleCountOutput(scanResults *result.Result, file *os.File) {
+	count := &countResult{}
+
+	if scanResults.HasIPsPorts() {
+		for hostResult := range scanResults.GetIPsPorts() {
+			isCDNIP, cdnName, _ := r.scanner.CdnCheck(hostResult.IP)
+			ports := r.filterHostPorts(hostResult.IP, hostResult.IP, hostResult.Ports, isCDNIP, cdnName)
+			if len(ports) == 0 {
+				continue
+			}
+			count.Hosts++
+			count.Ports += len(ports)
+			r.onResult(&result.HostResult{IP: hostResult.IP, Ports: ports})
+		}
+	} else {
+		for hostIP := range scanResults.GetIPs() {
+			count.Hosts++
+			r.onResult(&result.HostResult{IP: hostIP})
+		}
+	}
+
+	line, err := count.format(r.options.JSON)
+	if err != nil {
+		gologger.Error().Msgf("Could not format count: %s\n", err)
+		return
+	}
+	gologger.Silent().Msgf("%s\n", line)
+	if file != nil {
+		if err := WriteCountOutput(count.Ports, count.Hosts, r.options.JSON, file); err != nil {
+			gologger.Error().Msgf("Could not write results to file %s: %s\n", r.options.Output, err)
+			r.recordError(ErrorOutput, r.options.Output, err)
+		}
+	}
+}
+
+func (count *countResult) format(asJSON bool) (string, error) {
+	if asJSON {
+		data, err := json.Marshal(count)
+		return string(data), err
+	}
+	return fmt.Sprintf("%d open ports on %d hosts", count.Ports, count.Hosts), nil
+}
+
+// WriteCountOutput writes the number of open ports and affected hosts to an io.Writer
+func WriteCountOutput(ports, hosts int, asJSON bool, writer io.Writer) error {
+	count := &countResult{Ports: ports, Hosts: hosts}
+	line, err := count.format(asJSON)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(writer, line+"\n")
+	return err
+}
@@ -0,0 +1,18 @@
+package runner
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCountOutput(t *testing.T) {
+	buf := bytes.Buffer{}
+	require.Nil(t, WriteCountOutput(5, 2, false, &buf))
+	require.Equal(t, "5 open ports on 2 hosts\n", buf.String())
+
+	buf.Reset()
+	require.Nil(t, WriteCountOutput(5, 2, true, &buf))
+	require.Equal(t, "{\"ports\":5,\"hosts\":2}\n", buf.String())
+}
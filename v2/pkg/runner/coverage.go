@@ -0,0 +1,122 @@
+package runner
+
+import (
+	"errors"
+	"math"
+	"syscall"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+	"github.com/projectdiscovery/naabu/v2/pkg/scan"
+)
+
+// confidenceZ is the z-score of the 95% confidence interval of the open ports estimate
+const confidenceZ = 1.96
+
+// coverage summarizes how much of the scope was effectively probed
+type coverage struct {
+	sent     uint64 // port probes sent
+	answered uint64 // probes answered by the target (open or closed)
+	open     uint64 // open ports found
+	hosts    int    // hosts with open ports
+	sampled  uint64 // host:port pairs of the sample, zero for full scans
+	total    uint64 // host:port pairs of the full scope
+}
+
+// answeredRatio returns the ratio of probes that were answered
+func (c *coverage) answeredRatio() float64 {
+	if c.sent == 0 {
+		return 0
+	}
+	return math.Min(float64(c.answered)/float64(c.sent), 1)
+}
+
+// estimate extrapolates the open ports of the sample to the full scope, returning
+// the estimate and the bounds of its 95% confidence interval
+func (c *coverage) estimate() (estimate, low, high uint64) {
+	if c.sampled == 0 {
+		return c.open, c.open, c.open
+	}
+	ratio := float64(c.open) / float64(c.sampled)
+	lowRatio, highRatio := wilsonInterval(c.open, c.sampled, confidenceZ)
+	scale := float64(c.total)
+	return uint64(math.Round(ratio * scale)), uint64(math.Floor(lowRatio * scale)), uint64(math.Ceil(highRatio * scale))
+}
+
+// wilsonInterval returns the wilson score interval of a binomial proportion
+func wilsonInterval(successes, trials uint64, z float64) (float64, float64) {
+	if trials == 0 {
+		return 0, 0
+	}
+	n := float64(trials)
+	p := float64(successes) / n
+	z2 := z * z
+	center := (p + z2/(2*n)) / (1 + z2/n)
+	margin := z * math.Sqrt(p*(1-p)/n+z2/(4*n*n)) / (1 + z2/n)
+	return math.Max(center-margin, 0), math.Min(center+margin, 1)
+}
+
+// tracksResponses returns true if the header fields of the responses are used, the answered
+// probes being only counted otherwise
+func (r *Runner) tracksResponses() bool {
+	return r.responses != nil || r.research != nil || r.clocks != nil || r.evidence != nil || r.durations != nil || r.anycast != nil
+}
+
+// onAnswer counts the answered raw probes
+func (r *Runner) onAnswer() {
+	r.probesAnswered.Add(1)
+}
+
+// onResponse counts and tracks the answered raw probes and records them in the research output
+func (r *Runner) onResponse(response *scan.Response) {
+	r.probesAnswered.Add(1)
+	if r.responses != nil {
+		r.responses.add(response.IP, response.Port)
+	}
+	if r.research != nil {
+		r.research.Write(response)
+	}
+	if r.clocks != nil && response.TSval != 0 {
+		r.clocks.add(response.IP, response.Time, response.TSval)
+	}
+	if r.evidence != nil {
+		r.evidence.add(response)
+	}
+	r.durations.add(response.IP, response.Time)
+	if r.anycast != nil {
+		r.anycast.addTTL(response.IP, response.TTL, response.Flags)
+	}
+}
+
+// isAnswered returns true if the connect probe was answered by the target, either accepted or refused
+func isAnswered(open bool, err error) bool {
+	return (open && err == nil) || errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// reportCoverage shows the estimated coverage in the final summary of sampled scans and scans
+// where probes may have been dropped or rate limited
+func (r *Runner) reportCoverage(results *result.Result, sampled, total uint64) {
+	if r.sample == nil && !r.probesDropped.Load() && r.errors.count(ErrorNetwork) == 0 {
+		return
+	}
+	r.scanner.FlushResults()
+	c := &coverage{
+		sent:     r.probesSent.Load(),
+		answered: r.probesAnswered.Load(),
+		total:    total,
+	}
+	if r.sample != nil {
+		c.sampled = sampled
+	}
+	for hostResult := range results.GetIPsPorts() {
+		c.open += uint64(len(hostResult.Ports))
+		c.hosts++
+	}
+
+	gologger.Info().Msgf("Coverage: %d of %d probes answered (%.2f%%)\n", c.answered, c.sent, c.answeredRatio()*100)
+	if c.sampled > 0 && c.total > 0 {
+		estimate, low, high := c.estimate()
+		gologger.Info().Msgf("Sampled %d of %d host:port pairs (%.2f%%): %d open ports on %d hosts, estimated %d open ports in the full scope (95%% CI %d-%d)\n",
+			c.sampled, c.total, float64(c.sampled)*100/float64(c.total), c.open, c.hosts, estimate, low, high)
+	}
+}
@@ -0,0 +1,54 @@
+package runner
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWilsonInterval(t *testing.T) {
+	low, high := wilsonInterval(10, 100, confidenceZ)
+	require.InDelta(t, 0.0552, low, 0.0001)
+	require.InDelta(t, 0.1744, high, 0.0001)
+
+	low, high = wilsonInterval(0, 100, confidenceZ)
+	require.Equal(t, 0.0, low)
+	require.InDelta(t, 0.0370, high, 0.0001)
+
+	low, high = wilsonInterval(0, 0, confidenceZ)
+	require.Equal(t, 0.0, low)
+	require.Equal(t, 0.0, high)
+}
+
+func TestCoverageEstimate(t *testing.T) {
+	c := &coverage{sent: 200, answered: 150, open: 10, sampled: 100, total: 10000}
+	require.Equal(t, 0.75, c.answeredRatio())
+	estimate, low, high := c.estimate()
+	require.Equal(t, uint64(1000), estimate)
+	require.Equal(t, uint64(552), low)
+	require.Equal(t, uint64(1744), high)
+
+	full := &coverage{sent: 100, answered: 120, open: 3}
+	require.Equal(t, 1.0, full.answeredRatio())
+	estimate, low, high = full.estimate()
+	require.Equal(t, []uint64{3, 3, 3}, []uint64{estimate, low, high})
+}
+
+func TestIsAnswered(t *testing.T) {
+	require.True(t, isAnswered(true, nil))
+	require.True(t, isAnswered(false, syscall.ECONNREFUSED))
+	require.False(t, isAnswered(false, errors.New("i/o timeout")))
+	require.False(t, isAnswered(false, nil))
+}
+
+func TestTracksResponses(t *testing.T) {
+	r := &Runner{}
+	require.False(t, r.tracksResponses())
+	r.onAnswer()
+	require.EqualValues(t, 1, r.probesAnswered.Load())
+
+	r.responses = newResponseTracker()
+	require.True(t, r.tracksResponses())
+}
@@ -10,7 +10,15 @@ const (
 	DefaultRetriesSynScan     = 3
 	DefaultRetriesConnectScan = 3
 
+	DefaultBannerThreads = 25
+	DefaultBannerRate    = 100
+	DefaultBannerTimeout = 3000
+
+	// DefaultFairnessWindow is the window of the prefix rate when no fairness window is given
+	DefaultFairnessWindow = 1024
+
 	SynScan             = "s"
 	ConnectScan         = "c"
+	UDPScan             = "u"
 	DefautStatsInterval = 5
 )
@@ -0,0 +1,98 @@
+package runner
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/scan"
+	"github.com/projectdiscovery/naabu/v2/pkg/schema"
+)
+
+// discoveryResult is an alive host found during host discovery
+type discoveryResult struct {
+	IP            string    `json:"ip"`
+	Probe         string    `json:"probe,omitempty"`
+	Port          int       `json:"port,omitempty"`
+	RTT           float64   `json:"rtt_ms"`
+	TimeStamp     time.Time `json:"timestamp"`
+	SchemaVersion int       `json:"schema_version"`
+}
+
+// handleHostDiscoveryOutput writes the alive hosts to the host discovery output file as soon as
+// host discovery completes, so the dataset survives an interrupted port scan
+func (r *Runner) handleHostDiscoveryOutput() {
+	if r.options.HostDiscoveryOutput == "" {
+		return
+	}
+	hosts := r.scanner.DiscoveredHosts()
+	for i, host := range hosts {
+		anonymized := *host
+		anonymized.IP = r.anonymizer.Value(host.IP)
+		hosts[i] = &anonymized
+	}
+
+	file, err := os.Create(r.options.HostDiscoveryOutput)
+	if err != nil {
+		gologger.Error().Msgf("Could not create file %s: %s\n", r.options.HostDiscoveryOutput, err)
+		r.recordError(ErrorOutput, r.options.HostDiscoveryOutput, err)
+		return
+	}
+	defer file.Close()
+
+	if err := WriteHostDiscoveryOutput(hosts, r.options.JSON, file); err != nil {
+		gologger.Error().Msgf("Could not write host discovery results to file %s: %s\n", r.options.HostDiscoveryOutput, err)
+		r.recordError(ErrorOutput, r.options.HostDiscoveryOutput, err)
+		return
+	}
+	gologger.Info().Msgf("Wrote %d alive hosts to %s\n", len(hosts), r.options.HostDiscoveryOutput)
+}
+
+// WriteHostDiscoveryOutput writes the alive hosts with the probe they answered and its round trip time
+// to an io.Writer, one per line as "ip probe rtt" or json
+func WriteHostDiscoveryOutput(hosts []*scan.DiscoveredHost, asJSON bool, writer io.Writer) error {
+	bufwriter := bufio.NewWriter(writer)
+	encoder := json.NewEncoder(bufwriter)
+	now := time.Now().UTC()
+
+	for _, host := range hosts {
+		var err error
+		if asJSON {
+			err = encoder.Encode(&discoveryResult{
+				IP:            host.IP,
+				Probe:         host.Probe,
+				Port:          host.Port,
+				RTT:           float64(host.RTT.Microseconds()) / 1000,
+				TimeStamp:     now,
+				SchemaVersion: schema.Version,
+			})
+		} else {
+			_, err = bufwriter.WriteString(formatDiscoveredHost(host) + "\n")
+		}
+		if err != nil {
+			bufwriter.Flush()
+			return err
+		}
+	}
+	return bufwriter.Flush()
+}
+
+// formatDiscoveredHost returns the "ip probe[/port] rtt" line of the host, omitting the unknown fields
+func formatDiscoveredHost(host *scan.DiscoveredHost) string {
+	if host.Probe == "" {
+		return host.IP
+	}
+	probe := host.Probe
+	if host.Port > 0 {
+		probe += "/" + strconv.Itoa(host.Port)
+	}
+	if host.RTT <= 0 {
+		return fmt.Sprintf("%s %s", host.IP, probe)
+	}
+	return fmt.Sprintf("%s %s %s", host.IP, probe, host.RTT.Round(time.Microsecond))
+}
@@ -0,0 +1,31 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/scan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteHostDiscoveryOutput(t *testing.T) {
+	hosts := []*scan.DiscoveredHost{
+		{IP: "192.168.1.1", Probe: scan.ProbeICMPEcho, RTT: 1500 * time.Microsecond},
+		{IP: "192.168.1.2", Probe: scan.ProbeTCP, Port: 443},
+		{IP: "192.168.1.3"},
+	}
+
+	buf := bytes.Buffer{}
+	require.Nil(t, WriteHostDiscoveryOutput(hosts, false, &buf))
+	require.Equal(t, "192.168.1.1 icmp-echo 1.5ms\n192.168.1.2 tcp/443\n192.168.1.3\n", buf.String())
+
+	buf.Reset()
+	require.Nil(t, WriteHostDiscoveryOutput(hosts[:1], true, &buf))
+	var data discoveryResult
+	require.Nil(t, json.Unmarshal(buf.Bytes(), &data))
+	require.Equal(t, "192.168.1.1", data.IP)
+	require.Equal(t, scan.ProbeICMPEcho, data.Probe)
+	require.Equal(t, 1.5, data.RTT)
+}
@@ -0,0 +1,117 @@
+package runner
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// dnsQueryTypes maps the query types accepted by -dns-query-types to dns record types
+var dnsQueryTypes = map[string]uint16{
+	"a":    dns.TypeA,
+	"aaaa": dns.TypeAAAA,
+}
+
+// dnsQuestionTypes returns the dns record types of the query types
+func dnsQuestionTypes(queryTypes []string) []uint16 {
+	var questionTypes []uint16
+	for _, queryType := range queryTypes {
+		if questionType, ok := dnsQueryTypes[queryType]; ok {
+			questionTypes = append(questionTypes, questionType)
+		}
+	}
+	return questionTypes
+}
+
+// dnsCacheEntry is the outcome of the resolution of a host
+type dnsCacheEntry struct {
+	ipsV4   []string
+	ipsV6   []string
+	err     error
+	expires time.Time
+}
+
+// dnsCache avoids resolving the same host several times during a scan (targets, exclusions, etc).
+// Failed resolutions are kept for the negative ttl.
+type dnsCache struct {
+	sync.RWMutex
+	ttl         time.Duration
+	negativeTTL time.Duration
+	entries     map[string]*dnsCacheEntry
+}
+
+func newDNSCache(ttl, negativeTTL time.Duration) *dnsCache {
+	return &dnsCache{ttl: ttl, negativeTTL: negativeTTL, entries: make(map[string]*dnsCacheEntry)}
+}
+
+// Get returns the cached resolution of the host if not expired
+func (cache *dnsCache) Get(host string, now time.Time) (*dnsCacheEntry, bool) {
+	if cache == nil {
+		return nil, false
+	}
+	cache.RLock()
+	defer cache.RUnlock()
+
+	entry, ok := cache.entries[host]
+	if !ok || now.After(entry.expires) {
+		return nil, false
+	}
+	return entry, true
+}
+
+// Set stores the resolution of the host for the positive or negative ttl
+func (cache *dnsCache) Set(host string, ipsV4, ipsV6 []string, err error, now time.Time) {
+	if cache == nil {
+		return
+	}
+	ttl := cache.ttl
+	if err != nil || (len(ipsV4) == 0 && len(ipsV6) == 0) {
+		ttl = cache.negativeTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+	cache.Lock()
+	defer cache.Unlock()
+
+	cache.entries[host] = &dnsCacheEntry{ipsV4: ipsV4, ipsV6: ipsV6, err: err, expires: now.Add(ttl)}
+}
+
+// cnameChains records the chain of aliases observed while resolving each host, which explains the
+// cdn classification and ownership of its addresses
+type cnameChains struct {
+	sync.RWMutex
+	chains map[string]CNAMEChain
+}
+
+func newCNAMEChains() *cnameChains {
+	return &cnameChains{chains: make(map[string]CNAMEChain)}
+}
+
+// set records the aliases of the host in resolution order, hosts without alias are not recorded
+func (c *cnameChains) set(host string, aliases []string) {
+	if c == nil || len(aliases) == 0 {
+		return
+	}
+	chain := make(CNAMEChain, 0, len(aliases))
+	for _, alias := range aliases {
+		chain = append(chain, strings.TrimSuffix(alias, "."))
+	}
+	c.Lock()
+	defer c.Unlock()
+
+	c.chains[host] = chain
+}
+
+// get returns the aliases of the host, nil if it has none
+func (c *cnameChains) get(host string) CNAMEChain {
+	if c == nil {
+		return nil
+	}
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.chains[host]
+}
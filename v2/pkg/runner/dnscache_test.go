@@ -0,0 +1,51 @@
+package runner
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDNSCache(t *testing.T) {
+	now := time.Now()
+	cache := newDNSCache(time.Minute, time.Second)
+
+	cache.Set("scanme.sh", []string{"45.33.32.156"}, nil, nil, now)
+	entry, ok := cache.Get("scanme.sh", now.Add(30*time.Second))
+	require.True(t, ok)
+	require.Equal(t, []string{"45.33.32.156"}, entry.ipsV4)
+	_, ok = cache.Get("scanme.sh", now.Add(2*time.Minute))
+	require.False(t, ok)
+
+	cache.Set("invalid.example", nil, nil, errors.New("nxdomain"), now)
+	entry, ok = cache.Get("invalid.example", now)
+	require.True(t, ok)
+	require.NotNil(t, entry.err)
+	_, ok = cache.Get("invalid.example", now.Add(2*time.Second))
+	require.False(t, ok)
+
+	var disabled *dnsCache
+	disabled.Set("scanme.sh", []string{"45.33.32.156"}, nil, nil, now)
+	_, ok = disabled.Get("scanme.sh", now)
+	require.False(t, ok)
+}
+
+func TestDNSQuestionTypes(t *testing.T) {
+	require.Equal(t, []uint16{dns.TypeAAAA}, dnsQuestionTypes([]string{"aaaa"}))
+	require.Equal(t, []uint16{dns.TypeA, dns.TypeAAAA}, dnsQuestionTypes([]string{"a", "aaaa"}))
+}
+
+func TestCNAMEChains(t *testing.T) {
+	chains := newCNAMEChains()
+	chains.set("app.example.com", []string{"app.example.net.", "lb.cdn.net."})
+	chains.set("direct.example.com", nil)
+	require.Equal(t, CNAMEChain{"app.example.net", "lb.cdn.net"}, chains.get("app.example.com"))
+	require.Nil(t, chains.get("direct.example.com"))
+
+	var disabled *cnameChains
+	disabled.set("app.example.com", []string{"lb.cdn.net"})
+	require.Nil(t, disabled.get("app.example.com"))
+}
@@ -0,0 +1,136 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/retryablehttp-go"
+)
+
+const (
+	// enrichConcurrency is the number of lookups running at once
+	enrichConcurrency = 8
+	// enrichTimeout bounds each lookup, retries included
+	enrichTimeout = 10 * time.Second
+)
+
+// enricher posts each finding to a lookup endpoint, typically a cmdb, and merges the fields of the
+// returned json object (owner, system, environment) into the result
+type enricher struct {
+	sync.Mutex
+	client  *retryablehttp.Client
+	url     string
+	timeout time.Duration
+	slots   chan struct{}
+	// entries are the lookups of each host ip port/protocol
+	entries map[string]*enrichEntry
+}
+
+// enrichEntry holds the fields of a finding, looked up by the first caller while the callers of the
+// same finding wait for it
+type enrichEntry struct {
+	once   sync.Once
+	fields map[string]interface{}
+	err    error
+}
+
+func newEnricher(url string) *enricher {
+	return &enricher{
+		client:  retryablehttp.NewClient(retryablehttp.DefaultOptionsSingle),
+		url:     url,
+		timeout: enrichTimeout,
+		slots:   make(chan struct{}, enrichConcurrency),
+		entries: make(map[string]*enrichEntry),
+	}
+}
+
+// lookup returns the fields of the finding, each finding being posted once. A failed lookup isn't
+// retried for each output of the finding
+func (e *enricher) lookup(finding *jsonResult) (map[string]interface{}, error) {
+	key := fmt.Sprintf("%s %s %d/%s", finding.Host, finding.IP, finding.PortNumber, finding.Protocol)
+	e.Lock()
+	entry, ok := e.entries[key]
+	if !ok {
+		entry = &enrichEntry{}
+		e.entries[key] = entry
+	}
+	e.Unlock()
+
+	entry.once.Do(func() {
+		e.slots <- struct{}{}
+		defer func() { <-e.slots }()
+		entry.fields, entry.err = e.post(finding)
+	})
+	return entry.fields, entry.err
+}
+
+// post sends the finding to the lookup endpoint and decodes the returned fields
+func (e *enricher) post(finding *jsonResult) (map[string]interface{}, error) {
+	body, err := json.Marshal(finding)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+	request, err := retryablehttp.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := e.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	// unknown findings have nothing to merge
+	if response.StatusCode == http.StatusNotFound || response.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected status code %d from %s", response.StatusCode, e.url)
+	}
+	var fields map[string]interface{}
+	if err := json.NewDecoder(response.Body).Decode(&fields); err != nil {
+		return nil, fmt.Errorf("invalid lookup response from %s: %w", e.url, err)
+	}
+	return fields, nil
+}
+
+// enrichPorts returns copies of the ports of the host with the fields looked up for each of them,
+// as the ports are shared among hosts. The ports are looked up concurrently, lookup failures
+// leave the ports as found
+func (r *Runner) enrichPorts(host, ip string, ports []*port.Port) []*port.Port {
+	if r.enricher == nil {
+		return ports
+	}
+	data := newOutputResult(host, ip, false, false, "")
+	enriched := make([]*port.Port, len(ports))
+	var wg sync.WaitGroup
+	for i, p := range ports {
+		enriched[i] = p
+		wg.Add(1)
+		go func(i int, p *port.Port) {
+			defer wg.Done()
+			fields, err := r.enricher.lookup(data.jsonResult(p))
+			if err != nil {
+				gologger.Warning().Msgf("Could not enrich %s:%d: %s\n", host, p.Port, err)
+				r.recordError(ErrorNetwork, r.options.EnrichURL, err)
+				return
+			}
+			if len(fields) > 0 {
+				withFields := *p
+				withFields.Enrichment = fields
+				enriched[i] = &withFields
+			}
+		}(i, p)
+	}
+	wg.Wait()
+	return enriched
+}
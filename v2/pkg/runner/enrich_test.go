@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnrichPorts(t *testing.T) {
+	var lookups atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		lookups.Add(1)
+		var finding map[string]interface{}
+		require.Nil(t, json.NewDecoder(req.Body).Decode(&finding))
+		if finding["port"].(float64) != 443 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"owner":"payments","environment":"prod","port":1}`))
+	}))
+	defer server.Close()
+
+	r := &Runner{options: &Options{EnrichURL: server.URL}, enricher: newEnricher(server.URL)}
+	https := &port.Port{Port: 443, Protocol: protocol.TCP}
+	ssh := &port.Port{Port: 22, Protocol: protocol.TCP}
+	ports := r.enrichPorts("a.example.com", "10.0.0.1", []*port.Port{https, ssh})
+	require.Equal(t, "payments", ports[0].Enrichment["owner"])
+	require.Nil(t, https.Enrichment)
+	require.Same(t, ssh, ports[1])
+
+	// the fields are merged without overriding the result ones
+	data, err := newOutputResult("a.example.com", "10.0.0.1", false, false, "").jsonResult(ports[0]).MarshalJSON()
+	require.Nil(t, err)
+	var fields map[string]interface{}
+	require.Nil(t, json.Unmarshal(data, &fields))
+	require.Equal(t, "prod", fields["environment"])
+	require.Equal(t, float64(443), fields["port"])
+
+	// each finding is looked up once
+	r.enrichPorts("a.example.com", "10.0.0.1", []*port.Port{https, ssh})
+	require.Equal(t, int32(2), lookups.Load())
+}
+
+func TestEnrichTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	enricher := newEnricher(server.URL)
+	enricher.timeout = 50 * time.Millisecond
+	r := &Runner{options: &Options{EnrichURL: server.URL}, enricher: enricher}
+	ports := []*port.Port{{Port: 443, Protocol: protocol.TCP}, {Port: 22, Protocol: protocol.TCP}}
+	start := time.Now()
+	enriched := r.enrichPorts("a.example.com", "10.0.0.1", ports)
+	// the ports are looked up concurrently, each lookup bounded by the timeout
+	require.Less(t, time.Since(start), time.Second)
+	require.Same(t, ports[0], enriched[0])
+	require.Equal(t, 2, r.errors.count(ErrorNetwork))
+}
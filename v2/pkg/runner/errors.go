@@ -0,0 +1,150 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// ErrorCategory classifies the failures of an enumeration
+type ErrorCategory string
+
+// Categories of the enumeration errors
+const (
+	ErrorInput      ErrorCategory = "input"
+	ErrorResolution ErrorCategory = "resolution"
+	ErrorPrivilege  ErrorCategory = "privilege"
+	ErrorNetwork    ErrorCategory = "network"
+	ErrorOutput     ErrorCategory = "output"
+)
+
+// maxEnumerationErrors is the number of errors kept, further errors are only counted
+const maxEnumerationErrors = 1000
+
+// EnumerationError is a failure of a target or subsystem during the enumeration
+type EnumerationError struct {
+	Category ErrorCategory
+	// Target is the host, ip or file the error relates to, if any
+	Target string
+	Err    error
+}
+
+func (e *EnumerationError) Error() string {
+	if e.Target != "" {
+		return fmt.Sprintf("%s error on %s: %s", e.Category, e.Target, e.Err)
+	}
+	return fmt.Sprintf("%s error: %s", e.Category, e.Err)
+}
+
+func (e *EnumerationError) Unwrap() error {
+	return e.Err
+}
+
+// EnumerationErrors aggregates the errors logged while the enumeration continued. It's returned by
+// Runner.Errors, errors stopping the enumeration being returned by RunEnumeration.
+type EnumerationErrors struct {
+	// Errors are the first recorded errors, in order
+	Errors []*EnumerationError
+	// Counts are the number of errors of each category, including the ones not kept in Errors
+	Counts map[ErrorCategory]int
+}
+
+func (e *EnumerationErrors) Error() string {
+	categories := make([]string, 0, len(e.Counts))
+	total := 0
+	for category, count := range e.Counts {
+		categories = append(categories, fmt.Sprintf("%s: %d", category, count))
+		total += count
+	}
+	sort.Strings(categories)
+	return fmt.Sprintf("%d errors during enumeration (%s)", total, strings.Join(categories, ", "))
+}
+
+// Unwrap returns the recorded errors, so that errors.Is and errors.As match any of them
+func (e *EnumerationErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// ByCategory returns the recorded errors of a category
+func (e *EnumerationErrors) ByCategory(category ErrorCategory) []*EnumerationError {
+	var errs []*EnumerationError
+	for _, err := range e.Errors {
+		if err.Category == category {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// errorCollector records the errors of an enumeration from concurrent workers
+type errorCollector struct {
+	sync.Mutex
+	errors EnumerationErrors
+}
+
+func (c *errorCollector) add(category ErrorCategory, target string, err error) {
+	c.Lock()
+	defer c.Unlock()
+	if c.errors.Counts == nil {
+		c.errors.Counts = make(map[ErrorCategory]int)
+	}
+	c.errors.Counts[category]++
+	if len(c.errors.Errors) < maxEnumerationErrors {
+		c.errors.Errors = append(c.errors.Errors, &EnumerationError{Category: category, Target: target, Err: err})
+	}
+}
+
+// count returns the number of errors of the category
+func (c *errorCollector) count(category ErrorCategory) int {
+	c.Lock()
+	defer c.Unlock()
+	return c.errors.Counts[category]
+}
+
+// err returns the aggregated errors, or nil if none were recorded
+func (c *errorCollector) err() *EnumerationErrors {
+	c.Lock()
+	defer c.Unlock()
+	if len(c.errors.Counts) == 0 {
+		return nil
+	}
+	errs := c.errors
+	errs.Errors = append([]*EnumerationError(nil), c.errors.Errors...)
+	errs.Counts = make(map[ErrorCategory]int, len(c.errors.Counts))
+	for category, count := range c.errors.Counts {
+		errs.Counts[category] = count
+	}
+	return &errs
+}
+
+// Errors returns the errors the enumeration continued after, or nil if none were recorded
+func (r *Runner) Errors() *EnumerationErrors {
+	return r.errors.err()
+}
+
+// recordError records an error the enumeration continued after
+func (r *Runner) recordError(category ErrorCategory, target string, err error) {
+	r.errors.add(category, target, err)
+}
+
+// socketErrorCategory classifies the errors of probe sockets, refused and timed out connections
+// being regular port states aren't reported
+func socketErrorCategory(err error) (ErrorCategory, bool) {
+	switch {
+	case errors.Is(err, os.ErrPermission), errors.Is(err, syscall.EPERM), errors.Is(err, syscall.EACCES):
+		return ErrorPrivilege, true
+	case errors.Is(err, syscall.EMFILE), errors.Is(err, syscall.ENFILE), errors.Is(err, syscall.ENOBUFS),
+		errors.Is(err, syscall.ENETUNREACH), errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.EADDRNOTAVAIL):
+		return ErrorNetwork, true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,52 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorCollector(t *testing.T) {
+	var collector errorCollector
+	require.Nil(t, collector.err())
+
+	errResolve := errors.New("no ip addresses found")
+	collector.add(ErrorResolution, "scanme.sh", errResolve)
+	collector.add(ErrorOutput, "results.json", os.ErrPermission)
+	collector.add(ErrorResolution, "example.com", errors.New("no ip addresses found"))
+
+	err := error(collector.err())
+	var enumerationErrors *EnumerationErrors
+	require.True(t, errors.As(err, &enumerationErrors))
+	require.Equal(t, "3 errors during enumeration (output: 1, resolution: 2)", err.Error())
+	require.Len(t, enumerationErrors.ByCategory(ErrorResolution), 2)
+	require.Equal(t, "resolution error on scanme.sh: no ip addresses found", enumerationErrors.Errors[0].Error())
+	require.ErrorIs(t, err, errResolve)
+	require.ErrorIs(t, err, os.ErrPermission)
+
+	for i := 0; i < maxEnumerationErrors; i++ {
+		collector.add(ErrorNetwork, "", syscall.EMFILE)
+	}
+	enumerationErrors = collector.err()
+	require.Len(t, enumerationErrors.Errors, maxEnumerationErrors)
+	require.Equal(t, maxEnumerationErrors, enumerationErrors.Counts[ErrorNetwork])
+}
+
+func TestSocketErrorCategory(t *testing.T) {
+	category, ok := socketErrorCategory(fmt.Errorf("dial tcp: %w", syscall.EACCES))
+	require.True(t, ok)
+	require.Equal(t, ErrorPrivilege, category)
+
+	category, ok = socketErrorCategory(fmt.Errorf("dial tcp: %w", syscall.EMFILE))
+	require.True(t, ok)
+	require.Equal(t, ErrorNetwork, category)
+
+	_, ok = socketErrorCategory(fmt.Errorf("dial tcp: %w", syscall.ECONNREFUSED))
+	require.False(t, ok)
+	_, ok = socketErrorCategory(nil)
+	require.False(t, ok)
+}
@@ -0,0 +1,269 @@
+package runner
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/pcapgo"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+	"github.com/projectdiscovery/naabu/v2/pkg/scan"
+	iputil "github.com/projectdiscovery/utils/ip"
+)
+
+// maxEvidencePackets is the maximum number of captured responses kept per host
+const maxEvidencePackets = 256
+
+// hostEvidence is the evidence of the open ports of a host attached to a finding
+type hostEvidence struct {
+	IP           string
+	Hosts        []string
+	Ports        []*port.Port
+	Certificates []*x509.Certificate
+	Responses    []*scan.Response
+}
+
+// evidenceCollector keeps the captured responses and the tls certificates of each host during the scan
+type evidenceCollector struct {
+	sync.Mutex
+	responses    map[string][]*scan.Response
+	certificates map[string][]*x509.Certificate
+	leaves       map[string]struct{}
+}
+
+func newEvidenceCollector() *evidenceCollector {
+	return &evidenceCollector{
+		responses:    make(map[string][]*scan.Response),
+		certificates: make(map[string][]*x509.Certificate),
+		leaves:       make(map[string]struct{}),
+	}
+}
+
+// add keeps the captured response of the host
+func (c *evidenceCollector) add(response *scan.Response) {
+	if len(response.Packet) == 0 {
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+
+	if len(c.responses[response.IP]) < maxEvidencePackets {
+		c.responses[response.IP] = append(c.responses[response.IP], response)
+	}
+}
+
+// addCertificates keeps the certificate chain presented to the host, once per distinct leaf
+func (c *evidenceCollector) addCertificates(ip string, chain []*x509.Certificate) {
+	if len(chain) == 0 {
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+
+	leaf := ip + "/" + string(chain[0].Raw)
+	if _, ok := c.leaves[leaf]; ok {
+		return
+	}
+	c.leaves[leaf] = struct{}{}
+	c.certificates[ip] = append(c.certificates[ip], chain...)
+}
+
+// get returns the captured responses of the host
+func (c *evidenceCollector) get(ip string) []*scan.Response {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.responses[ip]
+}
+
+// getCertificates returns the certificates presented by the tls services of the host
+func (c *evidenceCollector) getCertificates(ip string) []*x509.Certificate {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.certificates[ip]
+}
+
+// grabCertificates records the certificates presented by the port when it speaks tls, as the
+// open ports are found so that the evidence reflects the services at scan time
+func (grabber *bannerGrabber) grabCertificates(ip string, p *port.Port) {
+	if state, ok := grabber.handshake(ip, p); ok {
+		grabber.runner.evidence.addCertificates(ip, state.PeerCertificates)
+	}
+}
+
+// serverName returns the first hostname of the ip to send as tls server name, empty for bare ips
+func (grabber *bannerGrabber) serverName(ip string) string {
+	if grabber.runner.scanner.IPRanger == nil {
+		return ""
+	}
+	hosts, err := grabber.runner.scanner.IPRanger.GetHostsByIP(ip)
+	if err != nil {
+		return ""
+	}
+	for _, host := range hosts {
+		if !iputil.IsIP(host) && host != "ip" {
+			return host
+		}
+	}
+	return ""
+}
+
+// handleEvidenceOutput writes the evidence bundle of the hosts having open ports
+func (r *Runner) handleEvidenceOutput(scanResults *result.Result) {
+	if r.evidence == nil || !scanResults.HasIPsPorts() {
+		return
+	}
+
+	var evidences []*hostEvidence
+	for hostResult := range scanResults.GetIPsPorts() {
+		hosts, err := r.getResultHosts(hostResult)
+		if err != nil {
+			continue
+		}
+		isCDNIP, cdnName, _ := r.scanner.CdnCheck(hostResult.IP)
+		ports := r.filterHostPorts(hostResult.IP, hostResult.IP, hostResult.Ports, isCDNIP, cdnName)
+		if len(ports) == 0 {
+			continue
+		}
+		sort.Slice(ports, func(i, j int) bool {
+			return ports[i].Port < ports[j].Port
+		})
+		evidences = append(evidences, &hostEvidence{
+			IP:           hostResult.IP,
+			Hosts:        hosts,
+			Ports:        ports,
+			Certificates: r.evidence.getCertificates(hostResult.IP),
+			Responses:    r.evidence.get(hostResult.IP),
+		})
+	}
+	sort.Slice(evidences, func(i, j int) bool {
+		return evidences[i].IP < evidences[j].IP
+	})
+
+	file, err := os.Create(r.options.EvidenceOutput)
+	if err != nil {
+		gologger.Error().Msgf("Could not create file %s: %s\n", r.options.EvidenceOutput, err)
+		r.recordError(ErrorOutput, r.options.EvidenceOutput, err)
+		return
+	}
+	defer file.Close()
+
+	if err := writeEvidenceBundle(evidences, file); err != nil {
+		gologger.Error().Msgf("Could not write evidence bundle %s: %s\n", r.options.EvidenceOutput, err)
+		r.recordError(ErrorOutput, r.options.EvidenceOutput, err)
+		return
+	}
+	gologger.Info().Msgf("Wrote evidence of %d hosts to %s\n", len(evidences), r.options.EvidenceOutput)
+}
+
+// writeEvidenceBundle writes a zip archive with a folder per host holding its open ports (ports.json),
+// banners (banners.txt), tls certificates (certificates.pem), captured responses (responses.pcap)
+// and a notes.md stub to complete, stating that no traceroute is collected
+func writeEvidenceBundle(evidences []*hostEvidence, writer io.Writer) error {
+	archive := zip.NewWriter(writer)
+	for _, evidence := range evidences {
+		if err := evidence.write(archive); err != nil {
+			archive.Close()
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// write adds the evidence files of the host to the archive, omitting the empty ones
+func (evidence *hostEvidence) write(archive *zip.Writer) error {
+	folder := strings.NewReplacer(":", "_", "/", "_").Replace(evidence.IP) + "/"
+
+	add := func(name string, content []byte) error {
+		if len(content) == 0 {
+			return nil
+		}
+		w, err := archive.Create(folder + name)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(content)
+		return err
+	}
+
+	var ports, banners, notes bytes.Buffer
+	for _, host := range evidence.Hosts {
+		if err := writeJSONResult(newOutputResult(host, evidence.IP, false, false, ""), evidence.Ports, &ports); err != nil {
+			return err
+		}
+	}
+	for _, p := range evidence.Ports {
+		if p.Banner != "" {
+			fmt.Fprintf(&banners, "%d/%s %s\n", p.Port, p.Protocol, p.Banner)
+		}
+	}
+	fmt.Fprintf(&notes, "# %s\n\n", evidence.IP)
+	fmt.Fprintf(&notes, "Hosts: %s\n\n", strings.Join(evidence.Hosts, ", "))
+	notes.WriteString("Open ports:\n\n")
+	for _, p := range evidence.Ports {
+		fmt.Fprintf(&notes, "- %d/%s\n", p.Port, p.Protocol)
+	}
+	notes.WriteString("\nTraceroute: not collected, add it to the notes when relevant\n")
+	notes.WriteString("\n## Notes\n\n")
+
+	if err := add("ports.json", ports.Bytes()); err != nil {
+		return err
+	}
+	if err := add("banners.txt", banners.Bytes()); err != nil {
+		return err
+	}
+	if err := add("certificates.pem", encodeCertificates(evidence.Certificates)); err != nil {
+		return err
+	}
+	capture, err := encodeResponses(evidence.Responses)
+	if err != nil {
+		return err
+	}
+	if err := add("responses.pcap", capture); err != nil {
+		return err
+	}
+	return add("notes.md", notes.Bytes())
+}
+
+// encodeCertificates returns the pem encoding of the certificates
+func encodeCertificates(certificates []*x509.Certificate) []byte {
+	var buffer bytes.Buffer
+	for _, certificate := range certificates {
+		_ = pem.Encode(&buffer, &pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw})
+	}
+	return buffer.Bytes()
+}
+
+// encodeResponses returns the pcap file of the captured responses sharing the link type of the first one
+func encodeResponses(responses []*scan.Response) ([]byte, error) {
+	if len(responses) == 0 {
+		return nil, nil
+	}
+	var buffer bytes.Buffer
+	writer := pcapgo.NewWriter(&buffer)
+	linkType := responses[0].LinkType
+	if err := writer.WriteFileHeader(65536, linkType); err != nil {
+		return nil, err
+	}
+	for _, response := range responses {
+		if response.LinkType != linkType {
+			continue
+		}
+		info := gopacket.CaptureInfo{Timestamp: response.Time, CaptureLength: len(response.Packet), Length: len(response.Packet)}
+		if err := writer.WritePacket(info, response.Packet); err != nil {
+			return nil, err
+		}
+	}
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,72 @@
+package runner
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/x509"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket/layers"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/projectdiscovery/naabu/v2/pkg/scan"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/maps"
+)
+
+func TestWriteEvidenceBundle(t *testing.T) {
+	evidences := []*hostEvidence{
+		{
+			IP:    "192.168.1.1",
+			Hosts: []string{"example.com"},
+			Ports: []*port.Port{
+				{Port: 22, Protocol: protocol.TCP, Banner: "SSH-2.0-OpenSSH_8.9"},
+				{Port: 80, Protocol: protocol.TCP},
+			},
+			Responses: []*scan.Response{
+				{IP: "192.168.1.1", Time: time.Now(), Packet: []byte{0x45, 0x00}, LinkType: layers.LinkTypeRaw},
+			},
+		},
+		{IP: "192.168.1.2", Hosts: []string{"192.168.1.2"}, Ports: []*port.Port{{Port: 443, Protocol: protocol.TCP}}},
+	}
+
+	buf := bytes.Buffer{}
+	require.Nil(t, writeEvidenceBundle(evidences, &buf))
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.Nil(t, err)
+	files := make(map[string]string)
+	for _, file := range archive.File {
+		f, err := file.Open()
+		require.Nil(t, err)
+		content, err := io.ReadAll(f)
+		require.Nil(t, err)
+		f.Close()
+		files[file.Name] = string(content)
+	}
+
+	require.ElementsMatch(t, []string{
+		"192.168.1.1/ports.json", "192.168.1.1/banners.txt", "192.168.1.1/responses.pcap", "192.168.1.1/notes.md",
+		"192.168.1.2/ports.json", "192.168.1.2/notes.md",
+	}, maps.Keys(files))
+	require.Contains(t, files["192.168.1.1/ports.json"], `"host":"example.com"`)
+	require.Equal(t, "22/tcp SSH-2.0-OpenSSH_8.9\n", files["192.168.1.1/banners.txt"])
+	require.Contains(t, files["192.168.1.2/notes.md"], "- 443/tcp")
+	require.Contains(t, files["192.168.1.2/notes.md"], "Traceroute: not collected")
+}
+
+func TestEvidenceCollectorCertificates(t *testing.T) {
+	leaf, other, ca := &x509.Certificate{Raw: []byte("leaf")}, &x509.Certificate{Raw: []byte("other")}, &x509.Certificate{Raw: []byte("ca")}
+	collector := newEvidenceCollector()
+	collector.addCertificates("192.168.1.1", []*x509.Certificate{leaf, ca})
+	collector.addCertificates("192.168.1.1", []*x509.Certificate{leaf, ca})
+	collector.addCertificates("192.168.1.1", []*x509.Certificate{other})
+	collector.addCertificates("192.168.1.1", nil)
+	collector.addCertificates("192.168.1.2", []*x509.Certificate{leaf})
+
+	require.Equal(t, []*x509.Certificate{leaf, ca, other}, collector.getCertificates("192.168.1.1"))
+	require.Equal(t, []*x509.Certificate{leaf}, collector.getCertificates("192.168.1.2"))
+	require.Empty(t, collector.getCertificates("192.168.1.3"))
+}
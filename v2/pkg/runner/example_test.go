@@ -0,0 +1,29 @@
+package runner_test
+
+import (
+	"log"
+	"time"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+	"github.com/projectdiscovery/naabu/v2/pkg/runner"
+)
+
+func ExampleNew() {
+	naabuRunner, err := runner.New(
+		runner.WithHosts("scanme.sh"),
+		runner.WithPorts("22,80,443"),
+		runner.WithScanType(runner.ConnectScan),
+		runner.WithTimeout(2*time.Second),
+		runner.WithOnResult(func(hr *result.HostResult) {
+			log.Println(hr.Host, hr.Ports)
+		}),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer naabuRunner.Close()
+
+	if err := naabuRunner.RunEnumeration(); err != nil {
+		log.Fatal(err)
+	}
+}
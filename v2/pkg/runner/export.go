@@ -0,0 +1,203 @@
+package runner
+
+import (
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // uuid v5 is defined over sha1
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+)
+
+// Export formats of the stream output, webhook and elasticsearch results
+const (
+	ExportJSON = "json"
+	ExportECS  = "ecs"
+	ExportSTIX = "stix"
+)
+
+// ecsVersion is the version of the Elastic Common Schema the ecs documents conform to
+const ecsVersion = "8.11.0"
+
+// stixNamespace is the uuid namespace of the deterministic identifiers of the STIX cyber observables
+var stixNamespace = [16]byte{0x00, 0xab, 0xed, 0xb4, 0xaa, 0x42, 0x46, 0x6c, 0x9c, 0x01, 0xfe, 0xd2, 0x33, 0x15, 0xa9, 0xb7}
+
+// exportDocument returns the document of the open port of the result in the export format
+func exportDocument(data *Result, format string) interface{} {
+	switch format {
+	case ExportECS:
+		return ecsDocument(data)
+	case ExportSTIX:
+		return stixBundle(data)
+	}
+	return data.jsonResult(data.Port)
+}
+
+// ecsDocument maps the result to an Elastic Common Schema event, the naabu specific fields being
+// kept under the naabu namespace
+func ecsDocument(data *Result) map[string]interface{} {
+	p := data.Port
+	destination := map[string]interface{}{"ip": data.IP, "port": p.Port}
+	if data.Host != "" && data.Host != data.IP {
+		destination["domain"] = data.Host
+	}
+	network := map[string]interface{}{"transport": p.Protocol.String()}
+	if family := result.Family(data.IP); family != "" {
+		network["type"] = family
+	}
+	if p.Service != "" {
+		network["protocol"] = p.Service
+	}
+	naabu := map[string]interface{}{"tls": p.TLS}
+	for name, value := range map[string]interface{}{
+		"banner":           p.Banner,
+		"service_version":  p.Version,
+		"responder":        p.Responder,
+		"severity":         p.Severity,
+		"scan_type":        data.ScanType,
+		"cdn_name":         data.CDNName,
+		"cname":            []string(data.CNAME),
+		"response_time_ms": float64(p.RTT.Microseconds()) / 1000,
+		"uptime_seconds":   data.Uptime,
+	} {
+		switch value := value.(type) {
+		case string:
+			if value == "" {
+				continue
+			}
+		case []string:
+			if len(value) == 0 {
+				continue
+			}
+		case float64:
+			if value == 0 {
+				continue
+			}
+		}
+		naabu[name] = value
+	}
+	if data.IsCDNIP {
+		naabu["cdn"] = true
+	}
+	if data.Anycast {
+		naabu["anycast"] = true
+	}
+	if len(p.Enrichment) > 0 {
+		naabu["enrichment"] = p.Enrichment
+	}
+
+	document := map[string]interface{}{
+		"@timestamp":  data.TimeStamp.UTC().Format(time.RFC3339Nano),
+		"ecs":         map[string]string{"version": ecsVersion},
+		"event":       map[string]interface{}{"kind": "event", "category": []string{"network"}, "type": []string{"info", "connection"}, "dataset": "naabu.port", "outcome": "success"},
+		"observer":    map[string]interface{}{"type": "scanner", "vendor": "ProjectDiscovery", "product": "naabu", "version": version},
+		"destination": destination,
+		"network":     network,
+		"naabu":       naabu,
+	}
+	if len(data.Tags) > 0 {
+		document["tags"] = []string(data.Tags)
+	}
+	if p.Path != nil {
+		if p.Path.SourceIP != "" {
+			document["source"] = map[string]string{"ip": p.Path.SourceIP}
+		}
+		if p.Path.Interface != "" || p.Path.Vantage != "" {
+			observer := document["observer"].(map[string]interface{})
+			if p.Path.Interface != "" {
+				observer["egress"] = map[string]interface{}{"interface": map[string]string{"name": p.Path.Interface}}
+			}
+			if p.Path.Vantage != "" {
+				observer["name"] = p.Path.Vantage
+			}
+		}
+	}
+	return document
+}
+
+// stixBundle maps the result to a STIX 2.1 bundle of an observed-data object referencing the
+// address, the hostname resolving to it and the network traffic to the open port
+func stixBundle(data *Result) map[string]interface{} {
+	p := data.Port
+	family := result.Family(data.IP)
+	addressType := "ipv4-addr"
+	if family == "ipv6" {
+		addressType = "ipv6-addr"
+	}
+	address := stixObservable(addressType, map[string]interface{}{"value": data.IP})
+	protocols := []string{p.Protocol.String()}
+	if family != "" {
+		protocols = append([]string{family}, protocols...)
+	}
+	if p.Service != "" {
+		protocols = append(protocols, p.Service)
+	}
+	traffic := stixObservable("network-traffic", map[string]interface{}{"dst_ref": address["id"], "dst_port": p.Port, "protocols": protocols})
+	objects := []interface{}{address, traffic}
+	refs := []interface{}{address["id"], traffic["id"]}
+	if data.Host != "" && data.Host != data.IP {
+		domain := stixObservable("domain-name", map[string]interface{}{"value": data.Host})
+		domain["resolves_to_refs"] = []interface{}{address["id"]}
+		objects = append(objects, domain)
+		refs = append(refs, domain["id"])
+	}
+
+	timestamp := data.TimeStamp.UTC().Format("2006-01-02T15:04:05.000Z")
+	observed := map[string]interface{}{
+		"type":            "observed-data",
+		"spec_version":    "2.1",
+		"id":              "observed-data--" + randomUUID(),
+		"created":         timestamp,
+		"modified":        timestamp,
+		"first_observed":  timestamp,
+		"last_observed":   timestamp,
+		"number_observed": 1,
+		"object_refs":     refs,
+	}
+	if len(data.Tags) > 0 {
+		observed["labels"] = []string(data.Tags)
+	}
+	return map[string]interface{}{
+		"type":    "bundle",
+		"id":      "bundle--" + randomUUID(),
+		"objects": append([]interface{}{observed}, objects...),
+	}
+}
+
+// stixObservable returns a STIX cyber observable whose id is derived from its properties, so the
+// same address or traffic observed by several scans has the same identifier
+func stixObservable(kind string, properties map[string]interface{}) map[string]interface{} {
+	// encoding/json sorts the keys, which is the canonical form of the id contributing properties
+	canonical, _ := json.Marshal(properties)
+	observable := map[string]interface{}{"type": kind, "spec_version": "2.1", "id": kind + "--" + uuidV5(stixNamespace, canonical)}
+	for name, value := range properties {
+		observable[name] = value
+	}
+	return observable
+}
+
+// uuidV5 returns the name based uuid of the name in the namespace (RFC 4122)
+func uuidV5(namespace [16]byte, name []byte) string {
+	hash := sha1.New() //nolint:gosec // uuid v5 is defined over sha1
+	hash.Write(namespace[:])
+	hash.Write(name)
+	var uuid [16]byte
+	copy(uuid[:], hash.Sum(nil))
+	uuid[6] = uuid[6]&0x0f | 0x50
+	uuid[8] = uuid[8]&0x3f | 0x80
+	return formatUUID(uuid)
+}
+
+// randomUUID returns a random uuid (RFC 4122 version 4)
+func randomUUID() string {
+	var uuid [16]byte
+	_, _ = rand.Read(uuid[:])
+	uuid[6] = uuid[6]&0x0f | 0x40
+	uuid[8] = uuid[8]&0x3f | 0x80
+	return formatUUID(uuid)
+}
+
+func formatUUID(uuid [16]byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:])
+}
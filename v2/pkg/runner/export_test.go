@@ -0,0 +1,82 @@
+package runner
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+// exportedFields returns the json object of the exported document
+func exportedFields(t *testing.T, document interface{}) map[string]interface{} {
+	data, err := json.Marshal(document)
+	require.Nil(t, err)
+	var fields map[string]interface{}
+	require.Nil(t, json.Unmarshal(data, &fields))
+	return fields
+}
+
+func TestECSDocument(t *testing.T) {
+	data := &Result{
+		Host:      "www.example.com",
+		IP:        "192.0.2.1",
+		ScanType:  "syn",
+		Tags:      Tags{"cloud:aws"},
+		TimeStamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Port:      &port.Port{Port: 443, Protocol: protocol.TCP, TLS: true, Service: "https", Path: &port.Path{SourceIP: "10.0.0.5", Interface: "eth0"}},
+	}
+	fields := exportedFields(t, exportDocument(data, ExportECS))
+	require.Equal(t, "2024-01-02T03:04:05Z", fields["@timestamp"])
+	require.Equal(t, map[string]interface{}{"ip": "192.0.2.1", "port": float64(443), "domain": "www.example.com"}, fields["destination"])
+	require.Equal(t, map[string]interface{}{"transport": "tcp", "type": "ipv4", "protocol": "https"}, fields["network"])
+	require.Equal(t, map[string]interface{}{"ip": "10.0.0.5"}, fields["source"])
+	require.Equal(t, []interface{}{"cloud:aws"}, fields["tags"])
+	naabu := fields["naabu"].(map[string]interface{})
+	require.Equal(t, true, naabu["tls"])
+	require.Equal(t, "syn", naabu["scan_type"])
+	require.NotContains(t, naabu, "banner")
+}
+
+func TestSTIXBundle(t *testing.T) {
+	data := &Result{Host: "www.example.com", IP: "198.51.100.3", TimeStamp: time.Now(), Port: &port.Port{Port: 53, Protocol: protocol.UDP}}
+	fields := exportedFields(t, exportDocument(data, ExportSTIX))
+	require.Equal(t, "bundle", fields["type"])
+	objects := fields["objects"].([]interface{})
+	require.Len(t, objects, 4)
+
+	observed := objects[0].(map[string]interface{})
+	require.Equal(t, "observed-data", observed["type"])
+	require.Len(t, observed["object_refs"], 3)
+	address := objects[1].(map[string]interface{})
+	// identifier of the STIX 2.1 specification for the value
+	require.Equal(t, "ipv4-addr--28bb3599-77cd-5a82-a950-b5bc3caf07c4", address["id"])
+	traffic := objects[2].(map[string]interface{})
+	require.Equal(t, address["id"], traffic["dst_ref"])
+	require.Equal(t, []interface{}{"ipv4", "udp"}, traffic["protocols"])
+	domain := objects[3].(map[string]interface{})
+	require.Equal(t, []interface{}{address["id"]}, domain["resolves_to_refs"])
+
+	// the observables of the same finding keep their identifiers
+	again := exportedFields(t, exportDocument(data, ExportSTIX))["objects"].([]interface{})
+	require.Equal(t, traffic["id"], again[2].(map[string]interface{})["id"])
+	require.NotEqual(t, observed["id"], again[0].(map[string]interface{})["id"])
+}
+
+func TestJSONLinesWriterExportFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stream.jsonl")
+	writer, err := newJSONLinesWriter(path)
+	require.Nil(t, err)
+	writer.format = ExportECS
+	require.Nil(t, writer.Write(testWriterResult(80)))
+	require.Nil(t, writer.Close())
+
+	data, err := os.ReadFile(path)
+	require.Nil(t, err)
+	require.True(t, strings.Contains(string(data), `"destination":{"ip":"192.0.2.1","port":80}`))
+}
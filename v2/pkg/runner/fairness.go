@@ -0,0 +1,235 @@
+package runner
+
+import (
+	"net"
+	"time"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+)
+
+// resumeRefresh is the number of emitted picks after which the resume index is computed again
+const resumeRefresh = 256
+
+// fairPick is a burst of ports of an ip picked by the scan loop at an index of the scan space
+type fairPick struct {
+	index int64
+	ip    string
+	ports []*port.Port
+}
+
+// maxPacedPrefixes is the number of prefix buckets above which the refilled ones are forgotten
+const maxPacedPrefixes = 1 << 16
+
+// prefixInterleaver reorders the picks of the shuffled scan within a window, sending them round-robin
+// across the /16 (ipv4) and /48 (ipv6) prefixes of their ip, so that indexes clustered by the shuffle
+// don't send bursts of probes to a single network. With a pacer, a prefix only sends when its token
+// bucket allows it, the other prefixes going first
+type prefixInterleaver struct {
+	window int
+	size   int
+	queues map[string][]*fairPick
+	// order holds the prefixes having queued picks, the first one sending next
+	order   []string
+	emitted int
+	resume  int64
+	pacer   *prefixPacer
+}
+
+// newPrefixInterleaver returns the interleaver of the window pacing each prefix at the rate in
+// probes per second (0 for no limit), nil if the window is too small to reorder the picks and no
+// rate is set. Paced prefixes without a window use DefaultFairnessWindow, their waits ending once
+// the stop channel is closed
+func newPrefixInterleaver(window, prefixRate int, stop <-chan struct{}) *prefixInterleaver {
+	if prefixRate > 0 && window <= 1 {
+		window = DefaultFairnessWindow
+	}
+	if window <= 1 {
+		return nil
+	}
+	fair := &prefixInterleaver{window: window, queues: make(map[string][]*fairPick)}
+	if prefixRate > 0 {
+		fair.pacer = newPrefixPacer(float64(prefixRate), stop)
+	}
+	return fair
+}
+
+// prefixBucket is the token bucket of a prefix, holding at most one token so that its probes are
+// spaced evenly. A burst of ports takes a token per port, the debt delaying the next probes
+type prefixBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// prefixPacer limits the probes sent to each prefix to a rate per second
+type prefixPacer struct {
+	rate    float64
+	buckets map[string]*prefixBucket
+	now     func() time.Time
+	// sleep waits for the duration, returning false if the scan stopped meanwhile
+	sleep func(time.Duration) bool
+}
+
+func newPrefixPacer(rate float64, stop <-chan struct{}) *prefixPacer {
+	sleep := func(d time.Duration) bool { return sleepUntil(d, stop) }
+	return &prefixPacer{rate: rate, buckets: make(map[string]*prefixBucket), now: time.Now, sleep: sleep}
+}
+
+// refill returns the bucket of the prefix with the tokens earned since its last use, nil if the
+// prefix sent no probe yet
+func (pacer *prefixPacer) refill(prefix string, now time.Time) *prefixBucket {
+	bucket, ok := pacer.buckets[prefix]
+	if !ok {
+		return nil
+	}
+	bucket.tokens += now.Sub(bucket.last).Seconds() * pacer.rate
+	if bucket.tokens > 1 {
+		bucket.tokens = 1
+	}
+	bucket.last = now
+	return bucket
+}
+
+// take takes the tokens of the probes from the bucket of the prefix, false if it has none
+func (pacer *prefixPacer) take(prefix string, probes int) bool {
+	now := pacer.now()
+	bucket := pacer.refill(prefix, now)
+	if bucket == nil {
+		if len(pacer.buckets) >= maxPacedPrefixes {
+			pacer.prune(now)
+		}
+		bucket = &prefixBucket{tokens: 1, last: now}
+		pacer.buckets[prefix] = bucket
+	}
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens -= float64(probes)
+	return true
+}
+
+// wait returns the time until the bucket of the prefix has a token
+func (pacer *prefixPacer) wait(prefix string) time.Duration {
+	bucket := pacer.refill(prefix, pacer.now())
+	if bucket == nil || bucket.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - bucket.tokens) / pacer.rate * float64(time.Second))
+}
+
+// prune forgets the buckets refilled since, equivalent to the bucket of a new prefix
+func (pacer *prefixPacer) prune(now time.Time) {
+	for prefix := range pacer.buckets {
+		if bucket := pacer.refill(prefix, now); bucket.tokens >= 1 {
+			delete(pacer.buckets, prefix)
+		}
+	}
+}
+
+// networkPrefix returns the /16 (ipv4) or /48 (ipv6) prefix of the ip
+func networkPrefix(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ip
+	}
+	if ipv4 := parsed.To4(); ipv4 != nil {
+		return string(ipv4[:2])
+	}
+	return string(parsed[:6])
+}
+
+// Push queues the pick and returns the pick to send once the window is full
+func (fair *prefixInterleaver) Push(pick *fairPick) []*fairPick {
+	if fair == nil {
+		return []*fairPick{pick}
+	}
+	prefix := networkPrefix(pick.ip)
+	queue, ok := fair.queues[prefix]
+	if !ok {
+		fair.order = append(fair.order, prefix)
+	}
+	fair.queues[prefix] = append(queue, pick)
+	fair.size++
+	if fair.size <= fair.window {
+		return nil
+	}
+	return []*fairPick{fair.pop()}
+}
+
+// Drain returns the queued picks in round-robin order
+func (fair *prefixInterleaver) Drain() []*fairPick {
+	if fair == nil {
+		return nil
+	}
+	picks := make([]*fairPick, 0, fair.size)
+	for fair.size > 0 {
+		picks = append(picks, fair.pop())
+	}
+	return picks
+}
+
+// pop removes the next pick of the first prefix allowed to send, which moves to the end of the order
+// while it has queued picks. If every prefix exhausted its rate, pop waits for the first token
+func (fair *prefixInterleaver) pop() *fairPick {
+	next := fair.next()
+	prefix := fair.order[next]
+	fair.order = append(fair.order[:next], fair.order[next+1:]...)
+	queue := fair.queues[prefix]
+	pick := queue[0]
+	if len(queue) == 1 {
+		delete(fair.queues, prefix)
+	} else {
+		fair.queues[prefix] = queue[1:]
+		fair.order = append(fair.order, prefix)
+	}
+	fair.size--
+	if fair.emitted%resumeRefresh == 0 {
+		fair.resume = fair.oldest()
+	}
+	fair.emitted++
+	return pick
+}
+
+// next returns the position in the order of the first prefix allowed to send, the first one once the
+// scan stopped so that the remaining picks drain without waiting
+func (fair *prefixInterleaver) next() int {
+	if fair.pacer == nil {
+		return 0
+	}
+	for {
+		var wait time.Duration
+		for i, prefix := range fair.order {
+			if fair.pacer.take(prefix, max(len(fair.queues[prefix][0].ports), 1)) {
+				return i
+			}
+			if prefixWait := fair.pacer.wait(prefix); i == 0 || prefixWait < wait {
+				wait = prefixWait
+			}
+		}
+		if !fair.pacer.sleep(wait) {
+			return 0
+		}
+	}
+}
+
+// oldest returns the lowest index of the queued picks, the first of each queue
+func (fair *prefixInterleaver) oldest() int64 {
+	oldest := int64(-1)
+	for _, queue := range fair.queues {
+		if oldest < 0 || queue[0].index < oldest {
+			oldest = queue[0].index
+		}
+	}
+	return oldest
+}
+
+// ResumeIndex returns an index below every pick not sent yet, the index of the sent pick without
+// reordering
+func (fair *prefixInterleaver) ResumeIndex(sent int64) int64 {
+	if fair == nil {
+		return sent
+	}
+	if fair.resume < 0 || fair.resume > sent {
+		return sent
+	}
+	return fair.resume
+}
@@ -0,0 +1,93 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefixInterleaver(t *testing.T) {
+	fair := newPrefixInterleaver(4, 0, nil)
+	var sent []*fairPick
+	for index, ip := range []string{"10.1.0.1", "10.1.0.2", "10.1.0.3", "10.2.0.1", "10.1.0.4", "10.3.0.1", "10.2.0.2"} {
+		sent = append(sent, fair.Push(&fairPick{index: int64(index), ip: ip})...)
+	}
+	sent = append(sent, fair.Drain()...)
+
+	var ips []string
+	for _, pick := range sent {
+		ips = append(ips, pick.ip)
+	}
+	require.Equal(t, []string{"10.1.0.1", "10.2.0.1", "10.1.0.2", "10.3.0.1", "10.2.0.2", "10.1.0.3", "10.1.0.4"}, ips)
+
+	// every pick not sent yet is at or after the resume index
+	fair = newPrefixInterleaver(4, 0, nil)
+	for index, ip := range []string{"10.1.0.1", "10.1.0.2", "10.1.0.3", "10.1.0.4", "10.2.0.1"} {
+		fair.Push(&fairPick{index: int64(index), ip: ip})
+	}
+	require.Equal(t, int64(1), fair.ResumeIndex(4))
+	require.Equal(t, int64(0), fair.ResumeIndex(0))
+
+	require.Nil(t, newPrefixInterleaver(0, 0, nil))
+	var disabled *prefixInterleaver
+	require.Len(t, disabled.Push(&fairPick{ip: "10.1.0.1"}), 1)
+	require.Empty(t, disabled.Drain())
+	require.Equal(t, int64(7), disabled.ResumeIndex(7))
+}
+
+func TestPrefixRate(t *testing.T) {
+	// the prefix rate is opt-in and uses the default window when none is given
+	fair := newPrefixInterleaver(0, 2, nil)
+	require.Equal(t, DefaultFairnessWindow, fair.window)
+	fair = newPrefixInterleaver(4, 2, nil)
+	now := time.Unix(0, 0)
+	var slept time.Duration
+	fair.pacer.now = func() time.Time { return now }
+	fair.pacer.sleep = func(d time.Duration) bool {
+		slept += d
+		now = now.Add(d)
+		return true
+	}
+
+	var ips []string
+	for _, ip := range []string{"10.1.0.1", "10.1.0.2", "10.1.0.3", "10.2.0.1", "10.1.0.4", "10.1.0.5"} {
+		for _, pick := range fair.Push(&fairPick{ip: ip}) {
+			ips = append(ips, pick.ip)
+		}
+	}
+	for _, pick := range fair.Drain() {
+		ips = append(ips, pick.ip)
+	}
+	// once the minority prefix drained, the dominant one is sent at 2 probes per second
+	require.Equal(t, []string{"10.1.0.1", "10.2.0.1", "10.1.0.2", "10.1.0.3", "10.1.0.4", "10.1.0.5"}, ips)
+	require.Equal(t, 2*time.Second, slept)
+
+	// a burst of ports takes a token per port
+	fair = newPrefixInterleaver(1, 10, nil)
+	fair.pacer.now = func() time.Time { return now }
+	require.True(t, fair.pacer.take("a", 5))
+	require.False(t, fair.pacer.take("a", 1))
+	require.Equal(t, 500*time.Millisecond, fair.pacer.wait("a"))
+}
+
+func TestPrefixRateStop(t *testing.T) {
+	stop := make(chan struct{})
+	close(stop)
+	fair := newPrefixInterleaver(4, 1, stop)
+	for _, ip := range []string{"10.1.0.1", "10.1.0.2", "10.1.0.3"} {
+		require.Empty(t, fair.Push(&fairPick{ip: ip}))
+	}
+
+	// the stopped scan drains the picks without waiting for the tokens of the prefix
+	start := time.Now()
+	require.Len(t, fair.Drain(), 3)
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestNetworkPrefix(t *testing.T) {
+	require.Equal(t, networkPrefix("10.1.2.3"), networkPrefix("10.1.200.1"))
+	require.NotEqual(t, networkPrefix("10.1.2.3"), networkPrefix("10.2.2.3"))
+	require.Equal(t, networkPrefix("2001:db8:1::1"), networkPrefix("2001:db8:1:ff::1"))
+	require.NotEqual(t, networkPrefix("2001:db8:1::1"), networkPrefix("2001:db8:2::1"))
+}
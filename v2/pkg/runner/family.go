@@ -0,0 +1,128 @@
+package runner
+
+import (
+	"sync"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+	sliceutil "github.com/projectdiscovery/utils/slice"
+)
+
+// IPVersionAuto scans each hostname over the address family found reachable by the host discovery
+const IPVersionAuto = "auto"
+
+// autoIPVersion returns true if the address family of each hostname is selected by reachability
+func (options *Options) autoIPVersion() bool {
+	return sliceutil.Contains(options.IPVersion, IPVersionAuto)
+}
+
+// discoversFamilies returns true if the family of the dual-stack hostnames is selected from the
+// hosts found alive by the discovery probes, otherwise they are scanned over ipv4
+func (options *Options) discoversFamilies() bool {
+	return options.autoIPVersion() && options.shouldDiscoverHosts() && options.shouldUseRawPackets() && !options.Stream
+}
+
+// preferFamily returns the addresses of the family to scan the hostname over, ipv4 unless only
+// its ipv6 addresses are reachable
+func preferFamily(target string, ipsV4, ipsV6 []string, reachable func(ip string) bool) ([]string, []string) {
+	if len(ipsV4) == 0 || len(ipsV6) == 0 {
+		return ipsV4, ipsV6
+	}
+	if anyReachable(ipsV4, reachable) {
+		return ipsV4, nil
+	}
+	if anyReachable(ipsV6, reachable) {
+		gologger.Verbose().Msgf("Scanning %s over ipv6, its ipv4 addresses are unreachable\n", target)
+		return nil, ipsV6
+	}
+	gologger.Debug().Msgf("Neither family of %s is reachable, scanning over ipv4\n", target)
+	return ipsV4, nil
+}
+
+// anyReachable returns true if one of the addresses is reachable
+func anyReachable(ips []string, reachable func(ip string) bool) bool {
+	for _, ip := range ips {
+		if reachable(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// dualStackHost is the addresses of both families of a hostname
+type dualStackHost struct {
+	ipsV4, ipsV6 []string
+}
+
+// dualStackHosts are the hostnames loaded with both families, whose family is selected once the
+// host discovery probed the addresses of both
+type dualStackHosts struct {
+	sync.Mutex
+	hosts map[string]dualStackHost
+}
+
+// add records the addresses of both families of the hostname
+func (d *dualStackHosts) add(target string, ipsV4, ipsV6 []string) {
+	d.Lock()
+	defer d.Unlock()
+	if d.hosts == nil {
+		d.hosts = make(map[string]dualStackHost)
+	}
+	d.hosts[target] = dualStackHost{ipsV4: ipsV4, ipsV6: ipsV6}
+}
+
+// selectFamily returns the addresses of the hostname to scan with the automatic ip version, both
+// families being kept for the host discovery to select one of them
+func (r *Runner) selectFamily(target string, ipsV4, ipsV6 []string) ([]string, []string) {
+	if len(ipsV4) == 0 || len(ipsV6) == 0 {
+		return ipsV4, ipsV6
+	}
+	if !r.options.discoversFamilies() {
+		return ipsV4, nil
+	}
+	r.dualStack.add(target, ipsV4, ipsV6)
+	return ipsV4, ipsV6
+}
+
+// selectDiscoveredFamilies removes from the alive hosts the addresses of the family not selected
+// for each dual-stack hostname, unless another target resolved to them
+func (r *Runner) selectDiscoveredFamilies(alive *result.Result) {
+	r.dualStack.Lock()
+	defer r.dualStack.Unlock()
+
+	dropped, kept := make(map[string]struct{}), make(map[string]struct{})
+	for target, host := range r.dualStack.hosts {
+		selectedV4, selectedV6 := preferFamily(target, host.ipsV4, host.ipsV6, alive.HasIP)
+		unselected := host.ipsV6
+		if len(selectedV4) == 0 {
+			unselected = host.ipsV4
+		}
+		for _, ip := range unselected {
+			dropped[ip] = struct{}{}
+		}
+		for _, ip := range append(selectedV4, selectedV6...) {
+			kept[ip] = struct{}{}
+		}
+	}
+	for ip := range dropped {
+		if _, ok := kept[ip]; ok || r.loadedForOtherTarget(ip) {
+			continue
+		}
+		alive.RemoveIP(ip)
+	}
+}
+
+// loadedForOtherTarget returns true if the ip was loaded for a target other than the dual-stack
+// hostnames, such as the ip itself or a single family hostname
+func (r *Runner) loadedForOtherTarget(ip string) bool {
+	hosts, err := r.scanner.IPRanger.GetHostsByIP(ip)
+	if err != nil {
+		return false
+	}
+	for _, host := range hosts {
+		if _, ok := r.dualStack.hosts[host]; !ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/projectdiscovery/ipranger"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+	"github.com/projectdiscovery/naabu/v2/pkg/scan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreferFamily(t *testing.T) {
+	ipsV4, ipsV6 := []string{"192.0.2.1"}, []string{"2001:db8::1"}
+	reachable := func(ips ...string) func(string) bool {
+		return func(ip string) bool {
+			for _, reachableIP := range ips {
+				if ip == reachableIP {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	v4, v6 := preferFamily("example.com", ipsV4, ipsV6, reachable("192.0.2.1", "2001:db8::1"))
+	require.Equal(t, ipsV4, v4)
+	require.Empty(t, v6)
+
+	// the host is scanned over ipv6 when only its AAAA record is reachable
+	v4, v6 = preferFamily("example.com", ipsV4, ipsV6, reachable("2001:db8::1"))
+	require.Empty(t, v4)
+	require.Equal(t, ipsV6, v6)
+
+	v4, v6 = preferFamily("example.com", ipsV4, ipsV6, reachable())
+	require.Equal(t, ipsV4, v4)
+	require.Empty(t, v6)
+
+	// single family hosts are not probed
+	v4, v6 = preferFamily("example.com", nil, ipsV6, func(string) bool {
+		t.Fatal("unexpected probe")
+		return false
+	})
+	require.Empty(t, v4)
+	require.Equal(t, ipsV6, v6)
+}
+
+func TestSelectDiscoveredFamilies(t *testing.T) {
+	ranger, err := ipranger.New()
+	require.Nil(t, err)
+	defer ranger.Close()
+	r := &Runner{
+		options: &Options{IPVersion: []string{IPVersionAuto}},
+		scanner: &scan.Scanner{IPRanger: ranger},
+	}
+	for target, ips := range map[string][]string{
+		"v4.example.com":    {"192.0.2.1", "2001:db8::1"},
+		"v6.example.com":    {"192.0.2.2", "2001:db8::2"},
+		"other.example.com": {"192.0.2.3", "2001:db8::3"},
+	} {
+		r.dualStack.add(target, ips[:1], ips[1:])
+		for _, ip := range ips {
+			require.Nil(t, ranger.AddHostWithMetadata(ip, target))
+		}
+	}
+	// the ipv6 address of other.example.com is a target of its own
+	require.Nil(t, ranger.AddHostWithMetadata("2001:db8::3", "2001:db8::3"))
+
+	alive := result.NewResult()
+	for _, ip := range []string{"192.0.2.1", "2001:db8::1", "2001:db8::2", "192.0.2.3", "2001:db8::3"} {
+		alive.AddIp(ip)
+	}
+	r.selectDiscoveredFamilies(alive)
+	// the hostnames are scanned over ipv4 unless only their ipv6 addresses answered the probes
+	require.True(t, alive.HasIP("192.0.2.1"))
+	require.False(t, alive.HasIP("2001:db8::1"))
+	require.True(t, alive.HasIP("2001:db8::2"))
+	require.True(t, alive.HasIP("192.0.2.3"))
+	require.True(t, alive.HasIP("2001:db8::3"))
+}
@@ -0,0 +1,81 @@
+package runner
+
+import (
+	"math"
+
+	"github.com/projectdiscovery/gologger"
+)
+
+const (
+	// fdReserve is the number of file descriptors kept for the pcap handles, output files, dns
+	// and the http clients next to the connect scan sockets
+	fdReserve = 128
+	// fdLimitTarget caps the raised soft limit when the hard limit is unlimited
+	fdLimitTarget = 1 << 16
+	// minConnectConcurrency is the concurrency kept when the file limit is lower than the reserve
+	minConnectConcurrency = 8
+)
+
+// connectConcurrency returns the number of concurrent connections allowed by the open file limit
+func connectConcurrency(limit uint64, requested int) int {
+	if limit == 0 || requested <= 0 {
+		return requested
+	}
+	available := minConnectConcurrency
+	if limit > fdReserve+minConnectConcurrency {
+		available = int(limit - fdReserve)
+	}
+	if requested > available {
+		return available
+	}
+	return requested
+}
+
+// socketBudget bounds the sockets held at once by the connect probes, the verification and the
+// banner, vhost, interception and anycast dials to the open file limit, a nil budget being unlimited
+type socketBudget chan struct{}
+
+// acquire waits for a socket of the budget
+func (b socketBudget) acquire() {
+	if b != nil {
+		b <- struct{}{}
+	}
+}
+
+// release returns the socket to the budget
+func (b socketBudget) release() {
+	if b != nil {
+		<-b
+	}
+}
+
+// tuneFileLimit raises the open file limit when permitted, once, and sizes the socket budget after it
+func (r *Runner) tuneFileLimit() {
+	r.socketsOnce.Do(func() {
+		previous, limit, err := raiseFileLimit()
+		if err != nil {
+			gologger.Debug().Msgf("Could not tune open file limit: %s\n", err)
+			return
+		}
+		if limit > previous {
+			gologger.Verbose().Msgf("Raised open file limit from %d to %d\n", previous, limit)
+		}
+		r.fileLimit = limit
+		r.sockets = make(socketBudget, connectConcurrency(limit, math.MaxInt32))
+	})
+}
+
+// scanConcurrency raises the open file limit when permitted and returns the concurrency of the
+// scan workers, clamped to the limit when connect probes hold a socket each
+func (r *Runner) scanConcurrency() int {
+	requested := r.maxRate()
+	r.tuneFileLimit()
+	if r.options.shouldUseRawPackets() && len(r.connectPorts) == 0 {
+		return requested
+	}
+	concurrency := connectConcurrency(r.fileLimit, requested)
+	if concurrency < requested {
+		gologger.Warning().Msgf("Open file limit is %d, reducing connect scan concurrency from %d to %d (raise it with ulimit -n)\n", r.fileLimit, requested, concurrency)
+	}
+	return concurrency
+}
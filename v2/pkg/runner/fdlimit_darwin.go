@@ -0,0 +1,12 @@
+package runner
+
+import "syscall"
+
+// maxFilesPerProcess returns the maximum number of open files of a process (kern.maxfilesperproc)
+func maxFilesPerProcess() (uint64, bool) {
+	value, err := syscall.SysctlUint32("kern.maxfilesperproc")
+	if err != nil {
+		return 0, false
+	}
+	return uint64(value), true
+}
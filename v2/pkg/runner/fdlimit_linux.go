@@ -0,0 +1,7 @@
+package runner
+
+// maxFilesPerProcess returns the maximum number of open files of a process, only capped by the
+// hard limit on linux
+func maxFilesPerProcess() (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+
+package runner
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// raiseFileLimit raises the soft limit of open files up to the hard limit and returns the
+// previous and current soft limits
+func raiseFileLimit() (uint64, uint64, error) {
+	return 0, 0, fmt.Errorf("open file limit not available on %s", runtime.GOOS)
+}
@@ -0,0 +1,51 @@
+package runner
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectConcurrency(t *testing.T) {
+	// default soft limit of most linux distributions
+	require.Equal(t, 1024-fdReserve, connectConcurrency(1024, 1500))
+	require.Equal(t, 1500, connectConcurrency(1<<16, 1500))
+	require.Equal(t, 25, connectConcurrency(1024, 25))
+	// unknown limit keeps the requested concurrency
+	require.Equal(t, 1500, connectConcurrency(0, 1500))
+	require.Equal(t, minConnectConcurrency, connectConcurrency(64, 1500))
+}
+
+func TestSocketBudget(t *testing.T) {
+	budget := make(socketBudget, 1)
+	budget.acquire()
+	acquired := make(chan struct{})
+	go func() {
+		budget.acquire()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("acquired a socket beyond the budget")
+	case <-time.After(50 * time.Millisecond):
+	}
+	budget.release()
+	<-acquired
+
+	// a nil budget is unlimited
+	var unlimited socketBudget
+	unlimited.acquire()
+	unlimited.acquire()
+	unlimited.release()
+}
+
+func TestTuneFileLimit(t *testing.T) {
+	r := &Runner{options: &Options{}}
+	r.tuneFileLimit()
+	if r.fileLimit == 0 {
+		t.Skip("open file limit not available")
+	}
+	require.Equal(t, connectConcurrency(r.fileLimit, math.MaxInt32), cap(r.sockets))
+}
@@ -0,0 +1,39 @@
+//go:build linux || darwin
+
+package runner
+
+import (
+	"syscall"
+)
+
+// raiseFileLimit raises the soft limit of open files up to the hard limit and returns the
+// previous and current soft limits
+func raiseFileLimit() (uint64, uint64, error) {
+	var rlimit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rlimit); err != nil {
+		return 0, 0, err
+	}
+	previous := uint64(rlimit.Cur)
+	target := uint64(rlimit.Max)
+	if target > fdLimitTarget {
+		target = fdLimitTarget
+	}
+	if target <= previous {
+		return previous, previous, nil
+	}
+	rlimit.Cur = target
+	if err := syscall.Setrlimit(syscall.RLIMIT_NOFILE, &rlimit); err != nil {
+		// darwin rejects values above kern.maxfilesperproc, which is tried instead, the current
+		// limit being kept otherwise
+		perProcess, ok := maxFilesPerProcess()
+		if !ok || perProcess <= previous || perProcess >= target {
+			return previous, previous, nil
+		}
+		rlimit.Cur = perProcess
+		if err := syscall.Setrlimit(syscall.RLIMIT_NOFILE, &rlimit); err != nil {
+			return previous, previous, nil
+		}
+		return previous, perProcess, nil
+	}
+	return previous, target, nil
+}
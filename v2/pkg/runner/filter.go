@@ -0,0 +1,90 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Knetic/govaluate"
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+)
+
+// filterVariables are the result fields available in filter expressions
+var filterVariables = []string{"host", "ip", "port", "protocol", "tls", "cdn", "cdn_name", "banner", "tags"}
+
+// resultFilter selects the results to output with an expression (example: port in (80,443) && cdn == false)
+type resultFilter struct {
+	expression *govaluate.EvaluableExpression
+}
+
+// newResultFilter parses the filter expression and checks it only references known fields
+func newResultFilter(value string) (*resultFilter, error) {
+	expression, err := govaluate.NewEvaluableExpression(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid filter expression")
+	}
+	for _, variable := range expression.Vars() {
+		if !isFilterVariable(variable) {
+			return nil, fmt.Errorf("unknown filter field %s (allowed: %s)", variable, strings.Join(filterVariables, ", "))
+		}
+	}
+	return &resultFilter{expression: expression}, nil
+}
+
+func isFilterVariable(variable string) bool {
+	for _, filterVariable := range filterVariables {
+		if variable == filterVariable {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns true if the port of the host satisfies the expression
+func (filter *resultFilter) Match(host, ip string, p *port.Port, isCDNIP bool, cdnName string, tags Tags) bool {
+	values := make([]interface{}, len(tags))
+	for i, tag := range tags {
+		values[i] = tag
+	}
+	parameters := map[string]interface{}{
+		"host":     host,
+		"ip":       ip,
+		"port":     float64(p.Port),
+		"protocol": p.Protocol.String(),
+		"tls":      p.TLS,
+		"cdn":      isCDNIP,
+		"cdn_name": cdnName,
+		"banner":   p.Banner,
+		"tags":     values,
+	}
+	value, err := filter.expression.Evaluate(parameters)
+	if err != nil {
+		gologger.Debug().Msgf("Couldn't evaluate filter on %s:%d: %s\n", host, p.Port, err)
+		return false
+	}
+	matched, ok := value.(bool)
+	return ok && matched
+}
+
+// filterPorts returns the ports of the host matching the filter expression if any, without the
+// ports expected by the baseline
+func (r *Runner) filterPorts(host, ip string, ports []*port.Port, isCDNIP bool, cdnName string) []*port.Port {
+	if r.filter == nil && r.baseline == nil && r.vhosts == nil {
+		return ports
+	}
+	var filtered []*port.Port
+	tags := r.resultTags(host, ip)
+	for _, p := range ports {
+		if r.baseline != nil && r.baseline.expected(host, ip, p) {
+			continue
+		}
+		if !r.vhosts.terminates(host, ip, p) {
+			continue
+		}
+		if r.filter == nil || r.filter.Match(host, ip, p, isCDNIP, cdnName, tags) {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,54 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultFilter(t *testing.T) {
+	http := &port.Port{Port: 80, Protocol: protocol.TCP}
+	ssh := &port.Port{Port: 22, Protocol: protocol.TCP, Banner: "SSH-2.0-OpenSSH_8.9"}
+
+	tests := []struct {
+		expression string
+		port       *port.Port
+		isCDN      bool
+		want       bool
+	}{
+		{"port in (80,443) && cdn == false", http, false, true},
+		{"port in (80,443) && cdn == false", http, true, false},
+		{"port in (80,443)", ssh, false, false},
+		{"protocol == 'tcp' && port < 1024", ssh, false, true},
+		{"banner =~ 'OpenSSH'", ssh, false, true},
+		{"host == 'scanme.sh'", http, false, true},
+		{"'cloud:aws' in tags", http, false, true},
+		{"!('cdn:cloudfront' in tags)", http, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			filter, err := newResultFilter(tt.expression)
+			require.Nil(t, err)
+			require.Equal(t, tt.want, filter.Match("scanme.sh", "45.33.32.156", tt.port, tt.isCDN, "", Tags{"cloud:aws"}))
+		})
+	}
+
+	_, err := newResultFilter("service == 'http'")
+	require.NotNil(t, err, "unknown fields should be rejected")
+	_, err = newResultFilter("port in (80")
+	require.NotNil(t, err)
+}
+
+func TestFilterPorts(t *testing.T) {
+	ports := []*port.Port{{Port: 80, Protocol: protocol.TCP}, {Port: 22, Protocol: protocol.TCP}}
+
+	r := &Runner{}
+	require.Equal(t, ports, r.filterPorts("host", "127.0.0.1", ports, false, ""))
+
+	filter, err := newResultFilter("port == 22")
+	require.Nil(t, err)
+	r.filter = filter
+	require.Equal(t, ports[1:], r.filterPorts("host", "127.0.0.1", ports, false, ""))
+}
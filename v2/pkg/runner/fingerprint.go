@@ -0,0 +1,103 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// FingerprintExact matches the hosts whose open ports are the fingerprint ports
+	FingerprintExact = "exact"
+	// FingerprintAll matches the hosts with all the fingerprint ports open, among others
+	FingerprintAll = "all"
+)
+
+// fingerprint is the open port set of known infrastructure (eg. cdn edges, corporate proxies)
+type fingerprint struct {
+	Name  string `yaml:"name"`
+	Ports string `yaml:"ports"`
+	Match string `yaml:"match"`
+	ports *port.List
+}
+
+// fingerprintFile holds the fingerprints of the hosts to drop from the output:
+//
+//	fingerprints:
+//	  - name: cdn-edge
+//	    ports: 80,443,2052,2053,2082,2083,2086,2087,2095,2096,8080,8443,8880
+//	    match: all
+//	  - name: corporate-proxy
+//	    ports: 80,443,3128
+type fingerprintFile struct {
+	Fingerprints []*fingerprint `yaml:"fingerprints"`
+}
+
+// fingerprints drop the hosts whose open ports match known infrastructure, reducing the noise of
+// recurring external scans
+type fingerprints []*fingerprint
+
+// loadFingerprints reads the fingerprints file
+func loadFingerprints(path string) (fingerprints, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file fingerprintFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid fingerprints file %s: %w", path, err)
+	}
+	for i, f := range file.Fingerprints {
+		if f.Name == "" {
+			return nil, fmt.Errorf("fingerprint %d: no name", i+1)
+		}
+		if f.Ports == "" {
+			return nil, fmt.Errorf("fingerprint %s: no ports", f.Name)
+		}
+		if f.ports, err = parsePortRanges(f.Ports); err != nil {
+			return nil, fmt.Errorf("fingerprint %s: %s", f.Name, err)
+		}
+		switch f.Match {
+		case "":
+			f.Match = FingerprintExact
+		case FingerprintExact, FingerprintAll:
+		default:
+			return nil, fmt.Errorf("fingerprint %s: invalid match %s (allowed: %s, %s)", f.Name, f.Match, FingerprintExact, FingerprintAll)
+		}
+	}
+	return file.Fingerprints, nil
+}
+
+// match returns the first fingerprint matched by the open ports of a host
+func (fingerprints fingerprints) match(ports []*port.Port) *fingerprint {
+	if len(fingerprints) == 0 || len(ports) == 0 {
+		return nil
+	}
+	open := port.NewList(ports...)
+	for _, f := range fingerprints {
+		if f.Match == FingerprintExact && open.Len() != f.ports.Len() {
+			continue
+		}
+		matched := true
+		f.ports.Each(func(p *port.Port) {
+			matched = matched && open.Contains(p.Port, p.Protocol)
+		})
+		if matched {
+			return f
+		}
+	}
+	return nil
+}
+
+// filterHostPorts returns the filtered ports of the host, none if its open ports match an
+// infrastructure fingerprint
+func (r *Runner) filterHostPorts(host, ip string, ports []*port.Port, isCDNIP bool, cdnName string) []*port.Port {
+	if f := r.fingerprints.match(ports); f != nil {
+		gologger.Debug().Msgf("Dropping %s from the output, its open ports match the %s fingerprint\n", host, f.Name)
+		return nil
+	}
+	return r.filterPorts(host, ip, ports, isCDNIP, cdnName)
+}
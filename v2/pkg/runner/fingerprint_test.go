@@ -0,0 +1,81 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func tcpPorts(numbers ...int) []*port.Port {
+	ports := make([]*port.Port, 0, len(numbers))
+	for _, number := range numbers {
+		ports = append(ports, &port.Port{Port: number, Protocol: protocol.TCP})
+	}
+	return ports
+}
+
+func TestFingerprints(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fingerprints.yaml")
+	require.Nil(t, os.WriteFile(path, []byte(`fingerprints:
+  - name: cdn-edge
+    ports: 80,443,2052-2053
+    match: all
+  - name: corporate-proxy
+    ports: 80,443,3128
+`), 0600))
+	fingerprints, err := loadFingerprints(path)
+	require.Nil(t, err)
+	require.Len(t, fingerprints, 2)
+
+	require.Equal(t, "cdn-edge", fingerprints.match(tcpPorts(443, 80, 2052, 2053, 8080)).Name)
+	require.Equal(t, "corporate-proxy", fingerprints.match(tcpPorts(3128, 443, 80)).Name)
+	// exact fingerprints don't match hosts exposing more ports
+	require.Nil(t, fingerprints.match(tcpPorts(80, 443, 3128, 22)))
+	require.Nil(t, fingerprints.match(tcpPorts(80, 443)))
+	require.Nil(t, fingerprints.match(nil))
+}
+
+func TestFingerprintsErrors(t *testing.T) {
+	for _, content := range []string{
+		"fingerprints:\n  - ports: 80\n",
+		"fingerprints:\n  - name: proxy\n",
+		"fingerprints:\n  - name: proxy\n    ports: 80,x\n",
+		"fingerprints:\n  - name: proxy\n    ports: 80\n    match: some\n",
+		"fingerprints: [",
+	} {
+		path := filepath.Join(t.TempDir(), "fingerprints.yaml")
+		require.Nil(t, os.WriteFile(path, []byte(content), 0600))
+		_, err := loadFingerprints(path)
+		require.NotNil(t, err, content)
+	}
+}
+
+func TestFingerprintsLiveSinks(t *testing.T) {
+	// the fingerprints match the final port set of a host, the live sinks publish each port as found
+	path := filepath.Join(t.TempDir(), "fingerprints.yaml")
+	require.Nil(t, os.WriteFile(path, []byte("fingerprints:\n  - name: proxy\n    ports: 80,443\n"), 0600))
+	options := DefaultOptions()
+	options.Host = []string{"scanme.sh"}
+	options.ScanType = ConnectScan
+	options.ExcludeFingerprints = path
+	require.Nil(t, options.ValidateOptions())
+	for _, sink := range []func(*Options){
+		func(options *Options) { options.StreamOutput = "results.jsonl" },
+		func(options *Options) { options.WebhookURL = "https://hooks.example.com/naabu" },
+		func(options *Options) { options.ElasticsearchURL = "https://es.example.com:9200" },
+		func(options *Options) { options.LiveResults = true },
+	} {
+		withSink := *options
+		sink(&withSink)
+		require.NotNil(t, withSink.ValidateOptions())
+	}
+
+	r := &Runner{fingerprints: fingerprints{{Name: "proxy"}}}
+	require.Nil(t, r.checkLiveFingerprints())
+	r.GetResults()
+	require.NotNil(t, r.checkLiveFingerprints())
+}
@@ -0,0 +1,146 @@
+package runner
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+)
+
+const (
+	// fullRangeStripes scan the most common ports of every host before the rest of the range
+	fullRangeStripes = "100,1000"
+	// fullRangeRxBuffer is the pcap receive buffer (MiB) absorbing the response bursts of full range scans
+	fullRangeRxBuffer = 64
+	// slowestHosts is the number of hosts listed in the per host duration summary
+	slowestHosts = 5
+)
+
+// isFullRange returns true if all the 65535 ports are scanned
+func (options *Options) isFullRange() bool {
+	if strings.EqualFold(options.TopPorts, "full") {
+		return true
+	}
+	switch strings.TrimSpace(options.Ports) {
+	case "-", "1-65535", "0-65535":
+		return true
+	}
+	return false
+}
+
+// usesFullRangePreset returns true if the full range preset applies to the scan
+func (options *Options) usesFullRangePreset() bool {
+	return !options.DisableFullRangePreset && options.isFullRange()
+}
+
+// configureFullRange tunes the options left at their defaults for scans of all the 65535 ports:
+// top ports stripes first and a larger receive buffer, and adaptive retries for -top-ports full. The
+// retries of -p - scans keep the strategy of the command
+func (options *Options) configureFullRange() {
+	if !options.usesFullRangePreset() {
+		return
+	}
+	var tuned []string
+	if options.PortStripes == "" && options.Sample == "" && !options.Stream {
+		options.PortStripes = fullRangeStripes
+		tuned = append(tuned, "port-stripes "+fullRangeStripes)
+	}
+	if strings.EqualFold(options.TopPorts, "full") && options.isDefault("retry-strategy", options.RetryStrategy == "") {
+		options.RetryStrategy = RetryAdaptive
+		tuned = append(tuned, "retry-strategy "+RetryAdaptive)
+	}
+	if options.RxBuffer == 0 {
+		options.RxBuffer = fullRangeRxBuffer
+		tuned = append(tuned, "rx-buffer 64")
+	}
+	if len(tuned) > 0 {
+		gologger.Info().Msgf("Full range scan, tuned %s (disable with -disable-full-range-preset)\n", strings.Join(tuned, ", "))
+	}
+}
+
+// hostDurations records the first and last activity (probe sent or response received) of each host,
+// the activities of a known host being recorded without locking
+type hostDurations struct {
+	hosts sync.Map // ip -> *hostActivity
+}
+
+// hostActivity holds the first and last activity of a host in unix nanoseconds
+type hostActivity struct {
+	first atomic.Int64
+	last  atomic.Int64
+}
+
+func newHostDurations() *hostDurations {
+	return &hostDurations{}
+}
+
+// add records an activity of the host
+func (d *hostDurations) add(ip string, at time.Time) {
+	if d == nil {
+		return
+	}
+	nanos := at.UnixNano()
+	value, ok := d.hosts.Load(ip)
+	if !ok {
+		activity := &hostActivity{}
+		activity.first.Store(nanos)
+		activity.last.Store(nanos)
+		if value, ok = d.hosts.LoadOrStore(ip, activity); !ok {
+			return
+		}
+	}
+	activity := value.(*hostActivity)
+	for first := activity.first.Load(); nanos < first && !activity.first.CompareAndSwap(first, nanos); first = activity.first.Load() {
+	}
+	for last := activity.last.Load(); nanos > last && !activity.last.CompareAndSwap(last, nanos); last = activity.last.Load() {
+	}
+}
+
+// hostDuration is the time spent scanning a host
+type hostDuration struct {
+	ip       string
+	duration time.Duration
+}
+
+// sorted returns the durations of the hosts, slowest first
+func (d *hostDurations) sorted() []hostDuration {
+	var durations []hostDuration
+	d.hosts.Range(func(key, value any) bool {
+		activity := value.(*hostActivity)
+		durations = append(durations, hostDuration{ip: key.(string), duration: time.Duration(activity.last.Load() - activity.first.Load())})
+		return true
+	})
+	sort.Slice(durations, func(i, j int) bool {
+		if durations[i].duration != durations[j].duration {
+			return durations[i].duration > durations[j].duration
+		}
+		return durations[i].ip < durations[j].ip
+	})
+	return durations
+}
+
+// reportDurations shows the minimum, median and maximum time spent on each host and the slowest hosts
+func (r *Runner) reportDurations() {
+	if r.durations == nil {
+		return
+	}
+	durations := r.durations.sorted()
+	if len(durations) == 0 {
+		return
+	}
+	last := len(durations) - 1
+	gologger.Info().Msgf("Per host durations of %d hosts: min %s, median %s, max %s\n",
+		len(durations), durations[last].duration.Round(time.Millisecond), durations[last/2].duration.Round(time.Millisecond), durations[0].duration.Round(time.Millisecond))
+
+	if len(durations) > slowestHosts {
+		durations = durations[:slowestHosts]
+	}
+	slowest := make([]string, 0, len(durations))
+	for _, host := range durations {
+		slowest = append(slowest, host.ip+" "+host.duration.Round(time.Millisecond).String())
+	}
+	gologger.Info().Msgf("Slowest hosts: %s\n", strings.Join(slowest, ", "))
+}
@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureFullRange(t *testing.T) {
+	options := &Options{Ports: "-", RetryStrategy: RetryUniform, givenFlags: map[string]struct{}{}}
+	options.configureFullRange()
+	require.Equal(t, fullRangeStripes, options.PortStripes)
+	require.Equal(t, RetryUniform, options.RetryStrategy, "-p - keeps the retry strategy")
+	require.Equal(t, fullRangeRxBuffer, options.RxBuffer)
+
+	options = &Options{TopPorts: "full", RetryStrategy: RetryUniform, givenFlags: map[string]struct{}{}}
+	options.configureFullRange()
+	require.Equal(t, RetryAdaptive, options.RetryStrategy)
+
+	// an explicit retry strategy is kept
+	options = &Options{TopPorts: "full", RetryStrategy: RetryUniform, givenFlags: map[string]struct{}{"retry-strategy": {}}}
+	options.configureFullRange()
+	require.Equal(t, RetryUniform, options.RetryStrategy)
+	options = &Options{TopPorts: "full", RetryStrategy: RetryUniform}
+	options.configureFullRange()
+	require.Equal(t, RetryUniform, options.RetryStrategy)
+
+	// explicit values are kept
+	options = &Options{TopPorts: "full", PortStripes: "100", RxBuffer: 8}
+	options.configureFullRange()
+	require.Equal(t, "100", options.PortStripes)
+	require.Equal(t, 8, options.RxBuffer)
+
+	// stripes are incompatible with stream mode
+	options = &Options{Ports: "1-65535", Stream: true}
+	options.configureFullRange()
+	require.Empty(t, options.PortStripes)
+
+	options = &Options{Ports: "-", DisableFullRangePreset: true}
+	options.configureFullRange()
+	require.Empty(t, options.RetryStrategy)
+	require.False(t, options.usesFullRangePreset())
+
+	options = &Options{Ports: "1-1024"}
+	options.configureFullRange()
+	require.Empty(t, options.PortStripes)
+}
+
+func TestHostDurations(t *testing.T) {
+	durations := newHostDurations()
+	start := time.Now()
+
+	durations.add("10.0.0.1", start.Add(time.Second))
+	durations.add("10.0.0.1", start)
+	durations.add("10.0.0.1", start.Add(3*time.Second))
+	durations.add("10.0.0.2", start.Add(time.Second))
+	durations.add("10.0.0.2", start.Add(2*time.Second))
+
+	require.Equal(t, []hostDuration{
+		{ip: "10.0.0.1", duration: 3 * time.Second},
+		{ip: "10.0.0.2", duration: time.Second},
+	}, durations.sorted())
+
+	var disabled *hostDurations
+	disabled.add("10.0.0.1", start)
+}
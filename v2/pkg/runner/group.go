@@ -0,0 +1,235 @@
+package runner
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+)
+
+// Output grouping modes
+const (
+	GroupByHost = "host"
+	GroupByPort = "port"
+)
+
+// hostGroup aggregates the open ports of a host, a hostname scanned over several addresses (ipv4
+// and ipv6) keeping the ports of each of them
+type hostGroup struct {
+	Host      string          `json:"host,omitempty"`
+	IP        string          `json:"ip"`
+	Ports     []int           `json:"ports"`
+	Addresses []*addressGroup `json:"ips,omitempty"`
+}
+
+// addressGroup is the open ports of an address of a hostname
+type addressGroup struct {
+	IP     string `json:"ip"`
+	Family string `json:"family"`
+	Ports  []int  `json:"ports"`
+}
+
+// portGroup aggregates the hosts exposing a port
+type portGroup struct {
+	Port     int      `json:"port"`
+	Protocol string   `json:"protocol"`
+	Hosts    []string `json:"hosts"`
+	seen     map[string]struct{}
+}
+
+// handleGroupedOutput writes the results aggregated by host or by port
+func (r *Runner) handleGroupedOutput(scanResults *result.Result, file *os.File) {
+	hostGroups := make(map[string]*hostGroup)
+	portGroups := make(map[string]*portGroup)
+
+	for hostResult := range scanResults.GetIPsPorts() {
+		hosts, err := r.getResultHosts(hostResult)
+		if err != nil {
+			continue
+		}
+		for _, host := range hosts {
+			isCDNIP, cdnName, _ := r.scanner.CdnCheck(hostResult.IP)
+			ports := r.ratePorts(r.filterHostPorts(host, hostResult.IP, hostResult.Ports, isCDNIP, cdnName))
+			if len(ports) == 0 {
+				continue
+			}
+
+			// the addresses of a hostname are merged under the hostname
+			group, ok := hostGroups[host]
+			if !ok {
+				group = &hostGroup{IP: hostResult.IP}
+				if host != hostResult.IP {
+					group.Host = host
+				}
+				hostGroups[host] = group
+			}
+			address := &addressGroup{IP: hostResult.IP, Family: result.Family(hostResult.IP), Ports: portNumbers(ports)}
+			sort.Ints(address.Ports)
+			group.Addresses = append(group.Addresses, address)
+			for _, p := range ports {
+				key := fmt.Sprintf("%d/%s", p.Port, p.Protocol.String())
+				if _, ok := portGroups[key]; !ok {
+					portGroups[key] = &portGroup{Port: p.Port, Protocol: p.Protocol.String(), seen: make(map[string]struct{})}
+				}
+				portGroups[key].add(host)
+			}
+
+			r.onResult(&result.HostResult{Host: host, IP: hostResult.IP, Ports: ports})
+		}
+	}
+
+	var groups []interface{}
+	switch r.options.GroupBy {
+	case GroupByHost:
+		sortedHostGroups := make([]*hostGroup, 0, len(hostGroups))
+		for _, group := range hostGroups {
+			group.merge()
+			sortedHostGroups = append(sortedHostGroups, group)
+		}
+		sort.Slice(sortedHostGroups, func(i, j int) bool {
+			return sortedHostGroups[i].name() < sortedHostGroups[j].name()
+		})
+		for _, group := range sortedHostGroups {
+			groups = append(groups, group)
+		}
+	case GroupByPort:
+		sortedPortGroups := make([]*portGroup, 0, len(portGroups))
+		for _, group := range portGroups {
+			sort.Strings(group.Hosts)
+			sortedPortGroups = append(sortedPortGroups, group)
+		}
+		sort.Slice(sortedPortGroups, func(i, j int) bool {
+			if sortedPortGroups[i].Port == sortedPortGroups[j].Port {
+				return sortedPortGroups[i].Protocol < sortedPortGroups[j].Protocol
+			}
+			return sortedPortGroups[i].Port < sortedPortGroups[j].Port
+		})
+		for _, group := range sortedPortGroups {
+			groups = append(groups, group)
+		}
+	}
+
+	for _, group := range groups {
+		line, err := formatGroup(group, r.options.JSON)
+		if err != nil {
+			continue
+		}
+		gologger.Silent().Msgf("%s\n", line)
+	}
+	if file != nil {
+		if err := WriteGroupedOutput(r.anonymizer.Groups(groups), r.options.JSON, file); err != nil {
+			gologger.Error().Msgf("Could not write results to file %s: %s\n", r.options.Output, err)
+			r.recordError(ErrorOutput, r.options.Output, err)
+		}
+	}
+}
+
+func (group *hostGroup) name() string {
+	if group.Host != "" {
+		return group.Host
+	}
+	return group.IP
+}
+
+// merge sets the ports of the group to the ports of all its addresses, the ipv4 address coming
+// first. The addresses are only kept if the host has several of them
+func (group *hostGroup) merge() {
+	sort.SliceStable(group.Addresses, func(i, j int) bool {
+		a, b := group.Addresses[i], group.Addresses[j]
+		if a.Family != b.Family {
+			return a.Family == result.FamilyIPv4
+		}
+		return a.IP < b.IP
+	})
+	group.IP = group.Addresses[0].IP
+	group.Ports = group.Addresses[0].Ports
+	if len(group.Addresses) == 1 {
+		group.Addresses = nil
+		return
+	}
+	seen := make(map[int]struct{})
+	group.Ports = nil
+	for _, address := range group.Addresses {
+		for _, number := range address.Ports {
+			if _, ok := seen[number]; !ok {
+				seen[number] = struct{}{}
+				group.Ports = append(group.Ports, number)
+			}
+		}
+	}
+	sort.Ints(group.Ports)
+}
+
+// add appends the host exposing the port, once for all its addresses
+func (group *portGroup) add(host string) {
+	if _, ok := group.seen[host]; !ok {
+		group.seen[host] = struct{}{}
+		group.Hosts = append(group.Hosts, host)
+	}
+}
+
+// formatGroup returns the text or json line of a host or port group
+func formatGroup(group interface{}, asJSON bool) (string, error) {
+	if asJSON {
+		data, err := json.Marshal(group)
+		return string(data), err
+	}
+	switch g := group.(type) {
+	case *hostGroup:
+		line := fmt.Sprintf("%s: %s", g.name(), joinPorts(g.Ports))
+		if len(g.Addresses) > 0 {
+			addresses := make([]string, 0, len(g.Addresses))
+			for _, address := range g.Addresses {
+				addresses = append(addresses, fmt.Sprintf("%s: %s", address.IP, joinPorts(address.Ports)))
+			}
+			line += " (" + strings.Join(addresses, "; ") + ")"
+		}
+		return line, nil
+	case *portGroup:
+		return fmt.Sprintf("%d/%s: %s", g.Port, g.Protocol, strings.Join(g.Hosts, ",")), nil
+	default:
+		return "", fmt.Errorf("unknown group type %T", group)
+	}
+}
+
+// WriteGroupedOutput writes host or port groups to an io.Writer
+func WriteGroupedOutput(groups []interface{}, asJSON bool, writer io.Writer) error {
+	bufwriter := bufio.NewWriter(writer)
+	for _, group := range groups {
+		line, err := formatGroup(group, asJSON)
+		if err != nil {
+			return err
+		}
+		if _, err := bufwriter.WriteString(line + "\n"); err != nil {
+			bufwriter.Flush()
+			return err
+		}
+	}
+	return bufwriter.Flush()
+}
+
+// joinPorts returns the port numbers separated by commas
+func joinPorts(numbers []int) string {
+	ports := make([]string, 0, len(numbers))
+	for _, number := range numbers {
+		ports = append(ports, strconv.Itoa(number))
+	}
+	return strings.Join(ports, ",")
+}
+
+// portNumbers returns the port numbers of the ports
+func portNumbers(ports []*port.Port) []int {
+	numbers := make([]int, 0, len(ports))
+	for _, p := range ports {
+		numbers = append(numbers, p.Port)
+	}
+	return numbers
+}
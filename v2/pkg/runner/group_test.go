@@ -0,0 +1,51 @@
+package runner
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteGroupedOutput(t *testing.T) {
+	groups := []interface{}{
+		&hostGroup{Host: "scanme.sh", IP: "45.33.32.156", Ports: []int{22, 80}},
+		&hostGroup{IP: "10.0.0.1", Ports: []int{443}},
+		&portGroup{Port: 445, Protocol: "tcp", Hosts: []string{"10.0.0.1", "10.0.0.2"}},
+	}
+
+	buf := bytes.Buffer{}
+	require.Nil(t, WriteGroupedOutput(groups, false, &buf))
+	require.Equal(t, "scanme.sh: 22,80\n10.0.0.1: 443\n445/tcp: 10.0.0.1,10.0.0.2\n", buf.String())
+
+	buf.Reset()
+	require.Nil(t, WriteGroupedOutput(groups, true, &buf))
+	require.Equal(t, `{"host":"scanme.sh","ip":"45.33.32.156","ports":[22,80]}
+{"ip":"10.0.0.1","ports":[443]}
+{"port":445,"protocol":"tcp","hosts":["10.0.0.1","10.0.0.2"]}
+`, buf.String())
+}
+
+func TestHostGroupMerge(t *testing.T) {
+	group := &hostGroup{Host: "scanme.sh", Addresses: []*addressGroup{
+		{IP: "2600:3c01::1", Family: "ipv6", Ports: []int{22, 8080}},
+		{IP: "45.33.32.156", Family: "ipv4", Ports: []int{22, 80}},
+	}}
+	group.merge()
+	require.Equal(t, "45.33.32.156", group.IP)
+	require.Equal(t, []int{22, 80, 8080}, group.Ports)
+
+	line, err := formatGroup(group, false)
+	require.Nil(t, err)
+	require.Equal(t, "scanme.sh: 22,80,8080 (45.33.32.156: 22,80; 2600:3c01::1: 22,8080)", line)
+
+	line, err = formatGroup(group, true)
+	require.Nil(t, err)
+	require.Equal(t, `{"host":"scanme.sh","ip":"45.33.32.156","ports":[22,80,8080],"ips":[{"ip":"45.33.32.156","family":"ipv4","ports":[22,80]},{"ip":"2600:3c01::1","family":"ipv6","ports":[22,8080]}]}`, line)
+
+	// a single address is written as before
+	single := &hostGroup{Host: "scanme.sh", Addresses: []*addressGroup{{IP: "45.33.32.156", Family: "ipv4", Ports: []int{22}}}}
+	single.merge()
+	require.Nil(t, single.Addresses)
+	require.Equal(t, []int{22}, single.Ports)
+}
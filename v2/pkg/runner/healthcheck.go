@@ -8,6 +8,7 @@ import (
 
 	"github.com/projectdiscovery/goflags"
 	"github.com/projectdiscovery/naabu/v2/pkg/privileges"
+	"github.com/projectdiscovery/naabu/v2/pkg/scan"
 	fileutil "github.com/projectdiscovery/utils/file"
 )
 
@@ -20,6 +21,7 @@ func DoHealthCheck(options *Options, flagSet *goflags.FlagSet) string {
 	test.WriteString(fmt.Sprintf("Architecture: %s\n", runtime.GOARCH))
 	test.WriteString(fmt.Sprintf("Go Version: %s\n", runtime.Version()))
 	test.WriteString(fmt.Sprintf("Compiler: %s\n", runtime.Compiler))
+	test.WriteString(fmt.Sprintf("Raw Packet Engine: %s\n", scan.RawEngine()))
 
 	var testResult string
 	if privileges.IsPrivileged {
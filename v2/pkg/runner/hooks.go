@@ -0,0 +1,51 @@
+package runner
+
+import (
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+	"github.com/projectdiscovery/naabu/v2/pkg/scan"
+)
+
+// Hooks are optional callbacks invoked at each phase of the scan, allowing embedding applications
+// to attach logging, persistence or policy logic. They must be set before RunEnumeration.
+type Hooks struct {
+	// OnScanStart is called once the targets are loaded, before host discovery and port scan
+	OnScanStart func()
+	// OnHostDiscovered is called the first time a host answers the host discovery probes
+	OnHostDiscovered func(ip string)
+	// OnRetryStart is called before each port scan pass, retry is zero based
+	OnRetryStart func(retry int)
+	// OnScanComplete is called with the results once they have been written
+	OnScanComplete func(results *result.Result)
+	// OnPhaseChange is called when the scan moves to another phase (init, host-discovery, guard, scan, verify, done)
+	OnPhaseChange func(from, to scan.State)
+}
+
+func (r *Runner) onScanStart() {
+	if r.Hooks.OnScanStart != nil {
+		r.Hooks.OnScanStart()
+	}
+}
+
+func (r *Runner) onHostDiscovered(ip string) {
+	if r.Hooks.OnHostDiscovered != nil {
+		r.Hooks.OnHostDiscovered(ip)
+	}
+}
+
+func (r *Runner) onRetryStart(retry int) {
+	if r.Hooks.OnRetryStart != nil {
+		r.Hooks.OnRetryStart(retry)
+	}
+}
+
+func (r *Runner) onScanComplete(results *result.Result) {
+	if r.Hooks.OnScanComplete != nil {
+		r.Hooks.OnScanComplete(results)
+	}
+}
+
+func (r *Runner) onPhaseChange(from, to scan.State) {
+	if r.Hooks.OnPhaseChange != nil {
+		r.Hooks.OnPhaseChange(from, to)
+	}
+}
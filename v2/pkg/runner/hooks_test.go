@@ -0,0 +1,50 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+	"github.com/projectdiscovery/naabu/v2/pkg/scan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHooks(t *testing.T) {
+	r := &Runner{
+		options: &Options{},
+		scanner: &scan.Scanner{HostDiscoveryResults: result.NewResult()},
+	}
+	r.scanner.OnHostFound = r.onHostDiscovered
+
+	// unset hooks are skipped
+	r.onScanStart()
+	r.onRetryStart(0)
+	r.scanner.AddHost("192.168.1.1")
+	r.scanner.FlushResults()
+
+	var events []string
+	r.Hooks = Hooks{
+		OnScanStart:      func() { events = append(events, "start") },
+		OnHostDiscovered: func(ip string) { events = append(events, "host "+ip) },
+		OnRetryStart:     func(retry int) { events = append(events, "retry") },
+		OnScanComplete:   func(results *result.Result) { events = append(events, "complete") },
+	}
+	r.onScanStart()
+	r.scanner.AddHost("192.168.1.2")
+	r.scanner.AddHost("192.168.1.2")
+	r.scanner.FlushResults()
+	r.onRetryStart(0)
+	r.onScanComplete(r.scanner.HostDiscoveryResults)
+	require.Equal(t, []string{"start", "host 192.168.1.2", "retry", "complete"}, events)
+}
+
+func TestPhaseChangeHook(t *testing.T) {
+	r := &Runner{options: &Options{}, scanner: &scan.Scanner{}}
+	r.scanner.Phase.OnTransition(r.onPhaseChange)
+	r.setPhase(scan.Scan)
+
+	var phases []string
+	r.Hooks.OnPhaseChange = func(from, to scan.State) { phases = append(phases, from.String()+">"+to.String()) }
+	r.setPhase(scan.Verify)
+	r.setPhase(scan.Done)
+	require.Equal(t, []string{"scan>verify", "verify>done"}, phases)
+}
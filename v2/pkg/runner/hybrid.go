@@ -0,0 +1,48 @@
+package runner
+
+import (
+	"fmt"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+)
+
+// parseConnectPorts returns the tcp ports always probed with a full connection in syn scans
+func parseConnectPorts(value string) (map[int]struct{}, error) {
+	if value == "" {
+		return nil, nil
+	}
+	ports, err := parsePortsList(value)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse connect ports: %s", err)
+	}
+	connectPorts := make(map[int]struct{}, len(ports))
+	for _, p := range ports {
+		if p.Protocol == protocol.TCP {
+			connectPorts[p.Port] = struct{}{}
+		}
+	}
+	return connectPorts, nil
+}
+
+// enumerate probes the port of the ip with a syn packet if raw packets are used, or with a full
+// connection otherwise and for the connect ports of hybrid scans (eg. ports behind load balancers
+// answering every syn)
+func (r *Runner) enumerate(ip string, p *port.Port, useRawPackets bool) {
+	if useRawPackets && !r.isConnectPort(p) {
+		r.RawSocketEnumeration(ip, p)
+		return
+	}
+	r.stages.enqueue(StageScan)
+	r.wgscan.Add()
+	go r.handleHostPort(ip, p)
+}
+
+// isConnectPort returns true if the port is always probed with a full connection
+func (r *Runner) isConnectPort(p *port.Port) bool {
+	if p.Protocol != protocol.TCP {
+		return false
+	}
+	_, ok := r.connectPorts[p.Port]
+	return ok
+}
@@ -0,0 +1,27 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectPorts(t *testing.T) {
+	connectPorts, err := parseConnectPorts("443,8000-8002,u:53")
+	require.Nil(t, err)
+	require.Len(t, connectPorts, 4)
+
+	r := &Runner{connectPorts: connectPorts}
+	require.True(t, r.isConnectPort(&port.Port{Port: 8001, Protocol: protocol.TCP}))
+	require.False(t, r.isConnectPort(&port.Port{Port: 80, Protocol: protocol.TCP}))
+	require.False(t, r.isConnectPort(&port.Port{Port: 53, Protocol: protocol.UDP}))
+
+	connectPorts, err = parseConnectPorts("")
+	require.Nil(t, err)
+	require.Nil(t, connectPorts)
+
+	_, err = parseConnectPorts("abc")
+	require.NotNil(t, err)
+}
@@ -0,0 +1,169 @@
+package runner
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+	"github.com/remeh/sizedwaitgroup"
+)
+
+// Interception check modes
+const (
+	InterceptWarn   = "warn"
+	InterceptVerify = "verify"
+)
+
+// interceptionProbeIP is a TEST-NET-1 (RFC 5737) address that is never routed, a connection to it
+// only succeeds when an interceptor answers on behalf of every destination
+var interceptionProbeIP = "192.0.2.1"
+
+// interceptedPorts are the ports transparent proxies and captive portals usually intercept
+var interceptedPorts = []int{80, 443}
+
+// fingerprintMinted is the fingerprint of a tls service presenting a certificate valid for a random
+// name, which only a tls intercepting proxy minting certificates on the fly does
+const fingerprintMinted = "tls:minted"
+
+// interception is the fingerprint of the answers of the interceptor per port
+type interception map[int]string
+
+// detectInterception connects to the unroutable probe address on the web ports and warns if anything
+// answers, returning the fingerprints of the interceptor
+func (r *Runner) detectInterception() interception {
+	detected := make(interception)
+	for _, p := range interceptedPorts {
+		if fingerprint, ok := r.webFingerprint(interceptionProbeIP, p); ok {
+			detected[p] = fingerprint
+		}
+	}
+	if len(detected) == 0 {
+		return nil
+	}
+
+	ports := make([]string, 0, len(detected))
+	for _, p := range interceptedPorts {
+		if _, ok := detected[p]; ok {
+			ports = append(ports, fmt.Sprint(p))
+		}
+	}
+	gologger.Warning().Msgf("Connections to the unroutable %s succeed on ports %s: a transparent proxy or captive portal answers every connection and these ports may be reported open on any host", interceptionProbeIP, strings.Join(ports, ","))
+	if r.options.InterceptCheck != InterceptVerify {
+		gologger.Warning().Msgf("Use -intercept-check %s to drop the ports answered by the interceptor", InterceptVerify)
+	}
+	return detected
+}
+
+// verifyInterception drops the web ports whose answer to a request for a random name matches the
+// answer of the interceptor
+func (r *Runner) verifyInterception(detected interception) {
+	// no more traffic is sent to the targets once the kill switch triggered
+	if len(detected) == 0 || r.options.InterceptCheck != InterceptVerify || r.scanKilled() {
+		return
+	}
+
+	var mu sync.Mutex
+	verifiedResult := result.NewResult()
+	dropped := 0
+	swg := sizedwaitgroup.New(r.options.Threads)
+	for hostResult := range r.scanner.ScanResults.GetIPsPorts() {
+		// the ports aren't verified once the kill switch triggered
+		if !r.waitTrafficWindow() {
+			verifiedResult.SetPorts(hostResult.IP, hostResult.Ports)
+			continue
+		}
+		swg.Add()
+		go func(hostResult *result.HostResult) {
+			defer swg.Done()
+			var ports []*port.Port
+			for _, p := range hostResult.Ports {
+				if fingerprint, ok := detected[p.Port]; ok && p.Protocol == protocol.TCP {
+					if current, answered := r.webFingerprint(hostResult.IP, p.Port); answered && (current == fingerprint || current == fingerprintMinted) {
+						gologger.Debug().Msgf("Dropping %s:%d answered by the interceptor (%s)\n", hostResult.IP, p.Port, current)
+						mu.Lock()
+						dropped++
+						mu.Unlock()
+						continue
+					}
+				}
+				ports = append(ports, p)
+			}
+			if len(ports) > 0 {
+				verifiedResult.SetPorts(hostResult.IP, ports)
+			}
+		}(hostResult)
+	}
+	swg.Wait()
+	r.scanner.ScanResults = verifiedResult
+
+	if dropped > 0 {
+		gologger.Info().Msgf("Dropped %d ports answered by the interceptor\n", dropped)
+	}
+}
+
+// webFingerprint connects to the web port and returns the fingerprint of its answer to a request for
+// a random name, false if the connection failed
+func (r *Runner) webFingerprint(ip string, p int) (string, bool) {
+	timeout := time.Duration(r.options.Timeout) * time.Millisecond
+	r.sockets.acquire()
+	defer r.sockets.release()
+	conn, err := r.scanner.DialPort(ip, &port.Port{Port: p, Protocol: protocol.TCP}, timeout)
+	if err != nil {
+		return "", false
+	}
+	defer conn.Close()
+
+	return fingerprintWeb(conn, p == 443, timeout), true
+}
+
+// fingerprintWeb sends a tls hello or http request for a random name over the connection and returns
+// the leaf certificate hash or the status line and server header of the answer
+func fingerprintWeb(conn net.Conn, useTLS bool, timeout time.Duration) string {
+	name := randomHostname()
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+
+	if useTLS {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: name, InsecureSkipVerify: true}) //nolint:gosec // the certificate is the fingerprint
+		if err := tlsConn.Handshake(); err != nil {
+			return "tls:failed"
+		}
+		chain := tlsConn.ConnectionState().PeerCertificates
+		if len(chain) == 0 {
+			return "tls:anonymous"
+		}
+		if chain[0].VerifyHostname(name) == nil {
+			return fingerprintMinted
+		}
+		hash := sha256.Sum256(chain[0].Raw)
+		return "tls:" + hex.EncodeToString(hash[:])
+	}
+
+	if _, err := fmt.Fprintf(conn, "GET / HTTP/1.0\r\nHost: %s\r\n\r\n", name); err != nil {
+		return "http:failed"
+	}
+	response, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		return "http:no-response"
+	}
+	response.Body.Close()
+	return fmt.Sprintf("http:%d %s", response.StatusCode, response.Header.Get("Server"))
+}
+
+// randomHostname returns a name no real service holds a certificate or virtual host for
+func randomHostname() string {
+	data := make([]byte, 8)
+	_, _ = rand.Read(data)
+	return hex.EncodeToString(data) + ".invalid"
+}
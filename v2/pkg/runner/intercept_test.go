@@ -0,0 +1,94 @@
+package runner
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+	"github.com/projectdiscovery/naabu/v2/pkg/scan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyInterception(t *testing.T) {
+	// the proxy answers the same page whatever the requested host
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Server", "squid")
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer proxy.Close()
+	web := httptest.NewServer(http.NotFoundHandler())
+	defer web.Close()
+
+	portOf := func(server *httptest.Server) int {
+		return server.Listener.Addr().(*net.TCPAddr).Port
+	}
+	proxyPort, webPort := portOf(proxy), portOf(web)
+
+	r := &Runner{
+		options: &Options{Timeout: 1000, Threads: 2, InterceptCheck: InterceptVerify},
+		scanner: &scan.Scanner{ScanResults: result.NewResult()},
+	}
+	fingerprint, ok := r.webFingerprint("127.0.0.1", proxyPort)
+	require.True(t, ok)
+	require.Equal(t, "http:403 squid", fingerprint)
+
+	r.scanner.ScanResults.AddPort("127.0.0.1", &port.Port{Port: proxyPort, Protocol: protocol.TCP})
+	r.scanner.ScanResults.AddPort("127.0.0.1", &port.Port{Port: 22, Protocol: protocol.TCP})
+	// a real web server answers differently for the random name
+	r.scanner.ScanResults.AddPort("127.0.0.1", &port.Port{Port: webPort, Protocol: protocol.TCP})
+
+	r.verifyInterception(interception{proxyPort: fingerprint, webPort: fingerprint})
+	require.False(t, r.scanner.ScanResults.IPHasPort("127.0.0.1", &port.Port{Port: proxyPort, Protocol: protocol.TCP}))
+	require.True(t, r.scanner.ScanResults.IPHasPort("127.0.0.1", &port.Port{Port: 22, Protocol: protocol.TCP}))
+	require.True(t, r.scanner.ScanResults.IPHasPort("127.0.0.1", &port.Port{Port: webPort, Protocol: protocol.TCP}))
+}
+
+func TestWebFingerprintSocketBudget(t *testing.T) {
+	web := httptest.NewServer(http.NotFoundHandler())
+	defer web.Close()
+
+	r := &Runner{
+		options: &Options{Timeout: 1000},
+		scanner: &scan.Scanner{ScanResults: result.NewResult()},
+		sockets: make(socketBudget, 1),
+	}
+	r.sockets.acquire()
+	done := make(chan bool)
+	go func() {
+		_, ok := r.webFingerprint("127.0.0.1", web.Listener.Addr().(*net.TCPAddr).Port)
+		done <- ok
+	}()
+
+	// the dial waits for a socket of the budget
+	select {
+	case <-done:
+		t.Fatal("the fingerprint must wait for the socket budget")
+	case <-time.After(50 * time.Millisecond):
+	}
+	r.sockets.release()
+	require.True(t, <-done)
+}
+
+func TestFingerprintWebTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+
+	fingerprints := make([]string, 2)
+	for i := range fingerprints {
+		conn, err := net.Dial("tcp", server.Listener.Addr().String())
+		require.Nil(t, err)
+		fingerprints[i] = fingerprintWeb(conn, true, time.Second)
+		conn.Close()
+	}
+	// the certificate of a regular server doesn't depend on the random name
+	require.True(t, strings.HasPrefix(fingerprints[0], "tls:"))
+	require.NotEqual(t, fingerprintMinted, fingerprints[0])
+	require.Equal(t, fingerprints[0], fingerprints[1])
+	require.True(t, strings.HasSuffix(randomHostname(), ".invalid"))
+}
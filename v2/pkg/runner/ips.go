@@ -1,10 +1,13 @@
 package runner
 
 import (
+	"net/netip"
 	"strings"
 
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
 	fileutil "github.com/projectdiscovery/utils/file"
 	iputil "github.com/projectdiscovery/utils/ip"
+	sliceutil "github.com/projectdiscovery/utils/slice"
 )
 
 func (r *Runner) parseExcludedIps(options *Options) ([]string, error) {
@@ -38,7 +41,10 @@ func (r *Runner) parseExcludedIps(options *Options) ([]string, error) {
 
 func (r *Runner) getExcludeItems(s string) ([]string, error) {
 	if isIpOrCidr(s) {
-		return []string{s}, nil
+		if iputil.IsCIDR(s) {
+			return []string{normalizeCIDR(s)}, nil
+		}
+		return []string{result.NormalizeIP(s)}, nil
 	}
 
 	ips4, ips6, err := r.host2ips(s)
@@ -51,3 +57,43 @@ func (r *Runner) getExcludeItems(s string) ([]string, error) {
 func isIpOrCidr(s string) bool {
 	return iputil.IsIP(s) || iputil.IsCIDR(s)
 }
+
+// normalizeCIDR returns the canonical notation of the cidr, converting ipv4-mapped ipv6 ranges to ipv4 ranges
+func normalizeCIDR(cidr string) string {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return cidr
+	}
+	if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+		prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+	}
+	return prefix.Masked().String()
+}
+
+// maxIPv6RangeBits is the number of host bits of the largest ipv6 range enumerated address by
+// address (/96), larger ranges overflow the shuffled host:port space
+const maxIPv6RangeBits = 32
+
+// isOversizedIPv6Range returns true if the cidr is an ipv6 range too large to be enumerated
+func isOversizedIPv6Range(cidr string) bool {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil || !prefix.Addr().Is6() {
+		return false
+	}
+	return prefix.Addr().BitLen()-prefix.Bits() > maxIPv6RangeBits
+}
+
+// splitMappedIPs moves the ipv4-mapped ipv6 addresses to the ipv4 addresses
+func splitMappedIPs(ipsV4, ipsV6 []string) ([]string, []string) {
+	var onlyV6 []string
+	for _, ip := range ipsV6 {
+		if addr, err := netip.ParseAddr(ip); err == nil && addr.Is4In6() {
+			if ip4 := addr.Unmap().String(); !sliceutil.Contains(ipsV4, ip4) {
+				ipsV4 = append(ipsV4, ip4)
+			}
+			continue
+		}
+		onlyV6 = append(onlyV6, ip)
+	}
+	return ipsV4, onlyV6
+}
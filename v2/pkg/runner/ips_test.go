@@ -40,3 +40,34 @@ func TestIsIpOrCidr(t *testing.T) {
 		require.False(t, isIpOrCidr(invalidItem))
 	}
 }
+
+func TestNormalizeCIDR(t *testing.T) {
+	tests := map[string]string{
+		"1.2.3.4/24":         "1.2.3.0/24",
+		"::ffff:1.2.3.0/120": "1.2.3.0/24",
+		"::ffff:0:0/96":      "0.0.0.0/0",
+		"2001:db8::1/64":     "2001:db8::/64",
+	}
+	for input, expected := range tests {
+		require.Equal(t, expected, normalizeCIDR(input), input)
+	}
+}
+
+func TestSplitMappedIPs(t *testing.T) {
+	ipsV4, ipsV6 := splitMappedIPs([]string{"1.2.3.4"}, []string{"::ffff:1.2.3.4", "::ffff:5.6.7.8", "2001:db8::1"})
+	require.Equal(t, []string{"1.2.3.4", "5.6.7.8"}, ipsV4)
+	require.Equal(t, []string{"2001:db8::1"}, ipsV6)
+}
+
+func TestIsOversizedIPv6Range(t *testing.T) {
+	tests := map[string]bool{
+		"2001:db8::/96":  false,
+		"2001:db8::/120": false,
+		"2001:db8::/64":  true,
+		"10.0.0.0/8":     false,
+		"0.0.0.0/0":      false,
+	}
+	for input, expected := range tests {
+		require.Equal(t, expected, isOversizedIPv6Range(input), input)
+	}
+}
@@ -0,0 +1,136 @@
+package runner
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+)
+
+// killSwitchStop is the response of a kill switch url stopping the scan
+const killSwitchStop = "stop"
+
+// isRemoteKillSwitch returns true if the kill switch is an url rather than a file
+func isRemoteKillSwitch(killSwitch string) bool {
+	return strings.HasPrefix(killSwitch, "http://") || strings.HasPrefix(killSwitch, "https://")
+}
+
+// startKillSwitch polls the kill switch at each interval until the context is done, the scan
+// being stopped as soon as the file exists or the url returns stop
+func (r *Runner) startKillSwitch(ctx context.Context) {
+	if r.options.KillSwitch == "" {
+		return
+	}
+	client := &http.Client{Timeout: r.options.KillSwitchInterval}
+	if r.pollKillSwitch(ctx, client) {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(r.options.KillSwitchInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if r.pollKillSwitch(ctx, client) {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// pollKillSwitch stops the scan if the kill switch is triggered and returns true if it was
+func (r *Runner) pollKillSwitch(ctx context.Context, client *http.Client) bool {
+	triggered, err := checkKillSwitch(ctx, client, r.options.KillSwitch)
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		gologger.Warning().Msgf("Could not check kill switch %s: %s\n", r.options.KillSwitch, err)
+		return false
+	}
+	if triggered && r.killScan() {
+		gologger.Warning().Msgf("Kill switch %s triggered, stopping the scan\n", r.options.KillSwitch)
+	}
+	return triggered
+}
+
+// checkKillSwitch returns true if the kill switch file exists or the url body is stop
+func checkKillSwitch(ctx context.Context, client *http.Client, killSwitch string) (bool, error) {
+	if !isRemoteKillSwitch(killSwitch) {
+		_, err := os.Stat(killSwitch)
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, killSwitch, nil)
+	if err != nil {
+		return false, err
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return false, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return false, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1024))
+	if err != nil {
+		return false, err
+	}
+	return strings.EqualFold(strings.TrimSpace(string(body)), killSwitchStop), nil
+}
+
+// scanKilled returns true if the kill switch stopped the scan, no more traffic being sent to the
+// targets (verification, banner grabs, interception and anycast checks, nmap)
+func (r *Runner) scanKilled() bool {
+	return r.killed.Load()
+}
+
+// killChan returns a channel closed once the kill switch stopped the scan, to wait for a timer or
+// the kill switch in the stages following the scan
+func (r *Runner) killChan() <-chan struct{} {
+	r.stopMu.Lock()
+	defer r.stopMu.Unlock()
+	if r.killCh == nil {
+		r.killCh = make(chan struct{})
+		if r.killed.Load() {
+			close(r.killCh)
+		}
+	}
+	return r.killCh
+}
+
+// killScan stops the scan and the traffic of the following stages, returning false if the kill
+// switch already triggered
+func (r *Runner) killScan() bool {
+	if !r.killed.CompareAndSwap(false, true) {
+		return false
+	}
+	r.stopScan()
+	r.closeKillChan()
+	return true
+}
+
+// closeKillChan closes the kill channel
+func (r *Runner) closeKillChan() {
+	r.stopMu.Lock()
+	defer r.stopMu.Unlock()
+	if r.killCh != nil {
+		select {
+		case <-r.killCh:
+		default:
+			close(r.killCh)
+		}
+	}
+}
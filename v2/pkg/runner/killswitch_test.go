@@ -0,0 +1,39 @@
+package runner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKillSwitch(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "stop")
+
+	triggered, err := checkKillSwitch(ctx, http.DefaultClient, path)
+	require.Nil(t, err)
+	require.False(t, triggered)
+	require.Nil(t, os.WriteFile(path, nil, 0644))
+	triggered, err = checkKillSwitch(ctx, http.DefaultClient, path)
+	require.Nil(t, err)
+	require.True(t, triggered)
+
+	response := "continue"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	r := &Runner{options: &Options{KillSwitch: server.URL}}
+	require.False(t, r.pollKillSwitch(ctx, http.DefaultClient))
+	require.False(t, r.scanStopped())
+	response = "STOP\n"
+	require.True(t, r.pollKillSwitch(ctx, http.DefaultClient))
+	require.True(t, r.scanStopped())
+	require.True(t, r.scanKilled())
+}
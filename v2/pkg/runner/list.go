@@ -0,0 +1,90 @@
+package runner
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+)
+
+// handleListOutput writes the sorted unique hostnames or ips having results, one per line
+func (r *Runner) handleListOutput(scanResults *result.Result, file *os.File) {
+	seen := make(map[string]struct{})
+
+	add := func(host, ip string) {
+		item := host
+		if r.options.OutputIPs {
+			item = ip
+		}
+		seen[item] = struct{}{}
+	}
+
+	if scanResults.HasIPsPorts() {
+		for hostResult := range scanResults.GetIPsPorts() {
+			hosts, err := r.getResultHosts(hostResult)
+			if err != nil {
+				continue
+			}
+			for _, host := range hosts {
+				isCDNIP, cdnName, _ := r.scanner.CdnCheck(hostResult.IP)
+				ports := r.filterHostPorts(host, hostResult.IP, hostResult.Ports, isCDNIP, cdnName)
+				if len(ports) == 0 {
+					continue
+				}
+				if !r.options.NoSummary {
+					gologger.Info().Msgf("Found %d ports on host %s (%s)\n", len(ports), host, hostResult.IP)
+				}
+				add(host, hostResult.IP)
+				r.onResult(&result.HostResult{Host: host, IP: hostResult.IP, Ports: ports})
+			}
+		}
+	} else {
+		for hostIP := range scanResults.GetIPs() {
+			hosts, err := r.scanner.IPRanger.GetHostsByIP(hostIP)
+			if err != nil {
+				continue
+			}
+			for _, host := range hosts {
+				if host == "ip" {
+					host = hostIP
+				}
+				if !r.options.NoSummary {
+					gologger.Info().Msgf("Found alive host %s (%s)\n", host, hostIP)
+				}
+				add(host, hostIP)
+				r.onResult(&result.HostResult{Host: host, IP: hostIP})
+			}
+		}
+	}
+
+	items := make([]string, 0, len(seen))
+	for item := range seen {
+		items = append(items, item)
+	}
+	sort.Strings(items)
+
+	for _, item := range items {
+		gologger.Silent().Msgf("%s\n", item)
+	}
+	if file != nil {
+		if err := WriteListOutput(r.anonymizer.Values(items), file); err != nil {
+			gologger.Error().Msgf("Could not write results to file %s: %s\n", r.options.Output, err)
+			r.recordError(ErrorOutput, r.options.Output, err)
+		}
+	}
+}
+
+// WriteListOutput writes one hostname or ip per line to an io.Writer
+func WriteListOutput(items []string, writer io.Writer) error {
+	bufwriter := bufio.NewWriter(writer)
+	for _, item := range items {
+		if _, err := bufwriter.WriteString(item + "\n"); err != nil {
+			bufwriter.Flush()
+			return err
+		}
+	}
+	return bufwriter.Flush()
+}
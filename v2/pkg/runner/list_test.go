@@ -0,0 +1,18 @@
+package runner
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteListOutput(t *testing.T) {
+	buf := bytes.Buffer{}
+	require.Nil(t, WriteListOutput([]string{"10.0.0.1", "scanme.sh"}, &buf))
+	require.Equal(t, "10.0.0.1\nscanme.sh\n", buf.String())
+
+	buf.Reset()
+	require.Nil(t, WriteListOutput(nil, &buf))
+	require.Empty(t, buf.String())
+}
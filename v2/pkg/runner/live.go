@@ -0,0 +1,158 @@
+package runner
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+)
+
+// liveQueueSize is the number of live results buffered for the GetResults consumer
+const liveQueueSize = 1024
+
+// liveResults streams the open ports as soon as they are confirmed, while the final output still
+// waits for the scan to complete
+type liveResults struct {
+	sync.Mutex
+	channel chan *result.HostResult
+	closed  bool
+	// sending are the publishers sending to the channel outside of the lock
+	sending sync.WaitGroup
+	// published are the ip:port/protocol findings already streamed
+	published map[string]struct{}
+}
+
+// unpublished returns the ports of the ip not streamed yet and marks them as streamed, so that
+// retries, verification, tls variants and the hostnames sharing the ip emit each finding once
+func (live *liveResults) unpublished(ip string, ports []*port.Port) []*port.Port {
+	if live.published == nil {
+		live.published = make(map[string]struct{})
+	}
+	var fresh []*port.Port
+	for _, p := range ports {
+		key := net.JoinHostPort(result.NormalizeIP(ip), strconv.Itoa(p.Port)) + "/" + p.Protocol.String()
+		if _, ok := live.published[key]; ok {
+			continue
+		}
+		live.published[key] = struct{}{}
+		fresh = append(fresh, p)
+	}
+	return fresh
+}
+
+// hasLiveSinks returns true if the open ports are published as soon as they are confirmed, before the
+// port set of their host is final
+func (options *Options) hasLiveSinks() bool {
+	return options.StreamOutput != "" || options.WebhookURL != "" || options.ElasticsearchURL != "" ||
+		len(options.ResultWriters) > 0 || options.LiveResults
+}
+
+// GetResults returns a channel receiving each open port once as soon as it is confirmed (found, or
+// verified with -verify), for the first hostname of its ip passing the filter expression or for
+// each hostname and confirmation with AllowDuplicates. The channel buffers liveQueueSize results and
+// is closed once the enumeration completes. It must be called before RunEnumeration and drained by
+// the caller, a slow consumer delays the aggregation of the results once the buffer is full. It can't
+// be used with ExcludeFingerprints, matched on the final port set of each host
+func (r *Runner) GetResults() <-chan *result.HostResult {
+	r.live.Lock()
+	defer r.live.Unlock()
+
+	if r.live.channel == nil {
+		r.live.channel = make(chan *result.HostResult, liveQueueSize)
+	}
+	return r.live.channel
+}
+
+// publishResult sends the confirmed open port to the result writers, the OnResult callback with
+// LiveResults and the GetResults channel. Only the deduplication holds the lock, the lookups,
+// callbacks and channel sends of a port don't delay the other ports
+func (r *Runner) publishResult(ip string, p *port.Port) {
+	liveCallback := r.options.LiveResults && r.options.OnResult != nil
+	r.live.Lock()
+	channel := r.live.channel
+	if r.live.closed {
+		channel = nil
+	}
+	if channel != nil {
+		r.live.sending.Add(1)
+		defer r.live.sending.Done()
+	}
+	r.live.Unlock()
+
+	if !liveCallback && len(r.writers) == 0 && channel == nil {
+		return
+	}
+	hosts, err := r.getResultHosts(&result.HostResult{IP: ip, Ports: []*port.Port{p}})
+	if err != nil {
+		return
+	}
+	isCDNIP, cdnName, _ := r.scanner.CdnCheck(ip)
+	for _, host := range hosts {
+		ports := r.ratePorts(r.filterPorts(host, ip, []*port.Port{p}, isCDNIP, cdnName))
+		if !r.options.AllowDuplicates {
+			r.live.Lock()
+			ports = r.live.unpublished(ip, ports)
+			r.live.Unlock()
+		}
+		if len(ports) == 0 {
+			continue
+		}
+		ports = r.enrichPorts(host, ip, ports)
+		if len(r.writers) > 0 {
+			data := r.newResult(host, ip, isCDNIP, cdnName)
+			for _, p := range ports {
+				data.Port = p
+				r.writeResult(data)
+			}
+		}
+		hostResult := &result.HostResult{Host: host, IP: ip, Ports: ports}
+		if liveCallback {
+			r.options.OnResult(hostResult)
+		}
+		if channel != nil {
+			channel <- hostResult
+		}
+	}
+}
+
+// onResult passes the results of a host to the OnResult callback once the scan completed, unless its
+// open ports were already passed as they were confirmed with LiveResults
+func (r *Runner) onResult(hostResult *result.HostResult) {
+	if r.options.OnResult == nil || (r.options.LiveResults && len(hostResult.Ports) > 0) {
+		return
+	}
+	r.options.OnResult(hostResult)
+}
+
+// checkLiveFingerprints returns an error if the GetResults channel would stream the ports of hosts
+// the fingerprints may drop once their port set is final
+func (r *Runner) checkLiveFingerprints() error {
+	r.live.Lock()
+	defer r.live.Unlock()
+	if r.fingerprints != nil && r.live.channel != nil {
+		return errors.New("exclude fingerprints can't be used with GetResults")
+	}
+	return nil
+}
+
+// closeResults closes the GetResults channel once no more results can be confirmed
+func (r *Runner) closeResults() {
+	// the results still queued are published before the channel is closed
+	if r.scanner != nil {
+		r.scanner.FlushResults()
+	}
+	r.live.Lock()
+	if r.live.channel == nil || r.live.closed {
+		r.live.Unlock()
+		return
+	}
+	r.live.closed = true
+	r.live.Unlock()
+
+	// the publishers that saw the channel open complete their sends first
+	r.live.sending.Wait()
+	close(r.live.channel)
+}
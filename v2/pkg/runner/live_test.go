@@ -0,0 +1,120 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/projectdiscovery/ipranger"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+	"github.com/projectdiscovery/naabu/v2/pkg/scan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLiveResults(t *testing.T) {
+	ranger, err := ipranger.New()
+	require.Nil(t, err)
+	defer ranger.Close()
+	require.Nil(t, ranger.AddHostWithMetadata("10.0.0.1", "scanme.sh"))
+
+	var live []*result.HostResult
+	r := &Runner{
+		options: &Options{LiveResults: true, OnResult: func(hostResult *result.HostResult) {
+			live = append(live, hostResult)
+		}},
+		scanner: &scan.Scanner{IPRanger: ranger, ScanResults: result.NewResult()},
+	}
+	results := r.GetResults()
+
+	r.onPortFound("10.0.0.1", &port.Port{Port: 443, Protocol: protocol.TCP})
+	require.Len(t, live, 1)
+	require.Equal(t, "scanme.sh", live[0].Host)
+	require.Equal(t, 443, live[0].Ports[0].Port)
+	require.Equal(t, live[0], <-results)
+
+	// filtered ports are not streamed
+	filter, err := newResultFilter("port != 22")
+	require.Nil(t, err)
+	r.filter = filter
+	r.onPortFound("10.0.0.1", &port.Port{Port: 22, Protocol: protocol.TCP})
+	require.Len(t, live, 1)
+
+	// with verification only the verified ports are streamed
+	r.options.Verify = true
+	r.onPortFound("10.0.0.1", &port.Port{Port: 8443, Protocol: protocol.TCP})
+	require.Len(t, live, 1)
+
+	r.closeResults()
+	_, ok := <-results
+	require.False(t, ok)
+	r.closeResults()
+}
+
+func TestLiveResultsDedup(t *testing.T) {
+	ranger, err := ipranger.New()
+	require.Nil(t, err)
+	defer ranger.Close()
+	require.Nil(t, ranger.AddHostWithMetadata("10.0.0.1", "a.scanme.sh"))
+	require.Nil(t, ranger.AddHostWithMetadata("10.0.0.1", "b.scanme.sh"))
+
+	var live []*result.HostResult
+	r := &Runner{
+		options: &Options{LiveResults: true, OnResult: func(hostResult *result.HostResult) {
+			live = append(live, hostResult)
+		}},
+		scanner: &scan.Scanner{IPRanger: ranger, ScanResults: result.NewResult()},
+	}
+
+	// a finding is streamed once across hostnames, confirmations and tls variants
+	r.publishResult("10.0.0.1", &port.Port{Port: 443, Protocol: protocol.TCP})
+	r.publishResult("10.0.0.1", &port.Port{Port: 443, Protocol: protocol.TCP, TLS: true})
+	require.Len(t, live, 1)
+	r.publishResult("10.0.0.1", &port.Port{Port: 443, Protocol: protocol.UDP})
+	require.Len(t, live, 2)
+
+	live = nil
+	r.options.AllowDuplicates = true
+	r.publishResult("10.0.0.1", &port.Port{Port: 443, Protocol: protocol.TCP})
+	require.Len(t, live, 2)
+}
+
+func TestLiveResultsOutsideLock(t *testing.T) {
+	ranger, err := ipranger.New()
+	require.Nil(t, err)
+	defer ranger.Close()
+	require.Nil(t, ranger.AddHostWithMetadata("10.0.0.1", "a.scanme.sh"))
+	require.Nil(t, ranger.AddHostWithMetadata("10.0.0.2", "b.scanme.sh"))
+
+	release := make(chan struct{})
+	published := make(chan string, 2)
+	r := &Runner{
+		options: &Options{LiveResults: true, OnResult: func(hostResult *result.HostResult) {
+			if hostResult.IP == "10.0.0.1" {
+				<-release
+			}
+			published <- hostResult.IP
+		}},
+		scanner: &scan.Scanner{IPRanger: ranger, ScanResults: result.NewResult()},
+	}
+	results := r.GetResults()
+
+	go r.publishResult("10.0.0.1", &port.Port{Port: 443, Protocol: protocol.TCP})
+	// a blocked callback doesn't delay the ports of the other ips
+	r.publishResult("10.0.0.2", &port.Port{Port: 443, Protocol: protocol.TCP})
+	require.Equal(t, "10.0.0.2", <-published)
+	close(release)
+	require.Equal(t, "10.0.0.1", <-published)
+
+	r.closeResults()
+	var ips []string
+	for hostResult := range results {
+		ips = append(ips, hostResult.IP)
+	}
+	require.ElementsMatch(t, []string{"10.0.0.1", "10.0.0.2"}, ips)
+
+	// the ports passed live are not passed again once the scan completed
+	r.onResult(&result.HostResult{IP: "10.0.0.1", Ports: []*port.Port{{Port: 443, Protocol: protocol.TCP}}})
+	r.onResult(&result.HostResult{IP: "10.0.0.3"})
+	require.Equal(t, "10.0.0.3", <-published)
+	require.Empty(t, published)
+}
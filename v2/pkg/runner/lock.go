@@ -0,0 +1,103 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/projectdiscovery/gologger"
+)
+
+// Policies applied when a previous scheduled run still holds the lock file
+const (
+	LockSkip          = "skip"
+	LockQueue         = "queue"
+	LockAbortPrevious = "abort-previous"
+)
+
+// ErrScanLocked is returned by NewRunner when a previous run still holds the lock file with the
+// skip policy
+var ErrScanLocked = errors.New("a previous scan holding the lock file is still running")
+
+// scanLock is an exclusive lock on a file held for the lifetime of the runner, so that scans
+// scheduled by cron or a systemd timer don't overlap when a cycle runs longer than its interval.
+// The lock is released by the system when the process exits, the file keeping the pid of its holder
+type scanLock struct {
+	file *os.File
+}
+
+// acquireScanLock locks the file, applying the policy if a previous run holds it: skip returns
+// ErrScanLocked, queue waits for the previous run to complete and abort-previous interrupts it,
+// the previous run writing its results and resume file as with CTRL+C
+func acquireScanLock(path, policy string) (*scanLock, error) {
+	if path == "" {
+		return nil, nil
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	locked, err := tryLockFile(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("could not lock %s: %w", path, err)
+	}
+	if !locked {
+		pid := lockHolder(file)
+		switch policy {
+		case LockQueue:
+			gologger.Info().Msgf("Waiting for the previous scan (pid %d) holding %s to complete\n", pid, path)
+		case LockAbortPrevious:
+			if pid <= 0 {
+				file.Close()
+				return nil, fmt.Errorf("could not abort the previous scan holding %s: unknown pid", path)
+			}
+			gologger.Info().Msgf("Aborting the previous scan (pid %d) holding %s\n", pid, path)
+			if err := interruptProcess(pid); err != nil {
+				file.Close()
+				return nil, fmt.Errorf("could not abort the previous scan (pid %d): %w", pid, err)
+			}
+		default:
+			file.Close()
+			return nil, ErrScanLocked
+		}
+		if err := lockFile(file); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("could not lock %s: %w", path, err)
+		}
+	}
+	if err := file.Truncate(0); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &scanLock{file: file}, nil
+}
+
+// lockHolder returns the pid written to the lock file by its holder, 0 if unknown
+func lockHolder(file *os.File) int {
+	data, err := io.ReadAll(io.NewSectionReader(file, 0, 32))
+	if err != nil {
+		return 0
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	return pid
+}
+
+// release unlocks the file, kept on disk since removing it would let a queued run and a new one
+// lock different files
+func (lock *scanLock) release() error {
+	if lock == nil || lock.file == nil {
+		return nil
+	}
+	file := lock.file
+	lock.file = nil
+	_ = file.Truncate(0)
+	return file.Close()
+}
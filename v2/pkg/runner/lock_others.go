@@ -0,0 +1,24 @@
+//go:build !linux && !darwin
+
+package runner
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// tryLockFile locks the file without blocking, returning false if another process holds it
+func tryLockFile(file *os.File) (bool, error) {
+	return false, fmt.Errorf("lock file not available on %s", runtime.GOOS)
+}
+
+// lockFile locks the file, waiting for the process holding it to release it
+func lockFile(file *os.File) error {
+	return fmt.Errorf("lock file not available on %s", runtime.GOOS)
+}
+
+// interruptProcess sends an interrupt to the process, as CTRL+C would
+func interruptProcess(pid int) error {
+	return fmt.Errorf("interrupting a process not available on %s", runtime.GOOS)
+}
@@ -0,0 +1,50 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanLock(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("lock file not available on", runtime.GOOS)
+	}
+	path := filepath.Join(t.TempDir(), "naabu.lock")
+	lock, err := acquireScanLock(path, LockSkip)
+	require.Nil(t, err)
+	data, err := os.ReadFile(path)
+	require.Nil(t, err)
+	require.Equal(t, strconv.Itoa(os.Getpid()), strings.TrimSpace(string(data)))
+
+	// a second run is skipped while the lock is held
+	_, err = acquireScanLock(path, LockSkip)
+	require.ErrorIs(t, err, ErrScanLocked)
+
+	// a queued run starts once the previous one released the lock
+	acquired := make(chan *scanLock)
+	go func() {
+		queued, err := acquireScanLock(path, LockQueue)
+		require.Nil(t, err)
+		acquired <- queued
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("queued run acquired a held lock")
+	case <-time.After(100 * time.Millisecond):
+	}
+	require.Nil(t, lock.release())
+	require.Nil(t, lock.release())
+	queued := <-acquired
+	require.Nil(t, queued.release())
+
+	lock, err = acquireScanLock("", LockSkip)
+	require.Nil(t, err)
+	require.Nil(t, lock.release())
+}
@@ -0,0 +1,33 @@
+//go:build linux || darwin
+
+package runner
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// tryLockFile locks the file without blocking, returning false if another process holds it
+func tryLockFile(file *os.File) (bool, error) {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// lockFile locks the file, waiting for the process holding it to release it
+func lockFile(file *os.File) error {
+	for {
+		err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX)
+		if !errors.Is(err, syscall.EINTR) {
+			return err
+		}
+	}
+}
+
+// interruptProcess sends an interrupt to the process, as CTRL+C would
+func interruptProcess(pid int) error {
+	return syscall.Kill(pid, syscall.SIGINT)
+}
@@ -0,0 +1,204 @@
+package runner
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/routing"
+)
+
+const (
+	// networkWatchInterval is the polling interval of the scanning network state
+	networkWatchInterval = 2 * time.Second
+	// drainPollInterval is the polling interval used while waiting for the send queue to drain
+	drainPollInterval = 100 * time.Millisecond
+)
+
+// Actions performed when the scanning interface goes down
+const (
+	LinkDownPause = "pause"
+	LinkDownAbort = "abort"
+)
+
+var errLinkDown = errors.New("scanning interface is down")
+
+// waitNetwork blocks while the network watcher has paused transmission and
+// returns an error if the scan was aborted
+func (r *Runner) waitNetwork() error {
+	r.networkGate.RLock()
+	defer r.networkGate.RUnlock()
+
+	return r.networkErr
+}
+
+// waitBeforeSend blocks until packets are allowed to be transmitted
+func (r *Runner) waitBeforeSend() error {
+	r.waitScanWindow()
+	return r.waitNetwork()
+}
+
+// startNetworkWatch starts monitoring the scanning interface and source ip
+func (r *Runner) startNetworkWatch(ctx context.Context) {
+	r.watchedInterface = r.options.Interface
+	r.updateWatchedInterface()
+	if r.activeSourceIP == "" && r.watchedInterface == "" {
+		return
+	}
+	go r.watchNetwork(ctx)
+}
+
+// updateWatchedInterface follows the interface owning the active source ip unless one was pinned
+func (r *Runner) updateWatchedInterface() {
+	if r.options.Interface != "" || r.activeSourceIP == "" {
+		return
+	}
+	if networkInterface, err := routing.FindInterfaceByIp(net.ParseIP(r.activeSourceIP)); err == nil {
+		r.watchedInterface = networkInterface.Name
+	}
+}
+
+// watchNetwork monitors the scanning interface and source ip during the scan, failing over to the
+// secondary source ip, pausing or aborting the scan when they stop being available
+func (r *Runner) watchNetwork(ctx context.Context) {
+	ticker := time.NewTicker(networkWatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		switch {
+		case r.watchedInterface != "" && !isInterfaceUp(r.watchedInterface):
+			r.networkGate.Lock()
+			r.handleLinkDown(ctx)
+			aborted := r.networkErr != nil
+			r.networkGate.Unlock()
+			if aborted {
+				return
+			}
+		case r.activeSourceIP != "" && !isLocalIP(r.activeSourceIP):
+			r.networkGate.Lock()
+			r.handleSourceIPLoss(ctx)
+			r.networkGate.Unlock()
+		}
+	}
+}
+
+// handleLinkDown aborts the scan or pauses it until the scanning interface is up again
+func (r *Runner) handleLinkDown(ctx context.Context) {
+	if r.options.LinkDownAction == LinkDownAbort {
+		r.networkErr = &EnumerationError{Category: ErrorNetwork, Target: r.watchedInterface, Err: errLinkDown}
+		gologger.Error().Msgf("Interface %s went down, aborting scan\n", r.watchedInterface)
+		return
+	}
+
+	r.saveCheckpoint()
+	gologger.Error().Msgf("Interface %s went down, pausing scan until the link is up again\n", r.watchedInterface)
+
+	ticker := time.NewTicker(networkWatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if isInterfaceUp(r.watchedInterface) {
+			gologger.Info().Msgf("Interface %s is up again, resuming scan\n", r.watchedInterface)
+			return
+		}
+	}
+}
+
+// handleSourceIPLoss swaps the active and standby source ips if possible,
+// otherwise it saves a checkpoint and waits for the source ip to come back
+func (r *Runner) handleSourceIPLoss(ctx context.Context) {
+	gologger.Warning().Msgf("Source ip %s is no longer assigned to any interface\n", r.activeSourceIP)
+
+	if r.standbySourceIP != "" && isLocalIP(r.standbySourceIP) {
+		r.drainSendQueue(ctx)
+		if err := r.SetSourceIP(r.standbySourceIP); err != nil {
+			gologger.Error().Msgf("Couldn't fail over to source ip %s: %s\n", r.standbySourceIP, err)
+		} else {
+			gologger.Info().Msgf("Failing over from source ip %s to %s\n", r.activeSourceIP, r.standbySourceIP)
+			r.activeSourceIP, r.standbySourceIP = r.standbySourceIP, r.activeSourceIP
+			r.updateWatchedInterface()
+			return
+		}
+	}
+
+	r.saveCheckpoint()
+	gologger.Error().Msgf("No source ip available, pausing scan until %s is reachable again\n", r.activeSourceIP)
+
+	ticker := time.NewTicker(networkWatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if isLocalIP(r.activeSourceIP) {
+			gologger.Info().Msgf("Source ip %s is available again, resuming scan\n", r.activeSourceIP)
+			return
+		}
+		if r.standbySourceIP != "" && isLocalIP(r.standbySourceIP) {
+			r.handleSourceIPLoss(ctx)
+			return
+		}
+	}
+}
+
+// drainSendQueue waits for the packets already queued to be transmitted
+func (r *Runner) drainSendQueue(ctx context.Context) {
+	for r.scanner.Health().Backlog > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(drainPollInterval):
+		}
+	}
+}
+
+// saveCheckpoint saves the scan progression before pausing, if the scan saves resume checkpoints
+func (r *Runner) saveCheckpoint() {
+	if !r.options.savesResume() {
+		return
+	}
+	if err := r.SaveResume(); err != nil {
+		gologger.Warning().Msgf("Couldn't save resume checkpoint: %s\n", err)
+	}
+}
+
+// isInterfaceUp checks if the interface exists, is administratively up and has a carrier
+func isInterfaceUp(name string) bool {
+	networkInterface, err := net.InterfaceByName(name)
+	if err != nil {
+		return false
+	}
+	return networkInterface.Flags&net.FlagUp != 0 && networkInterface.Flags&net.FlagRunning != 0
+}
+
+// isLocalIP checks if the ip is currently assigned to a local interface
+func isLocalIP(ip string) bool {
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil {
+		return false
+	}
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return false
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(parsedIP) {
+			return true
+		}
+	}
+	return false
+}
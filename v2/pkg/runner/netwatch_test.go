@@ -0,0 +1,26 @@
+package runner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsLocalIP(t *testing.T) {
+	require.True(t, isLocalIP("127.0.0.1"))
+	require.False(t, isLocalIP("192.0.2.1"))
+	require.False(t, isLocalIP("invalid"))
+}
+
+func TestIsInterfaceUp(t *testing.T) {
+	require.False(t, isInterfaceUp("naabu-missing0"))
+}
+
+func TestWaitNetwork(t *testing.T) {
+	r := &Runner{options: &Options{LinkDownAction: LinkDownAbort}, watchedInterface: "naabu-missing0"}
+	require.Nil(t, r.waitNetwork())
+
+	r.handleLinkDown(context.Background())
+	require.ErrorIs(t, r.waitBeforeSend(), errLinkDown)
+}
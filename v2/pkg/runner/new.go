@@ -0,0 +1,271 @@
+package runner
+
+import (
+	"time"
+)
+
+// Option configures the runner created by New
+type Option func(*Options)
+
+// DefaultOptions returns the options with the same defaults as the command line flags
+func DefaultOptions() *Options {
+	return &Options{
+		ScanType:            SynScan,
+		Threads:             25,
+		Rate:                DefaultRateSynScan,
+		Retries:             DefaultRetriesSynScan,
+		Timeout:             DefaultPortTimeoutSynScan,
+		WarmUpTime:          2,
+		BannerThreads:       DefaultBannerThreads,
+		BannerRate:          DefaultBannerRate,
+		BannerTimeout:       DefaultBannerTimeout,
+		OwnershipMismatch:   OwnershipWarn,
+		LinkDownAction:      LinkDownPause,
+		LockPolicy:          LockSkip,
+		DialerCache:         256,
+		DNSCacheTTL:         10 * time.Minute,
+		DNSNegativeCacheTTL: time.Minute,
+		InputReadTimeout:    3 * time.Minute,
+		StatsInterval:       DefautStatsInterval,
+		MetricsPort:         63636,
+		CanaryInterval:      10,
+		DisableUpdateCheck:  true,
+	}
+}
+
+// New creates a runner from the default options and the given functional options, eg.
+//
+//	runner.New(runner.WithHosts("scanme.sh"), runner.WithPorts("80,443"), runner.WithOnResult(callback))
+//
+// The options are validated as the command line flags would be. NewRunner is still available
+// to create a runner from a fully populated Options struct.
+func New(opts ...Option) (*Runner, error) {
+	options := DefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.Pipeline != "" {
+		if err := options.applyLibraryPipeline(); err != nil {
+			return nil, err
+		}
+	}
+	options.ConfigureHostDiscovery()
+	options.configureFullRange()
+	options.configureOffline()
+	if err := options.ValidateOptions(); err != nil {
+		return nil, err
+	}
+	return NewRunner(options)
+}
+
+// WithHosts sets the hosts, ips or cidrs to scan
+func WithHosts(hosts ...string) Option {
+	return func(options *Options) {
+		options.Host = append(options.Host, hosts...)
+	}
+}
+
+// WithHostsFile sets the file containing the hosts to scan, one per line
+func WithHostsFile(path string) Option {
+	return func(options *Options) {
+		options.HostsFile = path
+	}
+}
+
+// WithTargetProviders adds lazily evaluated target sources
+func WithTargetProviders(providers ...TargetProvider) Option {
+	return func(options *Options) {
+		options.TargetProviders = append(options.TargetProviders, providers...)
+	}
+}
+
+// WithPipeline applies the named pipeline of the yaml file (optional with a single pipeline), the
+// options changed from their default by the other functional options taking precedence
+func WithPipeline(path, name string) Option {
+	return func(options *Options) {
+		options.Pipeline, options.PipelineName = path, name
+	}
+}
+
+// WithResolver resolves the hostnames of the targets with the resolver instead of the default dnsx client
+func WithResolver(resolver Resolver) Option {
+	return func(options *Options) {
+		options.Resolver = resolver
+	}
+}
+
+// WithPorts sets the ports to scan (eg. 80,443,100-200,u:53)
+func WithPorts(ports string) Option {
+	return func(options *Options) {
+		options.Ports = ports
+	}
+}
+
+// WithConnectPorts sets the ports always probed with a full connection in syn scans
+func WithConnectPorts(ports string) Option {
+	return func(options *Options) {
+		options.ConnectPorts = ports
+	}
+}
+
+// WithTopPorts scans the top ports (full, 100, 1000)
+func WithTopPorts(topPorts string) Option {
+	return func(options *Options) {
+		options.TopPorts = topPorts
+	}
+}
+
+// WithExcludePorts sets the ports to exclude from the scan
+func WithExcludePorts(ports string) Option {
+	return func(options *Options) {
+		options.ExcludePorts = ports
+	}
+}
+
+// WithScanType sets the type of port scan (SynScan/ConnectScan/UDPScan)
+func WithScanType(scanType string) Option {
+	return func(options *Options) {
+		options.ScanType = scanType
+	}
+}
+
+// WithRate sets the packets to send per second
+func WithRate(rate int) Option {
+	return func(options *Options) {
+		options.Rate = rate
+	}
+}
+
+// WithAdaptiveRate adjusts the rate to the response ratio of the scan, starting from the configured rate
+func WithAdaptiveRate() Option {
+	return func(options *Options) {
+		options.AdaptiveRate = true
+	}
+}
+
+// WithThreads sets the number of internal worker threads
+func WithThreads(threads int) Option {
+	return func(options *Options) {
+		options.Threads = threads
+	}
+}
+
+// WithTimeout sets the time to wait for a port probe response
+func WithTimeout(timeout time.Duration) Option {
+	return func(options *Options) {
+		options.Timeout = int(timeout / time.Millisecond)
+	}
+}
+
+// WithRetries sets the number of retries of the port scan
+func WithRetries(retries int) Option {
+	return func(options *Options) {
+		options.Retries = retries
+	}
+}
+
+// WithResolvers sets the dns resolvers used to resolve the hosts
+func WithResolvers(resolvers ...string) Option {
+	return func(options *Options) {
+		options.Resolvers = joinCommaSeparated(options.Resolvers, resolvers)
+	}
+}
+
+// WithNoDNS disables dns resolution, hostnames are resolved from the resolve overrides only
+func WithNoDNS() Option {
+	return func(options *Options) {
+		options.NoDNS = true
+	}
+}
+
+// WithOffline guarantees that no outbound lookups are performed besides the probes
+func WithOffline() Option {
+	return func(options *Options) {
+		options.Offline = true
+	}
+}
+
+// WithProxy scans through a socks5 proxy, falling back to connect scan
+func WithProxy(proxy, auth string) Option {
+	return func(options *Options) {
+		options.Proxy = proxy
+		options.ProxyAuth = auth
+	}
+}
+
+// WithOnResult sets the callback receiving the results of each host
+func WithOnResult(callback OnResultCallback) Option {
+	return func(options *Options) {
+		options.OnResult = callback
+	}
+}
+
+// WithOnLiveResult sets the OnResult callback and passes it each open port as soon as it is confirmed,
+// instead of the results of each host once the scan completed
+func WithOnLiveResult(callback OnResultCallback) Option {
+	return func(options *Options) {
+		options.OnResult = callback
+		options.LiveResults = true
+	}
+}
+
+// WithResultWriter adds a writer receiving each open port as soon as it is confirmed
+func WithResultWriter(writer ResultWriter) Option {
+	return func(options *Options) {
+		options.ResultWriters = append(options.ResultWriters, writer)
+	}
+}
+
+// WithOutput writes the results to a file, in json lines format if asJSON is set
+func WithOutput(path string, asJSON bool) Option {
+	return func(options *Options) {
+		options.Output = path
+		options.JSON = asJSON
+	}
+}
+
+// WithSkipHostDiscovery scans the ports of every target without checking it's alive first
+func WithSkipHostDiscovery() Option {
+	return func(options *Options) {
+		options.SkipHostDiscovery = true
+	}
+}
+
+// WithBanner grabs the banner of the open ports
+func WithBanner() Option {
+	return func(options *Options) {
+		options.Banner = true
+	}
+}
+
+// WithExcludeCDN scans only ports 80 and 443 of cdn/waf ips
+func WithExcludeCDN() Option {
+	return func(options *Options) {
+		options.ExcludeCDN = true
+	}
+}
+
+// WithSilent disables the log output, leaving only the results
+func WithSilent() Option {
+	return func(options *Options) {
+		options.Silent = true
+	}
+}
+
+// WithExcludedIPs sets the ips or cidrs to exclude from the scan
+func WithExcludedIPs(ips ...string) Option {
+	return func(options *Options) {
+		options.ExcludeIps = joinCommaSeparated(options.ExcludeIps, ips)
+	}
+}
+
+// joinCommaSeparated appends values to a comma separated list
+func joinCommaSeparated(list string, values []string) string {
+	for _, value := range values {
+		if list != "" {
+			list += ","
+		}
+		list += value
+	}
+	return list
+}
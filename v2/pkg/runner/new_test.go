@@ -0,0 +1,42 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFunctionalOptions(t *testing.T) {
+	options := DefaultOptions()
+	for _, opt := range []Option{
+		WithHosts("scanme.sh"),
+		WithHosts("192.168.1.0/24"),
+		WithPorts("80,443"),
+		WithScanType(ConnectScan),
+		WithRate(500),
+		WithTimeout(2 * time.Second),
+		WithResolvers("1.1.1.1", "8.8.8.8"),
+		WithExcludedIPs("192.168.1.1", "192.168.1.2"),
+		WithOnResult(func(*result.HostResult) {}),
+	} {
+		opt(options)
+	}
+
+	require.Equal(t, []string{"scanme.sh", "192.168.1.0/24"}, []string(options.Host))
+	require.Equal(t, "80,443", options.Ports)
+	require.Equal(t, ConnectScan, options.ScanType)
+	require.Equal(t, 500, options.Rate)
+	require.Equal(t, 2000, options.Timeout)
+	require.Equal(t, DefaultRetriesSynScan, options.Retries)
+	require.Equal(t, "1.1.1.1,8.8.8.8", options.Resolvers)
+	require.Equal(t, "192.168.1.1,192.168.1.2", options.ExcludeIps)
+	require.NotNil(t, options.OnResult)
+	require.Nil(t, options.ValidateOptions())
+}
+
+func TestNewValidatesOptions(t *testing.T) {
+	_, err := New(WithPorts("80"))
+	require.ErrorIs(t, err, errNoInputList)
+}
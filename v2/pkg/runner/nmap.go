@@ -10,11 +10,19 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
 	"github.com/projectdiscovery/naabu/v2/pkg/result"
 	osutil "github.com/projectdiscovery/utils/os"
 )
 
 func (r *Runner) handleNmap() error {
+	// nmap would probe the targets after the kill switch stopped the scan
+	if r.scanKilled() {
+		return nil
+	}
+	span := r.startSpan("nmap")
+	defer span.End()
+
 	// command from CLI
 	command := r.options.NmapCLI
 	hasCLI := r.options.NmapCLI != ""
@@ -25,68 +33,25 @@ func (r *Runner) handleNmap() error {
 			ipsPorts = append(ipsPorts, hostResult)
 		}
 
-		// sort by number of ports
-		sort.Slice(ipsPorts, func(i, j int) bool {
-			return len(ipsPorts[i].Ports) < len(ipsPorts[j].Ports)
-		})
-
-		// suggests commands grouping ips in pseudo-exp ranges
-		// 0 - 100 ports
-		// 100 - 1000 ports
-		// 1000 - 10000 ports
-		// 10000 - 60000 ports
-		ranges := make(map[int][]*result.HostResult) // for better readability
-		// collect the indexes corresponding to ranges changes
-		for _, ipPorts := range ipsPorts {
-			length := len(ipPorts.Ports)
-			var index int
-			switch {
-			case length > 100 && length < 1000:
-				index = 1
-			case length >= 1000 && length < 10000:
-				index = 2
-			case length >= 10000:
-				index = 3
-			default:
-				index = 0
-			}
-			ranges[index] = append(ranges[index], ipPorts)
-		}
-
-		for _, rang := range ranges {
+		for _, group := range groupByPorts(ipsPorts) {
 			args := strings.Split(command, " ")
-			var (
-				ips   []string
-				ports []string
-			)
-			allports := make(map[int]struct{})
-			for _, ipPorts := range rang {
-				ips = append(ips, ipPorts.IP)
-				for _, pp := range ipPorts.Ports {
-					allports[pp.Port] = struct{}{}
-				}
-			}
-			for p := range allports {
-				ports = append(ports, fmt.Sprint(p))
-			}
-
-			// if we have no open ports we avoid running nmap
-			if len(ports) == 0 {
-				continue
-			}
-
-			portsStr := strings.Join(ports, ",")
-			ipsStr := strings.Join(ips, " ")
+			args = append(args, group.scanTypes(args)...)
+			commandStr := strings.Join(args, " ")
+			portsStr := strings.Join(group.ports, ",")
+			ipsStr := strings.Join(group.ips, " ")
 
 			args = append(args, "-p", portsStr)
-			args = append(args, ips...)
+			args = append(args, group.ips...)
 
 			// if the command is not executable, we just suggest it
 			commandCanBeExecuted := isCommandExecutable(args)
 
 			// if requested via config file or via cli
 			if (r.options.Nmap || hasCLI) && commandCanBeExecuted {
-				gologger.Info().Msgf("Running nmap command: %s -p %s %s", command, portsStr, ipsStr)
+				if !r.waitTrafficWindow() {
+					return nil
+				}
+				gologger.Info().Msgf("Running nmap command: %s -p %s %s", commandStr, portsStr, ipsStr)
 				// check when user type '-nmap-cli "nmap -sV"'
 				// automatically remove nmap
 				posArgs := 0
@@ -111,7 +76,7 @@ func (r *Runner) handleNmap() error {
 					return errMsg
 				}
 			} else {
-				gologger.Info().Msgf("Suggested nmap command: %s -p %s %s", command, portsStr, ipsStr)
+				gologger.Info().Msgf("Suggested nmap command: %s -p %s %s", commandStr, portsStr, ipsStr)
 			}
 		}
 	}
@@ -119,6 +84,149 @@ func (r *Runner) handleNmap() error {
 	return nil
 }
 
+// maxNmapGroups is the maximum number of nmap invocations, the smallest groups being merged beyond
+const maxNmapGroups = 16
+
+// nmapGroup is a set of hosts sharing the same open ports, scanned by a single nmap invocation
+type nmapGroup struct {
+	ips   []string
+	ports []string // nmap port list, with T: and U: prefixes when udp ports are present
+	tcp   []int
+	udp   []int
+}
+
+// scanTypes returns the scan type flags to add to the command for the udp ports of the group: -sU,
+// and -sS for the tcp ports unless the command already selects a tcp scan type
+func (group *nmapGroup) scanTypes(args []string) []string {
+	if len(group.udp) == 0 {
+		return nil
+	}
+	var flags []string
+	hasUDP, hasTCP := false, false
+	for _, arg := range args {
+		switch arg {
+		case "-sU":
+			hasUDP = true
+		case "-sS", "-sT", "-sA", "-sW", "-sM", "-sN", "-sF", "-sX":
+			hasTCP = true
+		}
+	}
+	if !hasUDP {
+		flags = append(flags, "-sU")
+	}
+	if len(group.tcp) > 0 && !hasTCP {
+		flags = append(flags, "-sS")
+	}
+	return flags
+}
+
+// groupByPorts groups the hosts by their set of open tcp and udp ports, so that each nmap invocation
+// only probes the ports open on its hosts instead of the union of all ports. Beyond maxNmapGroups, the
+// group with the fewest hosts is merged into the group its ports extend the least. Groups are ordered
+// by decreasing size
+func groupByPorts(ipsPorts []*result.HostResult) []*nmapGroup {
+	byKey := make(map[string]*nmapGroup)
+	for _, ipPorts := range ipsPorts {
+		if len(ipPorts.Ports) == 0 {
+			continue
+		}
+		tcpSet, udpSet := make(map[int]struct{}), make(map[int]struct{})
+		for _, pp := range ipPorts.Ports {
+			if pp.Protocol == protocol.UDP {
+				udpSet[pp.Port] = struct{}{}
+			} else {
+				tcpSet[pp.Port] = struct{}{}
+			}
+		}
+		tcp, udp := sortedPorts(tcpSet), sortedPorts(udpSet)
+		key := fmt.Sprint(tcp, udp)
+		group, ok := byKey[key]
+		if !ok {
+			group = &nmapGroup{tcp: tcp, udp: udp}
+			byKey[key] = group
+		}
+		group.ips = append(group.ips, ipPorts.IP)
+	}
+
+	groups := make([]*nmapGroup, 0, len(byKey))
+	for _, group := range byKey {
+		groups = append(groups, group)
+	}
+	sortNmapGroups(groups)
+	for len(groups) > maxNmapGroups {
+		smallest := groups[len(groups)-1]
+		groups = groups[:len(groups)-1]
+		target, added := groups[0], -1
+		for _, group := range groups {
+			extra := len(unionPorts(group.tcp, smallest.tcp)) - len(group.tcp) + len(unionPorts(group.udp, smallest.udp)) - len(group.udp)
+			if added == -1 || extra < added {
+				target, added = group, extra
+			}
+		}
+		target.tcp, target.udp = unionPorts(target.tcp, smallest.tcp), unionPorts(target.udp, smallest.udp)
+		target.ips = append(target.ips, smallest.ips...)
+		sortNmapGroups(groups)
+	}
+
+	for _, group := range groups {
+		sort.Strings(group.ips)
+		group.ports = nmapPorts(group.tcp, group.udp)
+	}
+	return groups
+}
+
+// sortNmapGroups orders the groups by decreasing number of hosts, then by ports
+func sortNmapGroups(groups []*nmapGroup) {
+	sort.Slice(groups, func(i, j int) bool {
+		if len(groups[i].ips) != len(groups[j].ips) {
+			return len(groups[i].ips) > len(groups[j].ips)
+		}
+		return fmt.Sprint(groups[i].tcp, groups[i].udp) < fmt.Sprint(groups[j].tcp, groups[j].udp)
+	})
+}
+
+// sortedPorts returns the sorted ports of the set
+func sortedPorts(set map[int]struct{}) []int {
+	ports := make([]int, 0, len(set))
+	for p := range set {
+		ports = append(ports, p)
+	}
+	sort.Ints(ports)
+	return ports
+}
+
+// unionPorts returns the sorted union of the sorted ports
+func unionPorts(a, b []int) []int {
+	set := make(map[int]struct{}, len(a)+len(b))
+	for _, p := range a {
+		set[p] = struct{}{}
+	}
+	for _, p := range b {
+		set[p] = struct{}{}
+	}
+	return sortedPorts(set)
+}
+
+// nmapPorts returns the nmap port list, T:80,443,U:53 when udp ports are present
+func nmapPorts(tcp, udp []int) []string {
+	ports := make([]string, 0, len(tcp)+len(udp))
+	for i, p := range tcp {
+		if i == 0 && len(udp) > 0 {
+			ports = append(ports, fmt.Sprintf("T:%d", p))
+			continue
+		}
+		ports = append(ports, fmt.Sprint(p))
+	}
+	for i, p := range udp {
+		if i == 0 {
+			ports = append(ports, fmt.Sprintf("U:%d", p))
+			continue
+		}
+		ports = append(ports, fmt.Sprint(p))
+	}
+	return ports
+}
+
 func isCommandExecutable(args []string) bool {
 	commandLength := calculateCmdLength(args)
 	if osutil.IsWindows() {
@@ -1,6 +1,7 @@
 package runner
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/projectdiscovery/naabu/v2/pkg/port"
@@ -24,3 +25,55 @@ func TestHandleNmap(t *testing.T) {
 	r.scanner.ScanResults.SetPorts("127.0.0.1", []*port.Port{{Port: 8080, Protocol: protocol.TCP}})
 	assert.Nil(t, r.handleNmap())
 }
+
+func TestGroupByPorts(t *testing.T) {
+	tcp := func(ports ...int) []*port.Port {
+		var list []*port.Port
+		for _, p := range ports {
+			list = append(list, &port.Port{Port: p, Protocol: protocol.TCP})
+		}
+		return list
+	}
+	groups := groupByPorts([]*result.HostResult{
+		{IP: "10.0.0.3", Ports: tcp(443, 80)},
+		{IP: "10.0.0.1", Ports: tcp(22)},
+		{IP: "10.0.0.2", Ports: tcp(80, 443)},
+		{IP: "10.0.0.4"},
+	})
+	assert.Equal(t, []*nmapGroup{
+		{ips: []string{"10.0.0.2", "10.0.0.3"}, ports: []string{"80", "443"}, tcp: []int{80, 443}, udp: []int{}},
+		{ips: []string{"10.0.0.1"}, ports: []string{"22"}, tcp: []int{22}, udp: []int{}},
+	}, groups)
+}
+
+func TestGroupByPortsUDP(t *testing.T) {
+	groups := groupByPorts([]*result.HostResult{
+		{IP: "10.0.0.1", Ports: []*port.Port{{Port: 53, Protocol: protocol.UDP}, {Port: 80, Protocol: protocol.TCP}, {Port: 443, Protocol: protocol.TCP}}},
+	})
+	assert.Len(t, groups, 1)
+	assert.Equal(t, []string{"T:80", "443", "U:53"}, groups[0].ports)
+	assert.Equal(t, []string{"-sU", "-sS"}, groups[0].scanTypes([]string{"nmap", "-sV"}))
+	assert.Equal(t, []string{"-sU"}, groups[0].scanTypes([]string{"nmap", "-sT"}))
+	assert.Empty(t, groups[0].scanTypes([]string{"nmap", "-sU", "-sT"}))
+
+	groups = groupByPorts([]*result.HostResult{{IP: "10.0.0.1", Ports: []*port.Port{{Port: 53, Protocol: protocol.UDP}}}})
+	assert.Equal(t, []string{"U:53"}, groups[0].ports)
+	assert.Equal(t, []string{"-sU"}, groups[0].scanTypes([]string{"nmap"}))
+}
+
+func TestGroupByPortsMerge(t *testing.T) {
+	var ipsPorts []*result.HostResult
+	for i := 0; i < maxNmapGroups+4; i++ {
+		ipsPorts = append(ipsPorts, &result.HostResult{IP: fmt.Sprintf("10.0.0.%d", i), Ports: []*port.Port{{Port: 80, Protocol: protocol.TCP}, {Port: 1000 + i, Protocol: protocol.TCP}}})
+	}
+	groups := groupByPorts(ipsPorts)
+	assert.Len(t, groups, maxNmapGroups)
+
+	hosts := 0
+	for _, group := range groups {
+		hosts += len(group.ips)
+		assert.Contains(t, group.tcp, 80)
+		assert.Len(t, group.ports, len(group.ips)+1, "merged groups probe the ports of each of their hosts")
+	}
+	assert.Equal(t, len(ipsPorts), hosts)
+}
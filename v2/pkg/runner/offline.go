@@ -0,0 +1,59 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// errOffline is returned in place of the outbound lookups skipped in offline mode
+var errOffline = errors.New("outbound lookups are disabled in offline mode")
+
+// configureOffline disables the dns resolution and the update check of offline scans, the
+// cdn ranges and the port and probe data are embedded in the binary
+func (options *Options) configureOffline() {
+	if !options.Offline {
+		return
+	}
+	options.NoDNS = true
+	options.DisableUpdateCheck = true
+}
+
+// validateOffline rejects the options relying on outbound lookups in offline mode
+func (options *Options) validateOffline() error {
+	var conflicts []string
+	if options.Passive {
+		conflicts = append(conflicts, "passive")
+	}
+	if len(options.AsnRate) > 0 {
+		conflicts = append(conflicts, "asn-rate")
+	}
+	if options.VerifyOwnership != "" {
+		conflicts = append(conflicts, "verify-ownership")
+	}
+	for _, source := range options.ScopeFilter {
+		if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+			conflicts = append(conflicts, "remote scope-filter")
+			break
+		}
+	}
+	if options.Resolvers != "" || options.ReversePTR {
+		conflicts = append(conflicts, "resolvers and rev-ptr")
+	}
+	if isRemoteKillSwitch(options.KillSwitch) {
+		conflicts = append(conflicts, "remote kill-switch")
+	}
+	if options.WebhookURL != "" || options.ElasticsearchURL != "" || options.OtlpEndpoint != "" {
+		conflicts = append(conflicts, "webhook, elasticsearch and otlp exports")
+	}
+	if options.EnrichURL != "" {
+		conflicts = append(conflicts, "enrich-url")
+	}
+	if options.OptOutURL != "" {
+		conflicts = append(conflicts, "opt-out-url")
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%s can't be used in offline mode", strings.Join(conflicts, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureOffline(t *testing.T) {
+	options := &Options{}
+	options.configureOffline()
+	require.False(t, options.NoDNS)
+
+	options.Offline = true
+	options.configureOffline()
+	require.True(t, options.NoDNS)
+	require.True(t, options.DisableUpdateCheck)
+	require.Nil(t, options.validateOffline())
+
+	options.ScopeFilter = []string{"ranges.txt"}
+	require.Nil(t, options.validateOffline())
+	options.ScopeFilter = append(options.ScopeFilter, "https://example.com/ranges.txt")
+	require.EqualError(t, options.validateOffline(), "remote scope-filter can't be used in offline mode")
+
+	options.Passive = true
+	require.EqualError(t, options.validateOffline(), "passive, remote scope-filter can't be used in offline mode")
+
+	options = &Options{Offline: true, EnrichURL: "https://cmdb.example.com/lookup", OptOutURL: "https://registry.example.com/opt-out.txt"}
+	require.EqualError(t, options.validateOffline(), "enrich-url, opt-out-url can't be used in offline mode")
+}
+
+func TestAddTargetOffline(t *testing.T) {
+	r := &Runner{options: &Options{Offline: true}}
+	require.ErrorIs(t, r.AddTarget("AS15169"), errOffline)
+}
@@ -0,0 +1,166 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/retryablehttp-go"
+)
+
+// maxOptOutSize is the maximum size of an opt-out list
+const maxOptOutSize = 16 * 1024 * 1024
+
+// optOutCacheFolder stores the last opt-out lists successfully fetched and verified
+var optOutCacheFolder = filepath.Join(DefaultResumeFolderPath(), "opt-out")
+
+// loadOptOutList fetches the do-not-scan registry, verifies its signature (published at url.sig)
+// and caches it. If the registry is unreachable the cached copy is used, otherwise the scan can't start.
+func (r *Runner) loadOptOutList() ([]string, error) {
+	var publicKey ed25519.PublicKey
+	if r.options.OptOutKey != "" {
+		key, err := base64.StdEncoding.DecodeString(r.options.OptOutKey)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid opt-out public key (expected base64 ed25519 key)")
+		}
+		publicKey = key
+	}
+	if publicKey == nil && !r.options.OptOutUnsigned {
+		return nil, errors.New("opt-out list can't be verified without a public key")
+	}
+
+	cachePath := optOutCachePath(r.options.OptOutURL)
+	data, signature, err := fetchOptOutList(r.options.OptOutURL, publicKey != nil)
+	if err == nil {
+		err = verifyOptOutList(data, signature, publicKey)
+	}
+	if err != nil {
+		gologger.Warning().Msgf("Couldn't fetch opt-out list %s: %s\n", r.options.OptOutURL, err)
+		data, signature, err = readOptOutCache(cachePath)
+		if err != nil {
+			return nil, errors.Wrap(err, "no cached opt-out list available")
+		}
+		if err := verifyOptOutList(data, signature, publicKey); err != nil {
+			return nil, errors.Wrap(err, "cached opt-out list")
+		}
+		gologger.Info().Msgf("Using cached opt-out list %s\n", cachePath)
+	} else if err := writeOptOutCache(cachePath, data, signature); err != nil {
+		gologger.Warning().Msgf("Couldn't cache opt-out list: %s\n", err)
+	}
+
+	entries := parseOptOutEntries(data)
+	gologger.Verbose().Msgf("Loaded %d opt-out entries from %s\n", len(entries), r.options.OptOutURL)
+	return r.resolveOptOutEntries(entries)
+}
+
+// resolveOptOutEntries returns the ips and cidrs of the entries. An entry that can't be resolved
+// fails the scan, as the hosts it opted out couldn't be excluded
+func (r *Runner) resolveOptOutEntries(entries []string) ([]string, error) {
+	var excluded []string
+	for _, entry := range entries {
+		ips, err := r.getExcludeItems(entry)
+		if err == nil && len(ips) == 0 {
+			err = errors.New("no addresses found")
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't resolve opt-out entry %s", entry)
+		}
+		excluded = append(excluded, ips...)
+	}
+	return excluded, nil
+}
+
+func fetchOptOutList(url string, withSignature bool) (data, signature []byte, err error) {
+	httpClient := retryablehttp.NewClient(retryablehttp.DefaultOptionsSingle)
+	fetch := func(url string) ([]byte, error) {
+		response, err := httpClient.Get(url)
+		if err != nil {
+			return nil, err
+		}
+		defer response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%s replied with status code %d", url, response.StatusCode)
+		}
+		// a truncated list would scan the hosts opted out at its end
+		data, err := io.ReadAll(io.LimitReader(response.Body, maxOptOutSize+1))
+		if err == nil && len(data) > maxOptOutSize {
+			return nil, fmt.Errorf("%s exceeds the maximum size of %d bytes", url, maxOptOutSize)
+		}
+		return data, err
+	}
+
+	if data, err = fetch(url); err != nil {
+		return nil, nil, err
+	}
+	if withSignature {
+		if signature, err = fetch(url + ".sig"); err != nil {
+			return nil, nil, err
+		}
+	}
+	return data, signature, nil
+}
+
+// verifyOptOutList checks the base64 ed25519 signature of the list if a public key was given
+func verifyOptOutList(data, signature []byte, publicKey ed25519.PublicKey) error {
+	if publicKey == nil {
+		return nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return errors.Wrap(err, "invalid signature encoding")
+	}
+	if !ed25519.Verify(publicKey, data, decoded) {
+		return errors.New("signature verification failed")
+	}
+	return nil
+}
+
+// parseOptOutEntries returns the ips, cidrs and hosts of the list ignoring comments
+func parseOptOutEntries(data []byte) []string {
+	var entries []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if line = strings.TrimSpace(line); line != "" {
+			entries = append(entries, line)
+		}
+	}
+	return entries
+}
+
+func optOutCachePath(url string) string {
+	hash := sha256.Sum256([]byte(url))
+	return filepath.Join(optOutCacheFolder, hex.EncodeToString(hash[:8])+".txt")
+}
+
+func readOptOutCache(path string) (data, signature []byte, err error) {
+	if data, err = os.ReadFile(path); err != nil {
+		return nil, nil, err
+	}
+	signature, _ = os.ReadFile(path + ".sig")
+	return data, signature, nil
+}
+
+func writeOptOutCache(path string, data, signature []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	if signature == nil {
+		return nil
+	}
+	return os.WriteFile(path+".sig", signature, 0600)
+}
@@ -0,0 +1,68 @@
+package runner
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptOutList(t *testing.T) {
+	data := []byte("# central no-scan registry\n192.0.2.0/24\n198.51.100.7 # payroll\n\n")
+	require.Equal(t, []string{"192.0.2.0/24", "198.51.100.7"}, parseOptOutEntries(data))
+
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	require.Nil(t, err)
+	signature := []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, data)))
+	require.Nil(t, verifyOptOutList(data, signature, publicKey))
+	require.NotNil(t, verifyOptOutList(append(data, "10.0.0.1\n"...), signature, publicKey))
+	require.Nil(t, verifyOptOutList(data, nil, nil), "unsigned lists are accepted without a key")
+
+	path := filepath.Join(t.TempDir(), "optout.txt")
+	require.Nil(t, writeOptOutCache(path, data, signature))
+	cachedData, cachedSignature, err := readOptOutCache(path)
+	require.Nil(t, err)
+	require.Equal(t, data, cachedData)
+	require.Equal(t, signature, cachedSignature)
+}
+
+func TestOptOutRequiresSignature(t *testing.T) {
+	r := &Runner{options: &Options{OptOutURL: "http://127.0.0.1:1/no-scan.txt", Offline: true}}
+	_, err := r.loadOptOutList()
+	require.ErrorContains(t, err, "without a public key")
+}
+
+func TestOptOutListTooLarge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("192.0.2.1\n", maxOptOutSize/10+1)))
+	}))
+	defer server.Close()
+
+	_, _, err := fetchOptOutList(server.URL, false)
+	require.ErrorContains(t, err, "maximum size")
+}
+
+func TestOptOutUnresolvableEntry(t *testing.T) {
+	resolver := ResolverFunc(func(host string) (*Resolution, error) {
+		if host == "gone.example.com" {
+			return nil, errors.New("no such host")
+		}
+		return &Resolution{A: []string{"192.0.2.7"}}, nil
+	})
+	r, err := NewRunner(&Options{Resolver: resolver, Retries: 1})
+	require.Nil(t, err)
+
+	excluded, err := r.resolveOptOutEntries([]string{"198.51.100.0/24", "payroll.example.com"})
+	require.Nil(t, err)
+	require.Equal(t, []string{"198.51.100.0/24", "192.0.2.7"}, excluded)
+
+	// the hosts of an unresolvable entry can't be excluded, so the scan doesn't start
+	_, err = r.resolveOptOutEntries([]string{"198.51.100.0/24", "gone.example.com"})
+	require.ErrorContains(t, err, "gone.example.com")
+}
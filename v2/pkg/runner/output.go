@@ -13,6 +13,8 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+	"github.com/projectdiscovery/naabu/v2/pkg/schema"
 )
 
 // Result contains the result for a host
@@ -23,29 +25,99 @@ type Result struct {
 	IsCDNIP   bool       `json:"cdn,omitempty" csv:"cdn"`
 	CDNName   string     `json:"cdn-name,omitempty" csv:"cdn-name"`
 	TimeStamp time.Time  `json:"timestamp" csv:"timestamp"`
+	Uptime    float64    `json:"uptime_seconds,omitempty" csv:"uptime_seconds"`
+	ClockSkew float64    `json:"clock_skew_ppm,omitempty" csv:"clock_skew_ppm"`
+	CNAME     CNAMEChain `json:"cname,omitempty" csv:"cname"`
+	Tags      Tags       `json:"tags,omitempty" csv:"tags"`
+	Anycast   bool       `json:"anycast,omitempty" csv:"anycast"`
+	ScanType  string     `json:"scan_type,omitempty" csv:"scan_type"`
+	// Family is the last csv column so that the columns of earlier versions keep their position
+	Family string `json:"family,omitempty" csv:"family"`
+}
+
+// names returns the hostname and aliases of the result
+func (r *Result) names() []string {
+	return append([]string{r.Host}, r.CNAME...)
+}
+
+// CNAMEChain is the chain of aliases followed while resolving the host (app.example.com > lb.cdn.net)
+type CNAMEChain []string
+
+// String returns the aliases of the chain separated by " > "
+func (chain CNAMEChain) String() string {
+	return strings.Join(chain, " > ")
+}
+
+// Tags are the labels of the tag rules matching the names of the host (cloud:aws)
+type Tags []string
+
+// String returns the tags separated by commas
+func (tags Tags) String() string {
+	return strings.Join(tags, ",")
 }
 
 type jsonResult struct {
 	Result
-	PortNumber int    `json:"port"`
-	Protocol   string `json:"protocol"`
-	TLS        bool   `json:"tls"`
+	PortNumber    int        `json:"port"`
+	Protocol      string     `json:"protocol"`
+	TLS           bool       `json:"tls"`
+	Banner        string     `json:"banner,omitempty"`
+	Service       string     `json:"service,omitempty"`
+	Version       string     `json:"version,omitempty"`
+	Responder     string     `json:"responder,omitempty"`
+	ResponseTime  float64    `json:"response_time_ms,omitempty"`
+	Severity      string     `json:"severity,omitempty"`
+	Risk          string     `json:"risk,omitempty"`
+	Path          *port.Path `json:"path,omitempty"`
+	Reserved      bool       `json:"reserved,omitempty"`
+	SchemaVersion int        `json:"schema_version"`
+	// Enrichment are merged into the json object, without overriding its fields
+	Enrichment map[string]interface{} `json:"-"`
 }
 
-func (r *Result) JSON() ([]byte, error) {
-	data := jsonResult{}
-	data.TimeStamp = r.TimeStamp
-	if r.Host != r.IP {
-		data.Host = r.Host
+// MarshalJSON adds the enrichment fields to the json object of the result
+func (data *jsonResult) MarshalJSON() ([]byte, error) {
+	type plainResult jsonResult
+	encoded, err := json.Marshal((*plainResult)(data))
+	if err != nil || len(data.Enrichment) == 0 {
+		return encoded, err
 	}
-	data.IP = r.IP
-	data.IsCDNIP = r.IsCDNIP
-	data.CDNName = r.CDNName
-	data.PortNumber = r.Port.Port
-	data.Protocol = r.Port.Protocol.String()
-	data.TLS = r.Port.TLS
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return nil, err
+	}
+	for name, value := range data.Enrichment {
+		if _, ok := fields[name]; !ok {
+			fields[name] = value
+		}
+	}
+	return json.Marshal(fields)
+}
 
-	return json.Marshal(data)
+func (r *Result) JSON() ([]byte, error) {
+	return json.Marshal(r.jsonResult(r.Port))
+}
+
+// jsonResult returns the json line of the port of the result
+func (r *Result) jsonResult(p *port.Port) *jsonResult {
+	data := &jsonResult{Result: *r, SchemaVersion: schema.Version}
+	if r.Host == r.IP {
+		data.Host = ""
+	}
+	data.PortNumber = p.Port
+	data.Protocol = p.Protocol.String()
+	data.TLS = p.TLS
+	data.Banner = p.Banner
+	data.Service = p.Service
+	data.Version = p.Version
+	data.Responder = p.Responder
+	data.ResponseTime = float64(p.RTT.Microseconds()) / 1000
+	data.Severity = p.Severity
+	data.Risk = p.Risk
+	data.Path = p.Path
+	data.Reserved = port.IsReserved(p.Port)
+	data.Enrichment = p.Enrichment
+	return data
 }
 
 var NumberOfCsvFieldsErr = errors.New("exported fields don't match csv tags")
@@ -58,7 +130,7 @@ func (r *Result) CSVHeaders() ([]string, error) {
 		csvTag := field.Tag.Get("csv")
 		fieldValue := reflect.ValueOf(*r).FieldByName(field.Name).Interface()
 		// appends tag value if field value is other than default value
-		if fieldValue != reflect.Zero(field.Type).Interface() {
+		if !reflect.DeepEqual(fieldValue, reflect.Zero(field.Type).Interface()) {
 			headers = append(headers, csvTag)
 		}
 	}
@@ -105,46 +177,51 @@ func WriteHostOutput(host string, ports []*port.Port, outputCDN bool, cdnName st
 
 // WriteJSONOutput writes the output list of subdomain in JSON to an io.Writer
 func WriteJSONOutput(host, ip string, ports []*port.Port, outputCDN bool, isCdn bool, cdnName string, writer io.Writer) error {
-	encoder := json.NewEncoder(writer)
-	data := jsonResult{}
-	data.TimeStamp = time.Now().UTC()
-	if host != ip {
+	return writeJSONResult(newOutputResult(host, ip, outputCDN, isCdn, cdnName), ports, writer)
+}
+
+// WriteCsvOutput writes the output list of subdomain in csv format to an io.Writer
+func WriteCsvOutput(host, ip string, ports []*port.Port, outputCDN bool, isCdn bool, cdnName string, header bool, writer io.Writer) error {
+	return writeCSVResult(newOutputResult(host, ip, outputCDN, isCdn, cdnName), ports, header, writer)
+}
+
+// newOutputResult returns the result of the host written for each of its ports
+func newOutputResult(host, ip string, outputCDN bool, isCdn bool, cdnName string) *Result {
+	ip = result.NormalizeIP(ip)
+	data := &Result{IP: ip, Family: result.Family(ip), TimeStamp: time.Now().UTC()}
+	if result.NormalizeIP(host) != ip {
 		data.Host = host
 	}
-	data.IP = ip
 	if outputCDN {
 		data.IsCDNIP = isCdn
 		data.CDNName = cdnName
 	}
+	return data
+}
+
+// writeJSONResult writes a json line for each port of the result to an io.Writer
+func writeJSONResult(data *Result, ports []*port.Port, writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
 	for _, p := range ports {
-		data.PortNumber = p.Port
-		data.Protocol = p.Protocol.String()
-		data.TLS = p.TLS
-		if err := encoder.Encode(&data); err != nil {
+		if err := encoder.Encode(data.jsonResult(p)); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-// WriteCsvOutput writes the output list of subdomain in csv format to an io.Writer
-func WriteCsvOutput(host, ip string, ports []*port.Port, outputCDN bool, isCdn bool, cdnName string, header bool, writer io.Writer) error {
+// writeCSVResult writes a csv row for each port of the result to an io.Writer
+func writeCSVResult(data *Result, ports []*port.Port, header bool, writer io.Writer) error {
 	encoder := csv.NewWriter(writer)
-	data := &Result{IP: ip, TimeStamp: time.Now().UTC(), Port: &port.Port{}}
-	if host != ip {
-		data.Host = host
-	}
-	if outputCDN {
-		data.IsCDNIP = isCdn
-		data.CDNName = cdnName
-	}
+	row := *data
+	row.Port = &port.Port{}
 	if header {
-		writeCSVHeaders(data, encoder)
+		writeCSVHeaders(&row, encoder)
 	}
 
 	for _, p := range ports {
-		data.Port = p
-		writeCSVRow(data, encoder)
+		row.Port = p
+		writeCSVRow(&row, encoder)
 	}
 	encoder.Flush()
 	return nil
@@ -7,6 +7,7 @@ import (
 
 	"github.com/projectdiscovery/naabu/v2/pkg/port"
 	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -35,3 +36,64 @@ func TestWriteJSONOutput(t *testing.T) {
 	assert.Nil(t, WriteJSONOutput(host, ip, ports, true, false, "", buf))
 	assert.Equal(t, 3, len(strings.Split(buf.String(), "\n")))
 }
+
+func TestResultJSONEnrichment(t *testing.T) {
+	data := &Result{IP: "127.0.0.1", Host: "127.0.0.1", Port: &port.Port{Port: 80, Protocol: protocol.TCP}, Uptime: 3600, ClockSkew: -12.5}
+	b, err := data.JSON()
+	assert.Nil(t, err)
+	assert.NotContains(t, string(b), `"host"`)
+	assert.Contains(t, string(b), `"port":80`)
+	assert.Contains(t, string(b), `"uptime_seconds":3600,"clock_skew_ppm":-12.5`)
+}
+
+func TestResultPath(t *testing.T) {
+	data := &Result{IP: "10.0.0.1", Port: &port.Port{Port: 22, Protocol: protocol.TCP, Path: &port.Path{Interface: "eth1", SourceIP: "10.0.0.254", Vantage: "eu-1"}}}
+	b, err := data.JSON()
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), `"path":{"interface":"eth1","source_ip":"10.0.0.254","vantage":"eu-1"}`)
+}
+
+func TestResultReservedPort(t *testing.T) {
+	b, err := (&Result{IP: "10.0.0.1", Port: &port.Port{Port: 0, Protocol: protocol.TCP}}).JSON()
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), `"port":0,"protocol":"tcp"`)
+	assert.Contains(t, string(b), `"reserved":true`)
+
+	b, err = (&Result{IP: "10.0.0.1", Port: &port.Port{Port: 22, Protocol: protocol.TCP}}).JSON()
+	assert.Nil(t, err)
+	assert.NotContains(t, string(b), `"reserved"`)
+}
+
+func TestResultCNAMEChain(t *testing.T) {
+	data := &Result{IP: "104.16.99.52", Host: "app.example.com", Port: &port.Port{Port: 443, Protocol: protocol.TCP}, CNAME: CNAMEChain{"app.example.net", "lb.cdn.net"}}
+	b, err := data.JSON()
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), `"cname":["app.example.net","lb.cdn.net"]`)
+
+	headers, err := data.CSVHeaders()
+	assert.Nil(t, err)
+	assert.Contains(t, headers, "cname")
+	fields, err := data.CSVFields()
+	assert.Nil(t, err)
+	assert.Contains(t, fields, "app.example.net > lb.cdn.net")
+}
+
+func TestWriteJSONOutputFamily(t *testing.T) {
+	ports := []*port.Port{{Port: 80, Protocol: protocol.TCP}}
+	buf := &bytes.Buffer{}
+	assert.Nil(t, WriteJSONOutput("::ffff:10.0.0.1", "::ffff:10.0.0.1", ports, false, false, "", buf))
+	assert.Contains(t, buf.String(), `"ip":"10.0.0.1"`)
+	assert.Contains(t, buf.String(), `"family":"ipv4"`)
+	assert.NotContains(t, buf.String(), `"host"`)
+
+	buf.Reset()
+	assert.Nil(t, WriteJSONOutput("scanme.sh", "2600:3C01::F03C:91FF:FE18:BB2F", ports, false, false, "", buf))
+	assert.Contains(t, buf.String(), `"ip":"2600:3c01::f03c:91ff:fe18:bb2f"`)
+	assert.Contains(t, buf.String(), `"family":"ipv6"`)
+
+	buf.Reset()
+	assert.Nil(t, writeCSVResult(&Result{Host: "scanme.sh", IP: "10.0.0.1", Family: result.FamilyIPv4}, ports, true, buf))
+	header := strings.SplitN(buf.String(), "\n", 2)[0]
+	assert.True(t, strings.HasPrefix(header, "host,ip,port,"), header)
+	assert.True(t, strings.HasSuffix(header, ",family"), header)
+}
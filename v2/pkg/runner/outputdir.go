@@ -0,0 +1,93 @@
+package runner
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// untaggedGroup is the output group of the results matching no tag rule
+const untaggedGroup = "untagged"
+
+// groupFiles writes the results of each tag to its own file of the output directory (dmz.json,
+// corp.json), the results having several tags being written to each of their files
+type groupFiles struct {
+	sync.Mutex
+	dir       string
+	extension string
+	files     map[string]*os.File
+}
+
+// newGroupFiles creates the output directory, the files being created with the first result of
+// their group
+func newGroupFiles(dir string, options *Options) (*groupFiles, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	extension := ".txt"
+	switch {
+	case options.JSON:
+		extension = ".json"
+	case options.CSV:
+		extension = ".csv"
+	}
+	return &groupFiles{dir: dir, extension: extension, files: make(map[string]*os.File)}, nil
+}
+
+// groupFileName returns the file name of the group, replacing the characters not allowed in file
+// names on every platform (cloud:aws is written to cloud_aws.json)
+func groupFileName(group string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, group)
+}
+
+// write calls fn with the file of each group of the tags, header being true for a new file
+func (g *groupFiles) write(tags Tags, fn func(writer io.Writer, header bool) error) error {
+	g.Lock()
+	defer g.Unlock()
+
+	groups := []string(tags)
+	if len(groups) == 0 {
+		groups = []string{untaggedGroup}
+	}
+	for _, group := range groups {
+		path := filepath.Join(g.dir, groupFileName(group)+g.extension)
+		file, ok := g.files[path]
+		if !ok {
+			var err error
+			if file, err = os.Create(path); err != nil {
+				return err
+			}
+			g.files[path] = file
+		}
+		if err := fn(file, !ok); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the files of the groups
+func (g *groupFiles) Close() error {
+	if g == nil {
+		return nil
+	}
+	g.Lock()
+	defer g.Unlock()
+
+	var firstErr error
+	for _, file := range g.files {
+		if err := file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
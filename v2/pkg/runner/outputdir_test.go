@@ -0,0 +1,48 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupFileName(t *testing.T) {
+	require.Equal(t, "dmz", groupFileName("dmz"))
+	require.Equal(t, "cloud_aws", groupFileName("cloud:aws"))
+	require.Equal(t, "eu-west.prod", groupFileName("eu-west.prod"))
+	require.Equal(t, ".._etc_passwd", groupFileName("../etc/passwd"))
+}
+
+func TestGroupFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "results")
+	groups, err := newGroupFiles(dir, &Options{CSV: true})
+	require.Nil(t, err)
+
+	write := func(line string) func(writer io.Writer, header bool) error {
+		return func(writer io.Writer, header bool) error {
+			if header {
+				fmt.Fprintln(writer, "header")
+			}
+			_, err := fmt.Fprintln(writer, line)
+			return err
+		}
+	}
+	require.Nil(t, groups.write(Tags{"dmz"}, write("a")))
+	require.Nil(t, groups.write(Tags{"corp", "dmz"}, write("b")))
+	require.Nil(t, groups.write(nil, write("c")))
+	require.Nil(t, groups.Close())
+
+	for name, content := range map[string]string{
+		"dmz.csv":      "header\na\nb\n",
+		"corp.csv":     "header\nb\n",
+		"untagged.csv": "header\nc\n",
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		require.Nil(t, err)
+		require.Equal(t, content, string(data), name)
+	}
+}
@@ -0,0 +1,65 @@
+package runner
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+)
+
+// resolveOverrides maps hostnames to static addresses, looked up before dns
+type resolveOverrides map[string][]string
+
+// loadResolveOverrides reads a hosts file style list of overrides
+func loadResolveOverrides(path string) (resolveOverrides, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parseResolveOverrides(f)
+}
+
+// parseResolveOverrides parses "ip hostname [hostname...]" lines, comments start with #
+func parseResolveOverrides(reader io.Reader) (resolveOverrides, error) {
+	overrides := make(resolveOverrides)
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		data, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(data)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("invalid resolve override at line %d (expected ip hostname)", line)
+		}
+		ip := net.ParseIP(fields[0])
+		if ip == nil {
+			return nil, fmt.Errorf("invalid ip %s in resolve override at line %d", fields[0], line)
+		}
+		for _, host := range fields[1:] {
+			host = canonicalHost(host)
+			overrides[host] = append(overrides[host], ip.String())
+		}
+	}
+	return overrides, scanner.Err()
+}
+
+// Lookup returns the ipv4 and ipv6 overrides of the host
+func (overrides resolveOverrides) Lookup(host string) (ipsV4, ipsV6 []string, ok bool) {
+	ips, ok := overrides[canonicalHost(host)]
+	if !ok {
+		return nil, nil, false
+	}
+	for _, ip := range ips {
+		if net.ParseIP(ip).To4() != nil {
+			ipsV4 = append(ipsV4, ip)
+		} else {
+			ipsV6 = append(ipsV6, ip)
+		}
+	}
+	return ipsV4, ipsV6, true
+}
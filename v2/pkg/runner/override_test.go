@@ -0,0 +1,34 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveOverrides(t *testing.T) {
+	overrides, err := parseResolveOverrides(strings.NewReader(`# pre-prod
+10.0.0.10 app.preprod.example.com api.preprod.example.com
+fd00::10  app.preprod.example.com # dual stack
+`))
+	require.Nil(t, err)
+
+	ipsV4, ipsV6, ok := overrides.Lookup("App.Preprod.Example.com.")
+	require.True(t, ok)
+	require.Equal(t, []string{"10.0.0.10"}, ipsV4)
+	require.Equal(t, []string{"fd00::10"}, ipsV6)
+
+	ipsV4, ipsV6, ok = overrides.Lookup("api.preprod.example.com")
+	require.True(t, ok)
+	require.Equal(t, []string{"10.0.0.10"}, ipsV4)
+	require.Empty(t, ipsV6)
+
+	_, _, ok = overrides.Lookup("example.com")
+	require.False(t, ok)
+
+	_, err = parseResolveOverrides(strings.NewReader("app.example.com"))
+	require.NotNil(t, err)
+	_, err = parseResolveOverrides(strings.NewReader("10.0.0.300 app.example.com"))
+	require.NotNil(t, err)
+}
@@ -0,0 +1,253 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/mapcidr"
+	"github.com/projectdiscovery/ratelimit"
+	"github.com/projectdiscovery/retryablehttp-go"
+)
+
+// Actions performed when targets are not registered to the expected organization
+const (
+	OwnershipWarn  = "warn"
+	OwnershipAbort = "abort"
+)
+
+// rdapURL is the RDAP bootstrap service redirecting ip queries to the authoritative registry
+var rdapURL = "https://rdap.org/ip/%s"
+
+// rdapNetwork is the subset of a RDAP ip network response used to verify ownership
+type rdapNetwork struct {
+	Handle       string       `json:"handle"`
+	StartAddress string       `json:"startAddress"`
+	EndAddress   string       `json:"endAddress"`
+	Name         string       `json:"name"`
+	Entities     []rdapEntity `json:"entities"`
+}
+
+type rdapEntity struct {
+	Roles      []string      `json:"roles"`
+	VcardArray []interface{} `json:"vcardArray"`
+	Entities   []rdapEntity  `json:"entities"`
+}
+
+// parseOwnershipOrg extracts the expected organization from a org:"Example Corp" value
+func parseOwnershipOrg(value string) (string, error) {
+	org, ok := strings.CutPrefix(strings.TrimSpace(value), "org:")
+	if !ok {
+		return "", fmt.Errorf("invalid ownership %s (expected org:\"name\")", value)
+	}
+	org = strings.Trim(strings.TrimSpace(org), `"'`)
+	if org == "" {
+		return "", errors.New("ownership organization can't be empty")
+	}
+	return org, nil
+}
+
+// Owners returns the network name and the names of the organizations registering the network
+func (network *rdapNetwork) Owners() []string {
+	var owners []string
+	if network.Name != "" {
+		owners = append(owners, network.Name)
+	}
+	var walk func(entities []rdapEntity)
+	walk = func(entities []rdapEntity) {
+		for _, entity := range entities {
+			owners = append(owners, vcardNames(entity.VcardArray)...)
+			walk(entity.Entities)
+		}
+	}
+	walk(network.Entities)
+	return owners
+}
+
+// vcardNames returns the fn and org properties of a jCard (["vcard", [[name, params, type, value], ...]])
+func vcardNames(vcard []interface{}) []string {
+	if len(vcard) < 2 {
+		return nil
+	}
+	properties, ok := vcard[1].([]interface{})
+	if !ok {
+		return nil
+	}
+	var names []string
+	for _, property := range properties {
+		fields, ok := property.([]interface{})
+		if !ok || len(fields) < 4 {
+			continue
+		}
+		if name, _ := fields[0].(string); name != "fn" && name != "org" {
+			continue
+		}
+		if value, ok := fields[3].(string); ok && value != "" {
+			names = append(names, value)
+		}
+	}
+	return names
+}
+
+// Contains returns true if the ip belongs to the network range
+func (network *rdapNetwork) Contains(ip net.IP) bool {
+	start, end := net.ParseIP(network.StartAddress), net.ParseIP(network.EndAddress)
+	if start == nil || end == nil || ip == nil {
+		return false
+	}
+	return bytes.Compare(ip.To16(), start.To16()) >= 0 && bytes.Compare(ip.To16(), end.To16()) <= 0
+}
+
+// isOwnedBy checks if any owner matches the organization (case insensitive)
+func isOwnedBy(owners []string, org string) bool {
+	for _, owner := range owners {
+		if strings.Contains(strings.ToLower(owner), strings.ToLower(org)) {
+			return true
+		}
+	}
+	return false
+}
+
+// queryRDAP retrieves the registration data of the network containing the ip
+func queryRDAP(httpClient *retryablehttp.Client, ip string) (*rdapNetwork, error) {
+	request, err := retryablehttp.NewRequest(http.MethodGet, fmt.Sprintf(rdapURL, ip), nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", "application/rdap+json")
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server replied with status code %d", response.StatusCode)
+	}
+	network := &rdapNetwork{}
+	if err := json.NewDecoder(response.Body).Decode(network); err != nil {
+		return nil, err
+	}
+	return network, nil
+}
+
+// verifyOwnership checks that the target prefixes are registered to the expected organization
+// before any packet is sent, warning about or aborting on mismatches
+func (r *Runner) verifyOwnership() error {
+	org, err := parseOwnershipOrg(r.options.VerifyOwnership)
+	if err != nil {
+		return err
+	}
+
+	verifier := &ownershipVerifier{
+		org:        org,
+		httpClient: retryablehttp.NewClient(retryablehttp.DefaultOptionsSingle),
+		limiter:    ratelimit.New(context.Background(), rdapRate, rdapRateDuration),
+	}
+	defer verifier.limiter.Stop()
+
+	cidrs, ipsWithPort := r.getPreprocessedIps()
+	targetsV4, targetsV6 := mapcidr.CoalesceCIDRs(cidrs)
+	for _, target := range append(targetsV4, targetsV6...) {
+		verifier.verifyRange(target)
+	}
+	for _, ipWithPort := range ipsWithPort {
+		if ip, _, err := net.SplitHostPort(ipWithPort); err == nil {
+			verifier.verifyIP(net.ParseIP(ip))
+		}
+	}
+
+	if verifier.mismatches > 0 && r.options.OwnershipMismatch == OwnershipAbort {
+		return fmt.Errorf("%d targets could not be verified as registered to %s", verifier.mismatches, org)
+	}
+	return nil
+}
+
+// rdapRate requests per rdapRateDuration are sent to the RDAP service, independently of the scan rate
+var (
+	rdapRate         uint = 10
+	rdapRateDuration      = 10 * time.Second
+)
+
+// ownershipVerifier queries the registered networks once and counts the targets not owned by org
+type ownershipVerifier struct {
+	org        string
+	httpClient *retryablehttp.Client
+	limiter    *ratelimit.Limiter
+	networks   []*rdapNetwork
+	mismatches int
+}
+
+// verifyRange checks every registered network overlapping the prefix, as a coalesced
+// prefix can span several allocations with different owners
+func (verifier *ownershipVerifier) verifyRange(target *net.IPNet) {
+	first, last, err := mapcidr.AddressRange(target)
+	if err != nil {
+		return
+	}
+	for ip := first; ip != nil && bytes.Compare(ip.To16(), last.To16()) <= 0; {
+		network := verifier.verifyIP(ip)
+		if network == nil {
+			// the remaining addresses of the prefix can't be attributed
+			return
+		}
+		end := net.ParseIP(network.EndAddress)
+		if end == nil || bytes.Compare(end.To16(), ip.To16()) < 0 {
+			return
+		}
+		ip = nextIP(end)
+	}
+}
+
+// verifyIP checks the network registering the ip and returns it, or nil if it couldn't be retrieved
+func (verifier *ownershipVerifier) verifyIP(ip net.IP) *rdapNetwork {
+	if ip == nil {
+		return nil
+	}
+	var network *rdapNetwork
+	// targets in the same registered network are verified once
+	for _, known := range verifier.networks {
+		if known.Contains(ip) {
+			network = known
+			break
+		}
+	}
+	if network == nil {
+		verifier.limiter.Take()
+		var err error
+		network, err = queryRDAP(verifier.httpClient, ip.String())
+		if err != nil {
+			gologger.Warning().Msgf("Couldn't verify ownership of %s: %s\n", ip, err)
+			verifier.mismatches++
+			return nil
+		}
+		verifier.networks = append(verifier.networks, network)
+	}
+	if owners := network.Owners(); !isOwnedBy(owners, verifier.org) {
+		gologger.Warning().Msgf("Target %s (%s) is not registered to %s (owners: %s)\n", ip, network.Handle, verifier.org, strings.Join(owners, ", "))
+		verifier.mismatches++
+	}
+	return network
+}
+
+// nextIP returns the address following ip, or nil when ip is the last address of its family
+func nextIP(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	if v4 := next.To4(); v4 != nil {
+		next = v4
+	}
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			return next
+		}
+	}
+	return nil
+}
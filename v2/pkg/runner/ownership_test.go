@@ -0,0 +1,84 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/projectdiscovery/ratelimit"
+	"github.com/projectdiscovery/retryablehttp-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOwnershipOrg(t *testing.T) {
+	org, err := parseOwnershipOrg(`org:"Example Corp"`)
+	require.Nil(t, err)
+	require.Equal(t, "Example Corp", org)
+
+	_, err = parseOwnershipOrg("Example Corp")
+	require.NotNil(t, err)
+	_, err = parseOwnershipOrg(`org:""`)
+	require.NotNil(t, err)
+}
+
+func TestRDAPNetworkOwners(t *testing.T) {
+	data := `{
+		"handle": "NET-192-0-2-0-1",
+		"startAddress": "192.0.2.0",
+		"endAddress": "192.0.2.255",
+		"name": "EXAMPLE-NET",
+		"entities": [{
+			"roles": ["registrant"],
+			"vcardArray": ["vcard", [["version", {}, "text", "4.0"], ["fn", {}, "text", "Example Corp"]]],
+			"entities": [{
+				"roles": ["abuse"],
+				"vcardArray": ["vcard", [["fn", {}, "text", "Abuse Desk"], ["email", {}, "text", "abuse@example.com"]]]
+			}]
+		}]
+	}`
+	network := &rdapNetwork{}
+	require.Nil(t, json.Unmarshal([]byte(data), network))
+
+	owners := network.Owners()
+	require.Equal(t, []string{"EXAMPLE-NET", "Example Corp", "Abuse Desk"}, owners)
+	require.True(t, isOwnedBy(owners, "example corp"))
+	require.False(t, isOwnedBy(owners, "Other Corp"))
+
+	require.True(t, network.Contains(net.ParseIP("192.0.2.42")))
+	require.False(t, network.Contains(net.ParseIP("192.0.3.1")))
+}
+
+func TestVerifyOwnershipEveryNetwork(t *testing.T) {
+	var queries atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		queries.Add(1)
+		start, end, name := "192.0.2.0", "192.0.2.255", "Example Corp"
+		if strings.HasPrefix(strings.TrimPrefix(req.URL.Path, "/"), "192.0.3.") {
+			start, end, name = "192.0.3.0", "192.0.3.255", "Other Corp"
+		}
+		fmt.Fprintf(w, `{"handle":"NET-%s","startAddress":"%s","endAddress":"%s","name":"%s"}`, start, start, end, name)
+	}))
+	defer server.Close()
+
+	defaultURL := rdapURL
+	rdapURL = server.URL + "/%s"
+	defer func() { rdapURL = defaultURL }()
+
+	verifier := &ownershipVerifier{
+		org:        "Example Corp",
+		httpClient: retryablehttp.NewClient(retryablehttp.DefaultOptionsSingle),
+		limiter:    ratelimit.NewUnlimited(context.Background()),
+	}
+	// the coalesced prefix spans two registered networks
+	_, target, _ := net.ParseCIDR("192.0.2.0/23")
+	verifier.verifyRange(target)
+	verifier.verifyIP(net.ParseIP("192.0.2.7"))
+	require.Equal(t, 1, verifier.mismatches)
+	require.EqualValues(t, 2, queries.Load())
+}
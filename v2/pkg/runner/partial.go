@@ -0,0 +1,114 @@
+package runner
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+)
+
+// PartialResults returns a snapshot of the open ports found so far for each hostname of their ip
+// passing the filter expression, without stopping the scan, so that the findings of a long scan
+// can be triaged before it completes. The ports may still be verified, grabbed or retried, the
+// final output remaining the reference
+func (r *Runner) PartialResults() []*result.HostResult {
+	var snapshot []*result.HostResult
+	for _, hostResult := range r.hostResults(r.scanner.ScanResults) {
+		hosts, err := r.getResultHosts(hostResult)
+		if err != nil {
+			continue
+		}
+		// the ports keep being updated by the banner grabs and verifications
+		ports := make([]*port.Port, 0, len(hostResult.Ports))
+		for _, p := range hostResult.Ports {
+			snapshotPort := *p
+			ports = append(ports, &snapshotPort)
+		}
+		isCDNIP, cdnName, _ := r.scanner.CdnCheck(hostResult.IP)
+		for _, host := range r.sortHosts(hosts) {
+			if hostPorts := r.filterHostPorts(host, hostResult.IP, ports, isCDNIP, cdnName); len(hostPorts) > 0 {
+				snapshot = append(snapshot, &result.HostResult{Host: host, IP: hostResult.IP, Ports: hostPorts})
+			}
+		}
+	}
+	return snapshot
+}
+
+// startResults serves the partial results on the configured address, apart from the probes so
+// that the findings are only exposed on request
+func (r *Runner) startResults() error {
+	listener, err := net.Listen("tcp", r.options.ResultsAddr)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/results", r.handlePartialResults)
+	r.resultsServer = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		if err := r.resultsServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			gologger.Warning().Msgf("Results server stopped: %s\n", err)
+		}
+	}()
+
+	return nil
+}
+
+// validResultsAddr returns an error if the results would be served unauthenticated on an address
+// reachable from other hosts
+func validResultsAddr(addr, token string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid results address %s (allowed: host:port)", addr)
+	}
+	if token != "" {
+		return nil
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return fmt.Errorf("results address %s is not a loopback address, set a results token to expose it", addr)
+	}
+	return nil
+}
+
+// authorized returns true if the request carries the results token, if any
+func (r *Runner) authorized(req *http.Request) bool {
+	if r.options.ResultsToken == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(r.options.ResultsToken)) == 1
+}
+
+// handlePartialResults writes the partial results as json lines, in the format of the json output
+func (r *Runner) handlePartialResults(w http.ResponseWriter, req *http.Request) {
+	if !r.authorized(req) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	encoder := json.NewEncoder(w)
+	for _, hostResult := range r.PartialResults() {
+		isCDNIP, cdnName, _ := r.scanner.CdnCheck(hostResult.IP)
+		data := newOutputResult(hostResult.Host, hostResult.IP, r.options.OutputCDN, isCDNIP, cdnName)
+		data.ScanType = scanTypeNames[r.options.usedScanType()]
+		data = r.anonymizer.Result(data)
+		for _, p := range r.anonymizer.Ports(hostResult.Ports, hostResult.Host) {
+			if err := encoder.Encode(data.jsonResult(p)); err != nil {
+				return
+			}
+		}
+	}
+}
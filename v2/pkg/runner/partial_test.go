@@ -0,0 +1,96 @@
+package runner
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/projectdiscovery/ipranger"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+	"github.com/projectdiscovery/naabu/v2/pkg/scan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartialResults(t *testing.T) {
+	ranger, err := ipranger.New()
+	require.Nil(t, err)
+	defer ranger.Close()
+	require.Nil(t, ranger.AddHostWithMetadata("10.0.0.1", "scanme.sh"))
+
+	r := &Runner{
+		options: &Options{ScanType: ConnectScan},
+		scanner: &scan.Scanner{IPRanger: ranger, ScanResults: result.NewResult()},
+	}
+	require.Empty(t, r.PartialResults())
+
+	found := &port.Port{Port: 443, Protocol: protocol.TCP}
+	r.scanner.ScanResults.AddPort("10.0.0.1", found)
+	r.scanner.ScanResults.AddPort("10.0.0.1", &port.Port{Port: 22, Protocol: protocol.TCP})
+	filter, err := newResultFilter("port != 22")
+	require.Nil(t, err)
+	r.filter = filter
+
+	partial := r.PartialResults()
+	require.Len(t, partial, 1)
+	require.Equal(t, "scanme.sh", partial[0].Host)
+	require.Len(t, partial[0].Ports, 1)
+	require.Equal(t, 443, partial[0].Ports[0].Port)
+
+	// the snapshot isn't updated by the running scan
+	found.Banner = "HTTP/1.1 200 OK"
+	require.Empty(t, partial[0].Ports[0].Banner)
+
+	rec := httptest.NewRecorder()
+	r.handlePartialResults(rec, httptest.NewRequest(http.MethodGet, "/results", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	require.Len(t, lines, 1)
+	var line map[string]interface{}
+	require.Nil(t, json.Unmarshal([]byte(lines[0]), &line))
+	require.Equal(t, "scanme.sh", line["host"])
+	require.Equal(t, float64(443), line["port"])
+	require.Equal(t, "connect", line["scan_type"])
+}
+
+func TestPartialResultsAccess(t *testing.T) {
+	ranger, err := ipranger.New()
+	require.Nil(t, err)
+	defer ranger.Close()
+	require.Nil(t, ranger.AddHostWithMetadata("10.0.0.1", "scanme.sh"))
+	anonymizer, err := newAnonymizer(AnonymizeHash, "key")
+	require.Nil(t, err)
+	r := &Runner{
+		options:    &Options{ScanType: ConnectScan, ResultsToken: "secret"},
+		scanner:    &scan.Scanner{IPRanger: ranger, ScanResults: result.NewResult()},
+		anonymizer: anonymizer,
+	}
+	r.scanner.ScanResults.AddPort("10.0.0.1", &port.Port{Port: 443, Protocol: protocol.TCP})
+
+	rec := httptest.NewRecorder()
+	r.handlePartialResults(rec, httptest.NewRequest(http.MethodGet, "/results", nil))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/results", nil)
+	request.Header.Set("Authorization", "Bearer wrong")
+	r.handlePartialResults(rec, request)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// the findings are anonymized like the output files
+	rec = httptest.NewRecorder()
+	request.Header.Set("Authorization", "Bearer secret")
+	r.handlePartialResults(rec, request)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NotContains(t, rec.Body.String(), "10.0.0.1")
+	require.NotContains(t, rec.Body.String(), "scanme.sh")
+
+	require.Nil(t, validResultsAddr("127.0.0.1:8081", ""))
+	require.Nil(t, validResultsAddr("[::1]:8081", ""))
+	require.Nil(t, validResultsAddr("0.0.0.0:8081", "secret"))
+	require.NotNil(t, validResultsAddr("0.0.0.0:8081", ""))
+	require.NotNil(t, validResultsAddr(":8081", ""))
+	require.NotNil(t, validResultsAddr("8081", "secret"))
+}
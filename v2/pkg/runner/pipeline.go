@@ -0,0 +1,273 @@
+package runner
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/projectdiscovery/gologger"
+	sliceutil "github.com/projectdiscovery/utils/slice"
+	"gopkg.in/yaml.v3"
+)
+
+// Pipeline stages
+const (
+	StageDiscover = "discover"
+	StageScan     = "scan"
+	StageVerify   = "verify"
+	StageBanner   = "banner"
+	StageEnrich   = "enrich"
+	StageOutput   = "output"
+)
+
+// pipelineStageSpec is what a stage enables and the options it accepts. The stages select and
+// configure the phases of the fixed scan flow, they are not executed on their own
+type pipelineStageSpec struct {
+	// after are the stages that must come first when present, requires the ones that must be present
+	after    []string
+	requires []string
+	// enables are the flags set by the stage, disables the flags set when it's missing
+	enables  map[string]string
+	disables map[string]string
+	flags    []string
+}
+
+var pipelineStages = map[string]*pipelineStageSpec{
+	StageDiscover: {
+		disables: map[string]string{"skip-host-discovery": "true"},
+		flags:    []string{"probe-tcp-syn", "probe-tcp-ack", "probe-icmp-echo", "probe-icmp-timestamp", "probe-icmp-address-mask", "arp-ping", "nd-ping", "rev-ptr", "host-discovery-output"},
+	},
+	StageScan: {
+		after:    []string{StageDiscover},
+		disables: map[string]string{"host-discovery": "true"},
+		flags:    []string{"scan-type", "port", "top-ports", "exclude-ports", "ports-file", "port-threshold", "port-stripes", "port-burst", "fairness-window", "prefix-rate", "connect-ports", "exclude-cdn", "rate", "adaptive-rate", "auto-rate", "bandwidth", "asn-rate", "scan-window", "sample", "retries", "retry-strategy", "timeout", "stop-after-n-ports", "exit-on-first-open", "tcp-timestamps", "raw-probe", "probe-tag"},
+	},
+	StageVerify: {
+		after:    []string{StageScan},
+		requires: []string{StageScan},
+		enables:  map[string]string{"verify": "true"},
+		flags:    []string{"connect-criteria", "vhost-check", "intercept-check"},
+	},
+	StageBanner: {
+		after:    []string{StageScan, StageVerify},
+		requires: []string{StageScan},
+		enables:  map[string]string{"banner": "true"},
+		flags:    []string{"banner-threads", "banner-rate", "banner-timeout"},
+	},
+	StageEnrich: {
+		after:    []string{StageScan, StageVerify, StageBanner},
+		requires: []string{StageScan},
+		flags:    []string{"service-discovery", "service-version", "risk-heuristics", "display-cdn", "anycast-check", "tag-rules", "severity-map", "baseline", "exclude-fingerprints", "enrich-url"},
+	},
+	StageOutput: {
+		after: []string{StageDiscover, StageScan, StageVerify, StageBanner, StageEnrich},
+		flags: []string{"output", "json", "csv", "stream-output", "allow-duplicates", "webhook-url", "es-url", "es-index", "export-format", "group-by", "output-dir", "sorted", "vantage", "output-hosts", "output-ips", "filter", "sort-severity", "min-severity", "evidence-output", "progress-file", "nmap-cli"},
+	},
+}
+
+// pipelineStage is a stage of a pipeline with its options, written as its name or as a mapping of
+// its name to the options (long flag names)
+type pipelineStage struct {
+	Name    string
+	Options map[string]string
+}
+
+// UnmarshalYAML decodes the "verify" and "scan: {top-ports: 1000}" forms of a stage
+func (stage *pipelineStage) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		stage.Name = node.Value
+		return nil
+	case yaml.MappingNode:
+		if len(node.Content) != 2 {
+			return fmt.Errorf("line %d: a stage maps its name to its options", node.Line)
+		}
+		stage.Name = node.Content[0].Value
+		stage.Options = make(map[string]string)
+		options := node.Content[1]
+		if options.Kind == yaml.ScalarNode && options.Tag == "!!null" {
+			return nil
+		}
+		if options.Kind != yaml.MappingNode {
+			return fmt.Errorf("line %d: options of stage %s must be a mapping", options.Line, stage.Name)
+		}
+		for i := 0; i+1 < len(options.Content); i += 2 {
+			name, value := options.Content[i].Value, options.Content[i+1]
+			switch value.Kind {
+			case yaml.ScalarNode:
+				stage.Options[name] = value.Value
+			case yaml.SequenceNode:
+				var items []string
+				for _, item := range value.Content {
+					items = append(items, item.Value)
+				}
+				stage.Options[name] = strings.Join(items, ",")
+			default:
+				return fmt.Errorf("line %d: invalid value of option %s", value.Line, name)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("line %d: invalid stage", node.Line)
+	}
+}
+
+// pipelineFile holds the named pipelines
+type pipelineFile struct {
+	Pipelines map[string][]*pipelineStage `yaml:"pipelines"`
+}
+
+// loadPipeline returns the stages of the named pipeline of the file, the name being optional if the
+// file defines a single pipeline
+func loadPipeline(path, name string) (string, []*pipelineStage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, err
+	}
+	var file pipelineFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return "", nil, fmt.Errorf("invalid pipeline file %s: %w", path, err)
+	}
+	names := make([]string, 0, len(file.Pipelines))
+	for pipelineName := range file.Pipelines {
+		names = append(names, pipelineName)
+	}
+	sort.Strings(names)
+	if name == "" {
+		if len(names) != 1 {
+			return "", nil, fmt.Errorf("pipeline name required (allowed: %s)", strings.Join(names, ", "))
+		}
+		name = names[0]
+	}
+	stages, ok := file.Pipelines[name]
+	if !ok {
+		return "", nil, fmt.Errorf("invalid pipeline %s (allowed: %s)", name, strings.Join(names, ", "))
+	}
+	return name, stages, validatePipeline(stages)
+}
+
+// validatePipeline checks that the stages are known, given once, with their accepted options and
+// ordered after the stages they depend on
+func validatePipeline(stages []*pipelineStage) error {
+	positions := make(map[string]int, len(stages))
+	for position, stage := range stages {
+		spec, ok := pipelineStages[stage.Name]
+		if !ok && strings.Contains(stage.Name, " ") {
+			return fmt.Errorf("invalid stage %s, the options of a stage are a mapping of the flags it accepts (eg. scan: {top-ports: 1000})", stage.Name)
+		}
+		if !ok {
+			return fmt.Errorf("invalid stage %s (allowed: %s, %s, %s, %s, %s, %s)", stage.Name, StageDiscover, StageScan, StageVerify, StageBanner, StageEnrich, StageOutput)
+		}
+		if _, ok := positions[stage.Name]; ok {
+			return fmt.Errorf("stage %s given more than once", stage.Name)
+		}
+		positions[stage.Name] = position
+		for option := range stage.Options {
+			if !sliceutil.Contains(spec.flags, option) {
+				return fmt.Errorf("invalid option %s of stage %s (allowed: %s)", option, stage.Name, strings.Join(spec.flags, ", "))
+			}
+		}
+	}
+	_, hasDiscover := positions[StageDiscover]
+	if _, hasScan := positions[StageScan]; !hasScan && !hasDiscover {
+		return fmt.Errorf("pipeline requires a %s or %s stage", StageDiscover, StageScan)
+	}
+	for _, stage := range stages {
+		spec := pipelineStages[stage.Name]
+		for _, required := range spec.requires {
+			if _, ok := positions[required]; !ok {
+				return fmt.Errorf("stage %s requires a %s stage", stage.Name, required)
+			}
+		}
+		for _, previous := range spec.after {
+			if position, ok := positions[previous]; ok && position > positions[stage.Name] {
+				return fmt.Errorf("stage %s must come after stage %s", stage.Name, previous)
+			}
+		}
+	}
+	return nil
+}
+
+// pipelineFlags returns the flag values of the stages, including the flags set by the stages given
+// or missing
+func pipelineFlags(stages []*pipelineStage) map[string]string {
+	values := make(map[string]string)
+	given := make(map[string]struct{}, len(stages))
+	for _, stage := range stages {
+		given[stage.Name] = struct{}{}
+		for name, value := range pipelineStages[stage.Name].enables {
+			values[name] = value
+		}
+		for name, value := range stage.Options {
+			values[name] = value
+		}
+	}
+	for name, spec := range pipelineStages {
+		if _, ok := given[name]; ok {
+			continue
+		}
+		for flagName, value := range spec.disables {
+			values[flagName] = value
+		}
+	}
+	return values
+}
+
+// applyPipeline sets the flags of the pipeline stages, the flags given on the command line or in the
+// config file taking precedence
+func (options *Options) applyPipeline(flagSet *flag.FlagSet) error {
+	// the short and long names of a flag share the same value
+	given := make(map[flag.Value]struct{})
+	flagSet.Visit(func(f *flag.Flag) {
+		given[f.Value] = struct{}{}
+	})
+	return options.setPipelineFlags(flagSet, given)
+}
+
+// applyLibraryPipeline sets the flags of the pipeline stages on the options of the library, the
+// options changed from DefaultOptions taking precedence
+func (options *Options) applyLibraryPipeline() error {
+	work, defaults := *options, *DefaultOptions()
+	// binding the flags writes their defaults, the options are restored in the bound structs
+	flagSet, defaultFlags := newFlagSet(&work).CommandLine, newFlagSet(&defaults).CommandLine
+	work, defaults = *options, *DefaultOptions()
+	given := make(map[flag.Value]struct{})
+	flagSet.VisitAll(func(f *flag.Flag) {
+		if f.Value.String() != defaultFlags.Lookup(f.Name).Value.String() {
+			given[f.Value] = struct{}{}
+		}
+	})
+	if err := work.setPipelineFlags(flagSet, given); err != nil {
+		return err
+	}
+	*options = work
+	return nil
+}
+
+// setPipelineFlags sets the flags of the pipeline stages but the given ones
+func (options *Options) setPipelineFlags(flagSet *flag.FlagSet, given map[flag.Value]struct{}) error {
+	name, stages, err := loadPipeline(options.Pipeline, options.PipelineName)
+	if err != nil {
+		return err
+	}
+	for flagName, value := range pipelineFlags(stages) {
+		f := flagSet.Lookup(flagName)
+		if f == nil {
+			return fmt.Errorf("invalid pipeline flag %s", flagName)
+		}
+		if _, ok := given[f.Value]; ok {
+			continue
+		}
+		if err := flagSet.Set(flagName, value); err != nil {
+			return fmt.Errorf("invalid value %s of %s: %w", value, flagName, err)
+		}
+	}
+	names := make([]string, 0, len(stages))
+	for _, stage := range stages {
+		names = append(names, stage.Name)
+	}
+	gologger.Info().Msgf("Running pipeline %s: %s\n", name, strings.Join(names, " > "))
+	return nil
+}
@@ -0,0 +1,108 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testPipelines = `pipelines:
+  weekly:
+    - discover:
+        probe-tcp-syn: [80, 443]
+    - scan:
+        top-ports: 1000
+        rate: 500
+    - verify
+    - banner:
+        banner-timeout: 2000
+    - output:
+        json: true
+        webhook-url: https://hooks.example.com/naabu
+  alive:
+    - discover
+    - output
+`
+
+func TestLoadPipeline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pipelines.yaml")
+	require.Nil(t, os.WriteFile(path, []byte(testPipelines), 0600))
+
+	name, stages, err := loadPipeline(path, "weekly")
+	require.Nil(t, err)
+	require.Equal(t, "weekly", name)
+	require.Len(t, stages, 5)
+	require.Equal(t, StageScan, stages[1].Name)
+	require.Equal(t, "80,443", stages[0].Options["probe-tcp-syn"])
+
+	_, _, err = loadPipeline(path, "")
+	require.ErrorContains(t, err, "allowed: alive, weekly")
+	_, _, err = loadPipeline(path, "daily")
+	require.NotNil(t, err)
+}
+
+func TestValidatePipeline(t *testing.T) {
+	require.Nil(t, validatePipeline([]*pipelineStage{{Name: StageScan}, {Name: StageVerify}}))
+	require.ErrorContains(t, validatePipeline([]*pipelineStage{{Name: StageVerify}, {Name: StageScan}}), "must come after")
+	require.ErrorContains(t, validatePipeline([]*pipelineStage{{Name: StageDiscover}, {Name: StageBanner}}), "requires a scan stage")
+	require.ErrorContains(t, validatePipeline([]*pipelineStage{{Name: StageOutput}}), "requires a discover or scan stage")
+	require.ErrorContains(t, validatePipeline([]*pipelineStage{{Name: StageScan}, {Name: StageScan}}), "more than once")
+	require.ErrorContains(t, validatePipeline([]*pipelineStage{{Name: "geo"}}), "invalid stage")
+	require.ErrorContains(t, validatePipeline([]*pipelineStage{{Name: "enrich geo"}}), "mapping of the flags")
+	require.ErrorContains(t, validatePipeline([]*pipelineStage{{Name: StageScan, Options: map[string]string{"verify": "true"}}}), "invalid option verify")
+}
+
+func TestApplyPipeline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pipelines.yaml")
+	require.Nil(t, os.WriteFile(path, []byte(testPipelines), 0600))
+
+	options := &Options{}
+	flagSet := newFlagSet(options)
+	// flags given on the command line take precedence, short names included
+	require.Nil(t, flagSet.CommandLine.Parse([]string{"-tp", "100"}))
+	options.Pipeline, options.PipelineName = path, "weekly"
+	require.Nil(t, options.applyPipeline(flagSet.CommandLine))
+
+	require.Equal(t, "100", options.TopPorts)
+	require.Equal(t, 500, options.Rate)
+	require.True(t, options.Verify)
+	require.True(t, options.Banner)
+	require.Equal(t, 2000, options.BannerTimeout)
+	require.True(t, options.JSON)
+	require.False(t, options.SkipHostDiscovery)
+	require.False(t, options.OnlyHostDiscovery)
+
+	options = &Options{}
+	flagSet = newFlagSet(options)
+	options.Pipeline, options.PipelineName = path, "alive"
+	require.Nil(t, options.applyPipeline(flagSet.CommandLine))
+	require.True(t, options.OnlyHostDiscovery)
+	require.False(t, options.Verify)
+}
+
+func TestApplyLibraryPipeline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pipelines.yaml")
+	require.Nil(t, os.WriteFile(path, []byte(testPipelines), 0600))
+
+	// the options changed from their default take precedence
+	options := DefaultOptions()
+	WithPipeline(path, "weekly")(options)
+	options.Rate = 2000
+	options.Host = []string{"scanme.sh"}
+	require.Nil(t, options.applyLibraryPipeline())
+
+	require.Equal(t, "1000", options.TopPorts)
+	require.Equal(t, 2000, options.Rate)
+	require.True(t, options.Verify)
+	require.Equal(t, 2000, options.BannerTimeout)
+	require.True(t, options.JSON)
+	require.Equal(t, []string{"scanme.sh"}, []string(options.Host))
+	require.Equal(t, DefaultBannerThreads, options.BannerThreads)
+
+	options = DefaultOptions()
+	WithPipeline(path, "alive")(options)
+	require.Nil(t, options.applyLibraryPipeline())
+	require.True(t, options.OnlyHostDiscovery)
+}
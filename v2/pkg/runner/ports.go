@@ -3,15 +3,36 @@ package runner
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
+	"github.com/projectdiscovery/gologger"
 	"github.com/projectdiscovery/naabu/v2/pkg/port"
 	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
 )
 
-const portListStrParts = 2
+const (
+	portListStrParts = 2
+	maxPort          = 65535
+)
+
+// udpOnlyPorts are well-known services only reachable over udp
+var udpOnlyPorts = map[int]string{
+	67:   "dhcp",
+	68:   "dhcp",
+	69:   "tftp",
+	123:  "ntp",
+	137:  "netbios-ns",
+	138:  "netbios-dgm",
+	161:  "snmp",
+	162:  "snmptrap",
+	500:  "ike",
+	1900: "ssdp",
+	4500: "ipsec-nat-t",
+	5353: "mdns",
+}
 
 // List of default ports
 const (
@@ -22,7 +43,19 @@ const (
 
 // ParsePorts parses the list of ports and creates a port map
 func ParsePorts(options *Options) ([]*port.Port, error) {
-	var portsFileMap, portsCLIMap, topPortsCLIMap, portsConfigList []*port.Port
+	ports, err := parsePortSelection(options)
+	if err != nil {
+		return nil, err
+	}
+	return ports.Ports(), nil
+}
+
+// parsePortSelection parses the ports to scan as runs of consecutive numbers, without allocating
+// each port of large ranges (eg. -p - over tcp and udp)
+func parsePortSelection(options *Options) (*port.List, error) {
+	var portsFileMap, portsCLIMap, topPortsCLIMap, portsConfigList *port.List
+	// listed are the single ports named in the port file and flag, the ranges and presets being ignored by the warnings
+	listed := &port.List{}
 
 	// If the user has specfied a ports file, use it
 	if options.PortsFile != "" {
@@ -30,10 +63,11 @@ func ParsePorts(options *Options) ([]*port.Port, error) {
 		if err != nil {
 			return nil, fmt.Errorf("could not read ports: %s", err)
 		}
-		ports, err := parsePortsList(string(data))
+		ports, err := parsePortRanges(string(data))
 		if err != nil {
 			return nil, fmt.Errorf("could not read ports: %s", err)
 		}
+		listed.AddList(listedPorts(string(data)))
 		portsFileMap, err = excludePorts(options, ports)
 		if err != nil {
 			return nil, fmt.Errorf("could not read ports: %s", err)
@@ -45,7 +79,7 @@ func ParsePorts(options *Options) ([]*port.Port, error) {
 		switch strings.ToLower(options.TopPorts) {
 		case "full": // If the user has specfied full ports, use them
 			var err error
-			ports, err := parsePortsList(Full)
+			ports, err := parsePortRanges(Full)
 			if err != nil {
 				return nil, fmt.Errorf("could not read ports: %s", err)
 			}
@@ -54,7 +88,7 @@ func ParsePorts(options *Options) ([]*port.Port, error) {
 				return nil, fmt.Errorf("could not read ports: %s", err)
 			}
 		case "100": // If the user has specfied 100, use them
-			ports, err := parsePortsList(NmapTop100)
+			ports, err := parsePortRanges(NmapTop100)
 			if err != nil {
 				return nil, fmt.Errorf("could not read ports: %s", err)
 			}
@@ -63,7 +97,7 @@ func ParsePorts(options *Options) ([]*port.Port, error) {
 				return nil, fmt.Errorf("could not read ports: %s", err)
 			}
 		case "1000": // If the user has specfied 1000, use them
-			ports, err := parsePortsList(NmapTop1000)
+			ports, err := parsePortRanges(NmapTop1000)
 			if err != nil {
 				return nil, fmt.Errorf("could not read ports: %s", err)
 			}
@@ -83,73 +117,82 @@ func ParsePorts(options *Options) ([]*port.Port, error) {
 			// Parse the custom ports list provided by the user
 			options.Ports = "1-65535"
 		}
-		ports, err := parsePortsList(options.Ports)
+		ports, err := parsePortRanges(options.Ports)
 		if err != nil {
 			return nil, fmt.Errorf("could not read ports: %s", err)
 		}
+		listed.AddList(listedPorts(options.Ports))
 		portsCLIMap, err = excludePorts(options, ports)
 		if err != nil {
 			return nil, fmt.Errorf("could not read ports: %s", err)
 		}
 	}
 
+	if options.ScanType != UDPScan {
+		warnUDPOnlyPorts(merge(portsFileMap, portsCLIMap), listed)
+	}
+	warnReservedPorts(merge(portsFileMap, portsCLIMap), listed)
+
 	// merge all the specified ports (meaningless if "all" is used)
 	ports := merge(portsFileMap, portsCLIMap, topPortsCLIMap, portsConfigList)
 
 	// By default scan top 100 ports only
-	if len(ports) == 0 {
-		portsList, err := parsePortsList(NmapTop100)
+	if ports.Len() == 0 {
+		portsList, err := parsePortRanges(NmapTop100)
 		if err != nil {
 			return nil, fmt.Errorf("could not read ports: %s", err)
 		}
-		m, err := excludePorts(options, portsList)
+		ports, err = excludePorts(options, portsList)
 		if err != nil {
 			return nil, err
 		}
-		return m, nil
 	}
 
+	// udp scans probe every port over udp
+	if options.ScanType == UDPScan {
+		return asUDP(ports), nil
+	}
 	return ports, nil
 }
 
 // excludePorts excludes the list of ports from the exclusion list
-func excludePorts(options *Options, ports []*port.Port) ([]*port.Port, error) {
+func excludePorts(options *Options, ports *port.List) (*port.List, error) {
 	if options.ExcludePorts == "" {
 		return ports, nil
 	}
 
-	var filteredPorts []*port.Port
-
 	// Exclude the ports specified by the user in exclusion list
-	excludedPortsCLI, err := parsePortsList(options.ExcludePorts)
+	excludedPortsCLI, err := parsePortRanges(options.ExcludePorts)
 	if err != nil {
 		return nil, fmt.Errorf("could not read exclusion ports: %s", err)
 	}
 
-	for _, port := range ports {
-		found := false
-		for _, excludedPort := range excludedPortsCLI {
-			if excludedPort.Port == port.Port && excludedPort.Protocol == port.Protocol {
-				found = true
-				break
-			}
-		}
-		if !found {
-			filteredPorts = append(filteredPorts, port)
-		}
-	}
-	return filteredPorts, nil
+	return ports.Without(excludedPortsCLI), nil
 }
 
-func parsePortsSlice(ranges []string) ([]*port.Port, error) {
-	var ports []*port.Port
+// parsePortsSlice parses the port segments, skipping the duplicated ports
+func parsePortsSlice(ranges []string) (*port.List, error) {
+	ports := &port.List{}
 	for _, r := range ranges {
 		r = strings.TrimSpace(r)
+		// tolerate trailing and repeated separators
+		if r == "" {
+			continue
+		}
+
+		portProtocol, r, err := parsePortProtocol(r)
+		if err != nil {
+			return nil, err
+		}
 
-		portProtocol := protocol.TCP
-		if strings.HasPrefix(r, "u:") {
-			portProtocol = protocol.UDP
-			r = strings.TrimPrefix(r, "u:")
+		// service names may contain dashes (ms-wbt-server), they are looked up before ranges
+		if port.IsService(r) {
+			portNumber, err := parsePortService(r, portProtocol)
+			if err != nil {
+				return nil, err
+			}
+			ports.Add(portNumber, portProtocol)
+			continue
 		}
 
 		if strings.Contains(r, "-") {
@@ -158,56 +201,146 @@ func parsePortsSlice(ranges []string) ([]*port.Port, error) {
 				return nil, fmt.Errorf("invalid port selection segment: '%s'", r)
 			}
 
-			p1, err := strconv.Atoi(parts[0])
+			p1, err := parsePortNumber(parts[0])
 			if err != nil {
-				return nil, fmt.Errorf("invalid port number: '%s'", parts[0])
+				return nil, err
 			}
 
-			p2, err := strconv.Atoi(parts[1])
+			p2, err := parsePortNumber(parts[1])
 			if err != nil {
-				return nil, fmt.Errorf("invalid port number: '%s'", parts[1])
+				return nil, err
 			}
 
 			if p1 > p2 {
 				return nil, fmt.Errorf("invalid port range: %d-%d", p1, p2)
 			}
 
-			for i := p1; i <= p2; i++ {
-				port := &port.Port{Port: i, Protocol: portProtocol}
-				ports = append(ports, port)
-			}
+			ports.AddRange(p1, p2, portProtocol)
 		} else {
-			portNumber, err := strconv.Atoi(r)
+			portNumber, err := parsePortNumber(r)
 			if err != nil {
-				return nil, fmt.Errorf("invalid port number: '%s'", r)
+				return nil, err
 			}
-			port := &port.Port{Port: portNumber, Protocol: portProtocol}
-			ports = append(ports, port)
+			ports.Add(portNumber, portProtocol)
 		}
 	}
+	return ports, nil
+}
 
-	// dedupe ports
-	seen := make(map[string]struct{})
-	var dedupedPorts []*port.Port
-	for _, port := range ports {
-		if _, ok := seen[port.String()]; ok {
+// parsePortProtocol returns the protocol of a port segment prefixed with u: / udp: or t: / tcp: (default tcp)
+func parsePortProtocol(segment string) (protocol.Protocol, string, error) {
+	prefix, value, ok := strings.Cut(segment, ":")
+	if !ok {
+		return protocol.TCP, segment, nil
+	}
+	switch strings.ToLower(prefix) {
+	case "u", "udp":
+		return protocol.UDP, value, nil
+	case "t", "tcp":
+		return protocol.TCP, value, nil
+	default:
+		return protocol.TCP, segment, fmt.Errorf("invalid protocol '%s' in port '%s' (use u: for udp ports, eg. u:53)", prefix, segment)
+	}
+}
+
+// parsePortService returns the port number of a service name of the services database (ssh, https, u:snmp)
+func parsePortService(name string, portProtocol protocol.Protocol) (int, error) {
+	if portNumber, ok := port.ServicePort(name, portProtocol); ok {
+		return portNumber, nil
+	}
+	if portProtocol == protocol.TCP {
+		return 0, fmt.Errorf("service '%s' is udp only (use u:%s)", name, name)
+	}
+	return 0, fmt.Errorf("service '%s' is tcp only (use %s)", name, name)
+}
+
+// parsePortNumber parses a port number in the valid port range
+func parsePortNumber(value string) (int, error) {
+	portNumber, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid port number: '%s'", value)
+	}
+	if portNumber < 0 || portNumber > maxPort {
+		return 0, fmt.Errorf("invalid port number: '%s' (must be in the 0-%d range)", value, maxPort)
+	}
+	return portNumber, nil
+}
+
+// listedPorts returns the single ports and services of the comma separated selection, without its ranges
+func listedPorts(data string) *port.List {
+	var singles []string
+	for _, segment := range strings.Split(data, ",") {
+		_, value, err := parsePortProtocol(strings.TrimSpace(segment))
+		if err != nil || (strings.Contains(value, "-") && !port.IsService(value)) {
 			continue
 		}
-		seen[port.String()] = struct{}{}
-		dedupedPorts = append(dedupedPorts, port)
+		singles = append(singles, segment)
+	}
+	listed, err := parsePortsSlice(singles)
+	if err != nil {
+		return &port.List{}
 	}
+	return listed
+}
 
-	return dedupedPorts, nil
+// warnUDPOnlyPorts warns when well-known udp only services are explicitly listed in a tcp only scan
+func warnUDPOnlyPorts(ports, listed *port.List) {
+	if ports.HasProtocol(protocol.UDP) {
+		return
+	}
+	var udpOnly []int
+	for portNumber := range udpOnlyPorts {
+		if listed.Contains(portNumber, protocol.TCP) && ports.Contains(portNumber, protocol.TCP) {
+			udpOnly = append(udpOnly, portNumber)
+		}
+	}
+	if len(udpOnly) == 0 {
+		return
+	}
+	sort.Ints(udpOnly)
+	services := make([]string, 0, len(udpOnly))
+	for _, portNumber := range udpOnly {
+		services = append(services, fmt.Sprintf("%d (%s)", portNumber, udpOnlyPorts[portNumber]))
+	}
+	gologger.Warning().Msgf("Ports %s are usually udp only and are scanned over tcp, use the u: prefix to scan them over udp (eg. u:%d)\n", strings.Join(services, ", "), udpOnly[0])
+}
+
+// warnReservedPorts reports the explicitly requested ports reserved by IANA, whose results are labeled
+func warnReservedPorts(ports, listed *port.List) {
+	var reserved []string
+	for portNumber := 0; portNumber <= maxPort; portNumber++ {
+		if !port.IsReserved(portNumber) {
+			continue
+		}
+		for _, portProtocol := range []protocol.Protocol{protocol.TCP, protocol.UDP} {
+			if listed.Contains(portNumber, portProtocol) && ports.Contains(portNumber, portProtocol) {
+				reserved = append(reserved, strconv.Itoa(portNumber))
+				break
+			}
+		}
+	}
+	if len(reserved) > 0 {
+		gologger.Info().Msgf("Probing reserved ports %s, their json results are labeled reserved\n", strings.Join(reserved, ", "))
+	}
 }
 
 func parsePortsList(data string) ([]*port.Port, error) {
+	ports, err := parsePortRanges(data)
+	if err != nil {
+		return nil, err
+	}
+	return ports.Ports(), nil
+}
+
+// parsePortRanges parses the comma separated ports and ranges
+func parsePortRanges(data string) (*port.List, error) {
 	return parsePortsSlice(strings.Split(data, ","))
 }
 
-func merge(slices ...[]*port.Port) []*port.Port {
-	var result []*port.Port
-	for _, slice := range slices {
-		result = append(result, slice...)
+func merge(lists ...*port.List) *port.List {
+	result := &port.List{}
+	for _, list := range lists {
+		result.AddList(list)
 	}
 	return result
 }
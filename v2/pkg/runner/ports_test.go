@@ -19,6 +19,11 @@ func TestParsePortsList(t *testing.T) {
 		{"1-3,10", []*port.Port{{Port: 1, Protocol: protocol.TCP}, {Port: 2, Protocol: protocol.TCP}, {Port: 3, Protocol: protocol.TCP}, {Port: 10, Protocol: protocol.TCP}}, false},
 		{"17,17,17,18", []*port.Port{{Port: 17, Protocol: protocol.TCP}, {Port: 18, Protocol: protocol.TCP}}, false},
 		{"a", nil, true},
+		{"80,443,", []*port.Port{{Port: 80, Protocol: protocol.TCP}, {Port: 443, Protocol: protocol.TCP}}, false},
+		{"u:53,U:123,udp:161,t:22", []*port.Port{{Port: 53, Protocol: protocol.UDP}, {Port: 123, Protocol: protocol.UDP}, {Port: 161, Protocol: protocol.UDP}, {Port: 22, Protocol: protocol.TCP}}, false},
+		{"80,443,999999", nil, true},
+		{"65530-65536", nil, true},
+		{"x:53", nil, true},
 	}
 	for _, tt := range tests {
 		t.Run("", func(t *testing.T) {
@@ -36,10 +41,10 @@ func TestParsePortsList(t *testing.T) {
 
 func TestExcludePorts(t *testing.T) {
 	var options Options
-	ports := []*port.Port{
-		{Port: 1, Protocol: protocol.TCP},
-		{Port: 10, Protocol: protocol.TCP},
-	}
+	ports := port.NewList(
+		&port.Port{Port: 1, Protocol: protocol.TCP},
+		&port.Port{Port: 10, Protocol: protocol.TCP},
+	)
 
 	// no filtering
 	filteredPorts, err := excludePorts(&options, ports)
@@ -58,7 +63,7 @@ func TestExcludePorts(t *testing.T) {
 	expectedPorts := []*port.Port{
 		{Port: 10, Protocol: protocol.TCP},
 	}
-	assert.EqualValues(t, expectedPorts, filteredPorts)
+	assert.EqualValues(t, expectedPorts, filteredPorts.Ports())
 }
 
 func TestParsePorts(t *testing.T) {
@@ -115,4 +120,49 @@ func TestParsePorts(t *testing.T) {
 	got, err := ParsePorts(&Options{})
 	assert.Nil(t, err)
 	assert.Equal(t, 100, len(got))
+
+	// udp scans probe every port over udp once
+	got, err = ParsePorts(&Options{Ports: "53,u:53,161", ScanType: UDPScan})
+	assert.Nil(t, err)
+	assert.Equal(t, []*port.Port{{Port: 53, Protocol: protocol.UDP}, {Port: 161, Protocol: protocol.UDP}}, got)
+}
+
+func TestParsePortErrors(t *testing.T) {
+	_, err := parsePortsList("80,443,999999")
+	assert.EqualError(t, err, "invalid port number: '999999' (must be in the 0-65535 range)")
+
+	_, err = parsePortsList("x:53")
+	assert.EqualError(t, err, "invalid protocol 'x' in port 'x:53' (use u: for udp ports, eg. u:53)")
+}
+
+func TestParsePortServices(t *testing.T) {
+	got, err := parsePortsList("ssh,HTTP,https,rdp,8000-8001,ms-wbt-server,u:snmp,udp:dns")
+	assert.Nil(t, err)
+	assert.Equal(t, []*port.Port{
+		{Port: 22, Protocol: protocol.TCP},
+		{Port: 80, Protocol: protocol.TCP},
+		{Port: 443, Protocol: protocol.TCP},
+		{Port: 3389, Protocol: protocol.TCP},
+		{Port: 8000, Protocol: protocol.TCP},
+		{Port: 8001, Protocol: protocol.TCP},
+		{Port: 161, Protocol: protocol.UDP},
+		{Port: 53, Protocol: protocol.UDP},
+	}, got)
+
+	_, err = parsePortsList("snmp")
+	assert.EqualError(t, err, "service 'snmp' is udp only (use u:snmp)")
+	_, err = parsePortsList("u:ssh")
+	assert.EqualError(t, err, "service 'ssh' is tcp only (use ssh)")
+	_, err = parsePortsList("sshh")
+	assert.EqualError(t, err, "invalid port number: 'sshh'")
+}
+
+func TestListedPorts(t *testing.T) {
+	listed := listedPorts("53,u:161,1-1024,ms-wbt-server,t:0-10")
+	assert.True(t, listed.Contains(53, protocol.TCP))
+	assert.True(t, listed.Contains(161, protocol.UDP))
+	assert.True(t, listed.Contains(3389, protocol.TCP))
+	assert.False(t, listed.Contains(80, protocol.TCP), "ports of ranges are not listed")
+	assert.False(t, listed.Contains(0, protocol.TCP))
+	assert.Equal(t, 0, listedPorts("1-65535").Len())
 }
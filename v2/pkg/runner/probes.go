@@ -0,0 +1,111 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/scan"
+)
+
+// probeStallTimeout is the time after which a non draining send queue marks the scanner as wedged
+const probeStallTimeout = 30 * time.Second
+
+// probeStatus is the json body returned by the liveness and readiness endpoints
+type probeStatus struct {
+	Status      string    `json:"status"`
+	Reason      string    `json:"reason,omitempty"`
+	Phase       string    `json:"phase"`
+	Readers     int       `json:"pcap_readers"`
+	Backlog     int       `json:"backlog"`
+	BacklogSize int       `json:"backlog_size"`
+	LastSent    time.Time `json:"last_sent,omitempty"`
+}
+
+// startProbes serves the liveness and readiness endpoints on the configured address
+func (r *Runner) startProbes() error {
+	listener, err := net.Listen("tcp", r.options.ProbesAddr)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", r.handleLiveness)
+	mux.HandleFunc("/readyz", r.handleReadiness)
+	r.probesServer = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		if err := r.probesServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			gologger.Warning().Msgf("Probes server stopped: %s\n", err)
+		}
+	}()
+
+	return nil
+}
+
+func (r *Runner) handleLiveness(w http.ResponseWriter, _ *http.Request) {
+	writeProbeStatus(w, r.liveness())
+}
+
+func (r *Runner) handleReadiness(w http.ResponseWriter, _ *http.Request) {
+	writeProbeStatus(w, r.readiness())
+}
+
+// liveness fails when the pcap readers died during a raw scan or the send queue stopped draining
+func (r *Runner) liveness() *probeStatus {
+	status := r.newProbeStatus()
+	phase := r.scanner.Phase.Get()
+	isActive := phase == scan.HostDiscovery || phase == scan.Scan
+
+	switch {
+	case isActive && r.options.shouldUseRawPackets() && status.Readers == 0:
+		status.Reason = "no pcap reader is running"
+	case status.Backlog > 0 && !status.LastSent.IsZero() && time.Since(status.LastSent) > probeStallTimeout:
+		status.Reason = "send queue is not draining"
+	}
+
+	return status
+}
+
+// readiness fails until the targets have been loaded or while the send queue is saturated
+func (r *Runner) readiness() *probeStatus {
+	status := r.newProbeStatus()
+
+	switch {
+	case r.scanner.Phase.Is(scan.Init):
+		status.Reason = "targets are being loaded"
+	case status.BacklogSize > 0 && status.Backlog >= status.BacklogSize:
+		status.Reason = "send queue is full"
+	}
+
+	return status
+}
+
+func (r *Runner) newProbeStatus() *probeStatus {
+	health := r.scanner.Health()
+	return &probeStatus{
+		Phase:       r.scanner.Phase.Get().String(),
+		Readers:     health.Readers,
+		Backlog:     health.Backlog,
+		BacklogSize: health.BacklogSize,
+		LastSent:    health.LastSent,
+	}
+}
+
+func writeProbeStatus(w http.ResponseWriter, status *probeStatus) {
+	w.Header().Set("Content-Type", "application/json")
+	if status.Reason != "" {
+		status.Status = "fail"
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		status.Status = "ok"
+		w.WriteHeader(http.StatusOK)
+	}
+	_ = json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,52 @@
+package runner
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/scan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbes(t *testing.T) {
+	r := &Runner{options: &Options{ScanType: ConnectScan}, scanner: &scan.Scanner{}}
+
+	get := func(handler http.HandlerFunc) int {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, get(r.handleLiveness))
+	assert.Equal(t, http.StatusServiceUnavailable, get(r.handleReadiness), "targets are still loading")
+
+	assert.Nil(t, r.scanner.Phase.Transition(scan.Scan))
+	assert.Equal(t, http.StatusOK, get(r.handleLiveness))
+	assert.Equal(t, http.StatusOK, get(r.handleReadiness))
+}
+
+func TestProbesClosedOnError(t *testing.T) {
+	probes, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	probesAddr := probes.Addr().String()
+	require.Nil(t, probes.Close())
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer busy.Close()
+
+	// the probes server is closed when the results server can't listen
+	_, err = NewRunner(&Options{ProbesAddr: probesAddr, ResultsAddr: busy.Addr().String()})
+	require.ErrorContains(t, err, "results server")
+	require.Eventually(t, func() bool {
+		listener, err := net.Listen("tcp", probesAddr)
+		if err != nil {
+			return false
+		}
+		_ = listener.Close()
+		return true
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,68 @@
+package runner
+
+import (
+	"os"
+	"runtime"
+	"runtime/pprof"
+
+	"github.com/projectdiscovery/gologger"
+)
+
+// profiler records the cpu profile of the runner from its creation until it is closed, and a
+// heap profile once it is closed, to be inspected with go tool pprof
+type profiler struct {
+	cpu     *os.File
+	memPath string
+}
+
+// startProfiler starts the cpu profile if a path is given
+func startProfiler(cpuPath, memPath string) (*profiler, error) {
+	p := &profiler{memPath: memPath}
+	if cpuPath == "" {
+		return p, nil
+	}
+	f, err := os.Create(cpuPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	p.cpu = f
+	return p, nil
+}
+
+// stop stops the cpu profile and writes the heap profile
+func (p *profiler) stop() error {
+	if p == nil {
+		return nil
+	}
+	if p.cpu != nil {
+		pprof.StopCPUProfile()
+		if err := p.cpu.Close(); err != nil {
+			return err
+		}
+		p.cpu = nil
+	}
+	if p.memPath == "" {
+		return nil
+	}
+	f, err := os.Create(p.memPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	p.memPath = ""
+	// the heap profile reflects the memory still in use rather than the garbage left by the scan
+	runtime.GC()
+	return pprof.WriteHeapProfile(f)
+}
+
+// stopProfiler writes the profiles requested with -profile-cpu and -profile-mem
+func (r *Runner) stopProfiler() {
+	if err := r.profiler.stop(); err != nil {
+		gologger.Warning().Msgf("Could not write profile: %s\n", err)
+		r.recordError(ErrorOutput, "", err)
+	}
+}
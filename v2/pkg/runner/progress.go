@@ -0,0 +1,153 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+)
+
+// progressSnapshot is a json line of the progress file
+type progressSnapshot struct {
+	Timestamp time.Time                 `json:"timestamp"`
+	Phase     string                    `json:"phase"`
+	Percent   float64                   `json:"percent"`
+	PPS       float64                   `json:"pps"`
+	Sent      uint64                    `json:"sent"`
+	Drops     uint64                    `json:"drops"`
+	Found     int                       `json:"found"`
+	Hosts     int                       `json:"hosts"`
+	Stages    map[string]*stageSnapshot `json:"stages,omitempty"`
+	Resources *resourceUsage            `json:"resources,omitempty"`
+}
+
+// progressFile appends periodic progress snapshots to a json lines file, so that schedulers running
+// many scans can monitor them without parsing the logs
+type progressFile struct {
+	sync.Mutex
+	file     *os.File
+	encoder  *json.Encoder
+	total    atomic.Uint64 // host:port pairs of all the passes
+	done     atomic.Uint64 // host:port pairs probed or skipped
+	lastSent uint64
+	lastTime time.Time
+	closed   bool
+}
+
+func newProgressFile(path string) (*progressFile, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	// each snapshot is encoded with a single write, readers never see partial lines
+	return &progressFile{file: file, encoder: json.NewEncoder(file), lastTime: time.Now()}, nil
+}
+
+// setTotal sets the number of host:port pairs of the scan
+func (p *progressFile) setTotal(total uint64) {
+	if p != nil {
+		p.total.Store(total)
+	}
+}
+
+// addTotal adds host:port pairs to the scan, the targets of a stream being counted as they are read
+func (p *progressFile) addTotal(pairs uint64) {
+	if p != nil {
+		p.total.Add(pairs)
+	}
+}
+
+// advance adds the host:port pairs the scan went through
+func (p *progressFile) advance(pairs uint64) {
+	if p != nil {
+		p.done.Add(pairs)
+	}
+}
+
+// percent returns the completion of the port scan, rounded to a tenth
+func (p *progressFile) percent() float64 {
+	total := p.total.Load()
+	if total == 0 {
+		return 0
+	}
+	percent := math.Min(100, float64(p.done.Load())/float64(total)*100)
+	return math.Round(percent*10) / 10
+}
+
+// startProgress writes a progress snapshot at each interval until the context is done
+func (r *Runner) startProgress(ctx context.Context) {
+	if r.progress == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(r.options.ProgressInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := r.writeProgress(); err != nil {
+					gologger.Warning().Msgf("Could not write progress file: %s\n", err)
+				}
+			}
+		}
+	}()
+}
+
+// writeProgress appends a snapshot of the scan progress, the packets per second being measured
+// since the previous snapshot
+func (r *Runner) writeProgress() error {
+	p := r.progress
+	p.Lock()
+	defer p.Unlock()
+
+	if p.closed {
+		return nil
+	}
+	now, sent := time.Now(), r.probesSent.Load()
+	snapshot := &progressSnapshot{
+		Timestamp: now.UTC(),
+		Phase:     r.scanner.Phase.Get().String(),
+		Percent:   p.percent(),
+		Sent:      sent,
+		Drops:     r.scanner.PcapDrops(),
+		Found:     r.scanner.ScanResults.PortCount(),
+		Hosts:     r.scanner.ScanResults.Len(),
+		Stages:    r.stages.snapshot(),
+		Resources: r.resourceUsage(),
+	}
+	if elapsed := now.Sub(p.lastTime).Seconds(); elapsed > 0 {
+		snapshot.PPS = math.Round(float64(sent-p.lastSent) / elapsed)
+	}
+	p.lastSent, p.lastTime = sent, now
+	return p.encoder.Encode(snapshot)
+}
+
+// closeProgress writes the final snapshot and closes the progress file
+func (r *Runner) closeProgress() error {
+	if r.progress == nil {
+		return nil
+	}
+	err := r.writeProgress()
+
+	p := r.progress
+	p.Lock()
+	defer p.Unlock()
+
+	if p.closed {
+		return err
+	}
+	p.closed = true
+	if closeErr := p.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,62 @@
+package runner
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+	"github.com/projectdiscovery/naabu/v2/pkg/scan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgressFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.jsonl")
+	progress, err := newProgressFile(path)
+	require.Nil(t, err)
+
+	r := &Runner{options: &Options{}, scanner: &scan.Scanner{ScanResults: result.NewResult()}, progress: progress}
+	require.Nil(t, r.scanner.Phase.Transition(scan.Scan))
+
+	progress.setTotal(200)
+	progress.advance(50)
+	r.probesSent.Add(50)
+	r.scanner.ScanResults.AddPort("192.0.2.1", &port.Port{Port: 443, Protocol: protocol.TCP})
+	require.Nil(t, r.writeProgress())
+
+	progress.advance(150)
+	require.Nil(t, r.closeProgress())
+	// closing again doesn't write anything
+	require.Nil(t, r.closeProgress())
+
+	file, err := os.Open(path)
+	require.Nil(t, err)
+	defer file.Close()
+
+	var snapshots []progressSnapshot
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var snapshot progressSnapshot
+		require.Nil(t, json.Unmarshal(scanner.Bytes(), &snapshot))
+		snapshots = append(snapshots, snapshot)
+	}
+	require.Len(t, snapshots, 2)
+	require.Equal(t, "scan", snapshots[0].Phase)
+	require.Equal(t, 25.0, snapshots[0].Percent)
+	require.Equal(t, uint64(50), snapshots[0].Sent)
+	require.Equal(t, 1, snapshots[0].Found)
+	require.Equal(t, 1, snapshots[0].Hosts)
+	require.Equal(t, 100.0, snapshots[1].Percent)
+
+	// the total of a stream grows with the targets read
+	stream := &progressFile{}
+	stream.addTotal(10)
+	stream.advance(5)
+	require.Equal(t, 50.0, stream.percent())
+	stream.addTotal(10)
+	require.Equal(t, 25.0, stream.percent())
+}
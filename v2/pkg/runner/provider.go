@@ -0,0 +1,198 @@
+package runner
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/projectdiscovery/mapcidr/asn"
+	"github.com/projectdiscovery/retryablehttp-go"
+	iputil "github.com/projectdiscovery/utils/ip"
+	readerutil "github.com/projectdiscovery/utils/reader"
+)
+
+// TargetProvider is a lazily evaluated source of targets consumed by the runner while loading targets.
+// Next returns io.EOF once the provider is exhausted.
+type TargetProvider interface {
+	Next() (Target, error)
+}
+
+// TargetProviderFunc adapts a function to a TargetProvider
+type TargetProviderFunc func() (Target, error)
+
+// Next returns the next target
+func (f TargetProviderFunc) Next() (Target, error) {
+	return f()
+}
+
+// ParseTarget classifies a host, ip, cidr, asn or host:port target
+func ParseTarget(value string) Target {
+	value = strings.TrimSpace(value)
+	switch {
+	case asn.IsASN(value):
+		return Target{Asn: value}
+	case iputil.IsCIDR(value):
+		return Target{Cidr: value}
+	case iputil.IsIP(value):
+		return Target{Ip: value}
+	}
+	host, port, _ := getPort(value)
+	if iputil.IsIP(host) {
+		return Target{Ip: host, Port: port}
+	}
+	return Target{Fqdn: host, Port: port}
+}
+
+// String returns the target in the input format of the runner
+func (t Target) String() string {
+	switch {
+	case t.Asn != "":
+		return t.Asn
+	case t.Cidr != "":
+		return t.Cidr
+	case t.Ip != "":
+		return joinHostPort(t.Ip, t.Port)
+	default:
+		return joinHostPort(t.Fqdn, t.Port)
+	}
+}
+
+// readerTargetProvider returns the targets of a reader, one per line
+type readerTargetProvider struct {
+	scanner *bufio.Scanner
+	closer  io.Closer
+}
+
+// NewReaderTargetProvider returns the targets of a reader, one per line
+func NewReaderTargetProvider(reader io.Reader) TargetProvider {
+	provider := &readerTargetProvider{scanner: bufio.NewScanner(reader)}
+	if closer, ok := reader.(io.Closer); ok {
+		provider.closer = closer
+	}
+	return provider
+}
+
+// NewFileTargetProvider returns the targets of a file, one per line
+func NewFileTargetProvider(path string) (TargetProvider, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewReaderTargetProvider(f), nil
+}
+
+// NewStdinTargetProvider returns the targets piped to stdin, one per line
+func NewStdinTargetProvider(timeout time.Duration) TargetProvider {
+	return NewReaderTargetProvider(readerutil.TimeoutReader{Reader: os.Stdin, Timeout: timeout})
+}
+
+// Next returns the target of the next non empty line, closing the reader once exhausted
+func (p *readerTargetProvider) Next() (Target, error) {
+	for p.scanner.Scan() {
+		if line := strings.TrimSpace(p.scanner.Text()); line != "" {
+			return ParseTarget(line), nil
+		}
+	}
+	if p.closer != nil {
+		p.closer.Close()
+		p.closer = nil
+	}
+	if err := p.scanner.Err(); err != nil {
+		return Target{}, err
+	}
+	return Target{}, io.EOF
+}
+
+// NewSliceTargetProvider returns the given targets
+func NewSliceTargetProvider(values ...string) TargetProvider {
+	return TargetProviderFunc(func() (Target, error) {
+		if len(values) == 0 {
+			return Target{}, io.EOF
+		}
+		value := values[0]
+		values = values[1:]
+		return ParseTarget(value), nil
+	})
+}
+
+// NewASNTargetProvider returns the cidrs announced by the autonomous systems, looking up one asn at a time
+func NewASNTargetProvider(asns ...string) TargetProvider {
+	return newASNTargetProvider(asns, asn.GetCIDRsForASNNum)
+}
+
+func newASNTargetProvider(asns []string, prefixes func(string) ([]*net.IPNet, error)) TargetProvider {
+	var cidrs []*net.IPNet
+	return TargetProviderFunc(func() (Target, error) {
+		for len(cidrs) == 0 {
+			if len(asns) == 0 {
+				return Target{}, io.EOF
+			}
+			var err error
+			cidrs, err = prefixes(asns[0])
+			if err != nil {
+				return Target{}, fmt.Errorf("could not get prefixes of %s: %s", asns[0], err)
+			}
+			asns = asns[1:]
+		}
+		cidr := cidrs[0]
+		cidrs = cidrs[1:]
+		return Target{Cidr: cidr.String()}, nil
+	})
+}
+
+// NewHTTPTargetProvider returns the targets of an api endpoint answering one target per line,
+// the response is streamed as the targets are consumed
+func NewHTTPTargetProvider(url string) TargetProvider {
+	var provider TargetProvider
+	return TargetProviderFunc(func() (Target, error) {
+		if provider == nil {
+			httpClient := retryablehttp.NewClient(retryablehttp.DefaultOptionsSingle)
+			response, err := httpClient.Get(url)
+			if err != nil {
+				return Target{}, err
+			}
+			if response.StatusCode != http.StatusOK {
+				response.Body.Close()
+				return Target{}, fmt.Errorf("unexpected status code %d from %s", response.StatusCode, url)
+			}
+			provider = NewReaderTargetProvider(response.Body)
+		}
+		return provider.Next()
+	})
+}
+
+// NewSQLTargetProvider returns the targets selected by a single column database query, the rows
+// are fetched as the targets are consumed
+func NewSQLTargetProvider(db *sql.DB, query string, args ...interface{}) TargetProvider {
+	var rows *sql.Rows
+	return TargetProviderFunc(func() (Target, error) {
+		if rows == nil {
+			var err error
+			rows, err = db.Query(query, args...)
+			if err != nil {
+				return Target{}, err
+			}
+		}
+		for rows.Next() {
+			var value sql.NullString
+			if err := rows.Scan(&value); err != nil {
+				rows.Close()
+				return Target{}, err
+			}
+			if value.Valid && strings.TrimSpace(value.String) != "" {
+				return ParseTarget(value.String), nil
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return Target{}, err
+		}
+		return Target{}, io.EOF
+	})
+}
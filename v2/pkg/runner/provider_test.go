@@ -0,0 +1,78 @@
+package runner
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTarget(t *testing.T) {
+	for value, expected := range map[string]Target{
+		"AS14421":            {Asn: "AS14421"},
+		"192.168.1.0/24":     {Cidr: "192.168.1.0/24"},
+		"192.168.1.1":        {Ip: "192.168.1.1"},
+		"192.168.1.1:8080":   {Ip: "192.168.1.1", Port: "8080"},
+		"[2001:db8::1]:443":  {Ip: "2001:db8::1", Port: "443"},
+		"scanme.sh":          {Fqdn: "scanme.sh"},
+		" scanme.sh:22 ":     {Fqdn: "scanme.sh", Port: "22"},
+		"2001:db8::1":        {Ip: "2001:db8::1"},
+		"2001:db8::/64":      {Cidr: "2001:db8::/64"},
+		"www.scanme.sh:http": {Fqdn: "www.scanme.sh:http"},
+	} {
+		target := ParseTarget(value)
+		require.Equal(t, expected, target, value)
+		require.Equal(t, strings.TrimSpace(value), target.String())
+	}
+}
+
+func readTargets(t *testing.T, provider TargetProvider) []string {
+	var targets []string
+	for {
+		target, err := provider.Next()
+		if err == io.EOF {
+			return targets
+		}
+		require.Nil(t, err)
+		targets = append(targets, target.String())
+	}
+}
+
+func TestTargetProviders(t *testing.T) {
+	require.Equal(t, []string{"scanme.sh", "192.168.1.1:80"}, readTargets(t, NewReaderTargetProvider(strings.NewReader("scanme.sh\n\n 192.168.1.1:80\n"))))
+	require.Equal(t, []string{"scanme.sh", "10.0.0.0/8"}, readTargets(t, NewSliceTargetProvider("scanme.sh", "10.0.0.0/8")))
+
+	path := filepath.Join(t.TempDir(), "targets.txt")
+	require.Nil(t, os.WriteFile(path, []byte("scanme.sh\nAS14421\n"), 0600))
+	provider, err := NewFileTargetProvider(path)
+	require.Nil(t, err)
+	require.Equal(t, []string{"scanme.sh", "AS14421"}, readTargets(t, provider))
+	_, err = NewFileTargetProvider(filepath.Join(t.TempDir(), "missing.txt"))
+	require.NotNil(t, err)
+}
+
+func TestASNTargetProvider(t *testing.T) {
+	var lookups []string
+	prefixes := func(asn string) ([]*net.IPNet, error) {
+		lookups = append(lookups, asn)
+		if asn == "AS2" {
+			return nil, nil
+		}
+		_, cidr, _ := net.ParseCIDR(map[string]string{"AS1": "192.0.2.0/24", "AS3": "198.51.100.0/24"}[asn])
+		return []*net.IPNet{cidr}, nil
+	}
+	provider := newASNTargetProvider([]string{"AS1", "AS2", "AS3"}, prefixes)
+
+	// asns are looked up as the targets are consumed
+	target, err := provider.Next()
+	require.Nil(t, err)
+	require.Equal(t, "192.0.2.0/24", target.Cidr)
+	require.Equal(t, []string{"AS1"}, lookups)
+
+	require.Equal(t, []string{"198.51.100.0/24"}, readTargets(t, provider))
+	require.Equal(t, []string{"AS1", "AS2", "AS3"}, lookups)
+}
@@ -0,0 +1,46 @@
+package runner
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// loadRawProbe reads the hex payload sent to the open ports
+func loadRawProbe(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseRawProbe(string(data))
+}
+
+// parseRawProbe decodes the hex payload, whitespace and # comments are ignored so that the
+// payload can be laid out by protocol field:
+//
+//	# dns version.bind query
+//	0000 0100 0001 0000 0000 0000
+//	07 76657273696f6e 04 62696e64 00 0010 0003
+func parseRawProbe(data string) ([]byte, error) {
+	var digits strings.Builder
+	for _, line := range strings.Split(data, "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		digits.WriteString(strings.Map(func(r rune) rune {
+			if unicode.IsSpace(r) {
+				return -1
+			}
+			return r
+		}, line))
+	}
+	if digits.Len() == 0 {
+		return nil, errors.New("empty raw probe")
+	}
+	payload, err := hex.DecodeString(digits.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid raw probe: %s", err)
+	}
+	return payload, nil
+}
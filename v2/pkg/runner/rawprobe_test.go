@@ -0,0 +1,20 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRawProbe(t *testing.T) {
+	payload, err := parseRawProbe("# version.bind\n0000 0100\n\t07 76 # length\n")
+	require.Nil(t, err)
+	require.Equal(t, []byte{0x00, 0x00, 0x01, 0x00, 0x07, 0x76}, payload)
+
+	_, err = parseRawProbe("# only a comment\n")
+	require.NotNil(t, err)
+	_, err = parseRawProbe("abc")
+	require.NotNil(t, err, "odd number of digits")
+	_, err = parseRawProbe("zz")
+	require.NotNil(t, err)
+}
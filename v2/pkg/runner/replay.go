@@ -0,0 +1,130 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/projectdiscovery/gologger"
+	"golang.org/x/exp/maps"
+)
+
+// redacted replaces the secrets of the dumped options
+const redacted = "REDACTED"
+
+// optionsDump is the file of the effective options of a run, replayed with -replay
+type optionsDump struct {
+	Version string   `json:"version"`
+	Options *Options `json:"options"`
+	// Flags are the flags given explicitly, so that the replay doesn't derive their values again
+	Flags []string `json:"flags,omitempty"`
+}
+
+// secretOption is an option holding credentials, redacted in the dump
+type secretOption struct {
+	flag  string
+	value func(options *Options) *string
+	// url is true if the option is an url whose password and query are redacted
+	url bool
+}
+
+// secretOptions are the options redacted in the dump, given again along -replay
+var secretOptions = []secretOption{
+	{flag: "proxy-auth", value: func(options *Options) *string { return &options.ProxyAuth }},
+	{flag: "anonymize-key", value: func(options *Options) *string { return &options.AnonymizeKey }},
+	{flag: "proxy", value: func(options *Options) *string { return &options.Proxy }, url: true},
+	{flag: "webhook-url", value: func(options *Options) *string { return &options.WebhookURL }, url: true},
+	{flag: "es-url", value: func(options *Options) *string { return &options.ElasticsearchURL }, url: true},
+	{flag: "enrich-url", value: func(options *Options) *string { return &options.EnrichURL }, url: true},
+	{flag: "opt-out-url", value: func(options *Options) *string { return &options.OptOutURL }, url: true},
+	{flag: "kill-switch", value: func(options *Options) *string { return &options.KillSwitch }, url: true},
+	{flag: "otlp-endpoint", value: func(options *Options) *string { return &options.OtlpEndpoint }, url: true},
+}
+
+// dumpOptions writes the options resolved from the flags, config file and pipeline to the file,
+// the credentials being redacted
+func (options *Options) dumpOptions(path string) error {
+	dumped := *options
+	for _, secret := range secretOptions {
+		value := secret.value(&dumped)
+		switch {
+		case *value == "":
+		case secret.url:
+			*value = redactURL(*value)
+		default:
+			*value = redacted
+		}
+	}
+	flags := maps.Keys(options.givenFlags)
+	sort.Strings(flags)
+	data, err := json.MarshalIndent(optionsDump{Version: version, Options: &dumped, Flags: flags}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// redactURL redacts the password, or the user if it has none, and the query values of the url
+func redactURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		return value
+	}
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+		} else {
+			u.User = url.User(redacted)
+		}
+	}
+	if u.RawQuery != "" {
+		query := u.Query()
+		for key := range query {
+			query.Set(key, redacted)
+		}
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+// applyReplay replaces the options by the ones of the dump file, the flags given along -replay
+// being ignored so that the run uses exactly the configuration of the dumped one. The options
+// missing from the dump keep their default, the flags given explicitly in the dumped run are given
+// in the replay too, and the redacted credentials are taken from the flags given along -replay
+func (options *Options) applyReplay() error {
+	data, err := os.ReadFile(options.Replay)
+	if err != nil {
+		return err
+	}
+	dump := optionsDump{Options: DefaultOptions()}
+	if err := json.Unmarshal(data, &dump); err != nil {
+		return fmt.Errorf("could not parse %s: %w", options.Replay, err)
+	}
+	if dump.Version != version {
+		gologger.Warning().Msgf("Replaying options dumped by naabu %s with naabu %s, the scan may differ\n", dump.Version, version)
+	}
+	for _, secret := range secretOptions {
+		given, value := *secret.value(options), secret.value(dump.Options)
+		switch {
+		case given != "":
+			*value = given
+		case strings.Contains(*value, redacted):
+			gologger.Warning().Msgf("The credentials of -%s were redacted from %s, give them along -replay\n", secret.flag, options.Replay)
+			if !secret.url {
+				*value = ""
+			}
+		}
+	}
+	replay, dumpPath := options.Replay, options.DumpOptions
+	*options = *dump.Options
+	options.Replay, options.DumpOptions = replay, dumpPath
+	options.givenFlags = make(map[string]struct{}, len(dump.Flags))
+	for _, flag := range dump.Flags {
+		options.givenFlags[flag] = struct{}{}
+	}
+	gologger.Info().Msgf("Replaying the options of %s\n", replay)
+	return nil
+}
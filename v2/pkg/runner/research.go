@@ -0,0 +1,71 @@
+package runner
+
+import (
+	"encoding/csv"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/scan"
+)
+
+// researchHeader are the columns of the research output
+var researchHeader = []string{"timestamp", "ip", "port", "flags", "ttl", "ipid", "window", "seq", "ack", "options"}
+
+// researchWriter records the header fields of every probe response (open or closed port) for network measurement studies
+type researchWriter struct {
+	sync.Mutex
+	file       *os.File
+	writer     *csv.Writer
+	anonymizer *anonymizer
+}
+
+// newResearchWriter creates the research csv output file
+func newResearchWriter(path string) (*researchWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	writer := csv.NewWriter(file)
+	if err := writer.Write(researchHeader); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &researchWriter{file: file, writer: writer}, nil
+}
+
+// Write records a probe response
+func (w *researchWriter) Write(response *scan.Response) {
+	record := []string{
+		response.Time.UTC().Format(time.RFC3339Nano),
+		w.anonymizer.Value(response.IP),
+		strconv.Itoa(response.Port),
+		response.Flags,
+		strconv.Itoa(int(response.TTL)),
+		strconv.Itoa(int(response.IPID)),
+		strconv.Itoa(int(response.Window)),
+		strconv.FormatUint(uint64(response.Seq), 10),
+		strconv.FormatUint(uint64(response.Ack), 10),
+		response.Options,
+	}
+
+	w.Lock()
+	defer w.Unlock()
+	if err := w.writer.Write(record); err != nil {
+		gologger.Warning().Msgf("Could not write research record for %s:%d: %s\n", response.IP, response.Port, err)
+	}
+}
+
+// Close flushes the pending records and closes the output file
+func (w *researchWriter) Close() error {
+	w.Lock()
+	defer w.Unlock()
+	w.writer.Flush()
+	if err := w.writer.Error(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
@@ -0,0 +1,38 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/scan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResearchWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "research.csv")
+	writer, err := newResearchWriter(path)
+	require.Nil(t, err)
+
+	writer.Write(&scan.Response{
+		Time:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		IP:      "192.168.1.1",
+		Port:    443,
+		TTL:     64,
+		IPID:    1234,
+		Window:  65535,
+		Flags:   "SA",
+		Options: "MSS=1460,SACKPermitted,Timestamps,NOP,WindowScale=7",
+		Seq:     1,
+		Ack:     2,
+	})
+	writer.Write(&scan.Response{Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), IP: "192.168.1.1", Port: 22, TTL: 64, Flags: "RA"})
+	require.Nil(t, writer.Close())
+
+	data, err := os.ReadFile(path)
+	require.Nil(t, err)
+	require.Equal(t, "timestamp,ip,port,flags,ttl,ipid,window,seq,ack,options\n"+
+		"2024-01-02T03:04:05Z,192.168.1.1,443,SA,64,1234,65535,1,2,\"MSS=1460,SACKPermitted,Timestamps,NOP,WindowScale=7\"\n"+
+		"2024-01-02T03:04:05Z,192.168.1.1,22,RA,64,0,0,0,0,\n", string(data))
+}
@@ -0,0 +1,78 @@
+package runner
+
+import (
+	"sync"
+
+	"github.com/projectdiscovery/dnsx/libs/dnsx"
+	iputil "github.com/projectdiscovery/utils/ip"
+)
+
+// Resolution holds the addresses of a hostname and the chain of aliases followed to resolve it
+type Resolution struct {
+	A     []string
+	AAAA  []string
+	CNAME []string
+}
+
+// Resolver resolves the hostnames of the targets. Library users can supply their own implementation
+// (internal dns apis, split-horizon aware resolvers) instead of the default dnsx client.
+type Resolver interface {
+	Resolve(host string) (*Resolution, error)
+}
+
+// ResolverFunc adapts a function to a Resolver
+type ResolverFunc func(host string) (*Resolution, error)
+
+// Resolve resolves the host
+func (f ResolverFunc) Resolve(host string) (*Resolution, error) {
+	return f(host)
+}
+
+// ReverseResolver is implemented by the resolvers also answering the reverse dns (PTR) lookups of
+// -rev-ptr and the tag rules, which use the system resolver otherwise
+type ReverseResolver interface {
+	LookupAddr(ip string) ([]string, error)
+}
+
+// dnsxResolver is the default resolver, its dnsx client being created on the first resolution so
+// that scans of ip targets never set up the dns stack
+type dnsxResolver struct {
+	options dnsx.Options
+	once    sync.Once
+	client  *dnsx.DNSX
+	err     error
+}
+
+func newDNSXResolver(options dnsx.Options) *dnsxResolver {
+	return &dnsxResolver{options: options}
+}
+
+// Resolve queries the configured record types of the host
+func (resolver *dnsxResolver) Resolve(host string) (*Resolution, error) {
+	resolver.once.Do(func() {
+		if resolver.client == nil {
+			resolver.client, resolver.err = dnsx.New(resolver.options)
+		}
+	})
+	if resolver.err != nil {
+		return nil, resolver.err
+	}
+	dnsData, err := resolver.client.QueryMultiple(host)
+	if err != nil || dnsData == nil {
+		return nil, err
+	}
+	return &Resolution{A: dnsData.A, AAAA: dnsData.AAAA, CNAME: dnsData.CNAME}, nil
+}
+
+// started returns true if the dnsx client was set up
+func (resolver *dnsxResolver) started() bool {
+	return resolver.client != nil || resolver.err != nil
+}
+
+// lookupAddr returns the reverse dns names of the ip, from the custom resolver if it supports them
+func (r *Runner) lookupAddr(ip string) ([]string, error) {
+	if reverse, ok := r.resolver.(ReverseResolver); ok {
+		return reverse.LookupAddr(ip)
+	}
+	return iputil.ToFQDN(ip)
+}
@@ -0,0 +1,159 @@
+package runner
+
+import (
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/projectdiscovery/clistats"
+	"github.com/projectdiscovery/gologger"
+)
+
+// resourceSampleInterval is the interval of the open files and memory samples
+const resourceSampleInterval = time.Second
+
+// resourceUsage is the usage of the host resources by the run, exposed by the stats, the progress
+// file and the summary so that the scanning infrastructure can be sized from real numbers
+type resourceUsage struct {
+	PeakMemory     uint64  `json:"peak_memory_bytes"`
+	CPUTimeMs      float64 `json:"cpu_time_ms"`
+	PeakOpenFiles  int64   `json:"peak_open_files,omitempty"`
+	BytesSent      uint64  `json:"bytes_sent"`
+	BytesReceived  uint64  `json:"bytes_received"`
+	BytesEstimated bool    `json:"bytes_estimated,omitempty"`
+}
+
+// resourceMonitor samples the high-water marks of the open files and memory during the run, the
+// cpu time and traffic being cumulative counters
+type resourceMonitor struct {
+	peakFiles  atomic.Int64
+	peakMemory atomic.Uint64
+	stop       chan struct{}
+}
+
+// newResourceMonitor starts sampling the resources of the process until stopped
+func newResourceMonitor() *resourceMonitor {
+	monitor := &resourceMonitor{stop: make(chan struct{})}
+	monitor.sample()
+	go func() {
+		ticker := time.NewTicker(resourceSampleInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-monitor.stop:
+				return
+			case <-ticker.C:
+				monitor.sample()
+			}
+		}
+	}()
+	return monitor
+}
+
+// sample raises the high-water marks to the current usage
+func (monitor *resourceMonitor) sample() {
+	if files, err := openFiles(); err == nil {
+		storeMax(&monitor.peakFiles, int64(files))
+	}
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	for {
+		previous := monitor.peakMemory.Load()
+		if memStats.Sys <= previous || monitor.peakMemory.CompareAndSwap(previous, memStats.Sys) {
+			break
+		}
+	}
+}
+
+// Stop ends the sampling, taking a last sample
+func (monitor *resourceMonitor) Stop() {
+	if monitor == nil {
+		return
+	}
+	select {
+	case <-monitor.stop:
+	default:
+		monitor.sample()
+		close(monitor.stop)
+	}
+}
+
+// monitorsResources returns true if the usage of the resources is reported, by the stats, the
+// progress file or the summary of a command line run, library runners not sampling them otherwise
+func (r *Runner) monitorsResources() bool {
+	return r.stats != nil || r.options.ProgressFile != "" || r.options.logsResources()
+}
+
+// logsResources returns true if the usage of the resources is printed at the end of the run, only
+// for command line runs not suppressing the summary
+func (options *Options) logsResources() bool {
+	return options.givenFlags != nil && !options.NoSummary
+}
+
+// resourceUsage returns the usage of the resources since the start of the run
+func (r *Runner) resourceUsage() *resourceUsage {
+	if r.resources == nil {
+		return nil
+	}
+	usage := &resourceUsage{
+		PeakMemory:    r.resources.peakMemory.Load(),
+		PeakOpenFiles: r.resources.peakFiles.Load(),
+	}
+	// the resident set size of the kernel includes the memory not obtained by the go runtime
+	if cpu, peakMemory, err := processUsage(); err == nil {
+		usage.CPUTimeMs = milliseconds(int64(cpu))
+		if peakMemory > usage.PeakMemory {
+			usage.PeakMemory = peakMemory
+		}
+	}
+	usage.BytesSent, usage.BytesReceived = r.scanner.Traffic()
+	// connect probes go through the kernel sockets, their bytes are estimated from the handshakes
+	if !r.options.shouldUseRawPackets() {
+		usage.BytesSent += r.probesSent.Load() * uint64(probeWireSize(ConnectScan, false, false))
+		usage.BytesEstimated = true
+	}
+	return usage
+}
+
+// addResourceStats exposes the usage of the resources
+func (r *Runner) addResourceStats(stats *clistats.Statistics) {
+	stats.AddDynamic("resources", func(_ clistats.StatisticsClient) interface{} {
+		return r.resourceUsage()
+	})
+}
+
+// reportResources prints the usage of the resources at the end of the run
+func (r *Runner) reportResources() {
+	if !r.options.logsResources() {
+		return
+	}
+	usage := r.resourceUsage()
+	if usage == nil {
+		return
+	}
+	files := "unknown"
+	if usage.PeakOpenFiles > 0 {
+		files = fmt.Sprint(usage.PeakOpenFiles)
+	}
+	sent := formatBytes(usage.BytesSent)
+	if usage.BytesEstimated {
+		sent = "~" + sent
+	}
+	gologger.Info().Msgf("Resources: %s peak memory, %s cpu time, %s peak open files, %s sent, %s received\n",
+		formatBytes(usage.PeakMemory), time.Duration(usage.CPUTimeMs*float64(time.Millisecond)).Round(time.Millisecond), files, sent, formatBytes(usage.BytesReceived))
+}
+
+// formatBytes returns the size in the largest binary unit (1.5 MiB)
+func formatBytes(size uint64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value, exponent := float64(size)/unit, 0
+	for value >= unit && exponent < 3 {
+		value /= unit
+		exponent++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMGT"[exponent])
+}
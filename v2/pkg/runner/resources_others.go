@@ -0,0 +1,19 @@
+//go:build !linux && !darwin
+
+package runner
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// processUsage returns the cpu time and the peak resident memory of the process
+func processUsage() (time.Duration, uint64, error) {
+	return 0, 0, fmt.Errorf("process usage not available on %s", runtime.GOOS)
+}
+
+// openFiles returns the number of file descriptors open by the process
+func openFiles() (int, error) {
+	return 0, fmt.Errorf("open files not available on %s", runtime.GOOS)
+}
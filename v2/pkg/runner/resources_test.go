@@ -0,0 +1,42 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatBytes(t *testing.T) {
+	require.Equal(t, "512 B", formatBytes(512))
+	require.Equal(t, "1.5 KiB", formatBytes(1536))
+	require.Equal(t, "20.0 MiB", formatBytes(20<<20))
+	require.Equal(t, "2.0 GiB", formatBytes(2<<30))
+}
+
+func TestResourceMonitor(t *testing.T) {
+	monitor := newResourceMonitor()
+	defer monitor.Stop()
+
+	require.Greater(t, monitor.peakMemory.Load(), uint64(0))
+	if files, err := openFiles(); err == nil {
+		require.Greater(t, files, 0)
+		require.GreaterOrEqual(t, monitor.peakFiles.Load(), int64(files)-1)
+	}
+	monitor.Stop()
+	// stopping twice is a no-op
+	monitor.Stop()
+}
+
+func TestMonitorsResources(t *testing.T) {
+	// library runners sample the resources only for the stats and the progress file
+	r := &Runner{options: &Options{}}
+	require.False(t, r.monitorsResources())
+	r.options.ProgressFile = "progress.jsonl"
+	require.True(t, r.monitorsResources())
+
+	// command line runs print them with the summary
+	options := &Options{givenFlags: map[string]struct{}{}}
+	require.True(t, options.logsResources())
+	options.NoSummary = true
+	require.False(t, options.logsResources())
+}
@@ -0,0 +1,40 @@
+//go:build linux || darwin
+
+package runner
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// processUsage returns the cpu time and the peak resident memory of the process
+func processUsage() (time.Duration, uint64, error) {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0, 0, err
+	}
+	cpu := time.Duration(usage.Utime.Nano() + usage.Stime.Nano())
+	peakMemory := uint64(usage.Maxrss)
+	// linux reports the maximum resident set size in kilobytes, darwin in bytes
+	if runtime.GOOS == "linux" {
+		peakMemory *= 1024
+	}
+	return cpu, peakMemory, nil
+}
+
+// openFiles returns the number of file descriptors open by the process
+func openFiles() (int, error) {
+	dir, err := os.Open("/dev/fd")
+	if err != nil {
+		return 0, err
+	}
+	defer dir.Close()
+	names, err := dir.Readdirnames(-1)
+	if err != nil {
+		return 0, err
+	}
+	// the descriptor of the listed directory itself
+	return len(names) - 1, nil
+}
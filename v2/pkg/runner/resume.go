@@ -1,12 +1,16 @@
 package runner
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
 	fileutil "github.com/projectdiscovery/utils/file"
 )
 
@@ -33,6 +37,8 @@ type ResumeCfg struct {
 	Retry int   `json:"retry"`
 	Seed  int64 `json:"seed"`
 	Index int64 `json:"index"`
+	// Results are the open ports found before the checkpoint, restored when resuming
+	Results map[string][]*port.Port `json:"results,omitempty"`
 }
 
 // NewResumeCfg creates a new scan progression structure
@@ -51,7 +57,12 @@ func (resumeCfg *ResumeCfg) SaveResumeConfig() error {
 		_ = os.MkdirAll(DefaultResumeFolderPath(), 0644)
 	}
 
-	return os.WriteFile(DefaultResumeFilePath(), data, 0644)
+	// the checkpoint is replaced atomically so that a kill while saving keeps the previous one
+	tmpFile := fmt.Sprintf("%s.%d.tmp", DefaultResumeFilePath(), os.Getpid())
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, DefaultResumeFilePath())
 }
 
 // ConfigureResume read the resume config file
@@ -79,3 +90,151 @@ func (resumeCfg *ResumeCfg) CleanupResumeConfig() {
 		os.Remove(DefaultResumeFilePath())
 	}
 }
+
+// SaveResume saves the scan progression and the open ports found so far. The checkpoint of a scan
+// holding the checkpoint lock isn't overwritten
+func (r *Runner) SaveResume() error {
+	if !r.resumeLocked.Load() && resumeLockHeld() {
+		return fmt.Errorf("resume file %s is checkpointed by another scan", DefaultResumeFilePath())
+	}
+	r.snapshotResults()
+
+	r.options.ResumeCfg.RLock()
+	defer r.options.ResumeCfg.RUnlock()
+
+	return r.options.ResumeCfg.SaveResumeConfig()
+}
+
+// snapshotResults copies the open ports found so far into the resume checkpoint
+func (r *Runner) snapshotResults() {
+	// the ports still queued in the scanner are part of the checkpoint
+	r.scanner.FlushResults()
+	results := make(map[string][]*port.Port)
+	for hostResult := range r.scanner.ScanResults.GetIPsPorts() {
+		results[hostResult.IP] = hostResult.Ports
+	}
+
+	r.options.ResumeCfg.Lock()
+	defer r.options.ResumeCfg.Unlock()
+
+	r.options.ResumeCfg.Results = results
+}
+
+// restoreResults adds the open ports found by the interrupted scan to the results
+func (r *Runner) restoreResults() {
+	r.options.ResumeCfg.RLock()
+	defer r.options.ResumeCfg.RUnlock()
+
+	var count int
+	for ip, ports := range r.options.ResumeCfg.Results {
+		for _, p := range ports {
+			r.scanner.ScanResults.AddPort(ip, p)
+			count++
+		}
+	}
+	if count > 0 {
+		gologger.Info().Msgf("Restored %d open ports found before the checkpoint\n", count)
+	}
+}
+
+// checkpointResume periodically saves the scan progression until the context is done, so that a
+// killed scan can be resumed from the last checkpoint. Checkpoints are only saved with -resume-interval,
+// by a single scan at a time holding the checkpoint lock
+func (r *Runner) checkpointResume(ctx context.Context) {
+	if r.options.ResumeInterval <= 0 {
+		return
+	}
+	if err := lockResume(); err != nil {
+		gologger.Warning().Msgf("Couldn't save resume checkpoints: %s\n", err)
+		return
+	}
+	r.resumeLocked.Store(true)
+	defer func() {
+		r.resumeLocked.Store(false)
+		unlockResume()
+	}()
+
+	ticker := time.NewTicker(r.options.ResumeInterval)
+	defer ticker.Stop()
+	refresh := time.NewTicker(resumeLockRefresh)
+	defer refresh.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-refresh.C:
+			now := time.Now()
+			_ = os.Chtimes(resumeLockPath(), now, now)
+		case <-ticker.C:
+			if err := r.SaveResume(); err != nil {
+				gologger.Warning().Msgf("Couldn't save resume checkpoint: %s\n", err)
+			}
+		}
+	}
+}
+
+const (
+	// resumeLockRefresh is the interval the checkpoint lock is refreshed at by its scan
+	resumeLockRefresh = 10 * time.Second
+	// resumeLockStale is the age of a lock left by a killed scan, taken over by the next one
+	resumeLockStale = 6 * resumeLockRefresh
+)
+
+// resumeLockPath returns the lock file of the scan saving periodic checkpoints
+func resumeLockPath() string {
+	return DefaultResumeFilePath() + ".lock"
+}
+
+// resumeLockHeld returns true if a running scan holds the checkpoint lock
+func resumeLockHeld() bool {
+	info, err := os.Stat(resumeLockPath())
+	return err == nil && time.Since(info.ModTime()) < resumeLockStale
+}
+
+// lockResume takes the checkpoint lock, so that concurrent scans don't overwrite each other's
+// checkpoints. A stale lock left by a killed scan is taken over
+func lockResume() error {
+	resumeFolderPath := DefaultResumeFolderPath()
+	if !fileutil.FolderExists(resumeFolderPath) {
+		_ = os.MkdirAll(resumeFolderPath, 0755)
+	}
+	for attempt := 0; attempt < 2; attempt++ {
+		file, err := os.OpenFile(resumeLockPath(), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_, _ = fmt.Fprintf(file, "%d\n", os.Getpid())
+			return file.Close()
+		}
+		if !os.IsExist(err) {
+			return err
+		}
+		if resumeLockHeld() {
+			break
+		}
+		_ = os.Remove(resumeLockPath())
+	}
+	return fmt.Errorf("resume file %s is checkpointed by another scan", DefaultResumeFilePath())
+}
+
+// unlockResume releases the checkpoint lock
+func unlockResume() {
+	_ = os.Remove(resumeLockPath())
+}
+
+// savesResume returns true if the scan resumed a checkpoint or saves them, the checkpoints of the
+// other scans never being written nor cleaned up
+func (options *Options) savesResume() bool {
+	return options.Resume || options.ResumeInterval > 0
+}
+
+// cleanupResume removes the checkpoint of a completed scan that saved or resumed one, unless another
+// scan holds the checkpoint lock
+func (r *Runner) cleanupResume() {
+	if !r.options.savesResume() {
+		return
+	}
+	if !r.resumeLocked.Load() && resumeLockHeld() {
+		return
+	}
+	r.options.ResumeCfg.CleanupResumeConfig()
+}
@@ -0,0 +1,76 @@
+package runner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+	"github.com/projectdiscovery/naabu/v2/pkg/scan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResumeCheckpoint(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	r := &Runner{
+		options: &Options{ResumeCfg: NewResumeCfg(), ResumeInterval: 10 * time.Millisecond},
+		scanner: &scan.Scanner{ScanResults: result.NewResult()},
+	}
+	r.options.ResumeCfg.Seed = 42
+	r.options.ResumeCfg.Index = 1000
+	r.scanner.ScanResults.AddPort("10.0.0.1", &port.Port{Port: 443, Protocol: protocol.TCP})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.checkpointResume(ctx)
+		close(done)
+	}()
+	require.Eventually(t, func() bool {
+		resumed := NewResumeCfg()
+		return resumed.ConfigureResume() == nil && len(resumed.Results) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// a concurrent scan doesn't overwrite the checkpoint
+	other := &Runner{
+		options: &Options{ResumeCfg: NewResumeCfg(), ResumeInterval: 10 * time.Millisecond},
+		scanner: &scan.Scanner{ScanResults: result.NewResult()},
+	}
+	require.NotNil(t, other.SaveResume())
+	other.checkpointResume(context.Background())
+	other.cleanupResume()
+	require.FileExists(t, DefaultResumeFilePath())
+
+	cancel()
+	<-done
+	require.NoFileExists(t, resumeLockPath())
+
+	resumed := &Runner{
+		options: &Options{ResumeCfg: NewResumeCfg(), Resume: true},
+		scanner: &scan.Scanner{ScanResults: result.NewResult()},
+	}
+	require.Nil(t, resumed.options.ResumeCfg.ConfigureResume())
+	require.Equal(t, int64(42), resumed.options.ResumeCfg.Seed)
+	require.Equal(t, int64(1000), resumed.options.ResumeCfg.Index)
+
+	resumed.restoreResults()
+	require.True(t, resumed.scanner.ScanResults.IPHasPort("10.0.0.1", &port.Port{Port: 443, Protocol: protocol.TCP}))
+
+	resumed.cleanupResume()
+	require.NotNil(t, NewResumeCfg().ConfigureResume())
+}
+
+func TestResumeCheckpointDisabled(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	r := &Runner{
+		options: &Options{ResumeCfg: NewResumeCfg()},
+		scanner: &scan.Scanner{ScanResults: result.NewResult()},
+	}
+	r.checkpointResume(context.Background())
+	require.NoFileExists(t, DefaultResumeFilePath())
+	require.NoFileExists(t, resumeLockPath())
+}
@@ -0,0 +1,221 @@
+package runner
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+)
+
+// Retry strategies
+const (
+	RetryUniform  = "uniform"
+	RetryAdaptive = "adaptive"
+	RetryBackoff  = "backoff"
+)
+
+// Retransmission timeouts of the backoff strategy, starting from the initial rto of the tcp stacks
+// (RFC 6298) and doubled at each retransmission like the os does for unanswered syns
+const (
+	backoffInitialDelay = time.Second
+	backoffMaxDelay     = 32 * time.Second
+)
+
+// adaptiveExtraRetries are the retransmissions added for ambiguous hosts by the adaptive strategy,
+// affordable as retries skip the ports already answered
+const adaptiveExtraRetries = 2
+
+// maxTrackedResponses is the number of hosts and answered ports recorded by the response tracker,
+// the answers exceeding it are not recorded and their ports are retried as unanswered
+const maxTrackedResponses = 1 << 22
+
+// responseTracker records the tcp ports answered (open or closed) by each host, so retries can be
+// concentrated on the unanswered ports of hosts known to be up
+type responseTracker struct {
+	sync.RWMutex
+	hosts map[string]map[int]struct{}
+	// entries is the number of hosts and ports recorded
+	entries int
+}
+
+func newResponseTracker() *responseTracker {
+	return &responseTracker{hosts: make(map[string]map[int]struct{})}
+}
+
+// add records the port answered by the host
+func (t *responseTracker) add(ip string, portNumber int) {
+	t.Lock()
+	defer t.Unlock()
+	ports, ok := t.hosts[ip]
+	if !ok {
+		if t.entries >= maxTrackedResponses {
+			return
+		}
+		ports = make(map[int]struct{})
+		t.hosts[ip] = ports
+		t.entries++
+	}
+	if _, ok := ports[portNumber]; ok || t.entries >= maxTrackedResponses {
+		return
+	}
+	ports[portNumber] = struct{}{}
+	t.entries++
+}
+
+// isAmbiguous returns true if the host answered some probes but not the one to the port,
+// which may have been lost or timed out. Hosts that never answered are considered down
+func (t *responseTracker) isAmbiguous(ip string, p *port.Port) bool {
+	t.RLock()
+	defer t.RUnlock()
+	ports, ok := t.hosts[ip]
+	if !ok {
+		return false
+	}
+	if p.Protocol != protocol.TCP {
+		return true
+	}
+	_, answered := ports[p.Port]
+	return !answered
+}
+
+// isAnswered returns true if the host answered the tcp probe to the port
+func (t *responseTracker) isAnswered(ip string, p *port.Port) bool {
+	if p.Protocol != protocol.TCP {
+		return false
+	}
+	t.RLock()
+	defer t.RUnlock()
+	_, answered := t.hosts[ip][p.Port]
+	return answered
+}
+
+// backoffDelay returns the minimum delay between the previous probe to a host and the retransmission
+// of the retry
+func backoffDelay(retry int) time.Duration {
+	delay := backoffInitialDelay
+	for i := 1; i < retry && delay < backoffMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > backoffMaxDelay {
+		return backoffMaxDelay
+	}
+	return delay
+}
+
+// backoffKey is a port of a host tracked by the backoff strategy
+type backoffKey struct {
+	ip       string
+	port     int
+	protocol protocol.Protocol
+}
+
+// backoffTracker records when each port of each host was last probed, so the retransmissions of
+// the backoff strategy are spaced by exponentially growing delays instead of following the previous
+// pass back-to-back. The probes older than the longest delay are pruned as the map grows
+type backoffTracker struct {
+	sync.Mutex
+	sent    map[backoffKey]time.Time
+	pruneAt int
+}
+
+func newBackoffTracker() *backoffTracker {
+	return &backoffTracker{sent: make(map[backoffKey]time.Time), pruneAt: 1024}
+}
+
+// record records the probes sent to the ports of the host
+func (t *backoffTracker) record(ip string, ports []*port.Port) {
+	if t == nil {
+		return
+	}
+	now := time.Now()
+	t.Lock()
+	defer t.Unlock()
+
+	for _, p := range ports {
+		t.sent[backoffKey{ip: ip, port: p.Port, protocol: p.Protocol}] = now
+	}
+	if len(t.sent) >= t.pruneAt {
+		for key, sent := range t.sent {
+			if now.Sub(sent) >= backoffMaxDelay {
+				delete(t.sent, key)
+			}
+		}
+		t.pruneAt = 2 * len(t.sent)
+		if t.pruneAt < 1024 {
+			t.pruneAt = 1024
+		}
+	}
+}
+
+// due returns when the retransmission of the retry to the ports of the host is due, once the
+// backoff delay elapsed since each of them was last probed. The zero time is returned for the
+// first pass and the ports not probed yet
+func (t *backoffTracker) due(ip string, ports []*port.Port, retry int) time.Time {
+	var due time.Time
+	if t == nil || retry == 0 {
+		return due
+	}
+	delay := backoffDelay(retry)
+	t.Lock()
+	defer t.Unlock()
+
+	for _, p := range ports {
+		if sent, ok := t.sent[backoffKey{ip: ip, port: p.Port, protocol: p.Protocol}]; ok {
+			if at := sent.Add(delay); at.After(due) {
+				due = at
+			}
+		}
+	}
+	return due
+}
+
+// backoffPick is a burst whose retransmission isn't due yet
+type backoffPick struct {
+	due  time.Time
+	pick *fairPick
+}
+
+// backoffQueue holds the bursts deferred by the backoff strategy ordered by due time, so that the
+// send loop goes on with the other bursts instead of waiting for them
+type backoffQueue []*backoffPick
+
+func (q backoffQueue) Len() int            { return len(q) }
+func (q backoffQueue) Less(i, j int) bool  { return q[i].due.Before(q[j].due) }
+func (q backoffQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *backoffQueue) Push(x interface{}) { *q = append(*q, x.(*backoffPick)) }
+func (q *backoffQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return item
+}
+
+// add queues the burst until its retransmission is due
+func (q *backoffQueue) add(due time.Time, pick *fairPick) {
+	heap.Push(q, &backoffPick{due: due, pick: pick})
+}
+
+// popDue returns the bursts due at the time, in due order
+func (q *backoffQueue) popDue(now time.Time) []*fairPick {
+	var picks []*fairPick
+	for q.Len() > 0 && !(*q)[0].due.After(now) {
+		picks = append(picks, heap.Pop(q).(*backoffPick).pick)
+	}
+	return picks
+}
+
+// next returns when the first deferred burst is due
+func (q backoffQueue) next() time.Time {
+	return q[0].due
+}
+
+// retryPasses returns the number of scan passes of the retry strategy
+func (options *Options) retryPasses() int {
+	if options.RetryStrategy == RetryAdaptive {
+		return options.Retries + adaptiveExtraRetries
+	}
+	return options.Retries
+}
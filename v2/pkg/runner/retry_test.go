@@ -0,0 +1,97 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseTracker(t *testing.T) {
+	tracker := newResponseTracker()
+	tracker.add("10.0.0.1", 80)
+
+	require.False(t, tracker.isAmbiguous("10.0.0.1", &port.Port{Port: 80, Protocol: protocol.TCP}))
+	require.True(t, tracker.isAmbiguous("10.0.0.1", &port.Port{Port: 443, Protocol: protocol.TCP}))
+	require.True(t, tracker.isAmbiguous("10.0.0.1", &port.Port{Port: 53, Protocol: protocol.UDP}))
+	require.False(t, tracker.isAmbiguous("10.0.0.2", &port.Port{Port: 443, Protocol: protocol.TCP}))
+}
+
+func TestRetryPasses(t *testing.T) {
+	require.Equal(t, 3, (&Options{Retries: 3}).retryPasses())
+	require.Equal(t, 3, (&Options{Retries: 3, RetryStrategy: RetryUniform}).retryPasses())
+	require.Equal(t, 3+adaptiveExtraRetries, (&Options{Retries: 3, RetryStrategy: RetryAdaptive}).retryPasses())
+	require.Equal(t, 3, (&Options{Retries: 3, RetryStrategy: RetryBackoff}).retryPasses())
+}
+
+func TestBackoffDelay(t *testing.T) {
+	require.Equal(t, time.Second, backoffDelay(1))
+	require.Equal(t, 2*time.Second, backoffDelay(2))
+	require.Equal(t, 4*time.Second, backoffDelay(3))
+	require.Equal(t, backoffMaxDelay, backoffDelay(20))
+}
+
+func TestBackoffTrackerPerPort(t *testing.T) {
+	tracker := newBackoffTracker()
+	http := []*port.Port{{Port: 80, Protocol: protocol.TCP}}
+	https := []*port.Port{{Port: 443, Protocol: protocol.TCP}}
+
+	require.True(t, tracker.due("10.0.0.1", http, 1).IsZero())
+	tracker.record("10.0.0.1", http)
+	require.True(t, tracker.due("10.0.0.1", http, 0).IsZero())
+	require.WithinDuration(t, time.Now().Add(backoffDelay(2)), tracker.due("10.0.0.1", http, 2), time.Second)
+	// the other ports of the host are not delayed by the probe
+	require.True(t, tracker.due("10.0.0.1", https, 1).IsZero())
+	require.True(t, tracker.due("10.0.0.2", http, 1).IsZero())
+}
+
+func TestBackoffQueue(t *testing.T) {
+	var queue backoffQueue
+	now := time.Now()
+	first, second, third := &fairPick{index: 1}, &fairPick{index: 2}, &fairPick{index: 3}
+	queue.add(now.Add(2*time.Second), second)
+	queue.add(now.Add(time.Hour), third)
+	queue.add(now.Add(time.Second), first)
+
+	require.Empty(t, queue.popDue(now))
+	require.Equal(t, now.Add(time.Second), queue.next())
+	require.Equal(t, []*fairPick{first, second}, queue.popDue(now.Add(2*time.Second)))
+	require.Equal(t, 1, queue.Len())
+	require.Equal(t, []*fairPick{third}, queue.popDue(now.Add(time.Hour)))
+}
+
+func TestResponseTrackerAnswered(t *testing.T) {
+	tracker := newResponseTracker()
+	tracker.add("10.0.0.1", 80)
+
+	require.True(t, tracker.isAnswered("10.0.0.1", &port.Port{Port: 80, Protocol: protocol.TCP}))
+	require.False(t, tracker.isAnswered("10.0.0.1", &port.Port{Port: 443, Protocol: protocol.TCP}))
+	require.False(t, tracker.isAnswered("10.0.0.2", &port.Port{Port: 80, Protocol: protocol.TCP}))
+	require.False(t, tracker.isAnswered("10.0.0.1", &port.Port{Port: 80, Protocol: protocol.UDP}))
+}
+
+func TestAmbiguousPorts(t *testing.T) {
+	r := &Runner{options: &Options{Retries: 1, RetryStrategy: RetryAdaptive}, responses: newResponseTracker()}
+	r.responses.add("10.0.0.1", 80)
+	ports := []*port.Port{{Port: 80, Protocol: protocol.TCP}, {Port: 443, Protocol: protocol.TCP}}
+
+	// regular retries resend the unanswered ports, silent hosts included
+	require.Equal(t, []*port.Port{ports[1]}, r.ambiguousPorts("10.0.0.1", ports, 0))
+	require.Equal(t, ports, r.ambiguousPorts("10.0.0.2", ports, 0))
+	// extra retries are reserved to the hosts that answered
+	require.Equal(t, []*port.Port{ports[1]}, r.ambiguousPorts("10.0.0.1", ports, 1))
+	require.Empty(t, r.ambiguousPorts("10.0.0.2", ports, 1))
+}
+
+func TestResponseTrackerCap(t *testing.T) {
+	tracker := newResponseTracker()
+	tracker.entries = maxTrackedResponses - 1
+	tracker.add("10.0.0.1", 80)
+	tracker.add("10.0.0.1", 443)
+	tracker.add("10.0.0.2", 80)
+	require.Equal(t, maxTrackedResponses, tracker.entries)
+	require.Len(t, tracker.hosts, 1)
+	require.False(t, tracker.isAnswered("10.0.0.1", &port.Port{Port: 80, Protocol: protocol.TCP}))
+}
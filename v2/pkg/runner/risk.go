@@ -0,0 +1,102 @@
+package runner
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+)
+
+// riskRule flags a clearly outdated high risk service from the banner, service and version of
+// its open port
+type riskRule struct {
+	match func(p *port.Port) bool
+	note  string
+}
+
+// riskRules are the heuristics tagging the results before deeper scanning tools run, they only
+// match unambiguous indicators to keep false positives low
+var riskRules = []riskRule{
+	{
+		match: func(p *port.Port) bool {
+			return strings.HasPrefix(p.Banner, "SSH-1.") && !strings.HasPrefix(p.Banner, "SSH-1.99")
+		},
+		note: "ssh protocol 1 only",
+	},
+	{
+		match: func(p *port.Port) bool {
+			major, minor, ok := opensshVersion(p.Version)
+			return ok && (major < 7 || (major == 7 && minor < 4))
+		},
+		note: "openssh older than 7.4, end of life with known vulnerabilities",
+	},
+	{
+		match: func(p *port.Port) bool {
+			return strings.Contains(p.Banner, "vsFTPd 2.3.4")
+		},
+		note: "vsftpd 2.3.4 backdoor (CVE-2011-2523)",
+	},
+	{
+		match: func(p *port.Port) bool {
+			// raw probe responses are hex encoded, \xffSMB starts the smb1 header
+			return strings.Contains(p.Banner, "ff534d42")
+		},
+		note: "smbv1 enabled (MS17-010 class vulnerabilities)",
+	},
+	{
+		match: func(p *port.Port) bool {
+			return strings.HasPrefix(p.Version, "Microsoft-IIS/6.0") || strings.HasPrefix(p.Version, "Microsoft-IIS/5.")
+		},
+		note: "iis 6.0 or older, end of life (CVE-2017-7269)",
+	},
+	{
+		match: func(p *port.Port) bool {
+			return strings.HasPrefix(p.Version, "Apache/2.2.") || strings.HasPrefix(p.Version, "Apache/2.0.") || strings.HasPrefix(p.Version, "Apache/1.")
+		},
+		note: "apache httpd 2.2 or older, end of life",
+	},
+	{
+		match: func(p *port.Port) bool {
+			return p.Service == "vnc" && p.Version == "003.003"
+		},
+		note: "vnc protocol 3.3, unencrypted authentication",
+	},
+}
+
+// opensshVersion returns the major and minor version of an OpenSSH software version (OpenSSH_7.2p2)
+func opensshVersion(version string) (int, int, bool) {
+	value, ok := strings.CutPrefix(version, "OpenSSH_")
+	if !ok {
+		return 0, 0, false
+	}
+	majorValue, rest, _ := strings.Cut(value, ".")
+	major, err := strconv.Atoi(majorValue)
+	if err != nil {
+		return 0, 0, false
+	}
+	minorValue := rest
+	if end := strings.IndexFunc(minorValue, func(r rune) bool { return r < '0' || r > '9' }); end >= 0 {
+		minorValue = minorValue[:end]
+	}
+	minor, err := strconv.Atoi(minorValue)
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
+// assessRisk returns the notes of the risk rules matching the open port, separated by "; "
+func assessRisk(p *port.Port) string {
+	// banners grabbed without service identification still announce their version
+	subject := *p
+	if subject.Service == "" && subject.Banner != "" {
+		subject.Service, subject.Version = matchBanner(subject.Banner)
+	}
+	var notes []string
+	for _, rule := range riskRules {
+		if rule.match(&subject) {
+			notes = append(notes, rule.note)
+		}
+	}
+	return strings.Join(notes, "; ")
+}
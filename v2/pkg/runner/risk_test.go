@@ -0,0 +1,40 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssessRisk(t *testing.T) {
+	for _, test := range []struct {
+		port *port.Port
+		risk string
+	}{
+		{&port.Port{Banner: "SSH-2.0-OpenSSH_5.3"}, "openssh older than 7.4, end of life with known vulnerabilities"},
+		{&port.Port{Service: "ssh", Version: "OpenSSH_7.2p2", Banner: "SSH-2.0-OpenSSH_7.2p2 Ubuntu-4ubuntu2.8"}, "openssh older than 7.4, end of life with known vulnerabilities"},
+		{&port.Port{Banner: "SSH-2.0-OpenSSH_8.9p1 Ubuntu-3"}, ""},
+		{&port.Port{Banner: "SSH-1.5-OpenSSH_8.9"}, "ssh protocol 1 only"},
+		{&port.Port{Banner: "SSH-1.99-OpenSSH_9.0"}, ""},
+		{&port.Port{Banner: "220 (vsFTPd 2.3.4)"}, "vsftpd 2.3.4 backdoor (CVE-2011-2523)"},
+		{&port.Port{Banner: "000000.0ff534d4272"}, "smbv1 enabled (MS17-010 class vulnerabilities)"},
+		{&port.Port{Service: "http", Version: "Microsoft-IIS/6.0"}, "iis 6.0 or older, end of life (CVE-2017-7269)"},
+		{&port.Port{Service: "http", Version: "Microsoft-IIS/10.0"}, ""},
+		{&port.Port{Service: "https", Version: "Apache/2.2.15 (CentOS)"}, "apache httpd 2.2 or older, end of life"},
+		{&port.Port{Service: "http", Version: "Apache/2.4.57"}, ""},
+		{&port.Port{Banner: "RFB 003.003"}, "vnc protocol 3.3, unencrypted authentication"},
+	} {
+		require.Equal(t, test.risk, assessRisk(test.port), test.port.Banner+test.port.Version)
+	}
+}
+
+func TestOpensshVersion(t *testing.T) {
+	major, minor, ok := opensshVersion("OpenSSH_7.2p2")
+	require.True(t, ok)
+	require.Equal(t, []int{7, 2}, []int{major, minor})
+	_, _, ok = opensshVersion("dropbear_2022.83")
+	require.False(t, ok)
+	_, _, ok = opensshVersion("OpenSSH_x")
+	require.False(t, ok)
+}
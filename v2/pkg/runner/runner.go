@@ -6,6 +6,7 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"math/big"
 	"net"
 	"net/http"
@@ -14,15 +15,16 @@ import (
 	"path/filepath"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/miekg/dns"
 	"github.com/pkg/errors"
-	"github.com/projectdiscovery/blackrock"
 	"github.com/projectdiscovery/clistats"
 	"github.com/projectdiscovery/dnsx/libs/dnsx"
 	"github.com/projectdiscovery/gologger"
 	"github.com/projectdiscovery/mapcidr"
+	"github.com/projectdiscovery/mapcidr/asn"
 	"github.com/projectdiscovery/naabu/v2/pkg/port"
 	"github.com/projectdiscovery/naabu/v2/pkg/privileges"
 	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
@@ -35,19 +37,109 @@ import (
 	iputil "github.com/projectdiscovery/utils/ip"
 	sliceutil "github.com/projectdiscovery/utils/slice"
 	"github.com/remeh/sizedwaitgroup"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Runner is an instance of the port enumeration
 // client used to orchestrate the whole process.
 type Runner struct {
-	options       *Options
-	targetsFile   string
-	scanner       *scan.Scanner
-	limiter       *ratelimit.Limiter
-	wgscan        sizedwaitgroup.SizedWaitGroup
-	dnsclient     *dnsx.DNSX
-	stats         *clistats.Statistics
-	streamChannel chan Target
+	options     *Options
+	targetsFile string
+	lock        *scanLock
+	scanner     *scan.Scanner
+	limiter     *ratelimit.Limiter
+	wgscan      sizedwaitgroup.SizedWaitGroup
+	// sockets bounds the sockets opened at once by the connect, verification, banner, interception
+	// and anycast dials to the open file limit fileLimit
+	sockets        socketBudget
+	socketsOnce    sync.Once
+	fileLimit      uint64
+	resolver       Resolver
+	dnsCache       *dnsCache
+	dualStack      dualStackHosts
+	cnames         *cnameChains
+	tagger         *tagger
+	severities     *severityMap
+	baseline       *baseline
+	fingerprints   fingerprints
+	stats          *clistats.Statistics
+	streamChannel  chan Target
+	probesServer   *http.Server
+	resultsServer  *http.Server
+	tracer         trace.Tracer
+	tracerProvider *sdktrace.TracerProvider
+	traceCtx       context.Context
+	scanWindow     *scanWindow
+	sample         *sample
+	// networkGate is write locked while transmission is paused by the network watcher
+	networkGate     sync.RWMutex
+	activeSourceIP  string
+	standbySourceIP string
+	// watchedInterface is the scanning interface monitored for link changes
+	watchedInterface string
+	networkErr       error
+	banners          *bannerGrabber
+	rawProbe         []byte
+	portStripes      []int
+	connectPorts     map[int]struct{} // ports probed with a full connection in syn scans
+	filter           *resultFilter
+	vhosts           *vhostChecker
+	scopeFilter      *scopeFilter
+	// scopeWarned holds the targets whose out of scope ips were logged
+	scopeWarned sync.Map
+	asnRates    *asnRateLimiter
+	// resumeLocked is set while the scan holds the checkpoint lock
+	resumeLocked atomic.Bool
+	// stopped is set once the scan must not send further probes, stopCh is closed at the same time
+	stopped atomic.Bool
+	stopMu  sync.Mutex
+	stopCh  chan struct{}
+	// killed is set once the kill switch stopped the scan, killCh is closed at the same time
+	killed atomic.Bool
+	killCh chan struct{}
+	// probesSent counts the port probes sent during the scan
+	probesSent atomic.Uint64
+	// probesAnswered counts the port probes answered by the targets
+	probesAnswered atomic.Uint64
+	// probesDropped is set once probes were detected as lost on the path
+	probesDropped atomic.Bool
+	// responses tracks the answered ports of the hosts for adaptive retries
+	responses *responseTracker
+	// backoff spaces the syn retransmissions to each host when the retry strategy is backoff
+	backoff *backoffTracker
+	// adaptiveRate paces the port scan from the response ratio when the rate is adaptive
+	adaptiveRate *adaptiveRate
+	// udpClosed holds the udp ports answered with an icmp port unreachable
+	udpClosed *result.Result
+	// clocks are the tcp timestamps of the hosts estimating their uptime
+	clocks *clockTracker
+	// evidence are the captured responses of the hosts for the evidence bundle
+	evidence *evidenceCollector
+	// durations are the first and last activity of the hosts of full range scans
+	durations *hostDurations
+	// anycast are the ttls and round trip times of the hosts detecting anycast addresses
+	anycast *anycastDetector
+	// writers receive the open ports as soon as they are confirmed
+	writers []ResultWriter
+	// arpSubnets are the ipv4 subnets of the scanning interfaces whose targets are ARP pinged
+	arpSubnets []*net.IPNet
+	// resolveOverrides are the static host addresses looked up before dns
+	resolveOverrides resolveOverrides
+	research         *researchWriter
+	progress         *progressFile
+	resources        *resourceMonitor
+	enricher         *enricher
+	// stages are the workers, queue depths and latencies of the scan stages
+	stages     pipelineMetrics
+	anonymizer *anonymizer
+	profiler   *profiler
+	errors     errorCollector
+
+	// Hooks are the callbacks invoked at each phase of the scan
+	Hooks Hooks
+	live  liveResults
 }
 
 type Target struct {
@@ -55,11 +147,26 @@ type Target struct {
 	Cidr string
 	Fqdn string
 	Port string
+	Asn  string
 }
 
 // NewRunner creates a new runner struct instance by parsing
 // the configuration options, configuring sources, reading lists, etc
 func NewRunner(options *Options) (*Runner, error) {
+	lock, err := acquireScanLock(options.LockFile, options.LockPolicy)
+	if err != nil {
+		return nil, err
+	}
+	runner, err := newRunner(options)
+	if err != nil {
+		_ = lock.release()
+		return nil, err
+	}
+	runner.lock = lock
+	return runner, nil
+}
+
+func newRunner(options *Options) (*Runner, error) {
 	if options.Retries == 0 {
 		options.Retries = DefaultRetriesSynScan
 	}
@@ -73,47 +180,140 @@ func NewRunner(options *Options) (*Runner, error) {
 	dnsOptions := dnsx.DefaultOptions
 	dnsOptions.MaxRetries = runner.options.Retries
 	dnsOptions.Hostsfile = true
-	if sliceutil.Contains(options.IPVersion, "6") {
+	if len(options.DNSQueryTypes) > 0 {
+		dnsOptions.QuestionTypes = dnsQuestionTypes(options.DNSQueryTypes)
+	} else if sliceutil.Contains(options.IPVersion, "6") || options.autoIPVersion() {
 		dnsOptions.QuestionTypes = append(dnsOptions.QuestionTypes, dns.TypeAAAA)
 	}
 	if len(runner.options.baseResolvers) > 0 {
 		dnsOptions.BaseResolvers = runner.options.baseResolvers
 	}
-	dnsclient, err := dnsx.New(dnsOptions)
+	runner.resolver = options.Resolver
+	if runner.resolver == nil {
+		runner.resolver = newDNSXResolver(dnsOptions)
+	}
+	runner.dnsCache = newDNSCache(options.DNSCacheTTL, options.DNSNegativeCacheTTL)
+	runner.cnames = newCNAMEChains()
+	var err error
+	if options.ResolveOverride != "" {
+		runner.resolveOverrides, err = loadResolveOverrides(options.ResolveOverride)
+		if err != nil {
+			return nil, fmt.Errorf("could not read resolve overrides: %s", err)
+		}
+	}
+	runner.severities = newSeverityMap()
+	if options.SeverityMap != "" {
+		runner.severities, err = loadSeverityMap(options.SeverityMap)
+		if err != nil {
+			return nil, fmt.Errorf("could not read severity map: %s", err)
+		}
+	}
+	if options.Baseline != "" {
+		runner.baseline, err = loadBaseline(options.Baseline)
+		if err != nil {
+			return nil, fmt.Errorf("could not read baseline: %s", err)
+		}
+	}
+	if options.ExcludeFingerprints != "" {
+		runner.fingerprints, err = loadFingerprints(options.ExcludeFingerprints)
+		if err != nil {
+			return nil, fmt.Errorf("could not read fingerprints: %s", err)
+		}
+	}
+	if options.VhostCheck {
+		runner.vhosts = newVhostChecker()
+	}
+	if options.TagRules != "" {
+		runner.tagger, err = loadTagRules(options.TagRules)
+		if err != nil {
+			return nil, fmt.Errorf("could not read tag rules: %s", err)
+		}
+		if options.NoDNS {
+			runner.tagger.lookup = noLookup
+		} else {
+			runner.tagger.lookup = runner.lookupAddr
+		}
+	}
+
+	excludedIps, err := runner.parseExcludedIps(options)
 	if err != nil {
 		return nil, err
 	}
-	runner.dnsclient = dnsclient
 
-	excludedIps, err := runner.parseExcludedIps(options)
+	if len(options.ScopeFilter) > 0 {
+		runner.scopeFilter, err = loadScopeFilter(options.ScopeFilter)
+		if err != nil {
+			return nil, err
+		}
+		excludedIps = append(excludedIps, runner.scopeFilter.CIDRs()...)
+	}
+
+	if options.OptOutURL != "" {
+		optOut, err := runner.loadOptOutList()
+		if err != nil {
+			return nil, fmt.Errorf("could not load opt-out list: %s", err)
+		}
+		excludedIps = append(excludedIps, optOut...)
+	}
+
+	tos, err := parseTOS(options.TOS)
 	if err != nil {
 		return nil, err
 	}
 
 	runner.streamChannel = make(chan Target)
+	options.configureAutoRate()
 
 	scanner, err := scan.NewScanner(&scan.Options{
-		Timeout:       time.Duration(options.Timeout) * time.Millisecond,
-		Retries:       options.Retries,
-		Rate:          options.Rate,
-		PortThreshold: options.PortThreshold,
-		Debug:         options.Debug,
-		ExcludeCdn:    options.ExcludeCDN,
-		OutputCdn:     options.OutputCDN,
-		ExcludedIps:   excludedIps,
-		Proxy:         options.Proxy,
-		ProxyAuth:     options.ProxyAuth,
-		Stream:        options.Stream,
+		Timeout:         time.Duration(options.Timeout) * time.Millisecond,
+		Retries:         options.Retries,
+		Rate:            options.Rate,
+		PortThreshold:   options.PortThreshold,
+		Debug:           options.Debug,
+		ExcludeCdn:      options.ExcludeCDN,
+		OutputCdn:       options.OutputCDN,
+		ExcludedIps:     excludedIps,
+		Proxy:           options.Proxy,
+		ProxyAuth:       options.ProxyAuth,
+		Stream:          options.Stream,
+		ResetClose:      options.ConnectReset,
+		DialerCacheSize: options.DialerCache,
+		Mark:            options.FwMark,
+		TOS:             tos,
+		SSHProxy:        options.SSHProxy,
+		SSHKey:          options.SSHKey,
+		Timestamps:      options.TCPTimestamps,
+		Capture:         options.EvidenceOutput != "",
+		ConnectCriteria: options.ConnectCriteria,
+		PacketTrace:     options.PacketTrace,
+		SampleDrops:     options.SampleDrops,
+		BufferSize:      options.RxBuffer * 1024 * 1024,
+		Tunnel:          options.Tunnel,
+		ProbeTag:        options.ProbeTag,
+		Vantage:         options.Vantage,
+		ActivityLog:     options.ActivityLog,
+		LookupHost:      runner.lookupHost,
 	})
 	if err != nil {
 		return nil, err
 	}
 	runner.scanner = scanner
 
-	runner.scanner.Ports, err = ParsePorts(options)
+	runner.scanner.Ports, err = parsePortSelection(options)
 	if err != nil {
 		return nil, fmt.Errorf("could not parse ports: %s", err)
 	}
+	runner.connectPorts, err = parseConnectPorts(options.ConnectPorts)
+	if err != nil {
+		return nil, err
+	}
+	if options.PortStripes != "" {
+		stripes, err := parsePortStripes(options.PortStripes)
+		if err != nil {
+			return nil, err
+		}
+		runner.scanner.Ports, runner.portStripes = stripePorts(runner.scanner.Ports, stripes)
+	}
 
 	if options.EnableProgressBar {
 		defaultOptions := &clistats.DefaultOptions
@@ -126,39 +326,227 @@ func NewRunner(options *Options) (*Runner, error) {
 		}
 	}
 
+	if options.RawProbe != "" {
+		runner.rawProbe, err = loadRawProbe(options.RawProbe)
+		if err != nil {
+			return nil, fmt.Errorf("could not read raw probe: %s", err)
+		}
+	}
+	if options.Pipeline != "" || options.ProgressFile != "" || runner.stats != nil {
+		runner.stages = newPipelineMetrics()
+		if runner.stats != nil {
+			runner.stages.addStats(runner.stats)
+		}
+	}
+	if runner.monitorsResources() {
+		runner.resources = newResourceMonitor()
+		if runner.stats != nil {
+			runner.addResourceStats(runner.stats)
+		}
+	}
+	if options.Banner || options.ServiceVersion || runner.rawProbe != nil || options.EvidenceOutput != "" {
+		runner.banners = newBannerGrabber(runner)
+		if runner.stats != nil {
+			runner.banners.addStats(runner.stats)
+		}
+	}
+
+	runner.scanner.OnPortFound = runner.onPortFound
+	runner.scanner.OnHostFound = runner.onHostDiscovered
+	runner.scanner.Phase.OnTransition(runner.onPhaseChange)
+
+	if options.Anonymize != "" {
+		runner.anonymizer, err = newAnonymizer(options.Anonymize, options.AnonymizeKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if options.ResearchOutput != "" {
+		runner.research, err = newResearchWriter(options.ResearchOutput)
+		if err != nil {
+			return nil, fmt.Errorf("could not create research output file: %s", err)
+		}
+		runner.research.anonymizer = runner.anonymizer
+	}
+	if options.ProgressFile != "" {
+		runner.progress, err = newProgressFile(options.ProgressFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not create progress file: %s", err)
+		}
+	}
+	runner.scanner.OnPortClosed = runner.onPortClosed
+	runner.udpClosed = result.NewResult()
+
+	if options.AdaptiveRate {
+		runner.adaptiveRate = newAdaptiveRate(options.Rate)
+	}
+
+	if options.RetryStrategy == RetryAdaptive || options.RetryStrategy == RetryBackoff {
+		runner.responses = newResponseTracker()
+	}
+	if options.RetryStrategy == RetryBackoff {
+		runner.backoff = newBackoffTracker()
+	}
+
+	if options.TCPTimestamps {
+		runner.clocks = newClockTracker()
+	}
+
+	if options.EvidenceOutput != "" {
+		runner.evidence = newEvidenceCollector()
+	}
+
+	if options.AnycastCheck {
+		runner.anycast = newAnycastDetector()
+	}
+
+	if options.EnrichURL != "" {
+		runner.enricher = newEnricher(options.EnrichURL)
+	}
+	if options.usesFullRangePreset() {
+		runner.durations = newHostDurations()
+	}
+	if runner.tracksResponses() {
+		runner.scanner.OnResponse = runner.onResponse
+	} else {
+		runner.scanner.OnAnswer = runner.onAnswer
+	}
+
+	if len(options.AsnRate) > 0 {
+		rates, err := parseASNRates(options.AsnRate)
+		if err != nil {
+			return nil, err
+		}
+		runner.asnRates, err = newASNRateLimiter(rates, asn.GetCIDRsForASNNum)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if options.Filter != "" {
+		runner.filter, err = newResultFilter(options.Filter)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if options.ScanWindow != "" {
+		runner.scanWindow, err = parseScanWindow(options.ScanWindow)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if options.Sample != "" {
+		runner.sample, err = parseSample(options.Sample)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the writers are opened once the options are parsed, and closed with the servers if the
+	// runner isn't created
+	runner.writers, err = newResultWriters(options)
+	if err != nil {
+		return nil, err
+	}
+	created := false
+	defer func() {
+		if !created {
+			runner.closeWriters()
+			runner.closeServers()
+		}
+	}()
+
+	if err := runner.setupTracing(); err != nil {
+		return nil, fmt.Errorf("could not setup tracing: %s", err)
+	}
+
+	if options.ProbesAddr != "" {
+		if err := runner.startProbes(); err != nil {
+			return nil, fmt.Errorf("could not start probes server: %s", err)
+		}
+	}
+	if options.ResultsAddr != "" {
+		if err := runner.startResults(); err != nil {
+			return nil, fmt.Errorf("could not start results server: %s", err)
+		}
+	}
+
+	if options.ProfileCPU != "" || options.ProfileMem != "" {
+		runner.profiler, err = startProfiler(options.ProfileCPU, options.ProfileMem)
+		if err != nil {
+			return nil, fmt.Errorf("could not start profiling: %s", err)
+		}
+	}
+
+	created = true
 	return runner, nil
 }
 
-// RunEnumeration runs the ports enumeration flow on the targets specified
+// RunEnumeration runs the ports enumeration flow on the targets specified. Errors stopping the
+// enumeration are returned, the errors it continued after are available from Errors once it completes.
 func (r *Runner) RunEnumeration() error {
+	defer r.closeResults()
+	defer r.closeWriters()
+	if err := r.checkLiveFingerprints(); err != nil {
+		return err
+	}
+	if err := r.runEnumeration(); err != nil {
+		return err
+	}
+	r.cleanupResume()
+	return nil
+}
+
+func (r *Runner) runEnumeration() error {
 	defer r.Close()
 
-	if privileges.IsPrivileged && r.options.ScanType == SynScan {
+	ctx, span := r.tracer.Start(context.Background(), "enumeration", trace.WithAttributes(
+		attribute.String("scan_type", r.options.ScanType),
+		attribute.Int("rate", r.options.Rate),
+		attribute.Int("retries", r.options.Retries),
+	))
+	defer span.End()
+	r.traceCtx = ctx
+
+	if privileges.IsPrivileged && r.options.isRawScanType() {
 		// Set values if those were specified via cli, errors are fatal
 		if r.options.SourceIP != "" {
 			err := r.SetSourceIP(r.options.SourceIP)
 			if err != nil {
-				return err
+				return &EnumerationError{Category: ErrorNetwork, Target: r.options.SourceIP, Err: err}
 			}
+			r.activeSourceIP = r.options.SourceIP
+			r.standbySourceIP = r.options.SourceIPFailover
 		}
 		if r.options.Interface != "" {
 			err := r.SetInterface(r.options.Interface)
 			if err != nil {
-				return err
+				return &EnumerationError{Category: ErrorNetwork, Target: r.options.Interface, Err: err}
 			}
 		}
 		if r.options.SourcePort != "" {
 			err := r.SetSourcePort(r.options.SourcePort)
 			if err != nil {
-				return err
+				return &EnumerationError{Category: ErrorNetwork, Target: r.options.SourcePort, Err: err}
 			}
 		}
 
 		err := r.scanner.SetupHandlers()
 		if err != nil {
-			return err
+			category, ok := socketErrorCategory(err)
+			if !ok {
+				category = ErrorNetwork
+			}
+			return &EnumerationError{Category: category, Err: err}
 		}
 		r.BackgroundWorkers()
+
+		watchCtx, cancelWatch := context.WithCancel(context.Background())
+		defer cancelWatch()
+		r.startNetworkWatch(watchCtx)
 	}
 
 	if r.options.Stream {
@@ -171,8 +559,25 @@ func (r *Runner) RunEnumeration() error {
 	}
 
 	// Scan workers
-	r.wgscan = sizedwaitgroup.New(r.options.Rate)
-	r.limiter = ratelimit.New(context.Background(), uint(r.options.Rate), time.Second)
+	r.wgscan = sizedwaitgroup.New(r.scanConcurrency())
+	r.limiter = ratelimit.New(context.Background(), uint(r.maxRate()), time.Second)
+
+	if r.options.VerifyOwnership != "" {
+		if err := r.verifyOwnership(); err != nil {
+			return err
+		}
+	}
+
+	var detected interception
+	if r.options.InterceptCheck != "" {
+		detected = r.detectInterception()
+	}
+
+	killCtx, cancelKill := context.WithCancel(context.Background())
+	defer cancelKill()
+	r.startKillSwitch(killCtx)
+
+	r.onScanStart()
 
 	shouldDiscoverHosts := r.options.shouldDiscoverHosts()
 	shouldUseRawPackets := r.options.shouldUseRawPackets()
@@ -180,7 +585,13 @@ func (r *Runner) RunEnumeration() error {
 	if shouldDiscoverHosts && shouldUseRawPackets {
 		// perform host discovery
 		showHostDiscoveryInfo()
-		r.scanner.Phase.Set(scan.HostDiscovery)
+		r.setPhase(scan.HostDiscovery)
+		discoverySpan := r.startSpan("host-discovery")
+		// ended once the discovery completes, or on the early error returns
+		defer discoverySpan.End()
+		if r.options.arpLocalSubnets {
+			r.arpSubnets = r.localSubnets()
+		}
 		// shrinks the ips to the minimum amount of cidr
 		_, targetsV4, targetsv6, _, err := r.GetTargetIps(r.getPreprocessedIps)
 		if err != nil {
@@ -199,41 +610,72 @@ func (r *Runner) RunEnumeration() error {
 			excludedIPsMap[ipString] = struct{}{}
 		}
 
-		discoverCidr := func(cidr *net.IPNet) {
+		var probedHosts uint64
+		discoverCidr := func(cidr *net.IPNet) error {
 			ipStream, _ := mapcidr.IPAddressesAsStream(cidr.String())
 			for ip := range ipStream {
+				if r.scanStopped() {
+					continue
+				}
 				// only run host discovery if the ip is not present in the excludedIPsMap
 				if _, exists := excludedIPsMap[ip]; !exists {
+					if err := r.waitBeforeSend(); err != nil {
+						return err
+					}
+					start := r.stages.start(StageDiscover, false)
 					r.handleHostDiscovery(ip)
+					r.stages.done(StageDiscover, start)
+					probedHosts++
 				}
 			}
+			return nil
 		}
 
-		for _, target4 := range targetsV4 {
-			discoverCidr(target4)
-		}
-		for _, target6 := range targetsv6 {
-			discoverCidr(target6)
+		for _, target := range append(targetsV4, targetsv6...) {
+			if err := discoverCidr(target); err != nil {
+				return err
+			}
 		}
 
 		if r.options.WarmUpTime > 0 {
 			time.Sleep(time.Duration(r.options.WarmUpTime) * time.Second)
 		}
+		// late discovery replies are ignored until the port scan starts
+		r.setPhase(scan.Guard)
+		discoverySpan.End()
+		r.scanner.FlushResults()
+		if r.options.discoversFamilies() {
+			r.selectDiscoveredFamilies(r.scanner.HostDiscoveryResults)
+		}
+		gologger.Info().Msgf("Host discovery found %d alive hosts out of %d\n", r.scanner.HostDiscoveryResults.Len(), probedHosts)
+		r.handleHostDiscoveryOutput()
 
 		// check if we should stop here or continue with full scan
 		if r.options.OnlyHostDiscovery {
+			r.setPhase(scan.Done)
 			r.handleOutput(r.scanner.HostDiscoveryResults)
+			r.onScanComplete(r.scanner.HostDiscoveryResults)
 			return nil
 		}
 	}
 
+	canaryCtx, cancelCanary := context.WithCancel(context.Background())
+	defer cancelCanary()
+	r.startCanary(canaryCtx)
+	r.startAdaptiveRate(canaryCtx)
+	r.startProgress(canaryCtx)
+
 	switch {
 	case r.options.Stream && !r.options.Passive: // stream active
 		showNetworkCapabilities(r.options)
-		r.scanner.Phase.Set(scan.Scan)
+		r.setPhase(scan.Scan)
 
 		handleStreamIp := func(target string, port *port.Port) bool {
-			if r.scanner.ScanResults.HasSkipped(target) {
+			if r.scanStopped() || r.scanner.ScanResults.HasStopped(target) {
+				return false
+			}
+			// the out of scope ranges of the streamed cidrs were logged as they were loaded
+			if !r.scanner.IPRanger.Np.ValidateAddress(target) {
 				return false
 			}
 			if r.options.PortThreshold > 0 && r.scanner.ScanResults.GetPortCount(target) >= r.options.PortThreshold {
@@ -242,12 +684,12 @@ func (r *Runner) RunEnumeration() error {
 				r.scanner.ScanResults.AddSkipped(target)
 				return false
 			}
-			if shouldUseRawPackets {
-				r.RawSocketEnumeration(target, port)
-			} else {
-				r.wgscan.Add()
-				go r.handleHostPort(target, port)
+			if err := r.waitBeforeSend(); err != nil {
+				return false
 			}
+			r.scheduleProbe(target, func() {
+				r.enumerate(target, port, shouldUseRawPackets)
+			})
 			return true
 		}
 
@@ -257,25 +699,39 @@ func (r *Runner) RunEnumeration() error {
 			}
 			if ipStream, err := mapcidr.IPAddressesAsStream(target.Cidr); err == nil {
 				for ip := range ipStream {
-					for _, port := range r.scanner.Ports {
-						if !handleStreamIp(ip, port) {
+					// the total of a stream grows with the targets read
+					r.progress.addTotal(uint64(r.scanner.Ports.Len()))
+					for index := 0; index < r.scanner.Ports.Len(); index++ {
+						if !handleStreamIp(ip, r.scanner.Ports.At(index)) {
+							r.progress.advance(uint64(r.scanner.Ports.Len() - index))
 							break
 						}
+						r.progress.advance(1)
 					}
 				}
 			} else if target.Ip != "" && target.Port != "" {
 				pp, _ := strconv.Atoi(target.Port)
+				r.progress.addTotal(1)
 				handleStreamIp(target.Ip, &port.Port{Port: pp, Protocol: protocol.TCP})
+				r.progress.advance(1)
+			}
+			if err := r.waitNetwork(); err != nil {
+				r.waitASNQueues()
+				r.wgscan.Wait()
+				return err
 			}
 		}
+		r.waitASNQueues()
 		r.wgscan.Wait()
+		r.reportCoverage(r.scanner.ScanResults, 0, 0)
 		r.handleOutput(r.scanner.ScanResults)
+		r.onScanComplete(r.scanner.ScanResults)
 		return nil
 	case r.options.Stream && r.options.Passive: // stream passive
 		showNetworkCapabilities(r.options)
 		// create retryablehttp instance
 		httpClient := retryablehttp.NewClient(retryablehttp.DefaultOptionsSingle)
-		r.scanner.Phase.Set(scan.Scan)
+		r.setPhase(scan.Scan)
 		for target := range r.streamChannel {
 			if err := r.scanner.IPRanger.Add(target.Cidr); err != nil {
 				gologger.Warning().Msgf("Couldn't track %s in scan results: %s\n", target, err)
@@ -297,10 +753,12 @@ func (r *Runner) RunEnumeration() error {
 					response, err := httpClient.Do(request)
 					if err != nil {
 						gologger.Warning().Msgf("Couldn't retrieve http response for %s: %s\n", ip, err)
+						r.recordError(ErrorNetwork, ip, err)
 						return
 					}
 					if response.StatusCode != http.StatusOK {
 						gologger.Warning().Msgf("Couldn't retrieve data for %s, server replied with status code: %d\n", ip, response.StatusCode)
+						r.recordError(ErrorNetwork, ip, fmt.Errorf("unexpected status code %d", response.StatusCode))
 						return
 					}
 
@@ -312,7 +770,7 @@ func (r *Runner) RunEnumeration() error {
 					}
 
 					for _, p := range data.Ports {
-						r.scanner.ScanResults.AddPort(ip, &port.Port{Port: p, Protocol: protocol.TCP})
+						r.scanner.StorePort(ip, &port.Port{Port: p, Protocol: protocol.TCP})
 					}
 				}(ip)
 			}
@@ -325,6 +783,7 @@ func (r *Runner) RunEnumeration() error {
 		}
 
 		r.handleOutput(r.scanner.ScanResults)
+		r.onScanComplete(r.scanner.ScanResults)
 
 		// handle nmap
 		return r.handleNmap()
@@ -348,11 +807,22 @@ func (r *Runner) RunEnumeration() error {
 			}
 			targetsCount += mapcidr.AddressCountIpnet(target)
 		}
-		portsCount = uint64(len(r.scanner.Ports))
+		portsCount = uint64(r.scanner.Ports.Len())
 		targetsWithPortCount = uint64(len(targetsWithPort))
 
-		r.scanner.Phase.Set(scan.Scan)
+		r.setPhase(scan.Scan)
+		scanSpan := r.startSpan("scan",
+			attribute.Int64("hosts", int64(targetsCount)),
+			attribute.Int64("ports", int64(portsCount)),
+			attribute.Int64("hosts_with_port", int64(targetsWithPortCount)),
+		)
+		defer scanSpan.End()
 		Range := targetsCount * portsCount
+		// a sample scans the first host:port pairs of the shuffled space
+		scanRange := r.sample.size(Range)
+		if r.sample != nil {
+			gologger.Info().Msgf("Sampling %d of %d host:port pairs\n", scanRange, Range)
+		}
 		if r.options.EnableProgressBar {
 			r.stats.AddStatic("ports", portsCount)
 			r.stats.AddStatic("hosts", targetsCount)
@@ -360,27 +830,58 @@ func (r *Runner) RunEnumeration() error {
 			r.stats.AddStatic("startedAt", time.Now())
 			r.stats.AddCounter("packets", uint64(0))
 			r.stats.AddCounter("errors", uint64(0))
-			r.stats.AddCounter("total", Range*uint64(r.options.Retries)+targetsWithPortCount)
+			r.stats.AddCounter("total", scanRange*uint64(r.options.Retries)+targetsWithPortCount)
 			r.stats.AddStatic("hosts_with_port", targetsWithPortCount)
 			if err := r.stats.Start(); err != nil {
 				gologger.Warning().Msgf("Couldn't start statistics: %s\n", err)
 			}
 		}
 
+		r.progress.setTotal((scanRange + targetsWithPortCount) * uint64(r.options.retryPasses()))
+		if r.options.Resume {
+			r.restoreResults()
+		}
+		checkpointCtx, stopCheckpoints := context.WithCancel(context.Background())
+		checkpointsDone := make(chan struct{})
+		defer func() {
+			stopCheckpoints()
+			<-checkpointsDone
+		}()
+		go func() {
+			defer close(checkpointsDone)
+			r.checkpointResume(checkpointCtx)
+		}()
+
+		// the backoff retransmissions follow the order of the first pass, so each host waits its own delay
+		var backoffSeed int64
 		// Retries are performed regardless of the previous scan results due to network unreliability
-		for currentRetry := 0; currentRetry < r.options.Retries; currentRetry++ {
+		for currentRetry := 0; currentRetry < r.options.retryPasses() && !r.scanStopped(); currentRetry++ {
 			if currentRetry < r.options.ResumeCfg.Retry {
 				gologger.Debug().Msgf("Skipping Retry: %d\n", currentRetry)
+				r.progress.advance(scanRange + targetsWithPortCount)
 				continue
 			}
+			r.onRetryStart(currentRetry)
 
-			// Use current time as seed
+			// Use current time as seed unless given, sampled scans always probe the same pairs
 			currentSeed := time.Now().UnixNano()
+			if r.options.Seed != 0 {
+				currentSeed = r.options.Seed
+			}
+			if r.sample != nil {
+				currentSeed = sampleSeed
+			}
 			r.options.ResumeCfg.RLock()
 			if r.options.ResumeCfg.Seed > 0 {
 				currentSeed = r.options.ResumeCfg.Seed
 			}
 			r.options.ResumeCfg.RUnlock()
+			if r.backoff != nil {
+				if backoffSeed == 0 {
+					backoffSeed = currentSeed
+				}
+				currentSeed = backoffSeed
+			}
 
 			// keep track of current retry and seed for resume
 			r.options.ResumeCfg.Lock()
@@ -388,52 +889,133 @@ func (r *Runner) RunEnumeration() error {
 			r.options.ResumeCfg.Seed = currentSeed
 			r.options.ResumeCfg.Unlock()
 
-			b := blackrock.New(int64(Range), currentSeed)
-			for index := int64(0); index < int64(Range); index++ {
-				xxx := b.Shuffle(index)
-				ipIndex := xxx / int64(portsCount)
-				portIndex := int(xxx % int64(portsCount))
-				ip := r.PickIP(targets, ipIndex)
-				port := r.PickPort(portIndex)
-
-				r.options.ResumeCfg.RLock()
-				resumeCfgIndex := r.options.ResumeCfg.Index
-				r.options.ResumeCfg.RUnlock()
-				if index < resumeCfgIndex {
-					gologger.Debug().Msgf("Skipping \"%s:%d\": Resume - Port scan already completed\n", ip, port.Port)
-					continue
+			stripes := r.portStripes
+			if len(stripes) == 0 {
+				stripes = []int{int(portsCount)}
+			}
+			burst := r.options.portBurst(shouldUseRawPackets)
+			space := newBurstSpace(int64(targetsCount), stripes, burst, currentSeed)
+			fair := newPrefixInterleaver(r.options.FairnessWindow, r.options.PrefixRate, r.stopChan())
+			send := func(pick *fairPick) error {
+				ip, ports := pick.ip, pick.ports
+				if err := r.waitBeforeSend(); err != nil {
+					r.waitASNQueues()
+					r.wgscan.Wait()
+					return err
 				}
-
-				r.limiter.Take()
+				for range ports {
+					r.limiter.Take()
+				}
+				r.adaptiveRate.wait()
 				//resume cfg logic
 				r.options.ResumeCfg.Lock()
-				r.options.ResumeCfg.Index = index
+				r.options.ResumeCfg.Index = fair.ResumeIndex(pick.index)
 				r.options.ResumeCfg.Unlock()
 
-				if r.scanner.ScanResults.HasSkipped(ip) {
-					continue
+				if r.scanner.ScanResults.HasStopped(ip) {
+					return nil
 				}
 				if r.options.PortThreshold > 0 && r.scanner.ScanResults.GetPortCount(ip) >= r.options.PortThreshold {
 					hosts, _ := r.scanner.IPRanger.GetHostsByIP(ip)
 					gologger.Info().Msgf("Skipping %s %v, Threshold reached \n", ip, hosts)
 					r.scanner.ScanResults.AddSkipped(ip)
-					continue
+					return nil
 				}
 
 				// connect scan
-				if shouldUseRawPackets {
-					r.RawSocketEnumeration(ip, port)
-				} else {
-					r.wgscan.Add()
-					go r.handleHostPort(ip, port)
-				}
+				r.backoff.record(ip, ports)
+				r.scheduleBurst(ip, ports, shouldUseRawPackets)
 				if r.options.EnableProgressBar {
-					r.stats.IncrementCounter("packets", 1)
+					r.stats.IncrementCounter("packets", len(ports))
+				}
+				return nil
+			}
+			// the backoff retransmissions not due yet are deferred, the loop going on with the
+			// other bursts instead of waiting
+			var deferred backoffQueue
+			dispatch := func(pick *fairPick) error {
+				if due := r.backoff.due(pick.ip, pick.ports, currentRetry); time.Now().Before(due) {
+					deferred.add(due, pick)
+					return nil
+				}
+				return send(pick)
+			}
+			sendDue := func() error {
+				for _, pick := range deferred.popDue(time.Now()) {
+					if r.scanStopped() {
+						return nil
+					}
+					if err := send(pick); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+			var picked int64
+			for index := int64(0); index < space.size() && picked < int64(scanRange) && !r.scanStopped(); index++ {
+				ipIndex, portIndex, count := space.pickBurst(index)
+				// a sample ends within the burst of its last pair
+				if remaining := int64(scanRange) - picked; int64(count) > remaining {
+					count = int(remaining)
+				}
+				picked += int64(count)
+				r.progress.advance(uint64(count))
+				ip := r.PickIP(targets, ipIndex)
+				ports := r.pickPorts(portIndex, count)
+
+				r.options.ResumeCfg.RLock()
+				resumeCfgIndex := r.options.ResumeCfg.Index
+				r.options.ResumeCfg.RUnlock()
+				if index < resumeCfgIndex {
+					gologger.Debug().Msgf("Skipping \"%s:%d\": Resume - Port scan already completed\n", ip, ports[0].Port)
+					continue
+				}
+				// adaptive retries only probe the unanswered ports
+				if currentRetry > 0 && r.responses != nil {
+					if ports = r.ambiguousPorts(ip, ports, currentRetry); len(ports) == 0 {
+						continue
+					}
+				}
+
+				for _, pick := range fair.Push(&fairPick{index: index, ip: ip, ports: ports}) {
+					if err := dispatch(pick); err != nil {
+						return err
+					}
+				}
+				if err := sendDue(); err != nil {
+					return err
+				}
+			}
+			for _, pick := range fair.Drain() {
+				if r.scanStopped() {
+					break
+				}
+				if err := dispatch(pick); err != nil {
+					return err
+				}
+			}
+			// the remaining retransmissions are sent as they become due
+			for deferred.Len() > 0 && !r.scanStopped() {
+				if err := sendDue(); err != nil {
+					return err
+				}
+				if deferred.Len() == 0 {
+					break
+				}
+				timer := time.NewTimer(time.Until(deferred.next()))
+				select {
+				case <-timer.C:
+				case <-r.stopChan():
+					timer.Stop()
 				}
 			}
 
 			// handle the ip:port combination
 			for _, targetWithPort := range targetsWithPort {
+				if r.scanStopped() {
+					break
+				}
+				r.progress.advance(1)
 				ip, p, err := net.SplitHostPort(targetWithPort)
 				if err != nil {
 					gologger.Debug().Msgf("Skipping %s: %v\n", targetWithPort, err)
@@ -451,18 +1033,18 @@ func (r *Runner) RunEnumeration() error {
 					Protocol: protocol.TCP,
 				}
 
-				// connect scan
-				if shouldUseRawPackets {
-					r.RawSocketEnumeration(ip, &portWithMetadata)
-				} else {
-					r.wgscan.Add()
-					go r.handleHostPort(ip, &portWithMetadata)
+				if err := r.waitBeforeSend(); err != nil {
+					r.wgscan.Wait()
+					return err
 				}
+
+				r.enumerate(ip, &portWithMetadata, shouldUseRawPackets)
 				if r.options.EnableProgressBar {
 					r.stats.IncrementCounter("packets", 1)
 				}
 			}
 
+			r.waitASNQueues()
 			r.wgscan.Wait()
 
 			r.options.ResumeCfg.Lock()
@@ -475,19 +1057,32 @@ func (r *Runner) RunEnumeration() error {
 			}
 			r.options.ResumeCfg.Unlock()
 		}
+		stopCheckpoints()
 
 		if r.options.WarmUpTime > 0 {
 			time.Sleep(time.Duration(r.options.WarmUpTime) * time.Second)
 		}
+		// the responses arriving after the warm up are ignored while the results are verified and written
+		r.setPhase(scan.Guard)
 
-		r.scanner.Phase.Set(scan.Done)
+		scanSpan.End()
+
+		r.reportCoverage(r.scanner.ScanResults, scanRange, Range)
+		r.reportUDP(scanRange)
+		r.reportAnomalies()
+		r.reportDurations()
+		r.reportScanFallback()
 
 		// Validate the hosts if the user has asked for second step validation
 		if r.options.Verify {
 			r.ConnectVerification()
 		}
+		r.verifyInterception(detected)
+		r.checkAnycast(r.scanner.ScanResults)
+		r.setPhase(scan.Done)
 
 		r.handleOutput(r.scanner.ScanResults)
+		r.onScanComplete(r.scanner.ScanResults)
 
 		// handle nmap
 		return r.handleNmap()
@@ -495,6 +1090,7 @@ func (r *Runner) RunEnumeration() error {
 }
 
 func (r *Runner) getHostDiscoveryIps() (ips []*net.IPNet, ipsWithPort []string) {
+	r.scanner.FlushResults()
 	for ip := range r.scanner.HostDiscoveryResults.GetIPs() {
 		ips = append(ips, iputil.ToCidr(string(ip)))
 	}
@@ -542,13 +1138,49 @@ func (r *Runner) ShowScanResultOnExit() {
 	}
 }
 
+// closeServers closes the probes and results servers
+func (r *Runner) closeServers() {
+	if r.probesServer != nil {
+		_ = r.probesServer.Close()
+	}
+	if r.resultsServer != nil {
+		_ = r.resultsServer.Close()
+	}
+}
+
 // Close runner instance
 func (r *Runner) Close() {
 	_ = os.RemoveAll(r.targetsFile)
 	_ = r.scanner.IPRanger.Hosts.Close()
+	r.scanner.CloseResults()
 	if r.options.EnableProgressBar {
 		_ = r.stats.Stop()
 	}
+	r.closeServers()
+	r.shutdownTracing()
+	if r.research != nil {
+		if err := r.research.Close(); err != nil {
+			gologger.Warning().Msgf("Could not write research output file %s: %s\n", r.options.ResearchOutput, err)
+			r.recordError(ErrorOutput, r.options.ResearchOutput, err)
+		}
+	}
+	if r.options.Pipeline != "" {
+		r.stages.logSummary()
+	}
+	r.resources.Stop()
+	r.reportResources()
+	if err := r.closeProgress(); err != nil {
+		gologger.Warning().Msgf("Could not write progress file %s: %s\n", r.options.ProgressFile, err)
+		r.recordError(ErrorOutput, r.options.ProgressFile, err)
+	}
+	if err := r.scanner.CloseActivityLog(); err != nil {
+		gologger.Warning().Msgf("Could not write activity log %s: %s\n", r.options.ActivityLog, err)
+		r.recordError(ErrorOutput, r.options.ActivityLog, err)
+	}
+	r.stopProfiler()
+	if err := r.lock.release(); err != nil {
+		gologger.Warning().Msgf("Could not release lock file %s: %s\n", r.options.LockFile, err)
+	}
 }
 
 // PickIP randomly
@@ -576,23 +1208,47 @@ func (r *Runner) PickSubnetIP(network *net.IPNet, index int64) string {
 }
 
 func (r *Runner) PickPort(index int) *port.Port {
-	return r.scanner.Ports[index]
+	return r.scanner.Ports.At(index)
+}
+
+// setPhase moves the scan to the next phase
+func (r *Runner) setPhase(state scan.State) {
+	if err := r.scanner.Phase.Transition(state); err != nil {
+		gologger.Warning().Msgf("%s\n", err)
+	}
 }
 
 func (r *Runner) ConnectVerification() {
-	r.scanner.Phase.Set(scan.Scan)
+	r.waitBanners()
+	r.setPhase(scan.Verify)
+	span := r.startSpan("verification")
+	defer span.End()
 	var swg sync.WaitGroup
 	limiter := ratelimit.New(context.Background(), uint(r.options.Rate), time.Second)
 
 	verifiedResult := result.NewResult()
 
 	for hostResult := range r.scanner.ScanResults.GetIPsPorts() {
+		// the results are drained without connecting to the targets once the kill switch triggered
+		if !r.waitTrafficWindow() {
+			continue
+		}
+		r.stages.enqueue(StageVerify)
 		limiter.Take()
+		// each verification dials the ports one after the other, holding a socket
+		r.sockets.acquire()
 		swg.Add(1)
 		go func(hostResult *result.HostResult) {
 			defer swg.Done()
+			defer r.sockets.release()
+			start := r.stages.start(StageVerify, true)
+			defer r.stages.done(StageVerify, start)
 			results := r.scanner.ConnectVerify(hostResult.IP, hostResult.Ports)
+			r.checkVhosts(hostResult.IP, results)
 			verifiedResult.SetPorts(hostResult.IP, results)
+			for _, p := range results {
+				r.publishResult(hostResult.IP, p)
+			}
 		}(hostResult)
 	}
 
@@ -615,7 +1271,13 @@ func (r *Runner) RawSocketEnumeration(ip string, p *port.Port) {
 		gologger.Debug().Msgf("Skipping cdn target: %s:%d\n", ip, p.Port)
 		return
 	}
+	if r.scanStopped() || r.scanner.ScanResults.HasStopped(ip) {
+		return
+	}
 	r.limiter.Take()
+	r.probesSent.Add(1)
+	start := r.stages.start(StageScan, false)
+	defer r.stages.done(StageScan, start)
 	switch p.Protocol {
 	case protocol.TCP:
 		r.scanner.EnqueueTCP(ip, scan.Syn, p)
@@ -642,6 +1304,8 @@ func (r *Runner) canIScanIfCDN(host string, port *port.Port) bool {
 
 func (r *Runner) handleHostPort(host string, p *port.Port) {
 	defer r.wgscan.Done()
+	stageStart := r.stages.start(StageScan, true)
+	defer r.stages.done(StageScan, stageStart)
 
 	// performs cdn scan exclusions checks
 	if !r.canIScanIfCDN(host, p) {
@@ -649,14 +1313,33 @@ func (r *Runner) handleHostPort(host string, p *port.Port) {
 		return
 	}
 
-	if r.scanner.ScanResults.IPHasPort(host, p) {
+	if r.scanStopped() || r.scanner.ScanResults.HasStopped(host) || r.scanner.ScanResults.IPHasPort(host, p) {
 		return
 	}
 
 	r.limiter.Take()
+	r.probesSent.Add(1)
+	r.sockets.acquire()
+	start := time.Now()
 	open, err := r.scanner.ConnectPort(host, p, time.Duration(r.options.Timeout)*time.Millisecond)
+	r.sockets.release()
+	if isAnswered(open, err) {
+		r.probesAnswered.Add(1)
+		if r.responses != nil {
+			r.responses.add(host, p.Port)
+		}
+		r.durations.add(host, time.Now())
+	}
 	if open && err == nil {
-		r.scanner.ScanResults.AddPort(host, p)
+		// ports are shared among hosts, so the response time is attached to a copy
+		found := *p
+		found.RTT = time.Since(start)
+		found.Path = r.scanner.ProbePath(host)
+		r.scanner.AddPort(host, &found)
+	} else if isUDPClosed(p, err) {
+		r.onPortClosed(host, p)
+	} else if category, ok := socketErrorCategory(err); ok {
+		r.recordError(category, host, err)
 	}
 }
 
@@ -676,18 +1359,18 @@ func (r *Runner) handleHostDiscovery(host string) {
 		r.scanner.EnqueueICMP(host, scan.IcmpAddressMaskRequest)
 	}
 	// ARP scan
-	if r.options.ArpPing {
+	if r.options.ArpPing || inSubnets(r.arpSubnets, host) {
 		r.scanner.EnqueueEthernet(host, scan.Arp)
 	}
 	// Syn Probes
 	if len(r.options.TcpSynPingProbes) > 0 {
 		ports, _ := parsePortsSlice(r.options.TcpSynPingProbes)
-		r.scanner.EnqueueTCP(host, scan.Syn, ports...)
+		r.scanner.EnqueueTCP(host, scan.Syn, ports.Ports()...)
 	}
 	// Ack Probes
 	if len(r.options.TcpAckPingProbes) > 0 {
 		ports, _ := parsePortsSlice(r.options.TcpAckPingProbes)
-		r.scanner.EnqueueTCP(host, scan.Ack, ports...)
+		r.scanner.EnqueueTCP(host, scan.Ack, ports.Ports()...)
 	}
 	// IPv6-ND (for now we broadcast ICMPv6 to ff02::1)
 	if r.options.IPv6NeighborDiscoveryPing {
@@ -736,10 +1419,157 @@ func (r *Runner) SetInterface(interfaceName string) error {
 	}
 
 	r.scanner.NetworkInterface = networkInterface
+
+	// probes leave from the interface addresses of each ip version unless a source ip was given
+	addrs, err := networkInterface.Addrs()
+	if err != nil {
+		return err
+	}
+	sourceIP4, sourceIP6 := interfaceSourceIPs(addrs)
+	if r.scanner.SourceIP4 == nil {
+		r.scanner.SourceIP4 = sourceIP4
+	}
+	if r.scanner.SourceIP6 == nil {
+		r.scanner.SourceIP6 = sourceIP6
+	}
 	return nil
 }
 
+// interfaceSourceIPs returns the first ipv4 and ipv6 addresses of the interface usable as source,
+// ipv6 link local addresses are skipped as they can't reach routed targets
+func interfaceSourceIPs(addrs []net.Addr) (sourceIP4, sourceIP6 net.IP) {
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		switch {
+		case ipNet.IP.To4() != nil:
+			if sourceIP4 == nil {
+				sourceIP4 = ipNet.IP.To4()
+			}
+		case !ipNet.IP.IsLinkLocalUnicast():
+			if sourceIP6 == nil {
+				sourceIP6 = ipNet.IP
+			}
+		}
+	}
+	return sourceIP4, sourceIP6
+}
+
+// localSubnets returns the ipv4 subnets of the scanning interface, or of all the interfaces that are up
+func (r *Runner) localSubnets() []*net.IPNet {
+	var interfaces []net.Interface
+	if r.scanner.NetworkInterface != nil {
+		interfaces = append(interfaces, *r.scanner.NetworkInterface)
+	} else if all, err := net.Interfaces(); err == nil {
+		interfaces = all
+	}
+
+	var subnets []*net.IPNet
+	for _, itf := range interfaces {
+		if itf.Flags&net.FlagUp == 0 || itf.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := itf.Addrs()
+		if err != nil {
+			continue
+		}
+		subnets = append(subnets, ipv4Subnets(addrs)...)
+	}
+	return subnets
+}
+
+// ipv4Subnets returns the ipv4 subnets of the interface addresses, point to point addresses are skipped
+// as no other host is reachable with ARP
+func ipv4Subnets(addrs []net.Addr) []*net.IPNet {
+	var subnets []*net.IPNet
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.To4() == nil {
+			continue
+		}
+		if ones, bits := ipNet.Mask.Size(); bits != net.IPv4len*8 || ones >= 31 {
+			continue
+		}
+		subnets = append(subnets, &net.IPNet{IP: ipNet.IP.To4().Mask(ipNet.Mask), Mask: ipNet.Mask})
+	}
+	return subnets
+}
+
+// inSubnets returns true if the ip belongs to one of the subnets
+func inSubnets(subnets []*net.IPNet, ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, subnet := range subnets {
+		if subnet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// getResultHosts returns the hostnames associated with the ip of the result
+func (r *Runner) getResultHosts(hostResult *result.HostResult) ([]string, error) {
+	dt, err := r.scanner.IPRanger.GetHostsByIP(hostResult.IP)
+	if err != nil {
+		return nil, err
+	}
+
+	// recover hostnames from ip:port combination
+	for _, p := range hostResult.Ports {
+		ipPort := net.JoinHostPort(hostResult.IP, fmt.Sprint(p.Port))
+		if dtOthers, ok := r.scanner.IPRanger.Hosts.Get(ipPort); ok {
+			if otherName, _, err := net.SplitHostPort(string(dtOthers)); err == nil {
+				// replace bare ip:port with host
+				for idx, ipCandidate := range dt {
+					if iputil.IsIP(ipCandidate) {
+						dt[idx] = otherName
+					}
+				}
+			}
+		}
+	}
+
+	for idx, host := range dt {
+		if host == "ip" {
+			dt[idx] = hostResult.IP
+		}
+	}
+	return dt, nil
+}
+
+// newResult returns the output result of the host with the enrichment fields of its ip
+func (r *Runner) newResult(host, ip string, isCDNIP bool, cdnName string) *Result {
+	start := r.stages.start(StageEnrich, false)
+	defer r.stages.done(StageEnrich, start)
+	data := newOutputResult(host, ip, r.options.OutputCDN, isCDNIP, cdnName)
+	if hint := r.clocks.hint(ip); hint != nil {
+		data.Uptime = hint.uptime
+		data.ClockSkew = hint.skew
+	}
+	if host != ip {
+		data.CNAME = r.cnames.get(canonicalHost(host))
+	}
+	data.Tags = r.resultTags(host, ip)
+	data.Anycast = r.anycast.isAnycast(ip)
+	data.ScanType = scanTypeNames[r.options.usedScanType()]
+	return data
+}
+
 func (r *Runner) handleOutput(scanResults *result.Result) {
+	r.waitBanners()
+	span := r.startSpan("output")
+	defer span.End()
+	// the evidence and the baseline don't depend on the output files, so they are
+	// produced even when the early returns below are taken
+	defer func() {
+		r.handleEvidenceOutput(scanResults)
+		r.reportBaseline(scanResults)
+	}()
+
 	var (
 		file   *os.File
 		err    error
@@ -757,6 +1587,7 @@ func (r *Runner) handleOutput(scanResults *result.Result) {
 			mkdirErr := os.MkdirAll(outputFolder, 0700)
 			if mkdirErr != nil {
 				gologger.Error().Msgf("Could not create output folder %s: %s\n", outputFolder, mkdirErr)
+				r.recordError(ErrorOutput, outputFolder, mkdirErr)
 				return
 			}
 		}
@@ -764,56 +1595,55 @@ func (r *Runner) handleOutput(scanResults *result.Result) {
 		file, err = os.Create(output)
 		if err != nil {
 			gologger.Error().Msgf("Could not create file %s: %s\n", output, err)
+			r.recordError(ErrorOutput, output, err)
 			return
 		}
 		defer file.Close()
 	}
+	var groups *groupFiles
+	if r.options.OutputDir != "" {
+		groups, err = newGroupFiles(r.options.OutputDir, r.options)
+		if err != nil {
+			gologger.Error().Msgf("Could not create output folder %s: %s\n", r.options.OutputDir, err)
+			r.recordError(ErrorOutput, r.options.OutputDir, err)
+			return
+		}
+		defer groups.Close()
+	}
 	csvFileHeaderEnabled := true
 
 	switch {
+	case r.options.Count:
+		r.handleCountOutput(scanResults, file)
+	case (scanResults.HasIPsPorts() || scanResults.HasIPS()) && (r.options.OutputHosts || r.options.OutputIPs):
+		r.handleListOutput(scanResults, file)
+	case scanResults.HasIPsPorts() && r.options.GroupBy != "":
+		r.handleGroupedOutput(scanResults, file)
 	case scanResults.HasIPsPorts():
-		for hostResult := range scanResults.GetIPsPorts() {
+		for _, hostResult := range r.hostResults(scanResults) {
 			csvHeaderEnabled := true
-			dt, err := r.scanner.IPRanger.GetHostsByIP(hostResult.IP)
+			dt, err := r.getResultHosts(hostResult)
 			if err != nil {
 				continue
 			}
-
-			// recover hostnames from ip:port combination
-			for _, p := range hostResult.Ports {
-				ipPort := net.JoinHostPort(hostResult.IP, fmt.Sprint(p.Port))
-				if dtOthers, ok := r.scanner.IPRanger.Hosts.Get(ipPort); ok {
-					if otherName, _, err := net.SplitHostPort(string(dtOthers)); err == nil {
-						// replace bare ip:port with host
-						for idx, ipCandidate := range dt {
-							if iputil.IsIP(ipCandidate) {
-								dt[idx] = otherName
-							}
-						}
-					}
-				}
-			}
+			dt = r.sortHosts(dt)
 
 			buffer := bytes.Buffer{}
 			writer := csv.NewWriter(&buffer)
 			for _, host := range dt {
 				buffer.Reset()
-				if host == "ip" {
-					host = hostResult.IP
-				}
 				isCDNIP, cdnName, _ := r.scanner.CdnCheck(hostResult.IP)
-				gologger.Info().Msgf("Found %d ports on host %s (%s)\n", len(hostResult.Ports), host, hostResult.IP)
+				ports := r.enrichPorts(host, hostResult.IP, r.ratePorts(r.filterHostPorts(host, hostResult.IP, hostResult.Ports, isCDNIP, cdnName)))
+				if len(ports) == 0 {
+					continue
+				}
+				if !r.options.NoSummary {
+					gologger.Info().Msgf("Found %d ports on host %s (%s)\n", len(ports), host, hostResult.IP)
+				}
 				// console output
+				data := r.newResult(host, hostResult.IP, isCDNIP, cdnName)
 				if r.options.JSON || r.options.CSV {
-					data := &Result{IP: hostResult.IP, TimeStamp: time.Now().UTC()}
-					if r.options.OutputCDN {
-						data.IsCDNIP = isCDNIP
-						data.CDNName = cdnName
-					}
-					if host != hostResult.IP {
-						data.Host = host
-					}
-					for _, p := range hostResult.Ports {
+					for _, p := range ports {
 						data.Port = p
 						if r.options.JSON {
 							b, marshallErr := data.JSON()
@@ -836,7 +1666,7 @@ func (r *Runner) handleOutput(scanResults *result.Result) {
 					writer.Flush()
 					gologger.Silent().Msgf("%s", buffer.String())
 				} else {
-					for _, p := range hostResult.Ports {
+					for _, p := range ports {
 						if r.options.OutputCDN && isCDNIP {
 							gologger.Silent().Msgf("%s:%d [%s]\n", host, p.Port, cdnName)
 						} else {
@@ -845,31 +1675,39 @@ func (r *Runner) handleOutput(scanResults *result.Result) {
 					}
 				}
 				// file output
-				if file != nil {
+				fileData, filePorts := r.anonymizer.Result(data), r.anonymizer.Ports(ports, data.names()...)
+				writeFile := func(writer io.Writer, header bool) error {
 					if r.options.JSON {
-						err = WriteJSONOutput(host, hostResult.IP, hostResult.Ports, r.options.OutputCDN, isCDNIP, cdnName, file)
+						return writeJSONResult(fileData, filePorts, writer)
 					} else if r.options.CSV {
-						err = WriteCsvOutput(host, hostResult.IP, hostResult.Ports, r.options.OutputCDN, isCDNIP, cdnName, csvFileHeaderEnabled, file)
-					} else {
-						err = WriteHostOutput(host, hostResult.Ports, r.options.OutputCDN, cdnName, file)
+						return writeCSVResult(fileData, filePorts, header, writer)
 					}
-					if err != nil {
+					return WriteHostOutput(r.anonymizer.Value(host), ports, r.options.OutputCDN, cdnName, writer)
+				}
+				if file != nil {
+					if err = writeFile(file, csvFileHeaderEnabled); err != nil {
 						gologger.Error().Msgf("Could not write results to file %s for %s: %s\n", output, host, err)
+						r.recordError(ErrorOutput, output, err)
 					}
 				}
-
-				if r.options.OnResult != nil {
-					r.options.OnResult(&result.HostResult{Host: host, IP: hostResult.IP, Ports: hostResult.Ports})
+				if groups != nil {
+					if err = groups.write(data.Tags, writeFile); err != nil {
+						gologger.Error().Msgf("Could not write results to folder %s for %s: %s\n", r.options.OutputDir, host, err)
+						r.recordError(ErrorOutput, r.options.OutputDir, err)
+					}
 				}
+
+				r.onResult(&result.HostResult{Host: host, IP: hostResult.IP, Ports: ports})
 			}
 			csvFileHeaderEnabled = false
 		}
 	case scanResults.HasIPS():
-		for hostIP := range scanResults.GetIPs() {
+		for _, hostIP := range r.resultIPs(scanResults) {
 			dt, err := r.scanner.IPRanger.GetHostsByIP(hostIP)
 			if err != nil {
 				continue
 			}
+			dt = r.sortHosts(dt)
 			buffer := bytes.Buffer{}
 			writer := csv.NewWriter(&buffer)
 			for _, host := range dt {
@@ -878,7 +1716,9 @@ func (r *Runner) handleOutput(scanResults *result.Result) {
 					host = hostIP
 				}
 				isCDNIP, cdnName, _ := r.scanner.CdnCheck(hostIP)
-				gologger.Info().Msgf("Found alive host %s (%s)\n", host, hostIP)
+				if !r.options.NoSummary {
+					gologger.Info().Msgf("Found alive host %s (%s)\n", host, hostIP)
+				}
 				// console output
 				if r.options.JSON || r.options.CSV {
 					data := &Result{IP: hostIP, TimeStamp: time.Now().UTC()}
@@ -903,22 +1743,29 @@ func (r *Runner) handleOutput(scanResults *result.Result) {
 					}
 				}
 				// file output
-				if file != nil {
+				fileHost, fileIP := r.anonymizer.Value(host), r.anonymizer.Value(hostIP)
+				writeFile := func(writer io.Writer, header bool) error {
 					if r.options.JSON {
-						err = WriteJSONOutput(host, hostIP, nil, r.options.OutputCDN, isCDNIP, cdnName, file)
+						return WriteJSONOutput(fileHost, fileIP, nil, r.options.OutputCDN, isCDNIP, cdnName, writer)
 					} else if r.options.CSV {
-						err = WriteCsvOutput(host, hostIP, nil, r.options.OutputCDN, isCDNIP, cdnName, csvFileHeaderEnabled, file)
-					} else {
-						err = WriteHostOutput(host, nil, r.options.OutputCDN, cdnName, file)
+						return WriteCsvOutput(fileHost, fileIP, nil, r.options.OutputCDN, isCDNIP, cdnName, header, writer)
 					}
-					if err != nil {
+					return WriteHostOutput(fileHost, nil, r.options.OutputCDN, cdnName, writer)
+				}
+				if file != nil {
+					if err = writeFile(file, csvFileHeaderEnabled); err != nil {
 						gologger.Error().Msgf("Could not write results to file %s for %s: %s\n", output, host, err)
+						r.recordError(ErrorOutput, output, err)
 					}
 				}
-
-				if r.options.OnResult != nil {
-					r.options.OnResult(&result.HostResult{Host: host, IP: hostIP})
+				if groups != nil {
+					if err = groups.write(r.resultTags(host, hostIP), writeFile); err != nil {
+						gologger.Error().Msgf("Could not write results to folder %s for %s: %s\n", r.options.OutputDir, host, err)
+						r.recordError(ErrorOutput, r.options.OutputDir, err)
+					}
 				}
+
+				r.onResult(&result.HostResult{Host: host, IP: hostIP})
 			}
 			csvFileHeaderEnabled = false
 		}
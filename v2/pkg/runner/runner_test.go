@@ -0,0 +1,52 @@
+package runner
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterfaceSourceIPs(t *testing.T) {
+	addr := func(cidr string) net.Addr {
+		ip, ipNet, err := net.ParseCIDR(cidr)
+		require.Nil(t, err)
+		ipNet.IP = ip
+		return ipNet
+	}
+
+	sourceIP4, sourceIP6 := interfaceSourceIPs([]net.Addr{
+		addr("fe80::1/64"),
+		addr("192.0.2.10/24"),
+		addr("2001:db8::10/64"),
+		addr("192.0.2.11/24"),
+	})
+	require.Equal(t, "192.0.2.10", sourceIP4.String())
+	require.Equal(t, "2001:db8::10", sourceIP6.String())
+
+	sourceIP4, sourceIP6 = interfaceSourceIPs([]net.Addr{addr("fe80::1/64")})
+	require.Nil(t, sourceIP4)
+	require.Nil(t, sourceIP6)
+}
+
+func TestIPv4Subnets(t *testing.T) {
+	addr := func(cidr string) net.Addr {
+		ip, ipNet, err := net.ParseCIDR(cidr)
+		require.Nil(t, err)
+		ipNet.IP = ip
+		return ipNet
+	}
+
+	subnets := ipv4Subnets([]net.Addr{
+		addr("192.168.1.20/24"),
+		addr("2001:db8::10/64"),
+		addr("10.0.0.1/32"),
+	})
+	require.Len(t, subnets, 1)
+	require.Equal(t, "192.168.1.0/24", subnets[0].String())
+
+	require.True(t, inSubnets(subnets, "192.168.1.200"))
+	require.False(t, inSubnets(subnets, "192.168.2.1"))
+	require.False(t, inSubnets(subnets, "not-an-ip"))
+	require.False(t, inSubnets(nil, "192.168.1.200"))
+}
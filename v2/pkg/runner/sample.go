@@ -0,0 +1,51 @@
+package runner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sampleSeed is the fixed shuffle seed of sampled scans, so the same scope always yields the same sample
+const sampleSeed = 0x6e61616275
+
+// sample is a subset of the host x port space scanned to estimate the exposure of a scope
+type sample struct {
+	percent float64 // fraction of the space in percent, when set
+	count   uint64  // absolute number of host:port pairs
+}
+
+// parseSample parses a sample expressed as a percentage (1%) or a number of host:port pairs (10000)
+func parseSample(value string) (*sample, error) {
+	value = strings.TrimSpace(value)
+	if percentValue, ok := strings.CutSuffix(value, "%"); ok {
+		percent, err := strconv.ParseFloat(percentValue, 64)
+		if err != nil || percent <= 0 || percent > 100 {
+			return nil, fmt.Errorf("invalid sample %s (percentage must be in the (0-100] range)", value)
+		}
+		return &sample{percent: percent}, nil
+	}
+	count, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || count == 0 {
+		return nil, fmt.Errorf("invalid sample %s (expected a percentage like 1%% or a number of host:port pairs)", value)
+	}
+	return &sample{count: count}, nil
+}
+
+// size returns the number of host:port pairs sampled from a space of total pairs
+func (s *sample) size(total uint64) uint64 {
+	if s == nil {
+		return total
+	}
+	size := s.count
+	if s.percent > 0 {
+		size = uint64(float64(total) * s.percent / 100)
+		if size == 0 {
+			size = 1
+		}
+	}
+	if size > total {
+		size = total
+	}
+	return size
+}
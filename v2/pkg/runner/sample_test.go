@@ -0,0 +1,31 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSample(t *testing.T) {
+	s, err := parseSample("1%")
+	require.Nil(t, err)
+	require.Equal(t, uint64(100), s.size(10000))
+	require.Equal(t, uint64(1), s.size(10))
+
+	s, err = parseSample("0.5%")
+	require.Nil(t, err)
+	require.Equal(t, uint64(50), s.size(10000))
+
+	s, err = parseSample("10000")
+	require.Nil(t, err)
+	require.Equal(t, uint64(10000), s.size(1000000))
+	require.Equal(t, uint64(500), s.size(500))
+
+	var none *sample
+	require.Equal(t, uint64(42), none.size(42))
+
+	for _, value := range []string{"0", "0%", "101%", "-5", "abc", "%"} {
+		_, err := parseSample(value)
+		require.NotNil(t, err, value)
+	}
+}
@@ -0,0 +1,161 @@
+package runner
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/retryablehttp-go"
+	iputil "github.com/projectdiscovery/utils/ip"
+)
+
+// scopeEntry is an ip range marked as out of scope
+type scopeEntry struct {
+	network *net.IPNet
+	reason  string
+}
+
+// scopeFilter skips targets listed in reputation/ownership data sources.
+// Each line of a source contains an ip or cidr optionally followed by the reason:
+//
+//	192.0.2.0/24 government range
+//	# comments and empty lines are ignored
+type scopeFilter struct {
+	entries []scopeEntry
+}
+
+// loadScopeFilter reads the out of scope ranges from local files or remote urls
+func loadScopeFilter(sources []string) (*scopeFilter, error) {
+	filter := &scopeFilter{}
+	for _, source := range sources {
+		reader, err := openScopeSource(source)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read scope filter %s", source)
+		}
+		entries, err := parseScopeEntries(reader, source)
+		reader.Close()
+		if err != nil {
+			return nil, err
+		}
+		gologger.Verbose().Msgf("Loaded %d out of scope ranges from %s\n", len(entries), source)
+		filter.entries = append(filter.entries, entries...)
+	}
+	return filter, nil
+}
+
+func openScopeSource(source string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return os.Open(source)
+	}
+	httpClient := retryablehttp.NewClient(retryablehttp.DefaultOptionsSingle)
+	response, err := httpClient.Get(source)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, fmt.Errorf("server replied with status code %d", response.StatusCode)
+	}
+	return response.Body, nil
+}
+
+// parseScopeEntries parses the ranges of a scope filter source
+func parseScopeEntries(reader io.Reader, source string) ([]scopeEntry, error) {
+	var entries []scopeEntry
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		value, reason, _ := strings.Cut(line, " ")
+		network := iputil.ToCidr(value)
+		if network == nil {
+			return nil, fmt.Errorf("invalid range %s in scope filter %s", value, source)
+		}
+		reason = strings.TrimSpace(reason)
+		if reason == "" {
+			reason = "listed in " + source
+		}
+		entries = append(entries, scopeEntry{network: network, reason: reason})
+	}
+	return entries, scanner.Err()
+}
+
+// Lookup returns the reason why the ip is out of scope
+func (filter *scopeFilter) Lookup(ip string) (string, bool) {
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil {
+		return "", false
+	}
+	for _, entry := range filter.entries {
+		if entry.network.Contains(parsedIP) {
+			return entry.reason, true
+		}
+	}
+	return "", false
+}
+
+// Overlaps returns the out of scope ranges overlapping the network
+func (filter *scopeFilter) Overlaps(network *net.IPNet) []scopeEntry {
+	var overlapping []scopeEntry
+	for _, entry := range filter.entries {
+		if entry.network.Contains(network.IP) || network.Contains(entry.network.IP) {
+			overlapping = append(overlapping, entry)
+		}
+	}
+	return overlapping
+}
+
+// CIDRs returns the out of scope ranges
+func (filter *scopeFilter) CIDRs() []string {
+	cidrs := make([]string, 0, len(filter.entries))
+	for _, entry := range filter.entries {
+		cidrs = append(cidrs, entry.network.String())
+	}
+	return cidrs
+}
+
+// isOutOfScope checks if the target ip is out of scope and logs the reason once per target
+func (r *Runner) isOutOfScope(target, ip string) bool {
+	if r.scopeFilter == nil {
+		return false
+	}
+	// the out of scope ranges are part of the exclusions of the scanner ranger, the reason is only
+	// looked up for the excluded ips
+	if r.scanner != nil && r.scanner.IPRanger.Np.ValidateAddress(ip) {
+		return false
+	}
+	reason, ok := r.scopeFilter.Lookup(ip)
+	if !ok {
+		return false
+	}
+	if _, warned := r.scopeWarned.LoadOrStore(target+" "+ip, struct{}{}); !warned {
+		if target != ip {
+			gologger.Warning().Msgf("Skipping host %s as ip %s is out of scope: %s\n", target, ip, reason)
+		} else {
+			gologger.Warning().Msgf("Skipping %s as it is out of scope: %s\n", ip, reason)
+		}
+	}
+	return true
+}
+
+// logOutOfScopeRanges logs the out of scope ranges excluded from the cidr
+func (r *Runner) logOutOfScopeRanges(cidr string) {
+	if r.scopeFilter == nil {
+		return
+	}
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return
+	}
+	for _, entry := range r.scopeFilter.Overlaps(network) {
+		gologger.Warning().Msgf("Excluding %s from %s as it is out of scope: %s\n", entry.network, cidr, entry.reason)
+	}
+}
@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopeFilter(t *testing.T) {
+	data := `# out of scope ranges
+192.0.2.0/24 government range
+
+198.51.100.7
+`
+	entries, err := parseScopeEntries(strings.NewReader(data), "ranges.txt")
+	require.Nil(t, err)
+	filter := &scopeFilter{entries: entries}
+
+	reason, ok := filter.Lookup("192.0.2.10")
+	require.True(t, ok)
+	require.Equal(t, "government range", reason)
+
+	reason, ok = filter.Lookup("198.51.100.7")
+	require.True(t, ok)
+	require.Equal(t, "listed in ranges.txt", reason)
+
+	_, ok = filter.Lookup("198.51.100.8")
+	require.False(t, ok)
+
+	_, network, _ := net.ParseCIDR("192.0.0.0/16")
+	require.Len(t, filter.Overlaps(network), 1)
+	require.Equal(t, []string{"192.0.2.0/24", "198.51.100.7/32"}, filter.CIDRs())
+
+	_, err = parseScopeEntries(strings.NewReader("not-an-ip reason"), "ranges.txt")
+	require.NotNil(t, err)
+}
+
+func TestIsOutOfScope(t *testing.T) {
+	entries, err := parseScopeEntries(strings.NewReader("192.0.2.0/24 government range"), "ranges.txt")
+	require.Nil(t, err)
+	r := &Runner{scopeFilter: &scopeFilter{entries: entries}}
+	require.True(t, r.isOutOfScope("gov.example.com", "192.0.2.10"))
+	require.True(t, r.isOutOfScope("gov.example.com", "192.0.2.10"))
+	require.False(t, r.isOutOfScope("198.51.100.1", "198.51.100.1"))
+
+	// the reason is logged once per target
+	warned := 0
+	r.scopeWarned.Range(func(key, value any) bool {
+		warned++
+		return true
+	})
+	require.Equal(t, 1, warned)
+}
@@ -0,0 +1,102 @@
+package runner
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+)
+
+// DefaultSelfTestTarget is the always open endpoint scanned by the self test
+const DefaultSelfTestTarget = "scanme.sh:80"
+
+// SelfTestCheck is the outcome of a scan pipeline against the self test endpoint
+type SelfTestCheck struct {
+	ScanType string
+	Open     bool
+	Duration time.Duration
+	// Skipped is the reason the pipeline couldn't run (eg. missing privileges)
+	Skipped string
+	Err     error
+}
+
+// Passed returns true if the pipeline found the endpoint open
+func (check *SelfTestCheck) Passed() bool {
+	return check.Skipped == "" && check.Err == nil && check.Open
+}
+
+// String returns the outcome of the check as a line of the self test report
+func (check *SelfTestCheck) String() string {
+	name := "SYN"
+	if check.ScanType == ConnectScan {
+		name = "CONNECT"
+	}
+	switch {
+	case check.Skipped != "":
+		return fmt.Sprintf("%s scan: skipped (%s)", name, check.Skipped)
+	case check.Err != nil:
+		return fmt.Sprintf("%s scan: failed (%s)", name, check.Err)
+	case !check.Open:
+		return fmt.Sprintf("%s scan: failed (port not found open in %s)", name, check.Duration.Round(time.Millisecond))
+	default:
+		return fmt.Sprintf("%s scan: ok (%s)", name, check.Duration.Round(time.Millisecond))
+	}
+}
+
+// SelfTest scans the always open endpoint (host:port) with the syn and connect pipelines end to
+// end, catching a broken pcap or routing setup before a real scan
+func SelfTest(target string, timeout time.Duration) ([]*SelfTestCheck, error) {
+	host, portValue, err := net.SplitHostPort(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid self test target %s (allowed: host:port)", target)
+	}
+	if port, err := strconv.Atoi(portValue); err != nil || port < 1 || port > 65535 {
+		return nil, fmt.Errorf("invalid self test port %s (allowed: 1-65535)", portValue)
+	}
+	checks := []*SelfTestCheck{
+		selfTestScan(SynScan, host, portValue, timeout),
+		selfTestScan(ConnectScan, host, portValue, timeout),
+	}
+	return checks, nil
+}
+
+// selfTestScan scans the endpoint with the scan type
+func selfTestScan(scanType, host, port string, timeout time.Duration) *SelfTestCheck {
+	check := &SelfTestCheck{ScanType: scanType}
+	expected, _ := strconv.Atoi(port)
+	// the results are delivered from the scan goroutines
+	var open atomic.Bool
+	naabuRunner, err := New(
+		WithHosts(host),
+		WithPorts(port),
+		WithScanType(scanType),
+		WithTimeout(timeout),
+		WithSkipHostDiscovery(),
+		WithOnResult(func(hr *result.HostResult) {
+			for _, p := range hr.Ports {
+				if p.Port == expected {
+					open.Store(true)
+				}
+			}
+		}),
+	)
+	if err != nil {
+		check.Err = err
+		return check
+	}
+	defer naabuRunner.Close()
+
+	// the syn scan falls back to a connect scan when raw packets are not available
+	if reason := naabuRunner.options.scanFallback; reason != "" {
+		check.Skipped = reason
+		return check
+	}
+	start := time.Now()
+	check.Err = naabuRunner.RunEnumeration()
+	check.Duration = time.Since(start)
+	check.Open = open.Load()
+	return check
+}
@@ -0,0 +1,33 @@
+package runner
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelfTestTarget(t *testing.T) {
+	for _, invalid := range []string{"scanme.sh", "scanme.sh:0", "scanme.sh:http", "scanme.sh:70000"} {
+		_, err := SelfTest(invalid, time.Second)
+		require.NotNil(t, err, invalid)
+	}
+}
+
+func TestSelfTestCheck(t *testing.T) {
+	check := &SelfTestCheck{ScanType: SynScan, Open: true, Duration: 1204 * time.Millisecond}
+	require.True(t, check.Passed())
+	require.Equal(t, "SYN scan: ok (1.204s)", check.String())
+
+	check = &SelfTestCheck{ScanType: ConnectScan, Duration: 5 * time.Second}
+	require.False(t, check.Passed())
+	require.Equal(t, "CONNECT scan: failed (port not found open in 5s)", check.String())
+
+	check = &SelfTestCheck{ScanType: SynScan, Skipped: "root privileges required"}
+	require.False(t, check.Passed())
+	require.Equal(t, "SYN scan: skipped (root privileges required)", check.String())
+
+	check = &SelfTestCheck{ScanType: ConnectScan, Err: errors.New("no route to host")}
+	require.Equal(t, "CONNECT scan: failed (no route to host)", check.String())
+}
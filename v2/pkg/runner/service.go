@@ -0,0 +1,125 @@
+package runner
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+)
+
+// bannerMatchers identify services from the initial banner they send, the banner starts with
+// the prefix and contains the keyword (case insensitive)
+var bannerMatchers = []struct {
+	prefix  string
+	keyword string
+	service string
+}{
+	{prefix: "SSH-", service: "ssh"},
+	{prefix: "220", keyword: "FTP", service: "ftp"},
+	{prefix: "220", keyword: "SMTP", service: "smtp"},
+	{prefix: "+OK", service: "pop3"},
+	{prefix: "* OK", service: "imap"},
+	{prefix: "RFB ", service: "vnc"},
+}
+
+// matchBanner returns the service and version announced by the banner, empty if unknown
+func matchBanner(banner string) (service, version string) {
+	upper := strings.ToUpper(banner)
+	for _, matcher := range bannerMatchers {
+		if !strings.HasPrefix(upper, matcher.prefix) || !strings.Contains(upper, matcher.keyword) {
+			continue
+		}
+		switch matcher.service {
+		case "ssh":
+			// SSH-2.0-OpenSSH_8.9p1 Ubuntu-3
+			if fields := strings.SplitN(banner, "-", 3); len(fields) == 3 {
+				if software := strings.Fields(fields[2]); len(software) > 0 {
+					version = software[0]
+				}
+			}
+		case "vnc":
+			// RFB 003.008
+			version = strings.TrimSpace(strings.TrimPrefix(banner, "RFB "))
+		}
+		return matcher.service, version
+	}
+	return "", ""
+}
+
+// identify returns a copy of the open port with the service and version read from its banner,
+// silent services are probed with a tls handshake, then an http request. The handshake comes first
+// as tls servers answer a plain http request with an http error
+func (grabber *bannerGrabber) identify(ip string, p *port.Port, banner string) *port.Port {
+	identified := *p
+	identified.Banner = banner
+	if banner != "" {
+		identified.Service, identified.Version = matchBanner(banner)
+		return &identified
+	}
+
+	if state, ok := grabber.handshake(ip, p); ok {
+		if grabber.certificates {
+			grabber.runner.evidence.addCertificates(ip, state.PeerCertificates)
+		}
+		identified.TLS = true
+		identified.Service, identified.Version = "tls", tls.VersionName(state.Version)
+		if len(state.PeerCertificates) > 0 && state.PeerCertificates[0].Subject.CommonName != "" {
+			identified.Banner = "CN=" + state.PeerCertificates[0].Subject.CommonName
+		}
+		if response, ok := grabber.probeHTTP(ip, p, true); ok {
+			identified.Service, identified.Version = "https", response.Header.Get("Server")
+		}
+		return &identified
+	}
+
+	if response, ok := grabber.probeHTTP(ip, p, false); ok {
+		identified.Service, identified.Version, identified.Banner = "http", response.Header.Get("Server"), response.Status
+		return &identified
+	}
+	return nil
+}
+
+// handshake performs a tls handshake with the port and returns the connection state if it speaks tls
+func (grabber *bannerGrabber) handshake(ip string, p *port.Port) (tls.ConnectionState, bool) {
+	conn, err := grabber.runner.scanner.DialPort(ip, p, grabber.timeout)
+	if err != nil {
+		return tls.ConnectionState{}, false
+	}
+	tlsConn := tls.Client(conn, &tls.Config{ServerName: grabber.serverName(ip), InsecureSkipVerify: true}) //nolint:gosec // identifies any tls service
+	defer tlsConn.Close()
+
+	_ = tlsConn.SetDeadline(time.Now().Add(grabber.timeout))
+	if err := tlsConn.Handshake(); err != nil {
+		return tls.ConnectionState{}, false
+	}
+	return tlsConn.ConnectionState(), true
+}
+
+// probeHTTP sends a HEAD request to the port and returns the response if it speaks http
+func (grabber *bannerGrabber) probeHTTP(ip string, p *port.Port, overTLS bool) (*http.Response, bool) {
+	conn, err := grabber.runner.scanner.DialPort(ip, p, grabber.timeout)
+	if err != nil {
+		return nil, false
+	}
+	if overTLS {
+		conn = tls.Client(conn, &tls.Config{InsecureSkipVerify: true}) //nolint:gosec // identifies any tls service
+	}
+	defer conn.Close()
+
+	_ = conn.SetDeadline(time.Now().Add(grabber.timeout))
+	if _, err := fmt.Fprintf(conn, "HEAD / HTTP/1.0\r\nHost: %s\r\n\r\n", ip); err != nil {
+		return nil, false
+	}
+	request, _ := http.NewRequest(http.MethodHead, "/", nil)
+	response, err := http.ReadResponse(bufio.NewReader(io.LimitReader(conn, maxBannerSize*8)), request)
+	if err != nil {
+		return nil, false
+	}
+	response.Body.Close()
+	return response, true
+}
@@ -0,0 +1,91 @@
+package runner
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+	"github.com/projectdiscovery/naabu/v2/pkg/scan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchBanner(t *testing.T) {
+	for banner, expected := range map[string][2]string{
+		"SSH-2.0-OpenSSH_8.9p1 Ubuntu-3ubuntu0.1": {"ssh", "OpenSSH_8.9p1"},
+		"SSH-2.0-":                          {"ssh", ""},
+		"220 ProFTPD Server ready.":         {"ftp", ""},
+		"220 mx.example.com ESMTP Postfix":  {"smtp", ""},
+		"* OK [CAPABILITY IMAP4rev1] ready": {"imap", ""},
+		"RFB 003.008":                       {"vnc", "003.008"},
+		"hello":                             {"", ""},
+	} {
+		service, version := matchBanner(banner)
+		require.Equal(t, expected, [2]string{service, version}, banner)
+	}
+}
+
+func TestIdentifyService(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Server", "nginx/1.25.3")
+	})
+	plain := httptest.NewServer(handler)
+	defer plain.Close()
+	secure := httptest.NewTLSServer(handler)
+	defer secure.Close()
+
+	r := &Runner{
+		options: &Options{BannerThreads: 1, BannerRate: 10, BannerTimeout: 1000, ServiceVersion: true},
+		scanner: &scan.Scanner{ScanResults: result.NewResult()},
+	}
+	grabber := newBannerGrabber(r)
+	defer grabber.Wait()
+	require.True(t, grabber.identifyServices)
+
+	portOf := func(server *httptest.Server) *port.Port {
+		return &port.Port{Port: server.Listener.Addr().(*net.TCPAddr).Port, Protocol: protocol.TCP}
+	}
+
+	identified := grabber.identify("127.0.0.1", portOf(plain), "")
+	require.NotNil(t, identified)
+	require.Equal(t, "http", identified.Service)
+	require.Equal(t, "nginx/1.25.3", identified.Version)
+	require.Equal(t, "200 OK", identified.Banner)
+
+	identified = grabber.identify("127.0.0.1", portOf(secure), "")
+	require.NotNil(t, identified)
+	require.True(t, identified.TLS)
+	require.Equal(t, "https", identified.Service)
+	require.Equal(t, "nginx/1.25.3", identified.Version)
+
+	identified = grabber.identify("127.0.0.1", portOf(plain), "SSH-2.0-OpenSSH_9.6")
+	require.Equal(t, "ssh", identified.Service)
+	require.Equal(t, "OpenSSH_9.6", identified.Version)
+}
+
+func TestIdentifyServiceStored(t *testing.T) {
+	secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer secure.Close()
+
+	r := &Runner{
+		options: &Options{BannerThreads: 1, BannerRate: 10, BannerTimeout: 1000, ServiceVersion: true},
+		scanner: &scan.Scanner{ScanResults: result.NewResult()},
+	}
+	r.banners = newBannerGrabber(r)
+	r.scanner.OnPortFound = r.banners.Enqueue
+
+	r.scanner.AddPort("127.0.0.1", &port.Port{Port: secure.Listener.Addr().(*net.TCPAddr).Port, Protocol: protocol.TCP})
+	r.waitBanners()
+	r.scanner.FlushResults()
+
+	// the identified tls service replaces the plain port instead of being stored next to it
+	require.Equal(t, 1, r.scanner.ScanResults.GetPortCount("127.0.0.1"))
+	for hostResult := range r.scanner.ScanResults.GetIPsPorts() {
+		require.Len(t, hostResult.Ports, 1)
+		require.True(t, hostResult.Ports[0].TLS)
+		require.Equal(t, "https", hostResult.Ports[0].Service)
+	}
+}
@@ -0,0 +1,159 @@
+package runner
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+)
+
+// Severities of the exposed ports, from the least to the most severe
+const (
+	SeverityInfo     = "info"
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+var severityRanks = map[string]int{
+	SeverityInfo:     0,
+	SeverityLow:      1,
+	SeverityMedium:   2,
+	SeverityHigh:     3,
+	SeverityCritical: 4,
+}
+
+// defaultSeverities rate remote access, file sharing and databases usually not meant to be exposed
+// above web services, the ports not listed are rated low
+var defaultSeverities = map[string]string{
+	"80/tcp":    SeverityInfo,
+	"443/tcp":   SeverityInfo,
+	"21/tcp":    SeverityMedium,
+	"22/tcp":    SeverityMedium,
+	"161/udp":   SeverityMedium,
+	"1433/tcp":  SeverityMedium,
+	"3306/tcp":  SeverityMedium,
+	"5432/tcp":  SeverityMedium,
+	"23/tcp":    SeverityHigh,
+	"445/tcp":   SeverityHigh,
+	"3389/tcp":  SeverityHigh,
+	"5900/tcp":  SeverityHigh,
+	"6379/tcp":  SeverityHigh,
+	"9200/tcp":  SeverityHigh,
+	"11211/tcp": SeverityHigh,
+	"27017/tcp": SeverityHigh,
+	"2375/tcp":  SeverityCritical,
+}
+
+// validSeverity returns an error if the severity is unknown
+func validSeverity(severity string) error {
+	if _, ok := severityRanks[severity]; !ok {
+		return fmt.Errorf("invalid severity %s (allowed: %s, %s, %s, %s, %s)", severity, SeverityInfo, SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical)
+	}
+	return nil
+}
+
+// severityMap rates the ports by port/protocol, the default ratings overridden by a file of
+// "port[/protocol] severity" lines:
+//
+//	8080 medium
+//	53/udp low
+//	# comments and empty lines are ignored
+type severityMap struct {
+	severities map[string]string
+}
+
+func newSeverityMap() *severityMap {
+	severities := make(map[string]string, len(defaultSeverities))
+	for key, severity := range defaultSeverities {
+		severities[key] = severity
+	}
+	return &severityMap{severities: severities}
+}
+
+// loadSeverityMap returns the default ratings overridden by the severity file
+func loadSeverityMap(path string) (*severityMap, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	severities := newSeverityMap()
+	return severities, severities.parse(f)
+}
+
+// parse reads "port[/protocol] severity" lines, the protocol defaults to tcp
+func (m *severityMap) parse(reader io.Reader) error {
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return fmt.Errorf("invalid severity at line %d: expected \"port[/protocol] severity\"", line)
+		}
+		number, protocolName, _ := strings.Cut(strings.ToLower(fields[0]), "/")
+		if protocolName == "" {
+			protocolName = "tcp"
+		}
+		if value, err := strconv.Atoi(number); err != nil || value <= 0 || value > 65535 {
+			return fmt.Errorf("invalid port %s at line %d", fields[0], line)
+		}
+		if protocolName != "tcp" && protocolName != "udp" {
+			return fmt.Errorf("invalid protocol %s at line %d (allowed: tcp, udp)", protocolName, line)
+		}
+		severity := strings.ToLower(fields[1])
+		if err := validSeverity(severity); err != nil {
+			return fmt.Errorf("%s at line %d", err, line)
+		}
+		m.severities[number+"/"+protocolName] = severity
+	}
+	return scanner.Err()
+}
+
+// of returns the severity of the port
+func (m *severityMap) of(p *port.Port) string {
+	if severity, ok := m.severities[fmt.Sprintf("%d/%s", p.Port, p.Protocol.String())]; ok {
+		return severity
+	}
+	return SeverityLow
+}
+
+// rate returns copies of the ports with their severity, as the ports are shared among hosts. If
+// sorted is set, the most severe ports come first
+func (m *severityMap) rate(ports []*port.Port, sorted bool) []*port.Port {
+	if m == nil {
+		return ports
+	}
+	rated := make([]*port.Port, len(ports))
+	for i, p := range ports {
+		withSeverity := *p
+		withSeverity.Severity = m.of(p)
+		rated[i] = &withSeverity
+	}
+	if sorted {
+		sort.SliceStable(rated, func(i, j int) bool {
+			return severityRanks[rated[i].Severity] > severityRanks[rated[j].Severity]
+		})
+	}
+	return rated
+}
+
+// ratePorts returns the ports of the host with their severity
+func (r *Runner) ratePorts(ports []*port.Port) []*port.Port {
+	return r.severities.rate(ports, r.options.SortSeverity)
+}
+
+// belowMinSeverity returns true if the port is less severe than the notification threshold
+func (r *Runner) belowMinSeverity(p *port.Port) bool {
+	return r.options.MinSeverity != "" && severityRanks[p.Severity] < severityRanks[r.options.MinSeverity]
+}
@@ -0,0 +1,43 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeverityMap(t *testing.T) {
+	severities := newSeverityMap()
+	require.Nil(t, severities.parse(strings.NewReader("# overrides\n8080 medium\n53/UDP low\n443 high\n")))
+	require.Equal(t, SeverityHigh, severities.of(&port.Port{Port: 3389, Protocol: protocol.TCP}))
+	require.Equal(t, SeverityHigh, severities.of(&port.Port{Port: 443, Protocol: protocol.TCP}))
+	require.Equal(t, SeverityMedium, severities.of(&port.Port{Port: 8080, Protocol: protocol.TCP}))
+	require.Equal(t, SeverityLow, severities.of(&port.Port{Port: 8080, Protocol: protocol.UDP}))
+	require.Equal(t, SeverityLow, severities.of(&port.Port{Port: 53, Protocol: protocol.UDP}))
+
+	for _, invalid := range []string{"8080", "0 high", "80/sctp high", "80 severe"} {
+		require.NotNil(t, newSeverityMap().parse(strings.NewReader(invalid)), invalid)
+	}
+}
+
+func TestRatePorts(t *testing.T) {
+	ports := []*port.Port{
+		{Port: 80, Protocol: protocol.TCP},
+		{Port: 8443, Protocol: protocol.TCP},
+		{Port: 3389, Protocol: protocol.TCP},
+	}
+	rated := newSeverityMap().rate(ports, true)
+	require.Equal(t, []int{3389, 8443, 80}, portNumbers(rated))
+	require.Equal(t, SeverityHigh, rated[0].Severity)
+	require.Empty(t, ports[2].Severity, "shared ports must not be modified")
+	require.Equal(t, []int{80, 8443, 3389}, portNumbers(newSeverityMap().rate(ports, false)))
+
+	r := &Runner{options: &Options{MinSeverity: SeverityMedium}}
+	require.True(t, r.belowMinSeverity(rated[1]))
+	require.False(t, r.belowMinSeverity(rated[0]))
+	r.options.MinSeverity = ""
+	require.False(t, r.belowMinSeverity(rated[2]))
+}
@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"net/netip"
+	"sort"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+)
+
+// hostResults returns the hosts having open ports, ordered by ip with their ports ordered by number
+// if the output is sorted
+func (r *Runner) hostResults(scanResults *result.Result) []*result.HostResult {
+	var hostResults []*result.HostResult
+	for hostResult := range scanResults.GetIPsPorts() {
+		hostResults = append(hostResults, hostResult)
+	}
+	if r.options.Sorted {
+		sort.Slice(hostResults, func(i, j int) bool {
+			return lessIP(hostResults[i].IP, hostResults[j].IP)
+		})
+		for _, hostResult := range hostResults {
+			sortPorts(hostResult.Ports)
+		}
+	}
+	return hostResults
+}
+
+// resultIPs returns the alive hosts, ordered if the output is sorted
+func (r *Runner) resultIPs(scanResults *result.Result) []string {
+	var ips []string
+	for ip := range scanResults.GetIPs() {
+		ips = append(ips, ip)
+	}
+	if r.options.Sorted {
+		sort.Slice(ips, func(i, j int) bool {
+			return lessIP(ips[i], ips[j])
+		})
+	}
+	return ips
+}
+
+// sortHosts orders the hostnames of an ip if the output is sorted
+func (r *Runner) sortHosts(hosts []string) []string {
+	if r.options.Sorted {
+		sort.Strings(hosts)
+	}
+	return hosts
+}
+
+// lessIP orders the ipv4 addresses before the ipv6 ones, numerically
+func lessIP(a, b string) bool {
+	addrA, errA := netip.ParseAddr(a)
+	addrB, errB := netip.ParseAddr(b)
+	if errA != nil || errB != nil {
+		return a < b
+	}
+	return addrA.Unmap().Less(addrB.Unmap())
+}
+
+// sortPorts orders the ports by number then protocol
+func sortPorts(ports []*port.Port) {
+	sort.Slice(ports, func(i, j int) bool {
+		if ports[i].Port != ports[j].Port {
+			return ports[i].Port < ports[j].Port
+		}
+		return ports[i].Protocol < ports[j].Protocol
+	})
+}
@@ -0,0 +1,33 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortedResults(t *testing.T) {
+	scanResults := result.NewResult()
+	scanResults.AddPort("2001:db8::1", &port.Port{Port: 22, Protocol: protocol.TCP})
+	scanResults.AddPort("10.0.0.10", &port.Port{Port: 443, Protocol: protocol.TCP})
+	scanResults.AddPort("10.0.0.10", &port.Port{Port: 53, Protocol: protocol.UDP})
+	scanResults.AddPort("10.0.0.10", &port.Port{Port: 53, Protocol: protocol.TCP})
+	scanResults.AddPort("10.0.0.9", &port.Port{Port: 80, Protocol: protocol.TCP})
+
+	r := &Runner{options: &Options{Sorted: true}}
+	hostResults := r.hostResults(scanResults)
+	require.Len(t, hostResults, 3)
+	require.Equal(t, "10.0.0.9", hostResults[0].IP)
+	require.Equal(t, "10.0.0.10", hostResults[1].IP)
+	require.Equal(t, "2001:db8::1", hostResults[2].IP)
+	ports := hostResults[1].Ports
+	require.Equal(t, protocol.TCP, ports[0].Protocol)
+	require.Equal(t, protocol.UDP, ports[1].Protocol)
+	require.Equal(t, 443, ports[2].Port)
+
+	require.Equal(t, []string{"10.0.0.9", "10.0.0.10", "2001:db8::1"}, r.resultIPs(scanResults))
+	require.Equal(t, []string{"a.example.com", "b.example.com"}, r.sortHosts([]string{"b.example.com", "a.example.com"}))
+}
@@ -0,0 +1,194 @@
+package runner
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// spillMinBackoff is the delay before retrying to post the spilled results after a first failure
+	spillMinBackoff = 5 * time.Second
+	// spillMaxBackoff is the maximum delay between two retries of a failing sink
+	spillMaxBackoff = 5 * time.Minute
+)
+
+// spill keeps the results a webhook or elasticsearch sink failed to receive in a local json lines
+// file, retried with an exponential backoff so that network hiccups to the sink don't lose findings.
+// The results are appended by the writer and read back in batches by its retry goroutine, the file
+// being truncated once they were all delivered
+type spill struct {
+	sync.Mutex
+	file *os.File
+	// offset is the position in the file of the first result not delivered yet
+	offset  int64
+	count   int
+	backoff time.Duration
+	retryAt time.Time
+}
+
+// add appends the documents to the spill file, created with the first failure
+func (s *spill) add(documents []interface{}) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if len(documents) == 0 {
+		return nil
+	}
+	if s.file == nil {
+		file, err := os.CreateTemp("", "naabu-spill-*.jsonl")
+		if err != nil {
+			return err
+		}
+		s.file = file
+	}
+	if _, err := s.file.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(s.file)
+	encoder := json.NewEncoder(writer)
+	for _, document := range documents {
+		if err := encoder.Encode(document); err != nil {
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	s.count += len(documents)
+	return nil
+}
+
+// fail doubles the backoff before the next retry
+func (s *spill) fail(now time.Time) time.Duration {
+	s.Lock()
+	defer s.Unlock()
+
+	s.backoff *= 2
+	if s.backoff < spillMinBackoff {
+		s.backoff = spillMinBackoff
+	}
+	if s.backoff > spillMaxBackoff {
+		s.backoff = spillMaxBackoff
+	}
+	s.retryAt = now.Add(s.backoff)
+	return s.backoff
+}
+
+// pending returns true if results are waiting in the spill file
+func (s *spill) pending() bool {
+	s.Lock()
+	defer s.Unlock()
+	return s.count > 0
+}
+
+// wait returns the time until the retry of the spilled results, false if none is waiting
+func (s *spill) wait(now time.Time) (time.Duration, bool) {
+	s.Lock()
+	defer s.Unlock()
+	if s.count == 0 {
+		return 0, false
+	}
+	if now.After(s.retryAt) {
+		return 0, true
+	}
+	return s.retryAt.Sub(now), true
+}
+
+// next returns the first spilled results not delivered yet, up to size, with the position in the
+// file following them to commit once delivered
+func (s *spill) next(size int) ([]interface{}, int64, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.count == 0 {
+		return nil, s.offset, nil
+	}
+	decoder := json.NewDecoder(io.NewSectionReader(s.file, s.offset, math.MaxInt64-s.offset))
+	documents := make([]interface{}, 0, min(size, s.count))
+	for len(documents) < size {
+		var document json.RawMessage
+		if err := decoder.Decode(&document); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, s.offset, err
+		}
+		documents = append(documents, document)
+	}
+	offset := s.offset + decoder.InputOffset()
+	if len(documents) > 0 {
+		// skips the newline ending the last result
+		offset++
+	}
+	return documents, offset, nil
+}
+
+// commit marks the results up to the position as delivered, truncating the file and resetting the
+// backoff once none is waiting
+func (s *spill) commit(offset int64, delivered int) error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.offset = offset
+	s.count -= delivered
+	if s.count > 0 {
+		return nil
+	}
+	s.count, s.offset, s.backoff, s.retryAt = 0, 0, 0, time.Time{}
+	return s.file.Truncate(0)
+}
+
+// close removes the spill file once its results were delivered, and returns its path with the
+// number of results kept otherwise
+func (s *spill) close() (string, int, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.file == nil {
+		return "", 0, nil
+	}
+	path := s.file.Name()
+	if s.count > 0 {
+		if err := s.compact(); err != nil {
+			return path, s.count, err
+		}
+	}
+	if err := s.file.Close(); err != nil {
+		return path, s.count, err
+	}
+	s.file = nil
+	if s.count > 0 {
+		return path, s.count, nil
+	}
+	return "", 0, os.Remove(path)
+}
+
+// compact moves the results not delivered yet to the start of the file, so that the kept file only
+// holds them
+func (s *spill) compact() error {
+	if s.offset == 0 {
+		return nil
+	}
+	buffer := make([]byte, 64<<10)
+	var written int64
+	for {
+		n, err := s.file.ReadAt(buffer, s.offset+written)
+		if n > 0 {
+			if _, err := s.file.WriteAt(buffer[:n], written); err != nil {
+				return err
+			}
+			written += int64(n)
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+	s.offset = 0
+	return s.file.Truncate(written)
+}
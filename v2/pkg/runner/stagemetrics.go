@@ -0,0 +1,146 @@
+package runner
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/projectdiscovery/clistats"
+	"github.com/projectdiscovery/gologger"
+)
+
+// metricStages are the phases of the scan flow measured while it runs, named after the pipeline stages
+var metricStages = []string{StageDiscover, StageScan, StageVerify, StageBanner, StageEnrich}
+
+// stageMetrics are the workers, queue depth and processing latency of a stage
+type stageMetrics struct {
+	workers    atomic.Int64
+	peakWorker atomic.Int64
+	queued     atomic.Int64
+	peakQueue  atomic.Int64
+	processed  atomic.Int64
+	latency    atomic.Int64 // total processing time in nanoseconds
+	maxLatency atomic.Int64
+}
+
+// stageSnapshot is the state of a stage exposed by the stats and the progress file
+type stageSnapshot struct {
+	Workers      int64   `json:"workers"`
+	PeakWorkers  int64   `json:"peak_workers"`
+	Queue        int64   `json:"queue"`
+	PeakQueue    int64   `json:"peak_queue"`
+	Processed    int64   `json:"processed"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	MaxLatencyMs float64 `json:"max_latency_ms"`
+}
+
+// pipelineMetrics measures each stage of the scan, so that the stage bottlenecking a large scan
+// can be spotted and tuned
+type pipelineMetrics map[string]*stageMetrics
+
+func newPipelineMetrics() pipelineMetrics {
+	metrics := make(pipelineMetrics, len(metricStages))
+	for _, stage := range metricStages {
+		metrics[stage] = &stageMetrics{}
+	}
+	return metrics
+}
+
+// storeMax raises the value to current if it's greater
+func storeMax(value *atomic.Int64, current int64) {
+	for {
+		previous := value.Load()
+		if current <= previous || value.CompareAndSwap(previous, current) {
+			return
+		}
+	}
+}
+
+// enqueue records an item waiting for a worker of the stage
+func (metrics pipelineMetrics) enqueue(stage string) {
+	if m, ok := metrics[stage]; ok {
+		storeMax(&m.peakQueue, m.queued.Add(1))
+	}
+}
+
+// start records a worker of the stage processing an item, queued is true if the item was enqueued
+func (metrics pipelineMetrics) start(stage string, queued bool) time.Time {
+	m, ok := metrics[stage]
+	if !ok {
+		return time.Time{}
+	}
+	if queued {
+		m.queued.Add(-1)
+	}
+	storeMax(&m.peakWorker, m.workers.Add(1))
+	return time.Now()
+}
+
+// done records the item processed by the worker of the stage since the start time
+func (metrics pipelineMetrics) done(stage string, start time.Time) {
+	m, ok := metrics[stage]
+	if !ok {
+		return
+	}
+	latency := int64(time.Since(start))
+	m.workers.Add(-1)
+	m.processed.Add(1)
+	m.latency.Add(latency)
+	storeMax(&m.maxLatency, latency)
+}
+
+// snapshot returns the state of the stages that processed or queued items
+func (metrics pipelineMetrics) snapshot() map[string]*stageSnapshot {
+	if metrics == nil {
+		return nil
+	}
+	snapshots := make(map[string]*stageSnapshot)
+	for stage, m := range metrics {
+		if s := m.snapshot(); s.Processed > 0 || s.Queue > 0 || s.Workers > 0 {
+			snapshots[stage] = s
+		}
+	}
+	return snapshots
+}
+
+func (m *stageMetrics) snapshot() *stageSnapshot {
+	s := &stageSnapshot{
+		Workers:      m.workers.Load(),
+		PeakWorkers:  m.peakWorker.Load(),
+		Queue:        m.queued.Load(),
+		PeakQueue:    m.peakQueue.Load(),
+		Processed:    m.processed.Load(),
+		MaxLatencyMs: milliseconds(m.maxLatency.Load()),
+	}
+	if s.Processed > 0 {
+		s.AvgLatencyMs = milliseconds(m.latency.Load() / s.Processed)
+	}
+	return s
+}
+
+// milliseconds converts the nanoseconds rounding to a hundredth
+func milliseconds(nanoseconds int64) float64 {
+	return math.Round(float64(nanoseconds)/float64(time.Millisecond)*100) / 100
+}
+
+// addStats exposes the state of each stage
+func (metrics pipelineMetrics) addStats(stats *clistats.Statistics) {
+	for _, stage := range metricStages {
+		m := metrics[stage]
+		stats.AddDynamic("stage_"+stage, func(_ clistats.StatisticsClient) interface{} {
+			return m.snapshot()
+		})
+	}
+}
+
+// logSummary prints the state of each stage at the end of the scan
+func (metrics pipelineMetrics) logSummary() {
+	snapshots := metrics.snapshot()
+	for _, stage := range metricStages {
+		s, ok := snapshots[stage]
+		if !ok {
+			continue
+		}
+		gologger.Info().Msgf("Stage %s: %d processed, %d peak workers, %d peak queue, %.2fms avg latency, %.2fms max latency\n", stage, s.Processed, s.PeakWorkers, s.PeakQueue, s.AvgLatencyMs, s.MaxLatencyMs)
+	}
+}
@@ -0,0 +1,38 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipelineMetrics(t *testing.T) {
+	metrics := newPipelineMetrics()
+	metrics.enqueue(StageBanner)
+	metrics.enqueue(StageBanner)
+	first := metrics.start(StageBanner, true)
+	second := metrics.start(StageBanner, true)
+	metrics.done(StageBanner, first.Add(-10*time.Millisecond))
+
+	snapshot := metrics.snapshot()
+	require.Len(t, snapshot, 1)
+	banner := snapshot[StageBanner]
+	require.Equal(t, int64(1), banner.Workers)
+	require.Equal(t, int64(2), banner.PeakWorkers)
+	require.Equal(t, int64(0), banner.Queue)
+	require.Equal(t, int64(2), banner.PeakQueue)
+	require.Equal(t, int64(1), banner.Processed)
+	require.GreaterOrEqual(t, banner.AvgLatencyMs, 10.0)
+	require.Equal(t, banner.AvgLatencyMs, banner.MaxLatencyMs)
+
+	metrics.done(StageBanner, second)
+	require.Equal(t, int64(0), metrics.snapshot()[StageBanner].Workers)
+
+	// unknown stages and disabled metrics are ignored
+	metrics.done(StageOutput, metrics.start(StageOutput, false))
+	var disabled pipelineMetrics
+	disabled.enqueue(StageScan)
+	disabled.done(StageScan, disabled.start(StageScan, true))
+	require.Nil(t, disabled.snapshot())
+}
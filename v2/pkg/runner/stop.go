@@ -0,0 +1,77 @@
+package runner
+
+import (
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+)
+
+// onPortFound is called by the scanner for each new open port
+func (r *Runner) onPortFound(ip string, p *port.Port) {
+	if r.banners != nil {
+		r.banners.Enqueue(ip, p)
+	}
+	if !r.options.Verify {
+		r.publishResult(ip, p)
+	}
+	if r.options.StopAfterNPorts > 0 && !r.scanner.ScanResults.HasStopped(ip) && r.scanner.ScanResults.GetPortCount(ip) >= r.options.StopAfterNPorts {
+		gologger.Debug().Msgf("Found %d ports on %s, skipping remaining probes\n", r.options.StopAfterNPorts, ip)
+		r.scanner.ScanResults.AddStopped(ip)
+	}
+	if r.options.ExitOnFirstOpen && r.stopScan() {
+		gologger.Info().Msgf("Found open port %s:%d, stopping scan\n", ip, p.Port)
+	}
+}
+
+// scanStopped returns true if no more probes should be sent
+func (r *Runner) scanStopped() bool {
+	return r.stopped.Load()
+}
+
+// stopScan stops sending probes, returning false if the scan was already stopped
+func (r *Runner) stopScan() bool {
+	if !r.stopped.CompareAndSwap(false, true) {
+		return false
+	}
+	r.stopMu.Lock()
+	defer r.stopMu.Unlock()
+	if r.stopCh != nil {
+		select {
+		case <-r.stopCh:
+		default:
+			close(r.stopCh)
+		}
+	}
+	return true
+}
+
+// stopChan returns a channel closed once the scan is stopped, to wait for a timer or the stop
+func (r *Runner) stopChan() <-chan struct{} {
+	r.stopMu.Lock()
+	defer r.stopMu.Unlock()
+	if r.stopCh == nil {
+		r.stopCh = make(chan struct{})
+		if r.stopped.Load() {
+			close(r.stopCh)
+		}
+	}
+	return r.stopCh
+}
+
+// sleepOrStop waits for the duration, returning false early if the scan was stopped meanwhile
+func (r *Runner) sleepOrStop(d time.Duration) bool {
+	return sleepUntil(d, r.stopChan())
+}
+
+// sleepUntil waits for the duration, returning false early if the stop channel is closed meanwhile
+func sleepUntil(d time.Duration, stop <-chan struct{}) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-stop:
+		return false
+	}
+}
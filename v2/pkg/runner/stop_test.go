@@ -0,0 +1,84 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+	"github.com/projectdiscovery/naabu/v2/pkg/scan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExitOnFirstOpen(t *testing.T) {
+	r := &Runner{
+		options: &Options{},
+		scanner: &scan.Scanner{ScanResults: result.NewResult()},
+	}
+	r.scanner.OnPortFound = r.onPortFound
+
+	r.scanner.AddPort("127.0.0.1", &port.Port{Port: 80, Protocol: protocol.TCP})
+	r.scanner.FlushResults()
+	require.False(t, r.scanStopped())
+
+	r.options.ExitOnFirstOpen = true
+	r.scanner.AddPort("127.0.0.1", &port.Port{Port: 443, Protocol: protocol.TCP})
+	r.scanner.FlushResults()
+	require.True(t, r.scanStopped())
+}
+
+func TestStopAfterNPorts(t *testing.T) {
+	r := &Runner{
+		options: &Options{StopAfterNPorts: 2},
+		scanner: &scan.Scanner{ScanResults: result.NewResult()},
+	}
+	r.scanner.OnPortFound = r.onPortFound
+
+	r.scanner.AddPort("127.0.0.1", &port.Port{Port: 80, Protocol: protocol.TCP})
+	r.scanner.FlushResults()
+	require.False(t, r.scanner.ScanResults.HasSkipped("127.0.0.1"))
+
+	r.scanner.AddPort("127.0.0.1", &port.Port{Port: 443, Protocol: protocol.TCP})
+	r.scanner.FlushResults()
+	require.True(t, r.scanner.ScanResults.HasStopped("127.0.0.1"))
+	require.False(t, r.scanner.ScanResults.HasStopped("127.0.0.2"))
+	require.False(t, r.scanStopped())
+	// the host stops being probed but keeps its results
+	require.False(t, r.scanner.ScanResults.HasSkipped("127.0.0.1"))
+}
+
+func TestStopAfterNPortsOutput(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "results.txt")
+	r, err := NewRunner(&Options{StopAfterNPorts: 2, Output: output, NoSummary: true})
+	require.Nil(t, err)
+	defer r.Close()
+	require.Nil(t, r.scanner.IPRanger.Add("127.0.0.1"))
+
+	r.scanner.AddPort("127.0.0.1", &port.Port{Port: 80, Protocol: protocol.TCP})
+	r.scanner.AddPort("127.0.0.1", &port.Port{Port: 443, Protocol: protocol.TCP})
+	r.scanner.FlushResults()
+	require.True(t, r.scanner.ScanResults.HasStopped("127.0.0.1"))
+
+	r.handleOutput(r.scanner.ScanResults)
+	data, err := os.ReadFile(output)
+	require.Nil(t, err)
+	require.Contains(t, string(data), "127.0.0.1:80")
+	require.Contains(t, string(data), "127.0.0.1:443")
+}
+
+func TestSleepOrStop(t *testing.T) {
+	r := &Runner{}
+	require.True(t, r.sleepOrStop(time.Millisecond))
+
+	// the waits end as soon as the scan is stopped
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		r.stopScan()
+	}()
+	start := time.Now()
+	require.False(t, r.sleepOrStop(time.Hour))
+	require.Less(t, time.Since(start), time.Second)
+}
@@ -0,0 +1,138 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/projectdiscovery/blackrock"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+)
+
+// stripeLists are the top ports lists a stripe can be made of
+var stripeLists = map[string]string{
+	"100":  NmapTop100,
+	"1000": NmapTop1000,
+}
+
+// parsePortStripes returns the top ports lists of the stripes, smallest first
+func parsePortStripes(value string) ([]string, error) {
+	var stripes []string
+	for _, stripe := range strings.Split(value, ",") {
+		stripe = strings.TrimSpace(stripe)
+		if _, ok := stripeLists[stripe]; !ok {
+			return nil, fmt.Errorf("invalid port stripe %s (allowed: 100, 1000)", stripe)
+		}
+		if len(stripes) > 0 && len(stripe) <= len(stripes[len(stripes)-1]) {
+			return nil, fmt.Errorf("port stripes must be given in increasing order (%s)", value)
+		}
+		stripes = append(stripes, stripe)
+	}
+	return stripes, nil
+}
+
+// stripePorts orders the ports by stripe, the ports of the first top ports list first and the
+// ports of no list last, and returns the number of ports of each non empty stripe
+func stripePorts(ports *port.List, stripes []string) (*port.List, []int) {
+	ordered := &port.List{}
+	var sizes []int
+	for _, stripe := range stripes {
+		list, _ := parsePortRanges(stripeLists[stripe])
+		size := 0
+		ports.Each(func(p *port.Port) {
+			if list.Contains(p.Port, protocol.TCP) && ordered.Add(p.Port, p.Protocol) {
+				size++
+			}
+		})
+		if size > 0 {
+			sizes = append(sizes, size)
+		}
+	}
+	size := 0
+	ports.Each(func(p *port.Port) {
+		if ordered.Add(p.Port, p.Protocol) {
+			size++
+		}
+	})
+	if size > 0 {
+		sizes = append(sizes, size)
+	}
+	return ordered, sizes
+}
+
+// portStripe is a range of the ports scanned on every host before the next stripe
+type portStripe struct {
+	firstPort int
+	ports     int
+	bursts    int   // number of port bursts of the stripe, one per port without bursts
+	offset    int64 // index of the first pick of the stripe
+	picks     int64
+	shuffler  *blackrock.BlackRock
+}
+
+// stripedSpace maps the indexes of a scan pass to host:port pairs, each stripe being shuffled
+// on its own and fully scanned before the next one. A single stripe shuffles the whole space
+type stripedSpace struct {
+	stripes []*portStripe
+	burst   int
+}
+
+func newStripedSpace(hosts int64, sizes []int, seed int64) *stripedSpace {
+	return newBurstSpace(hosts, sizes, 1, seed)
+}
+
+// newBurstSpace returns a striped space whose picks are bursts of up to burst consecutive ports of
+// a host, the bursts being shuffled instead of the single pairs
+func newBurstSpace(hosts int64, sizes []int, burst int, seed int64) *stripedSpace {
+	space := &stripedSpace{burst: burst}
+	var firstPort int
+	var offset int64
+	for _, size := range sizes {
+		bursts := (size + burst - 1) / burst
+		picks := hosts * int64(bursts)
+		space.stripes = append(space.stripes, &portStripe{
+			firstPort: firstPort,
+			ports:     size,
+			bursts:    bursts,
+			offset:    offset,
+			picks:     picks,
+			shuffler:  blackrock.New(picks, seed),
+		})
+		firstPort += size
+		offset += picks
+	}
+	return space
+}
+
+// size returns the number of picks of the space
+func (space *stripedSpace) size() int64 {
+	if len(space.stripes) == 0 {
+		return 0
+	}
+	last := space.stripes[len(space.stripes)-1]
+	return last.offset + last.picks
+}
+
+// pick returns the host and port indexes of the pair at the index of the pass
+func (space *stripedSpace) pick(index int64) (int64, int) {
+	host, firstPort, _ := space.pickBurst(index)
+	return host, firstPort
+}
+
+// pickBurst returns the host index, the first port index and the number of ports of the burst at
+// the index of the pass
+func (space *stripedSpace) pickBurst(index int64) (int64, int, int) {
+	for _, stripe := range space.stripes {
+		if index >= stripe.offset+stripe.picks {
+			continue
+		}
+		shuffled := stripe.shuffler.Shuffle(index - stripe.offset)
+		burst := int(shuffled % int64(stripe.bursts))
+		count := space.burst
+		if remaining := stripe.ports - burst*space.burst; remaining < count {
+			count = remaining
+		}
+		return shuffled / int64(stripe.bursts), stripe.firstPort + burst*space.burst, count
+	}
+	return 0, 0, 0
+}
@@ -0,0 +1,102 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePortStripes(t *testing.T) {
+	stripes, err := parsePortStripes("100,1000")
+	require.Nil(t, err)
+	require.Equal(t, []string{"100", "1000"}, stripes)
+
+	_, err = parsePortStripes("1000,100")
+	require.NotNil(t, err)
+	_, err = parsePortStripes("500")
+	require.NotNil(t, err)
+}
+
+func TestStripePorts(t *testing.T) {
+	var ports []*port.Port
+	for _, p := range []int{1, 80, 81, 443, 9999, 60000, 60001} {
+		ports = append(ports, &port.Port{Port: p, Protocol: protocol.TCP})
+	}
+	ordered, sizes := stripePorts(port.NewList(ports...), []string{"100", "1000"})
+	var numbers []int
+	for _, p := range ordered.Ports() {
+		numbers = append(numbers, p.Port)
+	}
+	// 80,81,443,9999 are top 100, 1 top 1000
+	require.Equal(t, []int{80, 81, 443, 9999, 1, 60000, 60001}, numbers)
+	require.Equal(t, []int{4, 1, 2}, sizes)
+}
+
+func TestStripedSpace(t *testing.T) {
+	space := newStripedSpace(4, []int{3, 2}, 1)
+
+	// every pair of the first stripe is picked before the second stripe
+	seen := make(map[[2]int64]struct{})
+	for index := int64(0); index < 20; index++ {
+		host, portIndex := space.pick(index)
+		require.Less(t, host, int64(4))
+		if index < 12 {
+			require.Less(t, portIndex, 3)
+		} else {
+			require.GreaterOrEqual(t, portIndex, 3)
+		}
+		seen[[2]int64{host, int64(portIndex)}] = struct{}{}
+	}
+	require.Len(t, seen, 20)
+}
+
+// BenchmarkStripedSpacePick measures the shuffle of the main scan loop picking the host:port pairs
+func BenchmarkStripedSpacePick(b *testing.B) {
+	space := newStripedSpace(1<<16, []int{100, 900}, 1)
+	pairs := int64(1<<16) * 1000
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		space.pick(int64(i) % pairs)
+	}
+}
+
+func TestBurstSpace(t *testing.T) {
+	// stripes of 5 and 2 ports in bursts of 2: 3 bursts then 1 burst per host
+	space := newBurstSpace(3, []int{5, 2}, 2, 1)
+	require.Equal(t, int64(12), space.size())
+
+	seen := make(map[[2]int64]struct{})
+	var pairs int
+	for index := int64(0); index < space.size(); index++ {
+		host, firstPort, count := space.pickBurst(index)
+		pairs += count
+		if index < 9 {
+			require.Less(t, firstPort, 5)
+			require.LessOrEqual(t, firstPort+count, 5)
+		} else {
+			require.Equal(t, 5, firstPort)
+			require.Equal(t, 2, count)
+		}
+		for portIndex := firstPort; portIndex < firstPort+count; portIndex++ {
+			seen[[2]int64{host, int64(portIndex)}] = struct{}{}
+		}
+	}
+	// every pair is covered exactly once
+	require.Len(t, seen, 21)
+	require.Equal(t, 21, pairs)
+}
+
+// BenchmarkBurstSpacePick measures the shuffle of the main scan loop picking bursts of 16 ports,
+// to compare with BenchmarkStripedSpacePick per pair
+func BenchmarkBurstSpacePick(b *testing.B) {
+	space := newBurstSpace(1<<16, []int{100, 900}, 16, 1)
+	picks := space.size()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		space.pickBurst(int64(i) % picks)
+	}
+}
@@ -0,0 +1,163 @@
+package runner
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	iputil "github.com/projectdiscovery/utils/ip"
+)
+
+// tagRule tags the results having a name matching the pattern, either an exact name or a
+// suffix (*.amazonaws.com), or an ip in the network of the pattern (10.0.0.0/8)
+type tagRule struct {
+	pattern string
+	network *net.IPNet
+	tag     string
+}
+
+// match returns true if the canonical name matches the pattern of the rule
+func (rule *tagRule) match(name string) bool {
+	if rule.network != nil {
+		ip := net.ParseIP(name)
+		return ip != nil && rule.network.Contains(ip)
+	}
+	if suffix, ok := strings.CutPrefix(rule.pattern, "*"); ok {
+		return strings.HasSuffix(name, suffix)
+	}
+	return name == rule.pattern
+}
+
+// tagger tags the results from their ip, the reverse dns (PTR) names of their ip, their hostname
+// and its cname chain, complementing the cidr based cloud exclusions:
+//
+//	*.amazonaws.com cloud:aws
+//	*.cloudfront.net cdn:cloudfront
+//	10.20.0.0/16 dmz
+//	# comments and empty lines are ignored
+type tagger struct {
+	rules []*tagRule
+
+	sync.Mutex
+	ptr    map[string]*ptrEntry
+	lookup func(ip string) ([]string, error)
+}
+
+// ptrEntry holds the reverse dns names of an ip, looked up by the first caller while the callers
+// of the same ip wait for it
+type ptrEntry struct {
+	once  sync.Once
+	names []string
+}
+
+// loadTagRules reads the tag rules file
+func loadTagRules(path string) (*tagger, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rules, err := parseTagRules(f)
+	if err != nil {
+		return nil, err
+	}
+	return &tagger{rules: rules, ptr: make(map[string]*ptrEntry), lookup: iputil.ToFQDN}, nil
+}
+
+// parseTagRules parses "pattern tag" lines
+func parseTagRules(reader io.Reader) ([]*tagRule, error) {
+	var rules []*tagRule
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid tag rule at line %d: expected \"pattern tag\"", line)
+		}
+		if network, ok := parseTagNetwork(fields[0]); ok {
+			rules = append(rules, &tagRule{pattern: fields[0], network: network, tag: fields[1]})
+			continue
+		}
+		pattern := strings.ToLower(strings.TrimSuffix(fields[0], "."))
+		if strings.Contains(strings.TrimPrefix(pattern, "*"), "*") || (strings.HasPrefix(pattern, "*") && !strings.HasPrefix(pattern, "*.")) {
+			return nil, fmt.Errorf("invalid tag rule pattern %s at line %d (allowed: name, *.suffix or cidr)", fields[0], line)
+		}
+		rules = append(rules, &tagRule{pattern: pattern, tag: fields[1]})
+	}
+	return rules, scanner.Err()
+}
+
+// parseTagNetwork returns the network of a cidr or ip pattern
+func parseTagNetwork(pattern string) (*net.IPNet, bool) {
+	if _, network, err := net.ParseCIDR(pattern); err == nil {
+		return network, true
+	}
+	ip := net.ParseIP(pattern)
+	if ip == nil {
+		return nil, false
+	}
+	if ipv4 := ip.To4(); ipv4 != nil {
+		return &net.IPNet{IP: ipv4, Mask: net.CIDRMask(32, 32)}, true
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, true
+}
+
+// tags returns the sorted tags of the rules matching any of the names
+func (t *tagger) tags(names ...string) Tags {
+	matched := make(map[string]struct{})
+	for _, name := range names {
+		name = canonicalHost(name)
+		for _, rule := range t.rules {
+			if rule.match(name) {
+				matched[rule.tag] = struct{}{}
+			}
+		}
+	}
+	if len(matched) == 0 {
+		return nil
+	}
+	tags := make(Tags, 0, len(matched))
+	for tag := range matched {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// ptrNames returns the reverse dns names of the ip, looked up once per ip outside of the lock so that
+// a slow lookup only delays the results of its own ip
+func (t *tagger) ptrNames(ip string) []string {
+	t.Lock()
+	entry, ok := t.ptr[ip]
+	if !ok {
+		entry = &ptrEntry{}
+		t.ptr[ip] = entry
+	}
+	t.Unlock()
+
+	entry.once.Do(func() {
+		entry.names, _ = t.lookup(ip)
+	})
+	return entry.names
+}
+
+// resultTags returns the tags of the result from its ip and the names of its ip and hostname
+func (r *Runner) resultTags(host, ip string) Tags {
+	if r.tagger == nil {
+		return nil
+	}
+	names := append([]string{ip}, r.tagger.ptrNames(ip)...)
+	if host != ip {
+		names = append(append(names, host), r.cnames.get(canonicalHost(host))...)
+	}
+	return r.tagger.tags(names...)
+}
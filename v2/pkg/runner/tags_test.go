@@ -0,0 +1,84 @@
+package runner
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagRules(t *testing.T) {
+	rules, err := parseTagRules(strings.NewReader(`
+# cloud providers
+*.amazonaws.com cloud:aws
+*.cloudfront.net. cdn:cloudfront
+vpn.example.com corp:vpn
+`))
+	require.Nil(t, err)
+	require.Len(t, rules, 3)
+
+	lookups := 0
+	tagger := &tagger{rules: rules, ptr: make(map[string]*ptrEntry), lookup: func(ip string) ([]string, error) {
+		lookups++
+		return []string{"ec2-3-5-140-2.eu-west-2.compute.amazonaws.com."}, nil
+	}}
+	r := &Runner{tagger: tagger, cnames: newCNAMEChains()}
+	r.cnames.set("app.example.com", []string{"d111111abcdef8.cloudfront.net."})
+
+	require.Equal(t, Tags{"cdn:cloudfront", "cloud:aws"}, r.resultTags("app.example.com", "3.5.140.2"))
+	require.Equal(t, Tags{"cloud:aws", "corp:vpn"}, r.resultTags("VPN.example.com.", "3.5.140.2"))
+	require.Equal(t, Tags{"cloud:aws"}, r.resultTags("3.5.140.2", "3.5.140.2"))
+	require.Equal(t, 1, lookups, "reverse dns names should be looked up once per ip")
+	require.Nil(t, tagger.tags("example.com", "amazonaws.com"))
+	require.Nil(t, (&Runner{}).resultTags("app.example.com", "3.5.140.2"))
+
+	_, err = parseTagRules(strings.NewReader("*.amazonaws.com"))
+	require.NotNil(t, err)
+	_, err = parseTagRules(strings.NewReader("*amazonaws.com cloud:aws"))
+	require.NotNil(t, err)
+}
+
+func TestTagRulesNetworks(t *testing.T) {
+	rules, err := parseTagRules(strings.NewReader(`
+10.20.0.0/16 dmz
+10.30.0.0/16 corp
+10.30.5.1 corp:vpn
+2001:db8::/32 lab
+`))
+	require.Nil(t, err)
+	require.Len(t, rules, 4)
+
+	r := &Runner{tagger: &tagger{rules: rules, ptr: make(map[string]*ptrEntry), lookup: noLookup}, cnames: newCNAMEChains()}
+	require.Equal(t, Tags{"dmz"}, r.resultTags("10.20.1.1", "10.20.1.1"))
+	require.Equal(t, Tags{"corp", "corp:vpn"}, r.resultTags("vpn.example.com", "10.30.5.1"))
+	require.Equal(t, Tags{"lab"}, r.resultTags("2001:db8::1", "2001:db8::1"))
+	require.Nil(t, r.resultTags("10.40.0.1", "10.40.0.1"))
+}
+
+func TestTaggerPTRNamesConcurrent(t *testing.T) {
+	release := make(chan struct{})
+	var lookups atomic.Int32
+	tagger := &tagger{ptr: make(map[string]*ptrEntry), lookup: func(ip string) ([]string, error) {
+		lookups.Add(1)
+		if ip == "10.0.0.1" {
+			<-release
+		}
+		return []string{"host-" + ip}, nil
+	}}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.Equal(t, []string{"host-10.0.0.1"}, tagger.ptrNames("10.0.0.1"))
+		}()
+	}
+	// the pending lookup of an ip doesn't block the other ips
+	require.Equal(t, []string{"host-10.0.0.2"}, tagger.ptrNames("10.0.0.2"))
+	close(release)
+	wg.Wait()
+	require.Equal(t, int32(2), lookups.Load())
+}
@@ -1,7 +1,6 @@
 package runner
 
 import (
-	"bufio"
 	"flag"
 	"fmt"
 	"io"
@@ -12,6 +11,7 @@ import (
 	"github.com/projectdiscovery/gologger"
 	"github.com/projectdiscovery/mapcidr/asn"
 	"github.com/projectdiscovery/naabu/v2/pkg/privileges"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
 	"github.com/projectdiscovery/naabu/v2/pkg/scan"
 	iputil "github.com/projectdiscovery/utils/ip"
 	readerutil "github.com/projectdiscovery/utils/reader"
@@ -19,7 +19,9 @@ import (
 )
 
 func (r *Runner) Load() error {
-	r.scanner.Phase.Set(scan.Init)
+	r.setPhase(scan.Init)
+	span := r.startSpan("load")
+	defer span.End()
 
 	// merge all target sources into a file
 	targetfile, err := r.mergeToFile()
@@ -52,14 +54,9 @@ func (r *Runner) mergeToFile() (string, error) {
 		}
 	}
 
-	// Targets from file
+	// Targets from file, compressed or archived
 	if r.options.HostsFile != "" {
-		f, err := os.Open(r.options.HostsFile)
-		if err != nil {
-			return "", err
-		}
-		defer f.Close()
-		if _, err := io.Copy(tempInput, f); err != nil {
+		if err := copyTargetsFile(tempInput, r.options.HostsFile); err != nil {
 			return "", err
 		}
 	}
@@ -86,20 +83,30 @@ func (r *Runner) PreProcessTargets() error {
 		defer close(r.streamChannel)
 	}
 	wg := sizedwaitgroup.New(r.options.Threads)
-	f, err := os.Open(r.targetsFile)
+	fileProvider, err := NewFileTargetProvider(r.targetsFile)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-	s := bufio.NewScanner(f)
-	for s.Scan() {
-		wg.Add()
-		func(target string) {
-			defer wg.Done()
-			if err := r.AddTarget(target); err != nil {
-				gologger.Warning().Msgf("%s\n", err)
+	providers := append([]TargetProvider{fileProvider}, r.options.TargetProviders...)
+	for _, provider := range providers {
+		for {
+			target, err := provider.Next()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				gologger.Warning().Msgf("Could not read targets: %s\n", err)
+				r.recordError(ErrorInput, "", err)
+				break
 			}
-		}(s.Text())
+			wg.Add()
+			func(target string) {
+				defer wg.Done()
+				if err := r.AddTarget(target); err != nil {
+					gologger.Warning().Msgf("%s\n", err)
+					r.recordError(ErrorResolution, target, err)
+				}
+			}(target.String())
+		}
 	}
 
 	wg.Wait()
@@ -111,13 +118,24 @@ func (r *Runner) AddTarget(target string) error {
 	if target == "" {
 		return nil
 	}
+	if ip, zone, ok := splitZone(target); ok {
+		return r.addZonedTarget(target, ip, zone)
+	}
 	if asn.IsASN(target) {
+		if r.options.Offline {
+			return fmt.Errorf("could not expand %s: %w", target, errOffline)
+		}
 		// Get CIDRs for ASN
 		cidrs, err := asn.GetCIDRsForASNNum(target)
 		if err != nil {
 			return err
 		}
 		for _, cidr := range cidrs {
+			if isOversizedIPv6Range(cidr.String()) {
+				gologger.Warning().Msgf("Skipping %s range %s larger than /%d\n", target, cidr, 128-maxIPv6RangeBits)
+				continue
+			}
+			r.logOutOfScopeRanges(cidr.String())
 			if r.options.Stream {
 				r.streamChannel <- Target{Cidr: cidr.String()}
 			} else if err := r.scanner.IPRanger.AddHostWithMetadata(cidr.String(), "cidr"); err != nil { // Add cidr directly to ranger, as single ips would allocate more resources later
@@ -127,6 +145,12 @@ func (r *Runner) AddTarget(target string) error {
 		return nil
 	}
 	if iputil.IsCIDR(target) {
+		target = normalizeCIDR(target)
+		if isOversizedIPv6Range(target) {
+			gologger.Warning().Msgf("Skipping %s: ipv6 ranges larger than /%d can't be enumerated, list the addresses or use host discovery\n", target, 128-maxIPv6RangeBits)
+			return nil
+		}
+		r.logOutOfScopeRanges(target)
 		if r.options.Stream {
 			r.streamChannel <- Target{Cidr: target}
 		} else if err := r.scanner.IPRanger.AddHostWithMetadata(target, "cidr"); err != nil { // Add cidr directly to ranger, as single ips would allocate more resources later
@@ -134,22 +158,24 @@ func (r *Runner) AddTarget(target string) error {
 		}
 		return nil
 	}
+	if iputil.IsIP(target) {
+		// convert ip4 expressed as ipv4-mapped ip6 back to ip4
+		target = result.NormalizeIP(target)
+	}
 	if iputil.IsIP(target) && !r.scanner.IPRanger.Contains(target) {
-		ip := net.ParseIP(target)
-		// convert ip4 expressed as ip6 back to ip4
-		if ip.To4() != nil {
-			target = ip.To4().String()
+		if r.isOutOfScope(target, target) {
+			return nil
 		}
 		if r.options.Stream {
 			r.streamChannel <- Target{Cidr: iputil.ToCidr(target).String()}
 		} else {
 			metadata := "ip"
 			if r.options.ReversePTR {
-				names, err := iputil.ToFQDN(target)
+				names, err := r.lookupAddr(target)
 				if err != nil {
 					gologger.Debug().Msgf("reverse ptr failed for %s: %s\n", target, err)
 				} else {
-					metadata = strings.Trim(names[0], ".")
+					metadata = canonicalHost(names[0])
 				}
 			}
 			err := r.scanner.IPRanger.AddHostWithMetadata(target, metadata)
@@ -161,6 +187,8 @@ func (r *Runner) AddTarget(target string) error {
 	}
 
 	host, port, hasPort := getPort(target)
+	host = canonicalHost(host)
+	target = joinHostPort(host, port)
 
 	targetToResolve := target
 	if hasPort {
@@ -225,6 +253,9 @@ func (r *Runner) resolveFQDN(target string) ([]string, error) {
 		hostIPS        []string
 	)
 	for _, ip := range ipsV4 {
+		if r.isOutOfScope(target, ip) {
+			continue
+		}
 		if !r.scanner.IPRanger.Np.ValidateAddress(ip) {
 			gologger.Warning().Msgf("Skipping host %s as ip %s was excluded\n", target, ip)
 			continue
@@ -233,6 +264,9 @@ func (r *Runner) resolveFQDN(target string) ([]string, error) {
 		initialHosts = append(initialHosts, ip)
 	}
 	for _, ip := range ipsV6 {
+		if r.isOutOfScope(target, ip) {
+			continue
+		}
 		if !r.scanner.IPRanger.Np.ValidateAddress(ip) {
 			gologger.Warning().Msgf("Skipping host %s as ip %s was excluded\n", target, ip)
 			continue
@@ -37,6 +37,16 @@ func Test_AddTarget(t *testing.T) {
 	err = r.AddTarget("127.0.0.1/24")
 	require.Nil(t, err, "ipv4 cidr incorrectly parsed")
 
+	// IPV6 cidr
+	err = r.AddTarget("2001:db8::/120")
+	require.Nil(t, err, "ipv6 cidr incorrectly parsed")
+	require.True(t, r.scanner.IPRanger.Contains("2001:db8::10"))
+
+	// IPV6 cidr too large to enumerate
+	err = r.AddTarget("2001:db8:1::/64")
+	require.Nil(t, err)
+	require.False(t, r.scanner.IPRanger.Contains("2001:db8:1::10"))
+
 	// todo: excluding due to api instability (https://github.com/projectdiscovery/asnmap/issues/198)
 	// err = r.AddTarget("AS14421")
 	// require.Nil(t, err, "ASN incorrectly parsed")
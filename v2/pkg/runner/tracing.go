@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const (
+	// tracerName is the instrumentation scope of the spans emitted by the runner
+	tracerName = "github.com/projectdiscovery/naabu/v2/pkg/runner"
+	// tracingShutdownTimeout is the maximum time to flush pending spans on close
+	tracingShutdownTimeout = 5 * time.Second
+)
+
+// tracingEnabled returns true if spans should be exported via OTLP
+func (options *Options) tracingEnabled() bool {
+	return options.OtlpEndpoint != "" || os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// setupTracing configures the OTLP span exporter, falling back to a no-op tracer when not configured
+func (r *Runner) setupTracing() error {
+	r.traceCtx = context.Background()
+	if !r.options.tracingEnabled() {
+		r.tracer = noop.NewTracerProvider().Tracer(tracerName)
+		return nil
+	}
+
+	var exporterOptions []otlptracehttp.Option
+	if r.options.OtlpEndpoint != "" {
+		exporterOptions = append(exporterOptions, otlptracehttp.WithEndpointURL(r.options.OtlpEndpoint))
+	}
+	exporter, err := otlptracehttp.New(context.Background(), exporterOptions...)
+	if err != nil {
+		return err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName("naabu"),
+		semconv.ServiceVersion(version),
+	))
+	if err != nil {
+		return err
+	}
+
+	r.tracerProvider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	r.tracer = r.tracerProvider.Tracer(tracerName)
+	return nil
+}
+
+// startSpan starts a span for a scan phase as child of the enumeration span
+func (r *Runner) startSpan(name string, attrs ...attribute.KeyValue) trace.Span {
+	if r.tracer == nil {
+		return noop.Span{}
+	}
+	_, span := r.tracer.Start(r.traceCtx, name, trace.WithAttributes(attrs...))
+	return span
+}
+
+// shutdownTracing flushes and stops the span exporter
+func (r *Runner) shutdownTracing() {
+	if r.tracerProvider == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+	defer cancel()
+	_ = r.tracerProvider.Shutdown(ctx)
+}
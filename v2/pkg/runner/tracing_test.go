@@ -0,0 +1,29 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupTracing(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+
+	r := &Runner{options: &Options{}}
+	require.False(t, r.options.tracingEnabled())
+	require.Nil(t, r.setupTracing())
+	require.Nil(t, r.tracerProvider, "exporter should not be configured without endpoint")
+	span := r.startSpan("load")
+	require.False(t, span.IsRecording())
+	span.End()
+
+	r = &Runner{options: &Options{OtlpEndpoint: "http://127.0.0.1:4318"}}
+	require.True(t, r.options.tracingEnabled())
+	require.Nil(t, r.setupTracing())
+	require.NotNil(t, r.tracerProvider)
+	span = r.startSpan("load")
+	require.True(t, span.IsRecording())
+	span.End()
+	r.shutdownTracing()
+}
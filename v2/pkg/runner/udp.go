@@ -0,0 +1,82 @@
+package runner
+
+import (
+	"errors"
+	"syscall"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+)
+
+// asUDP returns the ports to scan over udp in udp scans, deduplicating the port numbers
+func asUDP(ports *port.List) *port.List {
+	udpPorts := &port.List{}
+	ports.Each(func(p *port.Port) {
+		udpPorts.Add(p.Port, protocol.UDP)
+	})
+	return udpPorts
+}
+
+// onPortClosed counts the udp probes answered with an icmp port unreachable as answered and
+// records the closed port, which is neither retried nor reported
+func (r *Runner) onPortClosed(ip string, p *port.Port) {
+	r.probesAnswered.Add(1)
+	if r.responses != nil {
+		r.responses.add(ip, p.Port)
+	}
+	r.udpClosed.AddPort(ip, p)
+}
+
+// isUDPClosed returns true if the connect probe of the udp port was refused (icmp port unreachable)
+func isUDPClosed(p *port.Port, err error) bool {
+	return p.Protocol == protocol.UDP && errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// udpStates is the classification of the udp probes of the scan
+type udpStates struct {
+	open         uint64 // answered with a datagram
+	closed       uint64 // answered with an icmp port unreachable
+	openFiltered uint64 // never answered, either open or dropped by a firewall
+}
+
+// classifyUDP classifies the udp host:port pairs probed among the scanned pairs
+func classifyUDP(results, closed *result.Result, ports *port.List, pairs uint64) *udpStates {
+	var udpPorts uint64
+	ports.Each(func(p *port.Port) {
+		if p.Protocol == protocol.UDP {
+			udpPorts++
+		}
+	})
+	if udpPorts == 0 || ports.Len() == 0 {
+		return nil
+	}
+
+	states := &udpStates{}
+	for hostResult := range results.GetIPsPorts() {
+		for _, p := range hostResult.Ports {
+			if p.Protocol == protocol.UDP {
+				states.open++
+			}
+		}
+	}
+	for hostResult := range closed.GetIPsPorts() {
+		states.closed += uint64(len(hostResult.Ports))
+	}
+	probed := pairs * udpPorts / uint64(ports.Len())
+	if answered := states.open + states.closed; probed > answered {
+		states.openFiltered = probed - answered
+	}
+	return states
+}
+
+// reportUDP shows the classification of the udp probes in the final summary
+func (r *Runner) reportUDP(pairs uint64) {
+	r.scanner.FlushResults()
+	states := classifyUDP(r.scanner.ScanResults, r.udpClosed, r.scanner.Ports, pairs)
+	if states == nil {
+		return
+	}
+	gologger.Info().Msgf("UDP: %d open, %d closed (icmp port unreachable), %d open|filtered (no answer)\n", states.open, states.closed, states.openFiltered)
+}
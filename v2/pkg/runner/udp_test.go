@@ -0,0 +1,30 @@
+package runner
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyUDP(t *testing.T) {
+	ports := []*port.Port{{Port: 80, Protocol: protocol.TCP}, {Port: 53, Protocol: protocol.UDP}, {Port: 161, Protocol: protocol.UDP}}
+	results, closed := result.NewResult(), result.NewResult()
+	results.AddPort("10.0.0.1", &port.Port{Port: 80, Protocol: protocol.TCP})
+	results.AddPort("10.0.0.1", &port.Port{Port: 53, Protocol: protocol.UDP})
+	closed.AddPort("10.0.0.1", &port.Port{Port: 161, Protocol: protocol.UDP})
+	// a retried probe is counted once
+	closed.AddPort("10.0.0.1", &port.Port{Port: 161, Protocol: protocol.UDP})
+	closed.AddPort("10.0.0.2", &port.Port{Port: 161, Protocol: protocol.UDP})
+
+	// 4 hosts x 3 ports
+	states := classifyUDP(results, closed, port.NewList(ports...), 12)
+	require.Equal(t, &udpStates{open: 1, closed: 2, openFiltered: 5}, states)
+	require.Nil(t, classifyUDP(results, closed, port.NewList(ports[:1]...), 4))
+
+	require.True(t, isUDPClosed(ports[1], syscall.ECONNREFUSED))
+	require.False(t, isUDPClosed(ports[0], syscall.ECONNREFUSED))
+}
@@ -3,52 +3,119 @@ package runner
 import (
 	"fmt"
 	"net"
+	"strings"
+	"time"
 
 	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+	"github.com/projectdiscovery/naabu/v2/pkg/scan"
 	iputil "github.com/projectdiscovery/utils/ip"
-	osutil "github.com/projectdiscovery/utils/os"
 	sliceutil "github.com/projectdiscovery/utils/slice"
+	"golang.org/x/net/idna"
 )
 
+// hostnameProfile converts internationalized hostnames to their ascii form, allowing
+// the underscores of service labels (eg. _sip._tcp.example.com)
+var hostnameProfile = idna.New(idna.MapForLookup(), idna.StrictDomainName(false), idna.Transitional(false))
+
 func (r *Runner) host2ips(target string) (targetIPsV4 []string, targetIPsV6 []string, err error) {
 	// If the host is a Domain, then perform resolution and discover all IP
 	// addresses for a given host. Else use that host for port scanning
 	if !iputil.IsIP(target) {
-		dnsData, err := r.dnsclient.QueryMultiple(target)
-		if err != nil || dnsData == nil {
-			gologger.Warning().Msgf("Could not get IP for host: %s\n", target)
-			return nil, nil, err
-		}
-		if len(r.options.IPVersion) > 0 {
-			if sliceutil.Contains(r.options.IPVersion, "4") {
-				targetIPsV4 = append(targetIPsV4, dnsData.A...)
-			}
-			if sliceutil.Contains(r.options.IPVersion, "6") {
-				targetIPsV6 = append(targetIPsV6, dnsData.AAAA...)
-			}
-		} else {
-			targetIPsV4 = append(targetIPsV4, dnsData.A...)
+		target = canonicalHost(target)
+		if entry, ok := r.dnsCache.Get(target, time.Now()); ok {
+			return entry.ipsV4, entry.ipsV6, entry.err
 		}
-		if len(targetIPsV4) == 0 && len(targetIPsV6) == 0 {
-			return targetIPsV4, targetIPsV6, fmt.Errorf("no IP addresses found for host: %s", target)
-		}
-	} else {
-		targetIPsV4 = append(targetIPsV6, target)
+		targetIPsV4, targetIPsV6, err = r.resolveHost(target)
+		r.dnsCache.Set(target, targetIPsV4, targetIPsV6, err, time.Now())
+		return targetIPsV4, targetIPsV6, err
+	} else if ip := result.NormalizeIP(target); iputil.IsIPv4(ip) {
+		targetIPsV4 = append(targetIPsV4, ip)
 		gologger.Debug().Msgf("Found %d addresses for %s\n", len(targetIPsV4), target)
+	} else {
+		targetIPsV6 = append(targetIPsV6, ip)
+		gologger.Debug().Msgf("Found %d addresses for %s\n", len(targetIPsV6), target)
 	}
 
 	return
 }
 
+// lookupHost returns the addresses of the host scanned with the configured resolvers
+func (r *Runner) lookupHost(host string) ([]string, error) {
+	ipsV4, ipsV6, err := r.host2ips(host)
+	return append(ipsV4, ipsV6...), err
+}
+
+// resolveHost queries the dns records of the host and returns the addresses of the ip versions to scan
+func (r *Runner) resolveHost(target string) (targetIPsV4 []string, targetIPsV6 []string, err error) {
+	if ipsV4, ipsV6, ok := r.resolveOverrides.Lookup(target); ok {
+		gologger.Debug().Msgf("Using resolve override for %s\n", target)
+		return r.selectIPVersions(target, ipsV4, ipsV6)
+	}
+
+	if r.options.NoDNS {
+		return nil, nil, fmt.Errorf("could not resolve %s: dns resolution is disabled", target)
+	}
+	resolution, err := r.resolver.Resolve(target)
+	if err != nil || resolution == nil {
+		gologger.Warning().Msgf("Could not get IP for host: %s\n", target)
+		return nil, nil, err
+	}
+	r.cnames.set(target, resolution.CNAME)
+	ipsV4, ipsV6 := splitMappedIPs(resolution.A, resolution.AAAA)
+	return r.selectIPVersions(target, ipsV4, ipsV6)
+}
+
+// noLookup is the reverse dns lookup used when dns resolution is disabled
+func noLookup(ip string) ([]string, error) {
+	return nil, nil
+}
+
+// selectIPVersions returns the addresses of the host matching the ip versions to scan
+func (r *Runner) selectIPVersions(target string, ipsV4, ipsV6 []string) (targetIPsV4 []string, targetIPsV6 []string, err error) {
+	if r.options.autoIPVersion() {
+		targetIPsV4, targetIPsV6 = r.selectFamily(target, ipsV4, ipsV6)
+	} else if len(r.options.IPVersion) > 0 {
+		if sliceutil.Contains(r.options.IPVersion, "4") {
+			targetIPsV4 = append(targetIPsV4, ipsV4...)
+		}
+		if sliceutil.Contains(r.options.IPVersion, "6") {
+			targetIPsV6 = append(targetIPsV6, ipsV6...)
+		}
+	} else {
+		targetIPsV4 = append(targetIPsV4, ipsV4...)
+		// ipv6 only hosts are scanned over ipv6 when AAAA records were queried
+		if len(targetIPsV4) == 0 && len(ipsV6) > 0 {
+			gologger.Debug().Msgf("Using ipv6 addresses of %s as it has no A record\n", target)
+			targetIPsV6 = append(targetIPsV6, ipsV6...)
+		}
+	}
+	if len(targetIPsV4) == 0 && len(targetIPsV6) == 0 {
+		return targetIPsV4, targetIPsV6, fmt.Errorf("no IP addresses found for host: %s", target)
+	}
+	return targetIPsV4, targetIPsV6, nil
+}
+
 func isOSSupported() bool {
-	return osutil.IsLinux() || osutil.IsOSX()
+	return scan.RawPacketsSupported()
 }
 
 func getPort(target string) (string, string, bool) {
 	host, port, err := net.SplitHostPort(target)
-	if err == nil && iputil.IsPort(port) {
+	// port 0 is a valid probe target (research, firewall behavior)
+	if err == nil && (iputil.IsPort(port) || port == "0") {
 		return host, port, true
 	}
 
 	return target, "", false
 }
+
+// canonicalHost returns the lowercase ascii (punycode) form of the hostname without trailing dot,
+// so the same host written in different forms is resolved and reported once
+func canonicalHost(host string) string {
+	host = strings.TrimSuffix(strings.TrimSpace(host), ".")
+	if ascii, err := hostnameProfile.ToASCII(host); err == nil {
+		return ascii
+	}
+	return strings.ToLower(host)
+}
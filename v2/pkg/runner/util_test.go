@@ -1,6 +1,7 @@
 package runner
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/projectdiscovery/dnsx/libs/dnsx"
@@ -15,6 +16,8 @@ func Test_host2ips(t *testing.T) {
 		wantErr bool
 	}{
 		{"10.10.10.10", []string{"10.10.10.10"}, nil, false},
+		{"2001:db8::1", nil, []string{"2001:db8::1"}, false},
+		{"::ffff:10.10.10.10", []string{"10.10.10.10"}, nil, false},
 		{"localhost", []string{"127.0.0.1"}, []string{"::1"}, false}, // some linux distribution don't have ::1 in /etc/hosts
 		{"aaaa", nil, nil, true},
 		{"10.10.10.0/24", nil, nil, true},
@@ -25,7 +28,7 @@ func Test_host2ips(t *testing.T) {
 	if dnsclient, err := dnsx.New(dnsx.DefaultOptions); err != nil {
 		assert.Error(t, err)
 	} else {
-		r.dnsclient = dnsclient
+		r.resolver = &dnsxResolver{client: dnsclient}
 	}
 
 	for _, tt := range tests {
@@ -46,3 +49,67 @@ func Test_host2ips(t *testing.T) {
 		})
 	}
 }
+
+func Test_host2ipsNoDNS(t *testing.T) {
+	r, err := NewRunner(&Options{NoDNS: true, Retries: 1})
+	assert.Nil(t, err)
+	r.resolveOverrides, err = parseResolveOverrides(strings.NewReader("10.0.0.10 app.internal"))
+	assert.Nil(t, err)
+
+	got, _, err := r.host2ips("10.10.10.10")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"10.10.10.10"}, got)
+
+	got, _, err = r.host2ips("app.internal")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"10.0.0.10"}, got)
+
+	_, _, err = r.host2ips("localhost")
+	assert.NotNil(t, err)
+	// the dns stack was never set up
+	resolver, ok := r.resolver.(*dnsxResolver)
+	assert.True(t, ok)
+	assert.False(t, resolver.started())
+}
+
+func Test_host2ipsResolver(t *testing.T) {
+	var queried []string
+	resolver := ResolverFunc(func(host string) (*Resolution, error) {
+		queried = append(queried, host)
+		return &Resolution{A: []string{"10.0.0.20"}, AAAA: []string{"2001:db8::20"}, CNAME: []string{"lb.internal"}}, nil
+	})
+	r, err := NewRunner(&Options{Resolver: resolver, Retries: 1})
+	assert.Nil(t, err)
+
+	got, _, err := r.host2ips("App.Internal.")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"10.0.0.20"}, got)
+	assert.Equal(t, []string{"app.internal"}, queried)
+	assert.Equal(t, CNAMEChain{"lb.internal"}, r.cnames.get("app.internal"))
+}
+
+func Test_canonicalHost(t *testing.T) {
+	tests := map[string]string{
+		"example.com":             "example.com",
+		"Example.COM.":            "example.com",
+		" www.example.com ":       "www.example.com",
+		"bücher.example":          "xn--bcher-kva.example",
+		"BÜCHER.example.":         "xn--bcher-kva.example",
+		"xn--bcher-kva.example":   "xn--bcher-kva.example",
+		"_sip._tcp.Example.com":   "_sip._tcp.example.com",
+		"r3---sn-Abc.example.com": "r3---sn-abc.example.com",
+	}
+	for input, expected := range tests {
+		assert.Equal(t, expected, canonicalHost(input), input)
+	}
+}
+
+func Test_getPort(t *testing.T) {
+	host, port, ok := getPort("10.0.0.1:0")
+	assert.True(t, ok)
+	assert.Equal(t, "10.0.0.1", host)
+	assert.Equal(t, "0", port)
+
+	_, _, ok = getPort("example.com")
+	assert.False(t, ok)
+}
@@ -4,10 +4,13 @@ import (
 	"flag"
 	"fmt"
 	"net"
+	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/projectdiscovery/naabu/v2/pkg/privileges"
+	"github.com/projectdiscovery/naabu/v2/pkg/scan"
 	fileutil "github.com/projectdiscovery/utils/file"
 	iputil "github.com/projectdiscovery/utils/ip"
 	osutil "github.com/projectdiscovery/utils/os"
@@ -29,9 +32,13 @@ var (
 func (options *Options) ValidateOptions() error {
 	// Check if Host, list of domains, or stdin info was provided.
 	// If none was provided, then return.
-	if options.Host == nil && options.HostsFile == "" && !options.Stdin && len(flag.Args()) == 0 {
+	if options.Host == nil && options.HostsFile == "" && !options.Stdin && len(flag.Args()) == 0 && len(options.TargetProviders) == 0 {
 		return errNoInputList
 	}
+	// the targets piped to stdin aren't part of the dumped options
+	if options.DumpOptions != "" && options.Stdin {
+		return errors.New("dump options can't be used with stdin input, the targets couldn't be replayed")
+	}
 
 	// Both verbose and silent flags were used
 	if options.Verbose && options.Silent {
@@ -113,14 +120,115 @@ func (options *Options) ValidateOptions() error {
 		options.SourcePort = port
 	}
 
-	if len(options.IPVersion) > 0 && !sliceutil.ContainsItems([]string{"4", "6"}, options.IPVersion) {
-		return errors.New("IP Version must be 4 and/or 6")
+	if options.SourceIPFailover != "" {
+		if options.SourceIP == "" {
+			return errors.New("source ip failover requires a source ip")
+		}
+		if !iputil.IsIP(options.SourceIPFailover) {
+			return errors.New("invalid source ip failover")
+		}
+		if iputil.IsIPv4(options.SourceIP) != iputil.IsIPv4(options.SourceIPFailover) {
+			return errors.New("source ip and source ip failover must belong to the same ip version")
+		}
+	}
+
+	if options.Tunnel != "" {
+		if _, _, err := scan.ParseTunnel(options.Tunnel); err != nil {
+			return err
+		}
+		if sourceIP, _, _ := getPort(options.SourceIP); !iputil.IsIPv4(sourceIP) {
+			return errors.New("tunnel requires an ipv4 source ip from the tunneled address space")
+		}
+		if sliceutil.Contains(options.IPVersion, "6") {
+			return errors.New("tunnel only supports ipv4 targets")
+		}
+	}
+
+	if options.Canary != "" {
+		if _, _, err := canaryAddress(options.Canary); err != nil {
+			return errors.Wrap(err, "invalid canary address")
+		}
+		if options.CanaryInterval <= 0 {
+			return errors.New("canary interval must be greater than 0")
+		}
+	}
+
+	if options.PipelineName != "" && options.Pipeline == "" {
+		return errors.New("pipeline name requires a pipeline file")
+	}
+
+	if options.ProgressFile != "" && options.ProgressInterval <= 0 {
+		return errors.New("progress interval must be greater than 0")
+	}
+
+	if options.OptOutKey != "" && options.OptOutURL == "" {
+		return errors.New("opt-out key requires an opt-out url")
+	}
+	if options.OptOutURL != "" && options.OptOutKey == "" && !options.OptOutUnsigned {
+		return errors.New("opt-out url requires an opt-out key (or -opt-out-unsigned)")
+	}
+	if options.OptOutUnsigned && options.OptOutKey != "" {
+		return errors.New("opt-out key and unsigned opt-out list can't be used together")
+	}
+
+	if options.Bandwidth != "" {
+		if _, err := parseBandwidth(options.Bandwidth); err != nil {
+			return err
+		}
+	}
+	if len(options.AsnRate) > 0 {
+		if _, err := parseASNRates(options.AsnRate); err != nil {
+			return err
+		}
+	}
+
+	if options.VerifyOwnership != "" {
+		if _, err := parseOwnershipOrg(options.VerifyOwnership); err != nil {
+			return err
+		}
+		if options.Stream {
+			return errors.New("verify ownership not supported in stream mode")
+		}
+	}
+	if options.OwnershipMismatch != "" && options.OwnershipMismatch != OwnershipWarn && options.OwnershipMismatch != OwnershipAbort {
+		return fmt.Errorf("invalid ownership mismatch action %s (allowed: %s, %s)", options.OwnershipMismatch, OwnershipWarn, OwnershipAbort)
+	}
+
+	if options.KillSwitch != "" {
+		if options.KillSwitchInterval <= 0 {
+			return errors.New("kill switch interval must be greater than 0")
+		}
+		if isRemoteKillSwitch(options.KillSwitch) {
+			if parsed, err := url.Parse(options.KillSwitch); err != nil || parsed.Host == "" {
+				return fmt.Errorf("invalid kill switch url %s", options.KillSwitch)
+			}
+		}
+	}
+	switch options.LockPolicy {
+	case "", LockSkip, LockQueue, LockAbortPrevious:
+	default:
+		return fmt.Errorf("invalid lock policy %s (allowed: %s, %s, %s)", options.LockPolicy, LockSkip, LockQueue, LockAbortPrevious)
+	}
+	if options.LinkDownAction != "" && options.LinkDownAction != LinkDownPause && options.LinkDownAction != LinkDownAbort {
+		return fmt.Errorf("invalid link down action %s (allowed: %s, %s)", options.LinkDownAction, LinkDownPause, LinkDownAbort)
+	}
+
+	if options.autoIPVersion() {
+		if len(options.IPVersion) > 1 {
+			return errors.New("IP Version auto can't be combined with 4 or 6")
+		}
+	} else if len(options.IPVersion) > 0 && !sliceutil.ContainsItems([]string{"4", "6"}, options.IPVersion) {
+		return errors.New("IP Version must be 4 and/or 6, or auto")
 	}
 	// Return error if any host discovery releated option is provided but host discovery is disabled
 	if options.SkipHostDiscovery && options.hasProbes() {
 		return errors.New("discovery probes were provided but host discovery is disabled")
 	}
 
+	if options.HostDiscoveryOutput != "" && !options.shouldDiscoverHosts() {
+		return errors.New("host discovery output requires host discovery")
+	}
+
 	// Host Discovery mode needs provileged access
 	if options.OnlyHostDiscovery && !privileges.IsPrivileged {
 		if osutil.IsWindows() {
@@ -129,13 +237,332 @@ func (options *Options) ValidateOptions() error {
 		return errors.New("sudo access required to perform host discovery")
 	}
 
+	for _, queryType := range options.DNSQueryTypes {
+		if _, ok := dnsQueryTypes[queryType]; !ok {
+			return fmt.Errorf("invalid dns query type %s (allowed: a, aaaa)", queryType)
+		}
+	}
+	if options.DNSCacheTTL < 0 || options.DNSNegativeCacheTTL < 0 {
+		return errors.New("dns cache ttl can't be negative")
+	}
+	if options.Offline {
+		if err := options.validateOffline(); err != nil {
+			return err
+		}
+	}
+	if options.NoDNS && (options.ReversePTR || options.Resolvers != "") {
+		return errors.New("reverse ptr and resolvers can't be used with no dns")
+	}
+	if options.Resolver != nil && (options.NoDNS || options.Resolvers != "") {
+		return errors.New("custom resolver can't be used with no dns or resolvers")
+	}
+
+	if options.FwMark < 0 {
+		return errors.New("fwmark can't be negative")
+	}
+	if options.FwMark > 0 && !osutil.IsLinux() {
+		return errors.New("fwmark is only supported on linux")
+	}
+
+	if _, err := parseTOS(options.TOS); err != nil {
+		return err
+	}
+	if options.TOS != "" && !osutil.IsLinux() && !osutil.IsOSX() {
+		return errors.New("tos is only supported on linux and darwin")
+	}
+
+	if len(options.ProbeTag) > scan.MaxProbeTagLength {
+		return fmt.Errorf("probe tag can't be longer than %d characters", scan.MaxProbeTagLength)
+	}
+	for _, r := range options.ProbeTag {
+		if r < 0x20 || r > 0x7e {
+			return errors.New("probe tag must only contain printable ascii characters")
+		}
+	}
+
+	if options.DialerCache < 0 {
+		return errors.New("dialer cache size can't be negative")
+	}
+
+	if options.StopAfterNPorts < 0 || options.StopAfterNPorts > 65535 {
+		return errors.New("stop after n ports must be between 0 and 65535")
+	}
+
 	if options.PortThreshold < 0 || options.PortThreshold > 65535 {
 		return errors.New("port threshold must be between 0 and 65535")
 	}
 
-	if options.Proxy != "" && options.ScanType == SynScan {
-		gologger.Warning().Msgf("Syn Scan can't be used with socks proxy: falling back to connect scan")
-		options.ScanType = ConnectScan
+	if (options.Banner || options.ServiceVersion || options.RawProbe != "") && options.Passive {
+		return errors.New("banner grab, service version and raw probe not supported in passive mode")
+	}
+	// the evidence certificates are recorded by the banner grab workers
+	if options.Banner || options.ServiceVersion || options.RawProbe != "" || options.EvidenceOutput != "" {
+		if options.BannerThreads <= 0 {
+			return errors.Wrap(errZeroValue, "banner threads")
+		}
+		if options.BannerRate <= 0 {
+			return errors.Wrap(errZeroValue, "banner rate")
+		}
+		if options.BannerTimeout <= 0 {
+			return errors.Wrap(errZeroValue, "banner timeout")
+		}
+	}
+
+	if options.RiskHeuristics && !options.Banner && !options.ServiceVersion && options.RawProbe == "" {
+		return errors.New("risk heuristics require banner grab, service version or raw probe")
+	}
+
+	if options.Count && (options.CSV || options.GroupBy != "" || options.OutputHosts || options.OutputIPs) {
+		return errors.New("count can't be used with csv, group by, output hosts or output ips")
+	}
+
+	if options.OutputHosts || options.OutputIPs {
+		if options.OutputHosts && options.OutputIPs {
+			return errors.New("output hosts and output ips can't be used together")
+		}
+		if options.JSON || options.CSV || options.GroupBy != "" {
+			return errors.New("output hosts and output ips can't be used with json, csv or group by output")
+		}
+	}
+
+	if options.OutputDir != "" {
+		if options.TagRules == "" {
+			return errors.New("output dir requires tag rules defining the groups")
+		}
+		if options.Count || options.GroupBy != "" || options.OutputHosts || options.OutputIPs {
+			return errors.New("output dir can't be used with count, group by, output hosts or output ips")
+		}
+	}
+
+	if options.GroupBy != "" {
+		if options.GroupBy != GroupByHost && options.GroupBy != GroupByPort {
+			return fmt.Errorf("invalid group by %s (allowed: %s, %s)", options.GroupBy, GroupByHost, GroupByPort)
+		}
+		if options.CSV {
+			return errors.New("group by not supported with csv output")
+		}
+	}
+
+	if options.Filter != "" {
+		if _, err := newResultFilter(options.Filter); err != nil {
+			return err
+		}
+	}
+
+	if options.Baseline != "" {
+		if _, err := loadBaseline(options.Baseline); err != nil {
+			return fmt.Errorf("could not read baseline: %s", err)
+		}
+	}
+	if options.ExcludeFingerprints != "" {
+		if _, err := loadFingerprints(options.ExcludeFingerprints); err != nil {
+			return fmt.Errorf("could not read fingerprints: %s", err)
+		}
+		if options.hasLiveSinks() {
+			return errors.New("exclude fingerprints can't be used with stream output, webhook, elasticsearch, live results or result writers")
+		}
+	}
+	if options.SeverityMap != "" {
+		if _, err := loadSeverityMap(options.SeverityMap); err != nil {
+			return fmt.Errorf("could not read severity map: %s", err)
+		}
+	}
+	if options.MinSeverity != "" {
+		if err := validSeverity(options.MinSeverity); err != nil {
+			return err
+		}
+		if options.StreamOutput == "" && options.WebhookURL == "" && options.ElasticsearchURL == "" && len(options.ResultWriters) == 0 {
+			return errors.New("min severity requires stream output, webhook or elasticsearch export")
+		}
+	}
+
+	switch options.ExportFormat {
+	case "", ExportJSON:
+	case ExportECS, ExportSTIX:
+		if options.StreamOutput == "" && options.WebhookURL == "" && options.ElasticsearchURL == "" {
+			return errors.New("export format requires stream output, webhook or elasticsearch export")
+		}
+	default:
+		return fmt.Errorf("invalid export format %s (allowed: %s, %s, %s)", options.ExportFormat, ExportJSON, ExportECS, ExportSTIX)
+	}
+
+	if options.RetryStrategy != "" && options.RetryStrategy != RetryUniform && options.RetryStrategy != RetryAdaptive && options.RetryStrategy != RetryBackoff {
+		return fmt.Errorf("invalid retry strategy %s (allowed: %s, %s, %s)", options.RetryStrategy, RetryUniform, RetryAdaptive, RetryBackoff)
+	}
+
+	if options.EnrichURL != "" {
+		if parsed, err := url.Parse(options.EnrichURL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid enrich url %s (allowed: http, https)", options.EnrichURL)
+		}
+		if options.Passive {
+			return errors.New("enrich url not supported in passive mode")
+		}
+	}
+	for _, exportURL := range []string{options.WebhookURL, options.ElasticsearchURL} {
+		if exportURL == "" {
+			continue
+		}
+		if parsed, err := url.Parse(exportURL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid export url %s (allowed: http, https)", exportURL)
+		}
+	}
+	if options.ElasticsearchURL != "" && options.ElasticsearchIndex == "" {
+		return errors.Wrap(errZeroValue, "elasticsearch index")
+	}
+
+	if options.AnycastCheck && options.Passive {
+		return errors.New("anycast check not supported in passive mode")
+	}
+
+	if options.RxBuffer < 0 {
+		return errors.New("rx buffer size can't be negative")
+	}
+
+	if _, err := parseConnectPorts(options.ConnectPorts); err != nil {
+		return err
+	}
+
+	if options.PortStripes != "" {
+		if _, err := parsePortStripes(options.PortStripes); err != nil {
+			return err
+		}
+		if options.Sample != "" {
+			return errors.New("port stripes can't be used with sample")
+		}
+		if options.Stream {
+			return errors.New("port stripes not supported in stream mode")
+		}
+	}
+
+	if options.Sample != "" {
+		if _, err := parseSample(options.Sample); err != nil {
+			return err
+		}
+		if options.Stream {
+			return errors.New("sample not supported in stream mode")
+		}
+	}
+
+	if options.ScanWindow != "" {
+		if _, err := parseScanWindow(options.ScanWindow); err != nil {
+			return err
+		}
+		if options.Stream {
+			gologger.Warning().Msgf("Scan window pauses can't be resumed in stream mode")
+		}
+	}
+
+	if options.ScanType == UDPScan && (options.Proxy != "" || options.SSHProxy != "") {
+		return errors.New("udp scan can't be used with socks or ssh proxy")
+	}
+
+	if options.Proxy != "" {
+		if _, _, _, err := scan.ParseProxy(options.Proxy); err != nil {
+			return err
+		}
+		if options.ScanType == SynScan {
+			options.fallbackToConnect("raw packets can't be proxied")
+		}
+	}
+
+	if options.SSHProxy != "" {
+		if options.Proxy != "" {
+			return errors.New("ssh proxy and socks proxy can't be used together")
+		}
+		if options.ScanType == SynScan {
+			options.fallbackToConnect("raw packets can't go through an ssh proxy")
+		}
+	}
+	if options.SSHKey != "" && options.SSHProxy == "" {
+		return errors.New("ssh key requires -ssh-proxy")
+	}
+
+	if options.Anonymize != "" && options.Anonymize != AnonymizeHash && options.Anonymize != AnonymizeTruncate {
+		return fmt.Errorf("invalid anonymize mode %s (allowed: %s, %s)", options.Anonymize, AnonymizeHash, AnonymizeTruncate)
+	}
+	if options.AnonymizeKey != "" && options.Anonymize == "" {
+		return errors.New("anonymize key requires -anonymize")
+	}
+	if options.EvidenceOutput != "" && options.Anonymize != "" {
+		return errors.New("evidence output can't be anonymized")
+	}
+
+	if options.ResearchOutput != "" && !options.shouldUseRawPackets() {
+		return errors.New("research output requires syn scan with root privileges")
+	}
+
+	if options.Tunnel != "" && !options.shouldUseRawPackets() {
+		return errors.New("tunnel requires syn or udp scan with root privileges")
+	}
+
+	if options.RetryStrategy == RetryBackoff && (options.ScanType != SynScan || !options.shouldUseRawPackets()) {
+		return errors.New("backoff retry strategy requires syn scan with root privileges")
+	}
+
+	if options.TCPTimestamps && !options.shouldUseRawPackets() {
+		return errors.New("tcp timestamps require syn scan with root privileges")
+	}
+
+	if options.PortBurst < 0 {
+		return errors.New("port burst can't be negative")
+	}
+	if options.PortBurst > 1 && !options.shouldUseRawPackets() {
+		return errors.New("port burst requires syn scan with root privileges")
+	}
+	if options.PortBurst > 1 && options.Stream {
+		return errors.New("port burst can't be used with stream mode")
+	}
+
+	if options.FairnessWindow < 0 {
+		return errors.New("fairness window can't be negative")
+	}
+	if options.PrefixRate < 0 {
+		return errors.New("prefix rate can't be negative")
+	}
+
+	if options.ResultsAddr != "" {
+		if err := validResultsAddr(options.ResultsAddr, options.ResultsToken); err != nil {
+			return err
+		}
+	} else if options.ResultsToken != "" {
+		return errors.New("results token requires a results address")
+	}
+
+	if options.PacketTrace && !options.shouldUseRawPackets() {
+		gologger.Warning().Msgf("Packet trace only covers raw packets: connect probes are not traced")
+	}
+
+	if options.SampleDrops < 0 {
+		return errors.New("sample drops can't be negative")
+	}
+	if options.SampleDrops > 0 && !options.shouldUseRawPackets() {
+		return errors.New("sample drops requires syn or udp scan with root privileges")
+	}
+
+	switch options.InterceptCheck {
+	case "", InterceptWarn, InterceptVerify:
+	default:
+		return fmt.Errorf("invalid intercept check mode %s (allowed: %s, %s)", options.InterceptCheck, InterceptWarn, InterceptVerify)
+	}
+	if options.InterceptCheck != "" && options.Passive {
+		return errors.New("intercept check can't be used with passive mode")
+	}
+	if options.VhostCheck && !options.Verify {
+		return errors.New("vhost check requires verify")
+	}
+
+	switch options.ConnectCriteria {
+	case "", scan.CriteriaHandshake:
+	case scan.CriteriaBanner, scan.CriteriaTLS:
+		if options.shouldUseRawPackets() && !options.Verify && options.ConnectPorts == "" {
+			return errors.New("connect criteria requires connect scan, -verify or -connect-ports")
+		}
+	default:
+		return fmt.Errorf("invalid connect criteria %s (allowed: %s, %s, %s)", options.ConnectCriteria, scan.CriteriaHandshake, scan.CriteriaBanner, scan.CriteriaTLS)
+	}
+
+	if options.ScanType == SynScan && !options.Passive && !options.shouldUseRawPackets() {
+		options.fallbackToConnect(rawPacketsUnavailable())
 	}
 
 	return nil
@@ -166,11 +593,25 @@ func (options *Options) ConfigureHostDiscovery() {
 		// - TCP SYN on port 443
 		// - TCP ACK on port 80
 		// - TCP ACK on port 443
+		// - ARP to the targets on the subnets of the scanning interfaces
 		options.IcmpEchoRequestProbe = true
 		options.IcmpTimestampRequestProbe = true
 		options.TcpSynPingProbes = append(options.TcpSynPingProbes, "80")
 		options.TcpSynPingProbes = append(options.TcpSynPingProbes, "443")
 		options.TcpAckPingProbes = append(options.TcpAckPingProbes, "80")
 		options.TcpAckPingProbes = append(options.TcpAckPingProbes, "443")
+		options.arpLocalSubnets = true
+	}
+}
+
+// parseTOS parses the decimal or hex DSCP/ToS byte, returning 0 if unset
+func parseTOS(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	tos, err := strconv.ParseUint(value, 0, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid tos %s: must be a byte value (0-255 or 0x00-0xff)", value)
 	}
+	return int(tos), nil
 }
@@ -20,3 +20,45 @@ func TestOptions(t *testing.T) {
 	options.Resolvers = "aaabbbccc"
 	assert.NotNil(t, options.ValidateOptions())
 }
+
+func TestParseTOS(t *testing.T) {
+	for value, expected := range map[string]int{"": 0, "16": 16, "0x10": 0x10, "0xb8": 0xb8} {
+		tos, err := parseTOS(value)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, tos)
+	}
+	for _, value := range []string{"256", "-1", "ef"} {
+		_, err := parseTOS(value)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestConfigureHostDiscovery(t *testing.T) {
+	options := Options{}
+	options.ConfigureHostDiscovery()
+	assert.True(t, options.IcmpEchoRequestProbe)
+	assert.Equal(t, []string{"80", "443"}, []string(options.TcpSynPingProbes))
+	assert.True(t, options.arpLocalSubnets)
+
+	// explicit probes are kept as given
+	options = Options{TcpSynPingProbes: []string{"22"}}
+	options.ConfigureHostDiscovery()
+	assert.False(t, options.IcmpEchoRequestProbe)
+	assert.Equal(t, []string{"22"}, []string(options.TcpSynPingProbes))
+	assert.False(t, options.arpLocalSubnets)
+
+	options = Options{SkipHostDiscovery: true}
+	options.ConfigureHostDiscovery()
+	assert.False(t, options.hasProbes())
+}
+
+func TestFallbackToConnect(t *testing.T) {
+	options := Options{ScanType: SynScan}
+	options.fallbackToConnect("raw packets can't be proxied")
+	assert.Equal(t, ConnectScan, options.ScanType)
+	assert.Equal(t, ConnectScan, options.usedScanType())
+	assert.Equal(t, "raw packets can't be proxied", options.scanFallback)
+
+	options = Options{ScanType: UDPScan}
+	assert.Equal(t, UDPScan, options.usedScanType())
+}
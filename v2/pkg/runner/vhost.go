@@ -0,0 +1,140 @@
+package runner
+
+import (
+	"crypto/tls"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	iputil "github.com/projectdiscovery/utils/ip"
+)
+
+// vhostChecker records the hostnames terminating the tls ports of the addresses shared by several
+// hostnames, as sni routed frontends only serve some of the names resolving to them
+type vhostChecker struct {
+	sync.Mutex
+	terminated map[string]map[int]map[string]struct{} // ip > port > hostnames
+}
+
+func newVhostChecker() *vhostChecker {
+	return &vhostChecker{terminated: make(map[string]map[int]map[string]struct{})}
+}
+
+// set records the hostnames terminating the tls port of the ip
+func (c *vhostChecker) set(ip string, portNumber int, hosts []string) {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.terminated[ip] == nil {
+		c.terminated[ip] = make(map[int]map[string]struct{})
+	}
+	names := make(map[string]struct{}, len(hosts))
+	for _, host := range hosts {
+		names[host] = struct{}{}
+	}
+	c.terminated[ip][portNumber] = names
+}
+
+// terminates returns true if the port is attributed to the host, the ports not checked being
+// attributed to every hostname of the ip
+func (c *vhostChecker) terminates(host, ip string, p *port.Port) bool {
+	if c == nil || p.Protocol != protocol.TCP || host == ip {
+		return true
+	}
+	c.Lock()
+	defer c.Unlock()
+
+	names, ok := c.terminated[ip][p.Port]
+	if !ok {
+		return true
+	}
+	_, ok = names[canonicalHost(host)]
+	return ok
+}
+
+// vhostNames returns the hostnames resolving to the ip
+func (r *Runner) vhostNames(ip string) []string {
+	hosts, err := r.scanner.IPRanger.GetHostsByIP(ip)
+	if err != nil {
+		return nil
+	}
+	unique := make(map[string]struct{}, len(hosts))
+	for _, host := range hosts {
+		if name, _, err := net.SplitHostPort(host); err == nil {
+			host = name
+		}
+		if host == "ip" || host == "cidr" || iputil.IsIP(host) {
+			continue
+		}
+		unique[canonicalHost(host)] = struct{}{}
+	}
+	names := make([]string, 0, len(unique))
+	for name := range unique {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkVhosts performs a tls handshake with each hostname of the ip on its tcp ports and records the
+// hostnames presenting a matching certificate. Ports without tls, or not terminating any of the
+// hostnames like catch-all frontends, stay attributed to all of them
+func (r *Runner) checkVhosts(ip string, ports []*port.Port) {
+	names := r.vhostNames(ip)
+	if r.vhosts == nil || len(names) < 2 {
+		return
+	}
+	timeout := time.Duration(r.options.Timeout) * time.Millisecond
+	for _, p := range ports {
+		if p.Protocol != protocol.TCP {
+			continue
+		}
+		var isTLS bool
+		var terminated, others []string
+		for _, name := range names {
+			handshake, matches := r.sniHandshake(ip, name, p, timeout)
+			isTLS = isTLS || handshake
+			if matches {
+				terminated = append(terminated, name)
+			} else {
+				others = append(others, name)
+			}
+		}
+		switch {
+		case !isTLS:
+			continue
+		case len(terminated) == 0:
+			gologger.Verbose().Msgf("No hostname of %s terminates tls port %d, keeping all of them\n", ip, p.Port)
+			continue
+		}
+		r.vhosts.set(ip, p.Port, terminated)
+		if len(others) > 0 {
+			gologger.Verbose().Msgf("Tls port %d of %s terminates %s (not %s)\n", p.Port, ip, strings.Join(terminated, ","), strings.Join(others, ","))
+		}
+	}
+}
+
+// sniHandshake returns if a tls handshake with the hostname as sni succeeded and if the certificate
+// presented is valid for the hostname
+func (r *Runner) sniHandshake(ip, name string, p *port.Port, timeout time.Duration) (bool, bool) {
+	conn, err := r.scanner.DialPort(ip, p, timeout)
+	if err != nil {
+		return false, false
+	}
+	defer conn.Close()
+
+	tlsConn := tls.Client(conn, &tls.Config{ServerName: name, InsecureSkipVerify: true}) //nolint:gosec // the certificate name is checked below
+	if err := tlsConn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return false, false
+	}
+	if err := tlsConn.Handshake(); err != nil {
+		return false, false
+	}
+	certificates := tlsConn.ConnectionState().PeerCertificates
+	return true, len(certificates) > 0 && certificates[0].VerifyHostname(name) == nil
+}
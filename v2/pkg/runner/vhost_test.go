@@ -0,0 +1,60 @@
+package runner
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/projectdiscovery/ipranger"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/projectdiscovery/naabu/v2/pkg/scan"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckVhosts(t *testing.T) {
+	// the certificate of the test server is valid for example.com
+	server := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+	plain, err := net.Listen("tcp4", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer plain.Close()
+	go func() {
+		for {
+			conn, err := plain.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	ranger, err := ipranger.New()
+	require.Nil(t, err)
+	defer ranger.Close()
+	require.Nil(t, ranger.AddHostWithMetadata("127.0.0.1", "example.com"))
+	require.Nil(t, ranger.AddHostWithMetadata("127.0.0.1", "other.example.net"))
+
+	r := &Runner{
+		options: &Options{Timeout: 2000},
+		scanner: &scan.Scanner{IPRanger: ranger},
+		vhosts:  newVhostChecker(),
+	}
+	require.Equal(t, []string{"example.com", "other.example.net"}, r.vhostNames("127.0.0.1"))
+
+	tlsPort := &port.Port{Port: server.Listener.Addr().(*net.TCPAddr).Port, Protocol: protocol.TCP}
+	plainPort := &port.Port{Port: plain.Addr().(*net.TCPAddr).Port, Protocol: protocol.TCP}
+	r.checkVhosts("127.0.0.1", []*port.Port{tlsPort, plainPort})
+
+	require.True(t, r.vhosts.terminates("example.com", "127.0.0.1", tlsPort))
+	require.False(t, r.vhosts.terminates("other.example.net", "127.0.0.1", tlsPort))
+	require.True(t, r.vhosts.terminates("127.0.0.1", "127.0.0.1", tlsPort))
+	require.True(t, r.vhosts.terminates("other.example.net", "127.0.0.1", plainPort))
+
+	ports := r.filterPorts("other.example.net", "127.0.0.1", []*port.Port{tlsPort, plainPort}, false, "")
+	require.Equal(t, []*port.Port{plainPort}, ports)
+
+	var disabled *vhostChecker
+	require.True(t, disabled.terminates("other.example.net", "127.0.0.1", tlsPort))
+}
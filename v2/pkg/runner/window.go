@@ -0,0 +1,140 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/gologger"
+)
+
+// scanWindow is a daily time range (local time) during which packets can be sent
+type scanWindow struct {
+	start time.Duration // offset from midnight
+	end   time.Duration // offset from midnight
+
+	mu sync.Mutex
+	// paused is the channel closed once the window reopens, nil while the window is open
+	paused atomic.Pointer[chan struct{}]
+}
+
+// parseScanWindow parses a window in the HH:MM-HH:MM format, ranges crossing midnight are allowed
+func parseScanWindow(value string) (*scanWindow, error) {
+	startValue, endValue, ok := strings.Cut(value, "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid scan window %s (expected HH:MM-HH:MM)", value)
+	}
+	start, err := parseClock(startValue)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid scan window start")
+	}
+	end, err := parseClock(endValue)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid scan window end")
+	}
+	if start == end {
+		return nil, fmt.Errorf("invalid scan window %s (start and end are equal)", value)
+	}
+	return &scanWindow{start: start, end: end}, nil
+}
+
+// parseClock returns the offset from midnight of a HH:MM time
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// sinceMidnight returns the time elapsed since the local midnight of t
+func sinceMidnight(t time.Time) time.Duration {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return t.Sub(midnight)
+}
+
+// Contains returns true if t falls within the window
+func (window *scanWindow) Contains(t time.Time) bool {
+	offset := sinceMidnight(t)
+	if window.start < window.end {
+		return offset >= window.start && offset < window.end
+	}
+	// the window crosses midnight
+	return offset >= window.start || offset < window.end
+}
+
+// UntilOpen returns the time left before the window opens (zero if already open)
+func (window *scanWindow) UntilOpen(t time.Time) time.Duration {
+	if window.Contains(t) {
+		return 0
+	}
+	wait := window.start - sinceMidnight(t)
+	if wait < 0 {
+		wait += 24 * time.Hour
+	}
+	return wait
+}
+
+func (window *scanWindow) String() string {
+	format := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return format(window.start) + "-" + format(window.end)
+}
+
+// gate blocks the senders outside the window: the first one finding the window closed calls pause
+// with the time left before it opens, the others waiting for it to return
+func (window *scanWindow) gate(now time.Time, pause func(wait time.Duration)) {
+	if window.paused.Load() == nil && window.Contains(now) {
+		return
+	}
+	window.mu.Lock()
+	if paused := window.paused.Load(); paused != nil {
+		window.mu.Unlock()
+		<-*paused
+		return
+	}
+	wait := window.UntilOpen(now)
+	if wait == 0 {
+		window.mu.Unlock()
+		return
+	}
+	paused := make(chan struct{})
+	window.paused.Store(&paused)
+	window.mu.Unlock()
+
+	pause(wait)
+	window.paused.Store(nil)
+	close(paused)
+}
+
+// waitScanWindow blocks the probes while outside the allowed scan window, saving a resume checkpoint
+// before pausing. The pause ends early once the scan is stopped
+func (r *Runner) waitScanWindow() {
+	r.pauseScanWindow(r.stopChan())
+}
+
+// waitTrafficWindow blocks the stages following the scan (verification, banner grabs, interception
+// and anycast checks, nmap) while outside the allowed scan window, and returns false once the kill
+// switch stopped the scan so that they send no more traffic
+func (r *Runner) waitTrafficWindow() bool {
+	r.pauseScanWindow(r.killChan())
+	return !r.scanKilled()
+}
+
+// pauseScanWindow blocks while outside the allowed scan window or until the stop channel is closed
+func (r *Runner) pauseScanWindow(stop <-chan struct{}) {
+	if r.scanWindow == nil {
+		return
+	}
+	r.scanWindow.gate(time.Now(), func(wait time.Duration) {
+		r.saveCheckpoint()
+		gologger.Info().Msgf("Outside scan window %s, pausing until %s\n", r.scanWindow, time.Now().Add(wait).Format(time.RFC1123))
+		if sleepUntil(wait, stop) {
+			gologger.Info().Msgf("Scan window %s opened, resuming scan\n", r.scanWindow)
+		}
+	})
+}
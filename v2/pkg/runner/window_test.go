@@ -0,0 +1,121 @@
+package runner
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScanWindow(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{"22:00-06:00", false},
+		{"09:30 - 17:45", false},
+		{"22:00", true},
+		{"25:00-06:00", true},
+		{"10:00-10:00", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			_, err := parseScanWindow(tt.value)
+			if tt.wantErr {
+				require.NotNil(t, err)
+			} else {
+				require.Nil(t, err)
+			}
+		})
+	}
+}
+
+func TestScanWindowContains(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2023, 1, 1, hour, minute, 0, 0, time.Local)
+	}
+
+	overnight, err := parseScanWindow("22:00-06:00")
+	require.Nil(t, err)
+	require.Equal(t, "22:00-06:00", overnight.String())
+	require.True(t, overnight.Contains(at(23, 0)))
+	require.True(t, overnight.Contains(at(2, 0)))
+	require.False(t, overnight.Contains(at(6, 0)))
+	require.False(t, overnight.Contains(at(12, 0)))
+	require.Equal(t, time.Duration(0), overnight.UntilOpen(at(23, 0)))
+	require.Equal(t, 10*time.Hour, overnight.UntilOpen(at(12, 0)))
+
+	daily, err := parseScanWindow("09:00-17:00")
+	require.Nil(t, err)
+	require.True(t, daily.Contains(at(9, 0)))
+	require.False(t, daily.Contains(at(17, 0)))
+	require.Equal(t, 16*time.Hour, daily.UntilOpen(at(17, 0)))
+	require.Equal(t, 30*time.Minute, daily.UntilOpen(at(8, 30)))
+}
+
+func TestScanWindowGate(t *testing.T) {
+	window, err := parseScanWindow("09:00-17:00")
+	require.Nil(t, err)
+	closed := time.Date(2023, 1, 1, 8, 0, 0, 0, time.Local)
+
+	var pauses atomic.Int32
+	release := make(chan struct{})
+	pause := func(wait time.Duration) {
+		require.Equal(t, time.Hour, wait)
+		pauses.Add(1)
+		<-release
+	}
+	var wg sync.WaitGroup
+	gate := func() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			window.gate(closed, pause)
+		}()
+	}
+	gate()
+	require.Eventually(t, func() bool { return window.paused.Load() != nil }, time.Second, time.Millisecond)
+	for i := 0; i < 9; i++ {
+		gate()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	// the senders waited for a single pause
+	require.Equal(t, int32(1), pauses.Load())
+	require.Nil(t, window.paused.Load())
+
+	window.gate(closed.Add(2*time.Hour), func(time.Duration) {
+		t.Fatal("paused within the window")
+	})
+}
+
+func TestWaitTrafficWindow(t *testing.T) {
+	now := time.Now()
+	window, err := parseScanWindow(now.Add(2*time.Hour).Format("15:04") + "-" + now.Add(3*time.Hour).Format("15:04"))
+	require.Nil(t, err)
+	// the pause doesn't save a checkpoint without -resume or -resume-interval
+	r := &Runner{options: &Options{}, scanWindow: window}
+
+	waited := make(chan bool)
+	go func() {
+		waited <- r.waitTrafficWindow()
+	}()
+	// stopping the scan doesn't open the window of the stages following it
+	r.stopScan()
+	select {
+	case <-waited:
+		t.Fatal("the stages following the scan must wait for the window")
+	case <-time.After(50 * time.Millisecond):
+	}
+	// the kill switch ends the pause and the traffic
+	require.True(t, r.killScan())
+	select {
+	case allowed := <-waited:
+		require.False(t, allowed)
+	case <-time.After(time.Second):
+		t.Fatal("the kill switch must end the pause")
+	}
+}
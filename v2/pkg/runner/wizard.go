@@ -0,0 +1,155 @@
+package runner
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/privileges"
+)
+
+// wizardQuestion asks for a value, the empty answer selecting the default
+type wizardQuestion struct {
+	prompt   string
+	fallback string
+	// check returns an error explaining why the answer is invalid, the question being asked again
+	check func(answer string) error
+}
+
+// DefaultConfigPath returns the config file naabu reads its flags from
+func DefaultConfigPath() (string, error) {
+	return newFlagSet(&Options{}).GetConfigFilePath()
+}
+
+// RunWizard asks a few questions on the input and returns the config file of the answers, its
+// keys being the long names of the flags
+func RunWizard(in io.Reader, out io.Writer) (string, error) {
+	scanner := bufio.NewScanner(in)
+	ask := func(question wizardQuestion) (string, error) {
+		for {
+			fmt.Fprintf(out, "%s [%s]: ", question.prompt, question.fallback)
+			if !scanner.Scan() {
+				if err := scanner.Err(); err != nil {
+					return "", err
+				}
+				return "", io.ErrUnexpectedEOF
+			}
+			answer := strings.TrimSpace(scanner.Text())
+			if answer == "" {
+				answer = question.fallback
+			}
+			if question.check == nil {
+				return answer, nil
+			}
+			if err := question.check(answer); err != nil {
+				fmt.Fprintf(out, "%s\n", err)
+				continue
+			}
+			return answer, nil
+		}
+	}
+	oneOf := func(allowed ...string) func(string) error {
+		return func(answer string) error {
+			for _, value := range allowed {
+				if strings.EqualFold(answer, value) {
+					return nil
+				}
+			}
+			return fmt.Errorf("invalid answer %s (allowed: %s)", answer, strings.Join(allowed, ", "))
+		}
+	}
+	yesNo := oneOf("y", "n", "yes", "no")
+	positive := func(answer string) error {
+		if value, err := strconv.Atoi(answer); err != nil || value <= 0 {
+			return fmt.Errorf("invalid answer %s (allowed: positive number)", answer)
+		}
+		return nil
+	}
+
+	var config []string
+	set := func(key string, value interface{}) {
+		if text, ok := value.(string); ok {
+			value = strconv.Quote(text)
+		}
+		config = append(config, fmt.Sprintf("%s: %v", key, value))
+	}
+
+	scanType := SynScan
+	if !privileges.IsPrivileged {
+		scanType = ConnectScan
+	}
+	answer, err := ask(wizardQuestion{prompt: "Scan type, syn needs root privileges (s/c)", fallback: scanType, check: oneOf(SynScan, ConnectScan)})
+	if err != nil {
+		return "", err
+	}
+	scanType = strings.ToLower(answer)
+	set("scan-type", scanType)
+
+	answer, err = ask(wizardQuestion{prompt: "Ports to scan (100, 1000, full or a list like 80,443,8000-9000)", fallback: "100", check: func(answer string) error {
+		if answer == "100" || answer == "1000" || strings.EqualFold(answer, "full") {
+			return nil
+		}
+		if _, err := parsePortsList(answer); err != nil {
+			return fmt.Errorf("invalid ports %s: %s", answer, err)
+		}
+		return nil
+	}})
+	if err != nil {
+		return "", err
+	}
+	if answer == "100" || answer == "1000" || strings.EqualFold(answer, "full") {
+		set("top-ports", strings.ToLower(answer))
+	} else {
+		set("port", answer)
+	}
+
+	rate := DefaultRateSynScan
+	if scanType == ConnectScan {
+		rate = DefaultRateConnectScan
+	}
+	answer, err = ask(wizardQuestion{prompt: "Packets to send per second", fallback: strconv.Itoa(rate), check: positive})
+	if err != nil {
+		return "", err
+	}
+	rate, _ = strconv.Atoi(answer)
+	set("rate", rate)
+
+	answer, err = ask(wizardQuestion{prompt: "Only scan ports 80,443 of hosts behind a CDN/WAF (y/n)", fallback: "y", check: yesNo})
+	if err != nil {
+		return "", err
+	}
+	set("exclude-cdn", isYes(answer))
+
+	answer, err = ask(wizardQuestion{prompt: "Verify the open ports with a full connection (y/n)", fallback: "n", check: yesNo})
+	if err != nil {
+		return "", err
+	}
+	set("verify", isYes(answer))
+
+	answer, err = ask(wizardQuestion{prompt: "Output format (text/json/csv)", fallback: "text", check: oneOf("text", "json", "csv")})
+	if err != nil {
+		return "", err
+	}
+	switch strings.ToLower(answer) {
+	case "json":
+		set("json", true)
+	case "csv":
+		set("csv", true)
+	}
+
+	answer, err = ask(wizardQuestion{prompt: "File to write the output to, none for stdout", fallback: "none"})
+	if err != nil {
+		return "", err
+	}
+	if answer != "none" {
+		set("output", answer)
+	}
+
+	return "# naabu config file generated by naabu init\n" + strings.Join(config, "\n") + "\n", nil
+}
+
+func isYes(answer string) bool {
+	return strings.HasPrefix(strings.ToLower(answer), "y")
+}
@@ -0,0 +1,35 @@
+package runner
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunWizard(t *testing.T) {
+	var out bytes.Buffer
+	config, err := RunWizard(strings.NewReader("c\n80,443,8000-9000\nfast\n2000\nn\n\njson\nresults.json\n"), &out)
+	require.Nil(t, err)
+	require.Equal(t, "# naabu config file generated by naabu init\n"+
+		"scan-type: \"c\"\n"+
+		"port: \"80,443,8000-9000\"\n"+
+		"rate: 2000\n"+
+		"exclude-cdn: false\n"+
+		"verify: false\n"+
+		"json: true\n"+
+		"output: \"results.json\"\n", config)
+	require.Contains(t, out.String(), "invalid answer fast (allowed: positive number)")
+
+	config, err = RunWizard(strings.NewReader("s\nfull\n\n\ny\n\n\n"), &out)
+	require.Nil(t, err)
+	require.Contains(t, config, "top-ports: \"full\"\n")
+	require.Contains(t, config, "rate: 1000\n")
+	require.Contains(t, config, "exclude-cdn: true\n")
+	require.Contains(t, config, "verify: true\n")
+	require.NotContains(t, config, "output")
+
+	_, err = RunWizard(strings.NewReader("c\n"), &out)
+	require.NotNil(t, err)
+}
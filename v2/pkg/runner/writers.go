@@ -0,0 +1,419 @@
+package runner
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/retryablehttp-go"
+)
+
+const (
+	// exportBatchSize is the number of results posted at once to a webhook or elasticsearch
+	exportBatchSize = 100
+	// exportFlushInterval is the maximum time a result waits in a batch
+	exportFlushInterval = 5 * time.Second
+	// exportQueueSize is the number of results waiting to be posted, Write blocking once it's full
+	exportQueueSize = 16 * exportBatchSize
+)
+
+// ResultWriter receives each open port as soon as it is confirmed, as a result whose Port is set
+type ResultWriter interface {
+	Write(result *Result) error
+	Close() error
+}
+
+// scanTypeNames are the names of the scan types reported in results
+var scanTypeNames = map[string]string{
+	SynScan:     "syn",
+	ConnectScan: "connect",
+	UDPScan:     "udp",
+}
+
+// jsonLinesWriter writes each result as a json line to a file
+type jsonLinesWriter struct {
+	sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+	format  string // export format of the results (json/ecs/stix)
+}
+
+func newJSONLinesWriter(path string) (*jsonLinesWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonLinesWriter{file: file, encoder: json.NewEncoder(file)}, nil
+}
+
+// Write appends the json line of the result
+func (w *jsonLinesWriter) Write(result *Result) error {
+	w.Lock()
+	defer w.Unlock()
+	return w.encoder.Encode(exportDocument(result, w.format))
+}
+
+// Close closes the file
+func (w *jsonLinesWriter) Close() error {
+	return w.file.Close()
+}
+
+// httpWriter posts the results in batches, as a json array to a webhook or as a bulk request to
+// an elasticsearch index. The results are queued and posted by a background goroutine, once a batch
+// is full or every flush interval. The batches the sink fails to receive are spilled to a local file
+// and posted again with backoff by a retry goroutine with its own timer, then at exit
+type httpWriter struct {
+	client *retryablehttp.Client
+	url    string
+	index  string // elasticsearch index, empty for a webhook
+	format string // export format of the results (json/ecs/stix)
+	queue  chan interface{}
+	done   chan struct{}
+	// closing guards the queue against writes after Close
+	closing sync.RWMutex
+	closed  bool
+	spill   spill
+	// retry wakes the retry goroutine up once results are spilled, stop makes it post them a last
+	// time regardless of the backoff before retried is closed
+	retry   chan struct{}
+	stop    chan struct{}
+	retried chan struct{}
+	errMu   sync.Mutex
+	err     error
+}
+
+func newWebhookWriter(url string) *httpWriter {
+	return newHTTPWriter(url, "")
+}
+
+func newElasticsearchWriter(url, index string) *httpWriter {
+	return newHTTPWriter(strings.TrimSuffix(url, "/")+"/_bulk", index)
+}
+
+func newHTTPWriter(url, index string) *httpWriter {
+	w := &httpWriter{
+		client:  retryablehttp.NewClient(retryablehttp.DefaultOptionsSingle),
+		url:     url,
+		index:   index,
+		queue:   make(chan interface{}, exportQueueSize),
+		done:    make(chan struct{}),
+		retry:   make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		retried: make(chan struct{}),
+	}
+	go w.run()
+	go w.retrySpilled()
+	return w
+}
+
+// Write queues the result to be posted, blocking while the queue is full
+func (w *httpWriter) Write(result *Result) error {
+	document := exportDocument(result, w.format)
+
+	w.closing.RLock()
+	defer w.closing.RUnlock()
+
+	if w.closed {
+		return fmt.Errorf("could not post result to %s: writer closed", w.url)
+	}
+	w.queue <- document
+	return nil
+}
+
+// Close posts the pending results, the spilled ones included regardless of the backoff, and keeps
+// the spill file of the results the sink still fails to receive
+func (w *httpWriter) Close() error {
+	w.closing.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.closing.Unlock()
+	<-w.done
+	close(w.stop)
+	<-w.retried
+
+	path, kept, closeErr := w.spill.close()
+	if path != "" {
+		return fmt.Errorf("could not post %d results to %s, they are kept in %s", kept, w.url, path)
+	}
+	if err := w.firstErr(); err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// run batches the queued results and posts them once the batch is full or on each tick
+func (w *httpWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(exportFlushInterval)
+	defer ticker.Stop()
+
+	var batch []interface{}
+	for {
+		select {
+		case document, ok := <-w.queue:
+			if !ok {
+				w.record(w.flush(batch))
+				return
+			}
+			batch = append(batch, document)
+			if len(batch) >= exportBatchSize {
+				w.record(w.flush(batch))
+				batch = nil
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				w.record(w.flush(batch))
+				batch = nil
+			}
+		}
+	}
+}
+
+// record reports the error of a flush, keeping the first one for Close
+func (w *httpWriter) record(err error) {
+	if err == nil {
+		return
+	}
+	gologger.Warning().Msgf("Could not export results to %s: %s\n", w.url, err)
+	w.errMu.Lock()
+	defer w.errMu.Unlock()
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+// firstErr returns the first error of the flushes and retries
+func (w *httpWriter) firstErr() error {
+	w.errMu.Lock()
+	defer w.errMu.Unlock()
+	return w.err
+}
+
+// flush posts the batch, appended to the spill file while spilled results wait for the sink so
+// that they reach it in order. The results the sink fails to receive because of a transient
+// failure are spilled, the retry goroutine posting them again once the backoff elapsed
+func (w *httpWriter) flush(batch []interface{}) error {
+	if w.spill.pending() {
+		err := w.spill.add(batch)
+		w.wakeRetry()
+		return err
+	}
+
+	var firstErr error
+	for len(batch) > 0 {
+		size := min(exportBatchSize, len(batch))
+		transient, err := w.post(batch[:size])
+		if err != nil && transient {
+			backoff := w.spill.fail(time.Now())
+			gologger.Warning().Msgf("Could not post %d results to %s, retrying in %s: %s\n", len(batch), w.url, backoff, err)
+			if err := w.spill.add(batch); err != nil {
+				return err
+			}
+			w.wakeRetry()
+			return firstErr
+		}
+		// the results the sink rejects are not retried
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		batch = batch[size:]
+	}
+	return firstErr
+}
+
+// wakeRetry makes the retry goroutine schedule the retry of the spilled results
+func (w *httpWriter) wakeRetry() {
+	select {
+	case w.retry <- struct{}{}:
+	default:
+	}
+}
+
+// retrySpilled posts the spilled results once their backoff elapsed, apart from the queue so that
+// a long outage doesn't delay the results being written, and a last time once the writer closes
+func (w *httpWriter) retrySpilled() {
+	defer close(w.retried)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	<-timer.C
+
+	for {
+		var due <-chan time.Time
+		if wait, ok := w.spill.wait(time.Now()); ok {
+			timer.Reset(wait)
+			due = timer.C
+		}
+		select {
+		case <-w.retry:
+		case <-due:
+			w.record(w.postSpilled())
+			continue
+		case <-w.stop:
+			w.record(w.postSpilled())
+			return
+		}
+		if due != nil && !timer.Stop() {
+			<-timer.C
+		}
+	}
+}
+
+// postSpilled posts the spilled results in batches read from the spill file, until the sink fails
+// again with a transient failure
+func (w *httpWriter) postSpilled() error {
+	var firstErr error
+	for {
+		documents, offset, err := w.spill.next(exportBatchSize)
+		if err != nil || len(documents) == 0 {
+			return err
+		}
+		transient, err := w.post(documents)
+		if err != nil && transient {
+			backoff := w.spill.fail(time.Now())
+			gologger.Warning().Msgf("Could not post the spilled results to %s, retrying in %s: %s\n", w.url, backoff, err)
+			return firstErr
+		}
+		// the results the sink rejects are not retried
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if err := w.spill.commit(offset, len(documents)); err != nil {
+			return err
+		}
+		if !w.spill.pending() {
+			gologger.Info().Msgf("Posted the spilled results to %s\n", w.url)
+			return firstErr
+		}
+	}
+}
+
+// post sends the documents to the sink, returning true for the failures worth retrying
+// (unreachable sink, server errors, timeouts and rate limits)
+func (w *httpWriter) post(documents []interface{}) (bool, error) {
+	body, contentType, err := w.encode(documents)
+	if err != nil {
+		return false, err
+	}
+
+	request, err := retryablehttp.NewRequest(http.MethodPost, w.url, body)
+	if err != nil {
+		return false, err
+	}
+	request.Header.Set("Content-Type", contentType)
+	response, err := w.client.Do(request)
+	if err != nil {
+		return isTransient(err), err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		transient := response.StatusCode >= 500 || response.StatusCode == http.StatusRequestTimeout || response.StatusCode == http.StatusTooManyRequests
+		return transient, fmt.Errorf("unexpected status code %d from %s", response.StatusCode, w.url)
+	}
+	if w.index != "" {
+		var bulk struct {
+			Errors bool `json:"errors"`
+		}
+		if err := json.NewDecoder(response.Body).Decode(&bulk); err == nil && bulk.Errors {
+			return false, fmt.Errorf("elasticsearch rejected some results of the bulk request to %s", w.url)
+		}
+	}
+	return false, nil
+}
+
+// isTransient returns true if the sink may receive the results later, the dns names not found and
+// the tls failures being configuration errors retrying can't fix
+func isTransient(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return false
+	}
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		hostname         x509.HostnameError
+		invalid          x509.CertificateInvalidError
+		recordHeader     tls.RecordHeaderError
+	)
+	return !errors.As(err, &unknownAuthority) && !errors.As(err, &hostname) && !errors.As(err, &invalid) && !errors.As(err, &recordHeader)
+}
+
+// encode returns the body of the documents with its content type
+func (w *httpWriter) encode(documents []interface{}) ([]byte, string, error) {
+	if w.index == "" {
+		body, err := json.Marshal(documents)
+		return body, "application/json", err
+	}
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	for _, data := range documents {
+		if err := encoder.Encode(map[string]interface{}{"index": map[string]string{"_index": w.index}}); err != nil {
+			return nil, "", err
+		}
+		if err := encoder.Encode(data); err != nil {
+			return nil, "", err
+		}
+	}
+	return buffer.Bytes(), "application/x-ndjson", nil
+}
+
+// newResultWriters returns the writers of the output options and the ones given by library users
+func newResultWriters(options *Options) ([]ResultWriter, error) {
+	var writers []ResultWriter
+	if options.StreamOutput != "" {
+		writer, err := newJSONLinesWriter(options.StreamOutput)
+		if err != nil {
+			return nil, fmt.Errorf("could not create stream output file: %s", err)
+		}
+		writer.format = options.ExportFormat
+		writers = append(writers, writer)
+	}
+	if options.WebhookURL != "" {
+		writer := newWebhookWriter(options.WebhookURL)
+		writer.format = options.ExportFormat
+		writers = append(writers, writer)
+	}
+	if options.ElasticsearchURL != "" {
+		writer := newElasticsearchWriter(options.ElasticsearchURL, options.ElasticsearchIndex)
+		writer.format = options.ExportFormat
+		writers = append(writers, writer)
+	}
+	return append(writers, options.ResultWriters...), nil
+}
+
+// writeResult sends the open port of the host to the result writers, anonymized as file outputs,
+// unless it is less severe than -min-severity
+func (r *Runner) writeResult(data *Result) {
+	if r.belowMinSeverity(data.Port) {
+		return
+	}
+	for _, writer := range r.writers {
+		if err := writer.Write(r.anonymizer.Result(data)); err != nil {
+			gologger.Warning().Msgf("Could not export %s:%d: %s\n", data.IP, data.Port.Port, err)
+			r.recordError(ErrorOutput, data.IP, err)
+		}
+	}
+}
+
+// closeWriters flushes and closes the result writers
+func (r *Runner) closeWriters() {
+	for _, writer := range r.writers {
+		if err := writer.Close(); err != nil {
+			gologger.Warning().Msgf("Could not flush results: %s\n", err)
+			r.recordError(ErrorOutput, "", err)
+		}
+	}
+	r.writers = nil
+}
@@ -0,0 +1,252 @@
+package runner
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/projectdiscovery/retryablehttp-go"
+	"github.com/stretchr/testify/require"
+)
+
+func testWriterResult(portNumber int) *Result {
+	return &Result{
+		IP:        "192.0.2.1",
+		ScanType:  "syn",
+		TimeStamp: time.Now().UTC(),
+		Port:      &port.Port{Port: portNumber, Protocol: protocol.TCP, RTT: 1500 * time.Microsecond},
+	}
+}
+
+func TestJSONLinesWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stream.jsonl")
+	writer, err := newJSONLinesWriter(path)
+	require.Nil(t, err)
+	require.Nil(t, writer.Write(testWriterResult(80)))
+	require.Nil(t, writer.Write(testWriterResult(443)))
+	require.Nil(t, writer.Close())
+
+	data, err := os.ReadFile(path)
+	require.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+
+	var record map[string]interface{}
+	require.Nil(t, json.Unmarshal([]byte(lines[1]), &record))
+	require.Equal(t, float64(443), record["port"])
+	require.Equal(t, "tcp", record["protocol"])
+	require.Equal(t, "syn", record["scan_type"])
+	require.Equal(t, 1.5, record["response_time_ms"])
+}
+
+func TestWebhookWriter(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		batches [][]map[string]interface{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch []map[string]interface{}
+		require.Nil(t, json.NewDecoder(r.Body).Decode(&batch))
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		mu.Lock()
+		batches = append(batches, batch)
+		mu.Unlock()
+	}))
+	defer server.Close()
+	posted := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(batches)
+	}
+
+	writer := newWebhookWriter(server.URL)
+	for i := 0; i < exportBatchSize+1; i++ {
+		require.Nil(t, writer.Write(testWriterResult(i+1)))
+	}
+	// the full batch is posted in the background
+	require.Eventually(t, func() bool { return posted() == 1 }, time.Second, 10*time.Millisecond)
+	require.Len(t, batches[0], exportBatchSize)
+	require.Nil(t, writer.Close())
+	require.Equal(t, 2, posted())
+	require.Equal(t, float64(exportBatchSize+1), batches[1][0]["port"])
+	require.NotNil(t, writer.Write(testWriterResult(80)))
+}
+
+func TestElasticsearchWriter(t *testing.T) {
+	var lines []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/_bulk", r.URL.Path)
+		require.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		_, _ = io.WriteString(w, `{"errors":false,"items":[]}`)
+	}))
+	defer server.Close()
+
+	writer := newElasticsearchWriter(server.URL+"/", "scans")
+	require.Nil(t, writer.Write(testWriterResult(22)))
+	require.Nil(t, writer.Close())
+	require.Len(t, lines, 2)
+	require.Equal(t, `{"index":{"_index":"scans"}}`, lines[0])
+	require.Contains(t, lines[1], `"port":22`)
+
+	// rejected documents are reported
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"errors":true}`)
+	})
+	writer = newElasticsearchWriter(server.URL+"/", "scans")
+	require.Nil(t, writer.Write(testWriterResult(22)))
+	require.NotNil(t, writer.Close())
+}
+
+func TestWebhookWriterSpill(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		ports []float64
+	)
+	var failing atomic.Bool
+	failing.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var batch []map[string]interface{}
+		require.Nil(t, json.NewDecoder(r.Body).Decode(&batch))
+		mu.Lock()
+		defer mu.Unlock()
+		for _, record := range batch {
+			ports = append(ports, record["port"].(float64))
+		}
+	}))
+	defer server.Close()
+
+	writer := newWebhookWriter(server.URL)
+	options := retryablehttp.DefaultOptionsSingle
+	options.RetryMax = 0
+	writer.client = retryablehttp.NewClient(options)
+
+	// the failed batch is spilled, the next results waiting for the retry
+	require.Nil(t, writer.flush([]interface{}{exportDocument(testWriterResult(80), "")}))
+	require.True(t, writer.spill.pending())
+	require.Equal(t, spillMinBackoff, writer.spill.backoff)
+	require.Nil(t, writer.flush([]interface{}{exportDocument(testWriterResult(443), "")}))
+	writer.spill.Lock()
+	require.Equal(t, 2, writer.spill.count)
+	path := writer.spill.file.Name()
+	writer.spill.Unlock()
+
+	// the retry goroutine posts the spilled results in order once the sink recovers, without
+	// further results being written
+	failing.Store(false)
+	writer.spill.Lock()
+	writer.spill.retryAt = time.Time{}
+	writer.spill.Unlock()
+	writer.wakeRetry()
+	require.Eventually(t, func() bool { return !writer.spill.pending() }, 5*time.Second, 10*time.Millisecond)
+	mu.Lock()
+	require.Equal(t, []float64{80, 443}, ports)
+	mu.Unlock()
+
+	require.Nil(t, writer.Close())
+	_, err := os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestWebhookWriterKeepsSpill(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	writer := newWebhookWriter(server.URL)
+	options := retryablehttp.DefaultOptionsSingle
+	options.RetryMax = 0
+	writer.client = retryablehttp.NewClient(options)
+	require.Nil(t, writer.Write(testWriterResult(80)))
+	err := writer.Close()
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "could not post 1 results")
+	_, path, ok := strings.Cut(err.Error(), "kept in ")
+	require.True(t, ok)
+	defer os.Remove(path)
+	_, err = os.Stat(path)
+	require.Nil(t, err)
+}
+
+func TestSpillBackoff(t *testing.T) {
+	var s spill
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		s.fail(now)
+	}
+	require.Equal(t, spillMaxBackoff, s.backoff)
+	require.Nil(t, s.add([]interface{}{map[string]int{"port": 80}}))
+	require.True(t, s.pending())
+	wait, ok := s.wait(now)
+	require.True(t, ok)
+	require.Equal(t, spillMaxBackoff, wait)
+	documents, offset, err := s.next(exportBatchSize)
+	require.Nil(t, err)
+	require.Nil(t, s.commit(offset, len(documents)))
+	require.Equal(t, time.Duration(0), s.backoff)
+	_, ok = s.wait(now)
+	require.False(t, ok)
+	path, kept, err := s.close()
+	require.Nil(t, err)
+	require.Empty(t, path)
+	require.Zero(t, kept)
+}
+
+func TestSpillBatches(t *testing.T) {
+	var s spill
+	for port := 1; port <= 5; port++ {
+		require.Nil(t, s.add([]interface{}{map[string]int{"port": port}}))
+	}
+
+	// the results are read back in batches, in order
+	documents, offset, err := s.next(2)
+	require.Nil(t, err)
+	require.Len(t, documents, 2)
+	require.JSONEq(t, `{"port":1}`, string(documents[0].(json.RawMessage)))
+	require.Nil(t, s.commit(offset, len(documents)))
+	documents, _, err = s.next(2)
+	require.Nil(t, err)
+	require.JSONEq(t, `{"port":3}`, string(documents[0].(json.RawMessage)))
+
+	// the kept file only holds the results not delivered
+	path, kept, err := s.close()
+	require.Nil(t, err)
+	defer os.Remove(path)
+	require.Equal(t, 3, kept)
+	data, err := os.ReadFile(path)
+	require.Nil(t, err)
+	require.Equal(t, "{\"port\":3}\n{\"port\":4}\n{\"port\":5}\n", string(data))
+}
+
+func TestIsTransient(t *testing.T) {
+	require.True(t, isTransient(errors.New("connection reset by peer")))
+	require.True(t, isTransient(&net.DNSError{Err: "timeout", IsTimeout: true}))
+	require.False(t, isTransient(&net.DNSError{Err: "no such host", IsNotFound: true}))
+	require.False(t, isTransient(fmt.Errorf("post: %w", x509.UnknownAuthorityError{})))
+	require.False(t, isTransient(fmt.Errorf("post: %w", x509.HostnameError{})))
+	require.False(t, isTransient(tls.RecordHeaderError{}))
+}
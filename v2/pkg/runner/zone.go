@@ -0,0 +1,37 @@
+package runner
+
+import (
+	"fmt"
+	"net/netip"
+
+	"github.com/projectdiscovery/gologger"
+	iputil "github.com/projectdiscovery/utils/ip"
+)
+
+// splitZone returns the address and zone of an ipv6 target with a zone identifier (fe80::1%eth0)
+func splitZone(target string) (ip, zone string, ok bool) {
+	addr, err := netip.ParseAddr(target)
+	if err != nil || !addr.Is6() || addr.Zone() == "" {
+		return "", "", false
+	}
+	return addr.WithZone("").String(), addr.Zone(), true
+}
+
+// addZonedTarget adds a link-local ipv6 target with its zone, scanned with connect probes dialed
+// through the interface of the zone and reported with the zone in its host
+func (r *Runner) addZonedTarget(target, ip, zone string) error {
+	if r.options.shouldUseRawPackets() {
+		return fmt.Errorf("could not add %s: targets with a zone require a connect scan (-scan-type c)", target)
+	}
+	if r.isOutOfScope(target, ip) {
+		return nil
+	}
+	if previous := r.scanner.Zone(ip); previous != "" && previous != zone {
+		gologger.Warning().Msgf("Scanning %s through %s only, it was also given with zone %s\n", ip, zone, previous)
+	}
+	r.scanner.SetZone(ip, zone)
+	if r.options.Stream {
+		r.streamChannel <- Target{Cidr: iputil.ToCidr(ip).String()}
+	}
+	return r.scanner.IPRanger.AddHostWithMetadata(ip, target)
+}
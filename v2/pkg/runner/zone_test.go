@@ -0,0 +1,24 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitZone(t *testing.T) {
+	ip, zone, ok := splitZone("fe80::1%eth0")
+	require.True(t, ok)
+	require.Equal(t, "fe80::1", ip)
+	require.Equal(t, "eth0", zone)
+
+	ip, zone, ok = splitZone("FE80::A%25")
+	require.True(t, ok)
+	require.Equal(t, "fe80::a", ip)
+	require.Equal(t, "25", zone)
+
+	for _, target := range []string{"fe80::1", "10.0.0.1", "scanme.sh", "10.0.0.0/24", "fe80::1%"} {
+		_, _, ok := splitZone(target)
+		require.False(t, ok, target)
+	}
+}
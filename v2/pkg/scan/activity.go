@@ -0,0 +1,207 @@
+package scan
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+)
+
+// activityFlushInterval is the interval the buffered activity records are compressed and written
+// to the file, bounding the records lost if naabu is killed
+const activityFlushInterval = 5 * time.Second
+
+// activityQueueSize is the number of records queued for the writer, the probes only blocking on
+// the log when the writer falls that far behind
+const activityQueueSize = 8192
+
+// Activity types of the probes
+const (
+	ActivitySyn     = "syn"
+	ActivityAck     = "ack"
+	ActivityUDP     = "udp"
+	ActivityConnect = "connect"
+)
+
+// ActivityRecord is a probe or connection attempt of the activity log
+type ActivityRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	Source    string    `json:"src,omitempty"`
+	Target    string    `json:"dst"`
+	Port      int       `json:"port,omitempty"`
+	Protocol  string    `json:"protocol,omitempty"`
+	Type      string    `json:"type"`
+}
+
+// activityLog appends a json line per probe and connection attempt to a gzip file, separate from
+// the results, to keep a full record of the scan activity. Each run appends a gzip member to the
+// file, read back as a whole by zcat or gzip.Reader. The records are queued and encoded by a
+// writer goroutine, so that the probes never wait on the compression
+type activityLog struct {
+	file    *os.File
+	buffer  *bufio.Writer
+	gzip    *gzip.Writer
+	encoder *json.Encoder
+	queue   chan *ActivityRecord
+	done    chan struct{}
+	err     error
+	// closing guards the queue against the records sent while the log is closed
+	closing sync.RWMutex
+	closed  bool
+}
+
+func newActivityLog(path string) (*activityLog, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	log := &activityLog{file: file, queue: make(chan *ActivityRecord, activityQueueSize), done: make(chan struct{})}
+	log.buffer = bufio.NewWriterSize(file, 64*1024)
+	log.gzip = gzip.NewWriter(log.buffer)
+	log.encoder = json.NewEncoder(log.gzip)
+	go log.run()
+	return log, nil
+}
+
+// run encodes the queued records and periodically flushes them to the file until the queue is
+// closed, then writes the remaining records and closes the file
+func (log *activityLog) run() {
+	defer close(log.done)
+	ticker := time.NewTicker(activityFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case record, ok := <-log.queue:
+			if !ok {
+				log.err = log.finish()
+				return
+			}
+			if err := log.encoder.Encode(record); err != nil {
+				gologger.Warning().Msgf("Could not write activity log: %s\n", err)
+			}
+		case <-ticker.C:
+			if err := log.flush(); err != nil {
+				gologger.Warning().Msgf("Could not write activity log: %s\n", err)
+			}
+		}
+	}
+}
+
+// flush writes the buffered records to the file
+func (log *activityLog) flush() error {
+	if err := log.gzip.Flush(); err != nil {
+		return err
+	}
+	return log.buffer.Flush()
+}
+
+// finish terminates the gzip member and closes the file
+func (log *activityLog) finish() error {
+	if err := log.gzip.Close(); err != nil {
+		log.file.Close()
+		return err
+	}
+	if err := log.buffer.Flush(); err != nil {
+		log.file.Close()
+		return err
+	}
+	if err := log.file.Sync(); err != nil {
+		log.file.Close()
+		return err
+	}
+	return log.file.Close()
+}
+
+// record queues the record, doing nothing if the log is disabled or closed
+func (log *activityLog) record(record *ActivityRecord) {
+	if log == nil {
+		return
+	}
+	log.closing.RLock()
+	defer log.closing.RUnlock()
+
+	if !log.closed {
+		log.queue <- record
+	}
+}
+
+// probe records a raw transport probe
+func (log *activityLog) probe(source net.IP, ip string, p *port.Port, flag PkgFlag) {
+	if log == nil {
+		return
+	}
+	kind := ActivityUDP
+	if p.Protocol == protocol.TCP {
+		kind = ActivitySyn
+		if flag == Ack {
+			kind = ActivityAck
+		}
+	}
+	log.record(&ActivityRecord{Timestamp: time.Now().UTC(), Source: source.String(), Target: ip, Port: p.Port, Protocol: p.Protocol.String(), Type: kind})
+}
+
+// close writes the remaining records and closes the file
+func (log *activityLog) close() error {
+	if log == nil {
+		return nil
+	}
+	log.closing.Lock()
+	if log.closed {
+		log.closing.Unlock()
+		return nil
+	}
+	log.closed = true
+	close(log.queue)
+	log.closing.Unlock()
+
+	<-log.done
+	return log.err
+}
+
+// connectActivity records a connection attempt, with the local address of the connection if it
+// was established directly
+func (s *Scanner) connectActivity(host string, p *port.Port, conn net.Conn) {
+	if s.activity == nil {
+		return
+	}
+	record := &ActivityRecord{Timestamp: time.Now().UTC(), Target: host, Port: p.Port, Protocol: p.Protocol.String(), Type: ActivityConnect}
+	if conn != nil && s.proxyDialer == nil {
+		record.Source, _, _ = net.SplitHostPort(conn.LocalAddr().String())
+	} else if s.proxyDialer == nil {
+		record.Source = s.sourceOf(host)
+	}
+	s.activity.record(record)
+}
+
+// probeActivity records a host discovery probe
+func (s *Scanner) probeActivity(ip, probe string) {
+	if s.activity == nil {
+		return
+	}
+	s.activity.record(&ActivityRecord{Timestamp: time.Now().UTC(), Source: s.sourceOf(ip), Target: ip, Type: probe})
+}
+
+// sourceOf returns the source ip of the probes to the ip, empty if unknown
+func (s *Scanner) sourceOf(ip string) string {
+	target := net.ParseIP(ip)
+	switch {
+	case target == nil:
+		return ""
+	case target.To4() != nil && s.SourceIP4 != nil:
+		return s.SourceIP4.String()
+	case target.To4() == nil && s.SourceIP6 != nil:
+		return s.SourceIP6.String()
+	case s.Router != nil:
+		if _, _, source, err := s.Router.Route(target); err == nil && source != nil {
+			return source.String()
+		}
+	}
+	return ""
+}
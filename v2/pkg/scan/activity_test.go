@@ -0,0 +1,93 @@
+package scan
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func readActivityLog(t *testing.T, path string) []*ActivityRecord {
+	f, err := os.Open(path)
+	require.Nil(t, err)
+	defer f.Close()
+	reader, err := gzip.NewReader(f)
+	require.Nil(t, err)
+
+	var records []*ActivityRecord
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		record := &ActivityRecord{}
+		require.Nil(t, json.Unmarshal(scanner.Bytes(), record))
+		records = append(records, record)
+	}
+	require.Nil(t, scanner.Err())
+	return records
+}
+
+func TestActivityLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "activity.jsonl.gz")
+
+	log, err := newActivityLog(path)
+	require.Nil(t, err)
+	log.probe(net.ParseIP("10.0.0.5"), "10.0.0.1", &port.Port{Port: 22, Protocol: protocol.TCP}, Syn)
+	log.probe(net.ParseIP("10.0.0.5"), "10.0.0.1", &port.Port{Port: 53, Protocol: protocol.UDP}, Syn)
+	require.Nil(t, log.close())
+	require.Nil(t, log.close())
+	log.probe(net.ParseIP("10.0.0.5"), "10.0.0.1", &port.Port{Port: 80, Protocol: protocol.TCP}, Syn)
+
+	// a second run appends a gzip member
+	s := &Scanner{}
+	s.activity, err = newActivityLog(path)
+	require.Nil(t, err)
+	s.probeActivity("10.0.0.2", ProbeICMPEcho)
+	require.Nil(t, s.CloseActivityLog())
+
+	records := readActivityLog(t, path)
+	require.Len(t, records, 3)
+	require.Equal(t, "10.0.0.5", records[0].Source)
+	require.Equal(t, "10.0.0.1", records[0].Target)
+	require.Equal(t, 22, records[0].Port)
+	require.Equal(t, ActivitySyn, records[0].Type)
+	require.Equal(t, ActivityUDP, records[1].Type)
+	require.Equal(t, "10.0.0.2", records[2].Target)
+	require.Equal(t, ProbeICMPEcho, records[2].Type)
+	require.Zero(t, records[2].Port)
+
+	var disabled *activityLog
+	disabled.probe(nil, "10.0.0.1", &port.Port{Port: 22, Protocol: protocol.TCP}, Syn)
+	require.Nil(t, disabled.close())
+}
+
+func TestConnectActivity(t *testing.T) {
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer listener.Close()
+
+	path := filepath.Join(t.TempDir(), "activity.jsonl.gz")
+	s := &Scanner{connectCriteria: CriteriaHandshake}
+	s.activity, err = newActivityLog(path)
+	require.Nil(t, err)
+
+	p := &port.Port{Port: listener.Addr().(*net.TCPAddr).Port, Protocol: protocol.TCP}
+	conn, err := s.DialPort("127.0.0.1", p, time.Second)
+	require.Nil(t, err)
+	conn.Close()
+	require.Nil(t, s.CloseActivityLog())
+
+	records := readActivityLog(t, path)
+	require.Len(t, records, 1)
+	require.Equal(t, "127.0.0.1", records[0].Source)
+	require.Equal(t, "127.0.0.1", records[0].Target)
+	require.Equal(t, p.Port, records[0].Port)
+	require.Equal(t, "tcp", records[0].Protocol)
+	require.Equal(t, ActivityConnect, records[0].Type)
+}
@@ -0,0 +1,159 @@
+package scan
+
+import (
+	"sync"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+)
+
+// resultKind is the operation applied by the aggregator to a result
+type resultKind int
+
+const (
+	hostFound resultKind = iota
+	portFound
+	portStored
+)
+
+// resultEvent is a host or port result sent to the aggregator
+type resultEvent struct {
+	kind resultKind
+	ip   string
+	port *port.Port
+	host *DiscoveredHost
+}
+
+// aggregator is the single goroutine writing the host discovery and scan results, so results
+// produced by concurrent workers are deduplicated and notified exactly once. The notifications run
+// on a separate worker through a bounded queue, so slow callbacks don't hold the recording of results
+type aggregator struct {
+	once   sync.Once
+	mu     sync.Mutex
+	cond   *sync.Cond
+	closed bool
+	// queued and completed count the events sent and fully handled, notification included
+	queued    uint64
+	completed uint64
+	events    chan *resultEvent
+	callbacks chan func()
+	done      chan struct{}
+}
+
+// startResults starts the aggregator and its notification worker on first use
+func (s *Scanner) startResults() {
+	s.results.once.Do(func() {
+		s.results.cond = sync.NewCond(&s.results.mu)
+		s.results.events = make(chan *resultEvent, chanSize)
+		s.results.callbacks = make(chan func(), chanSize)
+		s.results.done = make(chan struct{})
+		go s.aggregate()
+		go s.notify()
+	})
+}
+
+// queueResult queues the result event, starting the aggregator on first use
+func (s *Scanner) queueResult(event *resultEvent) {
+	s.startResults()
+	s.results.mu.Lock()
+	if s.results.closed {
+		s.results.mu.Unlock()
+		return
+	}
+	s.results.queued++
+	s.results.mu.Unlock()
+	s.results.events <- event
+}
+
+// aggregate applies the result events in order
+func (s *Scanner) aggregate() {
+	for {
+		select {
+		case event := <-s.results.events:
+			if callback := s.apply(event); callback != nil {
+				s.results.callbacks <- func() {
+					callback()
+					s.completeResult()
+				}
+			} else {
+				s.completeResult()
+			}
+		case <-s.results.done:
+			return
+		}
+	}
+}
+
+// notify runs the callbacks of the recorded results in order
+func (s *Scanner) notify() {
+	for {
+		select {
+		case callback := <-s.results.callbacks:
+			callback()
+		case <-s.results.done:
+			return
+		}
+	}
+}
+
+func (s *Scanner) completeResult() {
+	s.results.mu.Lock()
+	s.results.completed++
+	s.results.cond.Broadcast()
+	s.results.mu.Unlock()
+}
+
+// apply records the result and returns the callback to notify, if any
+func (s *Scanner) apply(event *resultEvent) func() {
+	switch event.kind {
+	case hostFound:
+		if s.HostDiscoveryResults.HasIP(event.ip) {
+			return nil
+		}
+		s.HostDiscoveryResults.AddIp(event.ip)
+		if event.host != nil {
+			s.discovery.add(event.host)
+		}
+		if onHostFound := s.OnHostFound; onHostFound != nil {
+			return func() { onHostFound(event.ip) }
+		}
+	case portFound:
+		if s.ScanResults.IPHasPort(event.ip, event.port) {
+			return nil
+		}
+		s.ScanResults.AddPort(event.ip, event.port)
+		if onPortFound := s.OnPortFound; onPortFound != nil {
+			return func() { onPortFound(event.ip, event.port) }
+		}
+	case portStored:
+		s.ScanResults.ReplacePort(event.ip, event.port)
+	}
+	return nil
+}
+
+// FlushResults waits until the results queued so far have been recorded and notified
+func (s *Scanner) FlushResults() {
+	s.results.mu.Lock()
+	defer s.results.mu.Unlock()
+	target := s.results.queued
+	for s.results.completed < target {
+		s.results.cond.Wait()
+	}
+}
+
+// CloseResults records the queued results and stops the aggregator, results sent afterwards are dropped
+func (s *Scanner) CloseResults() {
+	// an aggregator not started yet must not be started afterwards
+	s.results.once.Do(func() {})
+	s.results.mu.Lock()
+	if s.results.closed {
+		s.results.mu.Unlock()
+		return
+	}
+	s.results.closed = true
+	s.results.mu.Unlock()
+
+	s.FlushResults()
+	if s.results.done != nil {
+		close(s.results.done)
+	}
+}
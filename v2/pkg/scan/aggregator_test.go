@@ -0,0 +1,96 @@
+package scan
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultAggregator(t *testing.T) {
+	s := &Scanner{ScanResults: result.NewResult(), HostDiscoveryResults: result.NewResult()}
+	var portsFound, hostsFound atomic.Int32
+	s.OnPortFound = func(ip string, p *port.Port) { portsFound.Add(1) }
+	s.OnHostFound = func(ip string) { hostsFound.Add(1) }
+
+	// concurrent workers reporting the same results are notified once
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.AddPort("10.0.0.1", &port.Port{Port: 80, Protocol: protocol.TCP})
+			s.AddPort("10.0.0.1", &port.Port{Port: 443, Protocol: protocol.TCP})
+			s.AddHost("10.0.0.1")
+		}()
+	}
+	wg.Wait()
+	s.FlushResults()
+	require.Equal(t, int32(2), portsFound.Load())
+	require.Equal(t, int32(1), hostsFound.Load())
+	require.Equal(t, 2, s.ScanResults.GetPortCount("10.0.0.1"))
+
+	// stored ports replace the recorded port without notification
+	s.StorePort("10.0.0.1", &port.Port{Port: 80, Protocol: protocol.TCP, Banner: "nginx"})
+	s.FlushResults()
+	require.Equal(t, int32(2), portsFound.Load())
+	for hostResult := range s.ScanResults.GetIPsPorts() {
+		for _, p := range hostResult.Ports {
+			if p.Port == 80 {
+				require.Equal(t, "nginx", p.Banner)
+			}
+		}
+	}
+
+	// results sent after close are dropped
+	s.CloseResults()
+	s.AddPort("10.0.0.2", &port.Port{Port: 22, Protocol: protocol.TCP})
+	s.FlushResults()
+	require.Equal(t, 0, s.ScanResults.GetPortCount("10.0.0.2"))
+}
+
+func TestResultAggregatorSlowCallback(t *testing.T) {
+	s := &Scanner{ScanResults: result.NewResult(), HostDiscoveryResults: result.NewResult()}
+	release := make(chan struct{})
+	var notified atomic.Int32
+	s.OnPortFound = func(ip string, p *port.Port) {
+		<-release
+		notified.Add(1)
+	}
+
+	s.AddPort("10.0.0.1", &port.Port{Port: 80, Protocol: protocol.TCP})
+	s.AddPort("10.0.0.1", &port.Port{Port: 443, Protocol: protocol.TCP})
+	// the results are recorded while the callback is blocked
+	require.Eventually(t, func() bool { return s.ScanResults.GetPortCount("10.0.0.1") == 2 }, time.Second, time.Millisecond)
+
+	flushed := make(chan struct{})
+	go func() {
+		s.FlushResults()
+		close(flushed)
+	}()
+	// the results are queued concurrently with the flush
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s.AddPort("10.0.0.2", &port.Port{Port: 1000 + i, Protocol: protocol.TCP})
+		}(i)
+	}
+	select {
+	case <-flushed:
+		t.Fatal("flush returned before the callbacks completed")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-flushed
+	wg.Wait()
+	s.FlushResults()
+	require.Equal(t, int32(12), notified.Load())
+	s.CloseResults()
+}
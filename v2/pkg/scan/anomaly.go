@@ -0,0 +1,158 @@
+package scan
+
+import (
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+)
+
+// probeLogSize is the number of recent syn probes remembered to attribute the syn/acks
+// received from another address than the probed one
+const probeLogSize = 1 << 16
+
+// sentProbe is a syn probe identified by its sequence number
+type sentProbe struct {
+	seq  uint32
+	ip   string
+	port int
+	sent time.Time
+}
+
+// probeLog is a ring of the last syn probes indexed by sequence number, which is linear
+type probeLog struct {
+	sync.RWMutex
+	probes []sentProbe
+}
+
+func newProbeLog() *probeLog {
+	return &probeLog{probes: make([]sentProbe, probeLogSize)}
+}
+
+// add records the syn probe sent to the ip and port
+func (l *probeLog) add(seq uint32, ip string, portNumber int) {
+	if l == nil {
+		return
+	}
+	l.Lock()
+	defer l.Unlock()
+	l.probes[seq%probeLogSize] = sentProbe{seq: seq, ip: ip, port: portNumber, sent: time.Now()}
+}
+
+// rtt returns the time elapsed since the syn probe to the ip acknowledged by the ack number was sent,
+// zero if the probe is unknown
+func (l *probeLog) rtt(ip string, ack uint32) time.Duration {
+	probe, ok := l.lookup(ack)
+	if !ok || probe.ip != ip {
+		return 0
+	}
+	return time.Since(probe.sent)
+}
+
+// lookup returns the syn probe acknowledged by the ack number, false if it was overwritten
+func (l *probeLog) lookup(ack uint32) (sentProbe, bool) {
+	if l == nil {
+		return sentProbe{}, false
+	}
+	seq := ack - 1
+	l.RLock()
+	defer l.RUnlock()
+	probe := l.probes[seq%probeLogSize]
+	return probe, probe.ip != "" && probe.seq == seq
+}
+
+// Anomaly is a syn/ack answering a probe sent to another address, as seen behind nat hairpins,
+// anycast addresses or middleboxes
+type Anomaly struct {
+	IP            string // probed address
+	Port          int    // probed port
+	ResponderIP   string // address the syn/ack came from
+	ResponderPort int    // port the syn/ack came from
+}
+
+// Responder returns the host:port the syn/ack came from
+func (a *Anomaly) Responder() string {
+	return net.JoinHostPort(a.ResponderIP, strconv.Itoa(a.ResponderPort))
+}
+
+// anomalyLog keeps the anomalies of the scan, once per probed port and responder
+type anomalyLog struct {
+	sync.Mutex
+	anomalies map[Anomaly]struct{}
+}
+
+// isSynAck returns true if the segment is a syn/ack sent to the source port of the probes
+func (s *Scanner) isSynAck(tcp *layers.TCP) bool {
+	return tcp.DstPort == layers.TCPPort(s.SourcePort) && tcp.SYN && tcp.ACK
+}
+
+// decodedIPv6 returns true if the decoded packet is an ipv6 one
+func decodedIPv6(decoded []gopacket.LayerType) bool {
+	for _, layerType := range decoded {
+		if layerType == layers.LayerTypeIPv6 {
+			return true
+		}
+	}
+	return false
+}
+
+// recordMisdirected records a syn/ack from the responder acknowledging a probe sent to another
+// address, reporting the probed port as open with its responder. It returns false if the probe
+// is unknown or was sent to the responder, in which case the syn/ack is handled as usual
+func (s *Scanner) recordMisdirected(responder string, tcp *layers.TCP) bool {
+	if responder == "" || !s.Phase.Is(Scan) {
+		return false
+	}
+	probe, ok := s.probes.lookup(tcp.Ack)
+	if !ok || probe.ip == responder {
+		return false
+	}
+	anomaly := Anomaly{IP: probe.ip, Port: probe.port, ResponderIP: responder, ResponderPort: int(tcp.SrcPort)}
+
+	s.anomalies.Lock()
+	if s.anomalies.anomalies == nil {
+		s.anomalies.anomalies = make(map[Anomaly]struct{})
+	}
+	_, seen := s.anomalies.anomalies[anomaly]
+	s.anomalies.anomalies[anomaly] = struct{}{}
+	s.anomalies.Unlock()
+	if seen {
+		return true
+	}
+
+	gologger.Debug().Msgf("Syn/ack for %s:%d received from %s\n", anomaly.IP, anomaly.Port, anomaly.Responder())
+	s.tcpChan <- &PkgResult{ip: anomaly.IP, port: &port.Port{Port: anomaly.Port, Protocol: protocol.TCP, Responder: anomaly.Responder()}}
+	if s.OnAnomaly != nil {
+		s.OnAnomaly(&anomaly)
+	}
+	return true
+}
+
+// Anomalies returns the syn/acks received from another address than the probed one, sorted by probed address
+func (s *Scanner) Anomalies() []*Anomaly {
+	s.anomalies.Lock()
+	defer s.anomalies.Unlock()
+
+	anomalies := make([]*Anomaly, 0, len(s.anomalies.anomalies))
+	for anomaly := range s.anomalies.anomalies {
+		anomaly := anomaly
+		anomalies = append(anomalies, &anomaly)
+	}
+	sort.Slice(anomalies, func(i, j int) bool {
+		if anomalies[i].IP != anomalies[j].IP {
+			return anomalies[i].IP < anomalies[j].IP
+		}
+		if anomalies[i].Port != anomalies[j].Port {
+			return anomalies[i].Port < anomalies[j].Port
+		}
+		return anomalies[i].Responder() < anomalies[j].Responder()
+	})
+	return anomalies
+}
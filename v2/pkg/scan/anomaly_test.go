@@ -0,0 +1,62 @@
+package scan
+
+import (
+	"testing"
+
+	"github.com/google/gopacket/layers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbeLog(t *testing.T) {
+	probes := newProbeLog()
+	probes.add(41, "10.0.0.1", 443)
+
+	probe, ok := probes.lookup(42)
+	require.True(t, ok)
+	require.Equal(t, "10.0.0.1", probe.ip)
+	require.Equal(t, 443, probe.port)
+
+	_, ok = probes.lookup(43)
+	require.False(t, ok)
+
+	// overwritten by a later probe sharing the slot
+	probes.add(41+probeLogSize, "10.0.0.2", 80)
+	_, ok = probes.lookup(42)
+	require.False(t, ok)
+
+	var disabled *probeLog
+	disabled.add(1, "10.0.0.1", 80)
+	_, ok = disabled.lookup(2)
+	require.False(t, ok)
+}
+
+func TestRecordMisdirected(t *testing.T) {
+	s := &Scanner{SourcePort: 40000, probes: newProbeLog(), tcpChan: make(chan *PkgResult, 2)}
+	require.Nil(t, s.Phase.Transition(Scan))
+	s.probes.add(99, "203.0.113.10", 443)
+
+	var anomalies []*Anomaly
+	s.OnAnomaly = func(anomaly *Anomaly) {
+		anomalies = append(anomalies, anomaly)
+	}
+
+	synAck := &layers.TCP{SrcPort: 8443, DstPort: 40000, SYN: true, ACK: true, Ack: 100}
+	require.True(t, s.isSynAck(synAck))
+
+	// answered by the probed address
+	require.False(t, s.recordMisdirected("203.0.113.10", synAck))
+	// unknown probe
+	require.False(t, s.recordMisdirected("10.0.0.5", &layers.TCP{SrcPort: 443, DstPort: 40000, SYN: true, ACK: true, Ack: 7}))
+
+	require.True(t, s.recordMisdirected("10.0.0.5", synAck))
+	require.True(t, s.recordMisdirected("10.0.0.5", synAck))
+	require.Len(t, anomalies, 1)
+	require.Equal(t, &Anomaly{IP: "203.0.113.10", Port: 443, ResponderIP: "10.0.0.5", ResponderPort: 8443}, anomalies[0])
+	require.Equal(t, anomalies, s.Anomalies())
+
+	// the probed port is reported open with its responder
+	require.Len(t, s.tcpChan, 1)
+	result := <-s.tcpChan
+	require.Equal(t, "203.0.113.10", result.ip)
+	require.Equal(t, "10.0.0.5:8443", result.port.Responder)
+}
@@ -1,5 +1,3 @@
-//go:build linux || darwin
-
 package scan
 
 import (
@@ -11,10 +9,6 @@ import (
 	"github.com/projectdiscovery/gologger"
 )
 
-func init() {
-	arpRequestAsyncCallback = ArpRequestAsync
-}
-
 // ArpRequestAsync asynchronous to the target ip address
 func ArpRequestAsync(s *Scanner, ip string) {
 	networkInterface, _, sourceIP, err := s.Router.Route(net.ParseIP(ip))
@@ -56,12 +50,7 @@ func ArpRequestAsync(s *Scanner, ip string) {
 		return
 	}
 	// send the packet out on every interface
-	if handlers, ok := s.handlers.(Handlers); ok {
-		for _, handler := range handlers.EthernetActive {
-			err := handler.WritePacketData(buf.Bytes())
-			if err != nil {
-				gologger.Warning().Msgf("%s\n", err)
-			}
-		}
+	if err := s.raw.WriteFrame(buf.Bytes()); err != nil {
+		gologger.Warning().Msgf("%s\n", err)
 	}
 }
@@ -1,24 +1,69 @@
 package scan
 
 import (
-	"fmt"
+	"crypto/tls"
+	"errors"
 	"net"
+	"time"
 
 	"github.com/projectdiscovery/gologger"
 	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	iputil "github.com/projectdiscovery/utils/ip"
+)
+
+// Connect scan success criteria
+const (
+	CriteriaHandshake = "handshake"
+	CriteriaBanner    = "banner"
+	CriteriaTLS       = "tls"
 )
 
 // ConnectVerify is used to verify if ports are accurate using a connect request
 func (s *Scanner) ConnectVerify(host string, ports []*port.Port) []*port.Port {
 	var verifiedPorts []*port.Port
 	for _, p := range ports {
-		conn, err := net.DialTimeout(p.Protocol.String(), fmt.Sprintf("%s:%d", host, p.Port), s.timeout)
+		conn, err := s.dialDirect(p.Protocol.String(), s.zonedHost(host), p.Port, s.timeout)
+		s.connectActivity(host, p, conn)
 		if err != nil {
 			continue
 		}
+		if p.Protocol == protocol.TCP && !s.meetsCriteria(conn, host, s.timeout) {
+			gologger.Debug().Msgf("Port %d on %s doesn't meet the %s criteria\n", p.Port, host, s.connectCriteria)
+			s.closeConn(conn)
+			continue
+		}
 		gologger.Debug().Msgf("Validated active port %d on %s\n", p.Port, host)
-		conn.Close()
+		s.closeConn(conn)
 		verifiedPorts = append(verifiedPorts, p)
 	}
 	return verifiedPorts
 }
+
+// meetsCriteria returns true if the established tcp connection satisfies the success criteria, as
+// interceptors accepting every connection neither send a banner nor complete a tls hello
+func (s *Scanner) meetsCriteria(conn net.Conn, host string, timeout time.Duration) bool {
+	switch s.connectCriteria {
+	case CriteriaBanner:
+		if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+			return false
+		}
+		n, _ := conn.Read(make([]byte, 1))
+		return n > 0
+	case CriteriaTLS:
+		config := &tls.Config{InsecureSkipVerify: true} //nolint:gosec // any certificate proves a tls service
+		if !iputil.IsIP(host) {
+			config.ServerName = host
+		}
+		tlsConn := tls.Client(conn, config)
+		if err := tlsConn.SetDeadline(time.Now().Add(timeout)); err != nil {
+			return false
+		}
+		// an alert still comes from a tls stack refusing the hello parameters
+		err := tlsConn.Handshake()
+		var alert tls.AlertError
+		return err == nil || errors.As(err, &alert)
+	default:
+		return true
+	}
+}
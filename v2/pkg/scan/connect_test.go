@@ -1,8 +1,12 @@
 package scan
 
 import (
+	"io"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/projectdiscovery/naabu/v2/pkg/port"
 	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
@@ -39,3 +43,109 @@ func TestConnectVerify(t *testing.T) {
 	got := s.ConnectVerify("localhost", targetPorts)
 	assert.EqualValues(t, wanted, got)
 }
+
+func TestConnectVerifyZeroTimeout(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	// without timeout the dials don't expire before connecting
+	s, err := NewScanner(&Options{})
+	assert.Nil(t, err)
+	open := &port.Port{Port: l.Addr().(*net.TCPAddr).Port, Protocol: protocol.TCP}
+	got := s.ConnectVerify("127.0.0.1", []*port.Port{open})
+	assert.EqualValues(t, []*port.Port{open}, got)
+}
+
+func TestConnectPortResetClose(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer l.Close()
+
+	readErr := make(chan error, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			readErr <- err
+			return
+		}
+		defer conn.Close()
+		_, err = conn.Read(make([]byte, 1))
+		readErr <- err
+	}()
+
+	s := &Scanner{resetClose: true}
+	p := &port.Port{Port: l.Addr().(*net.TCPAddr).Port, Protocol: protocol.TCP}
+	open, err := s.ConnectPort("127.0.0.1", p, time.Second)
+	assert.Nil(t, err)
+	assert.True(t, open)
+
+	// the peer observes a reset instead of a graceful close
+	err = <-readErr
+	assert.NotNil(t, err)
+	assert.NotErrorIs(t, err, io.EOF)
+}
+
+func TestConnectCriteria(t *testing.T) {
+	// an interceptor accepting every connection without speaking
+	silent, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer silent.Close()
+	go func() {
+		for {
+			conn, err := silent.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	talking, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer talking.Close()
+	go func() {
+		for {
+			conn, err := talking.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = conn.Write([]byte("SSH-2.0-OpenSSH\r\n"))
+			defer conn.Close()
+		}
+	}()
+
+	tlsServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer tlsServer.Close()
+
+	portOf := func(addr net.Addr) *port.Port {
+		return &port.Port{Port: addr.(*net.TCPAddr).Port, Protocol: protocol.TCP}
+	}
+	tests := []struct {
+		criteria string
+		addr     net.Addr
+		open     bool
+	}{
+		{CriteriaHandshake, silent.Addr(), true},
+		{CriteriaBanner, silent.Addr(), false},
+		{CriteriaBanner, talking.Addr(), true},
+		{CriteriaTLS, silent.Addr(), false},
+		{CriteriaTLS, talking.Addr(), false},
+		{CriteriaTLS, tlsServer.Listener.Addr(), true},
+	}
+	for _, test := range tests {
+		s := &Scanner{connectCriteria: test.criteria}
+		open, err := s.ConnectPort("127.0.0.1", portOf(test.addr), 500*time.Millisecond)
+		assert.Nil(t, err)
+		assert.Equal(t, test.open, open, "%s %s", test.criteria, test.addr)
+	}
+}
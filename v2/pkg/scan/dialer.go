@@ -0,0 +1,154 @@
+package scan
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	iputil "github.com/projectdiscovery/utils/ip"
+)
+
+// hostDialers keeps the resolved address and dialer of the most recently contacted hosts, so that
+// verification and banner grabbing of hosts with many open ports resolve and set up only once
+type hostDialers struct {
+	sync.Mutex
+	size      int
+	newDialer func() *net.Dialer
+	resolve   func(ctx context.Context, host string) (net.IP, error)
+	entries   map[string]*hostDialer
+	// order is the insertion order used to evict the oldest entries
+	order []string
+}
+
+type hostDialer struct {
+	ip     net.IP
+	dialer *net.Dialer
+}
+
+func newHostDialers(size int, newDialer func() *net.Dialer, resolve func(ctx context.Context, host string) (net.IP, error)) *hostDialers {
+	return &hostDialers{size: size, newDialer: newDialer, resolve: resolve, entries: make(map[string]*hostDialer)}
+}
+
+// get returns the dialer of the host, resolving it on first use
+func (dialers *hostDialers) get(ctx context.Context, host string) (*hostDialer, error) {
+	dialers.Lock()
+	entry, ok := dialers.entries[host]
+	dialers.Unlock()
+	if ok {
+		return entry, nil
+	}
+
+	ip, err := dialers.resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	entry = &hostDialer{ip: ip, dialer: dialers.newDialer()}
+
+	dialers.Lock()
+	defer dialers.Unlock()
+	if _, ok := dialers.entries[host]; !ok {
+		if len(dialers.order) >= dialers.size {
+			delete(dialers.entries, dialers.order[0])
+			dialers.order = dialers.order[1:]
+		}
+		dialers.order = append(dialers.order, host)
+	}
+	dialers.entries[host] = entry
+	return entry, nil
+}
+
+// resolve returns the address of the host through the configured resolver, falling back to the system one
+func (s *Scanner) resolve(ctx context.Context, host string) (net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip, nil
+	}
+	var ips []net.IP
+	if s.lookupHost != nil {
+		addresses, err := s.lookupHost(host)
+		if err != nil {
+			return nil, err
+		}
+		for _, address := range addresses {
+			if ip := net.ParseIP(address); ip != nil {
+				ips = append(ips, ip)
+			}
+		}
+	} else {
+		var err error
+		if ips, err = net.DefaultResolver.LookupIP(ctx, "ip", host); err != nil {
+			return nil, err
+		}
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no address found for %s", host)
+	}
+	return ips[0], nil
+}
+
+// dialDirect connects to the host port without proxy, reusing the cached host dialer if enabled
+func (s *Scanner) dialDirect(network, host string, port int, timeout time.Duration) (net.Conn, error) {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	// the cached dialers resolve the address of the host, which drops the zone of link-local ipv6 addresses
+	if strings.Contains(host, "%") {
+		return s.newDialer(timeout).DialContext(ctx, network, net.JoinHostPort(host, fmt.Sprint(port)))
+	}
+	if s.dialers == nil {
+		ip, err := s.resolve(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		return s.newDialer(timeout).DialContext(ctx, network, net.JoinHostPort(ip.String(), fmt.Sprint(port)))
+	}
+	entry, err := s.dialers.get(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	return entry.dialer.DialContext(ctx, network, net.JoinHostPort(entry.ip.String(), fmt.Sprint(port)))
+}
+
+// newDialer returns a dialer applying the configured socket options
+func (s *Scanner) newDialer(timeout time.Duration) *net.Dialer {
+	dialer := &net.Dialer{Timeout: timeout}
+	if s.mark > 0 || s.tos > 0 {
+		dialer.Control = func(network, address string, conn syscall.RawConn) error {
+			host, _, _ := net.SplitHostPort(address)
+			host, _, _ = strings.Cut(host, "%")
+			return s.setSocketOptions(conn, iputil.IsIPv6(host))
+		}
+	}
+	return dialer
+}
+
+// setConnOptions applies the configured socket options to a listener used to send probes
+func (s *Scanner) setConnOptions(conn syscall.Conn, ipv6 bool) error {
+	if s.mark <= 0 && s.tos <= 0 {
+		return nil
+	}
+	rawConn, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	return s.setSocketOptions(rawConn, ipv6)
+}
+
+// setSocketOptions sets the mark and type of service of a probe socket
+func (s *Scanner) setSocketOptions(conn syscall.RawConn, ipv6 bool) error {
+	if s.mark > 0 {
+		if err := setSocketMark(conn, s.mark); err != nil {
+			return err
+		}
+	}
+	if s.tos > 0 {
+		return setSocketTOS(conn, s.tos, ipv6)
+	}
+	return nil
+}
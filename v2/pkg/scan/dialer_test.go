@@ -0,0 +1,71 @@
+package scan
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostDialers(t *testing.T) {
+	dialers := newHostDialers(2, func() *net.Dialer { return &net.Dialer{} }, (&Scanner{}).resolve)
+	first, err := dialers.get(context.Background(), "127.0.0.1")
+	require.Nil(t, err)
+	again, err := dialers.get(context.Background(), "127.0.0.1")
+	require.Nil(t, err)
+	require.Same(t, first, again)
+
+	_, err = dialers.get(context.Background(), "127.0.0.2")
+	require.Nil(t, err)
+	_, err = dialers.get(context.Background(), "127.0.0.3")
+	require.Nil(t, err)
+	require.Len(t, dialers.entries, 2)
+	require.NotContains(t, dialers.entries, "127.0.0.1", "oldest entry must be evicted")
+}
+
+func TestDialDirect(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer l.Close()
+	port := l.Addr().(*net.TCPAddr).Port
+
+	s := &Scanner{}
+	for _, dialers := range []*hostDialers{nil, newHostDialers(1, func() *net.Dialer { return s.newDialer(0) }, s.resolve)} {
+		s.dialers = dialers
+		conn, err := s.dialDirect("tcp", "127.0.0.1", port, time.Second)
+		require.Nil(t, err)
+		conn.Close()
+		// a zero timeout doesn't expire the dial
+		conn, err = s.dialDirect("tcp", "127.0.0.1", port, 0)
+		require.Nil(t, err)
+		conn.Close()
+	}
+}
+
+func TestDialDirectLookupHost(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer l.Close()
+	port := l.Addr().(*net.TCPAddr).Port
+
+	var lookups []string
+	s := &Scanner{lookupHost: func(host string) ([]string, error) {
+		lookups = append(lookups, host)
+		return []string{"127.0.0.1"}, nil
+	}}
+	for _, dialers := range []*hostDialers{nil, newHostDialers(1, func() *net.Dialer { return s.newDialer(0) }, s.resolve)} {
+		s.dialers = dialers
+		conn, err := s.dialDirect("tcp", "scanme.invalid", port, time.Second)
+		require.Nil(t, err)
+		conn.Close()
+	}
+	require.Equal(t, []string{"scanme.invalid", "scanme.invalid"}, lookups)
+}
+
+func TestNewDialerMark(t *testing.T) {
+	require.Nil(t, (&Scanner{}).newDialer(time.Second).Control)
+	require.NotNil(t, (&Scanner{mark: 100}).newDialer(time.Second).Control)
+	require.NotNil(t, (&Scanner{tos: 0x10}).newDialer(time.Second).Control)
+}
@@ -0,0 +1,110 @@
+package scan
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Host discovery probes a host can answer to
+const (
+	ProbeICMPEcho      = "icmp-echo"
+	ProbeICMPTimestamp = "icmp-timestamp"
+	ProbeICMPMask      = "icmp-address-mask"
+	ProbeTCP           = "tcp"
+	ProbeUDP           = "udp"
+	ProbeARP           = "arp"
+)
+
+// DiscoveredHost is an alive host with the probe it first answered
+type DiscoveredHost struct {
+	IP    string
+	Probe string
+	Port  int // answering port of tcp and udp probes
+	RTT   time.Duration
+}
+
+// discoveryTracker times the host discovery probes and records the alive hosts
+type discoveryTracker struct {
+	sync.Mutex
+	sent  map[string]time.Time
+	hosts map[string]*DiscoveredHost
+}
+
+// probeKey identifies the probes of a kind sent to an ip
+func probeKey(ip, probe string) string {
+	return probe + "/" + ip
+}
+
+// markSent records the time the probe was sent to the ip, keeping the latest
+func (t *discoveryTracker) markSent(ip, probe string) {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.sent == nil {
+		t.sent = make(map[string]time.Time)
+	}
+	t.sent[probeKey(ip, probe)] = time.Now()
+}
+
+// rtt returns the time elapsed since the probe was sent to the ip, zero if unknown
+func (t *discoveryTracker) rtt(ip, probe string) time.Duration {
+	t.Lock()
+	defer t.Unlock()
+
+	sent, ok := t.sent[probeKey(ip, probe)]
+	if !ok {
+		return 0
+	}
+	return time.Since(sent)
+}
+
+// add records the host unless already discovered
+func (t *discoveryTracker) add(host *DiscoveredHost) {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.hosts == nil {
+		t.hosts = make(map[string]*DiscoveredHost)
+	}
+	if _, ok := t.hosts[host.IP]; !ok {
+		t.hosts[host.IP] = host
+	}
+}
+
+// list returns the discovered hosts sorted by ip
+func (t *discoveryTracker) list() []*DiscoveredHost {
+	t.Lock()
+	defer t.Unlock()
+
+	hosts := make([]*DiscoveredHost, 0, len(t.hosts))
+	for _, host := range t.hosts {
+		hosts = append(hosts, host)
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		return hosts[i].IP < hosts[j].IP
+	})
+	return hosts
+}
+
+// probeOf returns the discovery probe sent by the packet flag
+func probeOf(flag PkgFlag) string {
+	switch flag {
+	case IcmpEchoRequest, Ndp:
+		return ProbeICMPEcho
+	case IcmpTimestampRequest:
+		return ProbeICMPTimestamp
+	case IcmpAddressMaskRequest:
+		return ProbeICMPMask
+	case Arp:
+		return ProbeARP
+	default:
+		return ProbeTCP
+	}
+}
+
+// DiscoveredHosts returns the alive hosts found during host discovery with the probe they answered
+func (s *Scanner) DiscoveredHosts() []*DiscoveredHost {
+	s.FlushResults()
+	return s.discovery.list()
+}
@@ -0,0 +1,30 @@
+package scan
+
+import (
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/projectdiscovery/naabu/v2/pkg/result"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscoveredHosts(t *testing.T) {
+	s := &Scanner{ScanResults: result.NewResult(), HostDiscoveryResults: result.NewResult()}
+
+	s.discovery.markSent("10.0.0.2", ProbeTCP)
+	s.addDiscoveredHost(&PkgResult{ip: "10.0.0.2", port: &port.Port{Port: 443, Protocol: protocol.TCP}, probe: ProbeTCP})
+	// only the first answered probe is kept
+	s.addDiscoveredHost(&PkgResult{ip: "10.0.0.2", probe: ProbeICMPEcho})
+	s.addDiscoveredHost(&PkgResult{ip: "10.0.0.1", probe: ProbeICMPEcho})
+
+	hosts := s.DiscoveredHosts()
+	require.Len(t, hosts, 2)
+	require.Equal(t, &DiscoveredHost{IP: "10.0.0.1", Probe: ProbeICMPEcho}, hosts[0])
+	require.Equal(t, "10.0.0.2", hosts[1].IP)
+	require.Equal(t, ProbeTCP, hosts[1].Probe)
+	require.Equal(t, 443, hosts[1].Port)
+	require.Greater(t, hosts[1].RTT, time.Duration(0))
+	require.True(t, s.HostDiscoveryResults.HasIP("10.0.0.1"))
+}
@@ -0,0 +1,132 @@
+package scan
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket/layers"
+)
+
+// dropSampleWindow is the period the sampling budget of each reason applies to
+const dropSampleWindow = time.Minute
+
+// Reasons of the packets ignored by the receive path
+const (
+	DropBadCookie       = "bad cookie"
+	DropUnmatchedPort   = "unmatched port"
+	DropNonTarget       = "non target ip"
+	DropUnexpectedFlags = "unexpected flags"
+	DropLate            = "late"
+)
+
+// dropSampler logs a few examples per minute of each reason the receive path ignored packets for,
+// the other ones being counted and reported once the next minute starts or the scanner is closed
+type dropSampler struct {
+	sync.Mutex
+	writer    io.Writer
+	perMinute int
+	window    time.Time
+	sampled   map[string]int
+	skipped   map[string]int
+}
+
+func newDropSampler(writer io.Writer, perMinute int) *dropSampler {
+	return &dropSampler{writer: writer, perMinute: perMinute, window: time.Now(), sampled: make(map[string]int), skipped: make(map[string]int)}
+}
+
+// sample logs the ignored packet unless the budget of the reason is exhausted
+func (d *dropSampler) sample(reason, format string, args ...interface{}) {
+	if d == nil {
+		return
+	}
+	d.Lock()
+	defer d.Unlock()
+
+	now := time.Now()
+	if now.Sub(d.window) >= dropSampleWindow {
+		d.flush()
+		d.window = now
+	}
+	if d.sampled[reason] >= d.perMinute {
+		d.skipped[reason]++
+		return
+	}
+	d.sampled[reason]++
+	fmt.Fprintf(d.writer, "IGNORED (%s) %s\n", reason, fmt.Sprintf(format, args...))
+}
+
+// flush reports the packets ignored beyond the budget of the window and resets it
+func (d *dropSampler) flush() {
+	if len(d.skipped) > 0 {
+		reasons := make([]string, 0, len(d.skipped))
+		var total int
+		for reason, count := range d.skipped {
+			reasons = append(reasons, fmt.Sprintf("%s: %d", reason, count))
+			total += count
+		}
+		sort.Strings(reasons)
+		fmt.Fprintf(d.writer, "IGNORED %d more packets not sampled (%s)\n", total, strings.Join(reasons, ", "))
+	}
+	d.sampled = make(map[string]int)
+	d.skipped = make(map[string]int)
+}
+
+// close reports the packets ignored beyond the budget of the last window
+func (d *dropSampler) close() {
+	if d == nil {
+		return
+	}
+	d.Lock()
+	defer d.Unlock()
+	d.flush()
+}
+
+// ignoredReason returns why the receive path ignores a packet from a target answering on the probe
+// port, empty if it is handled
+func (s *Scanner) ignoredReason(tcp *layers.TCP, tcpPortMatches bool) string {
+	switch {
+	case s.Phase.Is(HostDiscovery):
+		return ""
+	case !s.Phase.Is(Scan) && !s.stream:
+		return DropLate
+	case s.isBadCookie(tcp, tcpPortMatches):
+		return DropBadCookie
+	case tcpPortMatches && !(tcp.SYN && tcp.ACK) && !tcp.RST:
+		return DropUnexpectedFlags
+	}
+	return ""
+}
+
+// isBadCookie returns true if the syn/ack acknowledges a sequence number no probe was sent with, as
+// the answers to another process using the probe source port or forged by middleboxes
+func (s *Scanner) isBadCookie(tcp *layers.TCP, tcpPortMatches bool) bool {
+	return tcpPortMatches && tcp.SYN && tcp.ACK && !s.tcpsequencer.Issued(tcp.Ack-1)
+}
+
+// sampleTransport samples the transport packet from the source if the receive path ignores it
+func (s *Scanner) sampleTransport(tcp *layers.TCP, udp *layers.UDP, source string, isTarget bool, ttl uint8, tcpPortMatches, udpPortMatches bool) {
+	if s.drops == nil {
+		return
+	}
+	var reason string
+	switch {
+	case !tcpPortMatches && !udpPortMatches:
+		reason = DropUnmatchedPort
+	case !isTarget:
+		reason = DropNonTarget
+	default:
+		reason = s.ignoredReason(tcp, tcpPortMatches)
+	}
+	if reason == "" {
+		return
+	}
+	if tcpPortMatches || udp.DstPort == 0 {
+		s.drops.sample(reason, "TCP %s > :%d %s ttl=%d win=%d", hostPort(source, int(tcp.SrcPort)), tcp.DstPort, tcpFlags(tcp), ttl, tcp.Window)
+		return
+	}
+	s.drops.sample(reason, "UDP %s > :%d len=%d ttl=%d", hostPort(source, int(udp.SrcPort)), udp.DstPort, udp.Length, ttl)
+}
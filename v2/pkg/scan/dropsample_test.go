@@ -0,0 +1,62 @@
+package scan
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket/layers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampleTransport(t *testing.T) {
+	var buffer bytes.Buffer
+	s := &Scanner{drops: newDropSampler(&buffer, 1)}
+	require.Nil(t, s.Phase.Transition(Scan))
+
+	synAck := &layers.TCP{SrcPort: 443, DstPort: 40000, SYN: true, ACK: true}
+	s.sampleTransport(synAck, &layers.UDP{}, "10.0.0.1", true, 64, true, false)
+	s.sampleTransport(&layers.TCP{SrcPort: 443, DstPort: 40000, FIN: true}, &layers.UDP{}, "10.0.0.1", true, 64, true, false)
+	s.sampleTransport(synAck, &layers.UDP{}, "10.0.0.9", false, 64, true, false)
+	s.sampleTransport(&layers.TCP{SrcPort: 443, DstPort: 40001, SYN: true, ACK: true}, &layers.UDP{}, "10.0.0.1", true, 64, false, false)
+	s.sampleTransport(&layers.TCP{}, &layers.UDP{SrcPort: 53, DstPort: 40001, Length: 20}, "10.0.0.1", true, 64, false, false)
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	require.Len(t, lines, 3)
+	require.Equal(t, "IGNORED (unexpected flags) TCP 10.0.0.1:443 > :40000 F ttl=64 win=0", lines[0])
+	require.True(t, strings.HasPrefix(lines[1], "IGNORED (non target ip) TCP 10.0.0.9:443"))
+	require.True(t, strings.HasPrefix(lines[2], "IGNORED (unmatched port) TCP"))
+
+	// the packets beyond the budget are reported once the next minute starts
+	buffer.Reset()
+	require.Nil(t, s.Phase.Transition(Done))
+	s.drops.window = time.Now().Add(-dropSampleWindow)
+	s.sampleTransport(synAck, &layers.UDP{}, "10.0.0.1", true, 64, true, false)
+	lines = strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Equal(t, "IGNORED 1 more packets not sampled (unmatched port: 1)", lines[0])
+	require.True(t, strings.HasPrefix(lines[1], "IGNORED (late) TCP"))
+
+	// a disabled sampler is a no-op
+	(&Scanner{}).sampleTransport(synAck, &layers.UDP{}, "10.0.0.1", true, 64, true, false)
+}
+
+func TestSampleBadCookie(t *testing.T) {
+	var buffer bytes.Buffer
+	s := &Scanner{drops: newDropSampler(&buffer, 1), tcpsequencer: NewTCPSequencer()}
+	require.Nil(t, s.Phase.Transition(Scan))
+	seq := s.tcpsequencer.Next()
+
+	s.sampleTransport(&layers.TCP{SrcPort: 443, DstPort: 40000, SYN: true, ACK: true, Ack: seq + 1}, &layers.UDP{}, "10.0.0.1", true, 64, true, false)
+	require.Empty(t, buffer.String())
+	forged := &layers.TCP{SrcPort: 443, DstPort: 40000, SYN: true, ACK: true, Ack: seq + 100}
+	s.sampleTransport(forged, &layers.UDP{}, "10.0.0.1", true, 64, true, false)
+	s.sampleTransport(forged, &layers.UDP{}, "10.0.0.1", true, 64, true, false)
+	require.True(t, strings.HasPrefix(buffer.String(), "IGNORED (bad cookie) TCP 10.0.0.1:443"))
+
+	// the packets beyond the budget of the last window are reported on close
+	buffer.Reset()
+	s.drops.close()
+	require.Equal(t, "IGNORED 1 more packets not sampled (bad cookie: 1)\n", buffer.String())
+}
@@ -0,0 +1,119 @@
+package scan
+
+import (
+	"net"
+	"runtime"
+
+	"github.com/google/gopacket/layers"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+)
+
+// rawEngine is the platform layer sending the raw probes and capturing their answers. Each
+// platform provides its implementation in a build tagged file, so that platform specific fast
+// paths (AF_PACKET, Npcap, BPF devices) live behind the same api
+type rawEngine interface {
+	// Name identifies the implementation
+	Name() string
+	// Supported returns true if raw packets can be sent and captured on the platform
+	Supported() bool
+	// Open opens the raw sockets of a scan, nil if the platform has none
+	Open(config rawConfig) (rawHandle, error)
+}
+
+// rawSocket identifies a raw socket of a handle
+type rawSocket int
+
+// Raw sockets of a handle
+const (
+	socketTCP4 rawSocket = iota
+	socketUDP4
+	socketTCP6
+	socketUDP6
+	socketICMP4
+	socketICMP6
+	// socketTunnel sends the encapsulated ipv4 probes, opened if a tunnel is configured
+	socketTunnel
+	rawSockets
+)
+
+// rawConfig configures the raw sockets and the capture of a handle
+type rawConfig struct {
+	// tunnel is the ip protocol of the tunnel encapsulation (47 for gre, 4 for ipip), zero for none
+	tunnel int
+	// bufferSize is the capture buffer size in bytes, zero for the platform default
+	bufferSize int
+	// socketOptions applies the mark and type of service of the scan to a probe socket
+	socketOptions func(conn *net.IPConn, ipv6 bool) error
+}
+
+// rawHandle is the raw sockets and the capture handlers of a scan, owned by the engine opening
+// them, which the scanner sends and receives packets through
+type rawHandle interface {
+	// WriteTo sends the packet to the address through the raw socket
+	WriteTo(socket rawSocket, data []byte, addr net.Addr) (int, error)
+	// ReadFrom reads a packet from the raw socket
+	ReadFrom(socket rawSocket, data []byte) (int, net.Addr, error)
+	// WriteFrame writes the link layer frame on every interface capturing arp packets
+	WriteFrame(frame []byte) error
+	// Capture starts capturing the packets of the protocols matching the bpf filter on the interface
+	Capture(interfaceName, bpfFilter string, protocols ...protocol.Protocol) error
+	// ReadCapture decodes the captured packets for the receiver until the capture is closed
+	ReadCapture(receiver packetReceiver)
+	// Readers returns the number of running capture read loops
+	Readers() int
+	// Drops returns the packets dropped before being read, the count of the last statistics once
+	// the capture is closed
+	Drops() uint64
+	// CloseCapture closes the capture handlers
+	CloseCapture()
+	// CloseSocket closes the raw socket
+	CloseSocket(socket rawSocket)
+}
+
+// capturedPacket is a tcp or udp packet captured by an engine, reused by the read loops so that
+// the receivers must copy what they keep
+type capturedPacket struct {
+	tcp    layers.TCP
+	udp    layers.UDP
+	isUDP  bool
+	srcIP4 string
+	srcIP6 string
+	isIPv6 bool
+	ttl    uint8
+	ipid   uint16
+	// data is the captured frame of link type linkType
+	data     []byte
+	linkType layers.LinkType
+}
+
+// packetReceiver handles the packets captured by an engine
+type packetReceiver interface {
+	// receiveTransport handles a captured tcp or udp packet
+	receiveTransport(packet *capturedPacket)
+	// receiveARP handles a captured arp packet
+	receiveARP(arp *layers.ARP)
+	// countReceived records the bytes of a captured packet
+	countReceived(n int)
+}
+
+// platformEngine is the raw packet engine of the platform naabu was built for
+var platformEngine rawEngine = unsupportedEngine{}
+
+// RawEngine returns the name of the raw packet engine with the platform it was built for (pcap linux/amd64)
+func RawEngine() string {
+	return platformEngine.Name() + " " + runtime.GOOS + "/" + runtime.GOARCH
+}
+
+// RawPacketsSupported returns true if the platform has a raw packet engine (syn, udp and arp scans)
+func RawPacketsSupported() bool {
+	return platformEngine.Supported()
+}
+
+// unsupportedEngine is the engine of the platforms without raw packet support, limited to connect scans
+type unsupportedEngine struct{}
+
+func (unsupportedEngine) Name() string    { return "none" }
+func (unsupportedEngine) Supported() bool { return false }
+func (unsupportedEngine) Open(rawConfig) (rawHandle, error) {
+	return nil, nil
+}
@@ -0,0 +1,109 @@
+package scan
+
+import (
+	"errors"
+	"net"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/gopacket/layers"
+	"github.com/projectdiscovery/ipranger"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRawEngine(t *testing.T) {
+	require.Equal(t, runtime.GOOS == "linux" || runtime.GOOS == "darwin", RawPacketsSupported())
+	require.True(t, strings.HasSuffix(RawEngine(), " "+runtime.GOOS+"/"+runtime.GOARCH))
+
+	// the platforms without engine are limited to connect scans
+	var engine rawEngine = unsupportedEngine{}
+	require.False(t, engine.Supported())
+	handle, err := engine.Open(rawConfig{})
+	require.Nil(t, err)
+	require.Nil(t, handle)
+	require.Zero(t, (&Scanner{}).PcapDrops())
+}
+
+// sentPacket is a packet written to a socket of a fake handle
+type sentPacket struct {
+	socket rawSocket
+	addr   string
+}
+
+// fakeHandle records the packets sent through it and delivers the queued captured packets
+type fakeHandle struct {
+	sent     []sentPacket
+	captured []*capturedPacket
+	writeErr error
+}
+
+func (h *fakeHandle) WriteTo(socket rawSocket, data []byte, addr net.Addr) (int, error) {
+	if h.writeErr != nil {
+		return 0, h.writeErr
+	}
+	h.sent = append(h.sent, sentPacket{socket: socket, addr: addr.String()})
+	return len(data), nil
+}
+func (h *fakeHandle) ReadFrom(rawSocket, []byte) (int, net.Addr, error)  { return 0, nil, net.ErrClosed }
+func (h *fakeHandle) WriteFrame([]byte) error                            { return nil }
+func (h *fakeHandle) Capture(string, string, ...protocol.Protocol) error { return nil }
+func (h *fakeHandle) ReadCapture(receiver packetReceiver) {
+	for _, packet := range h.captured {
+		receiver.receiveTransport(packet)
+	}
+}
+func (h *fakeHandle) Readers() int          { return 0 }
+func (h *fakeHandle) Drops() uint64         { return 7 }
+func (h *fakeHandle) CloseCapture()         {}
+func (h *fakeHandle) CloseSocket(rawSocket) {}
+
+func TestRawHandle(t *testing.T) {
+	handle := &fakeHandle{}
+	s := newProbeScanner()
+	s.raw = handle
+	s.SourceIP4 = net.ParseIP("192.0.2.1")
+
+	// the probes and the tunneled probes go through the sockets of the handle
+	s.sendAsyncTCP4("203.0.113.10", &port.Port{Port: 443, Protocol: protocol.TCP}, Syn)
+	s.tunnel = &tunnel{kind: TunnelGRE, endpoint: &net.IPAddr{IP: net.ParseIP("198.51.100.1")}}
+	s.sendAsyncTCP4("203.0.113.10", &port.Port{Port: 443, Protocol: protocol.TCP}, Syn)
+	require.Equal(t, []sentPacket{{socketTCP4, "203.0.113.10"}, {socketTunnel, "198.51.100.1"}}, handle.sent)
+	require.Equal(t, uint64(7), s.PcapDrops())
+
+	// the captured answers of the targets are handled by the scanner
+	var err error
+	s.IPRanger, err = ipranger.New()
+	require.Nil(t, err)
+	require.Nil(t, s.IPRanger.Add("203.0.113.10"))
+	s.tcpChan = make(chan *PkgResult, 2)
+	require.Nil(t, s.Phase.Transition(Scan))
+	seq := s.tcpsequencer.Next()
+	handle.captured = []*capturedPacket{
+		{tcp: layers.TCP{SrcPort: 443, DstPort: 40000, SYN: true, ACK: true, Ack: seq + 1}, srcIP4: "203.0.113.10"},
+		{tcp: layers.TCP{SrcPort: 443, DstPort: 40000, SYN: true, ACK: true, Ack: seq + 1}, srcIP4: "203.0.113.99"},
+	}
+	s.TCPReadWorkerPCAP()
+	require.Len(t, s.tcpChan, 1)
+	result := <-s.tcpChan
+	require.Equal(t, "203.0.113.10", result.ip)
+	require.Equal(t, 443, result.port.Port)
+}
+
+func TestWriteToCountsSentBytes(t *testing.T) {
+	handle := &fakeHandle{}
+	s := &Scanner{raw: handle}
+	addr := &net.IPAddr{IP: net.ParseIP("203.0.113.10")}
+
+	require.Nil(t, s.writeTo(socketTCP4, make([]byte, 40), addr, 0))
+	sent, _ := s.Traffic()
+	require.Equal(t, uint64(40), sent)
+
+	// the failed writes are retried, none being counted
+	handle.writeErr = errors.New("no buffer space available")
+	require.ErrorIs(t, s.writeTo(socketTCP4, make([]byte, 40), addr, 0), handle.writeErr)
+	sent, _ = s.Traffic()
+	require.Equal(t, uint64(40), sent)
+}
@@ -0,0 +1,17 @@
+//go:build linux || darwin
+
+package scan
+
+func init() {
+	platformEngine = pcapEngine{}
+}
+
+// pcapEngine sends the probes through raw ip sockets and captures the answers with libpcap
+type pcapEngine struct{}
+
+func (pcapEngine) Name() string    { return "pcap" }
+func (pcapEngine) Supported() bool { return true }
+
+func (pcapEngine) Open(config rawConfig) (rawHandle, error) {
+	return newPcapHandle(config)
+}
@@ -0,0 +1,129 @@
+package scan
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// Proxy schemes
+const (
+	ProxySOCKS5 = "socks5"
+	ProxyHTTP   = "http"
+)
+
+// ParseProxy returns the scheme and address of a proxy given as host:port (socks5) or as an url
+// (socks5://host:port, http://host:port), with the credentials of the url if any
+func ParseProxy(value string) (scheme, address string, auth *proxy.Auth, err error) {
+	if !strings.Contains(value, "://") {
+		return ProxySOCKS5, value, nil, nil
+	}
+	proxyURL, err := url.Parse(value)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("invalid proxy %s: %w", value, err)
+	}
+	scheme = strings.ToLower(proxyURL.Scheme)
+	if scheme == "socks5h" {
+		scheme = ProxySOCKS5
+	}
+	if scheme != ProxySOCKS5 && scheme != ProxyHTTP {
+		return "", "", nil, fmt.Errorf("invalid proxy scheme %s (allowed: %s, %s)", proxyURL.Scheme, ProxySOCKS5, ProxyHTTP)
+	}
+	if proxyURL.Host == "" {
+		return "", "", nil, fmt.Errorf("invalid proxy %s: missing host", value)
+	}
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		auth = &proxy.Auth{User: proxyURL.User.Username(), Password: password}
+	}
+	return scheme, proxyURL.Host, auth, nil
+}
+
+// httpProxyDialer tunnels tcp connections through an http proxy with the CONNECT method
+type httpProxyDialer struct {
+	address string
+	auth    *proxy.Auth
+	forward *net.Dialer
+}
+
+// Dial connects to the address through the proxy
+func (d *httpProxyDialer) Dial(network, address string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address)
+}
+
+// DialContext connects to the address through the proxy, only tcp can be tunneled
+func (d *httpProxyDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if network != "tcp" && network != "tcp4" && network != "tcp6" {
+		return nil, fmt.Errorf("network %s can't be tunneled through an http proxy", network)
+	}
+	conn, err := d.forward.DialContext(ctx, "tcp", d.address)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	request := fmt.Sprintf("CONNECT %s HTTP/1.1\r\nHost: %s\r\n", address, address)
+	if d.auth != nil {
+		credentials := base64.StdEncoding.EncodeToString([]byte(d.auth.User + ":" + d.auth.Password))
+		request += "Proxy-Authorization: Basic " + credentials + "\r\n"
+	}
+	if _, err := conn.Write([]byte(request + "\r\n")); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, &http.Request{Method: http.MethodConnect})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy refused connection to %s: %s", address, response.Status)
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	// the service may have spoken first, the data read ahead stays available
+	if reader.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, reader: reader}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn is a connection whose first bytes were read ahead
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(data []byte) (int, error) {
+	return c.reader.Read(data)
+}
+
+// newProxyDialer returns the dialer of the socks5 or http proxy, the credentials of the proxy url
+// take precedence over the given ones
+func newProxyDialer(value string, auth *proxy.Auth, forward *net.Dialer) (proxy.Dialer, error) {
+	scheme, address, urlAuth, err := ParseProxy(value)
+	if err != nil {
+		return nil, err
+	}
+	if urlAuth != nil {
+		auth = urlAuth
+	}
+	if scheme == ProxyHTTP {
+		return &httpProxyDialer{address: address, auth: auth, forward: forward}, nil
+	}
+	return proxy.SOCKS5("tcp", address, auth, forward)
+}
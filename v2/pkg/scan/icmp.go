@@ -1,5 +1,3 @@
-//go:build linux || darwin
-
 package scan
 
 import (
@@ -22,15 +20,6 @@ const (
 	transmitTimestamp = 12
 )
 
-func init() {
-	pingIcmpEchoRequestCallback = PingIcmpEchoRequest
-	pingIcmpEchoRequestAsyncCallback = PingIcmpEchoRequestAsync
-	pingIcmpTimestampRequestCallback = PingIcmpTimestampRequest
-	pingIcmpTimestampRequestAsyncCallback = PingIcmpTimestampRequestAsync
-	pingIcmpAddressMaskRequestAsyncCallback = PingIcmpAddressMaskRequestAsync
-	pingNdpRequestAsyncCallback = PingNdpRequestAsync
-}
-
 // PingIcmpEchoRequest synchronous to the target ip address
 func PingIcmpEchoRequest(ip string, timeout time.Duration) bool {
 	destAddr := &net.IPAddr{IP: net.ParseIP(ip)}
@@ -86,19 +75,19 @@ func PingIcmpEchoRequestAsync(s *Scanner, ip string) {
 		Body: &icmp.Echo{
 			ID:   os.Getpid() & 0xffff,
 			Seq:  1,
-			Data: []byte(""),
+			Data: s.probeTag,
 		},
 	}
 
-	var packetListener net.PacketConn
+	var socket rawSocket
 	switch {
 	case iputil.IsIPv4(ip):
 		m.Type = ipv4.ICMPTypeEcho
-		packetListener = s.icmpPacketListener4
+		socket = socketICMP4
 		destAddr = &net.IPAddr{IP: destinationIP}
 	case iputil.IsIPv6(ip):
 		m.Type = ipv6.ICMPTypeEchoRequest
-		packetListener = s.icmpPacketListener6
+		socket = socketICMP6
 		networkInterface, _, _, err := s.Router.Route(destinationIP)
 		if networkInterface == nil {
 			err = fmt.Errorf("could not send ICMP Echo Request packet to %s: no interface with outbout source ipv6 found", destinationIP)
@@ -114,18 +103,7 @@ func PingIcmpEchoRequestAsync(s *Scanner, ip string) {
 	if err != nil {
 		return
 	}
-	retries := 0
-send:
-	if retries >= maxRetries {
-		return
-	}
-	_, err = packetListener.WriteTo(data, destAddr)
-	if err != nil {
-		retries++
-		// introduce a small delay to allow the network interface to flush the queue
-		time.Sleep(time.Duration(DeadlineSec) * time.Millisecond)
-		goto send
-	}
+	_ = s.writeTo(socket, data, destAddr, time.Duration(DeadlineSec)*time.Millisecond)
 }
 
 // PingIcmpTimestampRequest synchronous to the target ip address
@@ -197,10 +175,11 @@ func PingIcmpTimestampRequestAsync(s *Scanner, ip string) {
 		return
 	}
 
-	_, err = s.icmpPacketListener4.WriteTo(data, destAddr)
+	n, err := s.raw.WriteTo(socketICMP4, data, destAddr)
 	if err != nil {
 		return
 	}
+	s.countSent(n)
 }
 
 // Timestamp ICMP structure
@@ -283,18 +262,7 @@ func PingIcmpAddressMaskRequestAsync(s *Scanner, ip string) {
 	if err != nil {
 		return
 	}
-	retries := 0
-send:
-	if retries >= maxRetries {
-		return
-	}
-	_, err = s.icmpPacketListener4.WriteTo(data, destAddr)
-	if err != nil {
-		retries++
-		// introduce a small delay to allow the network interface to flush the queue
-		time.Sleep(time.Duration(DeadlineSec) * time.Millisecond)
-		goto send
-	}
+	_ = s.writeTo(socketICMP4, data, destAddr, time.Duration(DeadlineSec)*time.Millisecond)
 }
 
 // AddressMask ICMP structure
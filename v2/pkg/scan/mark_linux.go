@@ -0,0 +1,19 @@
+//go:build linux
+
+package scan
+
+import (
+	"syscall"
+)
+
+// setSocketMark sets the SO_MARK of the socket so that probe traffic can be matched by firewall and policy routing rules
+func setSocketMark(conn syscall.RawConn, mark int) error {
+	var sockErr error
+	err := conn.Control(func(fd uintptr) {
+		sockErr = syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_MARK, mark)
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
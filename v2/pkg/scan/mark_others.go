@@ -0,0 +1,13 @@
+//go:build !linux
+
+package scan
+
+import (
+	"errors"
+	"syscall"
+)
+
+// setSocketMark is only supported on linux
+func setSocketMark(conn syscall.RawConn, mark int) error {
+	return errors.New("socket mark is only supported on linux")
+}
@@ -1,5 +1,3 @@
-//go:build linux || darwin
-
 package scan
 
 import (
@@ -13,10 +11,6 @@ import (
 	"golang.org/x/net/ipv6"
 )
 
-func init() {
-	pingNdpRequestAsyncCallback = PingNdpRequestAsync
-}
-
 // PingNdpRequestAsync asynchronous to the target ip address
 func PingNdpRequestAsync(s *Scanner, ip string) {
 	networkInterface, _, _, err := s.Router.Route(net.ParseIP(ip))
@@ -42,16 +36,5 @@ func PingNdpRequestAsync(s *Scanner, ip string) {
 	if err != nil {
 		return
 	}
-	retries := 0
-send:
-	if retries >= maxRetries {
-		return
-	}
-	_, err = s.icmpPacketListener6.WriteTo(data, destAddr)
-	if err != nil {
-		retries++
-		// introduce a small delay to allow the network interface to flush the queue
-		time.Sleep(time.Duration(DeadlineSec) * time.Millisecond)
-		goto send
-	}
+	_ = s.writeTo(socketICMP6, data, destAddr, time.Duration(DeadlineSec)*time.Millisecond)
 }
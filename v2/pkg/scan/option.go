@@ -17,4 +17,39 @@ type Options struct {
 	Proxy         string
 	ProxyAuth     string
 	Stream        bool
+	ResetClose    bool
+	// DialerCacheSize is the number of hosts whose resolved address and dialer are reused
+	DialerCacheSize int
+	// Mark is the SO_MARK set on probe sockets (linux)
+	Mark int
+	// TOS is the type of service (DSCP/ECN byte) set on probe packets
+	TOS int
+	// SSHProxy is the user@host[:port] jump host connect probes are forwarded through
+	SSHProxy string
+	// SSHKey is the private key used to authenticate to the ssh proxy
+	SSHKey string
+	// Timestamps adds the tcp timestamps option to syn probes
+	Timestamps bool
+	// Capture keeps the captured frame of the responses passed to OnResponse
+	Capture bool
+	// ConnectCriteria is the success criteria of connect probes (handshake/banner/tls)
+	ConnectCriteria string
+	// PacketTrace logs every sent probe and received response to stderr
+	PacketTrace bool
+	// SampleDrops is the number of packets ignored by the receive path logged to stderr per minute and reason
+	SampleDrops int
+	// BufferSize is the pcap receive buffer size in bytes (0 keeps the libpcap default)
+	BufferSize int
+	// ActivityLog is the gzip file every probe and connection attempt is appended to
+	ActivityLog string
+	// Tunnel encapsulates the ipv4 probes in a gre or ipip tunnel to the endpoint (gre:ip, ipip:ip)
+	Tunnel string
+	// ProbeTag is the engagement identifier embedded in the tcp options of syn and ack probes, the
+	// empty udp datagrams and the icmp echo requests
+	ProbeTag string
+	// Vantage is the label of the scanning node recorded in the network path of the found ports
+	Vantage string
+	// LookupHost resolves the hostnames dialed by connect probes, verification and banner grabbing
+	// (the system resolver is used when nil)
+	LookupHost func(host string) ([]string, error)
 }
@@ -0,0 +1,147 @@
+package scan
+
+import (
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+)
+
+// maxTrackedPaths is the number of ips whose probe path is recorded, the path of the ips exceeding
+// it being looked up from the routing table when their results arrive
+const maxTrackedPaths = 1 << 20
+
+// pathTracker records the network path of the latest probe sent to each ip. Most probes share a
+// handful of paths, which are interned
+type pathTracker struct {
+	sync.Mutex
+	paths    map[string]*port.Path
+	interned map[port.Path]*port.Path
+}
+
+// record records the path of the probe sent to the ip
+func (t *pathTracker) record(ip string, path port.Path) {
+	t.Lock()
+	defer t.Unlock()
+	if t.paths == nil {
+		t.paths = make(map[string]*port.Path)
+		t.interned = make(map[port.Path]*port.Path)
+	}
+	if current, ok := t.paths[ip]; ok && *current == path {
+		return
+	} else if !ok && len(t.paths) >= maxTrackedPaths {
+		return
+	}
+	interned, ok := t.interned[path]
+	if !ok {
+		interned = &path
+		t.interned[path] = interned
+	}
+	t.paths[ip] = interned
+}
+
+// get returns the path of the latest probe sent to the ip, nil if not recorded
+func (t *pathTracker) get(ip string) *port.Path {
+	t.Lock()
+	defer t.Unlock()
+	return t.paths[ip]
+}
+
+// ProbePath returns the network path of the latest probe sent to the ip, as recorded when it was
+// sent: the interface and gateway of the packet or connection and its source ip, only the vantage
+// being known if the probes go through a proxy
+func (s *Scanner) ProbePath(ip string) *port.Path {
+	if path := s.paths.get(ip); path != nil {
+		return path
+	}
+	path := &port.Path{Vantage: s.vantage}
+	if s.proxyDialer == nil {
+		path.SourceIP = s.sourceOf(ip)
+		s.routePath(net.ParseIP(ip), path)
+	}
+	if *path == (port.Path{}) {
+		return nil
+	}
+	return path
+}
+
+// routePath sets the interface and gateway of the route to the ip
+func (s *Scanner) routePath(ip net.IP, path *port.Path) {
+	if s.Router == nil || ip == nil {
+		return
+	}
+	if iface, gateway, _, err := s.Router.Route(ip); err == nil {
+		if iface != nil {
+			path.Interface = iface.Name
+		}
+		if gateway != nil && !gateway.IsUnspecified() {
+			path.Gateway = gateway.String()
+		}
+	}
+}
+
+// recordPacketPath records the path of a raw probe sent from the source ip, tunneled probes
+// leaving through the tunnel to its endpoint
+func (s *Scanner) recordPacketPath(ip string, destination, source net.IP) {
+	if current := s.paths.get(ip); current != nil && current.SourceIP == source.String() {
+		return
+	}
+	path := port.Path{SourceIP: source.String(), Vantage: s.vantage}
+	if s.tunnel != nil && destination.To4() != nil {
+		path.Interface = s.tunnel.kind
+		path.Gateway = s.tunnel.endpoint.IP.String()
+	} else {
+		s.routePath(destination, &path)
+	}
+	s.paths.record(ip, path)
+}
+
+// recordConnPath records the path of a connection established directly to the ip, from the local
+// address the kernel bound it to
+func (s *Scanner) recordConnPath(ip string, conn net.Conn) {
+	if conn == nil || s.proxyDialer != nil {
+		return
+	}
+	source, _, err := net.SplitHostPort(conn.LocalAddr().String())
+	if err != nil {
+		return
+	}
+	source, zone, _ := strings.Cut(source, "%")
+	path := port.Path{SourceIP: source, Vantage: s.vantage}
+	s.routePath(net.ParseIP(ip), &path)
+	if zone != "" {
+		path.Interface = zone
+	} else if iface := interfaceOf(source); iface != "" {
+		path.Interface = iface
+	}
+	s.paths.record(ip, path)
+}
+
+var (
+	localInterfacesOnce sync.Once
+	localInterfaces     map[string]string
+)
+
+// interfaceOf returns the name of the local interface owning the ip, empty if unknown
+func interfaceOf(ip string) string {
+	localInterfacesOnce.Do(func() {
+		localInterfaces = make(map[string]string)
+		ifaces, err := net.Interfaces()
+		if err != nil {
+			return
+		}
+		for _, iface := range ifaces {
+			addrs, err := iface.Addrs()
+			if err != nil {
+				continue
+			}
+			for _, addr := range addrs {
+				if ipnet, ok := addr.(*net.IPNet); ok {
+					localInterfaces[ipnet.IP.String()] = iface.Name
+				}
+			}
+		}
+	})
+	return localInterfaces[ip]
+}
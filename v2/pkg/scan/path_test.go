@@ -0,0 +1,78 @@
+package scan
+
+import (
+	"net"
+	"testing"
+
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/stretchr/testify/require"
+)
+
+// staticRouter routes every destination through the same interface and gateway
+type staticRouter struct {
+	iface   *net.Interface
+	gateway net.IP
+	source  net.IP
+}
+
+func (r *staticRouter) Route(dst net.IP) (*net.Interface, net.IP, net.IP, error) {
+	return r.iface, r.gateway, r.source, nil
+}
+
+func (r *staticRouter) RouteWithSrc(input net.HardwareAddr, src, dst net.IP) (*net.Interface, net.IP, net.IP, error) {
+	return r.Route(dst)
+}
+
+func TestProbePath(t *testing.T) {
+	s := &Scanner{Router: &staticRouter{
+		iface:   &net.Interface{Name: "eth1"},
+		gateway: net.ParseIP("10.0.0.1"),
+		source:  net.ParseIP("10.0.0.5"),
+	}}
+	require.Equal(t, &port.Path{Interface: "eth1", SourceIP: "10.0.0.5", Gateway: "10.0.0.1"}, s.ProbePath("203.0.113.10"))
+
+	s.SourceIP4 = net.ParseIP("10.0.0.6")
+	s.vantage = "eu-1"
+	require.Equal(t, &port.Path{Interface: "eth1", SourceIP: "10.0.0.6", Gateway: "10.0.0.1", Vantage: "eu-1"}, s.ProbePath("203.0.113.10"))
+
+	require.Nil(t, (&Scanner{}).ProbePath("203.0.113.10"))
+	require.Equal(t, &port.Path{Vantage: "eu-1"}, (&Scanner{vantage: "eu-1", proxyDialer: &net.Dialer{}}).ProbePath("203.0.113.10"))
+}
+
+func TestProbePathRecorded(t *testing.T) {
+	s := &Scanner{vantage: "eu-1", Router: &staticRouter{
+		iface:   &net.Interface{Name: "eth1"},
+		gateway: net.ParseIP("10.0.0.1"),
+		source:  net.ParseIP("10.0.0.5"),
+	}}
+	// the path of the probe is kept even if the route changes before the result arrives
+	s.recordPacketPath("203.0.113.10", net.ParseIP("203.0.113.10"), net.ParseIP("10.0.0.6"))
+	s.Router = &staticRouter{iface: &net.Interface{Name: "eth2"}}
+	require.Equal(t, &port.Path{Interface: "eth1", SourceIP: "10.0.0.6", Gateway: "10.0.0.1", Vantage: "eu-1"}, s.ProbePath("203.0.113.10"))
+
+	s.tunnel = &tunnel{kind: TunnelGRE, endpoint: &net.IPAddr{IP: net.ParseIP("198.51.100.1")}}
+	s.recordPacketPath("203.0.113.11", net.ParseIP("203.0.113.11"), net.ParseIP("10.0.0.6"))
+	require.Equal(t, &port.Path{Interface: TunnelGRE, SourceIP: "10.0.0.6", Gateway: "198.51.100.1", Vantage: "eu-1"}, s.ProbePath("203.0.113.11"))
+	// identical paths are shared
+	s.recordPacketPath("203.0.113.12", net.ParseIP("203.0.113.12"), net.ParseIP("10.0.0.6"))
+	require.Same(t, s.ProbePath("203.0.113.11"), s.ProbePath("203.0.113.12"))
+}
+
+func TestProbePathConnection(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer listener.Close()
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	require.Nil(t, err)
+	defer conn.Close()
+
+	s := &Scanner{}
+	s.recordConnPath("127.0.0.1", conn)
+	path := s.ProbePath("127.0.0.1")
+	require.NotNil(t, path)
+	require.Equal(t, "127.0.0.1", path.SourceIP)
+
+	proxied := &Scanner{proxyDialer: &net.Dialer{}}
+	proxied.recordConnPath("127.0.0.1", conn)
+	require.Nil(t, proxied.ProbePath("127.0.0.1"))
+}
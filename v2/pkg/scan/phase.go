@@ -0,0 +1,142 @@
+package scan
+
+import (
+	"fmt"
+	"sync"
+)
+
+// State determines the internal scan state
+type State int
+
+const (
+	Init State = iota
+	HostDiscovery
+	Scan
+	Verify
+	Done
+	// Guard pauses the handling of the received packets
+	Guard
+)
+
+// String returns the human readable name of the state
+func (state State) String() string {
+	switch state {
+	case Init:
+		return "init"
+	case HostDiscovery:
+		return "host-discovery"
+	case Scan:
+		return "scan"
+	case Verify:
+		return "verify"
+	case Done:
+		return "done"
+	case Guard:
+		return "guard"
+	default:
+		return "unknown"
+	}
+}
+
+// transitions are the states reachable from each state
+var transitions = map[State][]State{
+	Init:          {HostDiscovery, Scan, Done},
+	HostDiscovery: {Scan, Done, Guard},
+	Scan:          {Verify, Done, Guard},
+	Verify:        {Done, Guard},
+	Done:          {Init},
+	Guard:         {HostDiscovery, Scan, Verify, Done},
+}
+
+// ErrInvalidTransition is returned when moving to a state not reachable from the current one
+type ErrInvalidTransition struct {
+	From State
+	To   State
+}
+
+func (err *ErrInvalidTransition) Error() string {
+	return fmt.Sprintf("invalid scan phase transition from %s to %s", err.From, err.To)
+}
+
+// TransitionHook is called after the phase moved from a state to another
+type TransitionHook func(from, to State)
+
+// Phase is the state machine of the scan (Init -> HostDiscovery -> Guard -> Scan -> Guard -> Verify -> Done),
+// safe for concurrent use. The received packets are ignored while in Guard, between the phases
+type Phase struct {
+	sync.RWMutex
+	State
+	hooks []TransitionHook
+}
+
+// Is returns true if the phase is in the state
+func (phase *Phase) Is(state State) bool {
+	phase.RLock()
+	defer phase.RUnlock()
+
+	return phase.State == state
+}
+
+// Get returns the current state
+func (phase *Phase) Get() State {
+	phase.RLock()
+	defer phase.RUnlock()
+
+	return phase.State
+}
+
+// Set moves the phase to the state without checking the transition and calls the transition hooks,
+// Transition should be preferred
+func (phase *Phase) Set(state State) {
+	phase.Lock()
+	from := phase.State
+	phase.State = state
+	hooks := phase.hooks
+	phase.Unlock()
+
+	if from != state {
+		for _, hook := range hooks {
+			hook(from, state)
+		}
+	}
+}
+
+// Transition moves the phase to the state and calls the transition hooks,
+// moving to the current state is a no-op
+func (phase *Phase) Transition(state State) error {
+	phase.Lock()
+	from := phase.State
+	if from == state {
+		phase.Unlock()
+		return nil
+	}
+	if !canTransition(from, state) {
+		phase.Unlock()
+		return &ErrInvalidTransition{From: from, To: state}
+	}
+	phase.State = state
+	hooks := phase.hooks
+	phase.Unlock()
+
+	for _, hook := range hooks {
+		hook(from, state)
+	}
+	return nil
+}
+
+// OnTransition registers a hook called after every state change
+func (phase *Phase) OnTransition(hook TransitionHook) {
+	phase.Lock()
+	defer phase.Unlock()
+
+	phase.hooks = append(phase.hooks, hook)
+}
+
+func canTransition(from, to State) bool {
+	for _, state := range transitions[from] {
+		if state == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package scan
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPhaseTransition(t *testing.T) {
+	var phase Phase
+	require.True(t, phase.Is(Init))
+
+	var changes [][2]State
+	phase.OnTransition(func(from, to State) {
+		changes = append(changes, [2]State{from, to})
+	})
+
+	for _, state := range []State{HostDiscovery, Scan, Scan, Verify, Done, Init} {
+		require.Nil(t, phase.Transition(state), state.String())
+	}
+	require.Equal(t, [][2]State{
+		{Init, HostDiscovery}, {HostDiscovery, Scan}, {Scan, Verify}, {Verify, Done}, {Done, Init},
+	}, changes)
+
+	err := phase.Transition(Verify)
+	var transitionErr *ErrInvalidTransition
+	require.True(t, errors.As(err, &transitionErr))
+	require.Equal(t, "invalid scan phase transition from init to verify", err.Error())
+	require.True(t, phase.Is(Init))
+
+	require.Nil(t, phase.Transition(Scan))
+	require.Nil(t, phase.Transition(Guard))
+	require.Nil(t, phase.Transition(Scan))
+	require.Equal(t, Scan, phase.Get())
+}
+
+func TestPhaseSet(t *testing.T) {
+	var phase Phase
+	var changes [][2]State
+	phase.OnTransition(func(from, to State) {
+		changes = append(changes, [2]State{from, to})
+	})
+
+	// Set is kept for compatibility and doesn't check the transition
+	phase.Set(Verify)
+	phase.Set(Verify)
+	require.Equal(t, Verify, phase.State)
+	require.Equal(t, [][2]State{{Init, Verify}}, changes)
+
+	for _, state := range []State{Guard, Scan, Guard, Verify, Done} {
+		require.Nil(t, phase.Transition(state), state.String())
+	}
+}
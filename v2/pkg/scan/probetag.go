@@ -0,0 +1,31 @@
+package scan
+
+import (
+	"github.com/google/gopacket/layers"
+)
+
+const (
+	// MaxProbeTagLength is the longest probe tag fitting in the tcp options of a syn probe along
+	// with the mss and timestamps options (40 bytes)
+	MaxProbeTagLength = 24
+	// probeTagOptionKind is the experimental tcp option carrying the probe tag (RFC 4727)
+	probeTagOptionKind layers.TCPOptionKind = 253
+)
+
+// probeTagOption returns the experimental tcp option carrying the tag
+func probeTagOption(tag []byte) layers.TCPOption {
+	return layers.TCPOption{
+		OptionType:   probeTagOptionKind,
+		OptionLength: uint8(2 + len(tag)),
+		OptionData:   tag,
+	}
+}
+
+// udpProbePayload returns the protocol aware payload of the udp port, or the probe tag for the
+// ports probed with an empty datagram
+func (s *Scanner) udpProbePayload(port int) []byte {
+	if payload := UDPPayload(port); payload != nil {
+		return payload
+	}
+	return s.probeTag
+}
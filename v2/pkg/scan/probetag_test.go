@@ -0,0 +1,32 @@
+package scan
+
+import (
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbeTag(t *testing.T) {
+	tag := []byte("ENG-2023-0042-acme-pt")
+	s := &Scanner{probeTag: tag, timestamps: true, tcpsequencer: NewTCPSequencer(), probes: newProbeLog()}
+
+	// the tagged syn probe with timestamps fits in the tcp options
+	tcp := s.tcpProbe("10.0.0.1", &port.Port{Port: 443, Protocol: protocol.TCP}, Syn)
+	buf := gopacket.NewSerializeBuffer()
+	require.Nil(t, tcp.SerializeTo(buf, gopacket.SerializeOptions{FixLengths: true}))
+	decoded := gopacket.NewPacket(buf.Bytes(), layers.LayerTypeTCP, gopacket.Default).Layer(layers.LayerTypeTCP).(*layers.TCP)
+	var found []byte
+	for _, option := range decoded.Options {
+		if option.OptionType == probeTagOptionKind {
+			found = option.OptionData
+		}
+	}
+	require.Equal(t, tag, found)
+
+	require.Equal(t, tag, s.udpProbePayload(9999))
+	require.Equal(t, UDPPayload(53), s.udpProbePayload(53))
+}
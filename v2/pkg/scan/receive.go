@@ -0,0 +1,107 @@
+package scan
+
+import (
+	"bytes"
+	"net"
+
+	"github.com/google/gopacket/layers"
+	"github.com/projectdiscovery/gologger"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+)
+
+// receiveTransport handles a tcp or udp packet captured by the engine
+func (s *Scanner) receiveTransport(packet *capturedPacket) {
+	tcp, udp := &packet.tcp, &packet.udp
+	srcPort := int(tcp.SrcPort)
+	if packet.isUDP {
+		srcPort = int(udp.SrcPort)
+	}
+	var ip string
+	switch {
+	case packet.srcIP4 != "" && s.IPRanger.ContainsAny(packet.srcIP4, hostPort(packet.srcIP4, srcPort)):
+		ip = packet.srcIP4
+	case packet.srcIP6 != "" && s.IPRanger.ContainsAny(packet.srcIP6, hostPort(packet.srcIP6, srcPort)):
+		ip = packet.srcIP6
+	default:
+		responder := packet.srcIP4
+		if packet.isIPv6 {
+			responder = packet.srcIP6
+		}
+		if s.isSynAck(tcp) && s.recordMisdirected(responder, tcp) {
+			return
+		}
+		gologger.Debug().Msgf("Discarding Transport packet from non target ips: ip4=%s ip6=%s\n", packet.srcIP4, packet.srcIP6)
+		tcpPortMatches := tcp.DstPort == layers.TCPPort(s.SourcePort)
+		udpPortMatches := udp.DstPort == layers.UDPPort(s.SourcePort)
+		s.sampleTransport(tcp, udp, responder, false, packet.ttl, tcpPortMatches, udpPortMatches)
+		return
+	}
+	s.handleTransport(packet, ip)
+}
+
+// handleTransport handles a tcp or udp packet from the target ip
+func (s *Scanner) handleTransport(packet *capturedPacket, ip string) {
+	tcp, udp := &packet.tcp, &packet.udp
+	// We consider only incoming packets
+	tcpPortMatches := tcp.DstPort == layers.TCPPort(s.SourcePort)
+	udpPortMatches := udp.DstPort == layers.UDPPort(s.SourcePort)
+	sourcePortMatches := tcpPortMatches || udpPortMatches
+	switch {
+	case !sourcePortMatches:
+		gologger.Debug().Msgf("Discarding Transport packet from non target ips: ip4=%s ip6=%s tcp_dport=%d udp_dport=%d\n", packet.srcIP4, packet.srcIP6, tcp.DstPort, udp.DstPort)
+	case s.isSynAck(tcp) && s.recordMisdirected(ip, tcp):
+		// answers a probe sent to another address
+
+	case s.Phase.Is(HostDiscovery):
+		proto, probe, srcPort := protocol.TCP, ProbeTCP, int(tcp.SrcPort)
+		if udpPortMatches {
+			proto, probe, srcPort = protocol.UDP, ProbeUDP, int(udp.SrcPort)
+		}
+		s.hostDiscoveryChan <- &PkgResult{ip: ip, port: &port.Port{Port: srcPort, Protocol: proto}, probe: probe}
+	case s.isBadCookie(tcp, tcpPortMatches):
+		gologger.Debug().Msgf("Discarding syn/ack from %s:%d acknowledging no probe: ack=%d\n", ip, tcp.SrcPort, tcp.Ack)
+	case tcpPortMatches && tcp.SYN && tcp.ACK:
+		s.tcpChan <- &PkgResult{ip: ip, port: &port.Port{Port: int(tcp.SrcPort), Protocol: protocol.TCP, RTT: s.probes.rtt(ip, tcp.Ack)}}
+	case udpPortMatches && udp.Length > 0: // needs a better matching of udp payloads
+		s.udpChan <- &PkgResult{ip: ip, port: &port.Port{Port: int(udp.SrcPort), Protocol: protocol.UDP}}
+	}
+
+	s.traceTransport(tcp, udp, ip, packet.srcIP4, packet.srcIP6, packet.ttl, tcpPortMatches, udpPortMatches)
+	s.sampleTransport(tcp, udp, ip, true, packet.ttl, tcpPortMatches, udpPortMatches)
+
+	if tcpPortMatches && s.Phase.Is(Scan) && !s.isBadCookie(tcp, tcpPortMatches) {
+		if s.OnResponse != nil {
+			response := newResponse(ip, packet.ttl, packet.ipid, tcp)
+			if s.capture {
+				response.Packet = append([]byte(nil), packet.data...)
+				response.LinkType = packet.linkType
+			}
+			s.OnResponse(response)
+		} else if s.OnAnswer != nil {
+			s.OnAnswer()
+		}
+	}
+}
+
+// receiveARP handles an arp packet captured by the engine, recording the targets replying
+func (s *Scanner) receiveARP(arp *layers.ARP) {
+	// check if the packet was sent out
+	isReply := arp.Operation == layers.ARPReply
+	var sourceMacIsInterfaceMac bool
+	if s.NetworkInterface != nil {
+		sourceMacIsInterfaceMac = bytes.Equal([]byte(s.NetworkInterface.HardwareAddr), arp.SourceHwAddress)
+	}
+	isOutgoingPacket := !isReply || sourceMacIsInterfaceMac
+	if isOutgoingPacket {
+		return
+	}
+	srcIP4 := net.IP(arp.SourceProtAddress)
+	srcMac := net.HardwareAddr(arp.SourceHwAddress)
+
+	if !s.IPRanger.Contains(srcIP4.String()) {
+		gologger.Debug().Msgf("Discarding ARP packet from non target ip: ip4=%s mac=%s\n", srcIP4, srcMac)
+		return
+	}
+	s.hostDiscoveryChan <- &PkgResult{ip: srcIP4.String(), probe: ProbeARP}
+}
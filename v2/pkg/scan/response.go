@@ -0,0 +1,107 @@
+package scan
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/gopacket/layers"
+)
+
+// Response holds the header fields of a tcp probe response (open or closed port)
+type Response struct {
+	Time    time.Time
+	IP      string
+	Port    int
+	TTL     uint8
+	IPID    uint16 // zero for ipv6
+	Window  uint16
+	Flags   string
+	Options string
+	Seq     uint32
+	Ack     uint32
+	TSval   uint32 // zero without the timestamps option
+	TSecr   uint32
+	// Packet is the captured frame of the response, set only if packet capture is enabled
+	Packet   []byte
+	LinkType layers.LinkType
+}
+
+// newResponse extracts the header fields of a tcp response
+func newResponse(ip string, ttl uint8, ipid uint16, tcp *layers.TCP) *Response {
+	tsval, tsecr := tcpTimestamps(tcp)
+	return &Response{
+		Time:    time.Now(),
+		IP:      ip,
+		Port:    int(tcp.SrcPort),
+		TTL:     ttl,
+		IPID:    ipid,
+		Window:  tcp.Window,
+		Flags:   tcpFlags(tcp),
+		Options: tcpOptions(tcp),
+		Seq:     tcp.Seq,
+		Ack:     tcp.Ack,
+		TSval:   tsval,
+		TSecr:   tsecr,
+	}
+}
+
+// tcpFlags returns the set flags of the segment in scapy notation (eg. SA for SYN-ACK, RA for RST-ACK)
+func tcpFlags(tcp *layers.TCP) string {
+	var flags strings.Builder
+	for _, flag := range []struct {
+		set  bool
+		name byte
+	}{
+		{tcp.FIN, 'F'}, {tcp.SYN, 'S'}, {tcp.RST, 'R'}, {tcp.PSH, 'P'},
+		{tcp.ACK, 'A'}, {tcp.URG, 'U'}, {tcp.ECE, 'E'}, {tcp.CWR, 'C'},
+	} {
+		if flag.set {
+			flags.WriteByte(flag.name)
+		}
+	}
+	return flags.String()
+}
+
+// tcpOptions returns the options of the segment in order, eg. MSS=1460,SACKPermitted,Timestamps,NOP,WindowScale=7
+func tcpOptions(tcp *layers.TCP) string {
+	options := make([]string, 0, len(tcp.Options))
+	for _, option := range tcp.Options {
+		switch option.OptionType {
+		case layers.TCPOptionKindMSS:
+			if len(option.OptionData) == 2 {
+				options = append(options, fmt.Sprintf("MSS=%d", uint16(option.OptionData[0])<<8|uint16(option.OptionData[1])))
+				continue
+			}
+		case layers.TCPOptionKindWindowScale:
+			if len(option.OptionData) == 1 {
+				options = append(options, fmt.Sprintf("WindowScale=%d", option.OptionData[0]))
+				continue
+			}
+		}
+		options = append(options, option.OptionType.String())
+	}
+	return strings.Join(options, ",")
+}
+
+// tcpTimestamps returns the TSval and TSecr of the timestamps option of the segment, zero if missing
+func tcpTimestamps(tcp *layers.TCP) (tsval, tsecr uint32) {
+	for _, option := range tcp.Options {
+		if option.OptionType == layers.TCPOptionKindTimestamps && len(option.OptionData) == 8 {
+			return binary.BigEndian.Uint32(option.OptionData[:4]), binary.BigEndian.Uint32(option.OptionData[4:])
+		}
+	}
+	return 0, 0
+}
+
+// timestampsOption returns the timestamps option of a syn probe, asking the target to send its own clock
+func timestampsOption() layers.TCPOption {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint32(data, uint32(time.Now().UnixMilli()))
+	return layers.TCPOption{
+		OptionType:   layers.TCPOptionKindTimestamps,
+		OptionLength: 10,
+		OptionData:   data,
+	}
+}
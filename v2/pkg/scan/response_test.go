@@ -0,0 +1,34 @@
+package scan
+
+import (
+	"testing"
+
+	"github.com/google/gopacket/layers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewResponse(t *testing.T) {
+	tcp := &layers.TCP{
+		SrcPort: 443,
+		SYN:     true,
+		ACK:     true,
+		Window:  65535,
+		Options: []layers.TCPOption{
+			{OptionType: layers.TCPOptionKindMSS, OptionData: []byte{0x05, 0xb4}},
+			{OptionType: layers.TCPOptionKindSACKPermitted},
+			{OptionType: layers.TCPOptionKindNop},
+			{OptionType: layers.TCPOptionKindWindowScale, OptionData: []byte{7}},
+		},
+	}
+	response := newResponse("192.168.1.1", 64, 1234, tcp)
+	require.Equal(t, 443, response.Port)
+	require.Equal(t, "SA", response.Flags)
+	require.Equal(t, "MSS=1460,SACKPermitted,NOP,WindowScale=7", response.Options)
+
+	require.Equal(t, "RA", tcpFlags(&layers.TCP{RST: true, ACK: true}))
+
+	tcp.Options = append(tcp.Options, layers.TCPOption{OptionType: layers.TCPOptionKindTimestamps, OptionData: []byte{0, 0, 0x01, 0x00, 0, 0, 0, 0x02}})
+	response = newResponse("192.168.1.1", 64, 1234, tcp)
+	require.Equal(t, uint32(256), response.TSval)
+	require.Equal(t, uint32(2), response.TSecr)
+}
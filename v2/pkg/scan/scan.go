@@ -9,6 +9,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/gopacket"
@@ -30,9 +31,6 @@ import (
 	"golang.org/x/net/proxy"
 )
 
-// State determines the internal scan state
-type State int
-
 const (
 	maxRetries     = 10
 	sendDelayMsec  = 10
@@ -42,33 +40,6 @@ const (
 	readtimeout    = 1500  //nolint
 )
 
-const (
-	Init State = iota
-	HostDiscovery
-	Scan
-	Done
-	Guard
-)
-
-type Phase struct {
-	sync.RWMutex
-	State
-}
-
-func (phase *Phase) Is(state State) bool {
-	phase.RLock()
-	defer phase.RUnlock()
-
-	return phase.State == state
-}
-
-func (phase *Phase) Set(state State) {
-	phase.Lock()
-	defer phase.Unlock()
-
-	phase.State = state
-}
-
 // PkgFlag represent the TCP packet flag
 type PkgFlag int
 
@@ -83,23 +54,19 @@ const (
 )
 
 type Scanner struct {
-	Router              routing.Router
-	SourceIP4           net.IP
-	SourceIP6           net.IP
-	tcpPacketListener4  net.PacketConn
-	udpPacketListener4  net.PacketConn
-	tcpPacketListener6  net.PacketConn
-	udpPacketListener6  net.PacketConn
-	icmpPacketListener4 net.PacketConn
-	icmpPacketListener6 net.PacketConn
-	retries             int
-	rate                int
-	portThreshold       int
-	SourcePort          int
-	timeout             time.Duration
-	proxyDialer         proxy.Dialer
-
-	Ports    []*port.Port
+	Router        routing.Router
+	SourceIP4     net.IP
+	SourceIP6     net.IP
+	raw           rawHandle // raw sockets and capture of the platform engine, nil without privileges
+	retries       int
+	rate          int
+	portThreshold int
+	SourcePort    int
+	timeout       time.Duration
+	proxyDialer   proxy.Dialer
+	sshClient     io.Closer
+
+	Ports    *port.List
 	IPRanger *ipranger.IPRanger
 
 	transportPacketSend  chan *PkgSend
@@ -116,8 +83,53 @@ type Scanner struct {
 	tcpsequencer         *TCPSequencer
 	serializeOptions     gopacket.SerializeOptions
 	debug                bool
-	handlers             interface{} //nolint
 	stream               bool
+	resetClose           bool   // close connect scan sockets with RST
+	lastSent             int64  // unix nano timestamp of the last transport packet sent
+	bytesSent            uint64 // bytes written by the raw probes
+	bytesReceived        uint64 // bytes captured by the pcap readers
+	dialers              *hostDialers
+	lookupHost           func(host string) ([]string, error)
+	mark                 int    // SO_MARK set on probe sockets
+	tos                  int    // IP_TOS/IPV6_TCLASS set on probe packets
+	timestamps           bool   // add the tcp timestamps option to syn probes
+	capture              bool   // keep the captured frame of the responses
+	connectCriteria      string // success criteria of connect probes (handshake/banner/tls)
+	bufferSize           int    // pcap receive buffer size in bytes
+	probeTag             []byte // engagement identifier embedded in the probes
+	vantage              string // label of the scanning node recorded in the probe paths
+	tracer               *packetTracer
+	drops                *dropSampler
+	activity             *activityLog
+	paths                pathTracker // path of the latest probe sent to each ip
+	results              aggregator
+	discovery            discoveryTracker
+	probes               *probeLog
+	anomalies            anomalyLog
+	tunnel               *tunnel  // gre or ipip encapsulation of the ipv4 probes
+	zones                sync.Map // zone of the link-local ipv6 targets (fe80::1 > eth0)
+
+	// OnPortFound is called the first time an open port is recorded for an ip
+	OnPortFound func(ip string, p *port.Port)
+	// OnHostFound is called the first time an alive host is recorded during host discovery
+	OnHostFound func(ip string)
+	// OnResponse is called with the header fields of every tcp response received during the scan
+	OnResponse func(response *Response)
+	// OnAnswer is called for every tcp response received during the scan when OnResponse is nil,
+	// without copying its header fields
+	OnAnswer func()
+	// OnPortClosed is called for the udp probes answered with an icmp port unreachable during the scan
+	OnPortClosed func(ip string, p *port.Port)
+	// OnAnomaly is called the first time a syn/ack answering a probe is received from another address
+	OnAnomaly func(anomaly *Anomaly)
+}
+
+// Health is a snapshot of the raw packet engine state
+type Health struct {
+	Readers     int
+	Backlog     int
+	BacklogSize int
+	LastSent    time.Time
 }
 
 // PkgSend is a TCP package
@@ -130,23 +142,10 @@ type PkgSend struct {
 
 // PkgResult contains the results of sending TCP packages
 type PkgResult struct {
-	ip   string
-	port *port.Port
-}
-
-var (
-	newScannerCallback                      func(s *Scanner) error
-	setupHandlerCallback                    func(s *Scanner, interfaceName, bpfFilter string, protocols ...protocol.Protocol) error
-	tcpReadWorkerPCAPCallback               func(s *Scanner)
-	cleanupHandlersCallback                 func(s *Scanner)
-	pingIcmpEchoRequestCallback             func(ip string, timeout time.Duration) bool //nolint
-	pingIcmpEchoRequestAsyncCallback        func(s *Scanner, ip string)
-	pingIcmpTimestampRequestCallback        func(ip string, timeout time.Duration) bool //nolint
-	pingIcmpTimestampRequestAsyncCallback   func(s *Scanner, ip string)
-	pingIcmpAddressMaskRequestAsyncCallback func(s *Scanner, ip string)
-	arpRequestAsyncCallback                 func(s *Scanner, ip string)
-	pingNdpRequestAsyncCallback             func(s *Scanner, ip string)
-)
+	ip    string
+	port  *port.Port
+	probe string // host discovery probe answered
+}
 
 // NewScanner creates a new full port scanner that scans all ports using SYN packets.
 func NewScanner(options *Options) (*Scanner, error) {
@@ -168,26 +167,52 @@ func NewScanner(options *Options) (*Scanner, error) {
 			FixLengths:       true,
 			ComputeChecksums: true,
 		},
-		timeout:       options.Timeout,
-		retries:       options.Retries,
-		rate:          options.Rate,
-		portThreshold: options.PortThreshold,
-		debug:         options.Debug,
-		tcpsequencer:  NewTCPSequencer(),
-		IPRanger:      iprang,
+		timeout:         options.Timeout,
+		retries:         options.Retries,
+		rate:            options.Rate,
+		portThreshold:   options.PortThreshold,
+		mark:            options.Mark,
+		tos:             options.TOS,
+		timestamps:      options.Timestamps,
+		capture:         options.Capture,
+		connectCriteria: options.ConnectCriteria,
+		bufferSize:      options.BufferSize,
+		debug:           options.Debug,
+		vantage:         options.Vantage,
+		tcpsequencer:    NewTCPSequencer(),
+		probes:          newProbeLog(),
+		IPRanger:        iprang,
+	}
+	if options.ProbeTag != "" {
+		scanner.probeTag = []byte(options.ProbeTag)
+	}
+
+	if options.Tunnel != "" {
+		if scanner.tunnel, err = newTunnel(options.Tunnel); err != nil {
+			return nil, err
+		}
 	}
 
-	if privileges.IsPrivileged && newScannerCallback != nil {
-		if err := newScannerCallback(scanner); err != nil {
+	if privileges.IsPrivileged {
+		if err := scanner.openRaw(); err != nil {
 			return nil, err
 		}
 	}
+	if scanner.tunnel != nil && scanner.raw == nil {
+		return nil, fmt.Errorf("could not open %s tunnel: raw packets are not available", scanner.tunnel.kind)
+	}
 
 	scanner.HostDiscoveryResults = result.NewResult()
 	scanner.ScanResults = result.NewResult()
 	if options.ExcludeCdn || options.OutputCdn {
 		scanner.cdn = cdncheck.New()
 	}
+	if options.PacketTrace {
+		scanner.tracer = newPacketTracer(os.Stderr)
+	}
+	if options.SampleDrops > 0 {
+		scanner.drops = newDropSampler(os.Stderr, options.SampleDrops)
+	}
 
 	var auth *proxy.Auth = nil
 
@@ -202,25 +227,101 @@ func NewScanner(options *Options) (*Scanner, error) {
 	}
 
 	if options.Proxy != "" {
-		proxyDialer, err := proxy.SOCKS5("tcp", options.Proxy, auth, &net.Dialer{Timeout: options.Timeout})
+		proxyDialer, err := newProxyDialer(options.Proxy, auth, scanner.newDialer(options.Timeout))
 		if err != nil {
 			return nil, err
 		}
 		scanner.proxyDialer = proxyDialer
 	}
 
+	if options.SSHProxy != "" {
+		sshClient, err := dialSSH(options.SSHProxy, options.SSHKey, options.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		scanner.proxyDialer = sshClient
+		scanner.sshClient = sshClient
+	}
+
 	scanner.stream = options.Stream
+	scanner.lookupHost = options.LookupHost
+	scanner.resetClose = options.ResetClose
+	if options.DialerCacheSize > 0 {
+		scanner.dialers = newHostDialers(options.DialerCacheSize, func() *net.Dialer {
+			return scanner.newDialer(0)
+		}, scanner.resolve)
+	}
+	if options.ActivityLog != "" {
+		if scanner.activity, err = newActivityLog(options.ActivityLog); err != nil {
+			return nil, err
+		}
+	}
 
 	return scanner, nil
 }
 
+// openRaw opens the raw sockets of the platform engine and the channels of the raw probes
+func (s *Scanner) openRaw() error {
+	if s.SourcePort <= 0 {
+		rawport, err := getFreePort()
+		if err != nil {
+			return err
+		}
+		s.SourcePort = rawport
+	}
+	config := rawConfig{bufferSize: s.bufferSize, socketOptions: func(conn *net.IPConn, ipv6 bool) error {
+		return s.setConnOptions(conn, ipv6)
+	}}
+	if s.tunnel != nil {
+		config.tunnel = s.tunnel.protocol()
+	}
+	raw, err := platformEngine.Open(config)
+	if err != nil || raw == nil {
+		return err
+	}
+	s.raw = raw
+
+	s.tcpChan = make(chan *PkgResult, chanSize)
+	s.udpChan = make(chan *PkgResult, chanSize)
+	s.transportPacketSend = make(chan *PkgSend, packetSendSize)
+	s.hostDiscoveryChan = make(chan *PkgResult, chanSize)
+	s.icmpPacketSend = make(chan *PkgSend, packetSendSize)
+	s.ethernetPacketSend = make(chan *PkgSend, packetSendSize)
+
+	s.Router, err = routing.New()
+	return err
+}
+
+func getFreePort() (int, error) {
+	rawPort, err := freeport.GetFreeTCPPort("")
+	if err != nil {
+		return 0, err
+	}
+	return rawPort.Port, nil
+}
+
 // Close the scanner and terminate all workers
 func (s *Scanner) Close() {
+	s.CloseResults()
 	s.CleanupHandlers()
-	s.tcpPacketListener4.Close()
-	s.udpPacketListener4.Close()
-	s.tcpPacketListener6.Close()
-	s.udpPacketListener6.Close()
+	if s.raw != nil {
+		for _, socket := range []rawSocket{socketTCP4, socketUDP4, socketTCP6, socketUDP6, socketTunnel} {
+			s.raw.CloseSocket(socket)
+		}
+	}
+	if s.sshClient != nil {
+		s.sshClient.Close()
+	}
+	if err := s.CloseActivityLog(); err != nil {
+		gologger.Warning().Msgf("Could not close activity log: %s\n", err)
+	}
+	s.drops.close()
+}
+
+// CloseActivityLog writes the remaining records of the activity log and closes it, the probes
+// sent afterwards are not recorded
+func (s *Scanner) CloseActivityLog() error {
+	return s.activity.close()
 }
 
 // StartWorkers of the scanner
@@ -242,67 +343,90 @@ func (s *Scanner) StartWorkers() {
 // TCPWriteWorker that sends out TCP|UDP packets
 func (s *Scanner) TransportWriteWorker() {
 	for pkg := range s.transportPacketSend {
+		// the host may have been skipped after the probe was queued
+		if s.ScanResults.HasStopped(pkg.ip) {
+			s.tracer.skipped(pkg.ip, pkg.port, "host skipped")
+			continue
+		}
+		if s.Phase.Is(HostDiscovery) {
+			s.discovery.markSent(pkg.ip, ProbeTCP)
+		}
+		s.tracer.sent(pkg.ip, pkg.port, pkg.flag)
 		s.SendAsyncPkg(pkg.ip, pkg.port, pkg.flag)
+		atomic.StoreInt64(&s.lastSent, time.Now().UnixNano())
 	}
 }
 
+// Health returns the current state of pcap readers and the transport send queue
+func (s *Scanner) Health() Health {
+	health := Health{
+		Backlog:     len(s.transportPacketSend),
+		BacklogSize: cap(s.transportPacketSend),
+	}
+	if s.raw != nil {
+		health.Readers = s.raw.Readers()
+	}
+	if lastSent := atomic.LoadInt64(&s.lastSent); lastSent > 0 {
+		health.LastSent = time.Unix(0, lastSent)
+	}
+	return health
+}
+
 // TCPReadWorker4 reads and parse incoming TCP packets
 func (s *Scanner) TCPReadWorker4() {
-	defer s.tcpPacketListener4.Close()
+	defer s.raw.CloseSocket(socketTCP4)
 	data := make([]byte, 4096)
 	for {
 		if s.Phase.Is(Done) {
 			break
 		}
 		// nolint:errcheck // just empty the buffer
-		s.tcpPacketListener4.ReadFrom(data)
+		s.raw.ReadFrom(socketTCP4, data)
 	}
 }
 
 // TCPReadWorker4 reads and parse incoming TCP packets
 func (s *Scanner) TCPReadWorker6() {
-	defer s.tcpPacketListener6.Close()
+	defer s.raw.CloseSocket(socketTCP6)
 	data := make([]byte, 4096)
 	for {
 		if s.Phase.Is(Done) {
 			break
 		}
 		// nolint:errcheck // just empty the buffer
-		s.tcpPacketListener6.ReadFrom(data)
+		s.raw.ReadFrom(socketTCP6, data)
 	}
 }
 
 // UDPReadWorker4 reads and parse incoming ipv4 UDP packets
 func (s *Scanner) UDPReadWorker4() {
-	defer s.udpPacketListener4.Close()
+	defer s.raw.CloseSocket(socketUDP4)
 	data := make([]byte, 4096)
 	for {
 		if s.Phase.Is(Done) {
 			break
 		}
 		// nolint:errcheck // just empty the buffer
-		s.udpPacketListener4.ReadFrom(data)
+		s.raw.ReadFrom(socketUDP4, data)
 	}
 }
 
 // UDPReadWorker6 reads and parse incoming ipv6 UDP packets
 func (s *Scanner) UDPReadWorker6() {
-	defer s.udpPacketListener6.Close()
+	defer s.raw.CloseSocket(socketUDP6)
 	data := make([]byte, 4096)
 	for {
 		if s.Phase.Is(Done) {
 			break
 		}
 		// nolint:errcheck // just empty the buffer
-		s.udpPacketListener6.ReadFrom(data)
+		s.raw.ReadFrom(socketUDP6, data)
 	}
 }
 
 // TCPReadWorkerPCAP reads and parse incoming TCP packets with pcap
 func (s *Scanner) TCPReadWorkerPCAP() {
-	if tcpReadWorkerPCAPCallback != nil {
-		tcpReadWorkerPCAPCallback(s)
-	}
+	s.raw.ReadCapture(s)
 }
 
 // EnqueueICMP outgoing ICMP packets
@@ -345,15 +469,18 @@ func (s *Scanner) EnqueueUDP(ip string, ports ...*port.Port) {
 // ICMPWriteWorker writes packet to the network layer
 func (s *Scanner) ICMPWriteWorker() {
 	for pkg := range s.icmpPacketSend {
-		switch {
-		case pkg.flag == IcmpEchoRequest && pingIcmpEchoRequestAsyncCallback != nil:
-			pingIcmpEchoRequestAsyncCallback(s, pkg.ip)
-		case pkg.flag == IcmpTimestampRequest && pingIcmpTimestampRequestAsyncCallback != nil:
-			pingIcmpTimestampRequestAsyncCallback(s, pkg.ip)
-		case pkg.flag == IcmpAddressMaskRequest && pingIcmpAddressMaskRequestAsyncCallback != nil:
-			pingIcmpAddressMaskRequestAsyncCallback(s, pkg.ip)
-		case pkg.flag == Ndp && pingNdpRequestAsyncCallback != nil:
-			pingNdpRequestAsyncCallback(s, pkg.ip)
+		s.discovery.markSent(pkg.ip, probeOf(pkg.flag))
+		s.tracer.sentProbe(pkg.ip, probeOf(pkg.flag))
+		s.probeActivity(pkg.ip, probeOf(pkg.flag))
+		switch pkg.flag {
+		case IcmpEchoRequest:
+			PingIcmpEchoRequestAsync(s, pkg.ip)
+		case IcmpTimestampRequest:
+			PingIcmpTimestampRequestAsync(s, pkg.ip)
+		case IcmpAddressMaskRequest:
+			PingIcmpAddressMaskRequestAsync(s, pkg.ip)
+		case Ndp:
+			PingNdpRequestAsync(s, pkg.ip)
 		}
 	}
 }
@@ -361,9 +488,11 @@ func (s *Scanner) ICMPWriteWorker() {
 // EthernetWriteWorker writes packet to the network layer
 func (s *Scanner) EthernetWriteWorker() {
 	for pkg := range s.ethernetPacketSend {
-		switch {
-		case pkg.flag == Arp && arpRequestAsyncCallback != nil:
-			arpRequestAsyncCallback(s, pkg.ip)
+		s.discovery.markSent(pkg.ip, probeOf(pkg.flag))
+		s.tracer.sentProbe(pkg.ip, probeOf(pkg.flag))
+		s.probeActivity(pkg.ip, probeOf(pkg.flag))
+		if pkg.flag == Arp {
+			ArpRequestAsync(s, pkg.ip)
 		}
 	}
 }
@@ -376,14 +505,14 @@ func (s *Scanner) ICMPReadWorker() {
 
 // ICMPReadWorker4 reads packets from the network layer
 func (s *Scanner) ICMPReadWorker4() {
-	defer s.icmpPacketListener4.Close()
+	defer s.raw.CloseSocket(socketICMP4)
 
 	data := make([]byte, 1500)
 	for {
 		if s.Phase.Is(Done) {
 			break
 		}
-		n, addr, err := s.icmpPacketListener4.ReadFrom(data)
+		n, addr, err := s.raw.ReadFrom(socketICMP4, data)
 		if err != nil {
 			continue
 		}
@@ -398,22 +527,30 @@ func (s *Scanner) ICMPReadWorker4() {
 		}
 
 		switch rm.Type {
-		case ipv4.ICMPTypeEchoReply, ipv4.ICMPTypeTimestampReply:
-			s.hostDiscoveryChan <- &PkgResult{ip: addr.String()}
+		case ipv4.ICMPTypeEchoReply:
+			s.hostDiscoveryChan <- &PkgResult{ip: addr.String(), probe: ProbeICMPEcho}
+		case ipv4.ICMPTypeTimestampReply:
+			s.hostDiscoveryChan <- &PkgResult{ip: addr.String(), probe: ProbeICMPTimestamp}
+		case ipv4.ICMPTypeDestinationUnreachable:
+			if body, ok := rm.Body.(*icmp.DstUnreach); ok && rm.Code == icmpPortUnreachable4 {
+				if ip, p, ok := parsePortUnreachable4(body.Data, s.SourcePort); ok {
+					s.portClosed(ip, p)
+				}
+			}
 		}
 	}
 }
 
 // ICMPReadWorker6 reads packets from the network layer
 func (s *Scanner) ICMPReadWorker6() {
-	defer s.icmpPacketListener6.Close()
+	defer s.raw.CloseSocket(socketICMP6)
 
 	data := make([]byte, 1500)
 	for {
 		if s.Phase.Is(Done) {
 			break
 		}
-		n, addr, err := s.icmpPacketListener6.ReadFrom(data)
+		n, addr, err := s.raw.ReadFrom(socketICMP6, data)
 		if err != nil {
 			continue
 		}
@@ -438,17 +575,38 @@ func (s *Scanner) ICMPReadWorker6() {
 			if idx := strings.Index(ip, "%"); idx > 0 {
 				ip = ip[:idx]
 			}
-			s.hostDiscoveryChan <- &PkgResult{ip: ip}
+			s.hostDiscoveryChan <- &PkgResult{ip: ip, probe: ProbeICMPEcho}
+		case ipv6.ICMPTypeDestinationUnreachable:
+			if body, ok := rm.Body.(*icmp.DstUnreach); ok && rm.Code == icmpPortUnreachable6 {
+				if ip, p, ok := parsePortUnreachable6(body.Data, s.SourcePort); ok {
+					s.portClosed(ip, p)
+				}
+			}
 		}
 	}
 }
 
+// portClosed reports a udp probe answered with an icmp port unreachable during the scan
+func (s *Scanner) portClosed(ip string, portNumber int) {
+	p := &port.Port{Port: portNumber, Protocol: protocol.UDP}
+	s.tracer.receivedProbe(hostPort(ip, portNumber), "icmp-unreachable", TraceClosed)
+	if s.OnPortClosed != nil && (s.Phase.Is(Scan) || s.stream) {
+		s.OnPortClosed(ip, p)
+	}
+}
+
 // ICMPResultWorker handles ICMP responses (used only during probes)
 func (s *Scanner) ICMPResultWorker() {
 	for ip := range s.hostDiscoveryChan {
+		// transport replies are traced by the pcap readers
 		if s.Phase.Is(HostDiscovery) {
+			if ip.port == nil {
+				s.tracer.receivedProbe(ip.ip, ip.probe, TraceAlive)
+			}
 			gologger.Debug().Msgf("Received ICMP response from %s\n", ip.ip)
-			s.HostDiscoveryResults.AddIp(ip.ip)
+			s.addDiscoveredHost(ip)
+		} else if ip.port == nil {
+			s.tracer.receivedProbe(ip.ip, ip.probe, TraceLate)
 		}
 	}
 }
@@ -458,10 +616,11 @@ func (s *Scanner) TCPResultWorker() {
 	for ip := range s.tcpChan {
 		if s.Phase.Is(HostDiscovery) {
 			gologger.Debug().Msgf("Received Transport (TCP|UDP) probe response from %s:%d\n", ip.ip, ip.port.Port)
-			s.HostDiscoveryResults.AddIp(ip.ip)
+			s.addDiscoveredHost(ip)
 		} else if s.Phase.Is(Scan) || s.stream {
 			gologger.Debug().Msgf("Received Transport (TCP) scan response from %s:%d\n", ip.ip, ip.port.Port)
-			s.ScanResults.AddPort(ip.ip, ip.port)
+			ip.port.Path = s.ProbePath(ip.ip)
+			s.AddPort(ip.ip, ip.port)
 		}
 	}
 }
@@ -471,45 +630,39 @@ func (s *Scanner) UDPResultWorker() {
 	for ip := range s.udpChan {
 		if s.Phase.Is(HostDiscovery) {
 			gologger.Debug().Msgf("Received UDP probe response from %s:%d\n", ip.ip, ip.port.Port)
-			s.HostDiscoveryResults.AddIp(ip.ip)
+			s.addDiscoveredHost(ip)
 		} else if s.Phase.Is(Scan) || s.stream {
 			gologger.Debug().Msgf("Received Transport (UDP) scan response from %s:%d\n", ip.ip, ip.port.Port)
-			s.ScanResults.AddPort(ip.ip, ip.port)
+			ip.port.Path = s.ProbePath(ip.ip)
+			s.AddPort(ip.ip, ip.port)
 		}
 	}
 }
 
-// send sends the given layers as a single packet on the network.
-func (s *Scanner) send(destIP string, conn net.PacketConn, l ...gopacket.SerializableLayer) error {
-	buf := gopacket.NewSerializeBuffer()
-	if err := gopacket.SerializeLayers(buf, s.serializeOptions, l...); err != nil {
+// send sends the given layers as a single packet on the network through the raw socket
+func (s *Scanner) send(destIP string, socket rawSocket, l ...gopacket.SerializableLayer) error {
+	data, err := s.serialize(l...)
+	if err != nil {
 		return err
 	}
 
-	var (
-		retries int
-		err     error
-	)
+	return s.writeTo(socket, data, &net.IPAddr{IP: net.ParseIP(destIP)}, time.Duration(sendDelayMsec)*time.Millisecond)
+}
 
-send:
-	if retries >= maxRetries {
-		return err
-	}
-	_, err = conn.WriteTo(buf.Bytes(), &net.IPAddr{IP: net.ParseIP(destIP)})
-	if err != nil {
-		retries++
-		// introduce a small delay to allow the network interface to flush the queue
-		time.Sleep(time.Duration(sendDelayMsec) * time.Millisecond)
-		goto send
+// serialize returns the packet of the layers with their lengths and checksums computed
+func (s *Scanner) serialize(l ...gopacket.SerializableLayer) ([]byte, error) {
+	buf := gopacket.NewSerializeBuffer()
+	if err := gopacket.SerializeLayers(buf, s.serializeOptions, l...); err != nil {
+		return nil, err
 	}
-	return err
+	return buf.Bytes(), nil
 }
 
 // ScanSyn a target ip
 func (s *Scanner) ScanSyn(ip string) {
-	for _, port := range s.Ports {
-		s.EnqueueTCP(ip, Syn, port)
-	}
+	s.Ports.Each(func(p *port.Port) {
+		s.EnqueueTCP(ip, Syn, p)
+	})
 }
 
 // GetInterfaceFromIP gets the name of the network interface from local ip address
@@ -544,31 +697,37 @@ func GetInterfaceFromIP(ip net.IP) (*net.Interface, error) {
 	return nil, fmt.Errorf("no interface found for ip %s", address)
 }
 
+// AddHost records an alive host, notifying OnHostFound the first time it's seen
+func (s *Scanner) AddHost(ip string) {
+	s.queueResult(&resultEvent{kind: hostFound, ip: ip, host: &DiscoveredHost{IP: ip}})
+}
+
+// addDiscoveredHost records the host answering a discovery probe with the probe round trip time
+func (s *Scanner) addDiscoveredHost(result *PkgResult) {
+	host := &DiscoveredHost{IP: result.ip, Probe: result.probe, RTT: s.discovery.rtt(result.ip, result.probe)}
+	if result.port != nil {
+		host.Port = result.port.Port
+	}
+	s.queueResult(&resultEvent{kind: hostFound, ip: result.ip, host: host})
+}
+
+// AddPort records an open port for the ip, notifying OnPortFound the first time it's seen
+func (s *Scanner) AddPort(ip string, p *port.Port) {
+	s.queueResult(&resultEvent{kind: portFound, ip: ip, port: p})
+}
+
+// StorePort records or replaces the port of the ip without notifying OnPortFound
+func (s *Scanner) StorePort(ip string, p *port.Port) {
+	s.queueResult(&resultEvent{kind: portStored, ip: ip, port: p})
+}
+
 // ConnectPort a single host and port
 func (s *Scanner) ConnectPort(host string, p *port.Port, timeout time.Duration) (bool, error) {
-	hostport := net.JoinHostPort(host, fmt.Sprint(p.Port))
-	var (
-		err  error
-		conn net.Conn
-	)
-	if s.proxyDialer != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
-		defer cancel()
-		proxyDialer, ok := s.proxyDialer.(proxy.ContextDialer)
-		if !ok {
-			return false, errors.New("invalid proxy dialer")
-		}
-		conn, err = proxyDialer.DialContext(ctx, p.Protocol.String(), hostport)
-		if err != nil {
-			return false, err
-		}
-	} else {
-		conn, err = net.DialTimeout(p.Protocol.String(), hostport, timeout)
-	}
+	conn, err := s.DialPort(host, p, timeout)
 	if err != nil {
 		return false, err
 	}
-	defer conn.Close()
+	defer s.closeConn(conn)
 
 	// udp needs data probe
 	switch p.Protocol {
@@ -576,7 +735,7 @@ func (s *Scanner) ConnectPort(host string, p *port.Port, timeout time.Duration)
 		if err := conn.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
 			return false, err
 		}
-		if _, err := conn.Write(nil); err != nil {
+		if _, err := conn.Write(s.udpProbePayload(p.Port)); err != nil {
 			return false, err
 		}
 		if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
@@ -590,9 +749,43 @@ func (s *Scanner) ConnectPort(host string, p *port.Port, timeout time.Duration)
 		return n > 0, nil
 	}
 
+	if !s.meetsCriteria(conn, host, timeout) {
+		return false, nil
+	}
 	return true, err
 }
 
+// DialPort connects to the host port, through the proxy if configured
+func (s *Scanner) DialPort(host string, p *port.Port, timeout time.Duration) (net.Conn, error) {
+	conn, err := s.dialPort(host, p, timeout)
+	s.connectActivity(host, p, conn)
+	s.recordConnPath(host, conn)
+	return conn, err
+}
+
+func (s *Scanner) dialPort(host string, p *port.Port, timeout time.Duration) (net.Conn, error) {
+	host = s.zonedHost(host)
+	hostport := net.JoinHostPort(host, fmt.Sprint(p.Port))
+	if s.proxyDialer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		proxyDialer, ok := s.proxyDialer.(proxy.ContextDialer)
+		if !ok {
+			return nil, errors.New("invalid proxy dialer")
+		}
+		return proxyDialer.DialContext(ctx, p.Protocol.String(), hostport)
+	}
+	return s.dialDirect(p.Protocol.String(), host, p.Port, timeout)
+}
+
+// closeConn closes the connection, aborting it with a RST if configured to avoid the FIN handshake and TIME_WAIT
+func (s *Scanner) closeConn(conn net.Conn) {
+	if tcpConn, ok := conn.(*net.TCPConn); ok && s.resetClose {
+		_ = tcpConn.SetLinger(0)
+	}
+	conn.Close()
+}
+
 // ACKPort sends an ACK packet to a port
 func (s *Scanner) ACKPort(dstIP string, port int, timeout time.Duration) (bool, error) {
 	conn, err := net.ListenPacket("ip4:tcp", "0.0.0.0")
@@ -646,10 +839,16 @@ func (s *Scanner) ACKPort(dstIP string, port int, timeout time.Duration) (bool,
 		return false, err
 	}
 
-	err = s.send(dstIP, conn, &tcp)
+	// the answer is read from the socket of the probe, apart from the raw sockets of the engine
+	packet, err := s.serialize(&tcp)
+	if err != nil {
+		return false, err
+	}
+	n, err := conn.WriteTo(packet, &net.IPAddr{IP: ip4.DstIP})
 	if err != nil {
 		return false, err
 	}
+	s.countSent(n)
 
 	data := make([]byte, 4096)
 	for {
@@ -708,6 +907,38 @@ func (s *Scanner) SendAsyncPkg(ip string, p *port.Port, pkgFlag PkgFlag) {
 	}
 }
 
+// tcpProbe returns the tcp segment of the syn or ack probe to the port, recording the syn probes
+// to match their answers
+func (s *Scanner) tcpProbe(ip string, p *port.Port, pkgFlag PkgFlag) *layers.TCP {
+	tcpOption := layers.TCPOption{
+		OptionType:   layers.TCPOptionKindMSS,
+		OptionLength: 4,
+		OptionData:   []byte{0x05, 0xB4},
+	}
+
+	tcp := &layers.TCP{
+		SrcPort: layers.TCPPort(s.SourcePort),
+		DstPort: layers.TCPPort(p.Port),
+		Window:  1024,
+		Seq:     s.tcpsequencer.Next(),
+		Options: []layers.TCPOption{tcpOption},
+	}
+
+	if pkgFlag == Syn {
+		tcp.SYN = true
+		if s.timestamps {
+			tcp.Options = append(tcp.Options, timestampsOption())
+		}
+		s.probes.add(tcp.Seq, ip, p.Port)
+	} else if pkgFlag == Ack {
+		tcp.ACK = true
+	}
+	if s.probeTag != nil {
+		tcp.Options = append(tcp.Options, probeTagOption(s.probeTag))
+	}
+	return tcp
+}
+
 func (s *Scanner) sendAsyncTCP4(ip string, p *port.Port, pkgFlag PkgFlag) {
 	// Construct all the network layers we need.
 	ip4 := layers.IPv4{
@@ -730,25 +961,9 @@ func (s *Scanner) sendAsyncTCP4(ip string, p *port.Port, pkgFlag PkgFlag) {
 		ip4.SrcIP = sourceIP
 	}
 
-	tcpOption := layers.TCPOption{
-		OptionType:   layers.TCPOptionKindMSS,
-		OptionLength: 4,
-		OptionData:   []byte{0x05, 0xB4},
-	}
-
-	tcp := layers.TCP{
-		SrcPort: layers.TCPPort(s.SourcePort),
-		DstPort: layers.TCPPort(p.Port),
-		Window:  1024,
-		Seq:     s.tcpsequencer.Next(),
-		Options: []layers.TCPOption{tcpOption},
-	}
-
-	if pkgFlag == Syn {
-		tcp.SYN = true
-	} else if pkgFlag == Ack {
-		tcp.ACK = true
-	}
+	tcp := s.tcpProbe(ip, p, pkgFlag)
+	s.activity.probe(ip4.SrcIP, ip, p, pkgFlag)
+	s.recordPacketPath(ip, ip4.DstIP, ip4.SrcIP)
 
 	err := tcp.SetNetworkLayerForChecksum(&ip4)
 	if err != nil {
@@ -756,7 +971,7 @@ func (s *Scanner) sendAsyncTCP4(ip string, p *port.Port, pkgFlag PkgFlag) {
 			gologger.Debug().Msgf("Can not set network layer for %s:%d port: %s\n", ip, p.Port, err)
 		}
 	} else {
-		err = s.send(ip, s.tcpPacketListener4, &tcp)
+		err = s.sendIPv4(ip, &ip4, socketTCP4, tcp)
 		if err != nil {
 			if s.debug {
 				gologger.Debug().Msgf("Can not send packet to %s:%d port: %s\n", ip, p.Port, err)
@@ -791,6 +1006,8 @@ func (s *Scanner) sendAsyncUDP4(ip string, p *port.Port, pkgFlag PkgFlag) {
 		SrcPort: layers.UDPPort(s.SourcePort),
 		DstPort: layers.UDPPort(p.Port),
 	}
+	s.activity.probe(ip4.SrcIP, ip, p, pkgFlag)
+	s.recordPacketPath(ip, ip4.DstIP, ip4.SrcIP)
 
 	err := udp.SetNetworkLayerForChecksum(&ip4)
 	if err != nil {
@@ -798,7 +1015,7 @@ func (s *Scanner) sendAsyncUDP4(ip string, p *port.Port, pkgFlag PkgFlag) {
 			gologger.Debug().Msgf("Can not set network layer for %s:%d port: %s\n", ip, p.Port, err)
 		}
 	} else {
-		err = s.send(ip, s.udpPacketListener4, &udp)
+		err = s.sendIPv4(ip, &ip4, socketUDP4, &udp, gopacket.Payload(s.udpProbePayload(p.Port)))
 		if err != nil {
 			if s.debug {
 				gologger.Debug().Msgf("Can not send packet to %s:%d port: %s\n", ip, p.Port, err)
@@ -830,25 +1047,9 @@ func (s *Scanner) sendAsyncTCP6(ip string, p *port.Port, pkgFlag PkgFlag) {
 		ip6.SrcIP = sourceIP
 	}
 
-	tcpOption := layers.TCPOption{
-		OptionType:   layers.TCPOptionKindMSS,
-		OptionLength: 4,
-		OptionData:   []byte{0x05, 0xB4},
-	}
-
-	tcp := layers.TCP{
-		SrcPort: layers.TCPPort(s.SourcePort),
-		DstPort: layers.TCPPort(p.Port),
-		Window:  1024,
-		Seq:     s.tcpsequencer.Next(),
-		Options: []layers.TCPOption{tcpOption},
-	}
-
-	if pkgFlag == Syn {
-		tcp.SYN = true
-	} else if pkgFlag == Ack {
-		tcp.ACK = true
-	}
+	tcp := s.tcpProbe(ip, p, pkgFlag)
+	s.activity.probe(ip6.SrcIP, ip, p, pkgFlag)
+	s.recordPacketPath(ip, ip6.DstIP, ip6.SrcIP)
 
 	err := tcp.SetNetworkLayerForChecksum(&ip6)
 	if err != nil {
@@ -856,7 +1057,7 @@ func (s *Scanner) sendAsyncTCP6(ip string, p *port.Port, pkgFlag PkgFlag) {
 			gologger.Debug().Msgf("Can not set network layer for %s:%d port: %s\n", ip, p.Port, err)
 		}
 	} else {
-		err = s.send(ip, s.tcpPacketListener6, &tcp)
+		err = s.send(ip, socketTCP6, tcp)
 		if err != nil {
 			if s.debug {
 				gologger.Debug().Msgf("Can not send packet to %s:%d port: %s\n", ip, p.Port, err)
@@ -892,6 +1093,8 @@ func (s *Scanner) sendAsyncUDP6(ip string, p *port.Port, pkgFlag PkgFlag) {
 		SrcPort: layers.UDPPort(s.SourcePort),
 		DstPort: layers.UDPPort(p.Port),
 	}
+	s.activity.probe(ip6.SrcIP, ip, p, pkgFlag)
+	s.recordPacketPath(ip, ip6.DstIP, ip6.SrcIP)
 
 	err := udp.SetNetworkLayerForChecksum(&ip6)
 	if err != nil {
@@ -899,7 +1102,7 @@ func (s *Scanner) sendAsyncUDP6(ip string, p *port.Port, pkgFlag PkgFlag) {
 			gologger.Debug().Msgf("Can not set network layer for %s:%d port: %s\n", ip, p.Port, err)
 		}
 	} else {
-		err = s.send(ip, s.udpPacketListener6, &udp)
+		err = s.send(ip, socketUDP6, &udp, gopacket.Payload(s.udpProbePayload(p.Port)))
 		if err != nil {
 			if s.debug {
 				gologger.Debug().Msgf("Can not send packet to %s:%d port: %s\n", ip, p.Port, err)
@@ -935,30 +1138,36 @@ func (s *Scanner) SetupHandlers() error {
 
 // SetupHandler to listen on the specified interface
 func (s *Scanner) SetupHandler(interfaceName string) error {
-	bpfFilter := fmt.Sprintf("dst port %d and (tcp or udp)", s.SourcePort)
-	if setupHandlerCallback != nil {
-		err := setupHandlerCallback(s, interfaceName, bpfFilter, protocol.TCP)
-		if err != nil {
-			return err
-		}
+	bpfFilter := s.transportFilter()
+	if s.raw == nil {
+		return errors.New("raw packets are not available")
+	}
+	if err := s.raw.Capture(interfaceName, bpfFilter, protocol.TCP); err != nil {
+		return err
 	}
 	// arp filter should be improved with source mac
 	// https://stackoverflow.com/questions/40196549/bpf-expression-to-capture-only-arp-reply-packets
 	// (arp[6:2] = 2) and dst host host and ether dst mac
 	bpfFilter = "arp"
-	if setupHandlerCallback != nil {
-		err := setupHandlerCallback(s, interfaceName, bpfFilter, protocol.ARP)
-		if err != nil {
-			return err
-		}
+	if err := s.raw.Capture(interfaceName, bpfFilter, protocol.ARP); err != nil {
+		return err
 	}
 
 	return nil
 }
 
+// PcapDrops returns the packets dropped by the kernel and the interfaces before the pcap readers
+// got them, zero if unknown
+func (s *Scanner) PcapDrops() uint64 {
+	if s.raw == nil {
+		return 0
+	}
+	return s.raw.Drops()
+}
+
 // CleanupHandlers for all interfaces
 func (s *Scanner) CleanupHandlers() {
-	if cleanupHandlersCallback != nil {
-		cleanupHandlersCallback(s)
+	if s.raw != nil {
+		s.raw.CloseCapture()
 	}
 }
@@ -0,0 +1,62 @@
+package scan
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+// newProbeScanner returns a scanner able to build probes without sockets
+func newProbeScanner() *Scanner {
+	return &Scanner{
+		serializeOptions: gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true},
+		SourcePort:       40000,
+		tcpsequencer:     NewTCPSequencer(),
+		probes:           newProbeLog(),
+	}
+}
+
+func TestTCPProbe(t *testing.T) {
+	s := newProbeScanner()
+	p := &port.Port{Port: 443, Protocol: protocol.TCP}
+
+	tcp := s.tcpProbe("203.0.113.10", p, Syn)
+	require.True(t, tcp.SYN)
+	require.Equal(t, layers.TCPPort(443), tcp.DstPort)
+	probe, ok := s.probes.lookup(tcp.Seq + 1)
+	require.True(t, ok)
+	require.Equal(t, "203.0.113.10", probe.ip)
+
+	tcp = s.tcpProbe("203.0.113.10", p, Ack)
+	require.True(t, tcp.ACK)
+	require.False(t, tcp.SYN)
+}
+
+// BenchmarkSynProbe4 measures the construction and serialization of an ipv4 syn probe
+func BenchmarkSynProbe4(b *testing.B) {
+	s := newProbeScanner()
+	p := &port.Port{Port: 443, Protocol: protocol.TCP}
+	ip4 := &layers.IPv4{
+		SrcIP:    net.ParseIP("192.0.2.1"),
+		DstIP:    net.ParseIP("203.0.113.10"),
+		Version:  4,
+		TTL:      255,
+		Protocol: layers.IPProtocolTCP,
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tcp := s.tcpProbe("203.0.113.10", p, Syn)
+		if err := tcp.SetNetworkLayerForChecksum(ip4); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := s.serialize(ip4, tcp); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -3,32 +3,21 @@
 package scan
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/gopacket"
 	"github.com/google/gopacket/layers"
 	"github.com/google/gopacket/pcap"
-	"github.com/projectdiscovery/freeport"
-	"github.com/projectdiscovery/gologger"
-	"github.com/projectdiscovery/naabu/v2/pkg/port"
 	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
-	"github.com/projectdiscovery/naabu/v2/pkg/routing"
 	"golang.org/x/net/icmp"
 )
 
-func init() {
-	newScannerCallback = NewScannerUnix
-	setupHandlerCallback = SetupHandlerUnix
-	tcpReadWorkerPCAPCallback = TransportReadWorkerPCAPUnix
-	cleanupHandlersCallback = CleanupHandlersUnix
-}
-
 // Handlers contains the list of pcap handlers
 type Handlers struct {
 	TransportActive   []*pcap.Handle
@@ -38,78 +27,108 @@ type Handlers struct {
 	EthernetInactive  []*pcap.InactiveHandle
 }
 
-func getFreePort() (int, error) {
-	rawPort, err := freeport.GetFreeTCPPort("")
-	if err != nil {
-		return 0, err
-	}
-	return rawPort.Port, nil
+// pcapHandle is the raw ip sockets sending the probes of a scan and the pcap handlers capturing
+// their answers
+type pcapHandle struct {
+	config  rawConfig
+	sockets [rawSockets]net.PacketConn
+	// mu guards the handlers and their statistics against the closing of the capture
+	mu          sync.Mutex
+	handlers    Handlers
+	closed      bool
+	closedDrops uint64 // drops counted when the handlers were closed
+	readers     int32  // number of running pcap read loops
 }
 
-// NewScannerUnix creates a new instance specific for unix OS
-func NewScannerUnix(scanner *Scanner) error {
-	if scanner.SourcePort <= 0 {
-		rawport, err := getFreePort()
+// newPcapHandle opens the raw sockets of the probes, the capture being started per interface
+func newPcapHandle(config rawConfig) (*pcapHandle, error) {
+	h := &pcapHandle{config: config}
+	listeners := []struct {
+		socket  rawSocket
+		network string
+	}{
+		{socketTCP4, "ip4:tcp"},
+		{socketUDP4, "ip4:udp"},
+		{socketTCP6, "ip6:tcp"},
+		{socketUDP6, "ip6:udp"},
+	}
+	for _, listener := range listeners {
+		conn, err := net.ListenIP(listener.network, &net.IPAddr{})
 		if err != nil {
-			return err
+			h.closeSockets()
+			return nil, err
+		}
+		h.sockets[listener.socket] = conn
+		if config.socketOptions != nil {
+			if err := config.socketOptions(conn, listener.socket == socketTCP6 || listener.socket == socketUDP6); err != nil {
+				h.closeSockets()
+				return nil, err
+			}
 		}
-		scanner.SourcePort = rawport
 	}
 
-	tcpConn4, err := net.ListenIP("ip4:tcp", &net.IPAddr{IP: net.ParseIP(fmt.Sprintf("0.0.0.0:%d", scanner.SourcePort))})
-	if err != nil {
-		return err
+	var err error
+	if h.sockets[socketICMP4], err = icmp.ListenPacket("ip4:icmp", "0.0.0.0"); err != nil {
+		h.closeSockets()
+		return nil, err
 	}
-	scanner.tcpPacketListener4 = tcpConn4
-
-	udpConn4, err := net.ListenIP("ip4:udp", &net.IPAddr{IP: net.ParseIP(fmt.Sprintf("0.0.0.0:%d", scanner.SourcePort))})
-	if err != nil {
-		return err
+	if h.sockets[socketICMP6], err = icmp.ListenPacket("ip6:icmp", "::"); err != nil {
+		h.closeSockets()
+		return nil, err
 	}
-	scanner.udpPacketListener4 = udpConn4
-
-	tcpConn6, err := net.ListenIP("ip6:tcp", &net.IPAddr{IP: net.ParseIP(fmt.Sprintf(":::%d", scanner.SourcePort))})
-	if err != nil {
-		return err
+	if config.tunnel > 0 {
+		if h.sockets[socketTunnel], err = net.ListenPacket(fmt.Sprintf("ip4:%d", config.tunnel), "0.0.0.0"); err != nil {
+			h.closeSockets()
+			return nil, fmt.Errorf("could not open tunnel socket: %w", err)
+		}
 	}
-	scanner.tcpPacketListener6 = tcpConn6
+	return h, nil
+}
 
-	udpConn6, err := net.ListenIP("ip6:udp", &net.IPAddr{IP: net.ParseIP(fmt.Sprintf(":::%d", scanner.SourcePort))})
-	if err != nil {
-		return err
+func (h *pcapHandle) WriteTo(socket rawSocket, data []byte, addr net.Addr) (int, error) {
+	conn := h.sockets[socket]
+	if conn == nil {
+		return 0, net.ErrClosed
 	}
-	scanner.udpPacketListener6 = udpConn6
-
-	var handlers Handlers
-	scanner.handlers = handlers
-
-	scanner.tcpChan = make(chan *PkgResult, chanSize)
-	scanner.udpChan = make(chan *PkgResult, chanSize)
-	scanner.transportPacketSend = make(chan *PkgSend, packetSendSize)
+	return conn.WriteTo(data, addr)
+}
 
-	icmpConn4, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0")
-	if err != nil {
-		return err
+func (h *pcapHandle) ReadFrom(socket rawSocket, data []byte) (int, net.Addr, error) {
+	conn := h.sockets[socket]
+	if conn == nil {
+		return 0, nil, net.ErrClosed
 	}
-	scanner.icmpPacketListener4 = icmpConn4
+	return conn.ReadFrom(data)
+}
 
-	icmpConn6, err := icmp.ListenPacket("ip6:icmp", "::")
-	if err != nil {
-		return err
+func (h *pcapHandle) CloseSocket(socket rawSocket) {
+	if conn := h.sockets[socket]; conn != nil {
+		conn.Close()
 	}
-	scanner.icmpPacketListener6 = icmpConn6
+}
 
-	scanner.hostDiscoveryChan = make(chan *PkgResult, chanSize)
-	scanner.icmpPacketSend = make(chan *PkgSend, packetSendSize)
-	scanner.ethernetPacketSend = make(chan *PkgSend, packetSendSize)
+// closeSockets closes the raw sockets opened so far
+func (h *pcapHandle) closeSockets() {
+	for socket := range h.sockets {
+		h.CloseSocket(rawSocket(socket))
+	}
+}
 
-	scanner.Router, err = routing.New()
+func (h *pcapHandle) WriteFrame(frame []byte) error {
+	h.mu.Lock()
+	handlers := h.handlers.EthernetActive
+	h.mu.Unlock()
 
-	return err
+	var errs []error
+	for _, handler := range handlers {
+		if err := handler.WritePacketData(frame); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
 }
 
-// SetupHandlerUnix on unix OS
-func SetupHandlerUnix(s *Scanner, interfaceName, bpfFilter string, protocols ...protocol.Protocol) error {
+func (h *pcapHandle) Capture(interfaceName, bpfFilter string, protocols ...protocol.Protocol) error {
 	for _, proto := range protocols {
 		inactive, err := pcap.NewInactiveHandle(interfaceName)
 		if err != nil {
@@ -123,31 +142,34 @@ func SetupHandlerUnix(s *Scanner, interfaceName, bpfFilter string, protocols ...
 
 		readTimeout := time.Duration(readtimeout) * time.Millisecond
 		if err = inactive.SetTimeout(readTimeout); err != nil {
-			s.CleanupHandlers()
+			h.CloseCapture()
 			return err
 		}
 		err = inactive.SetImmediateMode(true)
 		if err != nil {
 			return err
 		}
-
-		handlers, ok := s.handlers.(Handlers)
-		if !ok {
-			return errors.New("couldn't create handlers")
+		if h.config.bufferSize > 0 {
+			if err = inactive.SetBufferSize(h.config.bufferSize); err != nil {
+				return err
+			}
 		}
 
+		h.mu.Lock()
 		switch proto {
 		case protocol.TCP, protocol.UDP:
-			handlers.TransportInactive = append(handlers.TransportInactive, inactive)
+			h.handlers.TransportInactive = append(h.handlers.TransportInactive, inactive)
 		case protocol.ARP:
-			handlers.EthernetInactive = append(handlers.EthernetInactive, inactive)
+			h.handlers.EthernetInactive = append(h.handlers.EthernetInactive, inactive)
 		default:
+			h.mu.Unlock()
 			panic("protocol not supported")
 		}
+		h.mu.Unlock()
 
 		handle, err := inactive.Activate()
 		if err != nil {
-			s.CleanupHandlers()
+			h.CloseCapture()
 			return err
 		}
 
@@ -161,151 +183,119 @@ func SetupHandlerUnix(s *Scanner, interfaceName, bpfFilter string, protocols ...
 		if err != nil {
 			return err
 		}
+		h.mu.Lock()
 		switch proto {
 		case protocol.TCP, protocol.UDP:
 			if iface.Flags&net.FlagLoopback == net.FlagLoopback {
-				handlers.LoopbackHandlers = append(handlers.LoopbackHandlers, handle)
+				h.handlers.LoopbackHandlers = append(h.handlers.LoopbackHandlers, handle)
 			} else {
-				handlers.TransportActive = append(handlers.TransportActive, handle)
+				h.handlers.TransportActive = append(h.handlers.TransportActive, handle)
 			}
 		case protocol.ARP:
-			handlers.EthernetActive = append(handlers.EthernetActive, handle)
-		default:
-			panic("protocol not supported")
+			h.handlers.EthernetActive = append(h.handlers.EthernetActive, handle)
 		}
-		s.handlers = handlers
+		h.mu.Unlock()
 	}
 
 	return nil
 }
 
-// TransportReadWorkerPCAPUnix for TCP and UDP
-func TransportReadWorkerPCAPUnix(s *Scanner) {
-	defer s.CleanupHandlers()
-
-	var wgread sync.WaitGroup
+// transportDecoder decodes the tcp and udp answers read on interfaces with a mac address (physical
+// and virtualized) or without (TUN/TAP), the tunneled answers decoding their inner ipv4 header over
+// the outer one (gre or ipip)
+type transportDecoder struct {
+	eth     layers.Ethernet
+	ip4     layers.IPv4
+	ip6     layers.IPv6
+	gre     layers.GRE
+	tcp     layers.TCP
+	udp     layers.UDP
+	parsers []*gopacket.DecodingLayerParser
+	decoded []gopacket.LayerType
+}
 
-	handlers, ok := s.handlers.(Handlers)
-	if !ok {
-		return
+func newTransportDecoder() *transportDecoder {
+	d := &transportDecoder{}
+	d.parsers = []*gopacket.DecodingLayerParser{
+		gopacket.NewDecodingLayerParser(layers.LayerTypeEthernet, &d.eth, &d.ip4, &d.gre, &d.tcp, &d.udp),
+		gopacket.NewDecodingLayerParser(layers.LayerTypeEthernet, &d.eth, &d.ip6, &d.tcp, &d.udp),
+		gopacket.NewDecodingLayerParser(layers.LayerTypeIPv4, &d.ip4, &d.gre, &d.tcp, &d.udp),
+		gopacket.NewDecodingLayerParser(layers.LayerTypeIPv6, &d.ip6, &d.tcp, &d.udp),
 	}
+	return d
+}
 
-	transportReaderCallback := func(tcp layers.TCP, udp layers.UDP, ip, srcIP4, srcIP6 string) {
-		// We consider only incoming packets
-		tcpPortMatches := tcp.DstPort == layers.TCPPort(s.SourcePort)
-		udpPortMatches := udp.DstPort == layers.UDPPort(s.SourcePort)
-		sourcePortMatches := tcpPortMatches || udpPortMatches
-		switch {
-		case !sourcePortMatches:
-			gologger.Debug().Msgf("Discarding Transport packet from non target ips: ip4=%s ip6=%s tcp_dport=%d udp_dport=%d\n", srcIP4, srcIP6, tcp.DstPort, udp.DstPort)
-
-		case s.Phase.Is(HostDiscovery):
-			proto := protocol.TCP
-			if udpPortMatches {
-				proto = protocol.UDP
-			}
-			s.hostDiscoveryChan <- &PkgResult{ip: ip, port: &port.Port{Port: int(tcp.SrcPort), Protocol: proto}}
-		case tcpPortMatches && tcp.SYN && tcp.ACK:
-			s.tcpChan <- &PkgResult{ip: ip, port: &port.Port{Port: int(tcp.SrcPort), Protocol: protocol.TCP}}
-		case udpPortMatches && udp.Length > 0: // needs a better matching of udp payloads
-			s.udpChan <- &PkgResult{ip: ip, port: &port.Port{Port: int(udp.SrcPort), Protocol: protocol.UDP}}
-		}
+// packet fills the captured packet with the decoded transport layer
+func (d *transportDecoder) packet(packet *capturedPacket, layerType gopacket.LayerType) {
+	*packet = capturedPacket{}
+	if layerType == layers.LayerTypeUDP {
+		packet.udp, packet.isUDP = d.udp, true
+	} else {
+		packet.tcp = d.tcp
+	}
+	if decodedIPv6(d.decoded) {
+		packet.srcIP6, packet.isIPv6, packet.ttl = d.ip6.SrcIP.String(), true, d.ip6.HopLimit
+	} else {
+		packet.srcIP4, packet.ttl, packet.ipid = d.ip4.SrcIP.String(), d.ip4.TTL, d.ip4.Id
 	}
+}
+
+func (h *pcapHandle) ReadCapture(receiver packetReceiver) {
+	defer h.CloseCapture()
+
+	h.mu.Lock()
+	handlers := h.handlers
+	h.mu.Unlock()
+
+	var wgread sync.WaitGroup
 
 	// In case of OSX, when we decode the data from 'loO' interface
 	// always get [Ethernet] layer only.
 	// with the help of data received from packetSource.Packets() we can
 	// extract the high level layers like [IPv4, IPv6, TCP, UDP]
-	loopBackScanCaseCallback := func(handler *pcap.Handle, wg *sync.WaitGroup) {
-		defer wg.Done()
-		packetSource := gopacket.NewPacketSource(handler, handler.LinkType())
-		for packet := range packetSource.Packets() {
-			tcp := &layers.TCP{}
-			udp := &layers.UDP{}
-			for _, layerType := range packet.Layers() {
-				ipLayer := packet.Layer(layers.LayerTypeIPv4)
-				if ipLayer == nil {
-					ipLayer = packet.Layer(layers.LayerTypeIPv6)
-					if ipLayer == nil {
-						continue
-					}
-				}
-				var srcIP4, srcIP6 string
-				if ipv4, ok := ipLayer.(*layers.IPv4); ok {
-					srcIP4 = ipv4.SrcIP.String()
-				} else if ipv6, ok := ipLayer.(*layers.IPv6); ok {
-					srcIP6 = ipv6.SrcIP.String()
-				}
-
-				tcpLayer := packet.Layer(layers.LayerTypeTCP)
-				if tcpLayer != nil {
-					tcp, ok = tcpLayer.(*layers.TCP)
-					if !ok {
-						continue
-					}
-				}
-				udpLayer := packet.Layer(layers.LayerTypeUDP)
-				if udpLayer != nil {
-					udp, ok = udpLayer.(*layers.UDP)
-					if !ok {
-						continue
-					}
+	for _, handler := range handlers.LoopbackHandlers {
+		wgread.Add(1)
+		atomic.AddInt32(&h.readers, 1)
+		go func(handler *pcap.Handle) {
+			defer wgread.Done()
+			defer atomic.AddInt32(&h.readers, -1)
+
+			var captured capturedPacket
+			packetSource := gopacket.NewPacketSource(handler, handler.LinkType())
+			for packet := range packetSource.Packets() {
+				receiver.countReceived(len(packet.Data()))
+				captured = capturedPacket{data: packet.Data(), linkType: handler.LinkType()}
+				switch ipLayer := packet.NetworkLayer().(type) {
+				case *layers.IPv4:
+					captured.srcIP4, captured.ttl, captured.ipid = ipLayer.SrcIP.String(), ipLayer.TTL, ipLayer.Id
+				case *layers.IPv6:
+					captured.srcIP6, captured.isIPv6, captured.ttl = ipLayer.SrcIP.String(), true, ipLayer.HopLimit
+				default:
+					continue
 				}
-
-				if layerType.LayerType() == layers.LayerTypeTCP || layerType.LayerType() == layers.LayerTypeUDP {
-					srcPort := fmt.Sprint(int(tcp.SrcPort))
-					srcIP4WithPort := net.JoinHostPort(srcIP4, srcPort)
-					isIP4InRange := s.IPRanger.ContainsAny(srcIP4, srcIP4WithPort)
-					srcIP6WithPort := net.JoinHostPort(srcIP6, srcPort)
-					isIP6InRange := s.IPRanger.ContainsAny(srcIP6, srcIP6WithPort)
-					var ip string
-					if isIP4InRange {
-						ip = srcIP4
-					} else if isIP6InRange {
-						ip = srcIP6
-					} else {
-						gologger.Debug().Msgf("Discarding Transport packet from non target ips: ip4=%s ip6=%s\n", srcIP4, srcIP6)
-					}
-					transportReaderCallback(*tcp, *udp, ip, srcIP4, srcIP6)
+				switch transport := packet.TransportLayer().(type) {
+				case *layers.TCP:
+					captured.tcp = *transport
+				case *layers.UDP:
+					captured.udp, captured.isUDP = *transport, true
+				default:
+					continue
 				}
+				receiver.receiveTransport(&captured)
 			}
-		}
-	}
-
-	// Loopback Readers
-	for _, handler := range handlers.LoopbackHandlers {
-		wgread.Add(1)
-		go loopBackScanCaseCallback(handler, &wgread)
+		}(handler)
 	}
 
 	// Transport Readers (TCP|UDP)
 	for _, handler := range handlers.TransportActive {
 		wgread.Add(1)
+		atomic.AddInt32(&h.readers, 1)
 		go func(handler *pcap.Handle) {
 			defer wgread.Done()
+			defer atomic.AddInt32(&h.readers, -1)
 
-			var (
-				eth layers.Ethernet
-				ip4 layers.IPv4
-				ip6 layers.IPv6
-				tcp layers.TCP
-				udp layers.UDP
-			)
-
-			// Interfaces with MAC (Physical + Virtualized)
-			parser4Mac := gopacket.NewDecodingLayerParser(layers.LayerTypeEthernet, &eth, &ip4, &tcp, &udp)
-			parser6Mac := gopacket.NewDecodingLayerParser(layers.LayerTypeEthernet, &eth, &ip6, &tcp, &udp)
-			// Interfaces without MAC (TUN/TAP)
-			parser4NoMac := gopacket.NewDecodingLayerParser(layers.LayerTypeIPv4, &ip4, &tcp, &udp)
-			parser6NoMac := gopacket.NewDecodingLayerParser(layers.LayerTypeIPv6, &ip6, &tcp, &udp)
-
-			var parsers []*gopacket.DecodingLayerParser
-			parsers = append(parsers,
-				parser4Mac, parser6Mac,
-				parser4NoMac, parser6NoMac,
-			)
-
-			decoded := []gopacket.LayerType{}
+			decoder := newTransportDecoder()
+			var captured capturedPacket
 
 			for {
 				data, _, err := handler.ReadPacketData()
@@ -314,31 +304,18 @@ func TransportReadWorkerPCAPUnix(s *Scanner) {
 				} else if err != nil {
 					continue
 				}
+				receiver.countReceived(len(data))
 
-				for _, parser := range parsers {
-					err := parser.DecodeLayers(data, &decoded)
+				for _, parser := range decoder.parsers {
+					err := parser.DecodeLayers(data, &decoder.decoded)
 					if err != nil {
 						continue
 					}
-					for _, layerType := range decoded {
+					for _, layerType := range decoder.decoded {
 						if layerType == layers.LayerTypeTCP || layerType == layers.LayerTypeUDP {
-							srcPort := fmt.Sprint(int(tcp.SrcPort))
-							srcIP4 := ip4.SrcIP.String()
-							srcIP4WithPort := net.JoinHostPort(srcIP4, srcPort)
-							isIP4InRange := s.IPRanger.ContainsAny(srcIP4, srcIP4WithPort)
-							srcIP6 := ip6.SrcIP.String()
-							srcIP6WithPort := net.JoinHostPort(srcIP6, srcPort)
-							isIP6InRange := s.IPRanger.ContainsAny(srcIP6, srcIP6WithPort)
-							var ip string
-							if isIP4InRange {
-								ip = srcIP4
-							} else if isIP6InRange {
-								ip = srcIP6
-							} else {
-								gologger.Debug().Msgf("Discarding Transport packet from non target ips: ip4=%s ip6=%s\n", srcIP4, srcIP6)
-								continue
-							}
-							transportReaderCallback(tcp, udp, ip, srcIP4, srcIP6)
+							decoder.packet(&captured, layerType)
+							captured.data, captured.linkType = data, handler.LinkType()
+							receiver.receiveTransport(&captured)
 						}
 					}
 				}
@@ -349,8 +326,10 @@ func TransportReadWorkerPCAPUnix(s *Scanner) {
 	// Ethernet Readers
 	for _, handler := range handlers.EthernetActive {
 		wgread.Add(1)
+		atomic.AddInt32(&h.readers, 1)
 		go func(handler *pcap.Handle) {
 			defer wgread.Done()
+			defer atomic.AddInt32(&h.readers, -1)
 
 			var (
 				eth layers.Ethernet
@@ -379,30 +358,7 @@ func TransportReadWorkerPCAPUnix(s *Scanner) {
 					}
 					for _, layerType := range decoded {
 						if layerType == layers.LayerTypeARP {
-							// check if the packet was sent out
-							isReply := arp.Operation == layers.ARPReply
-							var sourceMacIsInterfaceMac bool
-							if s.NetworkInterface != nil {
-								sourceMacIsInterfaceMac = bytes.Equal([]byte(s.NetworkInterface.HardwareAddr), arp.SourceHwAddress)
-							}
-							isOutgoingPacket := !isReply || sourceMacIsInterfaceMac
-							if isOutgoingPacket {
-								continue
-							}
-							srcIP4 := net.IP(arp.SourceProtAddress)
-							srcMac := net.HardwareAddr(arp.SourceHwAddress)
-
-							isIP4InRange := s.IPRanger.Contains(srcIP4.String())
-
-							var ip string
-							if isIP4InRange {
-								ip = srcIP4.String()
-							} else {
-								gologger.Debug().Msgf("Discarding ARP packet from non target ip: ip4=%s mac=%s\n", srcIP4, srcMac)
-								continue
-							}
-
-							s.hostDiscoveryChan <- &PkgResult{ip: ip}
+							receiver.receiveARP(&arp)
 						}
 					}
 				}
@@ -413,14 +369,48 @@ func TransportReadWorkerPCAPUnix(s *Scanner) {
 	wgread.Wait()
 }
 
-// CleanupHandlers for all interfaces
-func CleanupHandlersUnix(s *Scanner) {
-	if handlers, ok := s.handlers.(Handlers); ok {
-		for _, handler := range append(handlers.TransportActive, handlers.EthernetActive...) {
+func (h *pcapHandle) Readers() int {
+	return int(atomic.LoadInt32(&h.readers))
+}
+
+func (h *pcapHandle) CloseCapture() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.closed {
+		// the statistics are gone with the handlers, the final count is kept for the reports
+		h.closedDrops = h.drops()
+	}
+	h.closed = true
+	for _, active := range [][]*pcap.Handle{h.handlers.TransportActive, h.handlers.LoopbackHandlers, h.handlers.EthernetActive} {
+		for _, handler := range active {
 			handler.Close()
 		}
-		for _, inactiveHandler := range append(handlers.TransportInactive, handlers.EthernetInactive...) {
-			inactiveHandler.CleanUp()
+	}
+	for _, inactiveHandler := range append(h.handlers.TransportInactive, h.handlers.EthernetInactive...) {
+		inactiveHandler.CleanUp()
+	}
+}
+
+func (h *pcapHandle) Drops() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return h.closedDrops
+	}
+	return h.drops()
+}
+
+// drops sums the drops of the active pcap handlers, the lock being held
+func (h *pcapHandle) drops() uint64 {
+	var drops uint64
+	for _, active := range [][]*pcap.Handle{h.handlers.TransportActive, h.handlers.LoopbackHandlers, h.handlers.EthernetActive} {
+		for _, handler := range active {
+			if stats, err := handler.Stats(); err == nil {
+				drops += uint64(stats.PacketsDropped + stats.PacketsIfDropped)
+			}
 		}
 	}
+	return drops
 }
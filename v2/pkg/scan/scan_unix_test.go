@@ -0,0 +1,56 @@
+//go:build linux || darwin
+
+package scan
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/stretchr/testify/require"
+)
+
+// synAckFrame returns the ethernet frame of a syn/ack answering a probe sent from port 40000
+func synAckFrame(t testing.TB) []byte {
+	eth := &layers.Ethernet{
+		SrcMAC:       net.HardwareAddr{0x02, 0, 0, 0, 0, 1},
+		DstMAC:       net.HardwareAddr{0x02, 0, 0, 0, 0, 2},
+		EthernetType: layers.EthernetTypeIPv4,
+	}
+	ip4 := &layers.IPv4{
+		SrcIP:    net.ParseIP("203.0.113.10"),
+		DstIP:    net.ParseIP("192.0.2.1"),
+		Version:  4,
+		TTL:      52,
+		Protocol: layers.IPProtocolTCP,
+	}
+	tcp := &layers.TCP{SrcPort: 443, DstPort: 40000, SYN: true, ACK: true, Seq: 1, Ack: 100, Window: 65535}
+	require.Nil(t, tcp.SetNetworkLayerForChecksum(ip4))
+	buf := gopacket.NewSerializeBuffer()
+	require.Nil(t, gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}, eth, ip4, tcp))
+	return buf.Bytes()
+}
+
+func TestTransportDecoder(t *testing.T) {
+	decoder := newTransportDecoder()
+	require.Nil(t, decoder.parsers[0].DecodeLayers(synAckFrame(t), &decoder.decoded))
+	require.Contains(t, decoder.decoded, layers.LayerTypeTCP)
+	require.Equal(t, "203.0.113.10", decoder.ip4.SrcIP.String())
+	require.Equal(t, layers.TCPPort(443), decoder.tcp.SrcPort)
+	require.True(t, decoder.tcp.SYN && decoder.tcp.ACK)
+}
+
+// BenchmarkTransportDecoder measures the decoding of a syn/ack by the parsers of the transport
+// readers, tried in order as the readers do
+func BenchmarkTransportDecoder(b *testing.B) {
+	data := synAckFrame(b)
+	decoder := newTransportDecoder()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, parser := range decoder.parsers {
+			_ = parser.DecodeLayers(data, &decoder.decoded)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package scan
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const defaultSSHPort = "22"
+
+// defaultSSHKeys are the private keys tried when no key file is given
+var defaultSSHKeys = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// parseSSHTarget splits a user@host[:port] jump host into the user and address
+func parseSSHTarget(target string) (string, string, error) {
+	user, host, ok := strings.Cut(target, "@")
+	if !ok || user == "" || host == "" {
+		return "", "", fmt.Errorf("invalid ssh proxy %q, expected user@host[:port]", target)
+	}
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(strings.Trim(host, "[]"), defaultSSHPort)
+	}
+	return user, host, nil
+}
+
+// dialSSH connects to the jump host, authenticating with the ssh agent and the private key
+func dialSSH(target, keyFile string, timeout time.Duration) (*ssh.Client, error) {
+	user, addr, err := parseSSHTarget(target)
+	if err != nil {
+		return nil, err
+	}
+
+	home, _ := os.UserHomeDir()
+	hostKeyCallback, err := knownhosts.New(filepath.Join(home, ".ssh", "known_hosts"))
+	if err != nil {
+		return nil, fmt.Errorf("could not load known hosts: %w", err)
+	}
+
+	auths, err := sshAuthMethods(home, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &ssh.ClientConfig{
+		User:            user,
+		Auth:            auths,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         timeout,
+	}
+	client, err := ssh.Dial("tcp", addr, config)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to ssh proxy %s: %w", addr, err)
+	}
+	return client, nil
+}
+
+// sshAuthMethods returns the agent and public key authentication methods available
+func sshAuthMethods(home, keyFile string) ([]ssh.AuthMethod, error) {
+	var auths []ssh.AuthMethod
+	if socket := os.Getenv("SSH_AUTH_SOCK"); socket != "" {
+		if conn, err := net.Dial("unix", socket); err == nil {
+			auths = append(auths, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+
+	keyFiles := []string{keyFile}
+	if keyFile == "" {
+		keyFiles = keyFiles[:0]
+		for _, name := range defaultSSHKeys {
+			keyFiles = append(keyFiles, filepath.Join(home, ".ssh", name))
+		}
+	}
+	var signers []ssh.Signer
+	for _, file := range keyFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			if keyFile != "" {
+				return nil, fmt.Errorf("could not read ssh key: %w", err)
+			}
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey(data)
+		if err != nil {
+			if keyFile != "" {
+				return nil, fmt.Errorf("could not parse ssh key: %w", err)
+			}
+			continue
+		}
+		signers = append(signers, signer)
+	}
+	if len(signers) > 0 {
+		auths = append(auths, ssh.PublicKeys(signers...))
+	}
+
+	if len(auths) == 0 {
+		return nil, errors.New("no ssh agent or private key available")
+	}
+	return auths, nil
+}
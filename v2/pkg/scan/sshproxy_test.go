@@ -0,0 +1,32 @@
+package scan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSSHTarget(t *testing.T) {
+	tests := []struct {
+		target string
+		user   string
+		addr   string
+	}{
+		{"ops@bastion.example.com", "ops", "bastion.example.com:22"},
+		{"ops@bastion.example.com:2222", "ops", "bastion.example.com:2222"},
+		{"root@10.0.0.1", "root", "10.0.0.1:22"},
+		{"root@[2001:db8::1]:2222", "root", "[2001:db8::1]:2222"},
+		{"root@[2001:db8::1]", "root", "[2001:db8::1]:22"},
+	}
+	for _, test := range tests {
+		user, addr, err := parseSSHTarget(test.target)
+		require.Nil(t, err, test.target)
+		require.Equal(t, test.user, user)
+		require.Equal(t, test.addr, addr)
+	}
+
+	for _, target := range []string{"bastion.example.com", "@bastion", "ops@"} {
+		_, _, err := parseSSHTarget(target)
+		require.NotNil(t, err, target)
+	}
+}
@@ -12,6 +12,8 @@ import (
 // number since linearity will be guaranteed by the wrapping around to initial 0.
 type TCPSequencer struct {
 	current uint32
+	// wrapped is set once every sequence number was issued
+	wrapped uint32
 }
 
 // NewTCPSequencer creates a new linear tcp sequenc enumber generator
@@ -24,5 +26,18 @@ func NewTCPSequencer() *TCPSequencer {
 // Next returns the next number in the sequence of tcp sequence numbers
 func (t *TCPSequencer) Next() uint32 {
 	value := atomic.AddUint32(&t.current, 1)
+	if value == math.MaxUint32 {
+		atomic.StoreUint32(&t.wrapped, 1)
+	}
 	return value
 }
+
+// Issued returns true if the sequence number was generated, a segment acknowledging another one
+// not answering any probe
+func (t *TCPSequencer) Issued(seq uint32) bool {
+	if t == nil || atomic.LoadUint32(&t.wrapped) == 1 {
+		return true
+	}
+	current := atomic.LoadUint32(&t.current)
+	return current != math.MaxUint32 && seq <= current
+}
@@ -1,6 +1,7 @@
 package scan
 
 import (
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -14,3 +15,16 @@ func TestTCPSequencer(t *testing.T) {
 		assert.Equal(t, uint32(i), actual)
 	}
 }
+
+func TestTCPSequencerIssued(t *testing.T) {
+	tcpSequencer := NewTCPSequencer()
+	assert.False(t, tcpSequencer.Issued(0))
+	tcpSequencer.Next()
+	tcpSequencer.Next()
+	assert.True(t, tcpSequencer.Issued(1))
+	assert.False(t, tcpSequencer.Issued(2))
+
+	tcpSequencer.current = math.MaxUint32 - 1
+	tcpSequencer.Next()
+	assert.True(t, tcpSequencer.Issued(2))
+}
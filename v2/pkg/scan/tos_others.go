@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package scan
+
+import (
+	"errors"
+	"syscall"
+)
+
+// setSocketTOS is only supported on linux and darwin
+func setSocketTOS(conn syscall.RawConn, tos int, ipv6 bool) error {
+	return errors.New("type of service is only supported on linux and darwin")
+}
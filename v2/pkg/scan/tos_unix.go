@@ -0,0 +1,23 @@
+//go:build linux || darwin
+
+package scan
+
+import (
+	"syscall"
+)
+
+// setSocketTOS sets the IPv4 type of service or IPv6 traffic class (DSCP/ECN byte) of the socket
+func setSocketTOS(conn syscall.RawConn, tos int, ipv6 bool) error {
+	level, opt := syscall.IPPROTO_IP, syscall.IP_TOS
+	if ipv6 {
+		level, opt = syscall.IPPROTO_IPV6, syscall.IPV6_TCLASS
+	}
+	var sockErr error
+	err := conn.Control(func(fd uintptr) {
+		sockErr = syscall.SetsockoptInt(int(fd), level, opt, tos)
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
@@ -0,0 +1,170 @@
+package scan
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket/layers"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+)
+
+// maxTraceLines is the maximum number of packet trace lines written per second, the
+// exceeding ones are counted and reported once the next second starts
+const maxTraceLines = 200
+
+// Packet trace classifications of the received packets
+const (
+	TraceOpen       = "open"
+	TraceClosed     = "closed"
+	TraceAlive      = "alive"
+	TraceLate       = "late"
+	TraceUnexpected = "unexpected"
+	TraceNotProbe   = "ignored (not a probe response)"
+	TraceNotTarget  = "ignored (non target ip)"
+)
+
+// packetTracer writes a line per sent probe and received response (like nmap --packet-trace)
+// with the time elapsed since the scan start
+type packetTracer struct {
+	sync.Mutex
+	writer     io.Writer
+	start      time.Time
+	window     time.Time
+	lines      int
+	suppressed int
+}
+
+func newPacketTracer(writer io.Writer) *packetTracer {
+	now := time.Now()
+	return &packetTracer{writer: writer, start: now, window: now}
+}
+
+// trace writes the line unless the per second budget is exhausted
+func (t *packetTracer) trace(direction, format string, args ...interface{}) {
+	if t == nil {
+		return
+	}
+	t.Lock()
+	defer t.Unlock()
+
+	now := time.Now()
+	if now.Sub(t.window) >= time.Second {
+		if t.suppressed > 0 {
+			fmt.Fprintf(t.writer, "TRACE (%.4fs) %d lines suppressed\n", now.Sub(t.start).Seconds(), t.suppressed)
+		}
+		t.window, t.lines, t.suppressed = now, 0, 0
+	}
+	if t.lines >= maxTraceLines {
+		t.suppressed++
+		return
+	}
+	t.lines++
+	fmt.Fprintf(t.writer, "%s (%.4fs) %s\n", direction, now.Sub(t.start).Seconds(), fmt.Sprintf(format, args...))
+}
+
+// sent traces a transport probe
+func (t *packetTracer) sent(ip string, p *port.Port, flag PkgFlag) {
+	if p.Protocol != protocol.TCP {
+		t.trace("SENT", "%s %s", strings.ToUpper(p.Protocol.String()), hostPort(ip, p.Port))
+		return
+	}
+	t.trace("SENT", "TCP %s %s", hostPort(ip, p.Port), flagName(flag))
+}
+
+// skipped traces a queued transport probe dropped before being sent
+func (t *packetTracer) skipped(ip string, p *port.Port, reason string) {
+	t.trace("SKIP", "%s %s %s", strings.ToUpper(p.Protocol.String()), hostPort(ip, p.Port), reason)
+}
+
+// sentProbe traces a host discovery probe without port
+func (t *packetTracer) sentProbe(ip, probe string) {
+	t.trace("SENT", "%s %s", strings.ToUpper(probe), ip)
+}
+
+// receivedProbe traces a host discovery reply without port
+func (t *packetTracer) receivedProbe(ip, probe, classification string) {
+	t.trace("RCVD", "%s %s %s", strings.ToUpper(probe), ip, classification)
+}
+
+// receivedTCP traces a tcp segment captured on the probe port
+func (t *packetTracer) receivedTCP(ip string, tcp *layers.TCP, ttl uint8, classification string) {
+	t.trace("RCVD", "TCP %s > :%d %s ttl=%d win=%d %s", hostPort(ip, int(tcp.SrcPort)), tcp.DstPort, tcpFlags(tcp), ttl, tcp.Window, classification)
+}
+
+// receivedUDP traces a udp datagram captured on the probe port
+func (t *packetTracer) receivedUDP(ip string, udp *layers.UDP, ttl uint8, classification string) {
+	t.trace("RCVD", "UDP %s > :%d len=%d ttl=%d %s", hostPort(ip, int(udp.SrcPort)), udp.DstPort, udp.Length, ttl, classification)
+}
+
+// classifyTCP returns the packet trace classification of a tcp response to a probe
+func classifyTCP(tcp *layers.TCP) string {
+	switch {
+	case tcp.SYN && tcp.ACK:
+		return TraceOpen
+	case tcp.RST:
+		return TraceClosed
+	default:
+		return TraceUnexpected
+	}
+}
+
+// flagName returns the name of the probe type
+func flagName(flag PkgFlag) string {
+	switch flag {
+	case Syn:
+		return "S"
+	case Ack:
+		return "A"
+	default:
+		return "?"
+	}
+}
+
+// hostPort formats the ip and port, bracketing ipv6 addresses
+func hostPort(ip string, port int) string {
+	return net.JoinHostPort(ip, strconv.Itoa(port))
+}
+
+// traceTransport traces a tcp or udp packet captured by the pcap readers with the way it was handled
+func (s *Scanner) traceTransport(tcp *layers.TCP, udp *layers.UDP, ip, srcIP4, srcIP6 string, ttl uint8, tcpPortMatches, udpPortMatches bool) {
+	if s.tracer == nil {
+		return
+	}
+	source := ip
+	if source == "" {
+		source = srcIP4
+		if source == "" {
+			source = srcIP6
+		}
+	}
+
+	var classification string
+	switch {
+	case !tcpPortMatches && !udpPortMatches:
+		classification = TraceNotProbe
+	case ip == "":
+		classification = TraceNotTarget
+	case s.Phase.Is(HostDiscovery):
+		classification = TraceAlive
+	case !s.Phase.Is(Scan) && !s.stream:
+		classification = TraceLate
+	case tcpPortMatches:
+		classification = classifyTCP(tcp)
+	case udp.Length > 0:
+		classification = TraceOpen
+	default:
+		classification = TraceUnexpected
+	}
+
+	if tcpPortMatches || udp.DstPort == 0 {
+		s.tracer.receivedTCP(source, tcp, ttl, classification)
+	} else {
+		s.tracer.receivedUDP(source, udp, ttl, classification)
+	}
+}
@@ -0,0 +1,44 @@
+package scan
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/gopacket/layers"
+	"github.com/projectdiscovery/naabu/v2/pkg/port"
+	"github.com/projectdiscovery/naabu/v2/pkg/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPacketTrace(t *testing.T) {
+	var buffer bytes.Buffer
+	s := &Scanner{tracer: newPacketTracer(&buffer)}
+	require.Nil(t, s.Phase.Transition(Scan))
+
+	s.tracer.sent("10.0.0.1", &port.Port{Port: 443, Protocol: protocol.TCP}, Syn)
+	s.tracer.sent("::1", &port.Port{Port: 53, Protocol: protocol.UDP}, Syn)
+	s.traceTransport(&layers.TCP{SrcPort: 443, DstPort: 40000, SYN: true, ACK: true}, &layers.UDP{}, "10.0.0.1", "10.0.0.1", "", 64, true, false)
+	s.traceTransport(&layers.TCP{SrcPort: 80, DstPort: 40000, RST: true, ACK: true}, &layers.UDP{}, "", "10.0.0.9", "", 64, true, false)
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	require.Len(t, lines, 4)
+	require.Regexp(t, `^SENT \(\d+\.\d{4}s\) TCP 10\.0\.0\.1:443 S$`, lines[0])
+	require.Regexp(t, `UDP \[::1\]:53$`, lines[1])
+	require.Regexp(t, `^RCVD .* TCP 10\.0\.0\.1:443 > :40000 SA ttl=64 win=0 open$`, lines[2])
+	require.True(t, strings.HasSuffix(lines[3], TraceNotTarget))
+}
+
+func TestPacketTraceRateLimit(t *testing.T) {
+	var buffer bytes.Buffer
+	tracer := newPacketTracer(&buffer)
+	for i := 0; i < maxTraceLines+10; i++ {
+		tracer.sentProbe("10.0.0.1", ProbeICMPEcho)
+	}
+	require.Equal(t, maxTraceLines, strings.Count(buffer.String(), "\n"))
+	require.Equal(t, 10, tracer.suppressed)
+
+	// a disabled tracer is a no-op
+	var disabled *packetTracer
+	disabled.sentProbe("10.0.0.1", ProbeICMPEcho)
+}
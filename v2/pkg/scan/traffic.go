@@ -0,0 +1,39 @@
+package scan
+
+import (
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// writeTo writes the packet to the address through the raw socket, retrying a failed write after
+// the delay up to maxRetries times. Only the bytes of a successful write are counted as sent
+func (s *Scanner) writeTo(socket rawSocket, data []byte, addr net.Addr, delay time.Duration) error {
+	var err error
+	for retries := 0; retries < maxRetries; retries++ {
+		var n int
+		if n, err = s.raw.WriteTo(socket, data, addr); err == nil {
+			s.countSent(n)
+			return nil
+		}
+		// introduce a small delay to allow the network interface to flush the queue
+		time.Sleep(delay)
+	}
+	return err
+}
+
+// countSent records the bytes of a probe written to the network
+func (s *Scanner) countSent(n int) {
+	atomic.AddUint64(&s.bytesSent, uint64(n))
+}
+
+// countReceived records the bytes of a packet captured by the pcap readers
+func (s *Scanner) countReceived(n int) {
+	atomic.AddUint64(&s.bytesReceived, uint64(n))
+}
+
+// Traffic returns the bytes written by the raw probes and captured by the pcap readers, connect
+// probes going through the kernel sockets are not counted
+func (s *Scanner) Traffic() (sent, received uint64) {
+	return atomic.LoadUint64(&s.bytesSent), atomic.LoadUint64(&s.bytesReceived)
+}
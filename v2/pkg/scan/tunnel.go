@@ -0,0 +1,91 @@
+package scan
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// Tunnel encapsulations
+const (
+	TunnelGRE  = "gre"
+	TunnelIPIP = "ipip"
+)
+
+// ParseTunnel returns the encapsulation and the ipv4 remote endpoint of a tunnel given as gre:ip or ipip:ip
+func ParseTunnel(value string) (kind string, endpoint net.IP, err error) {
+	kind, address, ok := strings.Cut(value, ":")
+	kind = strings.ToLower(kind)
+	if !ok || (kind != TunnelGRE && kind != TunnelIPIP) {
+		return "", nil, fmt.Errorf("invalid tunnel %s (allowed: %s:ip, %s:ip)", value, TunnelGRE, TunnelIPIP)
+	}
+	if endpoint = net.ParseIP(address).To4(); endpoint == nil {
+		return "", nil, fmt.Errorf("invalid tunnel endpoint %s: an ipv4 address is expected", address)
+	}
+	return kind, endpoint, nil
+}
+
+// tunnel encapsulates the ipv4 probes in gre or ipip packets sent to the remote endpoint of a
+// tunnel through the tunnel socket of the engine, the outer ip header is added by the kernel
+type tunnel struct {
+	kind     string
+	endpoint *net.IPAddr
+}
+
+func newTunnel(value string) (*tunnel, error) {
+	kind, endpoint, err := ParseTunnel(value)
+	if err != nil {
+		return nil, err
+	}
+	return &tunnel{kind: kind, endpoint: &net.IPAddr{IP: endpoint}}, nil
+}
+
+// protocol returns the ip protocol of the encapsulation
+func (t *tunnel) protocol() int {
+	if t.kind == TunnelIPIP {
+		return int(layers.IPProtocolIPv4)
+	}
+	return int(layers.IPProtocolGRE)
+}
+
+// encapsulate returns the inner packet made of the ip header and the layers, preceded by the gre header
+func (t *tunnel) encapsulate(options gopacket.SerializeOptions, ip4 *layers.IPv4, l ...gopacket.SerializableLayer) ([]byte, error) {
+	packet := append([]gopacket.SerializableLayer{ip4}, l...)
+	if t.kind == TunnelGRE {
+		packet = append([]gopacket.SerializableLayer{&layers.GRE{Protocol: layers.EthernetTypeIPv4}}, packet...)
+	}
+	buf := gopacket.NewSerializeBuffer()
+	if err := gopacket.SerializeLayers(buf, options, packet...); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sendIPv4 sends the layers to the ip, encapsulated with their ip header if a tunnel is configured
+func (s *Scanner) sendIPv4(ip string, ip4 *layers.IPv4, socket rawSocket, l ...gopacket.SerializableLayer) error {
+	if s.tunnel == nil {
+		return s.send(ip, socket, l...)
+	}
+	data, err := s.tunnel.encapsulate(s.serializeOptions, ip4, l...)
+	if err != nil {
+		return err
+	}
+	n, err := s.raw.WriteTo(socketTunnel, data, s.tunnel.endpoint)
+	if err != nil {
+		return err
+	}
+	s.countSent(n)
+	return nil
+}
+
+// transportFilter returns the bpf filter of the responses, tunneled responses are encapsulated
+func (s *Scanner) transportFilter() string {
+	filter := fmt.Sprintf("dst port %d and (tcp or udp)", s.SourcePort)
+	if s.tunnel != nil {
+		filter = fmt.Sprintf("(%s) or ip proto 47 or ip proto 4", filter)
+	}
+	return filter
+}
@@ -0,0 +1,58 @@
+package scan
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTunnel(t *testing.T) {
+	kind, endpoint, err := ParseTunnel("GRE:203.0.113.1")
+	require.Nil(t, err)
+	require.Equal(t, TunnelGRE, kind)
+	require.Equal(t, "203.0.113.1", endpoint.String())
+
+	kind, _, err = ParseTunnel("ipip:203.0.113.1")
+	require.Nil(t, err)
+	require.Equal(t, TunnelIPIP, kind)
+
+	for _, value := range []string{"203.0.113.1", "vxlan:203.0.113.1", "gre:2001:db8::1", "gre:host"} {
+		_, _, err := ParseTunnel(value)
+		require.NotNil(t, err, value)
+	}
+}
+
+func TestTunnelEncapsulate(t *testing.T) {
+	options := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	newProbe := func() (*layers.IPv4, *layers.TCP) {
+		ip4 := &layers.IPv4{
+			SrcIP:    net.ParseIP("198.51.100.7"),
+			DstIP:    net.ParseIP("192.0.2.1"),
+			Version:  4,
+			TTL:      255,
+			Protocol: layers.IPProtocolTCP,
+		}
+		tcp := &layers.TCP{SrcPort: 40000, DstPort: 443, SYN: true, Seq: 1}
+		require.Nil(t, tcp.SetNetworkLayerForChecksum(ip4))
+		return ip4, tcp
+	}
+
+	for kind, first := range map[string]gopacket.LayerType{TunnelGRE: layers.LayerTypeGRE, TunnelIPIP: layers.LayerTypeIPv4} {
+		ip4, tcp := newProbe()
+		data, err := (&tunnel{kind: kind}).encapsulate(options, ip4, tcp)
+		require.Nil(t, err)
+
+		packet := gopacket.NewPacket(data, first, gopacket.Default)
+		inner, ok := packet.Layer(layers.LayerTypeIPv4).(*layers.IPv4)
+		require.True(t, ok, kind)
+		require.Equal(t, "198.51.100.7", inner.SrcIP.String())
+		require.Equal(t, "192.0.2.1", inner.DstIP.String())
+		decoded, ok := packet.Layer(layers.LayerTypeTCP).(*layers.TCP)
+		require.True(t, ok, kind)
+		require.Equal(t, layers.TCPPort(443), decoded.DstPort)
+		require.True(t, decoded.SYN)
+	}
+}
@@ -0,0 +1,68 @@
+package scan
+
+import (
+	"encoding/binary"
+	"net"
+)
+
+// udpPayloads are the protocol aware probes of common udp services, which only answer to a
+// well formed request. Other ports are probed with an empty datagram
+var udpPayloads = map[int][]byte{
+	// dns query for the root name servers
+	53: {
+		0x12, 0x34, 0x01, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x02, 0x00, 0x01,
+	},
+	// ntp v4 client request
+	123: append([]byte{0xe3}, make([]byte, 47)...),
+	// snmp v1 get-request of sysDescr.0 with the public community
+	161: {
+		0x30, 0x29, 0x02, 0x01, 0x00, 0x04, 0x06, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0xa0, 0x1c, 0x02,
+		0x04, 0x00, 0x00, 0x00, 0x01, 0x02, 0x01, 0x00, 0x02, 0x01, 0x00, 0x30, 0x0e, 0x30, 0x0c, 0x06,
+		0x08, 0x2b, 0x06, 0x01, 0x02, 0x01, 0x01, 0x01, 0x00, 0x05, 0x00,
+	},
+}
+
+// UDPPayload returns the probe payload of the udp port, nil for an empty datagram
+func UDPPayload(port int) []byte {
+	return udpPayloads[port]
+}
+
+const (
+	// udpHeaderSize is the size of the udp header quoted in icmp errors
+	udpHeaderSize = 8
+	// icmpPortUnreachable4 and icmpPortUnreachable6 are the port unreachable codes of icmp destination unreachable messages
+	icmpPortUnreachable4 = 3
+	icmpPortUnreachable6 = 4
+)
+
+// parsePortUnreachable4 returns the target and port of the udp probe quoted in an icmp port
+// unreachable message (original ipv4 header followed by the udp header)
+func parsePortUnreachable4(data []byte, sourcePort int) (string, int, bool) {
+	if len(data) < 20 || data[0]>>4 != 4 {
+		return "", 0, false
+	}
+	headerSize := int(data[0]&0x0f) * 4
+	if data[9] != 17 || len(data) < headerSize+udpHeaderSize {
+		return "", 0, false
+	}
+	return parseQuotedUDP(net.IP(data[16:20]), data[headerSize:], sourcePort)
+}
+
+// parsePortUnreachable6 returns the target and port of the udp probe quoted in an icmpv6 port
+// unreachable message (original ipv6 header followed by the udp header)
+func parsePortUnreachable6(data []byte, sourcePort int) (string, int, bool) {
+	const headerSize = 40
+	if len(data) < headerSize+udpHeaderSize || data[0]>>4 != 6 || data[6] != 17 {
+		return "", 0, false
+	}
+	return parseQuotedUDP(net.IP(data[24:40]), data[headerSize:], sourcePort)
+}
+
+// parseQuotedUDP returns the destination of the quoted udp header if it was sent from the probe port
+func parseQuotedUDP(target net.IP, udp []byte, sourcePort int) (string, int, bool) {
+	if int(binary.BigEndian.Uint16(udp[0:2])) != sourcePort {
+		return "", 0, false
+	}
+	return target.String(), int(binary.BigEndian.Uint16(udp[2:4])), true
+}
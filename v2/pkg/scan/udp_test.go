@@ -0,0 +1,44 @@
+package scan
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUDPPayload(t *testing.T) {
+	require.Nil(t, UDPPayload(9999))
+	require.Len(t, UDPPayload(123), 48)
+	// the snmp message length covers the whole payload
+	snmp := UDPPayload(161)
+	require.Equal(t, len(snmp)-2, int(snmp[1]))
+}
+
+func TestParsePortUnreachable(t *testing.T) {
+	quote := func(network gopacket.SerializableLayer, udp *layers.UDP) []byte {
+		buffer := gopacket.NewSerializeBuffer()
+		require.Nil(t, gopacket.SerializeLayers(buffer, gopacket.SerializeOptions{FixLengths: true}, network, udp))
+		return buffer.Bytes()
+	}
+
+	ip4 := &layers.IPv4{Version: 4, IHL: 5, TTL: 64, Protocol: layers.IPProtocolUDP, SrcIP: net.ParseIP("10.0.0.1"), DstIP: net.ParseIP("10.0.0.2")}
+	data := quote(ip4, &layers.UDP{SrcPort: 40000, DstPort: 53})
+	ip, p, ok := parsePortUnreachable4(data, 40000)
+	require.True(t, ok)
+	require.Equal(t, "10.0.0.2", ip)
+	require.Equal(t, 53, p)
+	// not sent from the probe port
+	_, _, ok = parsePortUnreachable4(data, 40001)
+	require.False(t, ok)
+	_, _, ok = parsePortUnreachable4(data[:20], 40000)
+	require.False(t, ok)
+
+	ip6 := &layers.IPv6{Version: 6, HopLimit: 64, NextHeader: layers.IPProtocolUDP, SrcIP: net.ParseIP("2001:db8::1"), DstIP: net.ParseIP("2001:db8::2")}
+	ip, p, ok = parsePortUnreachable6(quote(ip6, &layers.UDP{SrcPort: 40000, DstPort: 161}), 40000)
+	require.True(t, ok)
+	require.Equal(t, "2001:db8::2", ip)
+	require.Equal(t, 161, p)
+}
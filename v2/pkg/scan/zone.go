@@ -0,0 +1,23 @@
+package scan
+
+// SetZone records the zone of a link-local ipv6 target (fe80::1%eth0), the connections to the ip
+// being dialed through the interface of the zone
+func (s *Scanner) SetZone(ip, zone string) {
+	s.zones.Store(ip, zone)
+}
+
+// Zone returns the zone of the ip, empty if it has none
+func (s *Scanner) Zone(ip string) string {
+	if zone, ok := s.zones.Load(ip); ok {
+		return zone.(string)
+	}
+	return ""
+}
+
+// zonedHost returns the host with the zone of its ip, if any (fe80::1%eth0)
+func (s *Scanner) zonedHost(host string) string {
+	if zone := s.Zone(host); zone != "" {
+		return host + "%" + zone
+	}
+	return host
+}
@@ -0,0 +1,16 @@
+package scan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestZones(t *testing.T) {
+	s := &Scanner{}
+	require.Equal(t, "fe80::1", s.zonedHost("fe80::1"))
+	s.SetZone("fe80::1", "eth0")
+	require.Equal(t, "eth0", s.Zone("fe80::1"))
+	require.Equal(t, "fe80::1%eth0", s.zonedHost("fe80::1"))
+	require.Equal(t, "10.0.0.1", s.zonedHost("10.0.0.1"))
+}
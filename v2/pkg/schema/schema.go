@@ -0,0 +1,110 @@
+// Package schema versions the json output records of naabu and upgrades records written by older versions
+package schema
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Version is the current version of the json output schema, written as schema_version in every record.
+// It must be incremented, with an upgrade step added to upgrades, whenever a field is renamed, removed
+// or changes type. Adding an optional field doesn't require a new version.
+const Version = 1
+
+// Field is the name of the schema version field of the records
+const Field = "schema_version"
+
+// upgrades convert a record from the version at their index to the next version
+var upgrades = []func(record map[string]interface{}) error{
+	upgradeV0,
+}
+
+// Upgrade converts a json record of any previous schema version to the current one.
+// Records written before versioning (without schema_version) are version 0.
+func Upgrade(data []byte) ([]byte, error) {
+	record := make(map[string]interface{})
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+
+	version := 0
+	if value, ok := record[Field]; ok {
+		number, ok := value.(float64)
+		if !ok || number < 0 || number != float64(int(number)) {
+			return nil, fmt.Errorf("invalid %s %v", Field, value)
+		}
+		version = int(number)
+	}
+	if version > Version {
+		return nil, fmt.Errorf("unsupported %s %d (latest known: %d)", Field, version, Version)
+	}
+	if version == Version {
+		return data, nil
+	}
+
+	for ; version < Version; version++ {
+		if err := upgrades[version](record); err != nil {
+			return nil, err
+		}
+	}
+	record[Field] = Version
+	return json.Marshal(record)
+}
+
+// Convert upgrades json lines records to the current schema version
+func Convert(reader io.Reader, writer io.Writer) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	bufwriter := bufio.NewWriter(writer)
+	for line := 1; scanner.Scan(); line++ {
+		data := strings.TrimSpace(scanner.Text())
+		if data == "" {
+			continue
+		}
+		upgraded, err := Upgrade([]byte(data))
+		if err != nil {
+			return errors.Wrapf(err, "invalid record at line %d", line)
+		}
+		if _, err := bufwriter.Write(append(upgraded, '\n')); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return bufwriter.Flush()
+}
+
+// upgradeV0 flattens the port object of older records ({"port":{"port":80,"protocol":"tcp","tls":false}})
+// into the port, protocol and tls fields, defaulting the protocol to tcp which was the only one supported
+func upgradeV0(record map[string]interface{}) error {
+	if nested, ok := record["port"].(map[string]interface{}); ok {
+		delete(record, "port")
+		for key, value := range nested {
+			field := strings.ToLower(key)
+			switch field {
+			case "port", "protocol", "tls", "banner":
+				record[field] = value
+			}
+		}
+		// protocols were marshalled as their number before having a json representation
+		if number, ok := record["protocol"].(float64); ok {
+			protocols := []string{"tcp", "udp", "arp"}
+			if int(number) < 0 || int(number) >= len(protocols) {
+				return fmt.Errorf("unknown protocol %v", number)
+			}
+			record["protocol"] = protocols[int(number)]
+		}
+	}
+	if _, ok := record["port"]; ok {
+		if _, ok := record["protocol"]; !ok {
+			record["protocol"] = "tcp"
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package schema
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpgrade(t *testing.T) {
+	// current records are left untouched
+	current := `{"ip":"192.168.1.1","port":80,"protocol":"tcp","tls":false,"schema_version":1}`
+	data, err := Upgrade([]byte(current))
+	require.Nil(t, err)
+	require.Equal(t, current, string(data))
+
+	data, err = Upgrade([]byte(`{"host":"scanme.sh","ip":"45.33.32.156","port":22}`))
+	require.Nil(t, err)
+	require.JSONEq(t, `{"host":"scanme.sh","ip":"45.33.32.156","port":22,"protocol":"tcp","schema_version":1}`, string(data))
+
+	data, err = Upgrade([]byte(`{"ip":"45.33.32.156","port":{"Port":53,"Protocol":1,"TLS":false}}`))
+	require.Nil(t, err)
+	require.JSONEq(t, `{"ip":"45.33.32.156","port":53,"protocol":"udp","tls":false,"schema_version":1}`, string(data))
+
+	// host discovery records don't have a port
+	data, err = Upgrade([]byte(`{"ip":"45.33.32.156"}`))
+	require.Nil(t, err)
+	require.JSONEq(t, `{"ip":"45.33.32.156","schema_version":1}`, string(data))
+
+	_, err = Upgrade([]byte(`{"ip":"45.33.32.156","port":22,"schema_version":99}`))
+	require.NotNil(t, err)
+	_, err = Upgrade([]byte(`not json`))
+	require.NotNil(t, err)
+}
+
+func TestConvert(t *testing.T) {
+	input := "{\"ip\":\"45.33.32.156\",\"port\":22}\n\n{\"ip\":\"45.33.32.156\",\"port\":80,\"protocol\":\"tcp\",\"schema_version\":1}\n"
+	var output bytes.Buffer
+	require.Nil(t, Convert(strings.NewReader(input), &output))
+	require.Equal(t, "{\"ip\":\"45.33.32.156\",\"port\":22,\"protocol\":\"tcp\",\"schema_version\":1}\n{\"ip\":\"45.33.32.156\",\"port\":80,\"protocol\":\"tcp\",\"schema_version\":1}\n", output.String())
+
+	require.NotNil(t, Convert(strings.NewReader("{\"port\":22}\ngarbage\n"), &output))
+}
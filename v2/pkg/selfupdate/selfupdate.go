@@ -0,0 +1,300 @@
+// Package selfupdate replaces the running naabu binary with a release verified against the
+// checksums published with it, the checksums being signed with an ed25519 key
+package selfupdate
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/projectdiscovery/retryablehttp-go"
+)
+
+const (
+	// DefaultBaseURL is the url the release assets are downloaded from
+	DefaultBaseURL = "https://github.com/projectdiscovery/naabu/releases/download"
+	// LatestURL is the api returning the latest release
+	LatestURL = "https://api.github.com/repos/projectdiscovery/naabu/releases/latest"
+	// LatestName is the file holding the latest version on a mirror (<base-url>/latest)
+	LatestName = "latest"
+	// PublicKeyEnv is the environment variable holding the public key
+	PublicKeyEnv = "NAABU_UPDATE_PUBLIC_KEY"
+)
+
+// DefaultPublicKey is the base64 ed25519 key the release checksums are signed with, set by the
+// release build (-ldflags "-X .../selfupdate.DefaultPublicKey=...")
+var DefaultPublicKey string
+
+// TrustedKey returns the key given in the environment, falling back to the key of the release
+// build. Nil is returned when neither is available
+func TrustedKey() (ed25519.PublicKey, error) {
+	value := os.Getenv(PublicKeyEnv)
+	if value == "" {
+		value = DefaultPublicKey
+	}
+	if value == "" {
+		return nil, nil
+	}
+	return ParsePublicKey(value)
+}
+
+// Updater downloads and verifies naabu releases
+type Updater struct {
+	// BaseURL is the url of the release assets, a mirror can serve them to offline fleets
+	BaseURL string
+	// PublicKey verifies the signature of the checksums, nil skips the verification
+	PublicKey ed25519.PublicKey
+	client    *retryablehttp.Client
+}
+
+// New returns an updater of the releases published under the base url
+func New(baseURL string, publicKey ed25519.PublicKey) *Updater {
+	return &Updater{
+		BaseURL:   strings.TrimSuffix(baseURL, "/"),
+		PublicKey: publicKey,
+		client:    retryablehttp.NewClient(retryablehttp.DefaultOptionsSingle),
+	}
+}
+
+// ParsePublicKey returns the ed25519 public key given as base64, or as a file holding it
+func ParsePublicKey(value string) (ed25519.PublicKey, error) {
+	if data, err := os.ReadFile(value); err == nil {
+		value = string(data)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key: expected %d bytes ed25519 key encoded as base64", ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// NormalizeVersion returns the version without its v prefix (v2.2.0 > 2.2.0)
+func NormalizeVersion(version string) string {
+	return strings.TrimPrefix(strings.TrimSpace(version), "v")
+}
+
+// AssetName returns the archive of the version for the platform (naabu_2.2.0_linux_amd64.zip)
+func AssetName(version, goos, goarch string) string {
+	if goos == "darwin" {
+		goos = "macOS"
+	}
+	return fmt.Sprintf("naabu_%s_%s_%s.zip", NormalizeVersion(version), goos, goarch)
+}
+
+// ChecksumsName returns the checksums file of the platform releases (naabu-linux-checksums.txt)
+func ChecksumsName(goos string) string {
+	if goos == "darwin" {
+		goos = "mac"
+	}
+	return fmt.Sprintf("naabu-%s-checksums.txt", goos)
+}
+
+// Latest returns the version of the latest release, read from the mirror when the assets aren't
+// downloaded from GitHub
+func (u *Updater) Latest() (string, error) {
+	if u.BaseURL != DefaultBaseURL {
+		data, err := u.get(u.BaseURL + "/" + LatestName)
+		if err != nil {
+			return "", err
+		}
+		latest := NormalizeVersion(string(data))
+		if latest == "" {
+			return "", fmt.Errorf("no release found at %s/%s", u.BaseURL, LatestName)
+		}
+		return latest, nil
+	}
+	data, err := u.get(LatestURL)
+	if err != nil {
+		return "", err
+	}
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.Unmarshal(data, &release); err != nil {
+		return "", err
+	}
+	if release.TagName == "" {
+		return "", fmt.Errorf("no release found at %s", LatestURL)
+	}
+	return NormalizeVersion(release.TagName), nil
+}
+
+// Download returns the naabu binary of the version for the platform, after checking the archive
+// against the checksums and the checksums against their signature (.sig, ed25519 signature
+// encoded as base64)
+func (u *Updater) Download(version, goos, goarch string) ([]byte, error) {
+	version = NormalizeVersion(version)
+	releaseURL := fmt.Sprintf("%s/v%s/", u.BaseURL, version)
+
+	checksums, err := u.get(releaseURL + ChecksumsName(goos))
+	if err != nil {
+		return nil, fmt.Errorf("could not download checksums: %w", err)
+	}
+	if u.PublicKey != nil {
+		signature, err := u.get(releaseURL + ChecksumsName(goos) + ".sig")
+		if err != nil {
+			return nil, fmt.Errorf("could not download checksums signature: %w", err)
+		}
+		if err := VerifySignature(u.PublicKey, checksums, signature); err != nil {
+			return nil, err
+		}
+	}
+
+	asset := AssetName(version, goos, goarch)
+	archive, err := u.get(releaseURL + asset)
+	if err != nil {
+		return nil, fmt.Errorf("could not download %s: %w", asset, err)
+	}
+	if err := VerifyChecksum(checksums, asset, archive); err != nil {
+		return nil, err
+	}
+	return ExtractBinary(archive, goos)
+}
+
+// Update replaces the executable with the version (default latest) and returns the installed
+// version, empty when the current version is already installed
+func (u *Updater) Update(version, current string) (string, error) {
+	if version == "" {
+		latest, err := u.Latest()
+		if err != nil {
+			return "", fmt.Errorf("could not get latest version: %w", err)
+		}
+		version = latest
+	}
+	version = NormalizeVersion(version)
+	if version == NormalizeVersion(current) {
+		return "", nil
+	}
+	binary, err := u.Download(version, runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return "", err
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	if err := Replace(executable, binary); err != nil {
+		return "", fmt.Errorf("could not replace %s: %w", executable, err)
+	}
+	return version, nil
+}
+
+func (u *Updater) get(url string) ([]byte, error) {
+	response, err := u.client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d from %s", response.StatusCode, url)
+	}
+	return io.ReadAll(response.Body)
+}
+
+// VerifySignature checks the ed25519 signature of the checksums, encoded as base64 or raw
+func VerifySignature(publicKey ed25519.PublicKey, checksums, signature []byte) error {
+	if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature))); err == nil {
+		signature = decoded
+	}
+	if !ed25519.Verify(publicKey, checksums, signature) {
+		return fmt.Errorf("invalid checksums signature")
+	}
+	return nil
+}
+
+// VerifyChecksum checks the sha256 of the asset against its "checksum  name" line
+func VerifyChecksum(checksums []byte, name string, data []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || fields[1] != name {
+			continue
+		}
+		sum := sha256.Sum256(data)
+		if !strings.EqualFold(fields[0], hex.EncodeToString(sum[:])) {
+			return fmt.Errorf("checksum mismatch for %s", name)
+		}
+		return nil
+	}
+	return fmt.Errorf("no checksum found for %s", name)
+}
+
+// ExtractBinary returns the naabu binary of the release archive
+func ExtractBinary(archive []byte, goos string) ([]byte, error) {
+	name := "naabu"
+	if goos == "windows" {
+		name += ".exe"
+	}
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range reader.File {
+		if filepath.Base(file.Name) != name {
+			continue
+		}
+		f, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return io.ReadAll(f)
+	}
+	return nil, fmt.Errorf("no %s binary in the release archive", name)
+}
+
+// Replace atomically replaces the executable with the binary, keeping its permissions. Windows
+// doesn't allow to overwrite a running executable, it is first moved aside to <executable>.old
+func Replace(executable string, binary []byte) error {
+	info, err := os.Stat(executable)
+	if err != nil {
+		return err
+	}
+	temp, err := os.CreateTemp(filepath.Dir(executable), ".naabu-update-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(binary); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(temp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	if runtime.GOOS != "windows" {
+		return os.Rename(temp.Name(), executable)
+	}
+	return replaceRunning(temp.Name(), executable)
+}
+
+// replaceRunning moves the running executable aside before moving the binary in place, the
+// previous executable is restored on failure. The .old file of a previous update is removed
+// first, it is no longer in use
+func replaceRunning(binary, executable string) error {
+	old := executable + ".old"
+	_ = os.Remove(old)
+	if err := os.Rename(executable, old); err != nil {
+		return err
+	}
+	if err := os.Rename(binary, executable); err != nil {
+		_ = os.Rename(old, executable)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package selfupdate
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newRelease serves a signed release of the version for linux/amd64
+func newRelease(t *testing.T, version string, binary []byte, privateKey ed25519.PrivateKey) *httptest.Server {
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	f, err := writer.Create("naabu")
+	require.Nil(t, err)
+	_, err = f.Write(binary)
+	require.Nil(t, err)
+	require.Nil(t, writer.Close())
+
+	asset := AssetName(version, "linux", "amd64")
+	sum := sha256.Sum256(archive.Bytes())
+	checksums := []byte(fmt.Sprintf("%s  %s\n", hex.EncodeToString(sum[:]), asset))
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, checksums))
+
+	assets := map[string][]byte{
+		"/v" + version + "/" + asset:                           archive.Bytes(),
+		"/v" + version + "/" + ChecksumsName("linux"):          checksums,
+		"/v" + version + "/" + ChecksumsName("linux") + ".sig": []byte(signature),
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := assets[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+}
+
+func TestDownload(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	require.Nil(t, err)
+	server := newRelease(t, "2.3.0", []byte("new binary"), privateKey)
+	defer server.Close()
+
+	binary, err := New(server.URL, publicKey).Download("v2.3.0", "linux", "amd64")
+	require.Nil(t, err)
+	require.Equal(t, []byte("new binary"), binary)
+
+	otherKey, _, err := ed25519.GenerateKey(nil)
+	require.Nil(t, err)
+	_, err = New(server.URL, otherKey).Download("2.3.0", "linux", "amd64")
+	require.ErrorContains(t, err, "invalid checksums signature")
+
+	_, err = New(server.URL, publicKey).Download("2.4.0", "linux", "amd64")
+	require.NotNil(t, err)
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	sum := sha256.Sum256([]byte("data"))
+	checksums := []byte(hex.EncodeToString(sum[:]) + "  naabu_2.3.0_linux_amd64.zip\n")
+	require.Nil(t, VerifyChecksum(checksums, "naabu_2.3.0_linux_amd64.zip", []byte("data")))
+	require.ErrorContains(t, VerifyChecksum(checksums, "naabu_2.3.0_linux_amd64.zip", []byte("tampered")), "mismatch")
+	require.ErrorContains(t, VerifyChecksum(checksums, "naabu_2.3.0_macOS_arm64.zip", []byte("data")), "no checksum")
+}
+
+func TestNames(t *testing.T) {
+	require.Equal(t, "naabu_2.3.0_macOS_arm64.zip", AssetName("v2.3.0", "darwin", "arm64"))
+	require.Equal(t, "naabu-mac-checksums.txt", ChecksumsName("darwin"))
+	require.Equal(t, "naabu-windows-checksums.txt", ChecksumsName("windows"))
+}
+
+func TestParsePublicKey(t *testing.T) {
+	publicKey, _, err := ed25519.GenerateKey(nil)
+	require.Nil(t, err)
+	encoded := base64.StdEncoding.EncodeToString(publicKey)
+
+	parsed, err := ParsePublicKey(encoded)
+	require.Nil(t, err)
+	require.Equal(t, publicKey, parsed)
+
+	path := filepath.Join(t.TempDir(), "naabu.pub")
+	require.Nil(t, os.WriteFile(path, []byte(encoded+"\n"), 0600))
+	parsed, err = ParsePublicKey(path)
+	require.Nil(t, err)
+	require.Equal(t, publicKey, parsed)
+
+	_, err = ParsePublicKey("c2hvcnQ=")
+	require.NotNil(t, err)
+}
+
+func TestReplace(t *testing.T) {
+	executable := filepath.Join(t.TempDir(), "naabu")
+	require.Nil(t, os.WriteFile(executable, []byte("old binary"), 0755))
+	require.Nil(t, Replace(executable, []byte("new binary")))
+
+	data, err := os.ReadFile(executable)
+	require.Nil(t, err)
+	require.Equal(t, []byte("new binary"), data)
+	info, err := os.Stat(executable)
+	require.Nil(t, err)
+	require.Equal(t, os.FileMode(0755), info.Mode().Perm())
+}
+
+func TestLatestMirror(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/"+LatestName, r.URL.Path)
+		_, _ = w.Write([]byte("v2.3.0\n"))
+	}))
+	defer server.Close()
+
+	latest, err := New(server.URL+"/", nil).Latest()
+	require.Nil(t, err)
+	require.Equal(t, "2.3.0", latest)
+}
+
+func TestTrustedKey(t *testing.T) {
+	publicKey, _, err := ed25519.GenerateKey(nil)
+	require.Nil(t, err)
+	t.Setenv(PublicKeyEnv, "")
+	defaultKey := DefaultPublicKey
+	defer func() { DefaultPublicKey = defaultKey }()
+
+	DefaultPublicKey = ""
+	key, err := TrustedKey()
+	require.Nil(t, err)
+	require.Nil(t, key)
+
+	DefaultPublicKey = base64.StdEncoding.EncodeToString(publicKey)
+	key, err = TrustedKey()
+	require.Nil(t, err)
+	require.Equal(t, publicKey, key)
+
+	t.Setenv(PublicKeyEnv, "c2hvcnQ=")
+	_, err = TrustedKey()
+	require.NotNil(t, err)
+}
+
+func TestReplaceRunning(t *testing.T) {
+	dir := t.TempDir()
+	executable := filepath.Join(dir, "naabu.exe")
+	binary := filepath.Join(dir, "update")
+	require.Nil(t, os.WriteFile(executable, []byte("old binary"), 0755))
+	require.Nil(t, os.WriteFile(executable+".old", []byte("previous binary"), 0755))
+	require.Nil(t, os.WriteFile(binary, []byte("new binary"), 0755))
+	require.Nil(t, replaceRunning(binary, executable))
+
+	data, err := os.ReadFile(executable)
+	require.Nil(t, err)
+	require.Equal(t, []byte("new binary"), data)
+	data, err = os.ReadFile(executable + ".old")
+	require.Nil(t, err)
+	require.Equal(t, []byte("old binary"), data)
+
+	require.NotNil(t, replaceRunning(filepath.Join(dir, "missing"), executable))
+	data, err = os.ReadFile(executable)
+	require.Nil(t, err)
+	require.Equal(t, []byte("new binary"), data)
+}